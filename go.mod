@@ -39,6 +39,7 @@ require (
 	git.happydns.org/checker-zonemaster v0.1.0
 	github.com/DNSControl/dnscontrol/v4 v4.46.0
 	github.com/JGLTechnologies/gin-rate-limit v1.5.9
+	github.com/ProtonMail/go-crypto v1.4.1
 	github.com/altcha-org/altcha-lib-go v1.0.0
 	github.com/coreos/go-oidc/v3 v3.20.0
 	github.com/earthboundkid/versioninfo/v2 v2.24.1
@@ -73,7 +74,6 @@ require (
 
 require (
 	github.com/Azure/go-ntlmssp v0.0.0-20221128193559-754e69321358 // indirect
-	github.com/ProtonMail/go-crypto v1.4.1 // indirect
 	github.com/adamantal/go-dreamhost v0.1.1 // indirect
 	github.com/alecthomas/kingpin/v2 v2.4.0 // indirect
 	github.com/alecthomas/units v0.0.0-20240927000941-0f3dac36c52b // indirect
@@ -0,0 +1,232 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package adapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// cloudflareBaseURL is the Cloudflare API used when the configuration does
+// not name another one.
+const cloudflareBaseURL = "https://api.cloudflare.com/client/v4"
+
+// cloudflareComments reads and writes the comment Cloudflare stores along
+// each record, through its API
+// (https://developers.cloudflare.com/api/resources/dns/subresources/records/).
+type cloudflareComments struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func newCloudflareComments(config map[string]string) (commentStore, error) {
+	baseURL := config["baseurl"]
+	if baseURL == "" {
+		baseURL = cloudflareBaseURL
+	}
+
+	return &cloudflareComments{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   config["apitoken"],
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// cloudflareRecord is a record as the Cloudflare API spells it.
+type cloudflareRecord struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Content  string  `json:"content"`
+	Priority *uint16 `json:"priority,omitempty"`
+	Comment  *string `json:"comment"`
+}
+
+// key returns the RecordCommentKey of the record, or false when happyDomain
+// can't parse it.
+func (r cloudflareRecord) key() (string, bool) {
+	content := r.Content
+	switch r.Type {
+	case "MX", "SRV", "URI":
+		// The priority comes apart from the rest of the data.
+		if r.Priority != nil {
+			content = fmt.Sprintf("%d %s", *r.Priority, content)
+		}
+	case "TXT", "SPF":
+		if !strings.HasPrefix(content, `"`) {
+			content = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(content) + `"`
+		}
+	}
+
+	rr, err := dns.NewRR(fmt.Sprintf("%s 0 IN %s %s", dns.Fqdn(r.Name), r.Type, content))
+	if err != nil || rr == nil {
+		return "", false
+	}
+
+	return happydns.RecordCommentKey(rr), true
+}
+
+func (c *cloudflareComments) comments(domain string) (happydns.RecordComments, error) {
+	_, records, err := c.records(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := happydns.RecordComments{}
+	for _, record := range records {
+		key, ok := record.key()
+		if !ok {
+			continue
+		}
+
+		comments[key] = ""
+		if record.Comment != nil {
+			comments[key] = *record.Comment
+		}
+	}
+
+	return comments, nil
+}
+
+func (c *cloudflareComments) setComments(domain string, comments happydns.RecordComments) error {
+	zoneID, records, err := c.records(domain)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		key, ok := record.key()
+		if !ok {
+			continue
+		}
+
+		comment, ok := comments[key]
+		if !ok || (record.Comment != nil && *record.Comment == comment) {
+			continue
+		}
+
+		body := map[string]string{"comment": comment}
+		if err := c.call(http.MethodPatch, fmt.Sprintf("/zones/%s/dns_records/%s", zoneID, record.ID), body, nil); err != nil {
+			return fmt.Errorf("unable to comment %s %s: %w", record.Name, record.Type, err)
+		}
+	}
+
+	return nil
+}
+
+// records returns the identifier of the zone of domain and its records,
+// walking through every page.
+func (c *cloudflareComments) records(domain string) (string, []cloudflareRecord, error) {
+	var zones struct {
+		Result []struct {
+			ID string `json:"id"`
+		} `json:"result"`
+	}
+	if err := c.call(http.MethodGet, "/zones?name="+url.QueryEscape(strings.TrimSuffix(domain, ".")), nil, &zones); err != nil {
+		return "", nil, err
+	}
+	if len(zones.Result) == 0 {
+		return "", nil, fmt.Errorf("Cloudflare: no zone %q", domain)
+	}
+	zoneID := zones.Result[0].ID
+
+	var ret []cloudflareRecord
+	for page := 1; ; page++ {
+		var resp struct {
+			Result     []cloudflareRecord `json:"result"`
+			ResultInfo struct {
+				TotalPages int `json:"total_pages"`
+			} `json:"result_info"`
+		}
+		if err := c.call(http.MethodGet, fmt.Sprintf("/zones/%s/dns_records?page=%d&per_page=1000", zoneID, page), nil, &resp); err != nil {
+			return "", nil, err
+		}
+
+		ret = append(ret, resp.Result...)
+		if page >= resp.ResultInfo.TotalPages {
+			return zoneID, ret, nil
+		}
+	}
+}
+
+// call sends in, encoded as JSON, to the given path of the API, and decodes
+// the answer into out. Either may be nil.
+func (c *cloudflareComments) call(method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		raw, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		if json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&apiErr) == nil && len(apiErr.Errors) > 0 {
+			var msgs []error
+			for _, e := range apiErr.Errors {
+				msgs = append(msgs, errors.New(e.Message))
+			}
+			return fmt.Errorf("Cloudflare: %w", errors.Join(msgs...))
+		}
+		return fmt.Errorf("Cloudflare: %s", resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
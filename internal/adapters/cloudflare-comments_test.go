@@ -0,0 +1,114 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package adapter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// fakeCloudflare serves the part of the Cloudflare API the comments use, for
+// the zone example.com.
+type fakeCloudflare struct {
+	records []cloudflareRecord
+}
+
+func (f *fakeCloudflare) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer secret-token" {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]any{"errors": []map[string]string{{"message": "Authentication error"}}})
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/zones":
+		json.NewEncoder(w).Encode(map[string]any{"result": []map[string]string{{"id": "z1"}}})
+	case r.Method == http.MethodGet && r.URL.Path == "/zones/z1/dns_records":
+		json.NewEncoder(w).Encode(map[string]any{
+			"result":      f.records,
+			"result_info": map[string]int{"page": 1, "total_pages": 1},
+		})
+	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/zones/z1/dns_records/"):
+		var body struct {
+			Comment string `json:"comment"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		for i := range f.records {
+			if r.URL.Path == "/zones/z1/dns_records/"+f.records[i].ID {
+				f.records[i].Comment = &body.Comment
+				json.NewEncoder(w).Encode(map[string]any{"result": f.records[i]})
+				return
+			}
+		}
+		fallthrough
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]any{"errors": []map[string]string{{"message": "Not found"}}})
+	}
+}
+
+func TestCloudflareComments(t *testing.T) {
+	byHand := "added by hand"
+	priority := uint16(10)
+	fake := &fakeCloudflare{records: []cloudflareRecord{
+		{ID: "r1", Name: "www.example.com", Type: "A", Content: "192.0.2.1"},
+		{ID: "r2", Name: "example.com", Type: "MX", Content: "mail.example.com", Priority: &priority, Comment: &byHand},
+		{ID: "r3", Name: "example.com", Type: "TXT", Content: "v=spf1 -all"},
+	}}
+
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	store, err := newCloudflareComments(map[string]string{"apitoken": "secret-token", "baseurl": srv.URL})
+	if err != nil {
+		t.Fatalf("newCloudflareComments() = %v", err)
+	}
+
+	www, _ := dns.NewRR("www.example.com. 300 IN A 192.0.2.1")
+	mx, _ := dns.NewRR("example.com. 300 IN MX 10 mail.example.com.")
+	txt, _ := dns.NewRR(`example.com. 300 IN TXT "v=spf1 -all"`)
+
+	comments, err := store.comments("example.com.")
+	if err != nil || len(comments) != 3 || comments[happydns.RecordCommentKey(mx)] != byHand || comments[happydns.RecordCommentKey(www)] != "" {
+		t.Fatalf("comments() = %v, %v; want the comment of the MX record, and none for the others", comments, err)
+	}
+
+	err = store.setComments("example.com.", happydns.RecordComments{
+		happydns.RecordCommentKey(www): happydns.FormatRecordComment("front"),
+		happydns.RecordCommentKey(txt): happydns.FormatRecordComment(""),
+	})
+	if err != nil {
+		t.Fatalf("setComments() = %v", err)
+	}
+
+	comments, err = store.comments("example.com.")
+	if err != nil || len(comments) != 3 || comments[happydns.RecordCommentKey(www)] != "managed by happyDomain: front" || comments[happydns.RecordCommentKey(mx)] != byHand {
+		t.Errorf("comments() = %v, %v; want those set, the MX one kept", comments, err)
+	}
+}
@@ -0,0 +1,138 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package adapter
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// nativeCommenters lists the backends whose comments happyDomain reads and
+// writes through their API, DNSControl carrying no comment. They are keyed by
+// the DNSControl name of the backend, and get its configuration.
+var nativeCommenters = map[string]func(map[string]string) (commentStore, error){
+	"CLOUDFLAREAPI": newCloudflareComments,
+	"POWERDNS":      newPowerDNSComments,
+}
+
+// commentStore reads and writes the comments a backend keeps along the
+// records of a zone.
+type commentStore interface {
+	// comments returns the comments stored along the records of the zone,
+	// with an empty one for each record bearing none.
+	comments(domain string) (happydns.RecordComments, error)
+
+	// setComments stores the given comments along the records of the zone
+	// they name, when they differ. The other records keep theirs, and the
+	// comments of records missing from the zone are dropped.
+	setComments(domain string, comments happydns.RecordComments) error
+}
+
+// DNSControlAdapterCommenter extends a DNSControl backend with the comments
+// it stores along the records, read and written apart from DNSControl (see
+// nativeCommenters). The registrar side, if any, is reached through Unwrap.
+type DNSControlAdapterCommenter struct {
+	happydns.ProviderActuator
+
+	adapter *DNSControlAdapterNSProvider
+	store   commentStore
+}
+
+// Unwrap returns the actuator extended with the comments.
+func (c *DNSControlAdapterCommenter) Unwrap() happydns.ProviderActuator {
+	return c.ProviderActuator
+}
+
+// GetZoneRecordComments returns the comments stored along the records.
+func (c *DNSControlAdapterCommenter) GetZoneRecordComments(domain string) (comments happydns.RecordComments, err error) {
+	defer c.adapter.observeProviderCall("get_zone_record_comments")(&err)
+
+	return c.store.comments(domain)
+}
+
+// GetZoneCorrectionsWithComments is GetZoneCorrections storing the given
+// comments once the records are written: the records created only exist by
+// then. When some comments of the records held differ from the stored ones,
+// a last correction stores them all. Otherwise the last correction does, if
+// any. The comments are left untouched when the stored ones can't be read.
+func (c *DNSControlAdapterCommenter) GetZoneCorrectionsWithComments(domain string, wantedRecords []happydns.Record, comments happydns.RecordComments) ([]*happydns.Correction, int, error) {
+	corrections, nbCorrections, err := c.GetZoneCorrections(domain, wantedRecords)
+	if err != nil || len(comments) == 0 {
+		return corrections, nbCorrections, err
+	}
+
+	// Failing to read the comments must not prevent the records from being
+	// published: the comments are then left as they are.
+	current, err := c.GetZoneRecordComments(domain)
+	if err != nil {
+		log.Printf("%s: unable to retrieve the record comments: %s", domain, err.Error())
+		return corrections, nbCorrections, nil
+	}
+
+	setComments := func() (err error) {
+		defer c.adapter.observeProviderCall("set_zone_record_comments")(&err)
+
+		return c.store.setComments(domain, comments)
+	}
+
+	if changed := comments.Changed(wantedRecords, current); changed != nil {
+		return append(corrections, NewCommentsCorrection(changed, setComments)), nbCorrections + 1, nil
+	}
+
+	if len(corrections) > 0 {
+		last := corrections[len(corrections)-1]
+		f := last.F
+		last.F = func() error {
+			if err := f(); err != nil {
+				return err
+			}
+			return setComments()
+		}
+	}
+
+	return corrections, nbCorrections, nil
+}
+
+// NewCommentsCorrection returns the correction storing the changed comments
+// along their records, f doing it. Its message has a line per record.
+func NewCommentsCorrection(changed happydns.RecordComments, f func() error) *happydns.Correction {
+	var lines []string
+	for _, key := range slices.Sorted(maps.Keys(changed)) {
+		lines = append(lines, fmt.Sprintf("± COMMENT %s: %q", key, changed[key]))
+	}
+	msg := strings.Join(lines, "\n")
+	id := sha256.Sum224([]byte(msg))
+
+	return &happydns.Correction{
+		F:     f,
+		Id:    id[:],
+		Msg:   msg,
+		Kind:  happydns.CorrectionKindUpdate,
+		Trace: &happydns.CorrectionTrace{Request: changed},
+	}
+}
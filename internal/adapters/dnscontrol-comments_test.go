@@ -0,0 +1,121 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package adapter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// fakeCommentStore keeps the comments in memory.
+type fakeCommentStore struct {
+	stored happydns.RecordComments
+}
+
+func (f *fakeCommentStore) comments(domain string) (happydns.RecordComments, error) {
+	return f.stored, nil
+}
+
+func (f *fakeCommentStore) setComments(domain string, comments happydns.RecordComments) error {
+	for key, comment := range comments {
+		f.stored[key] = comment
+	}
+	return nil
+}
+
+// unreadableCommentStore fails to read the comments.
+type unreadableCommentStore struct {
+	fakeCommentStore
+}
+
+func (unreadableCommentStore) comments(domain string) (happydns.RecordComments, error) {
+	return nil, errors.New("API unavailable")
+}
+
+// changedZone is an actuator finding the given corrections to make.
+type changedZone struct {
+	happydns.ProviderActuator
+	corrections []*happydns.Correction
+}
+
+func (z changedZone) GetZoneCorrections(domain string, wantedRecords []happydns.Record) ([]*happydns.Correction, int, error) {
+	return z.corrections, len(z.corrections), nil
+}
+
+// unchangedZone is an actuator finding the zone as wanted.
+type unchangedZone struct {
+	happydns.ProviderActuator
+}
+
+func (unchangedZone) GetZoneCorrections(domain string, wantedRecords []happydns.Record) ([]*happydns.Correction, int, error) {
+	return nil, 0, nil
+}
+
+func TestDNSControlAdapterCommenter(t *testing.T) {
+	www, _ := dns.NewRR("www.example.com. 300 IN A 192.0.2.1")
+	key := happydns.RecordCommentKey(www)
+
+	store := &fakeCommentStore{stored: happydns.RecordComments{key: happydns.FormatRecordComment("front")}}
+	commenter := &DNSControlAdapterCommenter{
+		ProviderActuator: unchangedZone{},
+		adapter:          &DNSControlAdapterNSProvider{providerName: "CLOUDFLAREAPI"},
+		store:            store,
+	}
+
+	corrections, _, err := commenter.GetZoneCorrectionsWithComments("example.com", []happydns.Record{www}, happydns.RecordComments{key: happydns.FormatRecordComment("front")})
+	if err != nil || len(corrections) != 0 {
+		t.Fatalf("GetZoneCorrectionsWithComments() = %v, %v; want no correction for a stored comment", corrections, err)
+	}
+
+	edited := happydns.RecordComments{key: happydns.FormatRecordComment("web front")}
+	corrections, nb, err := commenter.GetZoneCorrectionsWithComments("example.com", []happydns.Record{www}, edited)
+	if err != nil || len(corrections) != 1 || nb != 1 {
+		t.Fatalf("GetZoneCorrectionsWithComments() = %v, %d, %v; want the correction of the comment", corrections, nb, err)
+	}
+	if err := corrections[0].F(); err != nil {
+		t.Fatalf("applying %q = %v", corrections[0].Msg, err)
+	}
+	if store.stored[key] != "managed by happyDomain: web front" {
+		t.Errorf("stored = %v; want the edited comment", store.stored)
+	}
+}
+
+func TestDNSControlAdapterCommenterUnreadableComments(t *testing.T) {
+	www, _ := dns.NewRR("www.example.com. 300 IN A 192.0.2.1")
+	key := happydns.RecordCommentKey(www)
+
+	record := &happydns.Correction{Msg: "+ CREATE www.example.com. A 192.0.2.1", F: func() error { return nil }}
+	commenter := &DNSControlAdapterCommenter{
+		ProviderActuator: changedZone{corrections: []*happydns.Correction{record}},
+		adapter:          &DNSControlAdapterNSProvider{providerName: "CLOUDFLAREAPI"},
+		store:            &unreadableCommentStore{},
+	}
+
+	corrections, nb, err := commenter.GetZoneCorrectionsWithComments("example.com", []happydns.Record{www}, happydns.RecordComments{key: happydns.FormatRecordComment("front")})
+	if err != nil || nb != 1 || len(corrections) != 1 || corrections[0] != record {
+		t.Fatalf("GetZoneCorrectionsWithComments() = %v, %d, %v; want the record correction alone", corrections, nb, err)
+	}
+}
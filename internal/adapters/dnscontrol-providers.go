@@ -25,10 +25,12 @@ import (
 	"crypto/sha256"
 	"errors"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
 	"github.com/DNSControl/dnscontrol/v4/models"
+	"github.com/DNSControl/dnscontrol/v4/pkg/diff2"
 	dnscontrol "github.com/DNSControl/dnscontrol/v4/pkg/providers"
 	"github.com/miekg/dns"
 
@@ -59,6 +61,9 @@ func GetDNSControlProviderCapabilities(prvd DNSControlConfigAdapter) (caps []str
 	if dnscontrol.ProviderHasCapability(prvd.DNSControlName(), dnscontrol.CanGetZones) {
 		caps = append(caps, "ListDomains")
 	}
+	if _, ok := dnscontrol.RegistrarTypes[prvd.DNSControlName()]; ok {
+		caps = append(caps, "Registrar")
+	}
 
 	// Compatible RR
 	for _, v := range []uint16{dns.TypeA, dns.TypeAAAA, dns.TypeCNAME, dns.TypeMX, dns.TypeNS, dns.TypeTXT} {
@@ -117,6 +122,9 @@ func GetDNSControlProviderCapabilities(prvd DNSControlConfigAdapter) (caps []str
 	// type code to.
 	caps = append(caps, pseudoTypeCapabilities(prvd.DNSControlName())...)
 
+	// An ALIAS the provider is unable to publish is flattened by happyDomain.
+	caps = append(caps, aliasFlatteningCapabilities(prvd.DNSControlName())...)
+
 	return
 }
 
@@ -156,11 +164,31 @@ func NewDNSControlProviderAdapter(configAdapter DNSControlConfigAdapter) (ret ha
 		auditor = p.RecordAuditor
 	}
 
-	return &DNSControlAdapterNSProvider{
+	adapter := &DNSControlAdapterNSProvider{
 		DNSServiceProvider: provider,
 		RecordAuditor:      auditor,
 		providerName:       configAdapter.DNSControlName(),
-	}, nil
+	}
+
+	ret = adapter
+	if registrar := newDNSControlRegistrar(adapter, config); registrar != nil {
+		ret = registrar
+	}
+
+	if newStore, ok := nativeCommenters[adapter.providerName]; ok {
+		store, err := newStore(config)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = &DNSControlAdapterCommenter{
+			ProviderActuator: ret,
+			adapter:          adapter,
+			store:            store,
+		}
+	}
+
+	return ret, nil
 }
 
 // DNSControlAdapterNSProvider wraps a DNSControl provider to implement the happyDomain ProviderActuator interface.
@@ -314,21 +342,106 @@ func (p *DNSControlAdapterNSProvider) GetZoneCorrections(domain string, rrs []ha
 		return nil, nbCorrections, err
 	}
 
+	changes := dnscontrolChanges(domain, records, dc)
+
 	ret = make([]*happydns.Correction, len(corrections))
 	for i, correction := range corrections {
 		id := sha256.Sum224([]byte(correction.Msg))
 
 		ret[i] = &happydns.Correction{
-			F:    correction.F,
-			Id:   id[:],
-			Msg:  correction.Msg,
-			Kind: DNSControlCorrectionKindFromMessage(correction.Msg),
+			F:     correction.F,
+			Id:    id[:],
+			Msg:   correction.Msg,
+			Kind:  DNSControlCorrectionKindFromMessage(correction.Msg),
+			Trace: changes.trace(correction.Msg),
 		}
 	}
 
 	return ret, nbCorrections, nil
 }
 
+// dnscontrolChange is what the provider journal keeps of a correction made
+// by a DNSControl backend: the records it removes from and adds to the zone.
+// DNSControl does not expose the API calls of the backends, so there is no
+// response.
+type dnscontrolChange struct {
+	Zone    string   `json:"zone"`
+	Removed []string `json:"removed,omitempty"`
+	Added   []string `json:"added,omitempty"`
+}
+
+// dnscontrolChangeIndex holds the changes between the zone at the provider
+// and the wanted one, by the message DNSControl gives each of them.
+type dnscontrolChangeIndex struct {
+	zone    string
+	changes map[string]diff2.Change
+}
+
+// dnscontrolChanges computes the changes from existing to dc. The backends
+// build their corrections from the same diff, and their messages from those
+// of its changes, one line each: that is how a correction finds back the
+// records it touches. Failing to compute them only leaves the corrections
+// untraced.
+func dnscontrolChanges(domain string, existing models.Records, dc *models.DomainConfig) *dnscontrolChangeIndex {
+	changes, _, err := diff2.ByRecord(existing, dc, nil)
+	if err != nil {
+		log.Printf("%s: unable to trace the corrections: %s", domain, err.Error())
+		return nil
+	}
+
+	idx := &dnscontrolChangeIndex{
+		zone:    dc.Name,
+		changes: map[string]diff2.Change{},
+	}
+	for _, change := range changes {
+		for _, msg := range change.Msgs {
+			idx.changes[strings.TrimSpace(msg)] = change
+		}
+	}
+
+	return idx
+}
+
+// trace returns the trace of the correction with the given message, or nil
+// when none of its lines is a known change.
+func (idx *dnscontrolChangeIndex) trace(msg string) *happydns.CorrectionTrace {
+	if idx == nil {
+		return nil
+	}
+
+	write := dnscontrolChange{Zone: idx.zone}
+	seen := map[string]bool{}
+	for _, line := range strings.Split(msg, "\n") {
+		line = strings.TrimSpace(line)
+		change, ok := idx.changes[line]
+		if !ok || seen[line] {
+			continue
+		}
+		for _, m := range change.Msgs {
+			seen[strings.TrimSpace(m)] = true
+		}
+
+		for _, rc := range change.Old {
+			write.Removed = append(write.Removed, dnscontrolRecordString(rc))
+		}
+		for _, rc := range change.New {
+			write.Added = append(write.Added, dnscontrolRecordString(rc))
+		}
+	}
+
+	if len(seen) == 0 {
+		return nil
+	}
+
+	return &happydns.CorrectionTrace{Request: write}
+}
+
+// dnscontrolRecordString formats rc as a line of zone file. RecordConfig.ToRR
+// is not used: it aborts on the pseudo-types.
+func dnscontrolRecordString(rc *models.RecordConfig) string {
+	return fmt.Sprintf("%s %d %s %s", rc.NameFQDN, rc.TTL, rc.Type, rc.GetTargetCombined())
+}
+
 // CreateDomain creates a new zone (domain) on the provider.
 // The fqdn parameter should be a fully qualified domain name (with or without trailing dot).
 // Returns an error if the provider doesn't support domain creation or if creation fails.
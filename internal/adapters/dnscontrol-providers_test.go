@@ -26,6 +26,8 @@ package adapter
 
 import (
 	"errors"
+	"slices"
+	"strings"
 	"testing"
 
 	dnscontrolmodels "github.com/DNSControl/dnscontrol/v4/models"
@@ -281,3 +283,44 @@ func TestObserveProviderCall_ListZones_Error(t *testing.T) {
 		t.Errorf("expected error counter=1, got %v", got)
 	}
 }
+
+// --- journal traces -----------------------------------------------------------
+
+func TestDNSControlChangesTrace(t *testing.T) {
+	existing := dnscontrolmodels.Records{
+		mustRecordConfig(t, "example.com", "www.example.com. 300 IN A 192.0.2.1"),
+		mustRecordConfig(t, "example.com", "old.example.com. 300 IN A 192.0.2.2"),
+	}
+
+	dc := NewDNSControlDomainConfigName("example.com")
+	dc.Records = dnscontrolmodels.Records{
+		mustRecordConfig(t, "example.com", "www.example.com. 300 IN A 192.0.2.1"),
+		mustRecordConfig(t, "example.com", "new.example.com. 300 IN A 192.0.2.3"),
+	}
+
+	changes := dnscontrolChanges("example.com", existing, dc)
+	if changes == nil || len(changes.changes) != 2 {
+		t.Fatalf("changes = %v, want the addition and the deletion", changes)
+	}
+
+	// A backend correcting the whole zone at once joins the messages.
+	var msgs []string
+	for msg := range changes.changes {
+		msgs = append(msgs, msg)
+	}
+
+	trace := changes.trace(strings.Join(msgs, "\n"))
+	if trace == nil {
+		t.Fatal("no trace for the joined messages")
+	}
+	write := trace.Request.(dnscontrolChange)
+	if write.Zone != "example.com" ||
+		!slices.Equal(write.Removed, []string{"old.example.com 300 A 192.0.2.2"}) ||
+		!slices.Equal(write.Added, []string{"new.example.com 300 A 192.0.2.3"}) {
+		t.Errorf("trace = %+v", write)
+	}
+
+	if changes.trace("a message of the backend's own") != nil {
+		t.Errorf("a correction matching no change got a trace")
+	}
+}
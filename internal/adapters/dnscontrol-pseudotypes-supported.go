@@ -151,6 +151,21 @@ func pseudoTypeCapabilities(providerName string) (caps []string) {
 	return
 }
 
+// AliasFlatteningCapability is advertised by the providers unable to publish an
+// ALIAS record. happyDomain offers the type anyway, and publishes instead the
+// addresses its target resolves to.
+const AliasFlatteningCapability = "alias-flattening"
+
+// aliasFlatteningCapabilities returns the capabilities standing for an ALIAS
+// happyDomain flattens itself, for a provider that does not declare it.
+func aliasFlatteningCapabilities(providerName string) []string {
+	if dnscontrol.ProviderHasCapability(providerName, dnscontrol.CanUseAlias) {
+		return nil
+	}
+
+	return []string{fmt.Sprintf("rr-%d-ALIAS", happydns.TypeALIAS), AliasFlatteningCapability}
+}
+
 // recordFromRecordConfig converts a record read from a provider into the
 // happyDomain representation.
 func recordFromRecordConfig(rc *models.RecordConfig) (happydns.Record, error) {
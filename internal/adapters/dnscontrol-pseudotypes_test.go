@@ -430,6 +430,14 @@ func TestPseudoTypeCapabilities(t *testing.T) {
 	}
 }
 
+// TestAliasFlatteningCapabilities covers the provider declaring ALIAS itself:
+// happyDomain hands the records over, it must not flatten them.
+func TestAliasFlatteningCapabilities(t *testing.T) {
+	if caps := aliasFlatteningCapabilities(testAliasProviderName); len(caps) != 0 {
+		t.Errorf("capabilities are %v, want none for a provider handling ALIAS", caps)
+	}
+}
+
 // TestGetZoneCorrectionsRefusesUndeclaredPseudoTypes covers the provider that
 // does not declare it handles a pseudo-type. happyDomain does not run
 // DNSControl's own capability check, and a RecordAuditor audits the content of
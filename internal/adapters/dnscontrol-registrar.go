@@ -0,0 +1,127 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package adapter
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/DNSControl/dnscontrol/v4/models"
+	dnscontrol "github.com/DNSControl/dnscontrol/v4/pkg/providers"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// nativeRegistrars lists the registrars happyDomain talks to directly rather
+// than through DNSControl, whose registrars only change the name servers.
+// They are keyed by the DNSControl name of the backend whose DNS side they
+// extend, and get its configuration.
+var nativeRegistrars = map[string]func(*DNSControlAdapterNSProvider, map[string]string) happydns.ProviderActuator{
+	"DNSIMPLE": newDNSimpleRegistrar,
+}
+
+// newDNSControlRegistrar extends adapter with the registrar side of its
+// backend, when it has one. It returns nil otherwise.
+//
+// The registrar of DNSControl is only created on its first use: most
+// instances of a provider never touch the registration of a domain.
+func newDNSControlRegistrar(adapter *DNSControlAdapterNSProvider, config map[string]string) happydns.ProviderActuator {
+	if native, ok := nativeRegistrars[adapter.providerName]; ok {
+		return native(adapter, config)
+	}
+
+	if _, ok := dnscontrol.RegistrarTypes[adapter.providerName]; !ok {
+		return nil
+	}
+
+	return &DNSControlAdapterRegistrar{
+		DNSControlAdapterNSProvider: adapter,
+		config:                      config,
+	}
+}
+
+// DNSControlAdapterRegistrar wraps a DNSControl backend which is a registrar
+// as well as a DNS provider. It implements happydns.RegistrarActuator on top
+// of the ProviderActuator.
+//
+// DNSControl only lets a registrar change the name servers of a domain: the
+// registration data are read from RDAP, and the DS records, transfer lock and
+// automatic renewal are left to the registrars happyDomain talks to directly
+// (see nativeRegistrars).
+type DNSControlAdapterRegistrar struct {
+	*DNSControlAdapterNSProvider
+
+	config map[string]string
+
+	once         sync.Once
+	registrar    dnscontrol.Registrar
+	registrarErr error
+}
+
+// getRegistrar creates the registrar side of the DNSControl backend, once.
+func (p *DNSControlAdapterRegistrar) getRegistrar() (dnscontrol.Registrar, error) {
+	p.once.Do(func() {
+		p.registrar, p.registrarErr = dnscontrol.CreateRegistrar(p.providerName, p.config)
+		if p.registrarErr != nil {
+			p.registrarErr = fmt.Errorf("unable to create the registrar: %w", p.registrarErr)
+		}
+	})
+
+	return p.registrar, p.registrarErr
+}
+
+// SetNameservers delegates the domain to the given name servers.
+func (p *DNSControlAdapterRegistrar) SetNameservers(domain string, nameservers []string) (err error) {
+	defer p.observeProviderCall("set_nameservers")(&err)
+
+	// Same as for the zones: a panic of the backend fails this request only.
+	defer func() {
+		if a := recover(); a != nil {
+			err = fmt.Errorf("%s", a)
+		}
+	}()
+
+	registrar, err := p.getRegistrar()
+	if err != nil {
+		return err
+	}
+
+	dc := NewDNSControlDomainConfigName(domain)
+	for _, ns := range nameservers {
+		dc.Nameservers = append(dc.Nameservers, &models.Nameserver{Name: strings.TrimSuffix(ns, ".")})
+	}
+
+	corrections, err := registrar.GetRegistrarCorrections(dc)
+	if err != nil {
+		return err
+	}
+
+	for _, correction := range corrections {
+		log.Printf("%s: apply registrar correction: %s", domain, correction.Msg)
+		if err = correction.F(); err != nil {
+			return fmt.Errorf("%s: %w", correction.Msg, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,373 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package adapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// dnsimpleBaseURL is the DNSimple API used when the configuration does not
+// name another one, such as its sandbox.
+const dnsimpleBaseURL = "https://api.dnsimple.com"
+
+// DNSimpleRegistrar manages the registration of the domains registered at
+// DNSimple through its API (https://developer.dnsimple.com/v2/registrar/).
+// Besides the name servers it reads the registration, and changes the DS
+// records, the transfer lock and the automatic renewal. The zones are still
+// managed through DNSControl.
+type DNSimpleRegistrar struct {
+	*DNSControlAdapterNSProvider
+
+	baseURL string
+	token   string
+	client  *http.Client
+
+	accountOnce sync.Once
+	account     string
+	accountErr  error
+}
+
+func newDNSimpleRegistrar(adapter *DNSControlAdapterNSProvider, config map[string]string) happydns.ProviderActuator {
+	baseURL := config["baseurl"]
+	if baseURL == "" {
+		baseURL = dnsimpleBaseURL
+	}
+
+	return &DNSimpleRegistrar{
+		DNSControlAdapterNSProvider: adapter,
+		baseURL:                     strings.TrimSuffix(baseURL, "/"),
+		token:                       config["token"],
+		client:                      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// dnsimpleDS is a DS record as the DNSimple API spells it: numbers as
+// strings.
+type dnsimpleDS struct {
+	ID         int64  `json:"id,omitempty"`
+	Algorithm  string `json:"algorithm"`
+	Digest     string `json:"digest"`
+	DigestType string `json:"digest_type"`
+	KeyTag     string `json:"keytag"`
+}
+
+func (ds dnsimpleDS) signer() (happydns.DelegationSigner, error) {
+	keyTag, err := strconv.ParseUint(ds.KeyTag, 10, 16)
+	if err != nil {
+		return happydns.DelegationSigner{}, fmt.Errorf("invalid key tag %q: %w", ds.KeyTag, err)
+	}
+	algorithm, err := strconv.ParseUint(ds.Algorithm, 10, 8)
+	if err != nil {
+		return happydns.DelegationSigner{}, fmt.Errorf("invalid algorithm %q: %w", ds.Algorithm, err)
+	}
+	digestType, err := strconv.ParseUint(ds.DigestType, 10, 8)
+	if err != nil {
+		return happydns.DelegationSigner{}, fmt.Errorf("invalid digest type %q: %w", ds.DigestType, err)
+	}
+
+	return happydns.DelegationSigner{
+		KeyTag:     uint16(keyTag),
+		Algorithm:  uint8(algorithm),
+		DigestType: uint8(digestType),
+		Digest:     strings.ToUpper(ds.Digest),
+	}, nil
+}
+
+func newDNSimpleDS(signer happydns.DelegationSigner) dnsimpleDS {
+	return dnsimpleDS{
+		Algorithm:  strconv.Itoa(int(signer.Algorithm)),
+		Digest:     strings.ToUpper(signer.Digest),
+		DigestType: strconv.Itoa(int(signer.DigestType)),
+		KeyTag:     strconv.Itoa(int(signer.KeyTag)),
+	}
+}
+
+// GetRegistration asks DNSimple how domain is registered.
+func (r *DNSimpleRegistrar) GetRegistration(domain string) (reg *happydns.Registration, err error) {
+	defer r.observeProviderCall("get_registration")(&err)
+
+	var delegation struct {
+		Data []string `json:"data"`
+	}
+	if err := r.registrarCall(http.MethodGet, domain, "/delegation", nil, &delegation); err != nil {
+		return nil, err
+	}
+
+	var lock struct {
+		Data struct {
+			Enabled bool `json:"enabled"`
+		} `json:"data"`
+	}
+	if err := r.registrarCall(http.MethodGet, domain, "/transfer_lock", nil, &lock); err != nil {
+		return nil, err
+	}
+
+	var info struct {
+		Data struct {
+			AutoRenew bool `json:"auto_renew"`
+		} `json:"data"`
+	}
+	if err := r.domainCall(http.MethodGet, domain, "", nil, &info); err != nil {
+		return nil, err
+	}
+
+	records, err := r.listDS(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	reg = &happydns.Registration{
+		TransferLock: &lock.Data.Enabled,
+		AutoRenew:    &info.Data.AutoRenew,
+	}
+	for _, ns := range delegation.Data {
+		reg.Nameservers = append(reg.Nameservers, strings.ToLower(dns.Fqdn(ns)))
+	}
+	for _, record := range records {
+		signer, err := record.signer()
+		if err != nil {
+			return nil, err
+		}
+		reg.DS = append(reg.DS, signer)
+	}
+
+	return reg, nil
+}
+
+// SetNameservers delegates the domain to the given name servers.
+func (r *DNSimpleRegistrar) SetNameservers(domain string, nameservers []string) (err error) {
+	defer r.observeProviderCall("set_nameservers")(&err)
+
+	names := make([]string, len(nameservers))
+	for i, ns := range nameservers {
+		names[i] = strings.TrimSuffix(ns, ".")
+	}
+
+	return r.registrarCall(http.MethodPut, domain, "/delegation", names, nil)
+}
+
+// SetDelegationSigners makes the DS records of domain those given. The new
+// records are added before the former ones are removed, for the domain not
+// to go through a moment without the DS record of its current key.
+func (r *DNSimpleRegistrar) SetDelegationSigners(domain string, signers []happydns.DelegationSigner) (err error) {
+	defer r.observeProviderCall("set_ds")(&err)
+
+	existing, err := r.listDS(domain)
+	if err != nil {
+		return err
+	}
+
+	current := map[happydns.DelegationSigner]dnsimpleDS{}
+	for _, record := range existing {
+		signer, err := record.signer()
+		if err != nil {
+			return err
+		}
+		current[signer] = record
+	}
+
+	wanted := map[happydns.DelegationSigner]bool{}
+	for _, signer := range signers {
+		signer.Digest = strings.ToUpper(signer.Digest)
+		wanted[signer] = true
+
+		if _, ok := current[signer]; ok {
+			continue
+		}
+		if err := r.domainCall(http.MethodPost, domain, "/ds_records", newDNSimpleDS(signer), nil); err != nil {
+			return fmt.Errorf("unable to add the DS record %d: %w", signer.KeyTag, err)
+		}
+	}
+
+	for signer, record := range current {
+		if wanted[signer] {
+			continue
+		}
+		if err := r.domainCall(http.MethodDelete, domain, fmt.Sprintf("/ds_records/%d", record.ID), nil, nil); err != nil {
+			return fmt.Errorf("unable to remove the DS record %d: %w", signer.KeyTag, err)
+		}
+	}
+
+	return nil
+}
+
+// SetTransferLock locks or unlocks the transfer of domain.
+func (r *DNSimpleRegistrar) SetTransferLock(domain string, locked bool) (err error) {
+	defer r.observeProviderCall("set_transfer_lock")(&err)
+
+	method := http.MethodDelete
+	if locked {
+		method = http.MethodPost
+	}
+
+	return r.registrarCall(method, domain, "/transfer_lock", nil, nil)
+}
+
+// SetAutoRenew turns the automatic renewal of domain on or off.
+func (r *DNSimpleRegistrar) SetAutoRenew(domain string, enabled bool) (err error) {
+	defer r.observeProviderCall("set_auto_renew")(&err)
+
+	method := http.MethodDelete
+	if enabled {
+		method = http.MethodPut
+	}
+
+	return r.registrarCall(method, domain, "/auto_renewal", nil, nil)
+}
+
+// listDS returns the DS records DNSimple holds for domain, walking through
+// every page.
+func (r *DNSimpleRegistrar) listDS(domain string) ([]dnsimpleDS, error) {
+	var ret []dnsimpleDS
+	for page := 1; ; page++ {
+		var resp struct {
+			Data       []dnsimpleDS `json:"data"`
+			Pagination struct {
+				TotalPages int `json:"total_pages"`
+			} `json:"pagination"`
+		}
+		if err := r.domainCall(http.MethodGet, domain, fmt.Sprintf("/ds_records?page=%d&per_page=100", page), nil, &resp); err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, resp.Data...)
+		if page >= resp.Pagination.TotalPages {
+			return ret, nil
+		}
+	}
+}
+
+// accountID returns the account the token acts for, asking DNSimple once.
+// A user token reaches every account of the user: it is only usable when
+// there is one.
+func (r *DNSimpleRegistrar) accountID() (string, error) {
+	r.accountOnce.Do(func() {
+		var whoami struct {
+			Data struct {
+				Account *struct {
+					ID int64 `json:"id"`
+				} `json:"account"`
+			} `json:"data"`
+		}
+		if r.accountErr = r.call(http.MethodGet, "/whoami", nil, &whoami); r.accountErr != nil {
+			return
+		}
+		if whoami.Data.Account != nil {
+			r.account = strconv.FormatInt(whoami.Data.Account.ID, 10)
+			return
+		}
+
+		var accounts struct {
+			Data []struct {
+				ID int64 `json:"id"`
+			} `json:"data"`
+		}
+		if r.accountErr = r.call(http.MethodGet, "/accounts", nil, &accounts); r.accountErr != nil {
+			return
+		}
+		if len(accounts.Data) != 1 {
+			r.accountErr = fmt.Errorf("the token reaches %d DNSimple accounts: use an account token", len(accounts.Data))
+			return
+		}
+		r.account = strconv.FormatInt(accounts.Data[0].ID, 10)
+	})
+
+	return r.account, r.accountErr
+}
+
+// registrarCall calls the registrar API about domain.
+func (r *DNSimpleRegistrar) registrarCall(method, domain, path string, in, out any) error {
+	account, err := r.accountID()
+	if err != nil {
+		return err
+	}
+
+	return r.call(method, fmt.Sprintf("/%s/registrar/domains/%s%s", account, url.PathEscape(strings.TrimSuffix(domain, ".")), path), in, out)
+}
+
+// domainCall calls the domain API about domain.
+func (r *DNSimpleRegistrar) domainCall(method, domain, path string, in, out any) error {
+	account, err := r.accountID()
+	if err != nil {
+		return err
+	}
+
+	return r.call(method, fmt.Sprintf("/%s/domains/%s%s", account, url.PathEscape(strings.TrimSuffix(domain, ".")), path), in, out)
+}
+
+// call sends in, encoded as JSON, to the given path of the API, and decodes
+// the answer into out. Either may be nil.
+func (r *DNSimpleRegistrar) call(method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		raw, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequest(method, r.baseURL+"/v2"+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+r.token)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("DNSimple: %s", apiErr.Message)
+		}
+		return fmt.Errorf("DNSimple: %s", resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,174 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package adapter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// fakeDNSimple serves the part of the DNSimple API the registrar uses, for
+// the domain example.com of account 42.
+type fakeDNSimple struct {
+	delegation []string
+	locked     bool
+	autoRenew  bool
+	ds         []dnsimpleDS
+	nextID     int64
+}
+
+func (f *fakeDNSimple) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer secret-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Authentication failed"})
+		return
+	}
+
+	data := func(v any) {
+		json.NewEncoder(w).Encode(map[string]any{"data": v})
+	}
+
+	switch r.Method + " " + r.URL.Path {
+	case "GET /v2/whoami":
+		data(map[string]any{"account": map[string]any{"id": 42}})
+	case "GET /v2/42/registrar/domains/example.com/delegation":
+		data(f.delegation)
+	case "PUT /v2/42/registrar/domains/example.com/delegation":
+		json.NewDecoder(r.Body).Decode(&f.delegation)
+		data(f.delegation)
+	case "GET /v2/42/registrar/domains/example.com/transfer_lock":
+		data(map[string]bool{"enabled": f.locked})
+	case "POST /v2/42/registrar/domains/example.com/transfer_lock":
+		f.locked = true
+		w.WriteHeader(http.StatusCreated)
+		data(map[string]bool{"enabled": f.locked})
+	case "DELETE /v2/42/registrar/domains/example.com/transfer_lock":
+		f.locked = false
+		data(map[string]bool{"enabled": f.locked})
+	case "PUT /v2/42/registrar/domains/example.com/auto_renewal":
+		f.autoRenew = true
+		w.WriteHeader(http.StatusNoContent)
+	case "DELETE /v2/42/registrar/domains/example.com/auto_renewal":
+		f.autoRenew = false
+		w.WriteHeader(http.StatusNoContent)
+	case "GET /v2/42/domains/example.com":
+		data(map[string]any{"name": "example.com", "auto_renew": f.autoRenew})
+	case "GET /v2/42/domains/example.com/ds_records":
+		json.NewEncoder(w).Encode(map[string]any{
+			"data":       f.ds,
+			"pagination": map[string]int{"current_page": 1, "total_pages": 1},
+		})
+	case "POST /v2/42/domains/example.com/ds_records":
+		var ds dnsimpleDS
+		json.NewDecoder(r.Body).Decode(&ds)
+		f.nextID++
+		ds.ID = f.nextID
+		f.ds = append(f.ds, ds)
+		w.WriteHeader(http.StatusCreated)
+		data(ds)
+	default:
+		for i, ds := range f.ds {
+			if r.Method == http.MethodDelete && r.URL.Path == "/v2/42/domains/example.com/ds_records/"+strconv.FormatInt(ds.ID, 10) {
+				f.ds = slices.Delete(f.ds, i, i+1)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Not found"})
+	}
+}
+
+func newTestDNSimpleRegistrar(t *testing.T, fake *fakeDNSimple) *DNSimpleRegistrar {
+	t.Helper()
+
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	return newDNSimpleRegistrar(&DNSControlAdapterNSProvider{providerName: "DNSIMPLE"}, map[string]string{
+		"token":   "secret-token",
+		"baseurl": srv.URL,
+	}).(*DNSimpleRegistrar)
+}
+
+func TestDNSimpleRegistrarOperations(t *testing.T) {
+	fake := &fakeDNSimple{
+		delegation: []string{"ns1.dnsimple.com", "ns2.dnsimple-edge.net"},
+		ds:         []dnsimpleDS{{ID: 1, KeyTag: "12345", Algorithm: "8", DigestType: "2", Digest: "aabb"}},
+		nextID:     1,
+	}
+	r := newTestDNSimpleRegistrar(t, fake)
+
+	reg, err := r.GetRegistration("example.com.")
+	if err != nil {
+		t.Fatalf("GetRegistration: %v", err)
+	}
+	if !slices.Equal(reg.Nameservers, []string{"ns1.dnsimple.com.", "ns2.dnsimple-edge.net."}) {
+		t.Errorf("Nameservers = %v", reg.Nameservers)
+	}
+	if len(reg.DS) != 1 || reg.DS[0] != (happydns.DelegationSigner{KeyTag: 12345, Algorithm: 8, DigestType: 2, Digest: "AABB"}) {
+		t.Errorf("DS = %v", reg.DS)
+	}
+	if reg.TransferLock == nil || *reg.TransferLock || reg.AutoRenew == nil || *reg.AutoRenew {
+		t.Errorf("TransferLock = %v, AutoRenew = %v, want both off", reg.TransferLock, reg.AutoRenew)
+	}
+
+	if err := r.SetNameservers("example.com.", []string{"ns1.example.net."}); err != nil {
+		t.Fatalf("SetNameservers: %v", err)
+	}
+	if !slices.Equal(fake.delegation, []string{"ns1.example.net"}) {
+		t.Errorf("delegation = %v", fake.delegation)
+	}
+
+	if err := r.SetTransferLock("example.com.", true); err != nil || !fake.locked {
+		t.Errorf("SetTransferLock(true) = %v, locked = %v", err, fake.locked)
+	}
+	if err := r.SetAutoRenew("example.com.", true); err != nil || !fake.autoRenew {
+		t.Errorf("SetAutoRenew(true) = %v, auto-renew = %v", err, fake.autoRenew)
+	}
+
+	// The new record is added, and the former one removed.
+	err = r.SetDelegationSigners("example.com.", []happydns.DelegationSigner{
+		{KeyTag: 2, Algorithm: 13, DigestType: 2, Digest: "ccdd"},
+	})
+	if err != nil {
+		t.Fatalf("SetDelegationSigners: %v", err)
+	}
+	if len(fake.ds) != 1 || fake.ds[0].KeyTag != "2" || fake.ds[0].Digest != "CCDD" {
+		t.Errorf("ds = %+v", fake.ds)
+	}
+}
+
+func TestDNSimpleRegistrarError(t *testing.T) {
+	r := newTestDNSimpleRegistrar(t, &fakeDNSimple{})
+	r.token = "wrong"
+
+	if err := r.SetAutoRenew("example.com.", true); err == nil || err.Error() != "DNSimple: Authentication failed" {
+		t.Errorf("SetAutoRenew = %v, want the message of the API", err)
+	}
+}
@@ -0,0 +1,268 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// RegisterLibdnsBridge registers the libdns provider T under name, without a
+// hand-written body: adding one is a single line in providers/, as
+// providers/spaceship.go shows.
+//
+//	adapter.RegisterLibdnsBridge[spaceship.Provider]("SpaceshipAPI", happydns.ProviderInfos{
+//		Name:    "Spaceship",
+//		Website: "https://www.spaceship.com",
+//	}, providerReg.RegisterNamedProvider)
+//
+// The settings form is derived from the exported fields of T (see
+// libdnsSettingsOf), and the capabilities from the libdns interfaces *T
+// implements. A provider needing more than these heuristics give, such as
+// labels and descriptions of its own or settings combined before reaching
+// libdns, keeps a hand-written body calling NewLibdnsProviderAdapter.
+func RegisterLibdnsBridge[T any](name string, infos happydns.ProviderInfos, registerFunc happydns.RegisterNamedProviderFunc) {
+	infos.Capabilities = append(infos.Capabilities, GetLibdnsProviderCapabilities(&LibdnsBridge[T]{})...)
+
+	registerFunc(name, func() happydns.ProviderBody {
+		return &LibdnsBridge[T]{}
+	}, infos)
+}
+
+// LibdnsBridge is the provider body of a libdns provider registered through
+// RegisterLibdnsBridge. Its settings live in a struct built at run time from
+// T, which is what gets stored, and what the forms walk (see
+// forms.SettingsHolder). The zero value is ready to use.
+type LibdnsBridge[T any] struct {
+	settings reflect.Value
+}
+
+// FormSettings returns a pointer to the settings struct.
+func (b *LibdnsBridge[T]) FormSettings() any {
+	if b == nil {
+		return nil
+	}
+
+	return b.settingsValue().Interface()
+}
+
+func (b *LibdnsBridge[T]) settingsValue() reflect.Value {
+	if !b.settings.IsValid() {
+		b.settings = reflect.New(libdnsSettingsOf(reflect.TypeFor[T]()).typ)
+	}
+
+	return b.settings
+}
+
+func (b *LibdnsBridge[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.settingsValue().Interface())
+}
+
+func (b *LibdnsBridge[T]) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, b.settingsValue().Interface())
+}
+
+// LibdnsProvider returns a *T holding the settings.
+func (b *LibdnsBridge[T]) LibdnsProvider() any {
+	p := new(T)
+
+	spec := libdnsSettingsOf(reflect.TypeFor[T]())
+	src := b.settingsValue().Elem()
+	dst := reflect.ValueOf(p).Elem()
+	for i, index := range spec.index {
+		dst.FieldByIndex(index).Set(src.Field(i))
+	}
+
+	return p
+}
+
+func (b *LibdnsBridge[T]) InstantiateProvider() (happydns.ProviderActuator, error) {
+	return NewLibdnsProviderAdapter(b)
+}
+
+// libdnsSettings describes the settings struct built for a libdns provider
+// type: the struct itself, and where each of its fields goes in the provider.
+type libdnsSettings struct {
+	typ   reflect.Type
+	index [][]int
+}
+
+var libdnsSettingsCache sync.Map
+
+// libdnsSettingsOf builds, once per provider type, the settings struct
+// presented to the user: the exported fields of t that a form can fill, under
+// the same JSON names, so that the stored settings are those the libdns
+// provider documents. Fields of embedded structs are promoted, as JSON does.
+//
+// A libdns provider only tags its fields for JSON, so the happyDomain tag is
+// guessed: the label from the field name, `secret` from the names credentials
+// usually have (libdnsSecretName), and `endpoint` from those of a
+// destination (libdnsEndpointName), so that the outbound guard sees them.
+// Nothing is marked required: an unused setting is left out rather than
+// blocking the form.
+func libdnsSettingsOf(t reflect.Type) *libdnsSettings {
+	if spec, ok := libdnsSettingsCache.Load(t); ok {
+		return spec.(*libdnsSettings)
+	}
+
+	spec := &libdnsSettings{}
+	var fields []reflect.StructField
+	collectLibdnsSettings(t, nil, spec, &fields)
+	spec.typ = reflect.StructOf(fields)
+
+	actual, _ := libdnsSettingsCache.LoadOrStore(t, spec)
+	return actual.(*libdnsSettings)
+}
+
+func collectLibdnsSettings(t reflect.Type, index []int, spec *libdnsSettings, fields *[]reflect.StructField) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		jsonTag := sf.Tag.Get("json")
+		jsonName, _, _ := strings.Cut(jsonTag, ",")
+		if jsonName == "-" {
+			continue
+		}
+
+		fieldIndex := append(slices.Clone(index), i)
+
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct && jsonName == "" {
+			collectLibdnsSettings(sf.Type, fieldIndex, spec, fields)
+			continue
+		}
+
+		if !sf.IsExported() || !isLibdnsSettingKind(sf.Type.Kind()) {
+			continue
+		}
+
+		// An embedded struct may shadow a field already collected; JSON
+		// keeps the shallowest, and so does the settings struct.
+		if slices.ContainsFunc(*fields, func(f reflect.StructField) bool { return f.Name == sf.Name }) {
+			continue
+		}
+
+		id := jsonName
+		if id == "" {
+			id = sf.Name
+		}
+
+		hdTag := []string{"label=" + libdnsLabel(sf.Name)}
+		if sf.Type.Kind() == reflect.String {
+			if libdnsSecretName(id) {
+				hdTag = append(hdTag, "secret")
+			} else if libdnsEndpointName(id) {
+				hdTag = append(hdTag, "endpoint")
+			}
+		}
+
+		tag := fmt.Sprintf(`happydomain:%q`, strings.Join(hdTag, ","))
+		if jsonTag != "" {
+			tag = fmt.Sprintf(`json:%q `, jsonTag) + tag
+		}
+
+		*fields = append(*fields, reflect.StructField{
+			Name: sf.Name,
+			Type: sf.Type,
+			Tag:  reflect.StructTag(tag),
+		})
+		spec.index = append(spec.index, fieldIndex)
+	}
+}
+
+// isLibdnsSettingKind reports whether a field of that kind can be filled from
+// a form. Clients, callbacks and other run-time state are left out.
+func isLibdnsSettingKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// normalizeSettingName lowercases name and drops its separators, so that
+// "api_token", "apiToken" and "APIToken" compare the same.
+func normalizeSettingName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || r == '.' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, name)
+}
+
+// libdnsSecretName reports whether a setting called name is likely a
+// credential. It errs on the side of secrecy: an identifier wrongly taken for
+// a secret is only hidden when read back.
+func libdnsSecretName(name string) bool {
+	n := normalizeSettingName(name)
+
+	for _, s := range []string{"secret", "password", "passwd", "token", "credential", "privatekey"} {
+		if strings.Contains(n, s) {
+			return true
+		}
+	}
+
+	return strings.HasSuffix(n, "key")
+}
+
+// libdnsEndpointName reports whether a setting called name is likely the
+// destination of the API calls.
+func libdnsEndpointName(name string) bool {
+	n := normalizeSettingName(name)
+
+	for _, s := range []string{"url", "endpoint", "host", "server"} {
+		if strings.Contains(n, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// libdnsLabel turns a Go field name into a label: "AuthAPIToken" becomes
+// "Auth API Token".
+func libdnsLabel(name string) string {
+	runes := []rune(name)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				b.WriteRune(' ')
+			}
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
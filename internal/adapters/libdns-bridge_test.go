@@ -0,0 +1,203 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/libdns/libdns"
+	spaceship "github.com/libdns/spaceship"
+
+	"git.happydns.org/happyDomain/internal/forms"
+	"git.happydns.org/happyDomain/model"
+)
+
+type bridgedCommon struct {
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// bridgedProvider has the shape of a typical libdns provider: settings tagged
+// for JSON next to run-time state.
+type bridgedProvider struct {
+	bridgedCommon
+	APIToken   string       `json:"api_token,omitempty"`
+	AccountID  string       `json:"account_id"`
+	PageSize   int          `json:"page_size,omitempty"`
+	HTTPClient *http.Client `json:"-"`
+	Hooks      []string     `json:"hooks,omitempty"`
+
+	mu sync.Mutex
+}
+
+func (p *bridgedProvider) GetRecords(_ context.Context, _ string) ([]libdns.Record, error) {
+	return nil, nil
+}
+
+func (p *bridgedProvider) ListZones(_ context.Context) ([]libdns.Zone, error) {
+	return []libdns.Zone{{Name: "example.com."}}, nil
+}
+
+func TestLibdnsBridgeFields(t *testing.T) {
+	fields := forms.GenStructFields(&LibdnsBridge[bridgedProvider]{})
+
+	var ids []string
+	byID := map[string]*happydns.Field{}
+	for _, f := range fields {
+		ids = append(ids, f.Id)
+		byID[f.Id] = f
+	}
+
+	want := []string{"endpoint", "api_token", "account_id", "page_size"}
+	if !slices.Equal(ids, want) {
+		t.Fatalf("fields = %v, want %v", ids, want)
+	}
+
+	if !byID["api_token"].Secret {
+		t.Errorf("api_token is not secret")
+	}
+	if byID["account_id"].Secret || byID["page_size"].Secret {
+		t.Errorf("a non-credential field is secret")
+	}
+	if !byID["endpoint"].Endpoint {
+		t.Errorf("endpoint is not an endpoint")
+	}
+	if byID["api_token"].Label != "API Token" || byID["account_id"].Label != "Account ID" {
+		t.Errorf("labels = %q, %q", byID["api_token"].Label, byID["account_id"].Label)
+	}
+}
+
+func TestLibdnsBridgeSettingsRoundTrip(t *testing.T) {
+	body := &LibdnsBridge[bridgedProvider]{}
+	if err := json.Unmarshal([]byte(`{"endpoint":"https://api.example.com","api_token":"t0k3n","page_size":50}`), body); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	p, ok := body.LibdnsProvider().(*bridgedProvider)
+	if !ok {
+		t.Fatalf("LibdnsProvider returned %T", body.LibdnsProvider())
+	}
+	if p.Endpoint != "https://api.example.com" || p.APIToken != "t0k3n" || p.PageSize != 50 {
+		t.Errorf("provider = %+v", p)
+	}
+
+	raw, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !strings.Contains(string(raw), `"api_token":"t0k3n"`) {
+		t.Errorf("stored settings = %s", raw)
+	}
+
+	forms.RedactSecrets(body)
+	if p := body.LibdnsProvider().(*bridgedProvider); p.APIToken != happydns.RedactedSecret || p.Endpoint != "https://api.example.com" {
+		t.Errorf("after RedactSecrets, provider = %+v", p)
+	}
+}
+
+func TestRegisterLibdnsBridge(t *testing.T) {
+	var (
+		name    string
+		creator happydns.ProviderCreatorFunc
+		infos   happydns.ProviderInfos
+	)
+	RegisterLibdnsBridge[bridgedProvider]("Bridged", happydns.ProviderInfos{Name: "Bridged"}, func(n string, c happydns.ProviderCreatorFunc, i happydns.ProviderInfos) {
+		name, creator, infos = n, c, i
+	})
+
+	if name != "Bridged" {
+		t.Errorf("registered as %q", name)
+	}
+	if _, ok := creator().(*LibdnsBridge[bridgedProvider]); !ok {
+		t.Errorf("creator returned %T", creator())
+	}
+	if !slices.Contains(infos.Capabilities, "ListDomains") {
+		t.Errorf("capabilities = %v, want ListDomains", infos.Capabilities)
+	}
+
+	actuator, err := creator().InstantiateProvider()
+	if err != nil {
+		t.Fatalf("InstantiateProvider: %v", err)
+	}
+	if zones, err := actuator.ListZones(); err != nil || !slices.Equal(zones, []string{"example.com."}) {
+		t.Errorf("ListZones = %v, %v", zones, err)
+	}
+}
+
+func TestLibdnsSettingHeuristics(t *testing.T) {
+	for name, want := range map[string]bool{
+		"api_token":     true,
+		"APIKey":        true,
+		"secret_key":    true,
+		"password":      true,
+		"access_key_id": false,
+		"username":      false,
+		"key_name":      false,
+	} {
+		if got := libdnsSecretName(name); got != want {
+			t.Errorf("libdnsSecretName(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	for name, want := range map[string]string{
+		"AuthAPIToken": "Auth API Token",
+		"BaseURL":      "Base URL",
+		"PageSize":     "Page Size",
+		"AccessKeyID":  "Access Key ID",
+		"Token":        "Token",
+	} {
+		if got := libdnsLabel(name); got != want {
+			t.Errorf("libdnsLabel(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// The settings of a Spaceship provider stored by the hand-written body it
+// replaced are read as they are by the bridge.
+func TestLibdnsBridgeSpaceshipSettings(t *testing.T) {
+	var b LibdnsBridge[spaceship.Provider]
+	if err := json.Unmarshal([]byte(`{"api_key":"key","api_secret":"secret"}`), &b); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	p := b.LibdnsProvider().(*spaceship.Provider)
+	if p.APIKey != "key" || p.APISecret != "secret" {
+		t.Errorf("LibdnsProvider() = %+v, want the stored credentials", p)
+	}
+
+	for _, f := range forms.GenStructFields(&b) {
+		switch f.Id {
+		case "api_key", "api_secret":
+			if !f.Secret {
+				t.Errorf("%s is not secret", f.Id)
+			}
+		case "base_url":
+			if !f.Endpoint {
+				t.Errorf("base_url is not an endpoint")
+			}
+		}
+	}
+}
@@ -69,6 +69,9 @@ func GetLibdnsProviderCapabilities(prvd LibdnsConfigAdapter) (caps []string) {
 		caps = append(caps, fmt.Sprintf("rr-%d-%s", v, dns.TypeToString[v]))
 	}
 
+	// None of them gives ALIAS any meaning: happyDomain flattens it.
+	caps = append(caps, fmt.Sprintf("rr-%d-ALIAS", happydns.TypeALIAS), AliasFlatteningCapability)
+
 	return
 }
 
@@ -217,21 +220,53 @@ func (p *LibdnsAdapterNSProvider) GetZoneCorrections(domain string, wantedRecord
 			Kind:       diff.Kind,
 			OldRecords: diff.OldRecords,
 			NewRecords: diff.NewRecords,
+			Trace:      &happydns.CorrectionTrace{},
 		}
 
-		corrections[i].F = p.makeCorrectionFunc(zone, diff, libdnsRecordsByKey)
+		corrections[i].F = p.makeCorrectionFunc(zone, corrections[i], libdnsRecordsByKey)
 	}
 
 	return corrections, nbDiffs, nil
 }
 
+// libdnsCall is what the provider journal keeps of a libdns call: the
+// records sent, or the records the provider returned.
+type libdnsCall struct {
+	Method  string      `json:"method"`
+	Zone    string      `json:"zone"`
+	Records []libdns.RR `json:"records"`
+}
+
+// traceLibdnsCall adds a call to the trace of a correction, if it has one.
+func traceLibdnsCall(trace *happydns.CorrectionTrace, method, zone string, sent, got []libdns.Record) {
+	if trace == nil {
+		return
+	}
+
+	request, _ := trace.Request.([]libdnsCall)
+	trace.Request = append(request, libdnsCall{Method: method, Zone: zone, Records: libdnsRRs(sent)})
+
+	response, _ := trace.Response.([]libdnsCall)
+	trace.Response = append(response, libdnsCall{Method: method, Zone: zone, Records: libdnsRRs(got)})
+}
+
+func libdnsRRs(recs []libdns.Record) []libdns.RR {
+	rrs := make([]libdns.RR, len(recs))
+	for i, rec := range recs {
+		rrs[i] = rec.RR()
+	}
+	return rrs
+}
+
 // makeCorrectionFunc creates an executable function for a single correction.
+// The calls it makes are traced in diff.Trace.
 func (p *LibdnsAdapterNSProvider) makeCorrectionFunc(
 	zone string,
 	diff *happydns.Correction,
 	libdnsRecordsByKey map[string][]libdns.Record,
 ) func() error {
 	kind := diff.Kind
+	trace := diff.Trace
 
 	// Resolve old records to their original libdns Records (with ProviderData).
 	oldRecs := p.resolveOriginalRecords(diff.OldRecords, zone, libdnsRecordsByKey)
@@ -243,16 +278,21 @@ func (p *LibdnsAdapterNSProvider) makeCorrectionFunc(
 			ctx := context.TODO()
 			switch kind {
 			case happydns.CorrectionKindAddition:
-				_, err := p.appender.AppendRecords(ctx, zone, newRecs)
+				got, err := p.appender.AppendRecords(ctx, zone, newRecs)
+				traceLibdnsCall(trace, "AppendRecords", zone, newRecs, got)
 				return err
 			case happydns.CorrectionKindDeletion:
-				_, err := p.deleter.DeleteRecords(ctx, zone, oldRecs)
+				got, err := p.deleter.DeleteRecords(ctx, zone, oldRecs)
+				traceLibdnsCall(trace, "DeleteRecords", zone, oldRecs, got)
 				return err
 			case happydns.CorrectionKindUpdate:
-				if _, err := p.deleter.DeleteRecords(ctx, zone, oldRecs); err != nil {
+				got, err := p.deleter.DeleteRecords(ctx, zone, oldRecs)
+				traceLibdnsCall(trace, "DeleteRecords", zone, oldRecs, got)
+				if err != nil {
 					return fmt.Errorf("delete phase of update: %w", err)
 				}
-				_, err := p.appender.AppendRecords(ctx, zone, newRecs)
+				got, err = p.appender.AppendRecords(ctx, zone, newRecs)
+				traceLibdnsCall(trace, "AppendRecords", zone, newRecs, got)
 				if err != nil {
 					return fmt.Errorf("append phase of update: %w", err)
 				}
@@ -270,7 +310,8 @@ func (p *LibdnsAdapterNSProvider) makeCorrectionFunc(
 			case happydns.CorrectionKindAddition:
 				// SetRecords with the new records will add them to the zone
 				// for their (name, type) pair.
-				_, err := p.setter.SetRecords(ctx, zone, newRecs)
+				got, err := p.setter.SetRecords(ctx, zone, newRecs)
+				traceLibdnsCall(trace, "SetRecords", zone, newRecs, got)
 				return err
 			case happydns.CorrectionKindDeletion:
 				// To delete, we need to set the (name, type) pair to empty.
@@ -281,7 +322,8 @@ func (p *LibdnsAdapterNSProvider) makeCorrectionFunc(
 				return fmt.Errorf("cannot delete records: provider only supports SetRecords, not DeleteRecords")
 			case happydns.CorrectionKindUpdate:
 				// SetRecords replaces all records for the (name, type) pair.
-				_, err := p.setter.SetRecords(ctx, zone, newRecs)
+				got, err := p.setter.SetRecords(ctx, zone, newRecs)
+				traceLibdnsCall(trace, "SetRecords", zone, newRecs, got)
 				return err
 			}
 			return nil
@@ -318,6 +318,11 @@ func TestGetLibdnsProviderCapabilities(t *testing.T) {
 			t.Errorf("expected capability %s", expected)
 		}
 	}
+
+	// ALIAS is offered, flattened by happyDomain
+	if !slices.Contains(caps, AliasFlatteningCapability) {
+		t.Errorf("expected capability %s", AliasFlatteningCapability)
+	}
 }
 
 // TestLibdnsAdapterRefusesPseudoTypes covers the pseudo-types on the libdns
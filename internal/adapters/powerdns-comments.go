@@ -0,0 +1,247 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package adapter
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// powerdnsCommentAccount is the account the comments happyDomain writes are
+// stored under, telling them from those written by someone else.
+const powerdnsCommentAccount = "happyDomain"
+
+// powerDNSComments reads and writes the comments PowerDNS stores along each
+// RRset, through its API
+// (https://doc.powerdns.com/authoritative/http-api/zone.html). PowerDNS
+// comments a whole RRset: its records share their comment.
+type powerDNSComments struct {
+	baseURL string
+	server  string
+	apiKey  string
+	client  *http.Client
+}
+
+func newPowerDNSComments(config map[string]string) (commentStore, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: config["skipTLSVerify"] == "true",
+	}
+	if cert := config["cert"]; cert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(cert)) {
+			return nil, errors.New("PowerDNS: unable to read the certificate")
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	server := config["serverName"]
+	if server == "" {
+		server = "localhost"
+	}
+
+	return &powerDNSComments{
+		baseURL: strings.TrimSuffix(config["apiUrl"], "/"),
+		server:  server,
+		apiKey:  config["apiKey"],
+		client:  &http.Client{Timeout: 30 * time.Second, Transport: transport},
+	}, nil
+}
+
+type powerdnsComment struct {
+	Content string `json:"content"`
+	Account string `json:"account"`
+}
+
+type powerdnsRecord struct {
+	Content  string `json:"content"`
+	Disabled bool   `json:"disabled"`
+}
+
+// powerdnsRRset is an RRset as the PowerDNS API spells it.
+type powerdnsRRset struct {
+	Name       string            `json:"name"`
+	Type       string            `json:"type"`
+	TTL        uint32            `json:"ttl"`
+	ChangeType string            `json:"changetype,omitempty"`
+	Records    []powerdnsRecord  `json:"records"`
+	Comments   []powerdnsComment `json:"comments"`
+}
+
+// comment returns the comment of the RRset: the one happyDomain wrote, or
+// else the first one.
+func (rrset powerdnsRRset) comment() string {
+	for _, comment := range rrset.Comments {
+		if comment.Account == powerdnsCommentAccount {
+			return comment.Content
+		}
+	}
+	if len(rrset.Comments) > 0 {
+		return rrset.Comments[0].Content
+	}
+	return ""
+}
+
+// keys returns the RecordCommentKey of each record of the RRset happyDomain
+// can parse.
+func (rrset powerdnsRRset) keys() []string {
+	var keys []string
+	for _, record := range rrset.Records {
+		rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", rrset.Name, rrset.TTL, rrset.Type, record.Content))
+		if err != nil || rr == nil {
+			continue
+		}
+		keys = append(keys, happydns.RecordCommentKey(rr))
+	}
+	return keys
+}
+
+func (p *powerDNSComments) comments(domain string) (happydns.RecordComments, error) {
+	rrsets, err := p.rrsets(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := happydns.RecordComments{}
+	for _, rrset := range rrsets {
+		comment := rrset.comment()
+		for _, key := range rrset.keys() {
+			comments[key] = comment
+		}
+	}
+
+	return comments, nil
+}
+
+// setComments replaces the comment happyDomain wrote along each RRset
+// holding a record to comment, keeping the comments of other accounts. The
+// records of the RRset are sent back as they are.
+func (p *powerDNSComments) setComments(domain string, comments happydns.RecordComments) error {
+	rrsets, err := p.rrsets(domain)
+	if err != nil {
+		return err
+	}
+
+	var patch []powerdnsRRset
+	for _, rrset := range rrsets {
+		var comment string
+		var found bool
+		for _, key := range rrset.keys() {
+			if comment, found = comments[key]; found {
+				break
+			}
+		}
+		if !found || comment == rrset.comment() {
+			continue
+		}
+
+		kept := []powerdnsComment{{Content: comment, Account: powerdnsCommentAccount}}
+		for _, c := range rrset.Comments {
+			if c.Account != powerdnsCommentAccount {
+				kept = append(kept, c)
+			}
+		}
+
+		rrset.ChangeType = "REPLACE"
+		rrset.Comments = kept
+		patch = append(patch, rrset)
+	}
+
+	if len(patch) == 0 {
+		return nil
+	}
+
+	return p.call(http.MethodPatch, p.zonePath(domain), map[string]any{"rrsets": patch}, nil)
+}
+
+func (p *powerDNSComments) rrsets(domain string) ([]powerdnsRRset, error) {
+	var zone struct {
+		RRsets []powerdnsRRset `json:"rrsets"`
+	}
+	if err := p.call(http.MethodGet, p.zonePath(domain), nil, &zone); err != nil {
+		return nil, err
+	}
+
+	return zone.RRsets, nil
+}
+
+func (p *powerDNSComments) zonePath(domain string) string {
+	return fmt.Sprintf("/servers/%s/zones/%s", url.PathEscape(p.server), url.PathEscape(dns.Fqdn(domain)))
+}
+
+// call sends in, encoded as JSON, to the given path of the API, and decodes
+// the answer into out. Either may be nil.
+func (p *powerDNSComments) call(method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		raw, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequest(method, p.baseURL+"/api/v1"+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-API-Key", p.apiKey)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("PowerDNS: %s", apiErr.Error)
+		}
+		return fmt.Errorf("PowerDNS: %s", resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
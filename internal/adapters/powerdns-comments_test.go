@@ -0,0 +1,114 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package adapter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// fakePowerDNS serves the zone example.com of the server localhost.
+type fakePowerDNS struct {
+	rrsets []powerdnsRRset
+}
+
+func (f *fakePowerDNS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-API-Key") != "secret-key" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
+		return
+	}
+	if r.URL.Path != "/api/v1/servers/localhost/zones/example.com." {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Not Found"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(map[string]any{"rrsets": f.rrsets})
+	case http.MethodPatch:
+		var patch struct {
+			RRsets []powerdnsRRset `json:"rrsets"`
+		}
+		json.NewDecoder(r.Body).Decode(&patch)
+		for _, changed := range patch.RRsets {
+			for i, rrset := range f.rrsets {
+				if rrset.Name == changed.Name && rrset.Type == changed.Type && changed.ChangeType == "REPLACE" {
+					changed.ChangeType = ""
+					f.rrsets[i] = changed
+				}
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestPowerDNSComments(t *testing.T) {
+	fake := &fakePowerDNS{rrsets: []powerdnsRRset{
+		{Name: "www.example.com.", Type: "A", TTL: 300, Records: []powerdnsRecord{{Content: "192.0.2.1"}, {Content: "192.0.2.2"}}},
+		{Name: "example.com.", Type: "MX", TTL: 300, Records: []powerdnsRecord{{Content: "10 mail.example.com."}}, Comments: []powerdnsComment{{Content: "added by hand", Account: "admin"}}},
+	}}
+
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	store, err := newPowerDNSComments(map[string]string{"apiKey": "secret-key", "apiUrl": srv.URL + "/"})
+	if err != nil {
+		t.Fatalf("newPowerDNSComments() = %v", err)
+	}
+
+	www1, _ := dns.NewRR("www.example.com. 300 IN A 192.0.2.1")
+	www2, _ := dns.NewRR("www.example.com. 300 IN A 192.0.2.2")
+	mx, _ := dns.NewRR("example.com. 300 IN MX 10 mail.example.com.")
+
+	err = store.setComments("example.com", happydns.RecordComments{
+		happydns.RecordCommentKey(www1): happydns.FormatRecordComment("front"),
+		happydns.RecordCommentKey(mx):   happydns.FormatRecordComment(""),
+	})
+	if err != nil {
+		t.Fatalf("setComments() = %v", err)
+	}
+
+	comments, err := store.comments("example.com")
+	if err != nil {
+		t.Fatalf("comments() = %v", err)
+	}
+	if comments[happydns.RecordCommentKey(www2)] != "managed by happyDomain: front" {
+		t.Errorf("comments() = %v; want the records of the RRset sharing their comment", comments)
+	}
+	if comments[happydns.RecordCommentKey(mx)] != happydns.ManagedRecordMarker {
+		t.Errorf("comments() = %v; want the comment of happyDomain read first", comments)
+	}
+	if mxComments := fake.rrsets[1].Comments; len(mxComments) != 2 || mxComments[1].Account != "admin" {
+		t.Errorf("MX comments = %v; want the comment of the other account kept", mxComments)
+	}
+	if len(fake.rrsets[0].Records) != 2 {
+		t.Errorf("www records = %v; want them sent back as they are", fake.rrsets[0].Records)
+	}
+}
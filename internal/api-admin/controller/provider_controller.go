@@ -23,6 +23,7 @@ package controller
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -196,3 +197,25 @@ func (pc *ProviderController) ClearProviders(c *gin.Context) {
 
 	happydns.ApiResponse(c, true, pc.adminService.ClearProviders())
 }
+
+// ReencryptProviders seals the credentials of every provider under the current
+// master key.
+//
+//	@Summary		Re-encrypt provider credentials (admin)
+//	@Schemes
+//	@Description	Write every provider back to the database, so that its credentials get sealed under the current master key: those stored in clear before a key was configured, and those sealed under a previous key. Run it after setting or rotating -secret-key, before retiring the previous key.
+//	@Tags			admin-providers
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	map[string]int	"Number of providers rewritten"
+//	@Failure		500	{object}	happydns.ErrorResponse	"Some providers could not be rewritten"
+//	@Router			/providers/reencrypt [post]
+func (pc *ProviderController) ReencryptProviders(c *gin.Context) {
+	done, err := pc.adminService.ReencryptProviders()
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, fmt.Errorf("%d providers re-encrypted, but: %w", done, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]int{"reencrypted": done})
+}
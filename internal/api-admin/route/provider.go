@@ -48,3 +48,12 @@ func declareProviderRoutes(router *gin.RouterGroup, dep Dependencies) {
 
 	declareDomainRoutes(apiProvidersRoutes, dep)
 }
+
+// declareProvidersMaintenanceRoutes declares the routes acting on every
+// provider at once, whoever owns it: unlike those of declareProviderRoutes,
+// they have no meaning under /users/:uid.
+func declareProvidersMaintenanceRoutes(router *gin.RouterGroup, dep Dependencies) {
+	pc := controller.NewProviderController(dep.Provider, dep.AdminProvider)
+
+	router.POST("/providers/reencrypt", pc.ReencryptProviders)
+}
@@ -70,6 +70,7 @@ func DeclareRoutes(cfg *happydns.Options, router *gin.Engine, dep Dependencies)
 	declareCheckersRoutes(apiRoutes, dep)
 	declareDomainRoutes(apiRoutes, dep)
 	declareProviderRoutes(apiRoutes, dep)
+	declareProvidersMaintenanceRoutes(apiRoutes, dep)
 	declareSchedulerRoutes(apiRoutes, dep)
 	declareSessionsRoutes(cfg, apiRoutes, dep)
 	declareUserAuthsRoutes(apiRoutes, dep)
@@ -0,0 +1,197 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"git.happydns.org/happyDomain/internal/api/middleware"
+	"git.happydns.org/happyDomain/model"
+)
+
+type DKIMController struct {
+	dkimService happydns.DKIMUsecase
+}
+
+func NewDKIMController(dkimService happydns.DKIMUsecase) *DKIMController {
+	return &DKIMController{
+		dkimService: dkimService,
+	}
+}
+
+// GetDKIMPolicy retrieves how DKIM keys are generated and rotated for the domain.
+//
+//	@Summary	Get the DKIM rotation policy.
+//	@Schemes
+//	@Description	Retrieve the settings used to generate and rotate the DKIM keys of the domain.
+//	@Tags			dkim
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.DKIMPolicy
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/dkim [get]
+func (dc *DKIMController) GetDKIMPolicy(c *gin.Context) {
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	policy, err := dc.dkimService.GetPolicy(domain)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy.Redacted())
+}
+
+// SetDKIMPolicy updates how DKIM keys are generated and rotated for the domain.
+//
+//	@Summary	Update the DKIM rotation policy.
+//	@Schemes
+//	@Description	Define the algorithm, selector prefix and rotation periods of the DKIM keys of the domain. Leave the webhook secret empty to keep the current one.
+//	@Tags			dkim
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string				true	"Domain identifier"
+//	@Param			body		body	happydns.DKIMPolicy	true	"The new policy"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.DKIMPolicy
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid input"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/dkim [put]
+func (dc *DKIMController) SetDKIMPolicy(c *gin.Context) {
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	var policy happydns.DKIMPolicy
+	err := c.ShouldBindJSON(&policy)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: fmt.Sprintf("Something is wrong in received data: %s", err.Error())})
+		return
+	}
+
+	saved, err := dc.dkimService.SetPolicy(domain, &policy)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, saved.Redacted())
+}
+
+// ListDKIMKeys lists the DKIM keys generated for the domain.
+//
+//	@Summary	List DKIM keys.
+//	@Schemes
+//	@Description	List the DKIM keys generated for the domain, newest first. Private keys are not included.
+//	@Tags			dkim
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{array}		happydns.DKIMKey
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/dkim/keys [get]
+func (dc *DKIMController) ListDKIMKeys(c *gin.Context) {
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	keys, err := dc.dkimService.ListKeys(domain)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	ret := make([]*happydns.DKIMKey, 0, len(keys))
+	for _, k := range keys {
+		ret = append(ret, k.Redacted())
+	}
+
+	c.JSON(http.StatusOK, ret)
+}
+
+// GenerateDKIMKey generates a new DKIM key for the domain.
+//
+//	@Summary	Generate a DKIM key.
+//	@Schemes
+//	@Description	Generate a new DKIM key following the domain policy and add its selector to the current zone. The key is pending until its selector is published: it then becomes active and the previous key is retired. The selector is published right away when the policy publishes on its own.
+//	@Tags			dkim
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.DKIMKey
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid input"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/dkim/keys [post]
+func (dc *DKIMController) GenerateDKIMKey(c *gin.Context) {
+	user := middleware.MyUser(c)
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	key, err := dc.dkimService.GenerateKey(c.Request.Context(), user, domain)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, key.Redacted())
+}
+
+// ExportDKIMPrivateKey retrieves the private part of a DKIM key.
+//
+//	@Summary	Export a DKIM private key.
+//	@Schemes
+//	@Description	Retrieve the PEM-encoded private key, to be installed on the MTA.
+//	@Tags			dkim
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Param			keyId		path	string	true	"Key identifier"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.DKIMPrivateKey
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid input"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain or key not found"
+//	@Router			/domains/{domainId}/dkim/keys/{keyId}/private [get]
+func (dc *DKIMController) ExportDKIMPrivateKey(c *gin.Context) {
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	keyId, err := happydns.NewIdentifierFromString(c.Param("keyId"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: fmt.Sprintf("Invalid key identifier: %s", err.Error())})
+		return
+	}
+
+	priv, err := dc.dkimService.ExportPrivateKey(domain, keyId)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, priv)
+}
@@ -0,0 +1,271 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controller
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"git.happydns.org/happyDomain/internal/api/middleware"
+	"git.happydns.org/happyDomain/model"
+)
+
+// maxReportUploadSize bounds the body of a report upload. Aggregate reports
+// are sent compressed, so legitimate ones stay far below.
+const maxReportUploadSize = 16 << 20
+
+type DMARCReportController struct {
+	dmarcService happydns.DMARCReportUsecase
+}
+
+func NewDMARCReportController(dmarcService happydns.DMARCReportUsecase) *DMARCReportController {
+	return &DMARCReportController{
+		dmarcService: dmarcService,
+	}
+}
+
+// readReportBody reads the raw report posted as request body.
+func readReportBody(c *gin.Context) ([]byte, bool) {
+	raw, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxReportUploadSize))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: fmt.Sprintf("Unable to read the report: %s", err.Error())})
+		return nil, false
+	}
+	if len(raw) == 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: "The request body should hold the report."})
+		return nil, false
+	}
+	return raw, true
+}
+
+// reportsSince parses the days query parameter into the start of the period
+// to consider, 30 days by default.
+func reportsSince(c *gin.Context) (time.Time, bool) {
+	days := 30
+	if d := c.Query("days"); d != "" {
+		var err error
+		days, err = strconv.Atoi(d)
+		if err != nil || days <= 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: "days should be a positive number."})
+			return time.Time{}, false
+		}
+	}
+	return time.Now().AddDate(0, 0, -days), true
+}
+
+// UploadDMARCReport ingests an aggregate report for the domain.
+//
+//	@Summary	Upload a DMARC aggregate report.
+//	@Schemes
+//	@Description	Ingest an RFC 7489 aggregate report, as XML, gzip or zip, sent as request body. The report must be about the domain or one of its subdomains. Uploading the same report twice is harmless.
+//	@Tags			dmarc
+//	@Accept			application/xml,application/gzip,application/zip
+//	@Produce		json
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.DMARCReport
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid report"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/dmarc/reports [post]
+func (dc *DMARCReportController) UploadDMARCReport(c *gin.Context) {
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	raw, ok := readReportBody(c)
+	if !ok {
+		return
+	}
+
+	report, err := dc.dmarcService.IngestForDomain(c.Request.Context(), domain, raw)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// IngestDMARCReport ingests an aggregate report for the domains it was sent
+// to the report address of.
+//
+//	@Summary	Ingest a DMARC aggregate report.
+//	@Schemes
+//	@Description	Ingest an RFC 7489 aggregate report, as XML, gzip or zip, sent as request body, for the domains whose report address is among the recipients of the message. Meant for the mail server receiving the rua= reports; requires the bearer token set in the reports-ingest-token option.
+//	@Tags			dmarc
+//	@Accept			application/xml,application/gzip,application/zip
+//	@Produce		json
+//	@Param			Authorization	header	string	true	"Bearer token"
+//	@Param			to				query	[]string	true	"Recipients of the message carrying the report"	collectionFormat(multi)
+//	@Success		200	{array}		happydns.DMARCReport
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid report or unknown domain"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Router			/reports/dmarc [post]
+func (dc *DMARCReportController) IngestDMARCReport(c *gin.Context) {
+	raw, ok := readReportBody(c)
+	if !ok {
+		return
+	}
+
+	reports, err := dc.dmarcService.Ingest(c.Request.Context(), c.QueryArray("to"), raw)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reports)
+}
+
+// ListDMARCReports lists the aggregate reports received for the domain.
+//
+//	@Summary	List DMARC aggregate reports.
+//	@Schemes
+//	@Description	List the aggregate reports received for the domain over the last days, newest first.
+//	@Tags			dmarc
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Param			days		query	int		false	"Number of days to cover (default 30)"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{array}		happydns.DMARCReport
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid input"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/dmarc/reports [get]
+func (dc *DMARCReportController) ListDMARCReports(c *gin.Context) {
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	since, ok := reportsSince(c)
+	if !ok {
+		return
+	}
+
+	reports, err := dc.dmarcService.ListReports(domain, since)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	if reports == nil {
+		reports = []*happydns.DMARCReport{}
+	}
+
+	c.JSON(http.StatusOK, reports)
+}
+
+// GetDMARCSummary aggregates the reports received for the domain.
+//
+//	@Summary	Summarize DMARC aggregate reports.
+//	@Schemes
+//	@Description	Aggregate per source IP the volume and the DKIM/SPF alignment reported for the domain over the last days.
+//	@Tags			dmarc
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Param			days		query	int		false	"Number of days to cover (default 30)"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.DMARCSummary
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid input"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/dmarc/summary [get]
+func (dc *DMARCReportController) GetDMARCSummary(c *gin.Context) {
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	since, ok := reportsSince(c)
+	if !ok {
+		return
+	}
+
+	summary, err := dc.dmarcService.Summary(domain, since)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetDMARCReportSettings retrieves the report settings of the domain.
+//
+//	@Summary	Get the DMARC report settings.
+//	@Schemes
+//	@Description	Retrieve the known sources and the notifications raised by incoming reports.
+//	@Tags			dmarc
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.DMARCReportSettings
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/dmarc/settings [get]
+func (dc *DMARCReportController) GetDMARCReportSettings(c *gin.Context) {
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	settings, err := dc.dmarcService.GetSettings(domain)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// SetDMARCReportSettings updates the report settings of the domain.
+//
+//	@Summary	Update the DMARC report settings.
+//	@Schemes
+//	@Description	Define the IP addresses or CIDR blocks of the legitimate senders and which notifications incoming reports raise.
+//	@Tags			dmarc
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string							true	"Domain identifier"
+//	@Param			body		body	happydns.DMARCReportSettings	true	"The new settings"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.DMARCReportSettings
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid input"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/dmarc/settings [put]
+func (dc *DMARCReportController) SetDMARCReportSettings(c *gin.Context) {
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	var settings happydns.DMARCReportSettings
+	err := c.ShouldBindJSON(&settings)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: fmt.Sprintf("Something is wrong in received data: %s", err.Error())})
+		return
+	}
+
+	saved, err := dc.dmarcService.SetSettings(domain, &settings)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
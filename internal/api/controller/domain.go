@@ -195,8 +195,19 @@ func (dc *DomainController) UpdateDomain(c *gin.Context) {
 		return
 	}
 
+	if domain.SPFFlattening != nil && !domain.SPFFlattening.Valid() {
+		middleware.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("unknown SPF flattening mode %q", *domain.SPFFlattening))
+		return
+	}
+
 	err = dc.domainService.UpdateDomain(old.Id, user, func(new *happydns.Domain) {
 		new.Group = domain.Group
+		if domain.SPFFlattening != nil {
+			new.SPFFlattening = *domain.SPFFlattening
+		}
+		if domain.RegistrarId != nil {
+			new.RegistrarId = *domain.RegistrarId
+		}
 	})
 	if err != nil {
 		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
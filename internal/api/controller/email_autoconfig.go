@@ -24,6 +24,7 @@ package controller
 import (
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
@@ -35,8 +36,10 @@ import (
 )
 
 // EmailAutoconfigController serves the public mail-client auto-configuration
-// endpoints used by Thunderbird (Mozilla Autoconfig) and Outlook (Microsoft
-// Autodiscover), plus the Caddy on-demand TLS validation hook.
+// endpoints used by Thunderbird (Mozilla Autoconfig), Outlook (Microsoft
+// Autodiscover) and Apple devices (configuration profiles), the MTA-STS
+// policy files and the OpenPGP Web Key Directory, plus the Caddy on-demand
+// TLS validation hook.
 type EmailAutoconfigController struct {
 	uc happydns.EmailAutoconfigUsecase
 }
@@ -151,13 +154,192 @@ func (ec *EmailAutoconfigController) MSAutodiscover(c *gin.Context) {
 	c.Data(http.StatusOK, "application/xml; charset=utf-8", body)
 }
 
+// msAutodiscoverError is the error body of the Autodiscover v2 endpoint.
+type msAutodiscoverError struct {
+	ErrorCode    string `json:"ErrorCode"`
+	ErrorMessage string `json:"ErrorMessage"`
+}
+
+// MSAutodiscoverJSON serves the Autodiscover v2 JSON format modern Outlook
+// tries before the POX one. The email address comes either from the path
+// (/autodiscover/autodiscover.json/v1.0/{email}) or from the Email query
+// parameter.
+//
+//	@Summary	Mail-client auto-configuration (Microsoft Autodiscover v2)
+//	@Description	Returns the URL of the service speaking the requested protocol for the domain of the email address.
+//	@Tags			email-autoconfig
+//	@Produce		json
+//	@Param			email		path	string	true	"Email address"
+//	@Param			Protocol	query	string	true	"Requested protocol (AutodiscoverV1, Ews, ActiveSync)"
+//	@Success		200	{string}	string
+//	@Failure		400	{object}	happydns.ErrorResponse
+//	@Failure		404	{object}	happydns.ErrorResponse
+//	@Router			/autodiscover/autodiscover.json/v1.0/{email} [get]
+func (ec *EmailAutoconfigController) MSAutodiscoverJSON(c *gin.Context) {
+	emailAddress := c.Param("email")
+	if emailAddress == "" {
+		emailAddress = c.Query("Email")
+	}
+
+	domain := resolveDomain(c)
+	if at := strings.LastIndex(emailAddress, "@"); at >= 0 {
+		domain = emailAddress[at+1:]
+	}
+	if domain == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: "missing domain"})
+		return
+	}
+
+	body, err := ec.uc.AutodiscoverJSON(dns.Fqdn(domain), c.Query("Protocol"))
+	if err != nil {
+		var verr happydns.ValidationError
+		if errors.As(err, &verr) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, msAutodiscoverError{ErrorCode: "InvalidProtocol", ErrorMessage: verr.Msg})
+			return
+		}
+		if errors.Is(err, happydns.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, happydns.ErrorResponse{Message: "no auto-configuration found for this domain"})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, happydns.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// AppleMobileConfig serves an Apple configuration profile that iOS and
+// macOS install to set up the mail account, and the calendar and contacts
+// accounts when the zone announces CalDAV or CardDAV servers.
+//
+//	@Summary	Mail-client auto-configuration (Apple configuration profile)
+//	@Description	Returns a .mobileconfig profile for the requested domain, signed when happyDomain holds a signing certificate.
+//	@Tags			email-autoconfig
+//	@Produce		application/x-apple-aspen-config
+//	@Param			emailaddress	query	string	false	"Email address (used to derive the domain and fill the username)"
+//	@Success		200	{string}	string
+//	@Failure		404	{object}	happydns.ErrorResponse
+//	@Router			/mail/config.mobileconfig [get]
+func (ec *EmailAutoconfigController) AppleMobileConfig(c *gin.Context) {
+	domain := resolveDomain(c, "emailaddress")
+	if domain == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: "missing domain"})
+		return
+	}
+
+	body, err := ec.uc.MobileConfig(dns.Fqdn(domain), c.Query("emailaddress"))
+	if err != nil {
+		if errors.Is(err, happydns.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, happydns.ErrorResponse{Message: "no auto-configuration found for this domain"})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, happydns.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(domain), "autoconfig."), "autodiscover.")+".mobileconfig"))
+	c.Data(http.StatusOK, "application/x-apple-aspen-config", body)
+}
+
+// MTASTSPolicy serves the MTA-STS policy file of the domain named by the
+// Host header.
+//
+//	@Summary	MTA-STS policy file
+//	@Description	Returns the RFC 8461 policy file of the domain, as hosted by happyDomain, when requested on mta-sts.<domain>.
+//	@Tags			email-autoconfig
+//	@Produce		text/plain
+//	@Success		200	{string}	string
+//	@Failure		404	{object}	happydns.ErrorResponse
+//	@Router			/.well-known/mta-sts.txt [get]
+func (ec *EmailAutoconfigController) MTASTSPolicy(c *gin.Context) {
+	host := resolveDomain(c)
+	if !strings.HasPrefix(strings.ToLower(host), "mta-sts.") {
+		c.AbortWithStatusJSON(http.StatusNotFound, happydns.ErrorResponse{Message: "no MTA-STS policy found for this domain"})
+		return
+	}
+
+	body, err := ec.uc.MTASTSPolicy(dns.Fqdn(strings.ToLower(host)))
+	if err != nil {
+		if errors.Is(err, happydns.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, happydns.ErrorResponse{Message: "no MTA-STS policy found for this domain"})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, happydns.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", body)
+}
+
+// wkdDomain returns the mail domain of a Web Key Directory request: the one
+// in the path for the advanced method, the Host header for the direct one.
+func wkdDomain(c *gin.Context) string {
+	if domain := c.Param("domain"); domain != "" {
+		return strings.ToLower(domain)
+	}
+	return strings.ToLower(resolveDomain(c))
+}
+
+// WKDPolicy serves the policy file of the OpenPGP Web Key Directory.
+//
+//	@Summary	OpenPGP Web Key Directory policy
+//	@Description	Returns the (empty) policy file of the Web Key Directory of the domain, when happyDomain publishes OpenPGP keys for it. Served on both the advanced (openpgpkey.<domain>) and the direct method paths.
+//	@Tags			email-autoconfig
+//	@Produce		text/plain
+//	@Param			domain	path	string	true	"Mail domain"
+//	@Success		200	{string}	string
+//	@Failure		404	{object}	happydns.ErrorResponse
+//	@Router			/.well-known/openpgpkey/{domain}/policy [get]
+func (ec *EmailAutoconfigController) WKDPolicy(c *gin.Context) {
+	body, err := ec.uc.WKDPolicy(dns.Fqdn(wkdDomain(c)))
+	if err != nil {
+		if errors.Is(err, happydns.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, happydns.ErrorResponse{Message: "no OpenPGP key published for this domain"})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, happydns.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	c.Header("Access-Control-Allow-Origin", "*")
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", body)
+}
+
+// WKDKey serves an OpenPGP key of the Web Key Directory.
+//
+//	@Summary	OpenPGP Web Key Directory key
+//	@Description	Returns the binary OpenPGP key of the address whose local-part hashes to the given value, from the OpenPGP services of the domain. Served on both the advanced (openpgpkey.<domain>) and the direct method paths.
+//	@Tags			email-autoconfig
+//	@Produce		application/octet-stream
+//	@Param			domain	path	string	true	"Mail domain"
+//	@Param			hash	path	string	true	"z-base-32 encoded SHA-1 of the lowercased local-part"
+//	@Param			l		query	string	false	"Local-part of the address"
+//	@Success		200	{string}	string
+//	@Failure		404	{object}	happydns.ErrorResponse
+//	@Router			/.well-known/openpgpkey/{domain}/hu/{hash} [get]
+func (ec *EmailAutoconfigController) WKDKey(c *gin.Context) {
+	body, err := ec.uc.WKDKey(dns.Fqdn(wkdDomain(c)), c.Param("hash"), c.Query("l"))
+	if err != nil {
+		if errors.Is(err, happydns.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, happydns.ErrorResponse{Message: "no OpenPGP key found for this address"})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, happydns.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	c.Header("Access-Control-Allow-Origin", "*")
+	c.Data(http.StatusOK, "application/octet-stream", body)
+}
+
 // CaddyAsk implements the Caddy on-demand TLS "ask" endpoint. Caddy treats
 // any 2xx response as "go ahead and issue the cert" and any other status as
-// "deny". The endpoint is scoped strictly to autoconfig./autodiscover.
-// subdomains so happyDomain never authorises certs for arbitrary domains.
+// "deny". The endpoint is scoped strictly to autoconfig./autodiscover./
+// mta-sts./openpgpkey. subdomains so happyDomain never authorises certs for
+// arbitrary domains.
 //
 //	@Summary	Caddy on-demand TLS validation
-//	@Description	Returns 200 when happyDomain hosts the email auto-configuration for the requested domain.
+//	@Description	Returns 200 when happyDomain hosts the email auto-configuration, the MTA-STS policy or the Web Key Directory for the requested domain.
 //	@Tags			email-autoconfig
 //	@Param			domain	query	string	true	"FQDN Caddy is about to obtain a certificate for"
 //	@Success		200
@@ -0,0 +1,81 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"git.happydns.org/happyDomain/internal/api/middleware"
+	"git.happydns.org/happyDomain/model"
+)
+
+type EmailKeysController struct {
+	emailKeysService happydns.EmailKeysUsecase
+}
+
+func NewEmailKeysController(emailKeysService happydns.EmailKeysUsecase) *EmailKeysController {
+	return &EmailKeysController{
+		emailKeysService: emailKeysService,
+	}
+}
+
+// PublishEmailKeys publishes uploaded OpenPGP keys and S/MIME certificates.
+//
+//	@Summary	Publish email keys.
+//	@Schemes
+//	@Description	Read the ASCII-armored OpenPGP public keys and PEM-encoded S/MIME certificates given, and add an OpenPGP (OPENPGPKEY) or S/MIME (SMIMEA) service to the subdomain for each of its addresses they hold, the owner names being derived from the local-parts. A key already published for an address is replaced.
+//	@Tags			zones
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string					true	"Domain identifier"
+//	@Param			zoneId		path	string					true	"Zone identifier"
+//	@Param			subdomain	path	string					true	"Part of the subdomain considered for the service (@ for the root of the zone ; subdomain is relative to the root, do not include it)"
+//	@Param			body		body	happydns.EmailKeysInput	true	"Keys to publish"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.EmailKeysPublication
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid keys, or no address of the subdomain"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain or zone not found"
+//	@Router			/domains/{domainId}/zone/{zoneId}/{subdomain}/email-keys [post]
+func (ec *EmailKeysController) PublishEmailKeys(c *gin.Context) {
+	user := middleware.MyUser(c)
+	domain := c.MustGet("domain").(*happydns.Domain)
+	zone := c.MustGet("zone").(*happydns.Zone)
+	subdomain := c.MustGet("subdomain").(happydns.Subdomain)
+
+	var input happydns.EmailKeysInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: fmt.Sprintf("Something is wrong in received data: %s", err.Error())})
+		return
+	}
+
+	publication, err := ec.emailKeysService.PublishKeys(user, domain, zone, subdomain, &input)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, publication)
+}
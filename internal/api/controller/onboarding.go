@@ -0,0 +1,105 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controller
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"git.happydns.org/happyDomain/internal/api/middleware"
+	"git.happydns.org/happyDomain/model"
+)
+
+type OnboardingController struct {
+	onboardingService happydns.OnboardingUsecase
+}
+
+func NewOnboardingController(onboardingService happydns.OnboardingUsecase) *OnboardingController {
+	return &OnboardingController{
+		onboardingService: onboardingService,
+	}
+}
+
+// GetOnboarding returns the progress of the last onboarding of the zones of the provider.
+//
+//	@Summary	Get the zones onboarding progress.
+//	@Schemes
+//	@Description	Return the progress of the last onboarding of the zones hosted by the provider, zone by zone.
+//	@Tags			providers
+//	@Accept			json
+//	@Produce		json
+//	@Param			providerId	path	string	true	"Provider identifier"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.Onboarding
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Provider not found, or no zone onboarded from it"
+//	@Router			/providers/{providerId}/onboarding [get]
+func (oc *OnboardingController) GetOnboarding(c *gin.Context) {
+	user := middleware.MyUser(c)
+	provider := c.MustGet("provider").(*happydns.Provider)
+
+	onboarding, err := oc.onboardingService.Get(user, provider)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, onboarding)
+}
+
+// StartOnboarding adds the zones hosted by the provider as domains.
+//
+//	@Summary	Onboard the zones of the provider.
+//	@Schemes
+//	@Description	Add all or the selected zones hosted by the provider as domains, and import their records. The zones are handled in the background: follow the progress with a GET on the same route.
+//	@Tags			providers
+//	@Accept			json
+//	@Produce		json
+//	@Param			providerId	path	string					true	"Provider identifier"
+//	@Param			body		body	happydns.OnboardingForm	false	"The zones to onboard, all of them when empty"
+//	@Security		securitydefinitions.basic
+//	@Success		202	{object}	happydns.Onboarding
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid input, the provider can't list its zones, or an onboarding is in progress"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Provider not found"
+//	@Router			/providers/{providerId}/onboarding [post]
+func (oc *OnboardingController) StartOnboarding(c *gin.Context) {
+	user := middleware.MyUser(c)
+	provider := c.MustGet("provider").(*happydns.Provider)
+
+	var form happydns.OnboardingForm
+	if err := c.ShouldBindJSON(&form); err != nil && err != io.EOF {
+		c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: fmt.Sprintf("Something is wrong in received data: %s", err.Error())})
+		return
+	}
+
+	onboarding, err := oc.onboardingService.Start(c.Request.Context(), user, provider, &form)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, onboarding)
+}
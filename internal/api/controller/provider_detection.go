@@ -0,0 +1,73 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"git.happydns.org/happyDomain/internal/api/middleware"
+	"git.happydns.org/happyDomain/model"
+)
+
+type ProviderDetectionController struct {
+	detectionService happydns.ProviderDetectionUsecase
+}
+
+func NewProviderDetectionController(detectionService happydns.ProviderDetectionUsecase) *ProviderDetectionController {
+	return &ProviderDetectionController{
+		detectionService: detectionService,
+	}
+}
+
+// DetectProvider guesses the provider hosting a domain from its name servers.
+//
+//	@Summary	Detect the provider of a domain.
+//	@Schemes
+//	@Description	Resolve the NS and SOA of the domain and suggest the provider types serving zones from these name servers, along with the matching providers of the user.
+//	@Tags			providers
+//	@Accept			json
+//	@Produce		json
+//	@Param			domain	query	string	true	"Domain name"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.ProviderDetection
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid domain name, or the domain is not delegated"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Router			/providers/detect [get]
+func (dc *ProviderDetectionController) DetectProvider(c *gin.Context) {
+	user := middleware.MyUser(c)
+
+	domain := c.Query("domain")
+	if domain == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: "The domain to detect the provider of is missing."})
+		return
+	}
+
+	detection, err := dc.detectionService.Detect(c.Request.Context(), user, domain)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, detection)
+}
@@ -0,0 +1,80 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"git.happydns.org/happyDomain/internal/api/middleware"
+	"git.happydns.org/happyDomain/model"
+)
+
+type ProviderJournalController struct {
+	journalService happydns.ProviderJournalUsecase
+}
+
+func NewProviderJournalController(journalService happydns.ProviderJournalUsecase) *ProviderJournalController {
+	return &ProviderJournalController{
+		journalService: journalService,
+	}
+}
+
+// ListCalls returns the corrections executed against the provider while
+// publishing the domain.
+//
+//	@Summary	List the provider calls of the domain.
+//	@Schemes
+//	@Description	Return the corrections executed against the provider while publishing the domain, the most recent first, with their timing, outcome and, when the provider adapter supplies them, the request and response, secrets redacted. Only the providers having their journal enabled record them.
+//	@Tags			domains
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Param			snapshot	query	string	false	"Only return the calls of the publication that created this snapshot"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	[]happydns.ProviderCall
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid snapshot identifier"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/journal [get]
+func (jc *ProviderJournalController) ListCalls(c *gin.Context) {
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	var snapshotId happydns.Identifier
+	if snapshot := c.Query("snapshot"); snapshot != "" {
+		var err error
+		snapshotId, err = happydns.NewIdentifierFromString(snapshot)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: fmt.Sprintf("Invalid snapshot identifier: %s", err)})
+			return
+		}
+	}
+
+	calls, err := jc.journalService.ListDomainCalls(c.Request.Context(), domain, snapshotId)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, calls)
+}
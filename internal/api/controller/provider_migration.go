@@ -0,0 +1,168 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"git.happydns.org/happyDomain/internal/api/middleware"
+	"git.happydns.org/happyDomain/model"
+)
+
+type ProviderMigrationController struct {
+	migrationService happydns.ProviderMigrationUsecase
+}
+
+func NewProviderMigrationController(migrationService happydns.ProviderMigrationUsecase) *ProviderMigrationController {
+	return &ProviderMigrationController{
+		migrationService: migrationService,
+	}
+}
+
+// GetMigration returns the state of the last provider migration of the domain.
+//
+//	@Summary	Get the provider migration.
+//	@Schemes
+//	@Description	Return the state of the last migration of the domain to another provider, as recorded in the domain log.
+//	@Tags			migration
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.ProviderMigration
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found, or never migrated"
+//	@Router			/domains/{domainId}/migration [get]
+func (mc *ProviderMigrationController) GetMigration(c *gin.Context) {
+	user := middleware.MyUser(c)
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	migration, err := mc.migrationService.Get(user, domain)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, migration)
+}
+
+// StartMigration begins moving the domain to another provider.
+//
+//	@Summary	Start a provider migration.
+//	@Schemes
+//	@Description	Start moving the domain to another provider of the user, and copy its last published zone there.
+//	@Tags			migration
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string							true	"Domain identifier"
+//	@Param			body		body	happydns.ProviderMigrationForm	true	"The provider to move to"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.ProviderMigration
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid input, or a migration is in progress"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain or provider not found"
+//	@Router			/domains/{domainId}/migration [post]
+func (mc *ProviderMigrationController) StartMigration(c *gin.Context) {
+	user := middleware.MyUser(c)
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	var form happydns.ProviderMigrationForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: fmt.Sprintf("Something is wrong in received data: %s", err.Error())})
+		return
+	}
+
+	migration, err := mc.migrationService.Start(c.Request.Context(), user, domain, &form)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, migration)
+}
+
+// ContinueMigration runs the next step of the provider migration of the domain.
+//
+//	@Summary	Continue a provider migration.
+//	@Schemes
+//	@Description	Run the next step of the migration in progress, or run it again from an earlier step.
+//	@Tags			migration
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string									true	"Domain identifier"
+//	@Param			body		body	happydns.ProviderMigrationContinueForm	false	"The step to run again"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.ProviderMigration
+//	@Failure		400	{object}	happydns.ErrorResponse	"No migration in progress, or the step cannot be run yet"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/migration/continue [post]
+func (mc *ProviderMigrationController) ContinueMigration(c *gin.Context) {
+	user := middleware.MyUser(c)
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	var form happydns.ProviderMigrationContinueForm
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&form); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: fmt.Sprintf("Something is wrong in received data: %s", err.Error())})
+			return
+		}
+	}
+
+	migration, err := mc.migrationService.Continue(c.Request.Context(), user, domain, &form)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, migration)
+}
+
+// AbortMigration gives the provider migration of the domain up.
+//
+//	@Summary	Abort a provider migration.
+//	@Schemes
+//	@Description	Give the migration in progress up. Before the switch, the TTLs lowered at the former provider are restored.
+//	@Tags			migration
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.ProviderMigration
+//	@Failure		400	{object}	happydns.ErrorResponse	"No migration in progress"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/migration [delete]
+func (mc *ProviderMigrationController) AbortMigration(c *gin.Context) {
+	user := middleware.MyUser(c)
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	migration, err := mc.migrationService.Abort(c.Request.Context(), user, domain)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, migration)
+}
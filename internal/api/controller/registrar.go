@@ -0,0 +1,103 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"git.happydns.org/happyDomain/internal/api/middleware"
+	"git.happydns.org/happyDomain/model"
+)
+
+type RegistrarController struct {
+	registrarService happydns.RegistrarUsecase
+}
+
+func NewRegistrarController(registrarService happydns.RegistrarUsecase) *RegistrarController {
+	return &RegistrarController{
+		registrarService: registrarService,
+	}
+}
+
+// GetRegistration returns the delegation and the registration settings of the domain.
+//
+//	@Summary	Get the registration of the domain.
+//	@Schemes
+//	@Description	Return the name servers and DS records the domain is delegated with, its transfer lock and automatic renewal, along with the changes its registrar can make. When the provider of the domain is not a registrar able to tell, they are looked up with RDAP and the DNS.
+//	@Tags			registrar
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.Registration
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/registrar [get]
+func (rc *RegistrarController) GetRegistration(c *gin.Context) {
+	user := middleware.MyUser(c)
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	reg, err := rc.registrarService.GetRegistration(c.Request.Context(), user, domain)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reg)
+}
+
+// UpdateRegistration changes the delegation or the registration settings of the domain.
+//
+//	@Summary	Update the registration of the domain.
+//	@Schemes
+//	@Description	Change, through the registrar of the domain, the fields given: name servers, DS records, transfer lock or automatic renewal. Each change is written to the domain log.
+//	@Tags			registrar
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string					true	"Domain identifier"
+//	@Param			body		body	happydns.RegistrarForm	true	"The changes to make"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.Registration
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid input, or a change the registrar cannot make"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/registrar [put]
+func (rc *RegistrarController) UpdateRegistration(c *gin.Context) {
+	user := middleware.MyUser(c)
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	var form happydns.RegistrarForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: fmt.Sprintf("Something is wrong in received data: %s", err.Error())})
+		return
+	}
+
+	reg, err := rc.registrarService.UpdateRegistration(c.Request.Context(), user, domain, &form)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reg)
+}
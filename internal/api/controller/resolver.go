@@ -106,6 +106,36 @@ func (rc *ResolverController) FlattenSPF(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// ExpandSPF rewrites an SPF record into its flattened form.
+//
+//	@Summary	Flatten an SPF record into addresses.
+//	@Schemes
+//	@Description	Rewrite an SPF record (taken either from a TXT lookup at the supplied domain or from an inline override), replacing its includes and its a/mx mechanisms by the ip4/ip6 terms they currently stand for.
+//	@Tags			resolver
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		happydns.SPFExpandRequest	true	"SPF expand request"
+//	@Success		200		{object}	happydns.SPFExpandResponse
+//	@Failure		400		{object}	happydns.ErrorResponse	"Invalid input"
+//	@Failure		500		{object}	happydns.ErrorResponse
+//	@Router			/resolver/spf-expand [post]
+func (rc *ResolverController) ExpandSPF(c *gin.Context) {
+	var req happydns.SPFExpandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("%s sends invalid SPFExpandRequest JSON: %s", c.ClientIP(), err.Error())
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"errmsg": fmt.Sprintf("Something is wrong in received data: %s", err.Error())})
+		return
+	}
+
+	resp, err := rc.resolverService.ExpandSPF(req)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 // FetchMTASTSPolicy retrieves and parses the MTA-STS policy file at
 // https://mta-sts.<domain>/.well-known/mta-sts.txt.
 //
@@ -0,0 +1,200 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"git.happydns.org/happyDomain/internal/api/middleware"
+	"git.happydns.org/happyDomain/model"
+)
+
+type ReverseDNSController struct {
+	reverseDNSService happydns.ReverseDNSUsecase
+}
+
+func NewReverseDNSController(reverseDNSService happydns.ReverseDNSUsecase) *ReverseDNSController {
+	return &ReverseDNSController{
+		reverseDNSService: reverseDNSService,
+	}
+}
+
+// GetReverseDNSPlan lists the PTR records to create or update for the servers of the domain.
+//
+//	@Summary	List the PTR records to synchronize.
+//	@Schemes
+//	@Description	List, for each server address of the domain whose reverse zone is also managed by the user, the PTR record to create or update so that it points back at the server.
+//	@Tags			reverse-dns
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{array}		happydns.ReverseDNSProposal
+//	@Failure		400	{object}	happydns.ErrorResponse	"The domain has no zone"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/reverse-dns [get]
+func (rc *ReverseDNSController) GetReverseDNSPlan(c *gin.Context) {
+	user := middleware.MyUser(c)
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	proposals, err := rc.reverseDNSService.Plan(user, domain)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	if proposals == nil {
+		proposals = []*happydns.ReverseDNSProposal{}
+	}
+
+	c.JSON(http.StatusOK, proposals)
+}
+
+// GetReverseDNSDiff computes the corrections of the domain and of its reverse zones.
+//
+//	@Summary	Combined diff of the forward and reverse zones.
+//	@Schemes
+//	@Description	Compute the corrections pending on the domain, along with those of the reverse zones covering its servers.
+//	@Tags			reverse-dns
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{array}		happydns.DomainCorrections
+//	@Failure		400	{object}	happydns.ErrorResponse	"The domain has no zone"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/reverse-dns/diff [get]
+func (rc *ReverseDNSController) GetReverseDNSDiff(c *gin.Context) {
+	user := middleware.MyUser(c)
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	diff, err := rc.reverseDNSService.Diff(c.Request.Context(), user, domain)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// SyncReverseDNS writes the PTR records of the servers of the domain into its reverse zones.
+//
+//	@Summary	Synchronize the PTR records.
+//	@Schemes
+//	@Description	Create or update, in the current zones of the reverse domains, the PTR records pointing back at the servers of the domain, then return the combined diff. Nothing is published.
+//	@Tags			reverse-dns
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string						true	"Domain identifier"
+//	@Param			body		body	happydns.ReverseDNSSyncForm	false	"Addresses to synchronize"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{array}		happydns.DomainCorrections
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid input"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/reverse-dns [post]
+func (rc *ReverseDNSController) SyncReverseDNS(c *gin.Context) {
+	user := middleware.MyUser(c)
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	var form happydns.ReverseDNSSyncForm
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&form); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: fmt.Sprintf("Something is wrong in received data: %s", err.Error())})
+			return
+		}
+	}
+
+	diff, err := rc.reverseDNSService.Sync(c.Request.Context(), user, domain, &form)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// CheckFCrDNS reports the forward-confirmed reverse DNS mismatches across the zones of the user.
+//
+//	@Summary	Check forward-confirmed reverse DNS.
+//	@Schemes
+//	@Description	Compare the addresses declared in the forward zones of the user with the PTR records of their reverse zones, and report the mismatches.
+//	@Tags			reverse-dns
+//	@Accept			json
+//	@Produce		json
+//	@Security		securitydefinitions.basic
+//	@Success		200	{array}		happydns.FCrDNSIssue
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Router			/reverse-dns/check [get]
+func (rc *ReverseDNSController) CheckFCrDNS(c *gin.Context) {
+	user := middleware.MyUser(c)
+
+	issues, err := rc.reverseDNSService.Check(user)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	if issues == nil {
+		issues = []*happydns.FCrDNSIssue{}
+	}
+
+	c.JSON(http.StatusOK, issues)
+}
+
+// CreateReverseZones creates the reverse zones of a network.
+//
+//	@Summary	Create the reverse zones of a network.
+//	@Schemes
+//	@Description	Derive from an IPv4 or IPv6 CIDR the reverse zones covering it, split on octet or nibble boundaries, and create them on the given provider. For IPv4 networks smaller than a /24, an RFC 2317 classless zone is created, and the CNAME records it needs are added to the current zone of its parent when the user manages it.
+//	@Tags			reverse-dns
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body	happydns.ReverseZonesCreationInput	true	"The network and the provider hosting its zones"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.ReverseZonesCreation
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid input"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Zone not found on the provider"
+//	@Router			/reverse-dns/zones [post]
+func (rc *ReverseDNSController) CreateReverseZones(c *gin.Context) {
+	user := middleware.MyUser(c)
+
+	var input happydns.ReverseZonesCreationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: fmt.Sprintf("Something is wrong in received data: %s", err.Error())})
+		return
+	}
+
+	created, err := rc.reverseDNSService.CreateZones(c.Request.Context(), user, &input)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, created)
+}
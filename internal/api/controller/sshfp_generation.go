@@ -0,0 +1,79 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"git.happydns.org/happyDomain/internal/api/middleware"
+	"git.happydns.org/happyDomain/model"
+)
+
+type SSHFPGenerationController struct {
+	sshfpService happydns.SSHFPGeneratorUsecase
+}
+
+func NewSSHFPGenerationController(sshfpService happydns.SSHFPGeneratorUsecase) *SSHFPGenerationController {
+	return &SSHFPGenerationController{
+		sshfpService: sshfpService,
+	}
+}
+
+// GenerateSSHFP computes the SSHFP records of the host keys of a subdomain.
+//
+//	@Summary	Generate SSHFP records.
+//	@Schemes
+//	@Description	Compute the SHA-256 SSHFP records of the given host keys (ssh-keyscan output or OpenSSH public key files), and of those offered by the SSH server of the subdomain when a scan is asked, and compare them with the records currently published. The zone is not modified.
+//	@Tags			zones
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string							true	"Domain identifier"
+//	@Param			zoneId		path	string							true	"Zone identifier"
+//	@Param			subdomain	path	string							true	"Part of the subdomain considered for the service (@ for the root of the zone ; subdomain is relative to the root, do not include it)"
+//	@Param			body		body	happydns.SSHFPGenerationInput	true	"Host keys and scan options"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.SSHFPGeneration
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid keys or unreachable server"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain or zone not found"
+//	@Router			/domains/{domainId}/zone/{zoneId}/{subdomain}/sshfp [post]
+func (sc *SSHFPGenerationController) GenerateSSHFP(c *gin.Context) {
+	domain := c.MustGet("domain").(*happydns.Domain)
+	subdomain := c.MustGet("subdomain").(happydns.Subdomain)
+
+	var input happydns.SSHFPGenerationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: fmt.Sprintf("Something is wrong in received data: %s", err.Error())})
+		return
+	}
+
+	generation, err := sc.sshfpService.Generate(c.Request.Context(), domain, subdomain, &input)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, generation)
+}
@@ -0,0 +1,296 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"git.happydns.org/happyDomain/internal/api/middleware"
+	"git.happydns.org/happyDomain/model"
+)
+
+type TLSReportController struct {
+	tlsReportService happydns.TLSReportUsecase
+}
+
+func NewTLSReportController(tlsReportService happydns.TLSReportUsecase) *TLSReportController {
+	return &TLSReportController{
+		tlsReportService: tlsReportService,
+	}
+}
+
+// UploadTLSReport ingests an SMTP TLS report for the domain.
+//
+//	@Summary	Upload an SMTP TLS report.
+//	@Schemes
+//	@Description	Ingest an RFC 8460 report, as JSON or gzip, sent as request body. Only the policies about the domain or its subdomains are kept. Uploading the same report twice is harmless.
+//	@Tags			tlsrpt
+//	@Accept			application/tlsrpt+json,application/tlsrpt+gzip
+//	@Produce		json
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.TLSReport
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid report"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/tlsrpt/reports [post]
+func (tc *TLSReportController) UploadTLSReport(c *gin.Context) {
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	raw, ok := readReportBody(c)
+	if !ok {
+		return
+	}
+
+	report, err := tc.tlsReportService.IngestForDomain(c.Request.Context(), domain, raw)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// SubmitTLSReport receives a report posted by a sending MTA to the report
+// URI of a domain.
+//
+//	@Summary	Submit an SMTP TLS report.
+//	@Schemes
+//	@Description	Endpoint of the https rua= address published in the TLS-RPT record of a domain (RFC 8460 section 5.3). The report URI is given by the report settings of the domain.
+//	@Tags			tlsrpt
+//	@Accept			application/tlsrpt+json,application/tlsrpt+gzip
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Param			token		path	string	true	"Ingestion token of the domain"
+//	@Success		201
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid report"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Unknown report URI"
+//	@Router			/reports/tlsrpt/{domainId}/{token} [post]
+func (tc *TLSReportController) SubmitTLSReport(c *gin.Context) {
+	domainId, err := happydns.NewIdentifierFromString(c.Param("domainId"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, happydns.ErrorResponse{Message: happydns.ErrDomainNotFound.Error()})
+		return
+	}
+
+	raw, ok := readReportBody(c)
+	if !ok {
+		return
+	}
+
+	_, err = tc.tlsReportService.IngestWithToken(c.Request.Context(), domainId, c.Param("token"), raw)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
+
+// IngestTLSReport ingests an SMTP TLS report for the domains it was sent to
+// the report address of.
+//
+//	@Summary	Ingest an SMTP TLS report.
+//	@Schemes
+//	@Description	Ingest an RFC 8460 report, as JSON or gzip, sent as request body, for the domains whose report address is among the recipients of the message. Meant for the mail server receiving the mailto: reports; requires the bearer token set in the reports-ingest-token option.
+//	@Tags			tlsrpt
+//	@Accept			application/tlsrpt+json,application/tlsrpt+gzip
+//	@Produce		json
+//	@Param			Authorization	header	string	true	"Bearer token"
+//	@Param			to				query	[]string	true	"Recipients of the message carrying the report"	collectionFormat(multi)
+//	@Success		200	{array}		happydns.TLSReport
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid report or unknown domain"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Router			/reports/tlsrpt [post]
+func (tc *TLSReportController) IngestTLSReport(c *gin.Context) {
+	raw, ok := readReportBody(c)
+	if !ok {
+		return
+	}
+
+	reports, err := tc.tlsReportService.Ingest(c.Request.Context(), c.QueryArray("to"), raw)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reports)
+}
+
+// ListTLSReports lists the SMTP TLS reports received for the domain.
+//
+//	@Summary	List SMTP TLS reports.
+//	@Schemes
+//	@Description	List the SMTP TLS reports received for the domain over the last days, newest first.
+//	@Tags			tlsrpt
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Param			days		query	int		false	"Number of days to cover (default 30)"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{array}		happydns.TLSReport
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid input"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/tlsrpt/reports [get]
+func (tc *TLSReportController) ListTLSReports(c *gin.Context) {
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	since, ok := reportsSince(c)
+	if !ok {
+		return
+	}
+
+	reports, err := tc.tlsReportService.ListReports(domain, since)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	if reports == nil {
+		reports = []*happydns.TLSReport{}
+	}
+
+	c.JSON(http.StatusOK, reports)
+}
+
+// GetTLSReportSummary aggregates the failures reported for the domain.
+//
+//	@Summary	Summarize SMTP TLS reports.
+//	@Schemes
+//	@Description	Aggregate per result type the failed TLS sessions reported for the domain over the last days.
+//	@Tags			tlsrpt
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Param			days		query	int		false	"Number of days to cover (default 30)"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.TLSReportSummary
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid input"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/tlsrpt/summary [get]
+func (tc *TLSReportController) GetTLSReportSummary(c *gin.Context) {
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	since, ok := reportsSince(c)
+	if !ok {
+		return
+	}
+
+	summary, err := tc.tlsReportService.Summary(domain, since)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetTLSReportSettings retrieves the report settings of the domain.
+//
+//	@Summary	Get the SMTP TLS report settings.
+//	@Schemes
+//	@Description	Retrieve the report URI to publish in the TLS-RPT record of the domain, and when incoming reports raise a notification.
+//	@Tags			tlsrpt
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.TLSReportSettings
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/tlsrpt/settings [get]
+func (tc *TLSReportController) GetTLSReportSettings(c *gin.Context) {
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	settings, err := tc.tlsReportService.GetSettings(domain)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// SetTLSReportSettings updates the report settings of the domain.
+//
+//	@Summary	Update the SMTP TLS report settings.
+//	@Schemes
+//	@Description	Define from which failure rate incoming reports raise a notification.
+//	@Tags			tlsrpt
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string						true	"Domain identifier"
+//	@Param			body		body	happydns.TLSReportSettings	true	"The new settings"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.TLSReportSettings
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid input"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/tlsrpt/settings [put]
+func (tc *TLSReportController) SetTLSReportSettings(c *gin.Context) {
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	var settings happydns.TLSReportSettings
+	err := c.ShouldBindJSON(&settings)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: fmt.Sprintf("Something is wrong in received data: %s", err.Error())})
+		return
+	}
+
+	saved, err := tc.tlsReportService.SetSettings(domain, &settings)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// ResetTLSReportToken replaces the report URI of the domain.
+//
+//	@Summary	Reset the SMTP TLS report URI.
+//	@Schemes
+//	@Description	Generate a new ingestion token: reports posted to the previous report URI are refused from now on, so the TLS-RPT record has to be updated.
+//	@Tags			tlsrpt
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string	true	"Domain identifier"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.TLSReportSettings
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain not found"
+//	@Router			/domains/{domainId}/tlsrpt/settings/token [post]
+func (tc *TLSReportController) ResetTLSReportToken(c *gin.Context) {
+	domain := c.MustGet("domain").(*happydns.Domain)
+
+	settings, err := tc.tlsReportService.ResetIngestToken(domain)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,84 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"git.happydns.org/happyDomain/internal/api/middleware"
+	"git.happydns.org/happyDomain/model"
+)
+
+type TLSAGenerationController struct {
+	tlsaService happydns.TLSAGeneratorUsecase
+}
+
+func NewTLSAGenerationController(tlsaService happydns.TLSAGeneratorUsecase) *TLSAGenerationController {
+	return &TLSAGenerationController{
+		tlsaService: tlsaService,
+	}
+}
+
+// GenerateTLSA proposes the TLSA records of the certificate served by the endpoint of a TLSA service.
+//
+//	@Summary	Generate TLSA records.
+//	@Schemes
+//	@Description	Fetch the certificate chain served by the endpoint of the TLSA service, negotiating STARTTLS when needed, and propose its DANE-EE (3 1 1) and DANE-TA (2 1 1) records. In rollover mode, the records already in the service and the next key are kept alongside. The zone is not modified.
+//	@Tags			service
+//	@Accept			json
+//	@Produce		json
+//	@Param			domainId	path	string							true	"Domain identifier"
+//	@Param			zoneId		path	string							true	"Zone identifier"
+//	@Param			subdomain	path	string							true	"Part of the subdomain considered for the service (@ for the root of the zone ; subdomain is relative to the root, do not include it)"
+//	@Param			serviceId	path	string							true	"Service identifier"
+//	@Param			body		body	happydns.TLSAGenerationInput	false	"Endpoint and rollover options"
+//	@Security		securitydefinitions.basic
+//	@Success		200	{object}	happydns.TLSAGeneration
+//	@Failure		400	{object}	happydns.ErrorResponse	"Invalid input or unreachable endpoint"
+//	@Failure		401	{object}	happydns.ErrorResponse	"Authentication failure"
+//	@Failure		404	{object}	happydns.ErrorResponse	"Domain, zone or service not found"
+//	@Router			/domains/{domainId}/zone/{zoneId}/{subdomain}/services/{serviceId}/tlsa [post]
+func (tc *TLSAGenerationController) GenerateTLSA(c *gin.Context) {
+	domain := c.MustGet("domain").(*happydns.Domain)
+	zone := c.MustGet("zone").(*happydns.Zone)
+	subdomain := c.MustGet("subdomain").(happydns.Subdomain)
+	serviceid := c.MustGet("serviceid").(happydns.Identifier)
+
+	var input happydns.TLSAGenerationInput
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, happydns.ErrorResponse{Message: fmt.Sprintf("Something is wrong in received data: %s", err.Error())})
+			return
+		}
+	}
+
+	generation, err := tc.tlsaService.Generate(c.Request.Context(), domain, zone, subdomain, serviceid, &input)
+	if err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, generation)
+}
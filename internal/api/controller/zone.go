@@ -25,7 +25,9 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -114,6 +116,10 @@ func (zc *ZoneController) GetZoneSubdomain(c *gin.Context) {
 // It retrieves corrections between the zone in context and either the currently deployed
 // zone (when oldzoneid is "@") or another zone identifier. The computed corrections and
 // difference count are stored in the context for use by subsequent handlers.
+//
+// The deployed records may come from a short-lived cache: their age is then
+// reported in the X-Provider-Records-Age header, in seconds. The refresh
+// query parameter forces them to be fetched again.
 func (zc *ZoneController) DiffZonesHandler(c *gin.Context) {
 	user := c.MustGet("LoggedUser").(*happydns.User)
 	domain := c.MustGet("domain").(*happydns.Domain)
@@ -121,13 +127,23 @@ func (zc *ZoneController) DiffZonesHandler(c *gin.Context) {
 
 	var nbDiffs int
 	var corrections []*happydns.Correction
+	var fetchedAt *time.Time
 	if c.Param("oldzoneid") == "@" {
+		if c.Query("refresh") == "true" || c.Query("refresh") == "1" {
+			zc.zoneCorrectionService.InvalidateProviderRecords(domain)
+		}
+
 		var err error
 		corrections, nbDiffs, err = zc.zoneCorrectionService.List(c.Request.Context(), user, domain, newzone)
 		if err != nil {
 			middleware.ErrorResponse(c, http.StatusInternalServerError, err)
 			return
 		}
+
+		fetchedAt = zc.zoneCorrectionService.ProviderRecordsFetchedAt(domain)
+		if fetchedAt != nil {
+			c.Header("X-Provider-Records-Age", strconv.Itoa(int(time.Since(*fetchedAt).Seconds())))
+		}
 	} else {
 		oldzoneid, err := middleware.ParseZoneId(c, "oldzoneid")
 		if err != nil {
@@ -145,6 +161,7 @@ func (zc *ZoneController) DiffZonesHandler(c *gin.Context) {
 
 	c.Set("corrections", corrections)
 	c.Set("nbDiffs", nbDiffs)
+	c.Set("providerRecordsFetchedAt", fetchedAt)
 
 	c.Next()
 }
@@ -161,7 +178,9 @@ func (zc *ZoneController) DiffZonesHandler(c *gin.Context) {
 //	@Param			domainId	path		string			true	"Domain identifier"
 //	@Param			zoneId		path		string			true	"Zone identifier to use as the new one."
 //	@Param			oldZoneId		path		string			true	"Zone identifier to use as the old one. Currently only @ are expected, to use the currently deployed zone."
+//	@Param			refresh		query		bool			false	"Fetch the deployed records from the provider instead of reusing the recently fetched ones."
 //	@Success		200			{array}		happydns.Correction	"Differences, reported as text, one diff per item"
+//	@Header			200			{integer}	X-Provider-Records-Age	"Age in seconds of the deployed records, when they were reused"
 //	@Failure		400			{object}	happydns.ErrorResponse	"Invalid input"
 //	@Failure		401			{object}	happydns.ErrorResponse	"Authentication failure"
 //	@Failure		404			{object}	happydns.ErrorResponse	"Domain not found"
@@ -186,7 +205,8 @@ func (zc *ZoneController) DiffZones(c *gin.Context) {
 //	@Param		domainId	path		string	true	"Domain identifier"
 //	@Param		zoneId		path		string	true	"Zone identifier to use as the new one."
 //	@Param		oldZoneId	path		string	true	"Zone identifier to use as the old one. Currently only @ are expected, to use the currently deployed zone."
-//	@Success	200			{object}	object{nbDiffs=int}	"Summary containing the number of differences"
+//	@Param		refresh		query		bool	false	"Fetch the deployed records from the provider instead of reusing the recently fetched ones."
+//	@Success	200			{object}	object{nbDiffs=int,providerRecordsFetchedAt=string}	"Summary containing the number of differences"
 //	@Failure	400			{object}	happydns.ErrorResponse	"Invalid input"
 //	@Failure	401			{object}	happydns.ErrorResponse	"Authentication failure"
 //	@Failure	404			{object}	happydns.ErrorResponse	"Domain not found"
@@ -195,8 +215,14 @@ func (zc *ZoneController) DiffZones(c *gin.Context) {
 //	@Router		/domains/{domainId}/zone/{zoneId}/diff/{oldZoneId}/summary [post]
 func (zc *ZoneController) DiffZonesSummary(c *gin.Context) {
 	nbDiffs := c.MustGet("nbDiffs").(int)
+	fetchedAt := c.MustGet("providerRecordsFetchedAt").(*time.Time)
+
+	if fetchedAt == nil {
+		c.JSON(http.StatusOK, gin.H{"nbDiffs": nbDiffs})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"nbDiffs": nbDiffs})
+	c.JSON(http.StatusOK, gin.H{"nbDiffs": nbDiffs, "providerRecordsFetchedAt": fetchedAt})
 }
 
 // ApplyZoneCorrections performs the requested changes with the provider.
@@ -0,0 +1,39 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package route
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"git.happydns.org/happyDomain/internal/api/controller"
+	"git.happydns.org/happyDomain/model"
+)
+
+func DeclareDKIMRoutes(router *gin.RouterGroup, dkimUC happydns.DKIMUsecase) {
+	dc := controller.NewDKIMController(dkimUC)
+
+	router.GET("", dc.GetDKIMPolicy)
+	router.PUT("", dc.SetDKIMPolicy)
+	router.GET("/keys", dc.ListDKIMKeys)
+	router.POST("/keys", dc.GenerateDKIMKey)
+	router.GET("/keys/:keyId/private", dc.ExportDKIMPrivateKey)
+}
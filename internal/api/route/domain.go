@@ -35,6 +35,16 @@ func DeclareDomainRoutes(
 	router *gin.RouterGroup,
 	domainUC happydns.DomainUsecase,
 	domainLogUC happydns.DomainLogUsecase,
+	migrationUC happydns.ProviderMigrationUsecase,
+	journalUC happydns.ProviderJournalUsecase,
+	registrarUC happydns.RegistrarUsecase,
+	dkimUC happydns.DKIMUsecase,
+	dmarcReportUC happydns.DMARCReportUsecase,
+	tlsReportUC happydns.TLSReportUsecase,
+	reverseDNSUC happydns.ReverseDNSUsecase,
+	tlsaUC happydns.TLSAGeneratorUsecase,
+	sshfpUC happydns.SSHFPGeneratorUsecase,
+	emailKeysUC happydns.EmailKeysUsecase,
 	remoteZoneImporter happydns.RemoteZoneImporterUsecase,
 	zoneImporter happydns.ZoneImporterUsecase,
 	zoneUC happydns.ZoneUsecase,
@@ -66,6 +76,13 @@ func DeclareDomainRoutes(
 
 	DeclareDomainInfoRoutes(apiDomainsRoutes.Group("/info"), domainInfoUC)
 	DeclareDomainLogRoutes(apiDomainsRoutes, domainLogUC)
+	DeclareProviderMigrationRoutes(apiDomainsRoutes.Group("/migration"), migrationUC)
+	DeclareProviderJournalRoutes(apiDomainsRoutes.Group("/journal"), journalUC)
+	DeclareRegistrarRoutes(apiDomainsRoutes.Group("/registrar"), registrarUC)
+	DeclareDKIMRoutes(apiDomainsRoutes.Group("/dkim"), dkimUC)
+	DeclareDMARCReportRoutes(apiDomainsRoutes.Group("/dmarc"), dmarcReportUC)
+	DeclareTLSReportRoutes(apiDomainsRoutes.Group("/tlsrpt"), tlsReportUC)
+	DeclareReverseDNSRoutes(apiDomainsRoutes.Group("/reverse-dns"), reverseDNSUC)
 
 	apiDomainsRoutes.POST("/zone", dc.ImportZone)
 	apiDomainsRoutes.POST("/retrieve_zone", dc.RetrieveZone)
@@ -90,5 +107,8 @@ func DeclareDomainRoutes(
 		serviceUC,
 		cc,
 		nc,
+		tlsaUC,
+		sshfpUC,
+		emailKeysUC,
 	)
 }
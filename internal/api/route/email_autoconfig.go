@@ -36,8 +36,9 @@ import (
 
 // DeclareEmailAutoconfigRoutes wires the public HTTP endpoints for mail-client
 // auto-configuration onto the provided base and API route groups. baseRoutes
-// receives the well-known XML paths dictated by the standards (Mozilla and
-// Microsoft); apiRoutes receives the Caddy validation hook.
+// receives the well-known paths dictated by the standards (Mozilla,
+// Microsoft, Apple, MTA-STS and WKD); apiRoutes receives the Caddy validation
+// hook.
 func DeclareEmailAutoconfigRoutes(baseRoutes, apiRoutes *gin.RouterGroup, uc happydns.EmailAutoconfigUsecase) {
 	if uc == nil {
 		return
@@ -72,6 +73,31 @@ func DeclareEmailAutoconfigRoutes(baseRoutes, apiRoutes *gin.RouterGroup, uc hap
 		baseRoutes.POST(path, rl, ctrl.MSAutodiscover)
 	}
 
+	// Microsoft Autodiscover v2: modern Outlook asks for the URL of each
+	// protocol, with the address in the path or in the Email parameter.
+	for _, path := range []string{
+		"/autodiscover/autodiscover.json",
+		"/Autodiscover/Autodiscover.json",
+	} {
+		baseRoutes.GET(path, rl, ctrl.MSAutodiscoverJSON)
+		baseRoutes.GET(path+"/v1.0/:email", rl, ctrl.MSAutodiscoverJSON)
+	}
+
+	// Apple configuration profile: users open
+	// https://autoconfig.<domain>/mail/config.mobileconfig on their device.
+	baseRoutes.GET("/mail/config.mobileconfig", rl, ctrl.AppleMobileConfig)
+
+	// MTA-STS: senders fetch GET https://mta-sts.<domain>/.well-known/mta-sts.txt
+	baseRoutes.GET("/.well-known/mta-sts.txt", rl, ctrl.MTASTSPolicy)
+
+	// OpenPGP Web Key Directory: clients first try the advanced method on
+	// https://openpgpkey.<domain>/.well-known/openpgpkey/<domain>/..., then
+	// the direct one on https://<domain>/.well-known/openpgpkey/...
+	baseRoutes.GET("/.well-known/openpgpkey/:domain/policy", rl, ctrl.WKDPolicy)
+	baseRoutes.GET("/.well-known/openpgpkey/:domain/hu/:hash", rl, ctrl.WKDKey)
+	baseRoutes.GET("/.well-known/openpgpkey/policy", rl, ctrl.WKDPolicy)
+	baseRoutes.GET("/.well-known/openpgpkey/hu/:hash", rl, ctrl.WKDKey)
+
 	// Caddy on-demand TLS ask hook.
 	apiRoutes.GET("/caddy/ask", rl, ctrl.CaddyAsk)
 }
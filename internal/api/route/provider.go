@@ -29,7 +29,7 @@ import (
 	"git.happydns.org/happyDomain/model"
 )
 
-func DeclareProviderRoutes(router *gin.RouterGroup, providerUC happydns.ProviderUsecase) {
+func DeclareProviderRoutes(router *gin.RouterGroup, providerUC happydns.ProviderUsecase, onboardingUC happydns.OnboardingUsecase, detectionUC happydns.ProviderDetectionUsecase) {
 	// Redact: these routes answer end users, who must never read a stored
 	// credential back out of happyDomain.
 	pc := controller.NewProviderController(providerUC, true)
@@ -37,6 +37,9 @@ func DeclareProviderRoutes(router *gin.RouterGroup, providerUC happydns.Provider
 	router.GET("/providers", pc.ListProviders)
 	router.POST("/providers", pc.AddProvider)
 
+	dc := controller.NewProviderDetectionController(detectionUC)
+	router.GET("/providers/detect", dc.DetectProvider)
+
 	apiProvidersMetaRoutes := router.Group("/providers/:pid")
 	apiProvidersMetaRoutes.Use(middleware.ProviderMetaHandler(providerUC))
 
@@ -50,4 +53,9 @@ func DeclareProviderRoutes(router *gin.RouterGroup, providerUC happydns.Provider
 
 	apiProviderRoutes.GET("/domains", pc.GetDomainsHostedByProvider)
 	apiProviderRoutes.POST("/domains/:fqdn", pc.CreateDomainOnProvider)
+
+	oc := controller.NewOnboardingController(onboardingUC)
+
+	apiProviderRoutes.GET("/onboarding", oc.GetOnboarding)
+	apiProviderRoutes.POST("/onboarding", oc.StartOnboarding)
 }
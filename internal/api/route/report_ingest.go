@@ -0,0 +1,65 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package route
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"git.happydns.org/happyDomain/internal/api/controller"
+	"git.happydns.org/happyDomain/model"
+)
+
+// reportIngestAuth only lets through the requests bearing token.
+func reportIngestAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, happydns.ErrorResponse{Message: "Invalid or missing ingestion token."})
+			return
+		}
+		c.Next()
+	}
+}
+
+// DeclareReportIngestRoutes exposes the endpoints where the reports can be
+// delivered. The per-domain TLS-RPT report URIs are authenticated by the
+// token they contain; the endpoints where the mail server receiving the
+// reports can forward them are only declared when an ingestion token is
+// configured.
+func DeclareReportIngestRoutes(cfg *happydns.Options, router *gin.RouterGroup, dmarcUC happydns.DMARCReportUsecase, tlsReportUC happydns.TLSReportUsecase) {
+	dc := controller.NewDMARCReportController(dmarcUC)
+	tc := controller.NewTLSReportController(tlsReportUC)
+
+	router.POST("/reports/tlsrpt/:domainId/:token", perClientRateLimiter(60), tc.SubmitTLSReport)
+
+	if cfg.ReportsIngestToken == "" {
+		return
+	}
+
+	authRoutes := router.Group("/reports", reportIngestAuth(cfg.ReportsIngestToken))
+	authRoutes.POST("/dmarc", dc.IngestDMARCReport)
+	authRoutes.POST("/tlsrpt", tc.IngestTLSReport)
+}
@@ -33,6 +33,7 @@ func DeclareResolverRoutes(router *gin.RouterGroup, resolverUC happydns.Resolver
 
 	router.POST("/resolver", rc.RunResolver)
 	router.POST("/resolver/spf-flatten", rc.FlattenSPF)
+	router.POST("/resolver/spf-expand", rc.ExpandSPF)
 	router.POST("/resolver/mta-sts-policy", rc.FetchMTASTSPolicy)
 	router.POST("/resolver/dmarc-report-auth", rc.CheckDMARCReportAuth)
 }
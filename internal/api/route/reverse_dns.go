@@ -0,0 +1,49 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package route
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"git.happydns.org/happyDomain/internal/api/controller"
+	"git.happydns.org/happyDomain/model"
+)
+
+// DeclareReverseDNSRoutes declares the routes synchronizing the PTR records
+// of the servers of a domain, on the group of that domain.
+func DeclareReverseDNSRoutes(router *gin.RouterGroup, reverseDNSUC happydns.ReverseDNSUsecase) {
+	rc := controller.NewReverseDNSController(reverseDNSUC)
+
+	router.GET("", rc.GetReverseDNSPlan)
+	router.POST("", rc.SyncReverseDNS)
+	router.GET("/diff", rc.GetReverseDNSDiff)
+}
+
+// DeclareUserReverseDNSRoutes declares the reverse DNS routes spanning all
+// the zones of the user: the FCrDNS report and the creation of the reverse
+// zones of a network.
+func DeclareUserReverseDNSRoutes(router *gin.RouterGroup, reverseDNSUC happydns.ReverseDNSUsecase) {
+	rc := controller.NewReverseDNSController(reverseDNSUC)
+
+	router.GET("/reverse-dns/check", rc.CheckFCrDNS)
+	router.POST("/reverse-dns/zones", rc.CreateReverseZones)
+}
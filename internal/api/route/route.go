@@ -46,21 +46,33 @@ type Dependencies struct {
 	Authentication        happydns.AuthenticationUsecase
 	AuthUser              happydns.AuthUserUsecase
 	CaptchaVerifier       happydns.CaptchaVerifier
+	DKIM                  happydns.DKIMUsecase
+	DMARCReport           happydns.DMARCReportUsecase
 	Domain                happydns.DomainUsecase
 	DomainInfo            happydns.DomainInfoUsecase
 	DomainLog             happydns.DomainLogUsecase
 	EmailAutoconfig       happydns.EmailAutoconfigUsecase
+	EmailKeys             happydns.EmailKeysUsecase
 	FailureTracker        happydns.FailureTracker
 	FaviconService        *favicon.FaviconService
+	Onboarding            happydns.OnboardingUsecase
 	OutboundGuard         *netguard.Guard
 	Provider              happydns.ProviderUsecase
+	ProviderDetection     happydns.ProviderDetectionUsecase
+	ProviderJournal       happydns.ProviderJournalUsecase
+	ProviderMigration     happydns.ProviderMigrationUsecase
 	ProviderSettings      happydns.ProviderSettingsUsecase
 	ProviderSpecs         happydns.ProviderSpecsUsecase
+	Registrar             happydns.RegistrarUsecase
 	RemoteZoneImporter    happydns.RemoteZoneImporterUsecase
 	Resolver              happydns.ResolverUsecase
+	ReverseDNS            happydns.ReverseDNSUsecase
 	Service               happydns.ServiceUsecase
 	ServiceSpecs          happydns.ServiceSpecsUsecase
 	Session               happydns.SessionUsecase
+	SSHFPGenerator        happydns.SSHFPGeneratorUsecase
+	TLSAGenerator         happydns.TLSAGeneratorUsecase
+	TLSReport             happydns.TLSReportUsecase
 	User                  happydns.UserUsecase
 	Zone                  happydns.ZoneUsecase
 	ZoneCorrectionApplier happydns.ZoneCorrectionApplierUsecase
@@ -143,6 +155,7 @@ func DeclareRoutes(cfg *happydns.Options, router *gin.RouterGroup, dep Dependenc
 	DeclareFaviconRoutes(apiRoutes.Group("/favicon", perClientRateLimiter(60)), dep.FaviconService)
 	DeclareProviderSpecsRoutes(apiRoutes, dep.ProviderSpecs)
 	DeclareRegistrationRoutes(apiRoutes, dep.AuthUser, dep.CaptchaVerifier)
+	DeclareReportIngestRoutes(cfg, apiRoutes, dep.DMARCReport, dep.TLSReport)
 	DeclareResolverRoutes(apiRoutes, dep.Resolver)
 	DeclareServiceSpecsRoutes(apiRoutes, dep.ServiceSpecs)
 	DeclareUserRecoveryRoutes(apiRoutes, dep.AuthUser, auc)
@@ -191,6 +204,16 @@ func DeclareRoutes(cfg *happydns.Options, router *gin.RouterGroup, dep Dependenc
 		apiAuthRoutes,
 		dep.Domain,
 		dep.DomainLog,
+		dep.ProviderMigration,
+		dep.ProviderJournal,
+		dep.Registrar,
+		dep.DKIM,
+		dep.DMARCReport,
+		dep.TLSReport,
+		dep.ReverseDNS,
+		dep.TLSAGenerator,
+		dep.SSHFPGenerator,
+		dep.EmailKeys,
 		dep.RemoteZoneImporter,
 		dep.ZoneImporter,
 		dep.Zone,
@@ -203,7 +226,8 @@ func DeclareRoutes(cfg *happydns.Options, router *gin.RouterGroup, dep Dependenc
 		nc,
 		dep.OutboundGuard,
 	)
-	DeclareProviderRoutes(apiAuthRoutes, dep.Provider)
+	DeclareUserReverseDNSRoutes(apiAuthRoutes, dep.ReverseDNS)
+	DeclareProviderRoutes(apiAuthRoutes, dep.Provider, dep.Onboarding, dep.ProviderDetection)
 	DeclareProviderSettingsRoutes(apiAuthRoutes, dep.ProviderSettings)
 	DeclareRecordRoutes(apiAuthRoutes)
 	DeclareUsersRoutes(apiAuthRoutes, dep.User, dep.Backup, lc)
@@ -38,6 +38,7 @@ func DeclareZoneServiceRoutes(
 	zoneUC happydns.ZoneUsecase,
 	cc *controller.CheckerController,
 	nc *controller.NotificationController,
+	tlsaUC happydns.TLSAGeneratorUsecase,
 ) {
 	sc := controller.NewServiceController(zoneServiceUC, serviceUC, zoneUC)
 
@@ -50,6 +51,9 @@ func DeclareZoneServiceRoutes(
 	apiZonesSubdomainServiceIDRoutes.GET("", sc.GetZoneService)
 	apiZonesSubdomainServiceIDRoutes.DELETE("", sc.DeleteZoneService)
 
+	tc := controller.NewTLSAGenerationController(tlsaUC)
+	apiZonesSubdomainServiceIDRoutes.POST("/tlsa", tc.GenerateTLSA)
+
 	// Mount service-scoped checker routes.
 	if cc != nil {
 		DeclareScopedCheckerRoutes(apiZonesSubdomainServiceIDRoutes, cc, nc)
@@ -39,6 +39,9 @@ func DeclareZoneRoutes(
 	serviceUC happydns.ServiceUsecase,
 	cc *controller.CheckerController,
 	nc *controller.NotificationController,
+	tlsaUC happydns.TLSAGeneratorUsecase,
+	sshfpUC happydns.SSHFPGeneratorUsecase,
+	emailKeysUC happydns.EmailKeysUsecase,
 ) {
 	var checkStatusUC *checkerUC.CheckStatusUsecase
 	if cc != nil {
@@ -67,6 +70,12 @@ func DeclareZoneRoutes(
 	apiZonesSubdomainRoutes.Use(middleware.SubdomainHandler)
 	apiZonesSubdomainRoutes.GET("", zc.GetZoneSubdomain)
 
+	sc := controller.NewSSHFPGenerationController(sshfpUC)
+	apiZonesSubdomainRoutes.POST("/sshfp", sc.GenerateSSHFP)
+
+	ekc := controller.NewEmailKeysController(emailKeysUC)
+	apiZonesSubdomainRoutes.POST("/email-keys", ekc.PublishEmailKeys)
+
 	DeclareZoneServiceRoutes(
 		apiZonesRoutes,
 		apiZonesSubdomainRoutes,
@@ -76,6 +85,7 @@ func DeclareZoneRoutes(
 		zoneUC,
 		cc,
 		nc,
+		tlsaUC,
 	)
 
 	apiZonesRoutes.POST("/records", zc.AddRecords)
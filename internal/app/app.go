@@ -36,39 +36,59 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	aliasflattenUC "git.happydns.org/happyDomain/internal/usecase/aliasflatten"
 	checkerUC "git.happydns.org/happyDomain/internal/usecase/checker"
+	dkimUC "git.happydns.org/happyDomain/internal/usecase/dkim"
 	notifUC "git.happydns.org/happyDomain/internal/usecase/notification"
+	onboardingUC "git.happydns.org/happyDomain/internal/usecase/onboarding"
 	"git.happydns.org/happyDomain/internal/usecase/orchestrator"
+	reportJanitorUC "git.happydns.org/happyDomain/internal/usecase/reportjanitor"
+	spfflattenUC "git.happydns.org/happyDomain/internal/usecase/spfflatten"
 
 	"git.happydns.org/happyDomain/internal/captcha"
+	"git.happydns.org/happyDomain/internal/mailbox"
 	notifPkg "git.happydns.org/happyDomain/internal/notifier"
+	"git.happydns.org/happyDomain/internal/secretbox"
 	"git.happydns.org/happyDomain/internal/storage"
+	"git.happydns.org/happyDomain/internal/storage/sealed"
 	"git.happydns.org/happyDomain/model"
 	"git.happydns.org/happyDomain/pkg/favicon"
 )
 
 type Usecases struct {
-	backup           happydns.BackupUsecase
-	authentication   happydns.AuthenticationUsecase
-	authUser         happydns.AuthUserUsecase
-	authUserAdmin    happydns.AdminAuthUserUsecase
-	domain           happydns.DomainUsecase
-	domainAdmin      happydns.AdminDomainUsecase
-	domainInfo       happydns.DomainInfoUsecase
-	domainLog        happydns.DomainLogUsecase
-	emailAutoconfig  happydns.EmailAutoconfigUsecase
-	provider         happydns.ProviderUsecase
-	providerAdmin    happydns.ProviderUsecase
-	providerSpecs    happydns.ProviderSpecsUsecase
-	providerSettings happydns.ProviderSettingsUsecase
-	resolver         happydns.ResolverUsecase
-	session          happydns.SessionUsecase
-	service          happydns.ServiceUsecase
-	serviceSpecs     happydns.ServiceSpecsUsecase
-	user             happydns.UserUsecase
-	userAdmin        happydns.AdminUserUsecase
-	zone             happydns.ZoneUsecase
-	zoneService      happydns.ZoneServiceUsecase
+	backup            happydns.BackupUsecase
+	authentication    happydns.AuthenticationUsecase
+	authUser          happydns.AuthUserUsecase
+	authUserAdmin     happydns.AdminAuthUserUsecase
+	dkim              happydns.DKIMUsecase
+	dmarcReport       happydns.DMARCReportUsecase
+	domain            happydns.DomainUsecase
+	domainAdmin       happydns.AdminDomainUsecase
+	domainInfo        happydns.DomainInfoUsecase
+	domainLog         happydns.DomainLogUsecase
+	emailAutoconfig   happydns.EmailAutoconfigUsecase
+	emailKeys         happydns.EmailKeysUsecase
+	onboarding        happydns.OnboardingUsecase
+	provider          happydns.ProviderUsecase
+	providerAdmin     happydns.ProviderUsecase
+	providerDetection happydns.ProviderDetectionUsecase
+	providerJournal   happydns.ProviderJournalUsecase
+	providerMigration happydns.ProviderMigrationUsecase
+	providerSpecs     happydns.ProviderSpecsUsecase
+	providerSettings  happydns.ProviderSettingsUsecase
+	registrar         happydns.RegistrarUsecase
+	resolver          happydns.ResolverUsecase
+	reverseDNS        happydns.ReverseDNSUsecase
+	session           happydns.SessionUsecase
+	service           happydns.ServiceUsecase
+	serviceSpecs      happydns.ServiceSpecsUsecase
+	sshfpGenerator    happydns.SSHFPGeneratorUsecase
+	tlsaGenerator     happydns.TLSAGeneratorUsecase
+	tlsReport         happydns.TLSReportUsecase
+	user              happydns.UserUsecase
+	userAdmin         happydns.AdminUserUsecase
+	zone              happydns.ZoneUsecase
+	zoneService       happydns.ZoneServiceUsecase
 
 	orchestrator *orchestrator.Orchestrator
 
@@ -80,6 +100,17 @@ type Usecases struct {
 	checkerJanitor   *checkerUC.Janitor
 	checkerUserGater *checkerUC.UserGater
 
+	dkimRotator *dkimUC.Rotator
+
+	aliasRefresher *aliasflattenUC.Refresher
+	spfRefresher   *spfflattenUC.Refresher
+
+	zoneSyncWatcher *onboardingUC.Watcher
+
+	dmarcReportJanitor *reportJanitorUC.Janitor[*happydns.DMARCReport]
+	tlsReportJanitor   *reportJanitorUC.Janitor[*happydns.TLSReport]
+	reportsPoller      *mailbox.Poller
+
 	notificationDispatcher *notifUC.Dispatcher
 	notificationRegistry   *notifPkg.Registry
 }
@@ -91,6 +122,7 @@ type App struct {
 	faviconService  *favicon.FaviconService
 	failureTracker  *captcha.FailureTracker
 	insights        *insightsCollector
+	keyring         *secretbox.Keyring
 	mailer          happydns.Mailer
 	newsletter      happydns.NewsletterSubscriptor
 	router          *gin.Engine
@@ -105,6 +137,7 @@ func NewApp(cfg *happydns.Options) *App {
 	}
 
 	app.initGuards()
+	app.initKeyring()
 	app.initMailer()
 	app.initStorageEngine()
 	app.initNewsletter()
@@ -126,6 +159,8 @@ func NewAppWithStorage(cfg *happydns.Options, store storage.Storage) *App {
 	}
 
 	app.initGuards()
+	app.initKeyring()
+	app.store = sealed.New(app.store, app.keyring)
 	app.initMailer()
 	app.initNewsletter()
 	if err := app.initPlugins(); err != nil {
@@ -29,7 +29,9 @@ import (
 	"git.happydns.org/happyDomain/internal/mailer"
 	"git.happydns.org/happyDomain/internal/metrics"
 	"git.happydns.org/happyDomain/internal/newsletter"
+	"git.happydns.org/happyDomain/internal/secretbox"
 	"git.happydns.org/happyDomain/internal/storage"
+	"git.happydns.org/happyDomain/internal/storage/sealed"
 )
 
 func (app *App) initCaptcha() {
@@ -43,6 +45,22 @@ func (app *App) initCaptcha() {
 	app.failureTracker = captcha.NewFailureTracker(threshold, 15*time.Minute)
 }
 
+// initKeyring loads the instance secret key used to seal the secrets
+// happyDomain generates on behalf of its users. Without one, the features
+// needing it stay disabled.
+func (app *App) initKeyring() {
+	keyring, err := secretbox.NewKeyring(app.cfg.SecretKey, app.cfg.PreviousSecretKeys...)
+	if err != nil {
+		log.Fatalf("Invalid -secret-key: %s", err)
+	}
+
+	if !keyring.Available() {
+		log.Println("No secret key configured: DKIM key generation is disabled and provider credentials are stored in clear.")
+	}
+
+	app.keyring = keyring
+}
+
 func (app *App) initMailer() {
 	if app.cfg.MailSMTPHost != "" {
 		smtpMailer, err := mailer.NewSMTPMailer(app.cfg.MailSMTPHost, app.cfg.MailSMTPPort, app.cfg.MailSMTPUsername, app.cfg.MailSMTPPassword)
@@ -87,7 +105,7 @@ func (app *App) initStorageEngine() {
 		}
 
 		metrics.NewStorageStatsCollector(storage.NewStatsProvider(app.store))
-		app.store = newInstrumentedStorage(app.store)
+		app.store = sealed.New(newInstrumentedStorage(app.store), app.keyring)
 	}
 }
 
@@ -147,6 +147,16 @@ func (s *instrumentedStorage) CreateCheckPlan(plan *happydns.CheckPlan) (err err
 	return s.inner.CreateCheckPlan(plan)
 }
 
+func (s *instrumentedStorage) CreateDKIMKey(key *happydns.DKIMKey) (err error) {
+	defer observe("create", "dkim")(&err)
+	return s.inner.CreateDKIMKey(key)
+}
+
+func (s *instrumentedStorage) CreateDMARCReport(report *happydns.DMARCReport) (err error) {
+	defer observe("create", "dmarc_report")(&err)
+	return s.inner.CreateDMARCReport(report)
+}
+
 func (s *instrumentedStorage) CreateDomain(domain *happydns.Domain) (err error) {
 	defer observe("create", "domain")(&err)
 	return s.inner.CreateDomain(domain)
@@ -182,6 +192,11 @@ func (s *instrumentedStorage) CreateProvider(prvd *happydns.Provider) (err error
 	return s.inner.CreateProvider(prvd)
 }
 
+func (s *instrumentedStorage) CreateProviderCall(call *happydns.ProviderCall) (err error) {
+	defer observe("create", "provider_call")(&err)
+	return s.inner.CreateProviderCall(call)
+}
+
 func (s *instrumentedStorage) CreateRecord(rec *happydns.NotificationRecord) (err error) {
 	defer observe("create", "notification_record")(&err)
 	return s.inner.CreateRecord(rec)
@@ -192,6 +207,11 @@ func (s *instrumentedStorage) CreateSnapshot(snap *happydns.ObservationSnapshot)
 	return s.inner.CreateSnapshot(snap)
 }
 
+func (s *instrumentedStorage) CreateTLSReport(report *happydns.TLSReport) (err error) {
+	defer observe("create", "tls_report")(&err)
+	return s.inner.CreateTLSReport(report)
+}
+
 func (s *instrumentedStorage) CreateZone(zone *happydns.Zone) (err error) {
 	defer observe("create", "zone")(&err)
 	return s.inner.CreateZone(zone)
@@ -202,6 +222,11 @@ func (s *instrumentedStorage) DeleteAuthUser(user *happydns.UserAuth) (err error
 	return s.inner.DeleteAuthUser(user)
 }
 
+func (s *instrumentedStorage) DeleteBuiltinZone(providerId happydns.Identifier, name string) (err error) {
+	defer observe("delete", "builtin_zone")(&err)
+	return s.inner.DeleteBuiltinZone(providerId, name)
+}
+
 func (s *instrumentedStorage) DeleteChannel(channelId happydns.Identifier) (err error) {
 	defer observe("delete", "notification_channel")(&err)
 	return s.inner.DeleteChannel(channelId)
@@ -217,6 +242,21 @@ func (s *instrumentedStorage) DeleteCheckerConfiguration(checkerName string, use
 	return s.inner.DeleteCheckerConfiguration(checkerName, userId, domainId, serviceId)
 }
 
+func (s *instrumentedStorage) DeleteDKIMKey(key *happydns.DKIMKey) (err error) {
+	defer observe("delete", "dkim")(&err)
+	return s.inner.DeleteDKIMKey(key)
+}
+
+func (s *instrumentedStorage) DeleteDKIMPolicy(domainId happydns.Identifier) (err error) {
+	defer observe("delete", "dkim")(&err)
+	return s.inner.DeleteDKIMPolicy(domainId)
+}
+
+func (s *instrumentedStorage) DeleteDMARCReport(report *happydns.DMARCReport) (err error) {
+	defer observe("delete", "dmarc_report")(&err)
+	return s.inner.DeleteDMARCReport(report)
+}
+
 func (s *instrumentedStorage) DeleteDiscoveryEntriesByProducer(producerID string, target happydns.CheckTarget) (err error) {
 	defer observe("delete", "discovery_entry")(&err)
 	return s.inner.DeleteDiscoveryEntriesByProducer(producerID, target)
@@ -267,6 +307,11 @@ func (s *instrumentedStorage) DeleteProvider(prvdid happydns.Identifier) (err er
 	return s.inner.DeleteProvider(prvdid)
 }
 
+func (s *instrumentedStorage) DeleteProviderCall(call *happydns.ProviderCall) (err error) {
+	defer observe("delete", "provider_call")(&err)
+	return s.inner.DeleteProviderCall(call)
+}
+
 func (s *instrumentedStorage) DeleteRecordsOlderThan(before time.Time) (err error) {
 	defer observe("delete", "notification_record")(&err)
 	return s.inner.DeleteRecordsOlderThan(before)
@@ -287,6 +332,11 @@ func (s *instrumentedStorage) DeleteState(checkerID string, target happydns.Chec
 	return s.inner.DeleteState(checkerID, target, userId)
 }
 
+func (s *instrumentedStorage) DeleteTLSReport(report *happydns.TLSReport) (err error) {
+	defer observe("delete", "tls_report")(&err)
+	return s.inner.DeleteTLSReport(report)
+}
+
 func (s *instrumentedStorage) DeleteUser(userid happydns.Identifier) (err error) {
 	defer observe("delete", "user")(&err)
 	return s.inner.DeleteUser(userid)
@@ -312,6 +362,11 @@ func (s *instrumentedStorage) GetAuthUserByEmail(email string) (ret *happydns.Us
 	return s.inner.GetAuthUserByEmail(email)
 }
 
+func (s *instrumentedStorage) GetBuiltinZone(providerId happydns.Identifier, name string) (ret *happydns.BuiltinZone, err error) {
+	defer observe("get", "builtin_zone")(&err)
+	return s.inner.GetBuiltinZone(providerId, name)
+}
+
 func (s *instrumentedStorage) GetCachedObservation(target happydns.CheckTarget, key happydns.ObservationKey) (ret *happydns.ObservationCacheEntry, err error) {
 	defer observe("get", "observation_cache")(&err)
 	return s.inner.GetCachedObservation(target, key)
@@ -332,6 +387,21 @@ func (s *instrumentedStorage) GetCheckerConfiguration(checkerName string, userId
 	return s.inner.GetCheckerConfiguration(checkerName, userId, domainId, serviceId)
 }
 
+func (s *instrumentedStorage) GetDKIMKey(domainId happydns.Identifier, keyId happydns.Identifier) (ret *happydns.DKIMKey, err error) {
+	defer observe("get", "dkim")(&err)
+	return s.inner.GetDKIMKey(domainId, keyId)
+}
+
+func (s *instrumentedStorage) GetDKIMPolicy(domainId happydns.Identifier) (ret *happydns.DKIMPolicy, err error) {
+	defer observe("get", "dkim")(&err)
+	return s.inner.GetDKIMPolicy(domainId)
+}
+
+func (s *instrumentedStorage) GetDMARCReportSettings(domainId happydns.Identifier) (ret *happydns.DMARCReportSettings, err error) {
+	defer observe("get", "dmarc_report")(&err)
+	return s.inner.GetDMARCReportSettings(domainId)
+}
+
 func (s *instrumentedStorage) GetDomain(domainid happydns.Identifier) (ret *happydns.Domain, err error) {
 	defer observe("get", "domain")(&err)
 	return s.inner.GetDomain(domainid)
@@ -387,6 +457,11 @@ func (s *instrumentedStorage) GetState(checkerID string, target happydns.CheckTa
 	return s.inner.GetState(checkerID, target, userId)
 }
 
+func (s *instrumentedStorage) GetTLSReportSettings(domainId happydns.Identifier) (ret *happydns.TLSReportSettings, err error) {
+	defer observe("get", "tls_report")(&err)
+	return s.inner.GetTLSReportSettings(domainId)
+}
+
 func (s *instrumentedStorage) GetUser(userid happydns.Identifier) (ret *happydns.User, err error) {
 	defer observe("get", "user")(&err)
 	return s.inner.GetUser(userid)
@@ -422,6 +497,11 @@ func (s *instrumentedStorage) ListAllAuthUsers() (ret happydns.Iterator[happydns
 	return s.inner.ListAllAuthUsers()
 }
 
+func (s *instrumentedStorage) ListAllBuiltinZones() (ret happydns.Iterator[happydns.BuiltinZone], err error) {
+	defer observe("list", "builtin_zone")(&err)
+	return s.inner.ListAllBuiltinZones()
+}
+
 func (s *instrumentedStorage) ListAllCachedObservations() (ret happydns.Iterator[happydns.ObservationCacheEntry], err error) {
 	defer observe("list", "observation_cache")(&err)
 	return s.inner.ListAllCachedObservations()
@@ -437,6 +517,11 @@ func (s *instrumentedStorage) ListAllCheckerConfigurations() (ret happydns.Itera
 	return s.inner.ListAllCheckerConfigurations()
 }
 
+func (s *instrumentedStorage) ListAllDKIMPolicies() (ret []*happydns.DKIMPolicy, err error) {
+	defer observe("list", "dkim")(&err)
+	return s.inner.ListAllDKIMPolicies()
+}
+
 func (s *instrumentedStorage) ListAllDiscoveryEntries() (ret happydns.Iterator[happydns.StoredDiscoveryEntry], err error) {
 	defer observe("list", "discovery_entry")(&err)
 	return s.inner.ListAllDiscoveryEntries()
@@ -467,6 +552,11 @@ func (s *instrumentedStorage) ListAllExecutions() (ret happydns.Iterator[happydn
 	return s.inner.ListAllExecutions()
 }
 
+func (s *instrumentedStorage) ListAllProviderCalls() (ret happydns.Iterator[happydns.ProviderCall], err error) {
+	defer observe("list", "provider_call")(&err)
+	return s.inner.ListAllProviderCalls()
+}
+
 func (s *instrumentedStorage) ListAllProviders() (ret happydns.Iterator[happydns.ProviderMessage], err error) {
 	defer observe("list", "provider")(&err)
 	return s.inner.ListAllProviders()
@@ -497,6 +587,11 @@ func (s *instrumentedStorage) ListAuthUserSessions(user *happydns.UserAuth) (ret
 	return s.inner.ListAuthUserSessions(user)
 }
 
+func (s *instrumentedStorage) ListBuiltinZones(providerId happydns.Identifier) (ret []*happydns.BuiltinZone, err error) {
+	defer observe("list", "builtin_zone")(&err)
+	return s.inner.ListBuiltinZones(providerId)
+}
+
 func (s *instrumentedStorage) ListChannelsByUser(userId happydns.Identifier) (ret []*happydns.NotificationChannel, err error) {
 	defer observe("list", "notification_channel")(&err)
 	return s.inner.ListChannelsByUser(userId)
@@ -522,6 +617,16 @@ func (s *instrumentedStorage) ListCheckerConfiguration(checkerName string) (ret
 	return s.inner.ListCheckerConfiguration(checkerName)
 }
 
+func (s *instrumentedStorage) ListDKIMKeys(domainId happydns.Identifier) (ret []*happydns.DKIMKey, err error) {
+	defer observe("list", "dkim")(&err)
+	return s.inner.ListDKIMKeys(domainId)
+}
+
+func (s *instrumentedStorage) ListDMARCReports(domainId happydns.Identifier) (ret []*happydns.DMARCReport, err error) {
+	defer observe("list", "dmarc_report")(&err)
+	return s.inner.ListDMARCReports(domainId)
+}
+
 func (s *instrumentedStorage) ListDiscoveryEntriesByProducer(producerID string, target happydns.CheckTarget) (ret []*happydns.StoredDiscoveryEntry, err error) {
 	defer observe("list", "discovery_entry")(&err)
 	return s.inner.ListDiscoveryEntriesByProducer(producerID, target)
@@ -582,6 +687,11 @@ func (s *instrumentedStorage) ListPreferencesByUser(userId happydns.Identifier)
 	return s.inner.ListPreferencesByUser(userId)
 }
 
+func (s *instrumentedStorage) ListProviderCalls(domainId happydns.Identifier) (ret []*happydns.ProviderCall, err error) {
+	defer observe("list", "provider_call")(&err)
+	return s.inner.ListProviderCalls(domainId)
+}
+
 func (s *instrumentedStorage) ListProviders(user *happydns.User) (ret happydns.ProviderMessages, err error) {
 	defer observe("list", "provider")(&err)
 	return s.inner.ListProviders(user)
@@ -597,6 +707,11 @@ func (s *instrumentedStorage) ListStatesByUser(userId happydns.Identifier) (ret
 	return s.inner.ListStatesByUser(userId)
 }
 
+func (s *instrumentedStorage) ListTLSReports(domainId happydns.Identifier) (ret []*happydns.TLSReport, err error) {
+	defer observe("list", "tls_report")(&err)
+	return s.inner.ListTLSReports(domainId)
+}
+
 func (s *instrumentedStorage) ListUserSessions(userid happydns.Identifier) (ret []*happydns.Session, err error) {
 	defer observe("list", "session")(&err)
 	return s.inner.ListUserSessions(userid)
@@ -604,11 +719,26 @@ func (s *instrumentedStorage) ListUserSessions(userid happydns.Identifier) (ret
 
 func (s *instrumentedStorage) MigrateSchema() error { return s.inner.MigrateSchema() }
 
+func (s *instrumentedStorage) PutBuiltinZone(zone *happydns.BuiltinZone) (err error) {
+	defer observe("put", "builtin_zone")(&err)
+	return s.inner.PutBuiltinZone(zone)
+}
+
 func (s *instrumentedStorage) PutCachedObservation(target happydns.CheckTarget, key happydns.ObservationKey, entry *happydns.ObservationCacheEntry) (err error) {
 	defer observe("put", "observation_cache")(&err)
 	return s.inner.PutCachedObservation(target, key, entry)
 }
 
+func (s *instrumentedStorage) PutDKIMPolicy(policy *happydns.DKIMPolicy) (err error) {
+	defer observe("put", "dkim")(&err)
+	return s.inner.PutDKIMPolicy(policy)
+}
+
+func (s *instrumentedStorage) PutDMARCReportSettings(settings *happydns.DMARCReportSettings) (err error) {
+	defer observe("put", "dmarc_report")(&err)
+	return s.inner.PutDMARCReportSettings(settings)
+}
+
 func (s *instrumentedStorage) PutDiscoveryObservationRef(ref *happydns.DiscoveryObservationRef) (err error) {
 	defer observe("put", "discovery_observation")(&err)
 	return s.inner.PutDiscoveryObservationRef(ref)
@@ -619,6 +749,11 @@ func (s *instrumentedStorage) PutState(state *happydns.NotificationState) (err e
 	return s.inner.PutState(state)
 }
 
+func (s *instrumentedStorage) PutTLSReportSettings(settings *happydns.TLSReportSettings) (err error) {
+	defer observe("put", "tls_report")(&err)
+	return s.inner.PutTLSReportSettings(settings)
+}
+
 func (s *instrumentedStorage) ReplaceDiscoveryEntries(producerID string, target happydns.CheckTarget, entries []happydns.DiscoveryEntry) (err error) {
 	defer observe("update", "discovery_entry")(&err)
 	return s.inner.ReplaceDiscoveryEntries(producerID, target, entries)
@@ -696,6 +831,11 @@ func (s *instrumentedStorage) UpdateCheckerConfiguration(checkerName string, use
 	return s.inner.UpdateCheckerConfiguration(checkerName, userId, domainId, serviceId, opts)
 }
 
+func (s *instrumentedStorage) UpdateDKIMKey(key *happydns.DKIMKey) (err error) {
+	defer observe("update", "dkim")(&err)
+	return s.inner.UpdateDKIMKey(key)
+}
+
 func (s *instrumentedStorage) UpdateDomain(domain *happydns.Domain) (err error) {
 	defer observe("update", "domain")(&err)
 	return s.inner.UpdateDomain(domain)
@@ -64,6 +64,34 @@ func (app *App) Start() {
 		app.usecases.checkerUserGater.Start(context.Background())
 	}
 
+	if app.usecases.dkimRotator != nil {
+		app.usecases.dkimRotator.Start(context.Background())
+	}
+
+	if app.usecases.aliasRefresher != nil {
+		app.usecases.aliasRefresher.Start(context.Background())
+	}
+
+	if app.usecases.spfRefresher != nil {
+		app.usecases.spfRefresher.Start(context.Background())
+	}
+
+	if app.usecases.zoneSyncWatcher != nil {
+		app.usecases.zoneSyncWatcher.Start(context.Background())
+	}
+
+	if app.usecases.dmarcReportJanitor != nil {
+		app.usecases.dmarcReportJanitor.Start(context.Background())
+	}
+
+	if app.usecases.tlsReportJanitor != nil {
+		app.usecases.tlsReportJanitor.Start(context.Background())
+	}
+
+	if app.usecases.reportsPoller != nil {
+		app.usecases.reportsPoller.Start(context.Background())
+	}
+
 	if app.usecases.notificationDispatcher != nil {
 		app.usecases.notificationDispatcher.Start()
 	}
@@ -90,6 +118,34 @@ func (app *App) Stop() {
 		app.usecases.checkerUserGater.Stop()
 	}
 
+	if app.usecases.dkimRotator != nil {
+		app.usecases.dkimRotator.Stop()
+	}
+
+	if app.usecases.aliasRefresher != nil {
+		app.usecases.aliasRefresher.Stop()
+	}
+
+	if app.usecases.spfRefresher != nil {
+		app.usecases.spfRefresher.Stop()
+	}
+
+	if app.usecases.zoneSyncWatcher != nil {
+		app.usecases.zoneSyncWatcher.Stop()
+	}
+
+	if app.usecases.dmarcReportJanitor != nil {
+		app.usecases.dmarcReportJanitor.Stop()
+	}
+
+	if app.usecases.tlsReportJanitor != nil {
+		app.usecases.tlsReportJanitor.Stop()
+	}
+
+	if app.usecases.reportsPoller != nil {
+		app.usecases.reportsPoller.Stop()
+	}
+
 	// Drain in-flight notification sends after the scheduler is stopped
 	// so no new jobs can be enqueued while we wait.
 	if app.usecases.notificationDispatcher != nil {
@@ -161,21 +161,33 @@ func (app *App) setupRouter() {
 			Authentication:        app.usecases.authentication,
 			AuthUser:              app.usecases.authUser,
 			CaptchaVerifier:       app.captchaVerifier,
+			DKIM:                  app.usecases.dkim,
+			DMARCReport:           app.usecases.dmarcReport,
 			Domain:                app.usecases.domain,
 			DomainInfo:            app.usecases.domainInfo,
 			DomainLog:             app.usecases.domainLog,
 			EmailAutoconfig:       app.usecases.emailAutoconfig,
+			EmailKeys:             app.usecases.emailKeys,
 			FailureTracker:        app.failureTracker,
 			FaviconService:        app.faviconService,
+			Onboarding:            app.usecases.onboarding,
 			OutboundGuard:         app.guards.Outbound,
 			Provider:              app.usecases.provider,
+			ProviderDetection:     app.usecases.providerDetection,
+			ProviderJournal:       app.usecases.providerJournal,
+			ProviderMigration:     app.usecases.providerMigration,
 			ProviderSettings:      app.usecases.providerSettings,
 			ProviderSpecs:         app.usecases.providerSpecs,
+			Registrar:             app.usecases.registrar,
 			RemoteZoneImporter:    app.usecases.orchestrator.RemoteZoneImporter,
 			Resolver:              app.usecases.resolver,
+			ReverseDNS:            app.usecases.reverseDNS,
 			Service:               app.usecases.service,
 			ServiceSpecs:          app.usecases.serviceSpecs,
 			Session:               app.usecases.session,
+			SSHFPGenerator:        app.usecases.sshfpGenerator,
+			TLSAGenerator:         app.usecases.tlsaGenerator,
+			TLSReport:             app.usecases.tlsReport,
 			User:                  app.usecases.user,
 			Zone:                  app.usecases.zone,
 			ZoneCorrectionApplier: app.usecases.orchestrator.ZoneCorrectionApplier,
@@ -22,29 +22,52 @@
 package app
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"log"
 	"strings"
+	"time"
 
 	checkerPkg "git.happydns.org/happyDomain/internal/dnschecker"
+	"git.happydns.org/happyDomain/internal/mailbox"
 	notifPkg "git.happydns.org/happyDomain/internal/notifier"
+	"git.happydns.org/happyDomain/internal/providerlimit"
 	"git.happydns.org/happyDomain/internal/usecase"
+	aliasflattenUC "git.happydns.org/happyDomain/internal/usecase/aliasflatten"
 	authuserUC "git.happydns.org/happyDomain/internal/usecase/authuser"
 	backupUC "git.happydns.org/happyDomain/internal/usecase/backup"
 	checkerUC "git.happydns.org/happyDomain/internal/usecase/checker"
+	dkimUC "git.happydns.org/happyDomain/internal/usecase/dkim"
+	dmarcReportUC "git.happydns.org/happyDomain/internal/usecase/dmarcreport"
 	domainUC "git.happydns.org/happyDomain/internal/usecase/domain"
 	domainlogUC "git.happydns.org/happyDomain/internal/usecase/domain_log"
 	emailAutoconfigUC "git.happydns.org/happyDomain/internal/usecase/emailautoconfig"
+	emailkeysUC "git.happydns.org/happyDomain/internal/usecase/emailkeys"
+	failoverUC "git.happydns.org/happyDomain/internal/usecase/failover"
+	migrationUC "git.happydns.org/happyDomain/internal/usecase/migration"
 	notifUC "git.happydns.org/happyDomain/internal/usecase/notification"
+	onboardingUC "git.happydns.org/happyDomain/internal/usecase/onboarding"
 	"git.happydns.org/happyDomain/internal/usecase/orchestrator"
 	providerUC "git.happydns.org/happyDomain/internal/usecase/provider"
+	providerDetectionUC "git.happydns.org/happyDomain/internal/usecase/providerdetection"
+	providerJournalUC "git.happydns.org/happyDomain/internal/usecase/providerjournal"
+	registrarUC "git.happydns.org/happyDomain/internal/usecase/registrar"
+	reportJanitorUC "git.happydns.org/happyDomain/internal/usecase/reportjanitor"
+	reverseDNSUC "git.happydns.org/happyDomain/internal/usecase/reversedns"
 	serviceUC "git.happydns.org/happyDomain/internal/usecase/service"
 	sessionUC "git.happydns.org/happyDomain/internal/usecase/session"
+	spfflattenUC "git.happydns.org/happyDomain/internal/usecase/spfflatten"
+	sshfpgenUC "git.happydns.org/happyDomain/internal/usecase/sshfpgen"
+	tlsagenUC "git.happydns.org/happyDomain/internal/usecase/tlsagen"
+	tlsReportUC "git.happydns.org/happyDomain/internal/usecase/tlsreport"
 	userUC "git.happydns.org/happyDomain/internal/usecase/user"
 	zoneUC "git.happydns.org/happyDomain/internal/usecase/zone"
 	zoneServiceUC "git.happydns.org/happyDomain/internal/usecase/zone_service"
 	"git.happydns.org/happyDomain/model"
 	"git.happydns.org/happyDomain/pkg/domaininfo"
 	"git.happydns.org/happyDomain/pkg/favicon"
+	"git.happydns.org/happyDomain/providers"
 	"git.happydns.org/happyDomain/services/abstract"
 )
 
@@ -57,8 +80,21 @@ func (app *App) initUsecases() {
 		sessionService,
 	)
 	domainLogService := domainlogUC.NewService(app.store)
+	// The built-in provider keeps its zones next to everything else.
+	providers.SetBuiltinZoneStorage(app.store)
+
 	providerService := providerUC.NewRestrictedService(app.cfg, app.store, app.guards.Outbound)
 	providerAdminService := providerUC.NewService(app.store, nil, app.guards.Outbound)
+
+	// Both services share one limiter, hence the quota of each account.
+	providerLimiter := providerlimit.NewLimiter(providerlimit.Config{
+		Rate:    app.cfg.ProviderRateLimit,
+		Burst:   app.cfg.ProviderRateBurst,
+		Retries: app.cfg.ProviderRetries,
+		MaxWait: app.cfg.ProviderMaxWait,
+	})
+	providerService.SetLimiter(providerLimiter)
+	providerAdminService.SetLimiter(providerLimiter)
 	serviceService := serviceUC.NewServiceUsecases()
 	zoneService := zoneUC.NewZoneUsecases(app.store, serviceService)
 
@@ -76,6 +112,7 @@ func (app *App) initUsecases() {
 		domaininfo.GetDomainWhoisInfo,
 	)
 	app.usecases.domainLog = domainLogService
+	app.usecases.resolver = usecase.NewResolverUsecase(app.cfg, app.guards.Resolver, app.guards.Outbound)
 
 	// Email auto-configuration: derive the autoconfig CNAME target from
 	// MailAutoconfigHost (if set) or fall back to ExternalURL.Host.
@@ -84,7 +121,15 @@ func (app *App) initUsecases() {
 		autoconfigHost = app.cfg.ExternalURL.Hostname()
 	}
 	abstract.SetAutoconfigHost(autoconfigHost)
-	app.usecases.emailAutoconfig = emailAutoconfigUC.NewUsecase(app.store, zoneService.GetZoneUC)
+	emailAutoconfigService := emailAutoconfigUC.NewUsecase(app.store, zoneService.GetZoneUC, app.usecases.resolver)
+	if app.cfg.MailProfileSigningCert != "" || app.cfg.MailProfileSigningKey != "" {
+		cert, err := tls.LoadX509KeyPair(app.cfg.MailProfileSigningCert, app.cfg.MailProfileSigningKey)
+		if err != nil {
+			log.Fatalf("Unable to load the configuration profile signing certificate: %s", err)
+		}
+		emailAutoconfigService.SetProfileSigner(&cert)
+	}
+	app.usecases.emailAutoconfig = emailAutoconfigService
 
 	domainService := domainUC.NewService(
 		app.store,
@@ -113,9 +158,11 @@ func (app *App) initUsecases() {
 	app.usecases.authentication = usecase.NewAuthenticationUsecase(app.cfg, app.store, app.usecases.user)
 	app.usecases.authUser = authUserService
 	app.usecases.authUserAdmin = authUserService
-	app.usecases.resolver = usecase.NewResolverUsecase(app.cfg, app.guards.Resolver, app.guards.Outbound)
 	app.usecases.session = sessionService
 
+	// The provider hosting a domain is guessed from its name servers.
+	app.usecases.providerDetection = providerDetectionUC.NewService(app.usecases.resolver, providerService)
+
 	app.usecases.orchestrator = orchestrator.NewOrchestrator(
 		domainLogService,
 		domainService,
@@ -128,6 +175,87 @@ func (app *App) initUsecases() {
 		zoneService.UpdateZoneUC,
 	)
 
+	// ALIAS records of the providers lacking them are published flattened.
+	app.usecases.orchestrator.SetAliasFlattener(aliasflattenUC.NewFlattener(app.usecases.resolver))
+	app.usecases.orchestrator.SetSPFFlattener(spfflattenUC.NewFlattener(app.usecases.resolver))
+
+	// The providers having their journal enabled keep what each publication
+	// executed.
+	app.usecases.providerJournal = providerJournalUC.NewService(app.store)
+	app.usecases.orchestrator.SetProviderJournal(app.usecases.providerJournal)
+
+	// The comments of the services travel along their records, to the
+	// providers able to store them.
+	app.usecases.orchestrator.SetZoneCommenter(providerAdminService)
+
+	// Successive diffs of a domain reuse the records just fetched from its
+	// provider.
+	app.usecases.orchestrator.SetZoneCacheTTL(app.cfg.ProviderZoneCacheTTL)
+
+	app.usecases.aliasRefresher = aliasflattenUC.NewRefresher(
+		app.store,
+		providerAdminService,
+		zoneService.GetZoneUC,
+		app.usecases.orchestrator.ZoneCorrectionApplier,
+		domainLogService,
+		15*time.Minute,
+	)
+
+	// The zones of a provider can be added at once, and those of the
+	// providers kept in sync as they get created.
+	onboardingService := onboardingUC.NewService(
+		providerAdminService,
+		domainService,
+		app.usecases.orchestrator.RemoteZoneImporter,
+	)
+	app.usecases.onboarding = onboardingService
+	app.usecases.zoneSyncWatcher = onboardingUC.NewWatcher(
+		app.store,
+		onboardingService,
+		domainLogService,
+		time.Hour,
+	)
+
+	app.usecases.providerMigration = migrationUC.NewService(
+		providerAdminService,
+		app.usecases.orchestrator.ZoneCorrectionApplier,
+		domainService,
+		domainLogService,
+		zoneService.GetZoneUC,
+		zoneService.ListRecordsUC,
+		app.usecases.zoneService,
+		app.usecases.resolver,
+	)
+
+	app.usecases.registrar = registrarUC.NewService(
+		providerAdminService,
+		providerAdminService,
+		app.usecases.domainInfo,
+		app.usecases.resolver,
+		domainLogService,
+	)
+
+	app.usecases.reverseDNS = reverseDNSUC.NewService(
+		app.store,
+		domainService,
+		zoneService.GetZoneUC,
+		zoneService.ListRecordsUC,
+		app.usecases.zoneService,
+		app.usecases.orchestrator.ZoneCorrectionApplier,
+	)
+
+	dkimService := dkimUC.NewService(
+		app.store,
+		app.keyring,
+		zoneService.GetZoneUC,
+		app.usecases.zoneService,
+		app.usecases.orchestrator.ZoneCorrectionApplier,
+		domainLogService,
+		app.guards.Outbound,
+	)
+	app.usecases.dkim = dkimService
+	app.usecases.dkimRotator = dkimUC.NewRotator(app.store, dkimService, time.Hour)
+
 	// Checker system.
 	checkerPkg.SetHTTPTimeout(app.cfg.CheckerHTTPTimeout)
 	app.usecases.checkerOptionsUC = checkerUC.NewCheckerOptionsUsecase(app.store, app.store).
@@ -201,8 +329,85 @@ func (app *App) initUsecases() {
 		ack,
 		stateLocker,
 	)
+
+	// Failover services switch their published target according to the
+	// executions of the checker plans watching them.
+	app.usecases.spfRefresher = spfflattenUC.NewRefresher(
+		app.store,
+		zoneService.GetZoneUC,
+		app.usecases.orchestrator.ZoneCorrectionApplier,
+		domainLogService,
+		app.usecases.notificationDispatcher,
+		time.Hour,
+	)
+
+	failoverService := failoverUC.NewService(
+		app.store,
+		app.store,
+		app.store,
+		zoneService.GetZoneUC,
+		app.usecases.zoneService,
+		app.usecases.orchestrator.ZoneCorrectionApplier,
+		domainLogService,
+		app.usecases.notificationDispatcher,
+	)
+
+	// TLSA records are regenerated when the DANE checker finds them out of
+	// date with the deployed certificate.
+	tlsaService := tlsagenUC.NewService(
+		tlsagenUC.NewFetcher(app.guards.Outbound),
+		app.store,
+		app.store,
+		zoneService.GetZoneUC,
+		app.usecases.zoneService,
+		domainLogService,
+		app.usecases.notificationDispatcher,
+	)
+	app.usecases.tlsaGenerator = tlsaService
+
+	app.usecases.emailKeys = emailkeysUC.NewService(app.usecases.zoneService)
+
+	app.usecases.sshfpGenerator = sshfpgenUC.NewService(
+		sshfpgenUC.NewScanner(app.guards.Outbound),
+		zoneService.GetZoneUC,
+		zoneService.ListRecordsUC,
+	)
+
 	if cb, ok := app.usecases.checkerEngine.(checkerUC.ExecutionCallbackSetter); ok {
-		cb.SetExecutionCallback(app.usecases.notificationDispatcher.OnExecutionComplete)
+		cb.SetExecutionCallback(func(exec *happydns.Execution, eval *happydns.CheckEvaluation) {
+			app.usecases.notificationDispatcher.OnExecutionComplete(exec, eval)
+			failoverService.OnExecutionComplete(exec, eval)
+			tlsaService.OnExecutionComplete(exec, eval)
+		})
+	}
+
+	// Aggregate reports: they feed the same notification pipeline as the
+	// checkers, and can be fetched from the mailbox receiving them.
+	dmarcReportService := dmarcReportUC.NewService(app.store, app.store, app.usecases.notificationDispatcher, app.cfg.ReportsAddress, app.cfg.ReportsRetentionDays)
+	app.usecases.dmarcReport = dmarcReportService
+	app.usecases.dmarcReportJanitor = reportJanitorUC.NewJanitor("DMARC reports", app.store, app.store.ListDMARCReports, dmarcReportService.Expired, app.store.DeleteDMARCReport, 24*time.Hour)
+
+	tlsReportService := tlsReportUC.NewService(app.store, app.store, app.usecases.notificationDispatcher, baseURL, app.cfg.ReportsAddress, app.cfg.ReportsRetentionDays)
+	app.usecases.tlsReport = tlsReportService
+	app.usecases.tlsReportJanitor = reportJanitorUC.NewJanitor("TLS reports", app.store, app.store.ListTLSReports, tlsReportService.Expired, app.store.DeleteTLSReport, 24*time.Hour)
+
+	if app.cfg.ReportsIMAPAddress != "" {
+		mbCfg := mailbox.Config{
+			Address:  app.cfg.ReportsIMAPAddress,
+			TLS:      app.cfg.ReportsIMAPTLS,
+			Username: app.cfg.ReportsIMAPUsername,
+			Password: app.cfg.ReportsIMAPPassword,
+			Mailbox:  app.cfg.ReportsIMAPMailbox,
+		}
+		if err := mbCfg.Validate(); err != nil {
+			log.Fatalf("Invalid -reports-imap-address: %s", err)
+		}
+		app.usecases.reportsPoller = mailbox.NewPoller(mbCfg, func(ctx context.Context, msg *mailbox.Message, attachments []mailbox.Attachment) error {
+			return errors.Join(
+				dmarcReportService.IngestMessage(ctx, msg, attachments),
+				tlsReportService.IngestMessage(ctx, msg, attachments),
+			)
+		}, app.cfg.ReportsIMAPInterval)
 	}
 }
 
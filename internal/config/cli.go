@@ -44,6 +44,11 @@ func declareFlags(o *happydns.Options) {
 	flag.Var(targetList(&o.ResolverAllowedTargets, "resolver target"), "resolver-allowed-target", "IP address (or CIDR block) that the DNS server chosen in the resolver tool may point at even though it is not publicly routable; does not affect -default-ns nor the local resolver; same syntax as -outbound-allowed-target (see docs/outbound-targets.md)")
 	flag.Var(faviconSourceList(&o.FaviconSources), "favicon-source", "Where to get the icons shown next to domains and providers, in order of preference: \"direct\" asks each site itself, \"duckduckgo\" and \"google\" ask that service (faster and lighter, but they only know the sites they crawled and they learn which domains you look up), or any URL template containing {domain}; may be repeated or comma separated; use \"none\" to fetch no icon at all (see docs/favicons.md)")
 	flag.BoolVar(&o.DisableProviders, "disable-providers-edit", o.DisableProviders, "Disallow all actions on provider (add/edit/delete)")
+	flag.Float64Var(&o.ProviderRateLimit, "provider-rate-limit", 5, "Calls per second allowed to a provider account, shared by every provider using the same credentials (0 disables the limit; see docs/provider-rate-limit.md)")
+	flag.IntVar(&o.ProviderRateBurst, "provider-rate-burst", 10, "Calls allowed at once to a provider account above -provider-rate-limit")
+	flag.IntVar(&o.ProviderRetries, "provider-retries", 3, "How many times a read throttled by the provider, or timing out, is attempted again")
+	flag.DurationVar(&o.ProviderMaxWait, "provider-max-wait", 2*time.Minute, "How long a call may wait for the rate limit of its provider before failing")
+	flag.DurationVar(&o.ProviderZoneCacheTTL, "provider-zone-cache-ttl", time.Minute, "How long the records fetched from a provider are reused to compute diffs (0 to disable)")
 	flag.BoolVar(&o.DisableRegistration, "disable-registration", o.DisableRegistration, "Forbids new account creation through public form/API (still allow registration from external services)")
 	flag.BoolVar(&o.DisableEmbeddedLogin, "disable-embedded-login", o.DisableEmbeddedLogin, "Disables the internal user/password login in favor of external-auth or OIDC")
 	flag.Var(&URL{&o.ExternalURL}, "externalurl", "Begining of the URL, before the base, that should be used eg. in mails")
@@ -52,6 +57,10 @@ func declareFlags(o *happydns.Options) {
 	flag.StringVar(&o.StorageEngine, "storage-engine", o.StorageEngine, fmt.Sprintf("Select the storage engine between %v", storage.GetStorageEngines()))
 	flag.BoolVar(&o.NoAuth, "no-auth", false, "Disable user access control, use default account")
 	flag.Var(&JWTSecretKey{&o.JWTSecretKey}, "jwt-secret-key", "Secret key used to verify JWT authentication tokens (a random secret is used if undefined)")
+	flag.Var(&secretKey{&o.SecretKey}, "secret-key", "Base64-encoded 32-byte master key sealing the secrets happyDomain keeps at rest, such as provider credentials and generated DKIM private keys (prefer -secret-key-file)")
+	flag.Var(&secretKeyFile{secretKey: secretKey{&o.SecretKey}}, "secret-key-file", "Path to a file holding the base64-encoded master key (see -secret-key)")
+	flag.Var(&previousSecretKeys{&o.PreviousSecretKeys}, "secret-key-previous", "Comma-separated list of base64-encoded master keys replaced by -secret-key, still accepted to open what they sealed (prefer -secret-key-previous-file)")
+	flag.Var(&previousSecretKeysFile{previousSecretKeys: previousSecretKeys{&o.PreviousSecretKeys}}, "secret-key-previous-file", "Path to a file holding the base64-encoded master keys replaced by -secret-key, one per line")
 	flag.Var(&URL{&o.ExternalAuth}, "external-auth", "Base URL to use for login and registration (use embedded forms if left empty)")
 	flag.BoolVar(&o.OptOutInsights, "opt-out-insights", false, "Disable the anonymous usage statistics report. If you care about this project and don't participate in discussions, don't opt-out.")
 	flag.IntVar(&o.CheckerMaxConcurrency, "checker-max-concurrency", runtime.NumCPU(), "Maximum number of checker jobs that can run simultaneously")
@@ -74,7 +83,19 @@ func declareFlags(o *happydns.Options) {
 	flag.StringVar(&o.MailSMTPPassword, "mail-smtp-password", o.MailSMTPPassword, "Password associated with the given username for SMTP authentication")
 	flag.BoolVar(&o.MailSMTPTLSSNoVerify, "mail-smtp-tls-no-verify", o.MailSMTPTLSSNoVerify, "Do not verify certificate validity on SMTP connection")
 
+	flag.StringVar(&o.ReportsIngestToken, "reports-ingest-token", o.ReportsIngestToken, "Bearer token required to post aggregate reports to /api/reports/dmarc and /api/reports/tlsrpt (endpoints disabled when empty)")
+	flag.StringVar(&o.ReportsAddress, "reports-address", o.ReportsAddress, "Email address of the mailbox receiving the aggregate reports; each domain publishes its own subaddress of it (reports+tag@example.com)")
+	flag.IntVar(&o.ReportsRetentionDays, "reports-retention-days", 180, "How many days the received DMARC and TLS aggregate reports are kept")
+	flag.StringVar(&o.ReportsIMAPAddress, "reports-imap-address", o.ReportsIMAPAddress, "host:port of the IMAP server holding the mailbox receiving the aggregate reports (no mailbox is polled when empty)")
+	flag.BoolVar(&o.ReportsIMAPTLS, "reports-imap-tls", true, "Connect to the reports IMAP server over TLS (can only be disabled for a loopback address)")
+	flag.StringVar(&o.ReportsIMAPUsername, "reports-imap-username", o.ReportsIMAPUsername, "Username to log in the reports IMAP server")
+	flag.StringVar(&o.ReportsIMAPPassword, "reports-imap-password", o.ReportsIMAPPassword, "Password to log in the reports IMAP server")
+	flag.StringVar(&o.ReportsIMAPMailbox, "reports-imap-mailbox", "INBOX", "Mailbox polled for aggregate reports")
+	flag.DurationVar(&o.ReportsIMAPInterval, "reports-imap-interval", 15*time.Minute, "How often the reports mailbox is polled")
+
 	flag.StringVar(&o.MailAutoconfigHost, "mail-autoconfig-host", o.MailAutoconfigHost, "Public FQDN serving Mozilla Autoconfig and Microsoft Autodiscover (defaults to externalurl host)")
+	flag.StringVar(&o.MailProfileSigningCert, "mail-profile-signing-cert", o.MailProfileSigningCert, "Path to the PEM certificate chain signing the Apple configuration profiles (served unsigned when empty)")
+	flag.StringVar(&o.MailProfileSigningKey, "mail-profile-signing-key", o.MailProfileSigningKey, "Path to the PEM private key of -mail-profile-signing-cert")
 
 	flag.StringVar(&o.CaptchaProvider, "captcha-provider", o.CaptchaProvider, "Captcha provider to use for bot protection (altcha, hcaptcha, recaptchav2, turnstile, or empty to disable)")
 	flag.IntVar(&o.CaptchaLoginThreshold, "captcha-login-threshold", 3, "Number of failed login attempts before captcha is required (0 = always require when provider configured)")
@@ -27,9 +27,12 @@ import (
 	"net/mail"
 	"net/netip"
 	"net/url"
+	"os"
 	"strconv"
 	"strings"
+	"unicode"
 
+	"git.happydns.org/happyDomain/internal/secretbox"
 	"git.happydns.org/happyDomain/model"
 	"git.happydns.org/happyDomain/pkg/favicon"
 )
@@ -241,6 +244,109 @@ func (i *JWTSecretKey) Set(value string) error {
 	return nil
 }
 
+// secretKey is a flag.Value holding the master key that seals the secrets
+// kept at rest. It accepts the key base64-encoded, the only form an
+// environment variable or a config file line can carry.
+type secretKey struct {
+	Secret *[]byte
+}
+
+func (i *secretKey) String() string {
+	// Never echo the key back, not even in -help defaults.
+	return ""
+}
+
+func (i *secretKey) Set(value string) error {
+	z, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("secret key is not valid base64: %w", err)
+	}
+
+	if len(z) != secretbox.KeySize {
+		return fmt.Errorf("secret key must decode to %d bytes, got %d (generate one with `head -c %d /dev/urandom | base64`)", secretbox.KeySize, len(z), secretbox.KeySize)
+	}
+
+	*i.Secret = z
+	return nil
+}
+
+// secretKeyFile is a flag.Value reading the master key from a file, so that it
+// can come from a mounted secret rather than the process environment. The file
+// holds the key base64-encoded, as -secret-key does.
+type secretKeyFile struct {
+	secretKey
+	path string
+}
+
+func (i *secretKeyFile) String() string {
+	return i.path
+}
+
+func (i *secretKeyFile) Set(value string) error {
+	content, err := os.ReadFile(value)
+	if err != nil {
+		return fmt.Errorf("unable to read secret key file: %w", err)
+	}
+
+	if err := i.secretKey.Set(string(content)); err != nil {
+		return fmt.Errorf("%s: %w", value, err)
+	}
+
+	i.path = value
+	return nil
+}
+
+// previousSecretKeys is a flag.Value holding the master keys replaced by
+// -secret-key, given base64-encoded and separated by commas or spaces.
+type previousSecretKeys struct {
+	Secrets *[][]byte
+}
+
+func (i *previousSecretKeys) String() string {
+	// Never echo the keys back, as secretKey does.
+	return ""
+}
+
+func (i *previousSecretKeys) Set(value string) error {
+	var keys [][]byte
+
+	for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		var key []byte
+		if err := (&secretKey{&key}).Set(field); err != nil {
+			return fmt.Errorf("previous %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	*i.Secrets = keys
+	return nil
+}
+
+// previousSecretKeysFile is a flag.Value reading the previous master keys from
+// a file, one per line.
+type previousSecretKeysFile struct {
+	previousSecretKeys
+	path string
+}
+
+func (i *previousSecretKeysFile) String() string {
+	return i.path
+}
+
+func (i *previousSecretKeysFile) Set(value string) error {
+	content, err := os.ReadFile(value)
+	if err != nil {
+		return fmt.Errorf("unable to read previous secret keys file: %w", err)
+	}
+
+	if err := i.previousSecretKeys.Set(string(content)); err != nil {
+		return fmt.Errorf("%s: %w", value, err)
+	}
+
+	i.path = value
+	return nil
+}
+
 // mailAddress defines an interface that handle mail.Address configuration
 // throught custom flag.
 type mailAddress struct {
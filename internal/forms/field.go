@@ -107,6 +107,7 @@ type Endpoint struct {
 // dial itself happens deep inside DNSControl or libdns, where no dialer of
 // ours can be injected. Reading the tags back is the only place we control.
 func Endpoints(data any) []Endpoint {
+	data = formData(data)
 	if data == nil {
 		return nil
 	}
@@ -168,6 +169,7 @@ func Endpoints(data any) []Endpoint {
 // Since the struct is already typed, basic type checking is handled by the
 // JSON decoder; this function validates higher-level constraints.
 func ValidateStructValues(data any) error {
+	data = formData(data)
 	if data == nil {
 		return nil
 	}
@@ -299,6 +301,7 @@ func checkMapValueType(fieldType string, value any, label string) error {
 
 // GenStructFields generates corresponding SourceFields of the given Source.
 func GenStructFields(data any) (fields []*happydns.Field) {
+	data = formData(data)
 	if data != nil {
 		dataMeta := reflect.Indirect(reflect.ValueOf(data)).Type()
 
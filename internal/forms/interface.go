@@ -20,3 +20,19 @@
 // along with this program.  If not, see <https://www.gnu.org/licenses/>.
 
 package forms // import "git.happydns.org/happyDomain/forms"
+
+// SettingsHolder is implemented by the bodies that do not carry their settings
+// as their own fields, such as the providers bridged from libdns, whose
+// settings struct is only built at run time. Every function of this package
+// walks the value FormSettings returns instead of the body itself.
+type SettingsHolder interface {
+	FormSettings() any
+}
+
+// formData returns what the functions of this package have to walk for data.
+func formData(data any) any {
+	if h, ok := data.(SettingsHolder); ok {
+		return h.FormSettings()
+	}
+	return data
+}
@@ -23,6 +23,7 @@ package forms // import "git.happydns.org/happyDomain/forms"
 
 import (
 	"bytes"
+	"fmt"
 	"reflect"
 
 	"git.happydns.org/happyDomain/model"
@@ -46,7 +47,7 @@ import (
 // withheld", and claiming that for a credential the user never filled in would
 // make an empty field look set.
 func RedactSecrets(data any) {
-	v := reflect.Indirect(reflect.ValueOf(data))
+	v := reflect.Indirect(reflect.ValueOf(formData(data)))
 	if !v.IsValid() || v.Kind() != reflect.Struct {
 		return
 	}
@@ -108,6 +109,83 @@ func redactValue(fv reflect.Value) {
 	}
 }
 
+// TransformSecrets replaces the value of every non-empty string or []byte
+// field tagged `secret` by what fn returns for it, recursing the same way
+// RedactSecrets does. It is what the storage uses to encrypt credentials at
+// rest, and like RedactSecrets it mutates data in place.
+//
+// Secrets of another kind are left as they are: fn only deals in strings, and
+// no such secret exists today.
+func TransformSecrets(data any, fn func(string) (string, error)) error {
+	v := reflect.Indirect(reflect.ValueOf(formData(data)))
+	if !v.IsValid() || v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return transformStruct(v, fn)
+}
+
+func transformStruct(v reflect.Value, fn func(string) (string, error)) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !isWalkable(sf) {
+			continue
+		}
+
+		fv := v.Field(i)
+
+		if inner := structValue(fv); inner.IsValid() {
+			if err := transformStruct(inner, fn); err != nil {
+				return err
+			}
+			if sf.Anonymous {
+				continue
+			}
+		}
+
+		if !GenField(sf).Secret {
+			continue
+		}
+
+		if err := transformValue(fv, fn); err != nil {
+			return fmt.Errorf("%s: %w", sf.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func transformValue(fv reflect.Value, fn func(string) (string, error)) error {
+	if !fv.CanSet() {
+		return nil
+	}
+
+	switch {
+	case fv.Kind() == reflect.String:
+		if fv.Len() == 0 {
+			return nil
+		}
+		nv, err := fn(fv.String())
+		if err != nil {
+			return err
+		}
+		fv.SetString(nv)
+	case isByteSlice(fv.Type()):
+		if fv.Len() == 0 {
+			return nil
+		}
+		nv, err := fn(string(fv.Bytes()))
+		if err != nil {
+			return err
+		}
+		fv.SetBytes([]byte(nv))
+	}
+
+	return nil
+}
+
 // MergeSecrets carries stored secrets forward across a write: wherever incoming
 // still holds the sentinel RedactSecrets put there, the value from existing is
 // restored. Without it, the redacted body a client just read back and submitted
@@ -122,12 +200,12 @@ func redactValue(fv reflect.Value) {
 // means the user changed the provider type, existing is ignored for the same
 // reason.
 func MergeSecrets(existing, incoming any) {
-	iv := reflect.Indirect(reflect.ValueOf(incoming))
+	iv := reflect.Indirect(reflect.ValueOf(formData(incoming)))
 	if !iv.IsValid() || iv.Kind() != reflect.Struct {
 		return
 	}
 
-	ev := reflect.Indirect(reflect.ValueOf(existing))
+	ev := reflect.Indirect(reflect.ValueOf(formData(existing)))
 	if !ev.IsValid() || ev.Type() != iv.Type() {
 		ev = reflect.Value{}
 	}
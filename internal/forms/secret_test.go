@@ -24,6 +24,7 @@ package forms
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"testing"
 
 	happydns "git.happydns.org/happyDomain/model"
@@ -240,6 +241,45 @@ func TestMergeSecretsNilNestedExisting(t *testing.T) {
 	}
 }
 
+func TestTransformSecrets(t *testing.T) {
+	p := filled()
+	err := TransformSecrets(p, func(v string) (string, error) {
+		return "enc(" + v + ")", nil
+	})
+	if err != nil {
+		t.Fatalf("TransformSecrets: %v", err)
+	}
+
+	if p.Secret != "enc(s3cr3t)" {
+		t.Errorf("Secret = %q", p.Secret)
+	}
+	if p.AppKey != "enc(app-key)" {
+		t.Errorf("embedded AppKey = %q", p.AppKey)
+	}
+	if p.Nested.Token != "enc(nested-token)" {
+		t.Errorf("nested Token = %q", p.Nested.Token)
+	}
+	if string(p.KeyBlob) != "enc(raw-key-material)" {
+		t.Errorf("KeyBlob = %q", p.KeyBlob)
+	}
+	if p.Unset != "" {
+		t.Errorf("empty Unset = %q, want it left empty", p.Unset)
+	}
+	if p.Host != "dns.example.com" || p.Account != "acct" || p.Nested.Endpoint != "https://example.com" {
+		t.Errorf("untagged fields were transformed: %+v", p)
+	}
+}
+
+func TestTransformSecretsStopsOnError(t *testing.T) {
+	p := filled()
+	err := TransformSecrets(p, func(v string) (string, error) {
+		return "", errors.New("boom")
+	})
+	if err == nil {
+		t.Fatal("TransformSecrets ignored the error")
+	}
+}
+
 func TestRedactAndMergeToleratePartialInput(t *testing.T) {
 	// Neither helper may panic on the shapes a handler can realistically hand
 	// them before anything has been decoded.
@@ -250,4 +290,8 @@ func TestRedactAndMergeToleratePartialInput(t *testing.T) {
 	MergeSecrets(nil, nil)
 	MergeSecrets(filled(), nil)
 	MergeSecrets((*testProvider)(nil), filled())
+
+	identity := func(v string) (string, error) { return v, nil }
+	TransformSecrets(nil, identity)
+	TransformSecrets((*testProvider)(nil), identity)
 }
@@ -22,8 +22,10 @@
 package helpers
 
 import (
+	"crypto/sha1"
 	"crypto/sha256"
 	"fmt"
+	"strings"
 )
 
 // EmailIdentifier computes the owner name prefix used by OPENPGPKEY (RFC 7929)
@@ -33,3 +35,29 @@ func EmailIdentifier(username string) string {
 	hash := sha256.Sum256([]byte(username))
 	return fmt.Sprintf("%x", hash[:28])
 }
+
+// zbase32Alphabet is the alphabet of the z-base-32 encoding.
+const zbase32Alphabet = "ybndrfg8ejkmcpqxot1uwisza345h769"
+
+// WKDHash computes the name under which the Web Key Directory serves the key
+// of a local-part: the z-base-32 encoded SHA-1 of the lowercased local-part.
+func WKDHash(localPart string) string {
+	hash := sha1.Sum([]byte(strings.ToLower(localPart)))
+
+	var b strings.Builder
+	var buffer uint32
+	var bits uint
+	for _, c := range hash {
+		buffer = buffer<<8 | uint32(c)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			b.WriteByte(zbase32Alphabet[(buffer>>bits)&0x1f])
+		}
+	}
+	if bits > 0 {
+		b.WriteByte(zbase32Alphabet[(buffer<<(5-bits))&0x1f])
+	}
+
+	return b.String()
+}
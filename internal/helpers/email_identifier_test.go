@@ -49,3 +49,18 @@ func TestEmailIdentifierLength(t *testing.T) {
 		t.Errorf("EmailIdentifier length = %d, expected 56", got)
 	}
 }
+
+func TestWKDHash(t *testing.T) {
+	for _, tt := range []struct {
+		localPart string
+		expected  string
+	}{
+		// draft-koch-openpgp-webkey-service example: Joe.Doe@Example.ORG
+		{"Joe.Doe", "iy9q119eutrkn8s1mk4r39qejnbu3n5q"},
+		{"joe.doe", "iy9q119eutrkn8s1mk4r39qejnbu3n5q"},
+	} {
+		if got := helpers.WKDHash(tt.localPart); got != tt.expected {
+			t.Errorf("WKDHash(%q) = %q, expected %q", tt.localPart, got, tt.expected)
+		}
+	}
+}
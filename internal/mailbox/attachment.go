@@ -0,0 +1,120 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mailbox
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+// maxAttachmentSize bounds the decoded size of a single attachment.
+const maxAttachmentSize = 16 << 20
+
+// maxPartDepth bounds the nesting of multipart bodies.
+const maxPartDepth = 8
+
+// Attachment is a leaf part of a message.
+type Attachment struct {
+	ContentType string
+	Filename    string
+	Data        []byte
+}
+
+// Attachments walks the MIME structure of a raw message and returns its
+// non-text leaf parts, decoded. Reports may also come as the single body of
+// the message, which is then returned as the only attachment.
+func Attachments(raw []byte) ([]Attachment, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Attachment
+	err = walkPart(textproto.MIMEHeader(msg.Header), msg.Body, 0, &out)
+	return out, err
+}
+
+func walkPart(header textproto.MIMEHeader, body io.Reader, depth int, out *[]Attachment) error {
+	if depth > maxPartDepth {
+		return errors.New("message nesting too deep")
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if err := walkPart(part.Header, part, depth+1, out); err != nil {
+				return err
+			}
+		}
+	}
+
+	filename := params["name"]
+	if _, dparams, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil && dparams["filename"] != "" {
+		filename = dparams["filename"]
+	}
+
+	// Skip the human readable part of the message.
+	if filename == "" && (mediaType == "text/plain" || mediaType == "text/html") {
+		return nil
+	}
+
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, maxAttachmentSize+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxAttachmentSize {
+		return errors.New("attachment too large")
+	}
+
+	*out = append(*out, Attachment{
+		ContentType: mediaType,
+		Filename:    filename,
+		Data:        data,
+	})
+	return nil
+}
@@ -0,0 +1,87 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mailbox
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestAttachments(t *testing.T) {
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte("<feedback/>"))
+	w.Close()
+
+	encoded := base64.StdEncoding.EncodeToString(gz.Bytes())
+
+	raw := strings.Join([]string{
+		"From: noreply-dmarc-support@google.com",
+		"To: dmarc@example.com",
+		"Subject: Report domain: example.com",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/mixed; boundary="b1"`,
+		"",
+		"--b1",
+		"Content-Type: text/plain",
+		"",
+		"This is an aggregate report.",
+		"--b1",
+		`Content-Type: application/gzip; name="google.com!example.com!1!2.xml.gz"`,
+		"Content-Transfer-Encoding: base64",
+		`Content-Disposition: attachment; filename="google.com!example.com!1!2.xml.gz"`,
+		"",
+		encoded[:10],
+		encoded[10:],
+		"--b1--",
+		"",
+	}, "\r\n")
+
+	atts, err := Attachments([]byte(raw))
+	if err != nil {
+		t.Fatalf("Attachments() error = %v", err)
+	}
+	if len(atts) != 1 {
+		t.Fatalf("Attachments() returned %d parts, want 1", len(atts))
+	}
+	if atts[0].ContentType != "application/gzip" || atts[0].Filename != "google.com!example.com!1!2.xml.gz" {
+		t.Errorf("unexpected attachment metadata: %q %q", atts[0].ContentType, atts[0].Filename)
+	}
+	if !bytes.Equal(atts[0].Data, gz.Bytes()) {
+		t.Errorf("attachment content not decoded properly")
+	}
+}
+
+func TestAttachmentsSingleBody(t *testing.T) {
+	raw := "Content-Type: text/xml\r\n\r\n<feedback/>\r\n"
+
+	atts, err := Attachments([]byte(raw))
+	if err != nil {
+		t.Fatalf("Attachments() error = %v", err)
+	}
+	if len(atts) != 1 || atts[0].ContentType != "text/xml" {
+		t.Fatalf("Attachments() = %v, want the XML body", atts)
+	}
+}
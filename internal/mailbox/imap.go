@@ -0,0 +1,290 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package mailbox fetches the reports that mail receivers send to a mailbox
+// (DMARC aggregate reports, SMTP TLS reports...). It implements just enough of
+// IMAP4rev1 (RFC 3501) to read the unseen messages of a mailbox hosted on a
+// server the operator runs, and extracts their attachments.
+package mailbox
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLiteralSize bounds the size of a single message fetched from the server.
+const maxLiteralSize = 32 << 20
+
+// Config describes how to reach the mailbox.
+type Config struct {
+	// Address is the host:port of the IMAP server.
+	Address string
+
+	// TLS enables implicit TLS (usually on port 993). Without it, the
+	// connection is in clear text, which is only acceptable for a server
+	// running on the same host or network.
+	TLS bool
+
+	Username string
+	Password string
+
+	// Mailbox is the folder to read; defaults to INBOX.
+	Mailbox string
+
+	// Timeout bounds each network operation.
+	Timeout time.Duration
+}
+
+// Message is a message fetched from the mailbox.
+type Message struct {
+	UID  uint32
+	Body []byte
+}
+
+// Client is an authenticated IMAP session with the configured mailbox
+// selected.
+type Client struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	timeout time.Duration
+	tag     int
+}
+
+// Validate checks that the configuration is usable, refusing to send the
+// credentials in clear text to a server not running on the same host.
+func (cfg Config) Validate() error {
+	host, _, err := net.SplitHostPort(cfg.Address)
+	if err != nil {
+		return fmt.Errorf("invalid IMAP server address %q: %w", cfg.Address, err)
+	}
+
+	if !cfg.TLS && host != "localhost" {
+		if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+			return fmt.Errorf("refusing a clear-text IMAP connection to %s: enable TLS or use a loopback address", host)
+		}
+	}
+
+	return nil
+}
+
+// Dial connects to the server, logs in and selects the mailbox.
+func Dial(cfg Config) (*Client, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+
+	var conn net.Conn
+	var err error
+	if cfg.TLS {
+		host, _, _ := net.SplitHostPort(cfg.Address)
+		conn, err = tls.DialWithDialer(dialer, "tcp", cfg.Address, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", cfg.Address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		conn:    conn,
+		r:       bufio.NewReader(conn),
+		timeout: timeout,
+	}
+
+	c.conn.SetDeadline(time.Now().Add(timeout))
+	greeting, err := c.r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(greeting, "* OK") {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected IMAP greeting: %s", strings.TrimSpace(greeting))
+	}
+
+	username, err := quote(cfg.Username)
+	if err == nil {
+		var password string
+		if password, err = quote(cfg.Password); err == nil {
+			_, err = c.command("LOGIN " + username + " " + password)
+		}
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("IMAP login failed: %w", err)
+	}
+
+	mailbox := cfg.Mailbox
+	if mailbox == "" {
+		mailbox = "INBOX"
+	}
+	quotedMailbox, err := quote(mailbox)
+	if err == nil {
+		_, err = c.command("SELECT " + quotedMailbox)
+	}
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("unable to select mailbox %q: %w", mailbox, err)
+	}
+
+	return c, nil
+}
+
+// Close logs out and closes the connection.
+func (c *Client) Close() error {
+	c.command("LOGOUT")
+	return c.conn.Close()
+}
+
+// Unseen returns the UIDs of the messages not marked as seen yet.
+func (c *Client) Unseen() ([]uint32, error) {
+	responses, err := c.command("UID SEARCH UNSEEN")
+	if err != nil {
+		return nil, err
+	}
+
+	var uids []uint32
+	for _, resp := range responses {
+		fields, ok := strings.CutPrefix(resp.line, "* SEARCH")
+		if !ok {
+			continue
+		}
+		for _, f := range strings.Fields(fields) {
+			uid, err := strconv.ParseUint(f, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("malformed SEARCH response: %q", resp.line)
+			}
+			uids = append(uids, uint32(uid))
+		}
+	}
+
+	return uids, nil
+}
+
+// Fetch retrieves the full content of a message, without marking it as seen.
+func (c *Client) Fetch(uid uint32) (*Message, error) {
+	responses, err := c.command(fmt.Sprintf("UID FETCH %d BODY.PEEK[]", uid))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, resp := range responses {
+		if strings.Contains(resp.line, "FETCH") && resp.literal != nil {
+			return &Message{UID: uid, Body: resp.literal}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("message %d not found", uid)
+}
+
+// MarkSeen flags the message as seen, so that it is not fetched again.
+func (c *Client) MarkSeen(uid uint32) error {
+	_, err := c.command(fmt.Sprintf("UID STORE %d +FLAGS.SILENT (\\Seen)", uid))
+	return err
+}
+
+// response is an untagged response line, with the literal it announced if
+// any.
+type response struct {
+	line    string
+	literal []byte
+}
+
+// command sends cmd and collects the untagged responses until the tagged
+// completion. A NO or BAD completion is returned as an error.
+func (c *Client) command(cmd string) ([]response, error) {
+	c.tag++
+	tag := fmt.Sprintf("a%03d", c.tag)
+
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if _, err := io.WriteString(c.conn, tag+" "+cmd+"\r\n"); err != nil {
+		return nil, err
+	}
+
+	var responses []response
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if rest, ok := strings.CutPrefix(line, tag+" "); ok {
+			if strings.HasPrefix(rest, "OK") {
+				return responses, nil
+			}
+			return responses, errors.New(rest)
+		}
+
+		resp := response{line: line}
+		if size, ok := literalSize(line); ok {
+			if size > maxLiteralSize {
+				return nil, fmt.Errorf("message too large (%d bytes)", size)
+			}
+			resp.literal = make([]byte, size)
+			if _, err := io.ReadFull(c.r, resp.literal); err != nil {
+				return nil, err
+			}
+			// Consume the remainder of the response, after the literal.
+			if _, err := c.r.ReadString('\n'); err != nil {
+				return nil, err
+			}
+		}
+		responses = append(responses, resp)
+	}
+}
+
+// literalSize parses the {n} announcing a literal at the end of line.
+func literalSize(line string) (int, bool) {
+	if !strings.HasSuffix(line, "}") {
+		return 0, false
+	}
+	start := strings.LastIndexByte(line, '{')
+	if start < 0 {
+		return 0, false
+	}
+	size, err := strconv.Atoi(line[start+1 : len(line)-1])
+	if err != nil || size < 0 {
+		return 0, false
+	}
+	return size, true
+}
+
+// quote returns s as an IMAP quoted string. A quoted string cannot hold CR,
+// LF or NUL (RFC 3501 section 4.3): such a value is refused rather than let
+// end the command and start another one.
+func quote(s string) (string, error) {
+	if strings.ContainsAny(s, "\r\n\x00") {
+		return "", errors.New("a value holds a line break or a NUL character")
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`, nil
+}
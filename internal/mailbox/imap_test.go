@@ -0,0 +1,150 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mailbox
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeServer answers a scripted IMAP session, one reply per command.
+func fakeServer(t *testing.T, replies map[string]string) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		fmt.Fprint(conn, "* OK IMAP4rev1 ready\r\n")
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			tag, cmd, _ := strings.Cut(strings.TrimSpace(line), " ")
+			verb := strings.SplitN(cmd, " ", 3)
+			key := verb[0]
+			if key == "UID" && len(verb) > 1 {
+				key += " " + verb[1]
+			}
+			fmt.Fprint(conn, replies[key])
+			fmt.Fprintf(conn, "%s OK done\r\n", tag)
+			if key == "LOGOUT" {
+				return
+			}
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestClient(t *testing.T) {
+	body := "Subject: test\r\n\r\nhello\r\n"
+	addr := fakeServer(t, map[string]string{
+		"SELECT":     "* 2 EXISTS\r\n",
+		"UID SEARCH": "* SEARCH 4 7\r\n",
+		"UID FETCH":  fmt.Sprintf("* 1 FETCH (UID 4 BODY[] {%d}\r\n%s)\r\n", len(body), body),
+	})
+
+	c, err := Dial(Config{Address: addr, Username: "user", Password: `p"ss`})
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer c.Close()
+
+	uids, err := c.Unseen()
+	if err != nil {
+		t.Fatalf("Unseen() error = %v", err)
+	}
+	if len(uids) != 2 || uids[0] != 4 || uids[1] != 7 {
+		t.Errorf("Unseen() = %v, want [4 7]", uids)
+	}
+
+	msg, err := c.Fetch(4)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if string(msg.Body) != body {
+		t.Errorf("Fetch() body = %q, want %q", msg.Body, body)
+	}
+
+	if err := c.MarkSeen(4); err != nil {
+		t.Errorf("MarkSeen() error = %v", err)
+	}
+}
+
+func TestLiteralSize(t *testing.T) {
+	if n, ok := literalSize("* 1 FETCH (BODY[] {42}"); !ok || n != 42 {
+		t.Errorf("literalSize() = %d, %v", n, ok)
+	}
+	if _, ok := literalSize("* SEARCH 1 2"); ok {
+		t.Error("literalSize() should not find a literal")
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if q, err := quote(`pa"ss\word`); err != nil || q != `"pa\"ss\\word"` {
+		t.Errorf("quote() = %s, %v", q, err)
+	}
+
+	for _, s := range []string{"INBOX\r\na2 DELETE INBOX", "pass\nword", "pass\x00word"} {
+		if q, err := quote(s); err == nil {
+			t.Errorf("quote(%q) = %s, want an error", s, q)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	for addr, tls := range map[string]bool{
+		"127.0.0.1:143":        false,
+		"[::1]:143":            false,
+		"localhost:143":        false,
+		"imap.example.com:993": true,
+	} {
+		if err := (Config{Address: addr, TLS: tls}).Validate(); err != nil {
+			t.Errorf("Validate(%s) error = %v", addr, err)
+		}
+	}
+
+	for _, cfg := range []Config{
+		{Address: "imap.example.com:143"},
+		{Address: "192.0.2.1:143"},
+		{Address: "imap.example.com", TLS: true},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want an error", cfg)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mailbox
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Handler processes the attachments of a fetched message. The message is
+// marked as seen whatever the outcome, so that a report happyDomain cannot
+// understand is not fetched over and over: errors are only logged.
+type Handler func(ctx context.Context, msg *Message, attachments []Attachment) error
+
+// Poller periodically fetches the unseen messages of a mailbox and hands
+// their attachments to a Handler.
+type Poller struct {
+	cfg      Config
+	handler  Handler
+	interval time.Duration
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	done    chan struct{}
+	running bool
+}
+
+// NewPoller builds a Poller that runs every `interval`.
+func NewPoller(cfg Config, handler Handler, interval time.Duration) *Poller {
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+	return &Poller{
+		cfg:      cfg,
+		handler:  handler,
+		interval: interval,
+	}
+}
+
+// Start launches the poller loop in a goroutine. It polls immediately once
+// the loop is up.
+func (p *Poller) Start(ctx context.Context) {
+	p.mu.Lock()
+	if p.running {
+		p.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+	p.done = make(chan struct{})
+	p.running = true
+	p.mu.Unlock()
+
+	go p.loop(ctx)
+}
+
+// Stop halts the poller and waits for the current poll to finish.
+func (p *Poller) Stop() {
+	p.mu.Lock()
+	cancel := p.cancel
+	done := p.done
+	p.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	if done != nil {
+		<-done
+	}
+	p.mu.Lock()
+	p.running = false
+	p.mu.Unlock()
+}
+
+func (p *Poller) loop(ctx context.Context) {
+	defer close(p.done)
+
+	p.RunOnce(ctx)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce fetches and handles the unseen messages. It returns the number of
+// messages processed.
+func (p *Poller) RunOnce(ctx context.Context) int {
+	c, err := Dial(p.cfg)
+	if err != nil {
+		log.Printf("Mailbox poller: unable to reach %s: %v", p.cfg.Address, err)
+		return 0
+	}
+	defer c.Close()
+
+	uids, err := c.Unseen()
+	if err != nil {
+		log.Printf("Mailbox poller: unable to list unseen messages: %v", err)
+		return 0
+	}
+
+	processed := 0
+	for _, uid := range uids {
+		select {
+		case <-ctx.Done():
+			return processed
+		default:
+		}
+
+		msg, err := c.Fetch(uid)
+		if err != nil {
+			log.Printf("Mailbox poller: unable to fetch message %d: %v", uid, err)
+			continue
+		}
+
+		if atts, err := Attachments(msg.Body); err != nil {
+			log.Printf("Mailbox poller: unable to parse message %d: %v", uid, err)
+		} else if err := p.handler(ctx, msg, atts); err != nil {
+			log.Printf("Mailbox poller: message %d: %v", uid, err)
+		}
+
+		if err := c.MarkSeen(uid); err != nil {
+			log.Printf("Mailbox poller: unable to mark message %d as seen: %v", uid, err)
+		}
+		processed++
+	}
+
+	return processed
+}
@@ -0,0 +1,98 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mailbox
+
+import (
+	"bytes"
+	"net/mail"
+	"strings"
+)
+
+// recipientHeaders are the headers telling whom a message was sent to. The
+// last two are added by the delivering mail server, and hold the envelope
+// recipient when the sender put the address in Bcc.
+var recipientHeaders = []string{"To", "Cc", "Delivered-To", "X-Original-To"}
+
+// Recipients returns the addresses the raw message was sent to, as found in
+// its headers.
+func Recipients(raw []byte) []string {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil
+	}
+
+	var ret []string
+	for _, h := range recipientHeaders {
+		for _, v := range msg.Header[h] {
+			addrs, err := mail.ParseAddressList(v)
+			if err != nil {
+				continue
+			}
+			for _, a := range addrs {
+				ret = append(ret, a.Address)
+			}
+		}
+	}
+
+	return ret
+}
+
+// Recipients returns the addresses the message was sent to.
+func (m *Message) Recipients() []string {
+	return Recipients(m.Body)
+}
+
+// Subaddress returns the address delivering to the same mailbox as address,
+// with tag appended to its local part (user+tag@example.com). It returns an
+// empty string when address is not an email address.
+func Subaddress(address, tag string) string {
+	local, domain, ok := strings.Cut(address, "@")
+	if !ok || local == "" || domain == "" {
+		return ""
+	}
+
+	return local + "+" + tag + "@" + domain
+}
+
+// SubaddressTags returns the tags of the recipients that are subaddresses of
+// address. The tags are case sensitive, the rest of the address is not.
+func SubaddressTags(address string, recipients []string) []string {
+	local, domain, ok := strings.Cut(address, "@")
+	if !ok || local == "" || domain == "" {
+		return nil
+	}
+
+	var tags []string
+	for _, r := range recipients {
+		rlocal, rdomain, ok := strings.Cut(r, "@")
+		if !ok || !strings.EqualFold(rdomain, domain) {
+			continue
+		}
+
+		base, tag, ok := strings.Cut(rlocal, "+")
+		if ok && tag != "" && strings.EqualFold(base, local) {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
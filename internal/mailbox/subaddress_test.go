@@ -0,0 +1,53 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mailbox
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSubaddressTags(t *testing.T) {
+	raw := strings.Join([]string{
+		"From: noreply-dmarc-support@google.com",
+		"To: Reports <Reports+abc.DEF@Example.com>, dmarc@example.com",
+		"Cc: reports+other@example.org",
+		"Delivered-To: reports+ghi.jkl@example.com",
+		"Subject: Report domain: example.com",
+		"",
+		"body",
+		"",
+	}, "\r\n")
+
+	got := SubaddressTags("reports@example.com", Recipients([]byte(raw)))
+	if want := []string{"abc.DEF", "ghi.jkl"}; !slices.Equal(got, want) {
+		t.Errorf("SubaddressTags() = %v, want %v", got, want)
+	}
+
+	if got := Subaddress("reports@example.com", "abc.DEF"); got != "reports+abc.DEF@example.com" {
+		t.Errorf("Subaddress() = %q", got)
+	}
+	if got := Subaddress("", "abc"); got != "" {
+		t.Errorf("Subaddress() of no address = %q, want none", got)
+	}
+}
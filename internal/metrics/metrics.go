@@ -96,6 +96,38 @@ var (
 		Buckets: prometheus.DefBuckets,
 	}, []string{"provider", "operation"})
 
+	// DNS provider throttling metrics
+	ProviderThrottledCallsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "happydomain_provider_throttled_calls_total",
+		Help: "Total number of DNS provider API calls delayed by the rate limiter.",
+	}, []string{"provider", "kind"})
+
+	ProviderThrottleWait = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "happydomain_provider_throttle_wait_seconds",
+		Help:    "Time DNS provider API calls waited for the rate limiter, in seconds.",
+		Buckets: []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"provider", "kind"})
+
+	ProviderRateLimitedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "happydomain_provider_rate_limited_total",
+		Help: "Total number of DNS provider API calls refused by the provider for exceeding its quota.",
+	}, []string{"provider"})
+
+	ProviderRetriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "happydomain_provider_retries_total",
+		Help: "Total number of DNS provider API reads retried.",
+	}, []string{"provider", "operation"})
+
+	ProviderQueuedWrites = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "happydomain_provider_queued_writes",
+		Help: "Number of DNS provider API writes currently waiting for their turn.",
+	}, []string{"provider"})
+
+	ProviderZoneCacheLookupsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "happydomain_provider_zone_cache_lookups_total",
+		Help: "Total number of lookups in the cache of the records fetched from DNS providers.",
+	}, []string{"result"})
+
 	// Storage metrics
 	StorageOperationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "happydomain_storage_operations_total",
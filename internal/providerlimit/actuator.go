@@ -0,0 +1,121 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package providerlimit
+
+import (
+	"git.happydns.org/happyDomain/model"
+)
+
+// limitedActuator throttles the calls made through a ProviderActuator.
+//
+// Embedding the actuator hides the optional interfaces it implements, apart
+// from those the wrapper throttles too: the callers look for the others
+// through Unwrap.
+type limitedActuator struct {
+	happydns.ProviderActuator
+	limiter *Limiter
+	account *account
+}
+
+// Unwrap returns the actuator being throttled, for the callers looking for
+// the other interfaces it implements.
+func (a *limitedActuator) Unwrap() happydns.ProviderActuator {
+	return a.ProviderActuator
+}
+
+func (a *limitedActuator) ListZones() (zones []string, err error) {
+	err = a.limiter.read(a.account, "list_zones", func() (err error) {
+		zones, err = a.ProviderActuator.ListZones()
+		return
+	})
+	return
+}
+
+func (a *limitedActuator) GetZoneRecords(domain string) (records []happydns.Record, err error) {
+	err = a.limiter.read(a.account, "get_zone_records", func() (err error) {
+		records, err = a.ProviderActuator.GetZoneRecords(domain)
+		return
+	})
+	return
+}
+
+// GetZoneCorrections reads the zone to compute the corrections, which is
+// idempotent; applying each correction then is a write.
+func (a *limitedActuator) GetZoneCorrections(domain string, wantedRecords []happydns.Record) (corrections []*happydns.Correction, nbCorrections int, err error) {
+	err = a.limiter.read(a.account, "get_zone_corrections", func() (err error) {
+		corrections, nbCorrections, err = a.ProviderActuator.GetZoneCorrections(domain, wantedRecords)
+		return
+	})
+	if err != nil {
+		return
+	}
+
+	a.throttleCorrections(corrections)
+	return
+}
+
+// throttleCorrections makes the application of each correction a write.
+func (a *limitedActuator) throttleCorrections(corrections []*happydns.Correction) {
+	for _, correction := range corrections {
+		if apply := correction.F; apply != nil {
+			correction.F = func() error {
+				return a.limiter.write(a.account, apply)
+			}
+		}
+	}
+}
+
+func (a *limitedActuator) CreateDomain(fqdn string) error {
+	return a.limiter.write(a.account, func() error {
+		return a.ProviderActuator.CreateDomain(fqdn)
+	})
+}
+
+// limitedCommenter is the limitedActuator of an actuator able to store
+// comments along the records: their reading and writing are throttled as
+// well. It is only used for such actuators, so that asserting
+// happydns.RecordCommenter on a wrapped actuator tells the truth.
+type limitedCommenter struct {
+	*limitedActuator
+	commenter happydns.RecordCommenter
+}
+
+func (a *limitedCommenter) GetZoneRecordComments(domain string) (comments happydns.RecordComments, err error) {
+	err = a.limiter.read(a.account, "get_zone_record_comments", func() (err error) {
+		comments, err = a.commenter.GetZoneRecordComments(domain)
+		return
+	})
+	return
+}
+
+func (a *limitedCommenter) GetZoneCorrectionsWithComments(domain string, wantedRecords []happydns.Record, comments happydns.RecordComments) (corrections []*happydns.Correction, nbCorrections int, err error) {
+	err = a.limiter.read(a.account, "get_zone_corrections", func() (err error) {
+		corrections, nbCorrections, err = a.commenter.GetZoneCorrectionsWithComments(domain, wantedRecords, comments)
+		return
+	})
+	if err != nil {
+		return
+	}
+
+	a.throttleCorrections(corrections)
+	return
+}
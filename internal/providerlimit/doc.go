@@ -0,0 +1,32 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package providerlimit throttles the calls happyDomain makes to the DNS
+// providers, so that the users sharing a provider account do not exhaust its
+// quota together.
+//
+// Calls are accounted per provider type and credentials: every provider using
+// the same API token shares one token bucket, whoever created it. Reads are
+// retried with an exponential backoff when the provider throttles them; writes
+// are never retried, but queue behind each other in the order they came. When
+// the provider tells how long to wait, with Retry-After, every call of the
+// account waits that long.
+package providerlimit
@@ -0,0 +1,322 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package providerlimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"git.happydns.org/happyDomain/internal/forms"
+	"git.happydns.org/happyDomain/internal/metrics"
+	"git.happydns.org/happyDomain/model"
+)
+
+// Config tunes a Limiter.
+type Config struct {
+	// Rate is the number of calls per second allowed for each account of a
+	// provider. Zero disables the rate limit, not the rest.
+	Rate float64
+
+	// Burst is the number of calls allowed at once above Rate.
+	Burst int
+
+	// Retries is how many times a read failing because the provider
+	// throttled it, or because it timed out, is attempted again.
+	Retries int
+
+	// MaxWait bounds how long a call may wait for its turn. Beyond it, the
+	// call fails with ErrThrottled instead of holding the request. It
+	// covers the whole call, not each attempt: the retries of a read and
+	// their backoff count against it too.
+	MaxWait time.Duration
+}
+
+// baseBackoff is the delay before the first retry of a read, doubled at each
+// attempt.
+var baseBackoff = 500 * time.Millisecond
+
+// idleAccount is how long the state of an account unused is kept.
+const idleAccount = time.Hour
+
+// Limiter throttles the calls made to the providers, per account: every
+// provider of the same type using the same credentials shares one quota,
+// whichever user it belongs to.
+//
+// A nil Limiter does nothing.
+type Limiter struct {
+	cfg      Config
+	mu       sync.Mutex
+	accounts map[string]*account
+}
+
+// account is the state shared by the calls made with the same credentials.
+type account struct {
+	providerType string
+	limiter      *rate.Limiter
+
+	// writes is a semaphore letting one write at a time through. Goroutines
+	// blocked on a channel are woken in the order they arrived, which is what
+	// makes the write queue fair.
+	writes chan struct{}
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+	lastSeen    time.Time
+}
+
+// NewLimiter creates a Limiter.
+func NewLimiter(cfg Config) *Limiter {
+	if cfg.Burst < 1 {
+		cfg.Burst = 1
+	}
+
+	return &Limiter{
+		cfg:      cfg,
+		accounts: map[string]*account{},
+	}
+}
+
+// Wrap returns actuator throttled along the other actuators of the same
+// account as provider. The result implements happydns.RecordCommenter when
+// actuator does; the other optional interfaces of actuator are reached
+// through its Unwrap method.
+func (l *Limiter) Wrap(provider *happydns.Provider, actuator happydns.ProviderActuator) happydns.ProviderActuator {
+	if l == nil {
+		return actuator
+	}
+
+	limited := &limitedActuator{
+		ProviderActuator: actuator,
+		limiter:          l,
+		account:          l.account(provider),
+	}
+
+	if commenter, ok := actuator.(happydns.RecordCommenter); ok {
+		return &limitedCommenter{
+			limitedActuator: limited,
+			commenter:       commenter,
+		}
+	}
+
+	return limited
+}
+
+// Key identifies the account of the provider: its type and a fingerprint of
+// its credentials, that is of its settings tagged `secret`, or of all its
+// settings when none is.
+func Key(provider *happydns.Provider) string {
+	h := sha256.New()
+
+	var secrets int
+	forms.TransformSecrets(provider.Provider, func(secret string) (string, error) {
+		secrets++
+		fmt.Fprintf(h, "%d:%s", len(secret), secret)
+		return secret, nil
+	})
+
+	if secrets == 0 {
+		settings, _ := json.Marshal(provider.Provider)
+		h.Write(settings)
+	}
+
+	return provider.Type + "|" + hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+func (l *Limiter) account(provider *happydns.Provider) *account {
+	key := Key(provider)
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	a, ok := l.accounts[key]
+	if !ok {
+		l.evictLocked(now)
+
+		limit := rate.Inf
+		if l.cfg.Rate > 0 {
+			limit = rate.Limit(l.cfg.Rate)
+		}
+
+		a = &account{
+			providerType: provider.Type,
+			limiter:      rate.NewLimiter(limit, l.cfg.Burst),
+			writes:       make(chan struct{}, 1),
+		}
+		l.accounts[key] = a
+	}
+
+	a.mu.Lock()
+	a.lastSeen = now
+	a.mu.Unlock()
+
+	return a
+}
+
+// evictLocked forgets the accounts unused for a while. The caller must hold
+// l.mu.
+func (l *Limiter) evictLocked(now time.Time) {
+	for key, a := range l.accounts {
+		a.mu.Lock()
+		idle := now.Sub(a.lastSeen) > idleAccount && now.After(a.pausedUntil)
+		a.mu.Unlock()
+
+		if idle {
+			delete(l.accounts, key)
+		}
+	}
+}
+
+// pause holds every call of the account for d, as the provider asked.
+func (a *account) pause(d time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(a.pausedUntil) {
+		a.pausedUntil = until
+	}
+}
+
+// wait blocks until the account may make a call, or fails with ErrThrottled
+// when that would take longer than ctx allows.
+func (a *account) wait(ctx context.Context, kind string) error {
+	start := time.Now()
+
+	a.mu.Lock()
+	until := a.pausedUntil
+	a.mu.Unlock()
+
+	if until.After(start) {
+		if deadline, ok := ctx.Deadline(); ok && until.After(deadline) {
+			return fmt.Errorf("%w until %s", ErrThrottled, until.Format(time.RFC3339))
+		}
+
+		timer := time.NewTimer(until.Sub(start))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %w", ErrThrottled, ctx.Err())
+		}
+	}
+
+	if err := a.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrThrottled, err)
+	}
+
+	if waited := time.Since(start); waited > time.Millisecond {
+		metrics.ProviderThrottledCallsTotal.WithLabelValues(a.providerType, kind).Inc()
+		metrics.ProviderThrottleWait.WithLabelValues(a.providerType, kind).Observe(waited.Seconds())
+	}
+
+	return nil
+}
+
+// context returns the context bounding the wait of a call. The actuator
+// interface carries none: the bound is MaxWait.
+func (l *Limiter) context() (context.Context, context.CancelFunc) {
+	if l.cfg.MaxWait <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), l.cfg.MaxWait)
+}
+
+// read makes an idempotent call, retrying it with an exponential backoff
+// while the provider throttles it or it times out. MaxWait bounds the whole
+// of it, not each attempt.
+func (l *Limiter) read(a *account, operation string, call func() error) error {
+	ctx, cancel := l.context()
+	defer cancel()
+
+	for attempt := 0; ; attempt++ {
+		if err := a.wait(ctx, "read"); err != nil {
+			return err
+		}
+
+		err := call()
+		if err == nil || !retryable(err) {
+			return err
+		}
+
+		backoff := baseBackoff << attempt
+		retryAfter, isThrottled := throttled(err)
+		if isThrottled {
+			metrics.ProviderRateLimitedTotal.WithLabelValues(a.providerType).Inc()
+			// The whole account is over its quota, not only this call: the
+			// next wait, this retry's included, holds until it is over.
+			a.pause(max(backoff, retryAfter))
+		}
+
+		if attempt >= l.cfg.Retries {
+			return err
+		}
+
+		metrics.ProviderRetriesTotal.WithLabelValues(a.providerType, operation).Inc()
+
+		if !isThrottled {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			}
+		}
+	}
+}
+
+// write makes a call changing the zone: it is not retried, as nothing tells
+// whether the provider applied it, but it waits for its turn behind the
+// writes of the same account, in the order they came.
+func (l *Limiter) write(a *account, call func() error) error {
+	ctx, cancel := l.context()
+	defer cancel()
+
+	metrics.ProviderQueuedWrites.WithLabelValues(a.providerType).Inc()
+	select {
+	case a.writes <- struct{}{}:
+		metrics.ProviderQueuedWrites.WithLabelValues(a.providerType).Dec()
+	case <-ctx.Done():
+		metrics.ProviderQueuedWrites.WithLabelValues(a.providerType).Dec()
+		return fmt.Errorf("%w: %w", ErrThrottled, ctx.Err())
+	}
+	defer func() { <-a.writes }()
+
+	if err := a.wait(ctx, "write"); err != nil {
+		return err
+	}
+
+	err := call()
+	if retryAfter, ok := throttled(err); ok {
+		metrics.ProviderRateLimitedTotal.WithLabelValues(a.providerType).Inc()
+		a.pause(max(baseBackoff, retryAfter))
+	}
+
+	return err
+}
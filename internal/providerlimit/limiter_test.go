@@ -0,0 +1,228 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package providerlimit
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+type tokenProvider struct {
+	Endpoint string `json:"endpoint"`
+	Token    string `json:"token" happydomain:"label=Token,secret"`
+}
+
+func (p *tokenProvider) InstantiateProvider() (happydns.ProviderActuator, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func newProvider(owner, token string) *happydns.Provider {
+	return &happydns.Provider{
+		ProviderMeta: happydns.ProviderMeta{Type: "tokenProvider", Owner: happydns.Identifier(owner)},
+		Provider:     &tokenProvider{Endpoint: "https://api.example.net", Token: token},
+	}
+}
+
+// fakeActuator fails its first calls with err.
+type fakeActuator struct {
+	happydns.ProviderActuator
+	failures int
+	err      error
+	calls    atomic.Int32
+	running  atomic.Int32
+	overlap  atomic.Bool
+}
+
+func (f *fakeActuator) ListZones() ([]string, error) {
+	if int(f.calls.Add(1)) <= f.failures {
+		return nil, f.err
+	}
+	return []string{"example.com."}, nil
+}
+
+func (f *fakeActuator) CreateDomain(fqdn string) error {
+	if f.running.Add(1) > 1 {
+		f.overlap.Store(true)
+	}
+	defer f.running.Add(-1)
+
+	time.Sleep(5 * time.Millisecond)
+	return nil
+}
+
+type retryAfterErr time.Duration
+
+func (e retryAfterErr) Error() string             { return "slow down" }
+func (e retryAfterErr) RetryAfter() time.Duration { return time.Duration(e) }
+
+func shortBackoff(t *testing.T) {
+	previous := baseBackoff
+	t.Cleanup(func() { baseBackoff = previous })
+	baseBackoff = time.Millisecond
+}
+
+func TestThrottled(t *testing.T) {
+	for _, tc := range []struct {
+		err        error
+		throttled  bool
+		retryAfter time.Duration
+	}{
+		{nil, false, 0},
+		{errors.New("401 unauthorized"), false, 0},
+		{errors.New("HTTP 429"), true, 0},
+		{errors.New("Too Many Requests"), true, 0},
+		{errors.New("rate limit exceeded, Retry-After: 7"), true, 7 * time.Second},
+		{fmt.Errorf("wrapped: %w", retryAfterErr(3*time.Second)), true, 3 * time.Second},
+	} {
+		retryAfter, ok := throttled(tc.err)
+		if ok != tc.throttled || retryAfter != tc.retryAfter {
+			t.Errorf("throttled(%v) = %v, %v; want %v, %v", tc.err, retryAfter, ok, tc.retryAfter, tc.throttled)
+		}
+	}
+}
+
+func TestKeySharedByCredentials(t *testing.T) {
+	if Key(newProvider("alice", "token")) != Key(newProvider("bob", "token")) {
+		t.Error("two users of the same token do not share the quota")
+	}
+	if Key(newProvider("alice", "token")) == Key(newProvider("alice", "other")) {
+		t.Error("two tokens share the quota")
+	}
+}
+
+func TestReadRetriedWhenThrottled(t *testing.T) {
+	shortBackoff(t)
+
+	l := NewLimiter(Config{Retries: 2, MaxWait: time.Minute})
+	inner := &fakeActuator{failures: 2, err: errors.New("429 Too Many Requests")}
+
+	zones, err := l.Wrap(newProvider("alice", "token"), inner).ListZones()
+	if err != nil || len(zones) != 1 {
+		t.Fatalf("ListZones() = %v, %v; want it to succeed on the third attempt", zones, err)
+	}
+	if got := inner.calls.Load(); got != 3 {
+		t.Errorf("%d calls; want 3", got)
+	}
+}
+
+func TestReadNotRetriedOnOtherErrors(t *testing.T) {
+	l := NewLimiter(Config{Retries: 2, MaxWait: time.Minute})
+	inner := &fakeActuator{failures: 1, err: errors.New("401 unauthorized")}
+
+	if _, err := l.Wrap(newProvider("alice", "token"), inner).ListZones(); err == nil {
+		t.Fatal("ListZones() succeeded")
+	}
+	if got := inner.calls.Load(); got != 1 {
+		t.Errorf("%d calls; want 1", got)
+	}
+}
+
+func TestRetryAfterHoldsTheAccount(t *testing.T) {
+	shortBackoff(t)
+
+	l := NewLimiter(Config{Retries: 0, MaxWait: 50 * time.Millisecond})
+	inner := &fakeActuator{failures: 1, err: retryAfterErr(time.Hour)}
+
+	if _, err := l.Wrap(newProvider("alice", "token"), inner).ListZones(); err == nil {
+		t.Fatal("ListZones() succeeded")
+	}
+
+	// Another user of the same token is held too, beyond MaxWait.
+	_, err := l.Wrap(newProvider("bob", "token"), inner).ListZones()
+	if !errors.Is(err, ErrThrottled) {
+		t.Fatalf("ListZones() = %v; want ErrThrottled", err)
+	}
+
+	// Other credentials are not.
+	if _, err := l.Wrap(newProvider("carol", "other"), inner).ListZones(); err != nil {
+		t.Fatalf("ListZones() with other credentials = %v", err)
+	}
+}
+
+func TestWritesAreSerialized(t *testing.T) {
+	l := NewLimiter(Config{MaxWait: time.Minute})
+	inner := &fakeActuator{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Wrap(newProvider("alice", "token"), inner).CreateDomain("example.com."); err != nil {
+				t.Errorf("CreateDomain() = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if inner.overlap.Load() {
+		t.Error("two writes of the same account ran at once")
+	}
+}
+
+func TestNilLimiter(t *testing.T) {
+	var l *Limiter
+	inner := &fakeActuator{}
+
+	if got := l.Wrap(newProvider("alice", "token"), inner); got != inner {
+		t.Errorf("Wrap() on a nil Limiter = %v; want the actuator itself", got)
+	}
+}
+
+// commentingActuator is a fakeActuator able to store comments.
+type commentingActuator struct {
+	fakeActuator
+}
+
+func (c *commentingActuator) GetZoneRecordComments(domain string) (happydns.RecordComments, error) {
+	return nil, nil
+}
+
+func (c *commentingActuator) GetZoneCorrectionsWithComments(domain string, wantedRecords []happydns.Record, comments happydns.RecordComments) ([]*happydns.Correction, int, error) {
+	return nil, 0, nil
+}
+
+func TestWrapKeepsCommentSupport(t *testing.T) {
+	l := NewLimiter(Config{})
+
+	inner := &fakeActuator{}
+	wrapped := l.Wrap(newProvider("alice", "token"), inner)
+	if _, ok := wrapped.(happydns.RecordCommenter); ok {
+		t.Error("Wrap() of an actuator without comments implements happydns.RecordCommenter")
+	}
+	if got := wrapped.(interface {
+		Unwrap() happydns.ProviderActuator
+	}).Unwrap(); got != inner {
+		t.Errorf("Unwrap() = %v; want the wrapped actuator", got)
+	}
+
+	commenter := &commentingActuator{}
+	if _, ok := l.Wrap(newProvider("alice", "token"), commenter).(happydns.RecordCommenter); !ok {
+		t.Error("Wrap() of an actuator storing comments does not implement happydns.RecordCommenter")
+	}
+}
@@ -0,0 +1,86 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package providerlimit
+
+import (
+	"errors"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryAfterError is implemented by the errors telling how long the provider
+// asked to wait before calling it again, as its Retry-After header does.
+type RetryAfterError interface {
+	error
+	RetryAfter() time.Duration
+}
+
+// ErrThrottled is returned, wrapped, when a call would have to wait longer
+// than allowed for the rate limit of its provider.
+var ErrThrottled = errors.New("the provider is rate limited")
+
+// retryAfterRe finds a Retry-After delay, in seconds, in an error message.
+// Most provider clients do not expose the header, but some do quote it.
+var retryAfterRe = regexp.MustCompile(`(?i)retry[- ]after\W{1,3}(\d+)`)
+
+// throttled tells whether err is the provider refusing a call for exceeding
+// its quota and, when it said, how long it asked to wait.
+//
+// Few provider clients return a typed error: beside RetryAfterError, the
+// message is searched for the status code and the usual wordings.
+func throttled(err error) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+
+	var rae RetryAfterError
+	if errors.As(err, &rae) {
+		return rae.RetryAfter(), true
+	}
+
+	msg := strings.ToLower(err.Error())
+	if !strings.Contains(msg, "429") && !strings.Contains(msg, "too many requests") && !strings.Contains(msg, "rate limit") {
+		return 0, false
+	}
+
+	if m := retryAfterRe.FindStringSubmatch(msg); m != nil {
+		if seconds, err := strconv.Atoi(m[1]); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+
+	return 0, true
+}
+
+// retryable tells whether a read failing with err is worth retrying: when
+// the provider throttled it, or when it timed out.
+func retryable(err error) bool {
+	if _, ok := throttled(err); ok {
+		return true
+	}
+
+	var nerr net.Error
+	return errors.As(err, &nerr) && nerr.Timeout()
+}
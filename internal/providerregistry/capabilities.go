@@ -0,0 +1,89 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package providerregistry
+
+import (
+	"strings"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// providerLimits lists, per provider type, the limits it enforces on the
+// records it publishes, each from the documentation linked along it. The
+// record types come from the registered capabilities. RecordComments is only
+// set for the providers whose actuator implements happydns.RecordCommenter:
+// the diff asks them for their comments.
+var providerLimits = map[string]happydns.ProviderCapabilities{
+	"BuiltinServer": {RecordComments: true},
+
+	// https://developers.cloudflare.com/dns/manage-dns-records/reference/ttl/
+	// https://developers.cloudflare.com/dns/manage-dns-records/reference/dns-record-types/#txt
+	"CloudflareAPI": {MinTTL: 60, MaxTTL: 86400, MaxTXTLength: 2048, RecordComments: true},
+
+	// https://desec.readthedocs.io/en/latest/dns/rrsets.html
+	"DeSECAPI": {MinTTL: 3600, MaxTTL: 86400},
+
+	// https://api.gandi.net/docs/livedns/
+	"GandiAPI": {MinTTL: 300, MaxTTL: 2592000},
+
+	// https://developer.godaddy.com/doc/endpoint/domains
+	"GoDaddyAPI": {MinTTL: 600},
+
+	// https://techdocs.akamai.com/linode-api/reference/post-domain-record
+	"LinodeAPI": {MinTTL: 300},
+
+	// https://www.namecheap.com/support/api/methods/domains-dns/set-hosts/
+	"NamecheapAPI": {MinTTL: 60, MaxTTL: 60000},
+
+	// https://porkbun.com/api/json/v3/documentation
+	"PorkbunAPI": {MinTTL: 600},
+
+	// https://doc.powerdns.com/authoritative/http-api/zone.html
+	"PowerdnsAPI": {RecordComments: true},
+
+	// https://docs.aws.amazon.com/Route53/latest/DeveloperGuide/ResourceRecordTypes.html#TXTFormat
+	"Route53API": {MaxTXTLength: 4000},
+}
+
+// GetProviderCapabilities returns the record types and limits of the given
+// provider type, or nil when the type is not registered.
+func GetProviderCapabilities(ptype string) *happydns.ProviderCapabilities {
+	creator, ok := providerRegistry[ptype]
+	if !ok {
+		return nil
+	}
+
+	caps := providerLimits[ptype]
+	caps.RRTypes = nil
+	for _, capability := range creator.Infos.Capabilities {
+		// Record types are registered as rr-<number>-<name>.
+		if !strings.HasPrefix(capability, "rr-") {
+			continue
+		}
+
+		if _, name, ok := strings.Cut(strings.TrimPrefix(capability, "rr-"), "-"); ok && name != "" {
+			caps.RRTypes = append(caps.RRTypes, name)
+		}
+	}
+
+	return &caps
+}
@@ -0,0 +1,48 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package providerregistry_test
+
+import (
+	"testing"
+
+	providerReg "git.happydns.org/happyDomain/internal/providerregistry"
+	_ "git.happydns.org/happyDomain/providers"
+)
+
+// TestProviderLimitsMatchRegisteredProviders keeps the limits table in step
+// with the providers: a renamed or dropped provider would otherwise leave an
+// entry nobody reads.
+func TestProviderLimitsMatchRegisteredProviders(t *testing.T) {
+	providers := providerReg.GetProviders()
+
+	for ptype, limits := range providerReg.ProviderLimits {
+		if _, ok := providers[ptype]; !ok {
+			t.Errorf("%s: limits listed for a provider type not registered", ptype)
+		}
+		if limits.MinTTL > 0 && limits.MaxTTL > 0 && limits.MinTTL > limits.MaxTTL {
+			t.Errorf("%s: MinTTL %d above MaxTTL %d", ptype, limits.MinTTL, limits.MaxTTL)
+		}
+		if limits.RRTypes != nil {
+			t.Errorf("%s: RRTypes listed, they come from the registered capabilities", ptype)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package providerregistry
+
+import (
+	"slices"
+	"testing"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+func TestGetProviderCapabilities(t *testing.T) {
+	RegisterNamedProvider("CloudflareAPI", func() happydns.ProviderBody { return nil }, happydns.ProviderInfos{
+		Name:         "Cloudflare",
+		Capabilities: []string{"ListDomains", "rr-1-A", "rr-257-CAA", "rr-65400-ALIAS", "rr-"},
+	})
+
+	caps := GetProviderCapabilities("CloudflareAPI")
+	if caps == nil {
+		t.Fatal("GetProviderCapabilities(CloudflareAPI) = nil")
+	}
+	if want := []string{"A", "CAA", "ALIAS"}; !slices.Equal(caps.RRTypes, want) {
+		t.Errorf("RRTypes = %v, want %v", caps.RRTypes, want)
+	}
+	if caps.MinTTL != 60 || caps.MaxTTL != 86400 || caps.MaxTXTLength != 2048 || !caps.RecordComments {
+		t.Errorf("limits = %+v, want those of Cloudflare", caps)
+	}
+	if !caps.SupportsType("CAA") || caps.SupportsType("TLSA") {
+		t.Errorf("SupportsType does not follow RRTypes %v", caps.RRTypes)
+	}
+
+	// The table is not altered by the types of a given call.
+	if providerLimits["CloudflareAPI"].RRTypes != nil {
+		t.Error("providerLimits got modified")
+	}
+
+	if caps := GetProviderCapabilities("UnknownAPI"); caps != nil {
+		t.Errorf("GetProviderCapabilities(UnknownAPI) = %+v, want nil", caps)
+	}
+}
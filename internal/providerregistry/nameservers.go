@@ -0,0 +1,114 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package providerregistry
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// nameserverPatterns lists, per provider type, the name servers its zones
+// are served from. Patterns are matched with path.Match against lower-case
+// FQDN; a * spans several labels.
+var nameserverPatterns = map[string][]string{
+	"AkamaiEdgeDnsAPI":  {"a*-*.akam.net."},
+	"AliDNSAPI":         {"*.alidns.com."},
+	"AzureDnsAPI":       {"ns*-*.azure-dns.com.", "ns*-*.azure-dns.net.", "ns*-*.azure-dns.org.", "ns*-*.azure-dns.info."},
+	"BunnyDNSAPI":       {"*.bunny.net."},
+	"ClouDNSAPI":        {"*.cloudns.net."},
+	"CloudflareAPI":     {"*.ns.cloudflare.com."},
+	"DNSMadeEasyAPI":    {"ns*.dnsmadeeasy.com."},
+	"DNSimpleAPI":       {"ns*.dnsimple.com.", "ns*.dnsimple-edge.net.", "ns*.dnsimple-edge.org.", "ns*.dnsimple-edge.com."},
+	"DeSECAPI":          {"ns*.desec.io.", "ns*.desec.org."},
+	"DigitalOceanAPI":   {"ns*.digitalocean.com."},
+	"DomainnameshopAPI": {"ns*.hyp.net."},
+	"DreamhostAPI":      {"ns*.dreamhost.com."},
+	"DynuAPI":           {"ns*.dynu.com."},
+	"ExoscaleAPI":       {"ns*.exoscale.ch.", "ns*.exoscale.com.", "ns*.exoscale.net.", "ns*.exoscale.io."},
+	"GCloudAPI":         {"ns-cloud-*.googledomains.com."},
+	"GandiAPI":          {"*.gandi.net."},
+	"GcoreAPI":          {"ns*.gcorelabs.net.", "ns*.gcdn.services."},
+	"GoDaddyAPI":        {"ns*.domaincontrol.com."},
+	"HEDNSAPI":          {"ns*.he.net."},
+	"HetznerAPI":        {"*.ns.hetzner.com.", "*.ns.hetzner.de.", "*.your-server.de.", "*.second-ns.com.", "*.second-ns.de."},
+	"HostingdeAPI":      {"ns*.hosting.de."},
+	"HostingerAPI":      {"ns*.dns-parking.com."},
+	"HuaweiCloudAPI":    {"ns*.huaweicloud-dns.com.", "ns*.huaweicloud-dns.net.", "ns*.huaweicloud-dns.cn.", "ns*.huaweicloud-dns.org."},
+	"INWXAPI":           {"ns*.inwx.de.", "ns*.inwx.com.", "ns*.inwx.eu."},
+	"InfomaniakAPI":     {"ns*.infomaniak.ch."},
+	"IonosAPI":          {"ns*.ui-dns.de.", "ns*.ui-dns.com.", "ns*.ui-dns.org.", "ns*.ui-dns.biz."},
+	"LinodeAPI":         {"ns*.linode.com."},
+	"LoopiaAPI":         {"ns*.loopia.se."},
+	"LuaDnsAPI":         {"ns*.luadns.net."},
+	"MythicBeastsAPI":   {"ns*.mythic-beasts.com."},
+	"NS1API":            {"dns*.p*.nsone.net."},
+	"NamecheapAPI":      {"dns*.registrar-servers.com."},
+	"NamedotcomAPI":     {"ns*.name.com."},
+	"NetcupAPI":         {"*-dns.netcup.net."},
+	"OVHAPI":            {"*.ovh.net.", "*.ovh.ca.", "*.anycast.me."},
+	"OracleAPI":         {"ns*.p*.dns.oraclecloud.net."},
+	"PorkbunAPI":        {"*.ns.porkbun.com."},
+	"Route53API":        {"ns-*.awsdns-*.com.", "ns-*.awsdns-*.net.", "ns-*.awsdns-*.org.", "ns-*.awsdns-*.co.uk."},
+	"ScalewayAPI":       {"ns*.dom.scw.cloud."},
+	"SpaceshipAPI":      {"launch*.spaceship.net."},
+	"TransIpAPI":        {"ns*.transip.net.", "ns*.transip.nl.", "ns*.transip.eu."},
+	"VercelAPI":         {"ns*.vercel-dns.com."},
+	"VultrAPI":          {"ns*.vultr.com."},
+	"WebsupportAPI":     {"ns*.websupport.sk."},
+}
+
+// MatchNameservers returns the provider types serving zones from the given
+// name servers, the type matching the most of them first.
+func MatchNameservers(nameservers []string) []string {
+	matches := map[string]int{}
+	for _, ns := range nameservers {
+		ns = strings.ToLower(dns.Fqdn(ns))
+		for ptype, patterns := range nameserverPatterns {
+			if _, registered := providerRegistry[ptype]; !registered {
+				continue
+			}
+
+			if slices.ContainsFunc(patterns, func(pattern string) bool {
+				matched, _ := path.Match(pattern, ns)
+				return matched
+			}) {
+				matches[ptype]++
+			}
+		}
+	}
+
+	ret := make([]string, 0, len(matches))
+	for ptype := range matches {
+		ret = append(ret, ptype)
+	}
+	slices.SortFunc(ret, func(a, b string) int {
+		if matches[a] != matches[b] {
+			return matches[b] - matches[a]
+		}
+		return strings.Compare(a, b)
+	})
+
+	return ret
+}
@@ -0,0 +1,75 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package providerregistry
+
+import (
+	"slices"
+	"testing"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+func TestMatchNameservers(t *testing.T) {
+	for _, ptype := range []string{"CloudflareAPI", "OVHAPI", "Route53API"} {
+		RegisterNamedProvider(ptype, func() happydns.ProviderBody { return nil }, happydns.ProviderInfos{Name: ptype})
+	}
+
+	tests := []struct {
+		name        string
+		nameservers []string
+		want        []string
+	}{
+		{
+			name:        "cloudflare",
+			nameservers: []string{"ada.ns.cloudflare.com.", "BOB.NS.CLOUDFLARE.COM"},
+			want:        []string{"CloudflareAPI"},
+		},
+		{
+			name:        "route53",
+			nameservers: []string{"ns-1536.awsdns-00.co.uk.", "ns-0.awsdns-00.com."},
+			want:        []string{"Route53API"},
+		},
+		{
+			name:        "most matching first",
+			nameservers: []string{"dns200.anycast.me.", "ns200.anycast.me.", "ada.ns.cloudflare.com."},
+			want:        []string{"OVHAPI", "CloudflareAPI"},
+		},
+		{
+			name:        "unregistered type",
+			nameservers: []string{"ns1.digitalocean.com."},
+			want:        []string{},
+		},
+		{
+			name:        "unknown",
+			nameservers: []string{"ns1.example.com.", "cloudflare.com."},
+			want:        []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchNameservers(tt.nameservers); !slices.Equal(got, tt.want) {
+				t.Errorf("MatchNameservers(%v) = %v, want %v", tt.nameservers, got, tt.want)
+			}
+		})
+	}
+}
@@ -37,7 +37,14 @@ var providerRegistry = map[string]happydns.ProviderCreator{}
 func RegisterProvider(creator happydns.ProviderCreatorFunc, infos happydns.ProviderInfos) {
 	provider := creator()
 	baseType := reflect.Indirect(reflect.ValueOf(provider)).Type()
-	name := baseType.Name()
+
+	RegisterNamedProvider(baseType.Name(), creator, infos)
+}
+
+// RegisterNamedProvider registers a provider under the given name, for the
+// bodies whose type is shared by several providers, such as those bridged
+// from libdns.
+func RegisterNamedProvider(name string, creator happydns.ProviderCreatorFunc, infos happydns.ProviderInfos) {
 	log.Println("Registering new provider:", name)
 
 	providerRegistry[name] = happydns.ProviderCreator{
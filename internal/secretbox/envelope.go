@@ -0,0 +1,166 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package secretbox
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+)
+
+// envelopePrefix starts every value produced by SealEnvelope.
+const envelopePrefix = "hdenv1:"
+
+// SealEnvelope encrypts plaintext under a fresh data key, and seals that data
+// key under the master key. Both travel together in the returned value:
+//
+//	hdenv1:<key id>:<sealed data key>:<ciphertext>
+//
+// Rotating the master key then only means sealing the data keys again, with
+// RewrapEnvelope, while the ciphertexts themselves stay untouched.
+func (k *Keyring) SealEnvelope(plaintext, associatedData []byte) (string, error) {
+	if !k.Available() {
+		return "", ErrNoKey
+	}
+
+	dek := make([]byte, KeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return "", err
+	}
+
+	dataAEAD, err := newAEAD(dek)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := sealWith(dataAEAD, plaintext, associatedData)
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err := sealWith(k.aead, dek, associatedData)
+	if err != nil {
+		return "", err
+	}
+
+	return joinEnvelope(k.id, wrapped, data), nil
+}
+
+// OpenEnvelope decrypts a value produced by SealEnvelope with the same
+// associated data, under the current master key or one it replaced.
+func (k *Keyring) OpenEnvelope(sealed string, associatedData []byte) ([]byte, error) {
+	if !k.Available() {
+		return nil, ErrNoKey
+	}
+
+	keyID, wrapped, data, err := splitEnvelope(sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	dek, err := k.openDataKey(keyID, wrapped, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	dataAEAD, err := newAEAD(dek)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	return openWith(dataAEAD, data, associatedData)
+}
+
+// RewrapEnvelope seals the data key of a value produced by SealEnvelope again,
+// under the current master key. A value already sealed under it is returned
+// as is.
+func (k *Keyring) RewrapEnvelope(sealed string, associatedData []byte) (string, error) {
+	if !k.Available() {
+		return "", ErrNoKey
+	}
+
+	keyID, wrapped, data, err := splitEnvelope(sealed)
+	if err != nil {
+		return "", err
+	}
+
+	if keyID == k.id {
+		return sealed, nil
+	}
+
+	dek, err := k.openDataKey(keyID, wrapped, associatedData)
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err = sealWith(k.aead, dek, associatedData)
+	if err != nil {
+		return "", err
+	}
+
+	return joinEnvelope(k.id, wrapped, data), nil
+}
+
+// IsEnvelope reports whether value looks like something SealEnvelope
+// produced.
+func IsEnvelope(value string) bool {
+	_, _, _, err := splitEnvelope(value)
+	return err == nil
+}
+
+func (k *Keyring) openDataKey(keyID string, wrapped, associatedData []byte) ([]byte, error) {
+	aead, err := k.aeadFor(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return openWith(aead, wrapped, associatedData)
+}
+
+func joinEnvelope(keyID string, wrapped, data []byte) string {
+	return envelopePrefix + keyID + ":" + base64.RawStdEncoding.EncodeToString(wrapped) + ":" + base64.RawStdEncoding.EncodeToString(data)
+}
+
+// splitEnvelope extracts the key identifier, the sealed data key and the
+// ciphertext of an envelope.
+func splitEnvelope(sealed string) (keyID string, wrapped, data []byte, err error) {
+	rest, ok := strings.CutPrefix(sealed, envelopePrefix)
+	if !ok {
+		return "", nil, nil, ErrMalformed
+	}
+
+	parts := strings.Split(rest, ":")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", nil, nil, ErrMalformed
+	}
+
+	wrapped, err = base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+
+	data, err = base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+
+	return parts[0], wrapped, data, nil
+}
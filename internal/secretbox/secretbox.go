@@ -0,0 +1,276 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package secretbox seals the secrets happyDomain has to keep at rest, such as
+// the private keys it generates on behalf of its users.
+//
+// Sealing uses AES-256-GCM under a master key the operator provides. Every
+// sealed value carries the identifier of the key that sealed it, so that a
+// value sealed under another key is reported as such instead of failing as a
+// corrupted ciphertext, and so that the master key can be rotated: the keys it
+// replaced stay in the keyring to open what they sealed, until everything has
+// been sealed again under the current one.
+//
+// Like netguard, this package only depends on the standard library: it is a
+// security primitive that anything storing a secret must be able to import.
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeySize is the length, in bytes, of a master key.
+const KeySize = 32
+
+// sealedPrefix starts every value produced by Seal. It versions the format,
+// so that a future change of algorithm can coexist with stored values.
+const sealedPrefix = "hdsb1:"
+
+var (
+	// ErrNoKey is returned when sealing or opening is attempted while no
+	// master key has been configured.
+	ErrNoKey = errors.New("no secret key configured: define -secret-key or -secret-key-file")
+
+	// ErrUnknownKey is returned when a value was sealed under a key the
+	// keyring does not hold.
+	ErrUnknownKey = errors.New("value sealed with an unknown secret key")
+
+	// ErrMalformed is returned when a value does not look like something
+	// Seal produced.
+	ErrMalformed = errors.New("malformed sealed value")
+)
+
+// Keyring holds the master key used to seal and open secrets. The zero value
+// and the nil pointer are valid keyrings that hold no key: every operation
+// then fails with ErrNoKey.
+type Keyring struct {
+	id   string
+	aead cipher.AEAD
+
+	// previous holds the keys the current one replaced, by identifier. They
+	// still open what they sealed, but never seal anything new.
+	previous map[string]cipher.AEAD
+}
+
+// NewKeyring builds a Keyring around the given master key, which must be
+// KeySize bytes long. An empty key yields an empty keyring, so that callers
+// can pass the configuration through without checking it first.
+//
+// previous lists the master keys used before the current one, kept to open
+// the values they sealed while those are sealed again.
+func NewKeyring(key []byte, previous ...[]byte) (*Keyring, error) {
+	if len(key) == 0 {
+		if len(previous) > 0 {
+			return nil, errors.New("previous secret keys given without a current one")
+		}
+		return &Keyring{}, nil
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &Keyring{
+		id:   KeyID(key),
+		aead: aead,
+	}
+
+	for _, old := range previous {
+		id := KeyID(old)
+		if id == k.id {
+			continue
+		}
+
+		oldAEAD, err := newAEAD(old)
+		if err != nil {
+			return nil, fmt.Errorf("previous key %s: %w", id, err)
+		}
+
+		if k.previous == nil {
+			k.previous = map[string]cipher.AEAD{}
+		}
+		k.previous[id] = oldAEAD
+	}
+
+	return k, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("secret key must be %d bytes long, got %d", KeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// KeyID returns the public identifier of a master key: the first bytes of a
+// domain-separated hash, enough to tell keys apart without revealing them.
+func KeyID(key []byte) string {
+	h := sha256.Sum256(append([]byte("happydomain-secretbox:"), key...))
+	return hex.EncodeToString(h[:4])
+}
+
+// Available reports whether the keyring holds a master key.
+func (k *Keyring) Available() bool {
+	return k != nil && k.aead != nil
+}
+
+// ID returns the identifier of the master key, or an empty string when the
+// keyring holds none.
+func (k *Keyring) ID() string {
+	if k == nil {
+		return ""
+	}
+	return k.id
+}
+
+// IsCurrent reports whether sealed, a value produced by Seal or SealEnvelope,
+// was sealed under the current master key. Values for which it is false have
+// to be sealed again before the key that sealed them can be retired.
+func (k *Keyring) IsCurrent(sealed string) bool {
+	if !k.Available() {
+		return false
+	}
+
+	keyID, _, err := splitSealed(sealed)
+	if err != nil {
+		keyID, _, _, err = splitEnvelope(sealed)
+	}
+
+	return err == nil && keyID == k.id
+}
+
+// aeadFor returns the cipher of the key identified by keyID, be it the
+// current one or one it replaced.
+func (k *Keyring) aeadFor(keyID string) (cipher.AEAD, error) {
+	if keyID == k.id {
+		return k.aead, nil
+	}
+
+	if aead, ok := k.previous[keyID]; ok {
+		return aead, nil
+	}
+
+	return nil, fmt.Errorf("%w (%s)", ErrUnknownKey, keyID)
+}
+
+// Seal encrypts plaintext. The associated data binds the sealed value to its
+// context (e.g. the identifier of the record holding it), so that it cannot be
+// moved to another record and still open.
+func (k *Keyring) Seal(plaintext, associatedData []byte) (string, error) {
+	if !k.Available() {
+		return "", ErrNoKey
+	}
+
+	sealed, err := sealWith(k.aead, plaintext, associatedData)
+	if err != nil {
+		return "", err
+	}
+
+	return sealedPrefix + k.id + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value produced by Seal with the same associated data, under
+// the current master key or one it replaced.
+func (k *Keyring) Open(sealed string, associatedData []byte) ([]byte, error) {
+	if !k.Available() {
+		return nil, ErrNoKey
+	}
+
+	keyID, payload, err := splitSealed(sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := k.aeadFor(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	return openWith(aead, raw, associatedData)
+}
+
+// sealWith encrypts plaintext under a random nonce, which it prepends to the
+// ciphertext.
+func sealWith(aead cipher.AEAD, plaintext, associatedData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+// openWith reverses sealWith.
+func openWith(aead cipher.AEAD, raw, associatedData []byte) ([]byte, error) {
+	nonceSize := aead.NonceSize()
+	if len(raw) < nonceSize {
+		return nil, ErrMalformed
+	}
+
+	plaintext, err := aead.Open(nil, raw[:nonceSize], raw[nonceSize:], associatedData)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open sealed value: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// IsSealed reports whether value looks like something Seal produced.
+func IsSealed(value string) bool {
+	_, _, err := splitSealed(value)
+	return err == nil
+}
+
+// splitSealed extracts the key identifier and the encoded payload of a sealed
+// value.
+func splitSealed(sealed string) (keyID, payload string, err error) {
+	rest, ok := strings.CutPrefix(sealed, sealedPrefix)
+	if !ok {
+		return "", "", ErrMalformed
+	}
+
+	keyID, payload, ok = strings.Cut(rest, ":")
+	if !ok || keyID == "" || payload == "" {
+		return "", "", ErrMalformed
+	}
+
+	return keyID, payload, nil
+}
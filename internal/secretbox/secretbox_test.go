@@ -0,0 +1,234 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package secretbox
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	kr, err := NewKeyring(testKey(1))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+
+	sealed, err := kr.Seal([]byte("private key"), []byte("ctx"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	if !IsSealed(sealed) {
+		t.Fatalf("IsSealed(%q) = false", sealed)
+	}
+	if strings.Contains(sealed, "private key") {
+		t.Fatalf("sealed value leaks the plaintext: %q", sealed)
+	}
+
+	plain, err := kr.Open(sealed, []byte("ctx"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if string(plain) != "private key" {
+		t.Errorf("Open = %q, want %q", plain, "private key")
+	}
+}
+
+func TestOpenRejectsOtherContext(t *testing.T) {
+	kr, _ := NewKeyring(testKey(1))
+
+	sealed, err := kr.Seal([]byte("secret"), []byte("record-a"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	if _, err := kr.Open(sealed, []byte("record-b")); err == nil {
+		t.Error("Open succeeded with a different associated data")
+	}
+}
+
+func TestOpenRejectsOtherKey(t *testing.T) {
+	kr1, _ := NewKeyring(testKey(1))
+	kr2, _ := NewKeyring(testKey(2))
+
+	sealed, err := kr1.Seal([]byte("secret"), nil)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	if _, err := kr2.Open(sealed, nil); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Open with another key: got %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestEmptyKeyring(t *testing.T) {
+	kr, err := NewKeyring(nil)
+	if err != nil {
+		t.Fatalf("NewKeyring(nil): %v", err)
+	}
+
+	if kr.Available() {
+		t.Error("empty keyring reports a key")
+	}
+	if _, err := kr.Seal([]byte("x"), nil); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Seal on empty keyring: got %v, want ErrNoKey", err)
+	}
+
+	var nilKr *Keyring
+	if nilKr.Available() {
+		t.Error("nil keyring reports a key")
+	}
+}
+
+func TestNewKeyringRejectsBadLength(t *testing.T) {
+	if _, err := NewKeyring([]byte("too short")); err == nil {
+		t.Error("NewKeyring accepted a short key")
+	}
+}
+
+func TestIsSealed(t *testing.T) {
+	for _, v := range []string{"", "plain", "hdsb1:", "hdsb1:abcd", "hdsb1::payload"} {
+		if IsSealed(v) {
+			t.Errorf("IsSealed(%q) = true", v)
+		}
+	}
+}
+
+func TestOpenWithPreviousKey(t *testing.T) {
+	old, _ := NewKeyring(testKey(1))
+	sealed, err := old.Seal([]byte("secret"), nil)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	rotated, err := NewKeyring(testKey(2), testKey(1))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+
+	if rotated.IsCurrent(sealed) {
+		t.Errorf("IsCurrent = true for a value sealed under the previous key")
+	}
+
+	plain, err := rotated.Open(sealed, nil)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if string(plain) != "secret" {
+		t.Errorf("Open = %q, want %q", plain, "secret")
+	}
+
+	resealed, err := rotated.Seal(plain, nil)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if !rotated.IsCurrent(resealed) {
+		t.Errorf("IsCurrent = false for a value sealed under the current key")
+	}
+}
+
+func TestNewKeyringRejectsPreviousWithoutCurrent(t *testing.T) {
+	if _, err := NewKeyring(nil, testKey(1)); err == nil {
+		t.Fatal("NewKeyring accepted previous keys without a current one")
+	}
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	kr, _ := NewKeyring(testKey(1))
+
+	sealed, err := kr.SealEnvelope([]byte("api token"), []byte("ctx"))
+	if err != nil {
+		t.Fatalf("SealEnvelope: %v", err)
+	}
+
+	if !IsEnvelope(sealed) {
+		t.Fatalf("IsEnvelope(%q) = false", sealed)
+	}
+	if IsSealed(sealed) {
+		t.Errorf("IsSealed(%q) = true for an envelope", sealed)
+	}
+	if strings.Contains(sealed, "api token") {
+		t.Fatalf("envelope leaks the plaintext: %q", sealed)
+	}
+
+	plain, err := kr.OpenEnvelope(sealed, []byte("ctx"))
+	if err != nil {
+		t.Fatalf("OpenEnvelope: %v", err)
+	}
+	if string(plain) != "api token" {
+		t.Errorf("OpenEnvelope = %q, want %q", plain, "api token")
+	}
+
+	if _, err := kr.OpenEnvelope(sealed, []byte("other")); err == nil {
+		t.Error("OpenEnvelope succeeded with another context")
+	}
+}
+
+func TestRewrapEnvelope(t *testing.T) {
+	old, _ := NewKeyring(testKey(1))
+	sealed, err := old.SealEnvelope([]byte("api token"), nil)
+	if err != nil {
+		t.Fatalf("SealEnvelope: %v", err)
+	}
+
+	rotated, _ := NewKeyring(testKey(2), testKey(1))
+	rewrapped, err := rotated.RewrapEnvelope(sealed, nil)
+	if err != nil {
+		t.Fatalf("RewrapEnvelope: %v", err)
+	}
+
+	if !rotated.IsCurrent(rewrapped) {
+		t.Errorf("IsCurrent = false after RewrapEnvelope")
+	}
+
+	// The ciphertext is kept, only the data key is sealed again.
+	if sealed[strings.LastIndex(sealed, ":"):] != rewrapped[strings.LastIndex(rewrapped, ":"):] {
+		t.Errorf("RewrapEnvelope changed the ciphertext")
+	}
+
+	again, err := rotated.RewrapEnvelope(rewrapped, nil)
+	if err != nil {
+		t.Fatalf("RewrapEnvelope: %v", err)
+	}
+	if again != rewrapped {
+		t.Errorf("RewrapEnvelope changed a value already sealed under the current key")
+	}
+
+	current, _ := NewKeyring(testKey(2))
+	plain, err := current.OpenEnvelope(rewrapped, nil)
+	if err != nil {
+		t.Fatalf("OpenEnvelope after retiring the previous key: %v", err)
+	}
+	if string(plain) != "api token" {
+		t.Errorf("OpenEnvelope = %q, want %q", plain, "api token")
+	}
+
+	if _, err := current.OpenEnvelope(sealed, nil); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("OpenEnvelope of a value sealed under a retired key: err = %v, want ErrUnknownKey", err)
+	}
+}
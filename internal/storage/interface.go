@@ -24,12 +24,16 @@ package storage // import "git.happydns.org/happyDomain/internal/storage"
 import (
 	"git.happydns.org/happyDomain/internal/usecase/authuser"
 	"git.happydns.org/happyDomain/internal/usecase/checker"
+	"git.happydns.org/happyDomain/internal/usecase/dkim"
+	"git.happydns.org/happyDomain/internal/usecase/dmarcreport"
 	"git.happydns.org/happyDomain/internal/usecase/domain"
 	"git.happydns.org/happyDomain/internal/usecase/domain_log"
 	"git.happydns.org/happyDomain/internal/usecase/insight"
 	"git.happydns.org/happyDomain/internal/usecase/notification"
 	"git.happydns.org/happyDomain/internal/usecase/provider"
+	"git.happydns.org/happyDomain/internal/usecase/providerjournal"
 	"git.happydns.org/happyDomain/internal/usecase/session"
+	"git.happydns.org/happyDomain/internal/usecase/tlsreport"
 	"git.happydns.org/happyDomain/internal/usecase/user"
 	"git.happydns.org/happyDomain/internal/usecase/zone"
 	"git.happydns.org/happyDomain/model"
@@ -51,6 +55,8 @@ type Storage interface {
 	checker.ObservationCacheStorage
 	checker.ObservationSnapshotStorage
 	checker.SchedulerStateStorage
+	dkim.DKIMStorage
+	dmarcreport.DMARCReportStorage
 	domain.DomainStorage
 	domainlog.DomainLogStorage
 	insight.InsightStorage
@@ -58,8 +64,11 @@ type Storage interface {
 	notification.NotificationPreferenceStorage
 	notification.NotificationStateStorage
 	notification.NotificationRecordStorage
+	provider.BuiltinZoneStorage
 	provider.ProviderStorage
+	providerjournal.ProviderCallStorage
 	session.SessionStorage
+	tlsreport.TLSReportStorage
 	user.UserStorage
 	zone.ZoneStorage
 
@@ -0,0 +1,82 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// Layout: builtinzone|<providerId>|<name> -> zone.
+
+const builtinZonePrimaryPrefix = "builtinzone|"
+
+func builtinZoneProviderPrefix(providerId happydns.Identifier) string {
+	return fmt.Sprintf("%s%s|", builtinZonePrimaryPrefix, providerId.String())
+}
+
+func builtinZonePrimaryKey(providerId happydns.Identifier, name string) string {
+	return builtinZoneProviderPrefix(providerId) + name
+}
+
+func (s *KVStorage) ListAllBuiltinZones() (happydns.Iterator[happydns.BuiltinZone], error) {
+	iter := s.db.Search(builtinZonePrimaryPrefix)
+	return NewKVIterator[happydns.BuiltinZone](s.db, iter), nil
+}
+
+func (s *KVStorage) ListBuiltinZones(providerId happydns.Identifier) (zones []*happydns.BuiltinZone, err error) {
+	iter := s.db.Search(builtinZoneProviderPrefix(providerId))
+	defer iter.Release()
+
+	for iter.Next() {
+		var z happydns.BuiltinZone
+
+		err = s.db.DecodeData(iter.Value(), &z)
+		if err != nil {
+			return
+		}
+
+		zones = append(zones, &z)
+	}
+
+	err = iter.Err()
+	return
+}
+
+func (s *KVStorage) GetBuiltinZone(providerId happydns.Identifier, name string) (*happydns.BuiltinZone, error) {
+	z := &happydns.BuiltinZone{}
+	err := s.db.Get(builtinZonePrimaryKey(providerId, name), z)
+	if errors.Is(err, happydns.ErrNotFound) {
+		return nil, happydns.ErrBuiltinZoneNotFound
+	}
+	return z, err
+}
+
+func (s *KVStorage) PutBuiltinZone(z *happydns.BuiltinZone) error {
+	return s.db.Put(builtinZonePrimaryKey(z.ProviderId, z.Name), z)
+}
+
+func (s *KVStorage) DeleteBuiltinZone(providerId happydns.Identifier, name string) error {
+	return s.db.Delete(builtinZonePrimaryKey(providerId, name))
+}
@@ -0,0 +1,130 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// Layout: dkimkey|<domainId>|<keyId> -> key; dkimpolicy|<domainId> -> policy.
+
+const (
+	dkimKeyPrimaryPrefix    = "dkimkey|"
+	dkimPolicyPrimaryPrefix = "dkimpolicy|"
+)
+
+func dkimKeyDomainPrefix(domainId happydns.Identifier) string {
+	return fmt.Sprintf("%s%s|", dkimKeyPrimaryPrefix, domainId.String())
+}
+
+func dkimKeyPrimaryKey(domainId, keyId happydns.Identifier) string {
+	return dkimKeyDomainPrefix(domainId) + keyId.String()
+}
+
+func dkimPolicyPrimaryKey(domainId happydns.Identifier) string {
+	return dkimPolicyPrimaryPrefix + domainId.String()
+}
+
+func (s *KVStorage) ListDKIMKeys(domainId happydns.Identifier) (keys []*happydns.DKIMKey, err error) {
+	iter := s.db.Search(dkimKeyDomainPrefix(domainId))
+	defer iter.Release()
+
+	for iter.Next() {
+		var k happydns.DKIMKey
+
+		err = s.db.DecodeData(iter.Value(), &k)
+		if err != nil {
+			return
+		}
+
+		keys = append(keys, &k)
+	}
+
+	err = iter.Err()
+	return
+}
+
+func (s *KVStorage) GetDKIMKey(domainId, keyId happydns.Identifier) (*happydns.DKIMKey, error) {
+	k := &happydns.DKIMKey{}
+	err := s.db.Get(dkimKeyPrimaryKey(domainId, keyId), k)
+	if errors.Is(err, happydns.ErrNotFound) {
+		return nil, happydns.ErrDKIMKeyNotFound
+	}
+	return k, err
+}
+
+func (s *KVStorage) CreateDKIMKey(k *happydns.DKIMKey) error {
+	key, id, err := s.db.FindIdentifierKey(dkimKeyDomainPrefix(k.DomainId))
+	if err != nil {
+		return err
+	}
+
+	k.Id = id
+	return s.db.Put(key, k)
+}
+
+func (s *KVStorage) UpdateDKIMKey(k *happydns.DKIMKey) error {
+	return s.db.Put(dkimKeyPrimaryKey(k.DomainId, k.Id), k)
+}
+
+func (s *KVStorage) DeleteDKIMKey(k *happydns.DKIMKey) error {
+	return s.db.Delete(dkimKeyPrimaryKey(k.DomainId, k.Id))
+}
+
+func (s *KVStorage) ListAllDKIMPolicies() (policies []*happydns.DKIMPolicy, err error) {
+	iter := s.db.Search(dkimPolicyPrimaryPrefix)
+	defer iter.Release()
+
+	for iter.Next() {
+		var p happydns.DKIMPolicy
+
+		err = s.db.DecodeData(iter.Value(), &p)
+		if err != nil {
+			return
+		}
+
+		policies = append(policies, &p)
+	}
+
+	err = iter.Err()
+	return
+}
+
+func (s *KVStorage) GetDKIMPolicy(domainId happydns.Identifier) (*happydns.DKIMPolicy, error) {
+	p := &happydns.DKIMPolicy{}
+	err := s.db.Get(dkimPolicyPrimaryKey(domainId), p)
+	if errors.Is(err, happydns.ErrNotFound) {
+		return nil, happydns.ErrDKIMPolicyNotFound
+	}
+	return p, err
+}
+
+func (s *KVStorage) PutDKIMPolicy(p *happydns.DKIMPolicy) error {
+	return s.db.Put(dkimPolicyPrimaryKey(p.DomainId), p)
+}
+
+func (s *KVStorage) DeleteDKIMPolicy(domainId happydns.Identifier) error {
+	return s.db.Delete(dkimPolicyPrimaryKey(domainId))
+}
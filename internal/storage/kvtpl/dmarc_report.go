@@ -0,0 +1,94 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// Layout: dmarcreport|<domainId>|<reportId> -> report; dmarcsettings|<domainId> -> settings.
+
+const (
+	dmarcReportPrimaryPrefix   = "dmarcreport|"
+	dmarcSettingsPrimaryPrefix = "dmarcsettings|"
+)
+
+func dmarcReportDomainPrefix(domainId happydns.Identifier) string {
+	return fmt.Sprintf("%s%s|", dmarcReportPrimaryPrefix, domainId.String())
+}
+
+func dmarcReportPrimaryKey(domainId, reportId happydns.Identifier) string {
+	return dmarcReportDomainPrefix(domainId) + reportId.String()
+}
+
+func dmarcSettingsPrimaryKey(domainId happydns.Identifier) string {
+	return dmarcSettingsPrimaryPrefix + domainId.String()
+}
+
+func (s *KVStorage) ListDMARCReports(domainId happydns.Identifier) (reports []*happydns.DMARCReport, err error) {
+	iter := s.db.Search(dmarcReportDomainPrefix(domainId))
+	defer iter.Release()
+
+	for iter.Next() {
+		var r happydns.DMARCReport
+
+		err = s.db.DecodeData(iter.Value(), &r)
+		if err != nil {
+			return
+		}
+
+		reports = append(reports, &r)
+	}
+
+	err = iter.Err()
+	return
+}
+
+func (s *KVStorage) CreateDMARCReport(r *happydns.DMARCReport) error {
+	key, id, err := s.db.FindIdentifierKey(dmarcReportDomainPrefix(r.DomainId))
+	if err != nil {
+		return err
+	}
+
+	r.Id = id
+	return s.db.Put(key, r)
+}
+
+func (s *KVStorage) DeleteDMARCReport(r *happydns.DMARCReport) error {
+	return s.db.Delete(dmarcReportPrimaryKey(r.DomainId, r.Id))
+}
+
+func (s *KVStorage) GetDMARCReportSettings(domainId happydns.Identifier) (*happydns.DMARCReportSettings, error) {
+	settings := &happydns.DMARCReportSettings{}
+	err := s.db.Get(dmarcSettingsPrimaryKey(domainId), settings)
+	if errors.Is(err, happydns.ErrNotFound) {
+		return nil, happydns.ErrDMARCReportSettingsNotFound
+	}
+	return settings, err
+}
+
+func (s *KVStorage) PutDMARCReportSettings(settings *happydns.DMARCReportSettings) error {
+	return s.db.Put(dmarcSettingsPrimaryKey(settings.DomainId), settings)
+}
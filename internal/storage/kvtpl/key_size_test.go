@@ -227,3 +227,33 @@ func TestCheckerOptionsNameIndexKeySize(t *testing.T) {
 	key := checkerOptionNameIndexKey("a-very-long-checker-plugin-name.v99", compoundHash)
 	assertKeySize(t, "checkerOptionNameIndexKey", key)
 }
+
+// --- dkim ---
+
+func TestDKIMKeyPrimaryKeySize(t *testing.T) {
+	assertKeySize(t, "dkimKeyPrimaryKey", dkimKeyPrimaryKey(maxID, maxID))
+}
+
+func TestDKIMPolicyPrimaryKeySize(t *testing.T) {
+	assertKeySize(t, "dkimPolicyPrimaryKey", dkimPolicyPrimaryKey(maxID))
+}
+
+// --- dmarc reports ---
+
+func TestDMARCReportPrimaryKeySize(t *testing.T) {
+	assertKeySize(t, "dmarcReportPrimaryKey", dmarcReportPrimaryKey(maxID, maxID))
+}
+
+func TestDMARCSettingsPrimaryKeySize(t *testing.T) {
+	assertKeySize(t, "dmarcSettingsPrimaryKey", dmarcSettingsPrimaryKey(maxID))
+}
+
+// --- tls reports ---
+
+func TestTLSReportPrimaryKeySize(t *testing.T) {
+	assertKeySize(t, "tlsReportPrimaryKey", tlsReportPrimaryKey(maxID, maxID))
+}
+
+func TestTLSSettingsPrimaryKeySize(t *testing.T) {
+	assertKeySize(t, "tlsSettingsPrimaryKey", tlsSettingsPrimaryKey(maxID))
+}
@@ -0,0 +1,74 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"fmt"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// Layout: providercall|<domainId>|<callId> -> call.
+
+const providerCallPrimaryPrefix = "providercall|"
+
+func providerCallDomainPrefix(domainId happydns.Identifier) string {
+	return fmt.Sprintf("%s%s|", providerCallPrimaryPrefix, domainId.String())
+}
+
+func (s *KVStorage) ListAllProviderCalls() (happydns.Iterator[happydns.ProviderCall], error) {
+	iter := s.db.Search(providerCallPrimaryPrefix)
+	return NewKVIterator[happydns.ProviderCall](s.db, iter), nil
+}
+
+func (s *KVStorage) ListProviderCalls(domainId happydns.Identifier) (calls []*happydns.ProviderCall, err error) {
+	iter := s.db.Search(providerCallDomainPrefix(domainId))
+	defer iter.Release()
+
+	for iter.Next() {
+		var c happydns.ProviderCall
+
+		err = s.db.DecodeData(iter.Value(), &c)
+		if err != nil {
+			return
+		}
+
+		calls = append(calls, &c)
+	}
+
+	err = iter.Err()
+	return
+}
+
+func (s *KVStorage) CreateProviderCall(c *happydns.ProviderCall) error {
+	key, id, err := s.db.FindIdentifierKey(providerCallDomainPrefix(c.DomainId))
+	if err != nil {
+		return err
+	}
+
+	c.Id = id
+	return s.db.Put(key, c)
+}
+
+func (s *KVStorage) DeleteProviderCall(c *happydns.ProviderCall) error {
+	return s.db.Delete(providerCallDomainPrefix(c.DomainId) + c.Id.String())
+}
@@ -0,0 +1,94 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// Layout: tlsreport|<domainId>|<reportId> -> report; tlsrptsettings|<domainId> -> settings.
+
+const (
+	tlsReportPrimaryPrefix   = "tlsreport|"
+	tlsSettingsPrimaryPrefix = "tlsrptsettings|"
+)
+
+func tlsReportDomainPrefix(domainId happydns.Identifier) string {
+	return fmt.Sprintf("%s%s|", tlsReportPrimaryPrefix, domainId.String())
+}
+
+func tlsReportPrimaryKey(domainId, reportId happydns.Identifier) string {
+	return tlsReportDomainPrefix(domainId) + reportId.String()
+}
+
+func tlsSettingsPrimaryKey(domainId happydns.Identifier) string {
+	return tlsSettingsPrimaryPrefix + domainId.String()
+}
+
+func (s *KVStorage) ListTLSReports(domainId happydns.Identifier) (reports []*happydns.TLSReport, err error) {
+	iter := s.db.Search(tlsReportDomainPrefix(domainId))
+	defer iter.Release()
+
+	for iter.Next() {
+		var r happydns.TLSReport
+
+		err = s.db.DecodeData(iter.Value(), &r)
+		if err != nil {
+			return
+		}
+
+		reports = append(reports, &r)
+	}
+
+	err = iter.Err()
+	return
+}
+
+func (s *KVStorage) CreateTLSReport(r *happydns.TLSReport) error {
+	key, id, err := s.db.FindIdentifierKey(tlsReportDomainPrefix(r.DomainId))
+	if err != nil {
+		return err
+	}
+
+	r.Id = id
+	return s.db.Put(key, r)
+}
+
+func (s *KVStorage) DeleteTLSReport(r *happydns.TLSReport) error {
+	return s.db.Delete(tlsReportPrimaryKey(r.DomainId, r.Id))
+}
+
+func (s *KVStorage) GetTLSReportSettings(domainId happydns.Identifier) (*happydns.TLSReportSettings, error) {
+	settings := &happydns.TLSReportSettings{}
+	err := s.db.Get(tlsSettingsPrimaryKey(domainId), settings)
+	if errors.Is(err, happydns.ErrNotFound) {
+		return nil, happydns.ErrTLSReportSettingsNotFound
+	}
+	return settings, err
+}
+
+func (s *KVStorage) PutTLSReportSettings(settings *happydns.TLSReportSettings) error {
+	return s.db.Put(tlsSettingsPrimaryKey(settings.DomainId), settings)
+}
@@ -0,0 +1,345 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+// Package sealed wraps a storage.Storage so that the credentials users entrust
+// to happyDomain for their providers are encrypted at rest.
+//
+// Every field of a provider tagged `happydomain:"secret"` is sealed with
+// secretbox.SealEnvelope on its way to the database, and opened again on its
+// way back, so that neither the engines nor the use cases above have to know
+// about it. Only the provider bodies are concerned: nothing else stored holds
+// a credential of the user.
+package sealed // import "git.happydns.org/happyDomain/internal/storage/sealed"
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+
+	"git.happydns.org/happyDomain/internal/forms"
+	providerReg "git.happydns.org/happyDomain/internal/providerregistry"
+	"git.happydns.org/happyDomain/internal/secretbox"
+	"git.happydns.org/happyDomain/internal/storage"
+	happydns "git.happydns.org/happyDomain/model"
+)
+
+// legacyProviderContext is the associated data the values were sealed with
+// before it was bound to their provider. Such values are still opened, and
+// sealed again under providerContext when their provider is rewritten.
+var legacyProviderContext = []byte("happydomain-provider-secret")
+
+// providerContext is the associated data binding the sealed values to their
+// purpose and to the provider holding them: a provider credential cannot be
+// opened as another kind of secret, nor once copied into another provider.
+func providerContext(id, owner happydns.Identifier) []byte {
+	return fmt.Appendf(nil, "happydomain-provider-secret:%s:%s", owner.String(), id.String())
+}
+
+// Storage seals the provider secrets written through it and opens those read
+// through it. Every other method goes straight to the wrapped storage.
+type Storage struct {
+	storage.Storage
+
+	keyring *secretbox.Keyring
+}
+
+// New wraps inner. Without a master key in keyring, providers are written in
+// clear as they used to be; values already sealed can then no longer be
+// opened, and reading them fails.
+func New(inner storage.Storage, keyring *secretbox.Keyring) *Storage {
+	return &Storage{
+		Storage: inner,
+		keyring: keyring,
+	}
+}
+
+// CreateProvider stores prvd with its secrets sealed. Their associated data
+// holds the identifier of the provider, only known once it is created: it is
+// first stored without its secrets, then updated with them.
+func (s *Storage) CreateProvider(prvd *happydns.Provider) error {
+	if !s.keyring.Available() || prvd.Provider == nil {
+		return s.Storage.CreateProvider(prvd)
+	}
+
+	blank, err := transformProvider(prvd, func(v string) (string, error) {
+		return "", nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.Storage.CreateProvider(blank); err != nil {
+		return err
+	}
+
+	prvd.Id = blank.Id
+
+	sealed, err := s.sealProvider(prvd, false)
+	if err == nil {
+		err = s.Storage.UpdateProvider(sealed)
+	}
+	if err != nil {
+		if delErr := s.Storage.DeleteProvider(prvd.Id); delErr != nil {
+			log.Printf("storage: orphan provider %q after its secrets could not be stored (rollback also failed: %v)", prvd.Id.String(), delErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) UpdateProvider(prvd *happydns.Provider) error {
+	sealed, err := s.sealProvider(prvd, false)
+	if err != nil {
+		return err
+	}
+
+	return s.Storage.UpdateProvider(sealed)
+}
+
+// RestoreProvider writes back a provider as ListSealedProviders returned it,
+// as a backup carries it: its secrets, already sealed, are kept rather than
+// sealed a second time, their data key being sealed again if the master key
+// has been rotated since. Only the restoration of a backup is meant to call
+// it; a value looking sealed given to UpdateProvider is sealed as any other.
+func (s *Storage) RestoreProvider(prvd *happydns.Provider) error {
+	sealed, err := s.sealProvider(prvd, true)
+	if err != nil {
+		return err
+	}
+
+	return s.Storage.UpdateProvider(sealed)
+}
+
+func (s *Storage) GetProvider(prvdid happydns.Identifier) (*happydns.ProviderMessage, error) {
+	msg, err := s.Storage.GetProvider(prvdid)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.openMessage(msg)
+}
+
+func (s *Storage) ListProviders(user *happydns.User) (happydns.ProviderMessages, error) {
+	msgs, err := s.Storage.ListProviders(user)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, msg := range msgs {
+		if msgs[i], err = s.openMessage(msg); err != nil {
+			return nil, err
+		}
+	}
+
+	return msgs, nil
+}
+
+// ListSealedProviders returns the providers of user as they are stored, their
+// secrets still sealed. It is what a backup carries, so that the credentials
+// do not leave the database in clear.
+func (s *Storage) ListSealedProviders(user *happydns.User) (happydns.ProviderMessages, error) {
+	return s.Storage.ListProviders(user)
+}
+
+func (s *Storage) ListAllProviders() (happydns.Iterator[happydns.ProviderMessage], error) {
+	iter, err := s.Storage.ListAllProviders()
+	if err != nil {
+		return nil, err
+	}
+
+	return &openingIterator{Iterator: iter, s: s}, nil
+}
+
+// sealProvider returns a copy of prvd whose secrets are sealed. prvd itself is
+// left alone: the caller goes on using the credentials it holds.
+//
+// With restore, a secret already sealed, as found in a restored backup, is
+// kept rather than sealed again. Otherwise every secret is sealed, whatever
+// it looks like.
+func (s *Storage) sealProvider(prvd *happydns.Provider, restore bool) (*happydns.Provider, error) {
+	if !s.keyring.Available() || prvd.Provider == nil {
+		return prvd, nil
+	}
+
+	context := providerContext(prvd.Id, prvd.Owner)
+	sealed, err := transformProvider(prvd, func(v string) (string, error) {
+		if restore && secretbox.IsEnvelope(v) {
+			return s.rewrap(v, context)
+		}
+		return s.keyring.SealEnvelope([]byte(v), context)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to seal the secrets of provider %s: %w", prvd.Id.String(), err)
+	}
+
+	return sealed, nil
+}
+
+// rewrap checks that the sealed value v belongs to the provider context is
+// bound to, and returns it with its data key sealed under the current master
+// key. A value sealed before the associated data was bound to its provider is
+// opened and sealed again.
+func (s *Storage) rewrap(v string, context []byte) (string, error) {
+	if _, err := s.keyring.OpenEnvelope(v, context); err != nil {
+		plain, legacyErr := s.keyring.OpenEnvelope(v, legacyProviderContext)
+		if legacyErr != nil {
+			return "", err
+		}
+
+		return s.keyring.SealEnvelope(plain, context)
+	}
+
+	return s.keyring.RewrapEnvelope(v, context)
+}
+
+// transformProvider returns a copy of prvd whose secret fields went through
+// fn.
+func transformProvider(prvd *happydns.Provider, fn func(string) (string, error)) (*happydns.Provider, error) {
+	raw, err := json.Marshal(prvd.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	body := reflect.New(reflect.Indirect(reflect.ValueOf(prvd.Provider)).Type())
+	if err := json.Unmarshal(raw, body.Interface()); err != nil {
+		return nil, err
+	}
+
+	if err := forms.TransformSecrets(body.Interface(), fn); err != nil {
+		return nil, err
+	}
+
+	ret := *prvd
+	ret.Provider = body.Interface().(happydns.ProviderBody)
+	return &ret, nil
+}
+
+// openMessage opens the sealed secrets of msg in place.
+//
+// Only the fields tagged secret in the body type registered for msg.Type are
+// opened: a value a user typed in any other field is returned as it was
+// written, even when it happens to look like an envelope.
+func (s *Storage) openMessage(msg *happydns.ProviderMessage) (*happydns.ProviderMessage, error) {
+	if len(msg.Provider) == 0 {
+		return msg, nil
+	}
+
+	body, err := providerReg.FindProvider(msg.Type)
+	if err != nil {
+		// Not ours to report: the use case parsing the body will.
+		return msg, nil
+	}
+
+	if err := json.Unmarshal(msg.Provider, body); err != nil {
+		return msg, nil
+	}
+
+	opened := false
+	err = forms.TransformSecrets(body, func(v string) (string, error) {
+		if !secretbox.IsEnvelope(v) {
+			// Stored before a master key was configured.
+			return v, nil
+		}
+
+		plain, err := s.keyring.OpenEnvelope(v, providerContext(msg.Id, msg.Owner))
+		if err != nil {
+			var legacyErr error
+			if plain, legacyErr = s.keyring.OpenEnvelope(v, legacyProviderContext); legacyErr != nil {
+				return "", err
+			}
+		}
+
+		opened = true
+		return string(plain), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open the secrets of provider %s: %w", msg.Id.String(), err)
+	}
+	if !opened {
+		return msg, nil
+	}
+
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	msg.Provider = raw
+	return msg, nil
+}
+
+// openingIterator opens the providers it walks through. Next skips, and logs,
+// a provider it cannot open, as it does one it cannot decode; NextWithError
+// reports it the way it does a decoding error: Item is nil and Err tells why.
+type openingIterator struct {
+	happydns.Iterator[happydns.ProviderMessage]
+
+	s    *Storage
+	item *happydns.ProviderMessage
+	err  error
+}
+
+func (it *openingIterator) Next() bool {
+	for it.Iterator.Next() {
+		item, err := it.s.openMessage(it.Iterator.Item())
+		if err != nil {
+			log.Printf("storage: skipping provider at %q: %s", it.Iterator.Key(), err.Error())
+			continue
+		}
+
+		it.item = item
+		return true
+	}
+
+	return false
+}
+
+func (it *openingIterator) NextWithError() bool {
+	if !it.Iterator.NextWithError() {
+		it.item, it.err = nil, nil
+		return false
+	}
+
+	item := it.Iterator.Item()
+	if item == nil {
+		it.item, it.err = nil, nil
+		return true
+	}
+
+	it.item, it.err = it.s.openMessage(item)
+	if it.err != nil {
+		it.item = nil
+	}
+	return true
+}
+
+func (it *openingIterator) Item() *happydns.ProviderMessage {
+	return it.item
+}
+
+func (it *openingIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+
+	return it.Iterator.Err()
+}
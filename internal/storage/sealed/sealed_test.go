@@ -0,0 +1,358 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package sealed_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"git.happydns.org/happyDomain/internal/secretbox"
+	"git.happydns.org/happyDomain/internal/storage"
+	"git.happydns.org/happyDomain/internal/storage/inmemory"
+	"git.happydns.org/happyDomain/internal/storage/sealed"
+	providerUC "git.happydns.org/happyDomain/internal/usecase/provider"
+	happydns "git.happydns.org/happyDomain/model"
+	"git.happydns.org/happyDomain/providers"
+)
+
+const keyMaterial = "raw-key-material"
+
+var encodedKeyMaterial = []byte(base64.StdEncoding.EncodeToString([]byte(keyMaterial)))
+
+func keyring(t *testing.T, keys ...byte) *secretbox.Keyring {
+	t.Helper()
+
+	var previous [][]byte
+	for _, b := range keys[1:] {
+		previous = append(previous, bytes.Repeat([]byte{b}, secretbox.KeySize))
+	}
+
+	kr, err := secretbox.NewKeyring(bytes.Repeat([]byte{keys[0]}, secretbox.KeySize), previous...)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return kr
+}
+
+func seed(t *testing.T, s storage.Storage) *happydns.Provider {
+	t.Helper()
+
+	p := &happydns.Provider{
+		ProviderMeta: happydns.ProviderMeta{
+			Type:  "DDNSServer",
+			Owner: happydns.Identifier([]byte("sealed-user")),
+		},
+		Provider: &providers.DDNSServer{
+			Server:  "127.0.0.1",
+			KeyName: "sealedkey",
+			KeyAlgo: "hmac-sha256",
+			KeyBlob: []byte(keyMaterial),
+		},
+	}
+	if err := s.CreateProvider(p); err != nil {
+		t.Fatalf("CreateProvider: %v", err)
+	}
+
+	return p
+}
+
+func TestProviderSecretsSealedAtRest(t *testing.T) {
+	inner, err := inmemory.Instantiate()
+	if err != nil {
+		t.Fatalf("failed to instantiate storage: %v", err)
+	}
+	s := sealed.New(inner, keyring(t, 1))
+
+	p := seed(t, s)
+
+	if string(p.Provider.(*providers.DDNSServer).KeyBlob) != keyMaterial {
+		t.Errorf("CreateProvider altered the caller's provider")
+	}
+
+	raw, err := inner.GetProvider(p.Id)
+	if err != nil {
+		t.Fatalf("GetProvider: %v", err)
+	}
+	if bytes.Contains(raw.Provider, encodedKeyMaterial) {
+		t.Errorf("the credential is stored in clear: %s", raw.Provider)
+	}
+	if !bytes.Contains(raw.Provider, []byte("sealedkey")) {
+		t.Errorf("untagged settings are not stored in clear: %s", raw.Provider)
+	}
+
+	msg, err := s.GetProvider(p.Id)
+	if err != nil {
+		t.Fatalf("GetProvider: %v", err)
+	}
+	if !bytes.Contains(msg.Provider, encodedKeyMaterial) {
+		t.Errorf("GetProvider did not open the credential: %s", msg.Provider)
+	}
+
+	msgs, err := s.ListProviders(&happydns.User{Id: p.Owner})
+	if err != nil {
+		t.Fatalf("ListProviders: %v", err)
+	}
+	if len(msgs) != 1 || !bytes.Contains(msgs[0].Provider, encodedKeyMaterial) {
+		t.Errorf("ListProviders did not open the credential: %v", msgs)
+	}
+
+	iter, err := s.ListAllProviders()
+	if err != nil {
+		t.Fatalf("ListAllProviders: %v", err)
+	}
+	defer iter.Close()
+	if !iter.Next() || !bytes.Contains(iter.Item().Provider, encodedKeyMaterial) {
+		t.Errorf("ListAllProviders did not open the credential")
+	}
+}
+
+func TestProviderSecretsKeyRotation(t *testing.T) {
+	inner, err := inmemory.Instantiate()
+	if err != nil {
+		t.Fatalf("failed to instantiate storage: %v", err)
+	}
+	p := seed(t, sealed.New(inner, keyring(t, 1)))
+
+	// A new master key, the previous one kept to open what it sealed.
+	rotated := sealed.New(inner, keyring(t, 2, 1))
+
+	msg, err := rotated.GetProvider(p.Id)
+	if err != nil {
+		t.Fatalf("GetProvider after rotation: %v", err)
+	}
+
+	parsed, err := providerUC.ParseProvider(msg)
+	if err != nil {
+		t.Fatalf("ParseProvider: %v", err)
+	}
+	if err := rotated.UpdateProvider(parsed); err != nil {
+		t.Fatalf("UpdateProvider: %v", err)
+	}
+
+	// Once rewritten, the previous key can be retired.
+	retired := sealed.New(inner, keyring(t, 2))
+	msg, err = retired.GetProvider(p.Id)
+	if err != nil {
+		t.Fatalf("GetProvider once the previous key is retired: %v", err)
+	}
+	if !bytes.Contains(msg.Provider, encodedKeyMaterial) {
+		t.Errorf("GetProvider did not open the credential: %s", msg.Provider)
+	}
+
+	if _, err := sealed.New(inner, keyring(t, 3)).GetProvider(p.Id); err == nil {
+		t.Errorf("GetProvider opened a credential under an unknown key")
+	}
+}
+
+// A sealed value found in a restored backup is stored as is rather than
+// sealed twice.
+func TestProviderSecretsRestored(t *testing.T) {
+	inner, err := inmemory.Instantiate()
+	if err != nil {
+		t.Fatalf("failed to instantiate storage: %v", err)
+	}
+	s := sealed.New(inner, keyring(t, 1))
+	p := seed(t, s)
+
+	raw, err := s.ListSealedProviders(&happydns.User{Id: p.Owner})
+	if err != nil || len(raw) != 1 {
+		t.Fatalf("ListSealedProviders = %v, %v", raw, err)
+	}
+
+	restored, err := providerUC.ParseProvider(raw[0])
+	if err != nil {
+		t.Fatalf("ParseProvider: %v", err)
+	}
+	if err := s.RestoreProvider(restored); err != nil {
+		t.Fatalf("RestoreProvider: %v", err)
+	}
+
+	msg, err := s.GetProvider(p.Id)
+	if err != nil {
+		t.Fatalf("GetProvider: %v", err)
+	}
+	if !bytes.Contains(msg.Provider, encodedKeyMaterial) {
+		t.Errorf("GetProvider did not open the credential: %s", msg.Provider)
+	}
+}
+
+// A value looking sealed that a user gives is sealed as any other: it is read
+// back as it was written, not as what it would open to.
+func TestProviderSecretsLookingSealed(t *testing.T) {
+	inner, err := inmemory.Instantiate()
+	if err != nil {
+		t.Fatalf("failed to instantiate storage: %v", err)
+	}
+	s := sealed.New(inner, keyring(t, 1))
+	victim := seed(t, s)
+	attacker := seed(t, s)
+
+	raw, err := s.ListSealedProviders(&happydns.User{Id: victim.Owner})
+	if err != nil || len(raw) != 2 {
+		t.Fatalf("ListSealedProviders = %v, %v", raw, err)
+	}
+	copied, err := providerUC.ParseProvider(raw[0])
+	if err != nil {
+		t.Fatalf("ParseProvider: %v", err)
+	}
+	envelope := copied.Provider.(*providers.DDNSServer).KeyBlob
+
+	attacker.Provider.(*providers.DDNSServer).KeyBlob = envelope
+	if err := s.UpdateProvider(attacker); err != nil {
+		t.Fatalf("UpdateProvider: %v", err)
+	}
+
+	msg, err := s.GetProvider(attacker.Id)
+	if err != nil {
+		t.Fatalf("GetProvider: %v", err)
+	}
+	parsed, err := providerUC.ParseProvider(msg)
+	if err != nil {
+		t.Fatalf("ParseProvider: %v", err)
+	}
+	if got := parsed.Provider.(*providers.DDNSServer).KeyBlob; !bytes.Equal(got, envelope) {
+		t.Errorf("KeyBlob = %q, want the value given, %q", got, envelope)
+	}
+}
+
+// A sealed value is bound to its provider: once copied into another one, it
+// can be neither restored nor opened.
+func TestProviderSecretsBoundToProvider(t *testing.T) {
+	inner, err := inmemory.Instantiate()
+	if err != nil {
+		t.Fatalf("failed to instantiate storage: %v", err)
+	}
+	s := sealed.New(inner, keyring(t, 1))
+	p := seed(t, s)
+	other := seed(t, s)
+
+	msg, err := inner.GetProvider(p.Id)
+	if err != nil {
+		t.Fatalf("GetProvider: %v", err)
+	}
+	restored, err := providerUC.ParseProvider(msg)
+	if err != nil {
+		t.Fatalf("ParseProvider: %v", err)
+	}
+
+	restored.Id = other.Id
+	if err := s.RestoreProvider(restored); err == nil {
+		t.Errorf("RestoreProvider kept a credential sealed for another provider")
+	}
+
+	// Written straight to the database.
+	if err := inner.UpdateProvider(restored); err != nil {
+		t.Fatalf("UpdateProvider: %v", err)
+	}
+	if _, err := s.GetProvider(other.Id); err == nil {
+		t.Errorf("GetProvider opened a credential sealed for another provider")
+	}
+}
+
+func TestProviderSecretsWithoutKey(t *testing.T) {
+	inner, err := inmemory.Instantiate()
+	if err != nil {
+		t.Fatalf("failed to instantiate storage: %v", err)
+	}
+	empty, _ := secretbox.NewKeyring(nil)
+
+	p := seed(t, sealed.New(inner, empty))
+
+	raw, err := inner.GetProvider(p.Id)
+	if err != nil {
+		t.Fatalf("GetProvider: %v", err)
+	}
+	if !bytes.Contains(raw.Provider, encodedKeyMaterial) {
+		t.Errorf("without a key, the credential should be stored as is: %s", raw.Provider)
+	}
+}
+
+// Only the fields tagged secret are opened: a setting the user typed is read
+// back as it was written, even when it looks like a sealed value.
+func TestProviderSettingsNotOpened(t *testing.T) {
+	inner, err := inmemory.Instantiate()
+	if err != nil {
+		t.Fatalf("failed to instantiate storage: %v", err)
+	}
+	kr := keyring(t, 1)
+	s := sealed.New(inner, kr)
+
+	lookalike, err := kr.SealEnvelope([]byte("not a credential"), []byte("elsewhere"))
+	if err != nil {
+		t.Fatalf("SealEnvelope: %v", err)
+	}
+
+	p := &happydns.Provider{
+		ProviderMeta: happydns.ProviderMeta{
+			Type:  "DDNSServer",
+			Owner: happydns.Identifier([]byte("sealed-user")),
+		},
+		Provider: &providers.DDNSServer{
+			Server:  "127.0.0.1",
+			KeyName: lookalike,
+			KeyAlgo: "hmac-sha256",
+			KeyBlob: []byte(keyMaterial),
+		},
+	}
+	if err := s.CreateProvider(p); err != nil {
+		t.Fatalf("CreateProvider: %v", err)
+	}
+
+	msg, err := s.GetProvider(p.Id)
+	if err != nil {
+		t.Fatalf("GetProvider: %v", err)
+	}
+
+	parsed, err := providerUC.ParseProvider(msg)
+	if err != nil {
+		t.Fatalf("ParseProvider: %v", err)
+	}
+	if got := parsed.Provider.(*providers.DDNSServer).KeyName; got != lookalike {
+		t.Errorf("KeyName = %q, want it untouched", got)
+	}
+	if !bytes.Contains(msg.Provider, encodedKeyMaterial) {
+		t.Errorf("GetProvider did not open the credential: %s", msg.Provider)
+	}
+}
+
+// A provider that cannot be opened is reported by NextWithError, rather than
+// skipped as Next does.
+func TestProviderSecretsIteratorReportsFailures(t *testing.T) {
+	inner, err := inmemory.Instantiate()
+	if err != nil {
+		t.Fatalf("failed to instantiate storage: %v", err)
+	}
+	seed(t, sealed.New(inner, keyring(t, 1)))
+
+	iter, err := sealed.New(inner, keyring(t, 2)).ListAllProviders()
+	if err != nil {
+		t.Fatalf("ListAllProviders: %v", err)
+	}
+	defer iter.Close()
+
+	if !iter.NextWithError() {
+		t.Fatalf("NextWithError skipped the provider it cannot open")
+	}
+	if iter.Item() != nil || iter.Err() == nil {
+		t.Errorf("NextWithError = %v, %v; want no item and an error", iter.Item(), iter.Err())
+	}
+}
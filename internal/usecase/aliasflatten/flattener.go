@@ -0,0 +1,150 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package aliasflatten
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// Flattener replaces ALIAS records by the addresses their target resolves to.
+type Flattener struct {
+	resolver Resolver
+}
+
+// NewFlattener builds a Flattener asking the given resolver.
+func NewFlattener(resolver Resolver) *Flattener {
+	return &Flattener{resolver: resolver}
+}
+
+// FlattenAliases returns the given records, where each ALIAS is replaced by
+// the A and AAAA records its target currently resolves to, under the same
+// owner and with the same TTL. The returned map gives the target of each
+// flattened owner, keyed by its canonical name.
+//
+// A target without any address is an error: publishing nothing would take
+// the name down.
+func (f *Flattener) FlattenAliases(ctx context.Context, rrs []happydns.Record) ([]happydns.Record, map[string]string, error) {
+	var ret []happydns.Record
+	flattened := map[string]string{}
+
+	for _, rr := range rrs {
+		if rr.Header().Rrtype != happydns.TypeALIAS {
+			ret = append(ret, rr)
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		target := aliasTarget(rr)
+		if target == "" {
+			return nil, nil, happydns.ValidationError{Msg: fmt.Sprintf("the ALIAS record of %s has no target", rr.Header().Name)}
+		}
+
+		addrs, err := f.resolve(target)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, ip := range addrs {
+			ret = append(ret, addressRecord(rr.Header().Name, rr.Header().Ttl, ip))
+		}
+		flattened[dns.CanonicalName(rr.Header().Name)] = target
+	}
+
+	return ret, flattened, nil
+}
+
+// resolve returns the addresses of the given target, sorted so that the
+// records built from them diff the same way from one resolution to another.
+func (f *Flattener) resolve(target string) ([]net.IP, error) {
+	var addrs []net.IP
+
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		r, err := f.resolver.ResolveQuestion(happydns.ResolverRequest{
+			Resolver:   "local",
+			DomainName: target,
+			Type:       dns.TypeToString[qtype],
+		})
+		var nxdomain happydns.NotFoundError
+		if errors.As(err, &nxdomain) {
+			return nil, happydns.ValidationError{Msg: fmt.Sprintf("unable to flatten the ALIAS pointing at %s: the name does not exist", target)}
+		} else if err != nil {
+			return nil, fmt.Errorf("unable to resolve the ALIAS target %s: %w", target, err)
+		}
+
+		// The answer holds the CNAME chain leading to the addresses as well.
+		for _, answer := range r.Answer {
+			switch a := answer.(type) {
+			case *dns.A:
+				addrs = append(addrs, a.A)
+			case *dns.AAAA:
+				addrs = append(addrs, a.AAAA)
+			}
+		}
+	}
+
+	if len(addrs) == 0 {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("unable to flatten the ALIAS pointing at %s: it has no address", target)}
+	}
+
+	slices.SortFunc(addrs, func(a, b net.IP) int {
+		return slices.Compare(a.To16(), b.To16())
+	})
+	addrs = slices.CompactFunc(addrs, net.IP.Equal)
+
+	return addrs, nil
+}
+
+// aliasTarget returns the name the given ALIAS points at.
+func aliasTarget(rr happydns.Record) string {
+	if record, ok := rr.(*dns.PrivateRR); ok {
+		if data, ok := record.Data.(happydns.TargetRdata); ok {
+			return dns.Fqdn(data.GetTarget())
+		}
+	}
+	return ""
+}
+
+// addressRecord builds the A or AAAA record publishing ip under owner.
+func addressRecord(owner string, ttl uint32, ip net.IP) happydns.Record {
+	if ip4 := ip.To4(); ip4 != nil {
+		return &dns.A{
+			Hdr: dns.RR_Header{Name: owner, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: ttl},
+			A:   ip4,
+		}
+	}
+
+	return &dns.AAAA{
+		Hdr:  dns.RR_Header{Name: owner, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: ttl},
+		AAAA: ip,
+	}
+}
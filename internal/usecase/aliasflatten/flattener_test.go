@@ -0,0 +1,150 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package aliasflatten
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// fakeResolver answers from a static table of zone file lines, keyed by
+// question name and type.
+type fakeResolver struct {
+	answers map[string][]string
+	asked   []string
+}
+
+func (f *fakeResolver) ResolveQuestion(req happydns.ResolverRequest) (*dns.Msg, error) {
+	key := req.DomainName + " " + req.Type
+	f.asked = append(f.asked, key)
+
+	lines, ok := f.answers[key]
+	if !ok {
+		return nil, happydns.NotFoundError{Msg: "not found"}
+	}
+
+	r := new(dns.Msg)
+	for _, line := range lines {
+		rr, err := dns.NewRR(line)
+		if err != nil {
+			return nil, err
+		}
+		r.Answer = append(r.Answer, rr)
+	}
+	return r, nil
+}
+
+func mustRR(t *testing.T, line string) happydns.Record {
+	t.Helper()
+
+	rr, err := dns.NewRR(line)
+	if err != nil {
+		t.Fatalf("unable to parse %q: %s", line, err)
+	}
+
+	return rr
+}
+
+func TestFlattenAliases(t *testing.T) {
+	resolver := &fakeResolver{answers: map[string][]string{
+		"cdn.example.net. A": {
+			"cdn.example.net. 60 IN CNAME edge.example.net.",
+			"edge.example.net. 60 IN A 192.0.2.20",
+			"edge.example.net. 60 IN A 192.0.2.10",
+			"edge.example.net. 60 IN A 192.0.2.10",
+		},
+		"cdn.example.net. AAAA": {
+			"cdn.example.net. 60 IN CNAME edge.example.net.",
+			"edge.example.net. 60 IN AAAA 2001:db8::1",
+		},
+	}}
+
+	rrs := []happydns.Record{
+		mustRR(t, "example.com. 3600 IN SOA ns.example.com. admin.example.com. 1 7200 3600 1209600 300"),
+		mustRR(t, "Example.com. 300 IN ALIAS cdn.example.net."),
+		mustRR(t, "www.example.com. 300 IN CNAME example.com."),
+	}
+
+	got, flattened, err := NewFlattener(resolver).FlattenAliases(context.Background(), rrs)
+	if err != nil {
+		t.Fatalf("FlattenAliases returned an error: %s", err)
+	}
+
+	want := []string{
+		"example.com.\t3600\tIN\tSOA\tns.example.com. admin.example.com. 1 7200 3600 1209600 300",
+		"Example.com.\t300\tIN\tA\t192.0.2.10",
+		"Example.com.\t300\tIN\tA\t192.0.2.20",
+		"Example.com.\t300\tIN\tAAAA\t2001:db8::1",
+		"www.example.com.\t300\tIN\tCNAME\texample.com.",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d records, want %d: %v", len(got), len(want), got)
+	}
+	for i, rr := range got {
+		if rr.String() != want[i] {
+			t.Errorf("record %d is %q, want %q", i, rr.String(), want[i])
+		}
+	}
+
+	if len(flattened) != 1 || flattened["example.com."] != "cdn.example.net." {
+		t.Errorf("flattened owners are %v, want example.com. pointing at cdn.example.net.", flattened)
+	}
+}
+
+func TestFlattenAliasesWithoutAlias(t *testing.T) {
+	resolver := &fakeResolver{}
+
+	rrs := []happydns.Record{mustRR(t, "example.com. 300 IN A 192.0.2.1")}
+
+	got, flattened, err := NewFlattener(resolver).FlattenAliases(context.Background(), rrs)
+	if err != nil {
+		t.Fatalf("FlattenAliases returned an error: %s", err)
+	}
+	if len(got) != 1 || len(flattened) != 0 || len(resolver.asked) != 0 {
+		t.Errorf("a zone without ALIAS was changed: %v, %v, asked %v", got, flattened, resolver.asked)
+	}
+}
+
+func TestFlattenAliasesUnresolvable(t *testing.T) {
+	for name, answers := range map[string]map[string][]string{
+		"nxdomain": {},
+		"no address": {
+			"cdn.example.net. A":    {},
+			"cdn.example.net. AAAA": {},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rrs := []happydns.Record{mustRR(t, "example.com. 300 IN ALIAS cdn.example.net.")}
+
+			_, _, err := NewFlattener(&fakeResolver{answers: answers}).FlattenAliases(context.Background(), rrs)
+			var verr happydns.ValidationError
+			if !errors.As(err, &verr) {
+				t.Errorf("FlattenAliases returned %v, want a ValidationError", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package aliasflatten
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	adapter "git.happydns.org/happyDomain/internal/adapters"
+	providerReg "git.happydns.org/happyDomain/internal/providerregistry"
+	domainlogUC "git.happydns.org/happyDomain/internal/usecase/domain_log"
+	"git.happydns.org/happyDomain/internal/usecase/orchestrator"
+	"git.happydns.org/happyDomain/model"
+	svcs "git.happydns.org/happyDomain/services"
+)
+
+// publishTimeout bounds the publication of a single zone, so that a slow
+// provider doesn't hold the whole sweep.
+const publishTimeout = 2 * time.Minute
+
+// RefresherStorage is the storage needed by the Refresher.
+type RefresherStorage interface {
+	DomainLister
+	UserGetter
+}
+
+// Refresher periodically re-resolves the flattened ALIAS records, and
+// republishes the addresses of those whose target's ones changed.
+type Refresher struct {
+	store     RefresherStorage
+	providers ProviderGetter
+	getZone   ZoneGetter
+	publisher ZonePublisher
+	domainLog domainlogUC.DomainLogAppender
+	interval  time.Duration
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	done    chan struct{}
+	running bool
+}
+
+// NewRefresher builds a Refresher that runs every `interval`.
+func NewRefresher(store RefresherStorage, providers ProviderGetter, getZone ZoneGetter, publisher ZonePublisher, domainLog domainlogUC.DomainLogAppender, interval time.Duration) *Refresher {
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+	return &Refresher{
+		store:     store,
+		providers: providers,
+		getZone:   getZone,
+		publisher: publisher,
+		domainLog: domainLog,
+		interval:  interval,
+	}
+}
+
+// Start launches the refresher loop in a goroutine.
+func (r *Refresher) Start(ctx context.Context) {
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r.cancel = cancel
+	r.done = make(chan struct{})
+	r.running = true
+	r.mu.Unlock()
+
+	go r.loop(ctx)
+}
+
+// Stop halts the refresher and waits for the current sweep to finish.
+func (r *Refresher) Stop() {
+	r.mu.Lock()
+	cancel := r.cancel
+	done := r.done
+	r.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	if done != nil {
+		<-done
+	}
+	r.mu.Lock()
+	r.running = false
+	r.mu.Unlock()
+}
+
+func (r *Refresher) loop(ctx context.Context) {
+	defer close(r.done)
+
+	r.RunOnce(ctx)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce performs a single sweep over the domains. It returns the number of
+// zones republished.
+func (r *Refresher) RunOnce(ctx context.Context) int {
+	iter, err := r.store.ListAllDomains()
+	if err != nil {
+		log.Printf("ALIAS refresher: failed to list domains: %v", err)
+		return 0
+	}
+
+	// Publishing updates the domains: don't hold the iterator meanwhile.
+	var domains []*happydns.Domain
+	for iter.Next() {
+		if domain := iter.Item(); len(domain.ZoneHistory) > 0 {
+			domains = append(domains, domain)
+		}
+	}
+	iter.Close()
+
+	republished := 0
+
+	for _, domain := range domains {
+		select {
+		case <-ctx.Done():
+			return republished
+		default:
+		}
+
+		zone, err := r.getZone.Get(domain.ZoneHistory[0])
+		if err != nil {
+			log.Printf("ALIAS refresher: unable to retrieve the zone of %s: %v", domain.DomainName, err)
+			continue
+		}
+
+		if !hasPublishedAlias(zone) {
+			continue
+		}
+
+		published, err := r.publishedZone(domain)
+		if err != nil {
+			log.Printf("ALIAS refresher: unable to retrieve the published zone of %s: %v", domain.DomainName, err)
+			continue
+		} else if published == nil {
+			continue
+		}
+
+		user, err := r.store.GetUser(domain.Owner)
+		if err != nil {
+			log.Printf("ALIAS refresher: unable to retrieve owner of %s: %v", domain.DomainName, err)
+			continue
+		}
+
+		provider, err := r.providers.GetUserProvider(ctx, user, domain.ProviderId)
+		if err != nil {
+			log.Printf("ALIAS refresher: unable to retrieve the provider of %s: %v", domain.DomainName, err)
+			continue
+		}
+
+		if !providerReg.ProviderHasCapability(provider, adapter.AliasFlatteningCapability) {
+			continue
+		}
+
+		if r.refresh(ctx, user, domain, zone, published) {
+			republished++
+		}
+	}
+
+	return republished
+}
+
+// publishedZone returns the newest zone of the domain published to its
+// provider, if any.
+func (r *Refresher) publishedZone(domain *happydns.Domain) (*happydns.Zone, error) {
+	for _, id := range domain.ZoneHistory {
+		zone, err := r.getZone.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		if zone.Published != nil {
+			return zone, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// refresh publishes the pending corrections of the flattened ALIAS of the
+// given zone, leaving the other ones for the user to review. Only the ALIAS
+// pointing at the target they had when last published are refreshed: a new
+// target the user gave since waits for them to publish it.
+func (r *Refresher) refresh(ctx context.Context, user *happydns.User, domain *happydns.Domain, zone, last *happydns.Zone) bool {
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+
+	published, err := r.publisher.ApplyMatching(ctx, user, domain, zone, "Refresh the addresses of flattened ALIAS", orchestrator.RefreshesPublished(last, orchestrator.FromFlattenedAlias))
+	if err != nil {
+		log.Printf("ALIAS refresher: unable to republish the flattened ALIAS of %s: %v", domain.DomainName, err)
+		return false
+	} else if published == nil {
+		return false
+	}
+
+	log.Printf("ALIAS refresher: %s republished with the new addresses of its ALIAS targets", domain.DomainName)
+	if r.domainLog != nil {
+		if err := r.domainLog.AppendDomainLog(domain, happydns.NewDomainLog(user, happydns.LOG_INFO, "The addresses an ALIAS target resolves to changed, the flattened records were republished.")); err != nil {
+			log.Printf("unable to append domain log for %s: %s", domain.DomainName, err.Error())
+		}
+	}
+
+	return true
+}
+
+// hasPublishedAlias tells whether the zone holds an ALIAS that was already
+// published once. The one the user just added is left for them to publish
+// along with the rest of their changes.
+func hasPublishedAlias(zone *happydns.Zone) bool {
+	for _, services := range zone.Services {
+		for _, s := range services {
+			alias, ok := s.Service.(*svcs.Alias)
+			if !ok || alias.Record == nil || s.PropagatedAt == nil {
+				continue
+			}
+
+			if alias.Record.Header().Rrtype == happydns.TypeALIAS {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,165 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package aliasflatten
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	adapter "git.happydns.org/happyDomain/internal/adapters"
+	providerReg "git.happydns.org/happyDomain/internal/providerregistry"
+	"git.happydns.org/happyDomain/model"
+	svcs "git.happydns.org/happyDomain/services"
+)
+
+const testProviderType = "AliasFlatteningTest"
+
+func init() {
+	providerReg.RegisterNamedProvider(testProviderType, nil, happydns.ProviderInfos{
+		Capabilities: []string{adapter.AliasFlatteningCapability},
+	})
+}
+
+type sliceIterator[T any] struct {
+	items []*T
+	idx   int
+	cur   *T
+}
+
+func (it *sliceIterator[T]) Next() bool {
+	if it.idx >= len(it.items) {
+		return false
+	}
+	it.cur = it.items[it.idx]
+	it.idx++
+	return true
+}
+func (it *sliceIterator[T]) NextWithError() bool { return it.Next() }
+func (it *sliceIterator[T]) Item() *T            { return it.cur }
+func (it *sliceIterator[T]) DropItem() error     { return nil }
+func (it *sliceIterator[T]) Key() string         { return "" }
+func (it *sliceIterator[T]) Raw() any            { return nil }
+func (it *sliceIterator[T]) Err() error          { return nil }
+func (it *sliceIterator[T]) Close()              {}
+
+type fakeStore struct {
+	domains []*happydns.Domain
+}
+
+func (s *fakeStore) ListAllDomains() (happydns.Iterator[happydns.Domain], error) {
+	return &sliceIterator[happydns.Domain]{items: s.domains}, nil
+}
+
+func (s *fakeStore) GetUser(id happydns.Identifier) (*happydns.User, error) {
+	return &happydns.User{Id: id}, nil
+}
+
+type fakeProviders struct{}
+
+func (fakeProviders) GetUserProvider(context.Context, *happydns.User, happydns.Identifier) (*happydns.Provider, error) {
+	return &happydns.Provider{ProviderMeta: happydns.ProviderMeta{Type: testProviderType}}, nil
+}
+
+type fakeZones map[string]*happydns.Zone
+
+func (z fakeZones) Get(id happydns.Identifier) (*happydns.Zone, error) {
+	return z[id.String()], nil
+}
+
+// fakePublisher proposes the same corrections for every zone, and records
+// those it was asked to publish.
+type fakePublisher struct {
+	corrections []*happydns.Correction
+	published   []*happydns.Correction
+}
+
+func (p *fakePublisher) ApplyMatching(_ context.Context, _ *happydns.User, _ *happydns.Domain, zone *happydns.Zone, _ string, keep func(*happydns.Correction) bool) (*happydns.Zone, error) {
+	var published []*happydns.Correction
+	for _, cr := range p.corrections {
+		if keep(cr) {
+			published = append(published, cr)
+		}
+	}
+	if len(published) == 0 {
+		return nil, nil
+	}
+
+	p.published = append(p.published, published...)
+	return zone, nil
+}
+
+func TestRefresherKeepsUnpublishedTargets(t *testing.T) {
+	now := time.Now()
+	published := &happydns.Zone{
+		ZoneMeta: happydns.ZoneMeta{
+			Id:        happydns.Identifier("published"),
+			Published: &now,
+			Derived: []*happydns.DerivedRecords{
+				{Owner: "example.com.", From: "ALIAS cdn.example.net.", Records: []string{"192.0.2.10"}},
+				{Owner: "www.example.com.", From: "ALIAS cdn.example.net.", Records: []string{"192.0.2.10"}},
+			},
+		},
+	}
+
+	// The user pointed www.example.com at another target, without publishing
+	// it yet.
+	wip := &happydns.Zone{
+		ZoneMeta: happydns.ZoneMeta{Id: happydns.Identifier("wip")},
+		Services: map[happydns.Subdomain][]*happydns.Service{
+			"": {{
+				ServiceMeta: happydns.ServiceMeta{PropagatedAt: &now},
+				Service:     &svcs.Alias{Record: mustRR(t, "example.com. 300 IN ALIAS cdn.example.net.")},
+			}},
+			"www": {{
+				ServiceMeta: happydns.ServiceMeta{PropagatedAt: &now},
+				Service:     &svcs.Alias{Record: mustRR(t, "www.example.com. 300 IN ALIAS other-cdn.example.org.")},
+			}},
+		},
+	}
+
+	domain := &happydns.Domain{
+		Id:          happydns.Identifier("domain"),
+		Owner:       happydns.Identifier("user"),
+		DomainName:  "example.com.",
+		ZoneHistory: []happydns.Identifier{wip.Id, published.Id},
+	}
+
+	refreshed := &happydns.Correction{
+		NewRecords:  []happydns.Record{mustRR(t, "example.com. 300 IN A 192.0.2.20")},
+		DerivedFrom: "ALIAS cdn.example.net.",
+	}
+	retargeted := &happydns.Correction{
+		NewRecords:  []happydns.Record{mustRR(t, "www.example.com. 300 IN A 198.51.100.1")},
+		DerivedFrom: "ALIAS other-cdn.example.org.",
+	}
+	publisher := &fakePublisher{corrections: []*happydns.Correction{refreshed, retargeted}}
+
+	r := NewRefresher(&fakeStore{domains: []*happydns.Domain{domain}}, fakeProviders{}, fakeZones{"wip": wip, "published": published}, publisher, nil, time.Hour)
+
+	if n := r.RunOnce(context.Background()); n != 1 {
+		t.Errorf("RunOnce republished %d zones, want 1", n)
+	}
+	if len(publisher.published) != 1 || publisher.published[0] != refreshed {
+		t.Errorf("RunOnce published %v, want only the refreshed addresses of the published target", publisher.published)
+	}
+}
@@ -41,6 +41,38 @@ func NewUsecase(store storage.Storage) *Usecase {
 	return &Usecase{store: store}
 }
 
+// sealedProviderLister is implemented by the storage encrypting provider
+// credentials at rest (see storage/sealed).
+type sealedProviderLister interface {
+	ListSealedProviders(user *happydns.User) (happydns.ProviderMessages, error)
+}
+
+// sealedProviderRestorer is implemented by the storage encrypting provider
+// credentials at rest, to write back what ListSealedProviders returned.
+type sealedProviderRestorer interface {
+	RestoreProvider(prvd *happydns.Provider) error
+}
+
+// listProviders returns the providers of user as stored, so that a backup
+// keeps their credentials encrypted when the storage encrypts them. Restore
+// writes them back as they are, through restoreProvider.
+func (u *Usecase) listProviders(user *happydns.User) (happydns.ProviderMessages, error) {
+	if sealed, ok := u.store.(sealedProviderLister); ok {
+		return sealed.ListSealedProviders(user)
+	}
+
+	return u.store.ListProviders(user)
+}
+
+// restoreProvider writes back a provider as listProviders returned it.
+func (u *Usecase) restoreProvider(prvd *happydns.Provider) error {
+	if sealed, ok := u.store.(sealedProviderRestorer); ok {
+		return sealed.RestoreProvider(prvd)
+	}
+
+	return u.store.UpdateProvider(prvd)
+}
+
 func (u *Usecase) backupOneUser(user *happydns.User, ret *happydns.Backup) {
 	ret.Users = append(ret.Users, user)
 
@@ -73,7 +105,7 @@ func (u *Usecase) backupOneUser(user *happydns.User, ret *happydns.Backup) {
 	}
 
 	// Providers
-	ps, err := u.store.ListProviders(user)
+	ps, err := u.listProviders(user)
 	if err != nil {
 		ret.Errors = append(ret.Errors, fmt.Sprintf("unable to retrieve Providers: %s", err.Error()))
 	} else {
@@ -236,8 +268,9 @@ func (u *Usecase) BackupUser(user *happydns.User) happydns.Backup {
 
 	// Same reasoning for the API keys and passwords a user entrusted to their
 	// providers: this file leaves happyDomain, so it must not carry anything
-	// that still opens a door. The administrative Backup() keeps them, as
-	// Restore has to put working providers back.
+	// that still opens a door. The administrative Backup() keeps them, sealed
+	// when a master key is configured, as Restore has to put working
+	// providers back.
 	for i, pm := range ret.Providers {
 		redacted, err := redactProviderMessage(pm)
 		if err != nil {
@@ -347,7 +380,7 @@ func (u *Usecase) Restore(backup *happydns.Backup) error {
 			errs = errors.Join(errs, err)
 		}
 
-		errs = errors.Join(errs, u.store.UpdateProvider(p))
+		errs = errors.Join(errs, u.restoreProvider(p))
 	}
 
 	// Domains
@@ -0,0 +1,31 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package dkim implements the DKIM key management use cases: generating key
+// pairs on behalf of the user, sealing their private part at rest, publishing
+// them in the zone under a dated selector and rotating them on a schedule.
+//
+// The Service exposes the user-facing operations (policy, listing, manual
+// generation, private key export) while the Rotator is the background worker
+// that activates the new keys once their selector is published, generates a
+// new key when the active one is due and withdraws the selectors of retired
+// keys once their grace period is over.
+package dkim
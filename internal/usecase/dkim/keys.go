@@ -0,0 +1,178 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dkim
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	"git.happydns.org/happyDomain/model"
+	svcs "git.happydns.org/happyDomain/services"
+)
+
+const (
+	defaultAlgorithm      = happydns.DKIMAlgorithmRSA
+	defaultBits           = 2048
+	defaultSelectorPrefix = "hd"
+	defaultRotationDays   = 180
+	defaultGraceDays      = 7
+
+	// minRSABits follows RFC 8301, which forbids verifiers to accept RSA
+	// keys shorter than 1024 bits.
+	minRSABits = 1024
+	maxRSABits = 4096
+)
+
+// DefaultPolicy returns the policy applied to a domain that has not defined
+// its own: a 2048-bit RSA key, still the most widely verified, rotated twice a
+// year.
+func DefaultPolicy(domainId happydns.Identifier) *happydns.DKIMPolicy {
+	return &happydns.DKIMPolicy{
+		DomainId:       domainId,
+		Algorithm:      defaultAlgorithm,
+		Bits:           defaultBits,
+		SelectorPrefix: defaultSelectorPrefix,
+		RotationDays:   defaultRotationDays,
+		GraceDays:      defaultGraceDays,
+	}
+}
+
+// validatePolicy fills the unset fields of policy with their defaults and
+// checks the others.
+func validatePolicy(policy *happydns.DKIMPolicy) error {
+	policy.Algorithm = strings.ToLower(strings.TrimSpace(policy.Algorithm))
+	switch policy.Algorithm {
+	case "":
+		policy.Algorithm = defaultAlgorithm
+		fallthrough
+	case happydns.DKIMAlgorithmRSA:
+		if policy.Bits == 0 {
+			policy.Bits = defaultBits
+		}
+		if policy.Bits < minRSABits || policy.Bits > maxRSABits || policy.Bits%1024 != 0 {
+			return happydns.ValidationError{Msg: fmt.Sprintf("RSA keys must be a multiple of 1024 bits, between %d and %d", minRSABits, maxRSABits)}
+		}
+	case happydns.DKIMAlgorithmEd25519:
+		policy.Bits = 0
+	default:
+		return happydns.ValidationError{Msg: fmt.Sprintf("unsupported DKIM key algorithm %q", policy.Algorithm)}
+	}
+
+	policy.SelectorPrefix = strings.ToLower(strings.TrimSpace(policy.SelectorPrefix))
+	if policy.SelectorPrefix == "" {
+		policy.SelectorPrefix = defaultSelectorPrefix
+	}
+	for _, c := range policy.SelectorPrefix {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return happydns.ValidationError{Msg: "the selector prefix can only contain letters, digits and dashes"}
+		}
+	}
+	if len(policy.SelectorPrefix) > 32 {
+		return happydns.ValidationError{Msg: "the selector prefix is too long"}
+	}
+
+	if policy.RotationDays < 0 {
+		return happydns.ValidationError{Msg: "the rotation period cannot be negative"}
+	}
+	if policy.GraceDays < 0 {
+		return happydns.ValidationError{Msg: "the grace period cannot be negative"}
+	}
+
+	return nil
+}
+
+// generateKeyPair creates a new key pair for the given algorithm. It returns
+// the public key in the form expected in the p= tag (SubjectPublicKeyInfo for
+// RSA, the raw 32 bytes for Ed25519 as mandated by RFC 8463) and the PKCS#8
+// encoded private key.
+func generateKeyPair(algorithm string, bits int) (pub []byte, priv []byte, err error) {
+	switch algorithm {
+	case happydns.DKIMAlgorithmRSA:
+		var key *rsa.PrivateKey
+		key, err = rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return
+		}
+		pub, err = x509.MarshalPKIXPublicKey(&key.PublicKey)
+		if err != nil {
+			return
+		}
+		priv, err = x509.MarshalPKCS8PrivateKey(key)
+	case happydns.DKIMAlgorithmEd25519:
+		var pk ed25519.PublicKey
+		var sk ed25519.PrivateKey
+		pk, sk, err = ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return
+		}
+		pub = []byte(pk)
+		priv, err = x509.MarshalPKCS8PrivateKey(sk)
+	default:
+		err = fmt.Errorf("unsupported DKIM key algorithm %q", algorithm)
+	}
+	return
+}
+
+// newSelector builds a dated selector (prefix followed by YYYYMMDD), adding a
+// numeric suffix when a key generated the same day already uses it.
+func newSelector(prefix string, now time.Time, keys []*happydns.DKIMKey) string {
+	base := prefix + now.UTC().Format("20060102")
+
+	used := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		used[k.Selector] = struct{}{}
+	}
+
+	selector := base
+	for i := 2; ; i++ {
+		if _, ok := used[selector]; !ok {
+			return selector
+		}
+		selector = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// selectorOwner returns the owner name, relative to the signing subdomain, of
+// the TXT record publishing the given selector.
+func selectorOwner(selector string) string {
+	return selector + "._domainkey"
+}
+
+// txtContent returns the content of the TXT record publishing key.
+func txtContent(key *happydns.DKIMKey) string {
+	return (&svcs.DKIM{
+		Version:   1,
+		KeyType:   key.Algorithm,
+		PublicKey: key.PublicKey,
+	}).String()
+}
+
+// sealingContext binds a sealed private key to its domain and selector, so
+// that a sealed blob cannot be swapped between two keys.
+func sealingContext(domainId happydns.Identifier, selector string) []byte {
+	return []byte(domainId.String() + "/" + selector)
+}
@@ -0,0 +1,147 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dkim
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+func TestValidatePolicyDefaults(t *testing.T) {
+	p := &happydns.DKIMPolicy{}
+	if err := validatePolicy(p); err != nil {
+		t.Fatalf("validatePolicy() error = %v", err)
+	}
+	if p.Algorithm != happydns.DKIMAlgorithmRSA || p.Bits != defaultBits || p.SelectorPrefix != defaultSelectorPrefix {
+		t.Errorf("defaults not applied: %+v", p)
+	}
+
+	p = &happydns.DKIMPolicy{Algorithm: "Ed25519", Bits: 2048}
+	if err := validatePolicy(p); err != nil {
+		t.Fatalf("validatePolicy() error = %v", err)
+	}
+	if p.Algorithm != happydns.DKIMAlgorithmEd25519 || p.Bits != 0 {
+		t.Errorf("ed25519 policy not normalized: %+v", p)
+	}
+}
+
+func TestValidatePolicyRejects(t *testing.T) {
+	for name, p := range map[string]*happydns.DKIMPolicy{
+		"algorithm":   {Algorithm: "dsa"},
+		"short key":   {Algorithm: "rsa", Bits: 512},
+		"odd key":     {Algorithm: "rsa", Bits: 1536},
+		"prefix":      {SelectorPrefix: "my_selector"},
+		"rotation":    {RotationDays: -1},
+		"grace":       {GraceDays: -1},
+		"long prefix": {SelectorPrefix: "abcdefghijklmnopqrstuvwxyz0123456789"},
+	} {
+		if err := validatePolicy(p); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestGenerateKeyPair(t *testing.T) {
+	pub, priv, err := generateKeyPair(happydns.DKIMAlgorithmEd25519, 0)
+	if err != nil {
+		t.Fatalf("generateKeyPair(ed25519) error = %v", err)
+	}
+	if len(pub) != ed25519.PublicKeySize {
+		t.Errorf("ed25519 p= should be the raw key, got %d bytes", len(pub))
+	}
+	if k, err := x509.ParsePKCS8PrivateKey(priv); err != nil {
+		t.Errorf("ed25519 private key is not PKCS#8: %v", err)
+	} else if _, ok := k.(ed25519.PrivateKey); !ok {
+		t.Errorf("ed25519 private key has type %T", k)
+	}
+
+	pub, priv, err = generateKeyPair(happydns.DKIMAlgorithmRSA, 1024)
+	if err != nil {
+		t.Fatalf("generateKeyPair(rsa) error = %v", err)
+	}
+	if k, err := x509.ParsePKIXPublicKey(pub); err != nil {
+		t.Errorf("rsa p= is not a SubjectPublicKeyInfo: %v", err)
+	} else if _, ok := k.(*rsa.PublicKey); !ok {
+		t.Errorf("rsa public key has type %T", k)
+	}
+	if _, err := x509.ParsePKCS8PrivateKey(priv); err != nil {
+		t.Errorf("rsa private key is not PKCS#8: %v", err)
+	}
+
+	if _, _, err := generateKeyPair("dsa", 0); err == nil {
+		t.Error("expected an error for an unknown algorithm")
+	}
+}
+
+func TestNewSelector(t *testing.T) {
+	now := time.Date(2026, 3, 4, 23, 0, 0, 0, time.UTC)
+
+	if got := newSelector("hd", now, nil); got != "hd20260304" {
+		t.Errorf("newSelector() = %q, want hd20260304", got)
+	}
+
+	keys := []*happydns.DKIMKey{{Selector: "hd20260304"}, {Selector: "hd20260304-2"}}
+	if got := newSelector("hd", now, keys); got != "hd20260304-3" {
+		t.Errorf("newSelector() = %q, want hd20260304-3", got)
+	}
+}
+
+func TestRotationDue(t *testing.T) {
+	now := time.Now()
+	old := now.AddDate(0, 0, -200)
+	recent := now.AddDate(0, 0, -10)
+
+	if rotationDue(&happydns.DKIMPolicy{RotationDays: 180}, now) {
+		t.Error("a domain without key should not be rotated")
+	}
+	if !rotationDue(&happydns.DKIMPolicy{RotationDays: 180, LastRotation: &old}, now) {
+		t.Error("an outdated key should be rotated")
+	}
+	if rotationDue(&happydns.DKIMPolicy{RotationDays: 180, LastRotation: &recent}, now) {
+		t.Error("a recent key should not be rotated")
+	}
+	if rotationDue(&happydns.DKIMPolicy{RotationDays: 0, LastRotation: &old}, now) {
+		t.Error("rotation disabled should never rotate")
+	}
+}
+
+func TestExpiredKeys(t *testing.T) {
+	now := time.Now()
+	longAgo := now.AddDate(0, 0, -30)
+	yesterday := now.AddDate(0, 0, -1)
+
+	keys := []*happydns.DKIMKey{
+		{Selector: "active", State: happydns.DKIMKeyActive},
+		{Selector: "expired", State: happydns.DKIMKeyRetiring, RetiredAt: &longAgo},
+		{Selector: "grace", State: happydns.DKIMKeyRetiring, RetiredAt: &yesterday},
+	}
+
+	expired := expiredKeys(keys, &happydns.DKIMPolicy{GraceDays: 7}, now)
+	if len(expired) != 1 || expired[0].Selector != "expired" {
+		t.Errorf("expiredKeys() = %v, want only the expired key", expired)
+	}
+}
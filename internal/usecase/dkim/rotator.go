@@ -0,0 +1,196 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dkim
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// RotatorStorage is the storage needed by the Rotator on top of DKIMStorage.
+type RotatorStorage interface {
+	DKIMStorage
+	DomainGetter
+	UserGetter
+}
+
+// Rotator periodically walks the DKIM policies, activating the keys whose
+// selector got published, generating a new key for the domains whose active
+// key is older than their rotation period, and withdrawing the selectors whose
+// grace period is over.
+type Rotator struct {
+	store    RotatorStorage
+	service  *Service
+	interval time.Duration
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	done    chan struct{}
+	running bool
+}
+
+// NewRotator builds a Rotator that runs every `interval`.
+func NewRotator(store RotatorStorage, service *Service, interval time.Duration) *Rotator {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &Rotator{
+		store:    store,
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Start launches the rotator loop in a goroutine.
+func (r *Rotator) Start(ctx context.Context) {
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r.cancel = cancel
+	r.done = make(chan struct{})
+	r.running = true
+	r.mu.Unlock()
+
+	go r.loop(ctx)
+}
+
+// Stop halts the rotator and waits for the current sweep to finish.
+func (r *Rotator) Stop() {
+	r.mu.Lock()
+	cancel := r.cancel
+	done := r.done
+	r.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	if done != nil {
+		<-done
+	}
+	r.mu.Lock()
+	r.running = false
+	r.mu.Unlock()
+}
+
+func (r *Rotator) loop(ctx context.Context) {
+	defer close(r.done)
+
+	r.RunOnce(ctx)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce performs a single sweep over the DKIM policies. It returns the
+// number of keys generated.
+func (r *Rotator) RunOnce(ctx context.Context) int {
+	policies, err := r.store.ListAllDKIMPolicies()
+	if err != nil {
+		log.Printf("DKIM rotator: failed to list policies: %v", err)
+		return 0
+	}
+
+	now := r.service.now()
+	rotated := 0
+
+	for _, policy := range policies {
+		select {
+		case <-ctx.Done():
+			return rotated
+		default:
+		}
+
+		domain, err := r.store.GetDomain(policy.DomainId)
+		if errors.Is(err, happydns.ErrDomainNotFound) {
+			r.forget(policy.DomainId)
+			continue
+		} else if err != nil {
+			log.Printf("DKIM rotator: unable to retrieve domain %s: %v", policy.DomainId.String(), err)
+			continue
+		}
+
+		user, err := r.store.GetUser(domain.Owner)
+		if err != nil {
+			log.Printf("DKIM rotator: unable to retrieve owner of %s: %v", domain.DomainName, err)
+			continue
+		}
+
+		if err := r.service.ActivatePublished(ctx, user, domain); err != nil {
+			log.Printf("DKIM rotator: unable to activate the published keys of %s: %v", domain.DomainName, err)
+		}
+
+		if rotationDue(policy, now) {
+			key, err := r.service.GenerateKey(ctx, user, domain)
+			if err != nil {
+				log.Printf("DKIM rotator: unable to rotate the key of %s: %v", domain.DomainName, err)
+				continue
+			}
+			log.Printf("DKIM rotator: %s rotated to selector %s", domain.DomainName, key.Selector)
+			rotated++
+		} else if err := r.service.PruneExpired(ctx, user, domain); err != nil {
+			log.Printf("DKIM rotator: unable to withdraw the retired selectors of %s: %v", domain.DomainName, err)
+		}
+	}
+
+	return rotated
+}
+
+// forget drops the policy and keys left behind by a deleted domain.
+func (r *Rotator) forget(domainId happydns.Identifier) {
+	keys, err := r.store.ListDKIMKeys(domainId)
+	if err == nil {
+		for _, k := range keys {
+			if err := r.store.DeleteDKIMKey(k); err != nil {
+				log.Printf("DKIM rotator: unable to delete key %s of removed domain %s: %v", k.Selector, domainId.String(), err)
+			}
+		}
+	}
+
+	if err := r.store.DeleteDKIMPolicy(domainId); err != nil {
+		log.Printf("DKIM rotator: unable to delete policy of removed domain %s: %v", domainId.String(), err)
+	}
+}
+
+// rotationDue tells whether the active key of the policy's domain has
+// outlived the rotation period. Domains that never had a key generated are
+// left alone: the first key is always requested by the user.
+func rotationDue(policy *happydns.DKIMPolicy, now time.Time) bool {
+	if policy.RotationDays <= 0 || policy.LastRotation == nil {
+		return false
+	}
+	return !now.Before(policy.LastRotation.AddDate(0, 0, policy.RotationDays))
+}
@@ -0,0 +1,606 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dkim
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/internal/helpers"
+	"git.happydns.org/happyDomain/internal/netguard"
+	"git.happydns.org/happyDomain/internal/secretbox"
+	domainlogUC "git.happydns.org/happyDomain/internal/usecase/domain_log"
+	"git.happydns.org/happyDomain/internal/usecase/orchestrator"
+	serviceUC "git.happydns.org/happyDomain/internal/usecase/service"
+	"git.happydns.org/happyDomain/model"
+	svcs "git.happydns.org/happyDomain/services"
+)
+
+const webhookTimeout = 10 * time.Second
+
+// Service implements happydns.DKIMUsecase.
+type Service struct {
+	store       DKIMStorage
+	keyring     *secretbox.Keyring
+	getZone     ZoneGetter
+	zoneService happydns.ZoneServiceUsecase
+	publisher   ZonePublisher
+	domainLog   domainlogUC.DomainLogAppender
+	guard       *netguard.Guard
+	client      *http.Client
+	now         func() time.Time
+}
+
+// NewService builds the DKIM Service. The keyring seals the generated private
+// keys: when it holds no key, key generation is refused. The guard filters
+// the webhook destinations.
+func NewService(
+	store DKIMStorage,
+	keyring *secretbox.Keyring,
+	getZone ZoneGetter,
+	zoneService happydns.ZoneServiceUsecase,
+	publisher ZonePublisher,
+	domainLog domainlogUC.DomainLogAppender,
+	guard *netguard.Guard,
+) *Service {
+	return &Service{
+		store:       store,
+		keyring:     keyring,
+		getZone:     getZone,
+		zoneService: zoneService,
+		publisher:   publisher,
+		domainLog:   domainLog,
+		guard:       guard,
+		client:      guard.HTTPClient(webhookTimeout),
+		now:         time.Now,
+	}
+}
+
+// GetPolicy returns the policy of the domain, or the default one when the
+// user has not defined any.
+func (s *Service) GetPolicy(domain *happydns.Domain) (*happydns.DKIMPolicy, error) {
+	policy, err := s.store.GetDKIMPolicy(domain.Id)
+	if errors.Is(err, happydns.ErrDKIMPolicyNotFound) {
+		return DefaultPolicy(domain.Id), nil
+	} else if err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to GetDKIMPolicy(%s): %w", domain.Id.String(), err),
+			UserMessage: "Sorry, we are currently unable to retrieve your DKIM settings. Please retry later.",
+		}
+	}
+
+	return policy, nil
+}
+
+// SetPolicy validates and stores the policy of the domain. An empty webhook
+// secret keeps the one previously stored, as clients never get it back.
+func (s *Service) SetPolicy(domain *happydns.Domain, policy *happydns.DKIMPolicy) (*happydns.DKIMPolicy, error) {
+	if err := validatePolicy(policy); err != nil {
+		return nil, err
+	}
+
+	if policy.WebhookURL != "" {
+		if _, err := netguard.ValidateURLShape(policy.WebhookURL); err != nil {
+			return nil, happydns.ValidationError{Msg: fmt.Sprintf("invalid webhook URL: %s", err.Error())}
+		}
+	}
+
+	existing, err := s.GetPolicy(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	policy.DomainId = domain.Id
+	policy.HasWebhookSecret = false
+	policy.LastRotation = existing.LastRotation
+	if policy.WebhookSecret == "" && policy.WebhookURL != "" {
+		policy.WebhookSecret = existing.WebhookSecret
+	}
+
+	if err := s.store.PutDKIMPolicy(policy); err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to PutDKIMPolicy(%s): %w", domain.Id.String(), err),
+			UserMessage: "Sorry, we are currently unable to save your DKIM settings. Please retry later.",
+		}
+	}
+
+	return policy, nil
+}
+
+// ListKeys returns the keys of the domain, newest first.
+func (s *Service) ListKeys(domain *happydns.Domain) ([]*happydns.DKIMKey, error) {
+	keys, err := s.store.ListDKIMKeys(domain.Id)
+	if err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to ListDKIMKeys(%s): %w", domain.Id.String(), err),
+			UserMessage: "Sorry, we are currently unable to retrieve your DKIM keys. Please retry later.",
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+// ExportPrivateKey unseals the private part of the given key and returns it
+// PEM-encoded.
+func (s *Service) ExportPrivateKey(domain *happydns.Domain, keyId happydns.Identifier) (*happydns.DKIMPrivateKey, error) {
+	key, err := s.store.GetDKIMKey(domain.Id, keyId)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := s.keyring.Open(key.SealedPrivateKey, sealingContext(domain.Id, key.Selector))
+	if err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to unseal DKIM key %s of %s: %w", key.Selector, domain.DomainName, err),
+			UserMessage: "This private key cannot be decrypted with the current secret key of the instance.",
+		}
+	}
+
+	return &happydns.DKIMPrivateKey{
+		Domain:    helpers.DomainJoin(string(key.Subdomain), domain.DomainName),
+		Selector:  key.Selector,
+		Algorithm: key.Algorithm,
+		PEM:       string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+	}, nil
+}
+
+// GenerateKey creates a key following the domain policy, adds its selector to
+// the current zone and withdraws the selectors whose grace period is over.
+// The key becomes the active one, retiring the former ones and reaching the
+// webhook, only once its selector is published: right away when the policy
+// publishes on its own, at the next sweep after the user publishes otherwise.
+func (s *Service) GenerateKey(ctx context.Context, user *happydns.User, domain *happydns.Domain) (*happydns.DKIMKey, error) {
+	if !s.keyring.Available() {
+		return nil, happydns.ValidationError{Msg: "DKIM keys cannot be generated: this instance has no secret key configured to protect them."}
+	}
+
+	policy, err := s.GetPolicy(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := s.store.ListDKIMKeys(domain.Id)
+	if err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to ListDKIMKeys(%s): %w", domain.Id.String(), err),
+			UserMessage: "Sorry, we are currently unable to generate a DKIM key. Please retry later.",
+		}
+	}
+
+	now := s.now()
+
+	pub, priv, err := generateKeyPair(policy.Algorithm, policy.Bits)
+	if err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to generate DKIM key for %s: %w", domain.DomainName, err),
+			UserMessage: "Sorry, we are currently unable to generate a DKIM key. Please retry later.",
+		}
+	}
+
+	key := &happydns.DKIMKey{
+		DomainId:  domain.Id,
+		Subdomain: policy.Subdomain,
+		Selector:  newSelector(policy.SelectorPrefix, now, keys),
+		Algorithm: policy.Algorithm,
+		Bits:      policy.Bits,
+		PublicKey: pub,
+		State:     happydns.DKIMKeyPending,
+		CreatedAt: now,
+	}
+
+	key.SealedPrivateKey, err = s.keyring.Seal(priv, sealingContext(domain.Id, key.Selector))
+	if err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to seal DKIM key for %s: %w", domain.DomainName, err),
+			UserMessage: "Sorry, we are currently unable to generate a DKIM key. Please retry later.",
+		}
+	}
+
+	// The key is saved before its selector is added to the zone, so that no
+	// selector is ever published without its private key.
+	if err = s.store.CreateDKIMKey(key); err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to CreateDKIMKey(%s): %w", domain.Id.String(), err),
+			UserMessage: "Sorry, we are currently unable to save the DKIM key. Please retry later.",
+		}
+	}
+
+	zone, err := s.currentZone(domain)
+	if err != nil {
+		s.dropKey(domain, key)
+		return nil, err
+	}
+
+	zone, err = s.zoneService.AddServiceToZone(user, domain, zone, key.Subdomain, happydns.Origin(domain.DomainName), &happydns.Service{
+		ServiceMeta: happydns.ServiceMeta{
+			Type:        "svcs.DKIMRecord",
+			UserComment: "Generated by happyDomain",
+		},
+		Service: &svcs.DKIMRecord{
+			Record: &happydns.TXT{
+				Hdr: dns.RR_Header{
+					Name:   selectorOwner(key.Selector),
+					Rrtype: dns.TypeTXT,
+					Class:  dns.ClassINET,
+				},
+				Txt: txtContent(key),
+			},
+		},
+	})
+	if err != nil {
+		s.dropKey(domain, key)
+		return nil, err
+	}
+
+	records := s.selectorRecords(domain, zone, key)
+
+	zone, withdrawn, forget := s.withdrawExpired(user, domain, zone, expiredKeys(keys, policy, now))
+	records = append(records, withdrawn...)
+
+	policy.LastRotation = &now
+	if err := s.store.PutDKIMPolicy(policy); err != nil {
+		log.Printf("DKIM: unable to save the last rotation date of %s: %s", domain.DomainName, err.Error())
+	}
+
+	s.appendLog(domain, user, happydns.LOG_INFO, fmt.Sprintf("New DKIM key generated, selector %s (%s)", key.Selector, key.Algorithm))
+
+	if !policy.AutoPublish {
+		s.forgetKeys(user, domain, forget)
+		s.appendLog(domain, user, happydns.LOG_INFO, fmt.Sprintf("The DKIM selector %s awaits publication: the key will be activated once the zone is published", key.Selector))
+		return key, nil
+	}
+
+	// Only the DKIM records are published: the other changes pending in the
+	// zone are left for the user to review.
+	if _, err := s.publisher.ApplyMatching(ctx, user, domain, zone, fmt.Sprintf("DKIM key rotation (selector %s)", key.Selector), orchestrator.MatchRecords(records...)); err != nil {
+		s.appendLog(domain, user, happydns.LOG_ERR, fmt.Sprintf("Unable to publish the DKIM selector %s: %s", key.Selector, err.Error()))
+		return key, err
+	}
+
+	s.forgetKeys(user, domain, forget)
+	s.activate(ctx, user, domain, policy, key, keys, priv)
+
+	return key, nil
+}
+
+// dropKey deletes the key just saved whose selector could not be added to
+// the zone.
+func (s *Service) dropKey(domain *happydns.Domain, key *happydns.DKIMKey) {
+	if err := s.store.DeleteDKIMKey(key); err != nil {
+		log.Printf("DKIM: unable to delete the key %s of %s, whose selector could not be added to the zone: %s", key.Selector, domain.DomainName, err.Error())
+	}
+}
+
+// ActivatePublished activates the pending keys whose selector got published
+// by the user since they were generated.
+func (s *Service) ActivatePublished(ctx context.Context, user *happydns.User, domain *happydns.Domain) error {
+	keys, err := s.store.ListDKIMKeys(domain.Id)
+	if err != nil {
+		return err
+	}
+
+	var pending []*happydns.DKIMKey
+	for _, k := range keys {
+		if k.State == happydns.DKIMKeyPending {
+			pending = append(pending, k)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	published, err := s.publishedZone(domain)
+	if err != nil || published == nil {
+		return err
+	}
+
+	policy, err := s.GetPolicy(domain)
+	if err != nil {
+		return err
+	}
+
+	// The newest published key ends up the active one.
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+	})
+	for _, k := range pending {
+		if findSelectorService(published, k) != nil {
+			s.activate(ctx, user, domain, policy, k, keys, nil)
+		}
+	}
+
+	return nil
+}
+
+// activate makes key, whose selector is published, the one the MTA signs
+// with: the former active keys start their grace period and the webhook gets
+// the private key, unsealed when priv is nil.
+func (s *Service) activate(ctx context.Context, user *happydns.User, domain *happydns.Domain, policy *happydns.DKIMPolicy, key *happydns.DKIMKey, keys []*happydns.DKIMKey, priv []byte) {
+	now := s.now()
+
+	for _, old := range keys {
+		if old.State == happydns.DKIMKeyActive && !old.Id.Equals(key.Id) {
+			old.State = happydns.DKIMKeyRetiring
+			old.RetiredAt = &now
+			if err := s.store.UpdateDKIMKey(old); err != nil {
+				log.Printf("DKIM: unable to retire key %s of %s: %s", old.Selector, domain.DomainName, err.Error())
+			}
+		}
+	}
+
+	key.State = happydns.DKIMKeyActive
+	if err := s.store.UpdateDKIMKey(key); err != nil {
+		log.Printf("DKIM: unable to activate key %s of %s: %s", key.Selector, domain.DomainName, err.Error())
+	}
+
+	s.appendLog(domain, user, happydns.LOG_INFO, fmt.Sprintf("DKIM selector %s published, key activated", key.Selector))
+
+	if policy.WebhookURL == "" {
+		return
+	}
+
+	if priv == nil {
+		var err error
+		priv, err = s.keyring.Open(key.SealedPrivateKey, sealingContext(domain.Id, key.Selector))
+		if err != nil {
+			s.appendLog(domain, user, happydns.LOG_WARN, fmt.Sprintf("Unable to deliver the DKIM key %s to the webhook: %s", key.Selector, err.Error()))
+			return
+		}
+	}
+
+	if err := s.sendWebhook(ctx, domain, policy, key, priv); err != nil {
+		s.appendLog(domain, user, happydns.LOG_WARN, fmt.Sprintf("Unable to deliver the new DKIM key %s to the webhook: %s", key.Selector, err.Error()))
+	}
+}
+
+// PruneExpired withdraws the selectors of the retiring keys whose grace period
+// is over, publishing the removal when the policy allows it.
+func (s *Service) PruneExpired(ctx context.Context, user *happydns.User, domain *happydns.Domain) error {
+	policy, err := s.GetPolicy(domain)
+	if err != nil {
+		return err
+	}
+
+	keys, err := s.store.ListDKIMKeys(domain.Id)
+	if err != nil {
+		return err
+	}
+
+	expired := expiredKeys(keys, policy, s.now())
+	if len(expired) == 0 {
+		return nil
+	}
+
+	zone, err := s.currentZone(domain)
+	if err != nil {
+		return err
+	}
+
+	zone, withdrawn, forget := s.withdrawExpired(user, domain, zone, expired)
+	if policy.AutoPublish && len(withdrawn) > 0 {
+		// The keys are kept until the removal is published: the next sweep
+		// tries again otherwise.
+		if _, err := s.publisher.ApplyMatching(ctx, user, domain, zone, "DKIM retired selectors removal", orchestrator.MatchRecords(withdrawn...)); err != nil {
+			return err
+		}
+	}
+
+	s.forgetKeys(user, domain, forget)
+	return nil
+}
+
+// expiredKeys returns the retiring keys whose grace period is over.
+func expiredKeys(keys []*happydns.DKIMKey, policy *happydns.DKIMPolicy, now time.Time) (expired []*happydns.DKIMKey) {
+	for _, k := range keys {
+		if k.State == happydns.DKIMKeyRetiring && k.RetiredAt != nil && !now.Before(k.RetiredAt.AddDate(0, 0, policy.GraceDays)) {
+			expired = append(expired, k)
+		}
+	}
+	return
+}
+
+// withdrawExpired removes from zone the selectors of the expired keys. It
+// returns the zone that has been edited, the records whose removal is to be
+// published, and the keys to forget once it is.
+//
+// The records are those the last published zone holds: a removal whose
+// publication failed is then published again, although the selector is
+// already gone from the current zone.
+func (s *Service) withdrawExpired(user *happydns.User, domain *happydns.Domain, zone *happydns.Zone, expired []*happydns.DKIMKey) (*happydns.Zone, []happydns.Record, []*happydns.DKIMKey) {
+	published, err := s.publishedZone(domain)
+	if err != nil {
+		log.Printf("DKIM: unable to retrieve the published zone of %s: %s", domain.DomainName, err.Error())
+		return zone, nil, nil
+	}
+
+	var (
+		withdrawn []happydns.Record
+		forget    []*happydns.DKIMKey
+	)
+	for _, k := range expired {
+		if svc := findSelectorService(zone, k); svc != nil {
+			newZone, err := s.zoneService.RemoveServiceFromZone(user, domain, zone, k.Subdomain, svc.Id)
+			if err != nil {
+				log.Printf("DKIM: unable to withdraw selector %s of %s: %s", k.Selector, domain.DomainName, err.Error())
+				continue
+			}
+			zone = newZone
+		}
+
+		if published != nil {
+			withdrawn = append(withdrawn, s.selectorRecords(domain, published, k)...)
+		}
+		forget = append(forget, k)
+	}
+
+	return zone, withdrawn, forget
+}
+
+// forgetKeys deletes the keys whose selector has been withdrawn.
+func (s *Service) forgetKeys(user *happydns.User, domain *happydns.Domain, keys []*happydns.DKIMKey) {
+	for _, k := range keys {
+		if err := s.store.DeleteDKIMKey(k); err != nil {
+			log.Printf("DKIM: unable to delete key %s of %s: %s", k.Selector, domain.DomainName, err.Error())
+			continue
+		}
+
+		s.appendLog(domain, user, happydns.LOG_INFO, fmt.Sprintf("Retired DKIM selector %s withdrawn", k.Selector))
+	}
+}
+
+// findSelectorService looks for the DKIM service publishing key in zone.
+func findSelectorService(zone *happydns.Zone, key *happydns.DKIMKey) *happydns.Service {
+	for _, svc := range zone.Services[key.Subdomain] {
+		rec, ok := svc.Service.(*svcs.DKIMRecord)
+		if !ok || rec.Record == nil {
+			continue
+		}
+		if dns.CanonicalName(rec.Record.Hdr.Name) == dns.CanonicalName(selectorOwner(key.Selector)) {
+			return svc
+		}
+	}
+	return nil
+}
+
+// selectorRecords returns the records publishing the selector of key in
+// zone, as they are sent to the provider.
+func (s *Service) selectorRecords(domain *happydns.Domain, zone *happydns.Zone, key *happydns.DKIMKey) []happydns.Record {
+	svc := findSelectorService(zone, key)
+	if svc == nil {
+		return nil
+	}
+
+	records, err := serviceUC.NewListRecordsUsecase().List(svc, domain.DomainName, zone.DefaultTTL)
+	if err != nil {
+		log.Printf("DKIM: unable to list the records of selector %s of %s: %s", key.Selector, domain.DomainName, err.Error())
+		return nil
+	}
+
+	return records
+}
+
+// publishedZone returns the newest zone of the domain published to its
+// provider, if any.
+func (s *Service) publishedZone(domain *happydns.Domain) (*happydns.Zone, error) {
+	for _, id := range domain.ZoneHistory {
+		zone, err := s.getZone.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		if zone.Published != nil {
+			return zone, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *Service) currentZone(domain *happydns.Domain) (*happydns.Zone, error) {
+	if len(domain.ZoneHistory) == 0 {
+		return nil, happydns.ValidationError{Msg: "this domain has no zone yet: import it before generating DKIM keys"}
+	}
+
+	return s.getZone.Get(domain.ZoneHistory[0])
+}
+
+func (s *Service) appendLog(domain *happydns.Domain, user *happydns.User, level int8, msg string) {
+	if err := s.domainLog.AppendDomainLog(domain, happydns.NewDomainLog(user, level, msg)); err != nil {
+		log.Printf("DKIM: unable to append domain log for %s: %s", domain.DomainName, err.Error())
+	}
+}
+
+// webhookPayload is the body POSTed to the policy webhook when a key is
+// generated.
+type webhookPayload struct {
+	Event      string    `json:"event"`
+	Domain     string    `json:"domain"`
+	Selector   string    `json:"selector"`
+	Algorithm  string    `json:"algorithm"`
+	PrivateKey string    `json:"private_key"`
+	Record     string    `json:"dns_record"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// sendWebhook hands the new private key to the MTA. The body is signed with
+// the policy secret, in the same way as the notification webhooks.
+func (s *Service) sendWebhook(ctx context.Context, domain *happydns.Domain, policy *happydns.DKIMPolicy, key *happydns.DKIMKey, priv []byte) error {
+	if _, err := s.guard.ValidateURL(ctx, policy.WebhookURL); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		Event:      "dkim.key_generated",
+		Domain:     helpers.DomainJoin(string(key.Subdomain), domain.DomainName),
+		Selector:   key.Selector,
+		Algorithm:  key.Algorithm,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: priv})),
+		Record:     txtContent(key),
+		CreatedAt:  key.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, policy.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "happyDomain-DKIM/1.0")
+	if policy.WebhookSecret != "" {
+		mac := hmac.New(sha256.New, []byte(policy.WebhookSecret))
+		mac.Write(body)
+		req.Header.Set("X-Happydomain-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+
+	return nil
+}
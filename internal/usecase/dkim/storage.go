@@ -0,0 +1,78 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dkim
+
+import (
+	"context"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+type DKIMStorage interface {
+	// ListDKIMKeys retrieves the keys generated for the given Domain.
+	ListDKIMKeys(domainId happydns.Identifier) ([]*happydns.DKIMKey, error)
+
+	// GetDKIMKey retrieves the key with the given id, generated for the given Domain.
+	GetDKIMKey(domainId, keyId happydns.Identifier) (*happydns.DKIMKey, error)
+
+	// CreateDKIMKey stores a new key, assigning its identifier.
+	CreateDKIMKey(key *happydns.DKIMKey) error
+
+	// UpdateDKIMKey updates the fields of the given key.
+	UpdateDKIMKey(key *happydns.DKIMKey) error
+
+	// DeleteDKIMKey removes the given key.
+	DeleteDKIMKey(key *happydns.DKIMKey) error
+
+	// ListAllDKIMPolicies retrieves the rotation policies of every Domain.
+	ListAllDKIMPolicies() ([]*happydns.DKIMPolicy, error)
+
+	// GetDKIMPolicy retrieves the rotation policy of the given Domain.
+	GetDKIMPolicy(domainId happydns.Identifier) (*happydns.DKIMPolicy, error)
+
+	// PutDKIMPolicy creates or replaces the rotation policy of its Domain.
+	PutDKIMPolicy(policy *happydns.DKIMPolicy) error
+
+	// DeleteDKIMPolicy removes the rotation policy of the given Domain.
+	DeleteDKIMPolicy(domainId happydns.Identifier) error
+}
+
+// UserGetter retrieves the owner of a Domain, on whose behalf the rotation
+// edits and publishes the zone.
+type UserGetter interface {
+	GetUser(id happydns.Identifier) (*happydns.User, error)
+}
+
+// DomainGetter retrieves a Domain by its identifier.
+type DomainGetter interface {
+	GetDomain(id happydns.Identifier) (*happydns.Domain, error)
+}
+
+// ZoneGetter retrieves a Zone by its identifier.
+type ZoneGetter interface {
+	Get(zoneID happydns.Identifier) (*happydns.Zone, error)
+}
+
+// ZonePublisher publishes a subset of the pending corrections of a zone.
+type ZonePublisher interface {
+	ApplyMatching(ctx context.Context, user *happydns.User, domain *happydns.Domain, zone *happydns.Zone, commitMsg string, keep func(*happydns.Correction) bool) (*happydns.Zone, error)
+}
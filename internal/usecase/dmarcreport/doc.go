@@ -0,0 +1,30 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package dmarcreport ingests the RFC 7489 aggregate reports (rua) that mail
+// receivers send about the domains managed in happyDomain, keeps them for a
+// retention period, and summarizes them per source IP.
+//
+// Reports reach the Service either uploaded through the API, or fetched by
+// the Poller from a mailbox the reports are delivered to. Sources never seen
+// before and messages failing alignment are notified through the
+// notification dispatcher.
+package dmarcreport
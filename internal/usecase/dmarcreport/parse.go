@@ -0,0 +1,191 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dmarcreport
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"strings"
+	"time"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// maxReportSize bounds the decompressed size of a report, as a defense
+// against compression bombs.
+const maxReportSize = 32 << 20
+
+// feedback mirrors the XML schema of RFC 7489 appendix C, limited to the
+// elements happyDomain uses.
+type feedback struct {
+	XMLName  xml.Name `xml:"feedback"`
+	Metadata struct {
+		OrgName   string `xml:"org_name"`
+		Email     string `xml:"email"`
+		ReportID  string `xml:"report_id"`
+		DateRange struct {
+			Begin int64 `xml:"begin"`
+			End   int64 `xml:"end"`
+		} `xml:"date_range"`
+	} `xml:"report_metadata"`
+	PolicyPublished struct {
+		Domain string `xml:"domain"`
+		P      string `xml:"p"`
+	} `xml:"policy_published"`
+	Records []struct {
+		Row struct {
+			SourceIP        string `xml:"source_ip"`
+			Count           int    `xml:"count"`
+			PolicyEvaluated struct {
+				Disposition string `xml:"disposition"`
+				DKIM        string `xml:"dkim"`
+				SPF         string `xml:"spf"`
+			} `xml:"policy_evaluated"`
+		} `xml:"row"`
+		Identifiers struct {
+			HeaderFrom string `xml:"header_from"`
+		} `xml:"identifiers"`
+		AuthResults struct {
+			DKIM []struct {
+				Domain string `xml:"domain"`
+				Result string `xml:"result"`
+			} `xml:"dkim"`
+			SPF []struct {
+				Domain string `xml:"domain"`
+				Result string `xml:"result"`
+			} `xml:"spf"`
+		} `xml:"auth_results"`
+	} `xml:"record"`
+}
+
+// decompress returns the XML document held in raw, which may be a gzip
+// stream, a zip archive containing a single document, or plain XML.
+func decompress(raw []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(raw, []byte{0x1f, 0x8b}):
+		zr, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return readLimited(zr)
+
+	case bytes.HasPrefix(raw, []byte("PK\x03\x04")):
+		zr, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return readLimited(rc)
+		}
+		return nil, errors.New("empty zip archive")
+
+	default:
+		return raw, nil
+	}
+}
+
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxReportSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxReportSize {
+		return nil, errors.New("report too large")
+	}
+	return data, nil
+}
+
+// Parse decodes a raw aggregate report, compressed or not.
+func Parse(raw []byte) (*happydns.DMARCReport, error) {
+	data, err := decompress(raw)
+	if err != nil {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("unable to decompress the report: %s", err.Error())}
+	}
+
+	var fb feedback
+	if err := xml.Unmarshal(data, &fb); err != nil {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("not a DMARC aggregate report: %s", err.Error())}
+	}
+
+	if fb.PolicyPublished.Domain == "" {
+		return nil, happydns.ValidationError{Msg: "not a DMARC aggregate report: no policy domain"}
+	}
+	if fb.Metadata.ReportID == "" {
+		return nil, happydns.ValidationError{Msg: "not a DMARC aggregate report: no report identifier"}
+	}
+
+	report := &happydns.DMARCReport{
+		OrgName:      strings.TrimSpace(fb.Metadata.OrgName),
+		Email:        strings.TrimSpace(fb.Metadata.Email),
+		ReportID:     strings.TrimSpace(fb.Metadata.ReportID),
+		Begin:        time.Unix(fb.Metadata.DateRange.Begin, 0).UTC(),
+		End:          time.Unix(fb.Metadata.DateRange.End, 0).UTC(),
+		PolicyDomain: strings.ToLower(strings.TrimSuffix(strings.TrimSpace(fb.PolicyPublished.Domain), ".")),
+		Policy:       strings.TrimSpace(fb.PolicyPublished.P),
+	}
+
+	for _, rec := range fb.Records {
+		ip, err := netip.ParseAddr(strings.TrimSpace(rec.Row.SourceIP))
+		if err != nil || rec.Row.Count <= 0 {
+			continue
+		}
+
+		r := happydns.DMARCReportRecord{
+			SourceIP:    ip.Unmap().String(),
+			Count:       rec.Row.Count,
+			Disposition: rec.Row.PolicyEvaluated.Disposition,
+			HeaderFrom:  strings.ToLower(rec.Identifiers.HeaderFrom),
+			DKIMAligned: strings.EqualFold(rec.Row.PolicyEvaluated.DKIM, "pass"),
+			SPFAligned:  strings.EqualFold(rec.Row.PolicyEvaluated.SPF, "pass"),
+		}
+		for _, d := range rec.AuthResults.DKIM {
+			if strings.EqualFold(d.Result, "pass") {
+				r.DKIMDomains = append(r.DKIMDomains, strings.ToLower(d.Domain))
+			}
+		}
+		for _, s := range rec.AuthResults.SPF {
+			if strings.EqualFold(s.Result, "pass") {
+				r.SPFDomain = strings.ToLower(s.Domain)
+				break
+			}
+		}
+
+		report.Records = append(report.Records, r)
+	}
+
+	return report, nil
+}
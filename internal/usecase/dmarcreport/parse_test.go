@@ -0,0 +1,184 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dmarcreport
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+const sampleReport = `<?xml version="1.0" encoding="UTF-8" ?>
+<feedback>
+  <report_metadata>
+    <org_name>google.com</org_name>
+    <email>noreply-dmarc-support@google.com</email>
+    <report_id>1234567890</report_id>
+    <date_range><begin>1700000000</begin><end>1700086399</end></date_range>
+  </report_metadata>
+  <policy_published>
+    <domain>Example.com</domain>
+    <p>quarantine</p>
+  </policy_published>
+  <record>
+    <row>
+      <source_ip>192.0.2.10</source_ip>
+      <count>12</count>
+      <policy_evaluated><disposition>none</disposition><dkim>pass</dkim><spf>fail</spf></policy_evaluated>
+    </row>
+    <identifiers><header_from>example.com</header_from></identifiers>
+    <auth_results>
+      <dkim><domain>example.com</domain><result>pass</result></dkim>
+      <spf><domain>bounce.example.net</domain><result>pass</result></spf>
+    </auth_results>
+  </record>
+  <record>
+    <row>
+      <source_ip>2001:db8::25</source_ip>
+      <count>3</count>
+      <policy_evaluated><disposition>quarantine</disposition><dkim>fail</dkim><spf>fail</spf></policy_evaluated>
+    </row>
+    <identifiers><header_from>example.com</header_from></identifiers>
+  </record>
+  <record>
+    <row>
+      <source_ip>not an ip</source_ip>
+      <count>1</count>
+    </row>
+  </record>
+</feedback>`
+
+func TestParseFormats(t *testing.T) {
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write([]byte(sampleReport))
+	gw.Close()
+
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	f, _ := zw.Create("google.com!example.com!1700000000!1700086399.xml")
+	f.Write([]byte(sampleReport))
+	zw.Close()
+
+	for name, raw := range map[string][]byte{
+		"xml":  []byte(sampleReport),
+		"gzip": gz.Bytes(),
+		"zip":  zipped.Bytes(),
+	} {
+		report, err := Parse(raw)
+		if err != nil {
+			t.Fatalf("%s: Parse() error = %v", name, err)
+		}
+
+		if report.OrgName != "google.com" || report.ReportID != "1234567890" || report.PolicyDomain != "example.com" || report.Policy != "quarantine" {
+			t.Errorf("%s: unexpected metadata: %+v", name, report)
+		}
+		if len(report.Records) != 2 {
+			t.Fatalf("%s: got %d records, want 2", name, len(report.Records))
+		}
+
+		r := report.Records[0]
+		if r.SourceIP != "192.0.2.10" || r.Count != 12 || !r.DKIMAligned || r.SPFAligned || r.SPFDomain != "bounce.example.net" || len(r.DKIMDomains) != 1 {
+			t.Errorf("%s: unexpected first record: %+v", name, r)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for name, raw := range map[string]string{
+		"empty":     "",
+		"not xml":   "hello",
+		"no domain": `<feedback><report_metadata><report_id>1</report_id></report_metadata></feedback>`,
+		"no id":     `<feedback><policy_published><domain>example.com</domain></policy_published></feedback>`,
+		"bad gzip":  "\x1f\x8bgarbage",
+	} {
+		if _, err := Parse([]byte(raw)); err == nil {
+			t.Errorf("%s: Parse() succeeded, want an error", name)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	report, err := Parse([]byte(sampleReport))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	other := *report
+	other.OrgName = "yahoo.com"
+
+	summary := summarize([]*happydns.DMARCReport{report, &other}, parseSources([]string{"192.0.2.0/24"}))
+
+	if summary.Reports != 2 || summary.Messages != 30 || summary.DKIMAligned != 24 || summary.SPFAligned != 0 || summary.Failed != 6 {
+		t.Errorf("unexpected totals: %+v", summary)
+	}
+	if len(summary.Sources) != 2 {
+		t.Fatalf("got %d sources, want 2", len(summary.Sources))
+	}
+	if s := summary.Sources[0]; s.SourceIP != "192.0.2.10" || s.Messages != 24 || !s.Known || len(s.Reporters) != 2 {
+		t.Errorf("unexpected first source: %+v", s)
+	}
+	if s := summary.Sources[1]; s.Known || s.Failed != 6 {
+		t.Errorf("unexpected second source: %+v", s)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	report, err := Parse([]byte(sampleReport))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	settings := &happydns.DMARCReportSettings{
+		KnownSources:         []string{"192.0.2.0/24"},
+		NotifyUnknownSenders: true,
+		NotifyFailures:       true,
+	}
+
+	states := evaluate(report, nil, settings)
+	codes := map[string]bool{}
+	for _, s := range states {
+		codes[s.Code] = true
+	}
+	if len(states) != 2 || !codes["dmarc_unknown_sender"] || !codes["dmarc_alignment_failure"] {
+		t.Errorf("unexpected states for a new sender: %+v", states)
+	}
+
+	// A source already seen in a previous report is not unknown anymore.
+	states = evaluate(report, []*happydns.DMARCReport{report}, settings)
+	if len(states) != 1 || states[0].Code != "dmarc_alignment_failure" {
+		t.Errorf("unexpected states for a seen sender: %+v", states)
+	}
+
+	settings.NotifyFailures = false
+	states = evaluate(report, []*happydns.DMARCReport{report}, settings)
+	if len(states) != 1 || states[0].Status != happydns.StatusOK {
+		t.Errorf("unexpected states without failure notification: %+v", states)
+	}
+
+	settings.NotifyUnknownSenders = false
+	if states := evaluate(report, nil, settings); states != nil {
+		t.Errorf("expected no state when notifications are disabled, got %+v", states)
+	}
+}
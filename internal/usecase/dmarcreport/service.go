@@ -0,0 +1,490 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dmarcreport
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/netip"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"git.happydns.org/happyDomain/internal/mailbox"
+	"git.happydns.org/happyDomain/model"
+)
+
+// CheckerID identifies the report notifications in the notification
+// pipeline, in place of a checker.
+const CheckerID = "dmarc-report"
+
+// Service implements happydns.DMARCReportUsecase.
+type Service struct {
+	store     DMARCReportStorage
+	domains   DomainFinder
+	notifier  EventNotifier
+	address   string
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewService builds the report Service. address is the mailbox receiving
+// the reports, of which each domain gets a subaddress. Reports older than
+// retentionDays are dropped; notifier may be nil to disable notifications.
+func NewService(store DMARCReportStorage, domains DomainFinder, notifier EventNotifier, address string, retentionDays int) *Service {
+	if retentionDays <= 0 {
+		retentionDays = 180
+	}
+	return &Service{
+		store:     store,
+		domains:   domains,
+		notifier:  notifier,
+		address:   address,
+		retention: time.Duration(retentionDays) * 24 * time.Hour,
+		now:       time.Now,
+	}
+}
+
+// IngestForDomain parses a report uploaded for domain. The report must be
+// about domain or one of its subdomains.
+func (s *Service) IngestForDomain(ctx context.Context, domain *happydns.Domain, raw []byte) (*happydns.DMARCReport, error) {
+	report, err := Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isSubdomain(report.PolicyDomain, domain.DomainName) {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("this report is about %s, not %s", report.PolicyDomain, strings.TrimSuffix(domain.DomainName, "."))}
+	}
+
+	return s.storeReport(domain, report)
+}
+
+// Ingest parses a report and stores it for the domains it was sent to the
+// report address of. Anyone can add a domain of any name, so the name of
+// the policy domain alone doesn't tell whose the report is: only the owner
+// of the domain can publish its report address in the DMARC record.
+func (s *Service) Ingest(ctx context.Context, recipients []string, raw []byte) ([]*happydns.DMARCReport, error) {
+	report, err := Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var stored []*happydns.DMARCReport
+	for _, tag := range mailbox.SubaddressTags(s.address, recipients) {
+		domain := s.taggedDomain(tag)
+		if domain == nil || !isSubdomain(report.PolicyDomain, domain.DomainName) {
+			continue
+		}
+
+		// Each domain gets its own copy, as storing assigns an identifier.
+		r := *report
+		saved, err := s.storeReport(domain, &r)
+		if err != nil {
+			return stored, err
+		}
+		stored = append(stored, saved)
+	}
+
+	if len(stored) == 0 {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("no domain matching %s has this report address", report.PolicyDomain)}
+	}
+
+	return stored, nil
+}
+
+// IngestMessage is the mailbox.Handler of the reports delivered by email:
+// every attachment holding a report is ingested.
+func (s *Service) IngestMessage(ctx context.Context, msg *mailbox.Message, attachments []mailbox.Attachment) error {
+	var errs []error
+	for _, att := range attachments {
+		if !IsReportAttachment(att) {
+			continue
+		}
+		if _, err := s.Ingest(ctx, msg.Recipients(), att.Data); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", att.Filename, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// IsReportAttachment tells whether an email attachment looks like an
+// aggregate report, from its media type or file name.
+func IsReportAttachment(att mailbox.Attachment) bool {
+	name := strings.ToLower(att.Filename)
+
+	// SMTP TLS reports are also sent gzipped, sometimes without their
+	// specific media type.
+	if strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".json.gz") {
+		return false
+	}
+
+	switch att.ContentType {
+	case "application/gzip", "application/x-gzip", "application/zip", "application/x-zip-compressed", "text/xml", "application/xml":
+		return true
+	}
+
+	return strings.HasSuffix(name, ".xml") || strings.HasSuffix(name, ".xml.gz") || strings.HasSuffix(name, ".zip")
+}
+
+// taggedDomain returns the domain whose report address has tag, if any.
+func (s *Service) taggedDomain(tag string) *happydns.Domain {
+	id, token, ok := strings.Cut(tag, ".")
+	if !ok {
+		return nil
+	}
+
+	domainId, err := happydns.NewIdentifierFromString(id)
+	if err != nil {
+		return nil
+	}
+
+	domain, err := s.domains.GetDomain(domainId)
+	if err != nil {
+		return nil
+	}
+
+	settings, err := s.store.GetDMARCReportSettings(domain.Id)
+	if err != nil || settings.IngestToken == "" || subtle.ConstantTimeCompare([]byte(settings.IngestToken), []byte(token)) != 1 {
+		return nil
+	}
+
+	return domain
+}
+
+// storeReport saves report for domain, unless the same report has already been
+// received, then raises the notifications it calls for.
+func (s *Service) storeReport(domain *happydns.Domain, report *happydns.DMARCReport) (*happydns.DMARCReport, error) {
+	previous, err := s.store.ListDMARCReports(domain.Id)
+	if err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to ListDMARCReports(%s): %w", domain.Id.String(), err),
+			UserMessage: "Sorry, we are currently unable to store the report. Please retry later.",
+		}
+	}
+
+	for _, p := range previous {
+		if p.OrgName == report.OrgName && p.ReportID == report.ReportID {
+			return p, nil
+		}
+	}
+
+	report.DomainId = domain.Id
+	report.ReceivedAt = s.now()
+
+	if err := s.store.CreateDMARCReport(report); err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to CreateDMARCReport(%s): %w", domain.Id.String(), err),
+			UserMessage: "Sorry, we are currently unable to store the report. Please retry later.",
+		}
+	}
+
+	if s.notifier != nil {
+		settings, err := s.GetSettings(domain)
+		if err != nil {
+			log.Printf("DMARC reports: unable to retrieve settings of %s: %s", domain.DomainName, err.Error())
+		} else if states := evaluate(report, previous, settings); states != nil {
+			s.notifier.NotifyEvent(CheckerID, happydns.CheckTarget{
+				UserId:   domain.Owner.String(),
+				DomainId: domain.Id.String(),
+			}, states)
+		}
+	}
+
+	return report, nil
+}
+
+// evaluate builds the notification states of a newly received report. It
+// returns nil when the settings ask for no notification at all.
+func evaluate(report *happydns.DMARCReport, previous []*happydns.DMARCReport, settings *happydns.DMARCReportSettings) []happydns.CheckState {
+	if !settings.NotifyUnknownSenders && !settings.NotifyFailures {
+		return nil
+	}
+
+	known := parseSources(settings.KnownSources)
+
+	seen := map[string]bool{}
+	for _, p := range previous {
+		for _, r := range p.Records {
+			seen[r.SourceIP] = true
+		}
+	}
+
+	var states []happydns.CheckState
+	reported := map[string]bool{}
+	for _, r := range report.Records {
+		if isKnown(known, r.SourceIP) {
+			continue
+		}
+
+		if settings.NotifyUnknownSenders && !seen[r.SourceIP] && !reported[r.SourceIP] {
+			reported[r.SourceIP] = true
+			states = append(states, happydns.CheckState{
+				Status:  happydns.StatusWarn,
+				Code:    "dmarc_unknown_sender",
+				Message: fmt.Sprintf("%s reported %d message(s) from %s, a source never seen before.", report.OrgName, r.Count, r.SourceIP),
+			})
+		}
+
+		if settings.NotifyFailures && !r.DKIMAligned && !r.SPFAligned {
+			states = append(states, happydns.CheckState{
+				Status:  happydns.StatusWarn,
+				Code:    "dmarc_alignment_failure",
+				Message: fmt.Sprintf("%s reported %d message(s) from %s failing both DKIM and SPF alignment (disposition: %s).", report.OrgName, r.Count, r.SourceIP, r.Disposition),
+			})
+		}
+	}
+
+	if len(states) == 0 {
+		states = append(states, happydns.CheckState{
+			Status:  happydns.StatusOK,
+			Code:    "dmarc_ok",
+			Message: fmt.Sprintf("%s reported no unknown sender nor alignment failure.", report.OrgName),
+		})
+	}
+
+	return states
+}
+
+// ListReports returns the reports covering a period ending after since,
+// newest first.
+func (s *Service) ListReports(domain *happydns.Domain, since time.Time) ([]*happydns.DMARCReport, error) {
+	reports, err := s.store.ListDMARCReports(domain.Id)
+	if err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to ListDMARCReports(%s): %w", domain.Id.String(), err),
+			UserMessage: "Sorry, we are currently unable to retrieve the reports. Please retry later.",
+		}
+	}
+
+	ret := reports[:0]
+	for _, r := range reports {
+		if !r.End.Before(since) {
+			ret = append(ret, r)
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].End.After(ret[j].End)
+	})
+
+	return ret, nil
+}
+
+// Summary aggregates per source IP the reports covering a period ending
+// after since.
+func (s *Service) Summary(domain *happydns.Domain, since time.Time) (*happydns.DMARCSummary, error) {
+	reports, err := s.ListReports(domain, since)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.GetSettings(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := summarize(reports, parseSources(settings.KnownSources))
+	summary.DomainId = domain.Id
+	summary.From = since
+	summary.To = s.now()
+
+	return summary, nil
+}
+
+func summarize(reports []*happydns.DMARCReport, known []netip.Prefix) *happydns.DMARCSummary {
+	summary := &happydns.DMARCSummary{
+		Reports: len(reports),
+		Sources: []*happydns.DMARCSourceStats{},
+	}
+
+	bySource := map[string]*happydns.DMARCSourceStats{}
+	for _, report := range reports {
+		for _, r := range report.Records {
+			src, ok := bySource[r.SourceIP]
+			if !ok {
+				src = &happydns.DMARCSourceStats{
+					SourceIP: r.SourceIP,
+					Known:    isKnown(known, r.SourceIP),
+				}
+				bySource[r.SourceIP] = src
+				summary.Sources = append(summary.Sources, src)
+			}
+
+			src.Messages += r.Count
+			summary.Messages += r.Count
+			if r.DKIMAligned {
+				src.DKIMAligned += r.Count
+				summary.DKIMAligned += r.Count
+			}
+			if r.SPFAligned {
+				src.SPFAligned += r.Count
+				summary.SPFAligned += r.Count
+			}
+			if !r.DKIMAligned && !r.SPFAligned {
+				src.Failed += r.Count
+				summary.Failed += r.Count
+			}
+			if !slices.Contains(src.Reporters, report.OrgName) {
+				src.Reporters = append(src.Reporters, report.OrgName)
+			}
+		}
+	}
+
+	sort.Slice(summary.Sources, func(i, j int) bool {
+		return summary.Sources[i].Messages > summary.Sources[j].Messages
+	})
+
+	return summary
+}
+
+// GetSettings returns the report settings of domain, or the default ones:
+// every notification enabled, no known source. The first call generates the
+// ingestion token, so that the report address is stable.
+func (s *Service) GetSettings(domain *happydns.Domain) (*happydns.DMARCReportSettings, error) {
+	settings, err := s.store.GetDMARCReportSettings(domain.Id)
+	if errors.Is(err, happydns.ErrDMARCReportSettingsNotFound) {
+		token, err := happydns.NewRandomIdentifier()
+		if err != nil {
+			return nil, happydns.InternalError{
+				Err:         fmt.Errorf("unable to generate an ingestion token: %w", err),
+				UserMessage: "Sorry, we are currently unable to generate the report address. Please retry later.",
+			}
+		}
+
+		return s.putSettings(&happydns.DMARCReportSettings{
+			DomainId:             domain.Id,
+			IngestToken:          token.String(),
+			KnownSources:         []string{},
+			NotifyUnknownSenders: true,
+			NotifyFailures:       true,
+		})
+	} else if err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to GetDMARCReportSettings(%s): %w", domain.Id.String(), err),
+			UserMessage: "Sorry, we are currently unable to retrieve the report settings. Please retry later.",
+		}
+	}
+
+	settings.ReportAddress = s.reportAddress(settings)
+	return settings, nil
+}
+
+// SetSettings validates and stores the report settings of domain. The
+// ingestion token is kept.
+func (s *Service) SetSettings(domain *happydns.Domain, settings *happydns.DMARCReportSettings) (*happydns.DMARCReportSettings, error) {
+	sources := make([]string, 0, len(settings.KnownSources))
+	for _, src := range settings.KnownSources {
+		src = strings.TrimSpace(src)
+		if src == "" {
+			continue
+		}
+		prefix, err := parseSource(src)
+		if err != nil {
+			return nil, happydns.ValidationError{Msg: fmt.Sprintf("invalid known source %q: expected an IP address or a CIDR block", src)}
+		}
+		sources = append(sources, prefix.String())
+	}
+
+	current, err := s.GetSettings(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	settings.DomainId = domain.Id
+	settings.IngestToken = current.IngestToken
+	settings.KnownSources = sources
+
+	return s.putSettings(settings)
+}
+
+func (s *Service) putSettings(settings *happydns.DMARCReportSettings) (*happydns.DMARCReportSettings, error) {
+	settings.ReportAddress = ""
+	if err := s.store.PutDMARCReportSettings(settings); err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to PutDMARCReportSettings(%s): %w", settings.DomainId.String(), err),
+			UserMessage: "Sorry, we are currently unable to save the report settings. Please retry later.",
+		}
+	}
+
+	settings.ReportAddress = s.reportAddress(settings)
+	return settings, nil
+}
+
+// reportAddress is the address the receivers send the reports of the domain
+// to, a subaddress of the mailbox receiving the reports.
+func (s *Service) reportAddress(settings *happydns.DMARCReportSettings) string {
+	if settings.IngestToken == "" {
+		return ""
+	}
+	return mailbox.Subaddress(s.address, settings.DomainId.String()+"."+settings.IngestToken)
+}
+
+// Expired tells whether the period of report ended before the retention
+// horizon.
+func (s *Service) Expired(report *happydns.DMARCReport) bool {
+	return report.End.Before(s.now().Add(-s.retention))
+}
+
+func parseSource(src string) (netip.Prefix, error) {
+	if strings.Contains(src, "/") {
+		prefix, err := netip.ParsePrefix(src)
+		return prefix.Masked(), err
+	}
+
+	addr, err := netip.ParseAddr(src)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func parseSources(sources []string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, src := range sources {
+		if prefix, err := parseSource(src); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+func isKnown(known []netip.Prefix, ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	return slices.ContainsFunc(known, func(p netip.Prefix) bool { return p.Contains(addr) })
+}
+
+// isSubdomain tells whether name is domain or one of its subdomains.
+func isSubdomain(name, domain string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	return name == domain || strings.HasSuffix(name, "."+domain)
+}
@@ -0,0 +1,54 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dmarcreport
+
+import (
+	"git.happydns.org/happyDomain/model"
+)
+
+type DMARCReportStorage interface {
+	// ListDMARCReports retrieves the reports received for the given Domain.
+	ListDMARCReports(domainId happydns.Identifier) ([]*happydns.DMARCReport, error)
+
+	// CreateDMARCReport stores a new report, assigning its identifier.
+	CreateDMARCReport(report *happydns.DMARCReport) error
+
+	// DeleteDMARCReport removes the given report.
+	DeleteDMARCReport(report *happydns.DMARCReport) error
+
+	// GetDMARCReportSettings retrieves the report settings of the given Domain.
+	GetDMARCReportSettings(domainId happydns.Identifier) (*happydns.DMARCReportSettings, error)
+
+	// PutDMARCReportSettings creates or replaces the report settings of its Domain.
+	PutDMARCReportSettings(settings *happydns.DMARCReportSettings) error
+}
+
+// DomainFinder retrieves Domains of any user.
+type DomainFinder interface {
+	GetDomain(domainId happydns.Identifier) (*happydns.Domain, error)
+}
+
+// EventNotifier raises notifications for events happening outside of the
+// checker engine.
+type EventNotifier interface {
+	NotifyEvent(checkerID string, target happydns.CheckTarget, states []happydns.CheckState)
+}
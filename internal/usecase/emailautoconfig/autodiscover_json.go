@@ -0,0 +1,76 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package emailautoconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"git.happydns.org/happyDomain/model"
+	"git.happydns.org/happyDomain/services/abstract"
+)
+
+// msAutodiscoverV2 is the response of the Autodiscover v2 JSON endpoint
+// modern Outlook queries first: it only tells where the service speaking
+// the requested protocol lives.
+// See https://learn.microsoft.com/en-us/exchange/client-developer/web-service-reference/autodiscover-for-exchange
+type msAutodiscoverV2 struct {
+	Protocol string `json:"Protocol"`
+	Url      string `json:"Url"`
+}
+
+// RenderAutodiscoverJSON returns the Autodiscover v2 response for the given
+// protocol. AutodiscoverV1 points to the POX endpoint happyDomain serves on
+// autodiscover.<domain>; Exchange protocols point to the Exchange server,
+// when one is configured.
+//
+// Returns a happydns.ValidationError for any other protocol.
+func RenderAutodiscoverJSON(s *abstract.EmailAutoConfig, domainName, protocol string) ([]byte, error) {
+	resp := msAutodiscoverV2{}
+
+	switch strings.ToLower(protocol) {
+	case "autodiscoverv1":
+		resp.Protocol = "AutodiscoverV1"
+		resp.Url = "https://autodiscover." + domainName + "/autodiscover/autodiscover.xml"
+	case "ews":
+		if s.ExchangeServer != "" {
+			resp.Protocol = "Ews"
+			resp.Url = "https://" + strings.TrimSuffix(s.ExchangeServer, ".") + "/EWS/Exchange.asmx"
+		}
+	case "activesync":
+		if s.ExchangeServer != "" {
+			resp.Protocol = "ActiveSync"
+			resp.Url = "https://" + strings.TrimSuffix(s.ExchangeServer, ".") + "/Microsoft-Server-ActiveSync"
+		}
+	}
+
+	if resp.Url == "" {
+		supported := "AutodiscoverV1"
+		if s.ExchangeServer != "" {
+			supported += ",Ews,ActiveSync"
+		}
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("The given protocol value '%s' is invalid. Supported values are '%s'.", protocol, supported)}
+	}
+
+	return json.Marshal(resp)
+}
@@ -0,0 +1,334 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package emailautoconfig
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+	"git.happydns.org/happyDomain/services/abstract"
+)
+
+// plistEntry is a key of a property list dictionary. Dictionaries are kept
+// as ordered slices so that the same service always renders the same
+// profile.
+type plistEntry struct {
+	Key   string
+	Value any
+}
+
+type plistDict []plistEntry
+
+// writePlistValue serialises a property list value. Only the types a
+// configuration profile needs are supported.
+func writePlistValue(b *bytes.Buffer, v any, indent string) error {
+	switch v := v.(type) {
+	case string:
+		b.WriteString(indent + "<string>")
+		xml.EscapeText(b, []byte(v))
+		b.WriteString("</string>\n")
+	case int:
+		fmt.Fprintf(b, "%s<integer>%d</integer>\n", indent, v)
+	case bool:
+		fmt.Fprintf(b, "%s<%t/>\n", indent, v)
+	case plistDict:
+		b.WriteString(indent + "<dict>\n")
+		for _, e := range v {
+			b.WriteString(indent + "\t<key>")
+			xml.EscapeText(b, []byte(e.Key))
+			b.WriteString("</key>\n")
+			if err := writePlistValue(b, e.Value, indent+"\t"); err != nil {
+				return err
+			}
+		}
+		b.WriteString(indent + "</dict>\n")
+	case []plistDict:
+		b.WriteString(indent + "<array>\n")
+		for _, d := range v {
+			if err := writePlistValue(b, d, indent+"\t"); err != nil {
+				return err
+			}
+		}
+		b.WriteString(indent + "</array>\n")
+	default:
+		return fmt.Errorf("unsupported property list value %T", v)
+	}
+	return nil
+}
+
+// profileUUID derives a stable UUID from the given parts, so that
+// downloading the profile again replaces the installed one instead of
+// adding a duplicate account.
+func profileUUID(parts ...string) string {
+	h := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	h[6] = (h[6] & 0x0f) | 0x50
+	h[8] = (h[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%X-%X-%X-%X-%X", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+}
+
+// profileIdentifier returns the reverse-DNS identifier of the profile of the
+// given domain, e.g. "com.example.mail" for example.com.
+func profileIdentifier(domainName string) string {
+	labels := dns.SplitDomainName(strings.ToLower(domainName))
+	slices.Reverse(labels)
+	return strings.Join(append(labels, "mail"), ".")
+}
+
+// appleAuthentication maps happyDomain auth identifiers to the Apple mail
+// payload vocabulary. Apple has no OAuth2 for managed accounts: the client
+// falls back to asking for a password.
+func appleAuthentication(auth string) string {
+	switch auth {
+	case "password-encrypted":
+		return "EmailAuthCRAMMD5"
+	case "NTLM":
+		return "EmailAuthNTLM"
+	}
+	return "EmailAuthPassword"
+}
+
+// appleUsername expands the username format for the given address. It
+// returns "" when no address is known, letting the device ask for it.
+func appleUsername(s *abstract.EmailAutoConfig, emailAddress string) string {
+	if emailAddress == "" {
+		return ""
+	}
+	if s.UsernameFormat == "%EMAILLOCALPART%" {
+		if at := strings.LastIndex(emailAddress, "@"); at >= 0 {
+			return emailAddress[:at]
+		}
+	}
+	return emailAddress
+}
+
+// davAccount is the CalDAV or CardDAV server announced in the zone.
+type davAccount struct {
+	Host string
+	Port int
+	TLS  bool
+	Path string
+}
+
+// findDAVAccount picks the server to configure among the SRV records of a
+// CalDAV or CardDAV service: the secure one first, then the lowest priority.
+// A "." target means the service is explicitly unavailable (RFC 2782).
+func findDAVAccount(records []*dns.SRV, paths []*happydns.TXT, securePrefix string) *davAccount {
+	var best *dns.SRV
+	bestSecure := false
+	for _, srv := range records {
+		if srv == nil || srv.Target == "." || srv.Target == "" {
+			continue
+		}
+		secure := strings.HasPrefix(srv.Hdr.Name, securePrefix)
+		if best == nil || (secure && !bestSecure) || (secure == bestSecure && srv.Priority < best.Priority) {
+			best, bestSecure = srv, secure
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	acct := &davAccount{
+		Host: strings.TrimSuffix(best.Target, "."),
+		Port: int(best.Port),
+		TLS:  bestSecure,
+	}
+
+	label, _, _ := strings.Cut(best.Hdr.Name, ".")
+	for _, txt := range paths {
+		if txt == nil || !strings.HasPrefix(txt.Hdr.Name, label+".") {
+			continue
+		}
+		if path, ok := strings.CutPrefix(strings.TrimSpace(txt.Txt), "path="); ok {
+			acct.Path = path
+			break
+		}
+	}
+
+	return acct
+}
+
+// davURL returns the principal URL to give to the device, or "" when the
+// zone doesn't advertise a context path.
+func davURL(acct *davAccount) string {
+	if acct.Path == "" {
+		return ""
+	}
+	scheme := "http"
+	if acct.TLS {
+		scheme = "https"
+	}
+	return scheme + "://" + acct.Host + ":" + strconv.Itoa(acct.Port) + acct.Path
+}
+
+// MobileConfigServices holds the optional services of the zone, other than
+// the email auto-configuration itself, that make their way into the Apple
+// configuration profile.
+type MobileConfigServices struct {
+	CalDAV  *abstract.CalDAV
+	CardDAV *abstract.CardDAV
+}
+
+// RenderMobileConfig returns an unsigned Apple configuration profile
+// (.mobileconfig) setting up the mail account described by the given
+// EmailAutoConfig service, plus the calendar and contacts accounts when the
+// zone announces CalDAV or CardDAV servers.
+func RenderMobileConfig(s *abstract.EmailAutoConfig, others MobileConfigServices, domainName, emailAddress string) ([]byte, error) {
+	identifier := profileIdentifier(domainName)
+	username := appleUsername(s, emailAddress)
+
+	description := s.DisplayName
+	if description == "" {
+		description = domainName
+	}
+
+	var payloads []plistDict
+
+	if host := s.IncomingHost(); host != "" {
+		accountType := "EmailTypeIMAP"
+		if strings.HasPrefix(s.IncomingType(), "pop3") {
+			accountType = "EmailTypePOP"
+		}
+
+		mail := plistDict{
+			{"EmailAccountDescription", description},
+			{"EmailAccountType", accountType},
+		}
+		if emailAddress != "" {
+			mail = append(mail, plistEntry{"EmailAddress", emailAddress})
+		}
+		mail = append(mail,
+			plistEntry{"IncomingMailServerAuthentication", appleAuthentication(s.IncomingAuth)},
+			plistEntry{"IncomingMailServerHostName", host},
+			plistEntry{"IncomingMailServerPortNumber", int(s.IncomingPort())},
+			plistEntry{"IncomingMailServerUseSSL", msAutodiscoverSSL(s.IncomingType()) == "on"},
+		)
+		if username != "" {
+			mail = append(mail, plistEntry{"IncomingMailServerUsername", username})
+		}
+		if host := s.OutgoingHost(); host != "" {
+			mail = append(mail,
+				plistEntry{"OutgoingMailServerAuthentication", appleAuthentication(s.OutgoingAuth)},
+				plistEntry{"OutgoingMailServerHostName", host},
+				plistEntry{"OutgoingMailServerPortNumber", int(s.OutgoingPort())},
+				// Apple's flag covers both TLS on connect and STARTTLS.
+				plistEntry{"OutgoingMailServerUseSSL", msAutodiscoverEncryption(s.OutgoingType()) != "None"},
+			)
+			if username != "" {
+				mail = append(mail, plistEntry{"OutgoingMailServerUsername", username})
+			}
+			mail = append(mail, plistEntry{"OutgoingPasswordSameAsIncomingPassword", true})
+		}
+		mail = append(mail,
+			plistEntry{"PayloadDisplayName", description},
+			plistEntry{"PayloadIdentifier", identifier + ".email"},
+			plistEntry{"PayloadType", "com.apple.mail.managed"},
+			plistEntry{"PayloadUUID", profileUUID(domainName, "email")},
+			plistEntry{"PayloadVersion", 1},
+		)
+		payloads = append(payloads, mail)
+	}
+
+	if others.CalDAV != nil {
+		if acct := findDAVAccount(others.CalDAV.Records, others.CalDAV.Paths, "_caldavs."); acct != nil {
+			dav := plistDict{
+				{"CalDAVAccountDescription", description},
+				{"CalDAVHostName", acct.Host},
+				{"CalDAVPort", acct.Port},
+			}
+			if u := davURL(acct); u != "" {
+				dav = append(dav, plistEntry{"CalDAVPrincipalURL", u})
+			}
+			dav = append(dav, plistEntry{"CalDAVUseSSL", acct.TLS})
+			if username != "" {
+				dav = append(dav, plistEntry{"CalDAVUsername", username})
+			}
+			dav = append(dav,
+				plistEntry{"PayloadDisplayName", description + " (CalDAV)"},
+				plistEntry{"PayloadIdentifier", identifier + ".caldav"},
+				plistEntry{"PayloadType", "com.apple.caldav.account"},
+				plistEntry{"PayloadUUID", profileUUID(domainName, "caldav")},
+				plistEntry{"PayloadVersion", 1},
+			)
+			payloads = append(payloads, dav)
+		}
+	}
+
+	if others.CardDAV != nil {
+		if acct := findDAVAccount(others.CardDAV.Records, others.CardDAV.Paths, "_carddavs."); acct != nil {
+			dav := plistDict{
+				{"CardDAVAccountDescription", description},
+				{"CardDAVHostName", acct.Host},
+				{"CardDAVPort", acct.Port},
+			}
+			if u := davURL(acct); u != "" {
+				dav = append(dav, plistEntry{"CardDAVPrincipalURL", u})
+			}
+			dav = append(dav, plistEntry{"CardDAVUseSSL", acct.TLS})
+			if username != "" {
+				dav = append(dav, plistEntry{"CardDAVUsername", username})
+			}
+			dav = append(dav,
+				plistEntry{"PayloadDisplayName", description + " (CardDAV)"},
+				plistEntry{"PayloadIdentifier", identifier + ".carddav"},
+				plistEntry{"PayloadType", "com.apple.carddav.account"},
+				plistEntry{"PayloadUUID", profileUUID(domainName, "carddav")},
+				plistEntry{"PayloadVersion", 1},
+			)
+			payloads = append(payloads, dav)
+		}
+	}
+
+	if len(payloads) == 0 {
+		return nil, happydns.ErrNotFound
+	}
+
+	profile := plistDict{
+		{"PayloadContent", payloads},
+		{"PayloadDescription", fmt.Sprintf("Sets up the %s accounts.", domainName)},
+		{"PayloadDisplayName", description},
+		{"PayloadIdentifier", identifier},
+		{"PayloadRemovalDisallowed", false},
+		{"PayloadType", "Configuration"},
+		{"PayloadUUID", profileUUID(domainName)},
+		{"PayloadVersion", 1},
+	}
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString(`<plist version="1.0">` + "\n")
+	if err := writePlistValue(&b, profile, ""); err != nil {
+		return nil, err
+	}
+	b.WriteString("</plist>\n")
+
+	return b.Bytes(), nil
+}
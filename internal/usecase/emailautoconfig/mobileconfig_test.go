@@ -0,0 +1,174 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package emailautoconfig
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+	"git.happydns.org/happyDomain/services/abstract"
+)
+
+func TestRenderMobileConfig(t *testing.T) {
+	caldav := &abstract.CalDAV{
+		Records: []*dns.SRV{
+			{Hdr: dns.RR_Header{Name: "_caldav._tcp", Rrtype: dns.TypeSRV}, Port: 80, Target: "dav.example.com."},
+			{Hdr: dns.RR_Header{Name: "_caldavs._tcp", Rrtype: dns.TypeSRV}, Port: 443, Target: "dav.example.com."},
+		},
+		Paths: []*happydns.TXT{
+			{Hdr: dns.RR_Header{Name: "_caldavs._tcp", Rrtype: dns.TypeTXT}, Txt: "path=/cal/"},
+		},
+	}
+
+	body, err := RenderMobileConfig(sampleService(), MobileConfigServices{CalDAV: caldav}, "example.com", "user@example.com")
+	if err != nil {
+		t.Fatalf("RenderMobileConfig: %v", err)
+	}
+	out := string(body)
+
+	for _, want := range []string{
+		`<plist version="1.0">`,
+		"<key>PayloadType</key>\n\t<string>Configuration</string>",
+		"<key>PayloadIdentifier</key>\n\t<string>com.example.mail</string>",
+		"<string>com.apple.mail.managed</string>",
+		"<key>EmailAccountType</key>\n\t\t\t<string>EmailTypeIMAP</string>",
+		"<key>EmailAddress</key>\n\t\t\t<string>user@example.com</string>",
+		"<key>IncomingMailServerHostName</key>\n\t\t\t<string>imap.example.com</string>",
+		"<key>IncomingMailServerPortNumber</key>\n\t\t\t<integer>993</integer>",
+		"<key>IncomingMailServerUseSSL</key>\n\t\t\t<true/>",
+		"<key>OutgoingMailServerPortNumber</key>\n\t\t\t<integer>587</integer>",
+		"<key>OutgoingMailServerUseSSL</key>\n\t\t\t<true/>",
+		"<string>com.apple.caldav.account</string>",
+		"<key>CalDAVPort</key>\n\t\t\t<integer>443</integer>",
+		"<key>CalDAVPrincipalURL</key>\n\t\t\t<string>https://dav.example.com:443/cal/</string>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("profile missing %q\nOutput:\n%s", want, out)
+		}
+	}
+
+	if strings.Contains(out, "carddav") {
+		t.Errorf("profile has a CardDAV account although the zone has none:\n%s", out)
+	}
+
+	// The same domain must always produce the same UUIDs, so a new download
+	// replaces the installed profile.
+	again, _ := RenderMobileConfig(sampleService(), MobileConfigServices{CalDAV: caldav}, "example.com", "user@example.com")
+	if !bytes.Equal(body, again) {
+		t.Errorf("profile is not stable across renders")
+	}
+}
+
+func TestSignProfile(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "happyDomain profiles"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile := []byte("<plist version=\"1.0\"><dict/></plist>")
+	signed, err := SignProfile(profile, &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key})
+	if err != nil {
+		t.Fatalf("SignProfile: %v", err)
+	}
+
+	var ci struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue `asn1:"explicit,tag:0"`
+	}
+	if _, err := asn1.Unmarshal(signed, &ci); err != nil {
+		t.Fatalf("unable to parse ContentInfo: %v", err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		t.Fatalf("content type = %v; want signedData", ci.ContentType)
+	}
+
+	var sd struct {
+		Version          int
+		DigestAlgorithms asn1.RawValue
+		EncapContentInfo struct {
+			EContentType asn1.ObjectIdentifier
+			EContent     []byte `asn1:"explicit,tag:0"`
+		}
+		Certificates asn1.RawValue `asn1:"tag:0"`
+		SignerInfos  []struct {
+			Version            int
+			SID                asn1.RawValue
+			DigestAlgorithm    asn1.RawValue
+			SignedAttrs        asn1.RawValue
+			SignatureAlgorithm asn1.RawValue
+			Signature          []byte
+		} `asn1:"set"`
+	}
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		t.Fatalf("unable to parse SignedData: %v", err)
+	}
+	if !bytes.Equal(sd.EncapContentInfo.EContent, profile) {
+		t.Errorf("embedded content = %q; want the profile", sd.EncapContentInfo.EContent)
+	}
+	if !bytes.Equal(sd.Certificates.Bytes, der) {
+		t.Errorf("certificate not embedded")
+	}
+	if len(sd.SignerInfos) != 1 {
+		t.Fatalf("got %d signers; want 1", len(sd.SignerInfos))
+	}
+
+	si := sd.SignerInfos[0]
+	if !bytes.Contains(si.SignedAttrs.Bytes, func() []byte { d := sha256.Sum256(profile); return d[:] }()) {
+		t.Errorf("signed attributes lack the digest of the profile")
+	}
+
+	// The signature covers the attributes encoded as a SET OF.
+	attrs := append([]byte{0x31}, si.SignedAttrs.FullBytes[1:]...)
+	digest := sha256.Sum256(attrs)
+	if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], si.Signature) {
+		t.Errorf("signature does not verify")
+	}
+
+	if _, err := SignProfile(profile, &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: crypto.PrivateKey("nope")}); err == nil {
+		t.Errorf("SignProfile succeeded with a key that cannot sign")
+	}
+}
@@ -0,0 +1,179 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package emailautoconfig
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+	"git.happydns.org/happyDomain/services"
+)
+
+type fakeDomains map[string][]*happydns.Domain
+
+func (f fakeDomains) FindDomainsByName(fqdn string) ([]*happydns.Domain, error) {
+	if d, ok := f[fqdn]; ok {
+		return d, nil
+	}
+	return nil, happydns.ErrNotFound
+}
+
+type fakeZones map[string]*happydns.Zone
+
+func (f fakeZones) Get(zoneID happydns.Identifier) (*happydns.Zone, error) {
+	if z, ok := f[zoneID.String()]; ok {
+		return z, nil
+	}
+	return nil, happydns.ErrZoneNotFound
+}
+
+// fakeResolver answers from a static table of zone file lines, keyed by
+// question name and type.
+type fakeResolver map[string][]string
+
+func (f fakeResolver) ResolveQuestion(req happydns.ResolverRequest) (*dns.Msg, error) {
+	lines, ok := f[req.DomainName+" "+req.Type]
+	if !ok {
+		return nil, happydns.NotFoundError{Msg: "not found"}
+	}
+
+	r := new(dns.Msg)
+	for _, line := range lines {
+		rr, err := dns.NewRR(line)
+		if err != nil {
+			return nil, err
+		}
+		r.Answer = append(r.Answer, rr)
+	}
+	return r, nil
+}
+
+func mtaSTSUsecase() *Usecase {
+	published := time.Now()
+	sts := &svcs.MTA_STS{
+		Record: &happydns.TXT{Txt: "v=STSv1; id=1"},
+		Mode:   "enforce",
+		MX:     []string{"mx.example.com"},
+	}
+	zoneId := happydns.Identifier{1}
+	zone := &happydns.Zone{
+		Published: &published,
+		Services: map[happydns.Subdomain][]*happydns.Service{
+			"":       {{Service: sts}},
+			"legacy": {{Service: &svcs.MTA_STS{Record: &happydns.TXT{Txt: "v=STSv1; id=1"}}}},
+		},
+	}
+
+	return NewUsecase(
+		fakeDomains{"example.com.": {{DomainName: "example.com.", ZoneHistory: []happydns.Identifier{zoneId}}}},
+		fakeZones{zoneId.String(): zone},
+		fakeResolver{
+			"_mta-sts.example.com. TXT":        {`_mta-sts.example.com. 300 IN TXT "v=STSv1; id=` + sts.PolicyID() + `"`},
+			"_mta-sts.legacy.example.com. TXT": {`_mta-sts.legacy.example.com. 300 IN TXT "v=STSv1; id=1"`},
+		},
+	)
+}
+
+func TestMTASTSPolicy(t *testing.T) {
+	uc := mtaSTSUsecase()
+
+	body, err := uc.MTASTSPolicy("mta-sts.example.com.")
+	if err != nil {
+		t.Fatalf("MTASTSPolicy() error = %v", err)
+	}
+	if !strings.Contains(string(body), "mode: enforce\r\n") || !strings.Contains(string(body), "mx: mx.example.com\r\n") {
+		t.Errorf("unexpected policy: %q", body)
+	}
+
+	// A policy hosted elsewhere, and a domain not managed at all.
+	for _, fqdn := range []string{"mta-sts.legacy.example.com.", "mta-sts.example.net."} {
+		if _, err := uc.MTASTSPolicy(fqdn); err != happydns.ErrNotFound {
+			t.Errorf("MTASTSPolicy(%s) error = %v; want ErrNotFound", fqdn, err)
+		}
+	}
+}
+
+func TestIsManagedMTASTS(t *testing.T) {
+	uc := mtaSTSUsecase()
+
+	for fqdn, want := range map[string]bool{
+		"mta-sts.example.com":        true,
+		"mta-sts.legacy.example.com": false,
+		"mta-sts.example.net":        false,
+		"example.com":                false,
+	} {
+		got, err := uc.IsManaged(fqdn)
+		if err != nil {
+			t.Fatalf("IsManaged(%s) error = %v", fqdn, err)
+		}
+		if got != want {
+			t.Errorf("IsManaged(%s) = %v; want %v", fqdn, got, want)
+		}
+	}
+}
+
+func TestMTASTSPolicyOtherAccount(t *testing.T) {
+	published := time.Now()
+	owner := &svcs.MTA_STS{Mode: "enforce", MX: []string{"mx.example.com"}}
+	other := &svcs.MTA_STS{Mode: "enforce", MX: []string{"mx.example.net"}}
+
+	// Another account holds the same domain name, with its own policy both
+	// published and in its working zone.
+	zones := fakeZones{
+		happydns.Identifier{1}.String(): {Services: map[happydns.Subdomain][]*happydns.Service{
+			"": {{Service: other}},
+		}},
+		happydns.Identifier{2}.String(): {Published: &published, Services: map[happydns.Subdomain][]*happydns.Service{
+			"": {{Service: other}},
+		}},
+		happydns.Identifier{3}.String(): {Published: &published, Services: map[happydns.Subdomain][]*happydns.Service{
+			"": {{Service: owner}},
+		}},
+	}
+	domains := fakeDomains{"example.com.": {
+		{DomainName: "example.com.", ZoneHistory: []happydns.Identifier{{1}, {2}}},
+		{DomainName: "example.com.", ZoneHistory: []happydns.Identifier{{3}}},
+	}}
+
+	uc := NewUsecase(domains, zones, fakeResolver{
+		"_mta-sts.example.com. TXT": {`_mta-sts.example.com. 300 IN TXT "v=STSv1; id=` + owner.PolicyID() + `"`},
+	})
+	body, err := uc.MTASTSPolicy("mta-sts.example.com.")
+	if err != nil {
+		t.Fatalf("MTASTSPolicy() error = %v", err)
+	}
+	if !strings.Contains(string(body), "mx: mx.example.com\r\n") {
+		t.Errorf("MTASTSPolicy() = %q; want the policy announced by the DNS", body)
+	}
+
+	// No policy is served while the DNS doesn't announce it.
+	uc = NewUsecase(domains, zones, fakeResolver{
+		"_mta-sts.example.com. TXT": {`_mta-sts.example.com. 300 IN TXT "v=STSv1; id=1"`},
+	})
+	if _, err := uc.MTASTSPolicy("mta-sts.example.com."); err != happydns.ErrNotFound {
+		t.Errorf("MTASTSPolicy() with a stale id error = %v; want ErrNotFound", err)
+	}
+}
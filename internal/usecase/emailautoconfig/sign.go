@@ -0,0 +1,212 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package emailautoconfig
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Object identifiers of the CMS (RFC 5652) structures used to sign
+// configuration profiles.
+//
+// The structures below only cover what a signed profile needs: explicitly
+// tagged fields are given as raw values already wrapped in their tag.
+var (
+	oidData            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidContentType     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningTime     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	oidSHA256          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSAEncryption   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+type cmsContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+type cmsAlgorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+type cmsEncapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     asn1.RawValue
+}
+
+type cmsSignedData struct {
+	Version          int
+	DigestAlgorithms []cmsAlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo cmsEncapsulatedContentInfo
+	Certificates     asn1.RawValue
+	SignerInfos      []cmsSignerInfo `asn1:"set"`
+}
+
+type cmsIssuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type cmsSignerInfo struct {
+	Version            int
+	SID                cmsIssuerAndSerialNumber
+	DigestAlgorithm    cmsAlgorithmIdentifier
+	SignedAttrs        asn1.RawValue
+	SignatureAlgorithm cmsAlgorithmIdentifier
+	Signature          []byte
+}
+
+type cmsAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+func cmsAttributeOf(oid asn1.ObjectIdentifier, value any) (cmsAttribute, error) {
+	v, err := asn1.Marshal(value)
+	if err != nil {
+		return cmsAttribute{}, err
+	}
+	return cmsAttribute{Type: oid, Values: []asn1.RawValue{{FullBytes: v}}}, nil
+}
+
+// SignProfile wraps the given configuration profile in a CMS SignedData
+// structure, signed with the given certificate, as Apple devices expect
+// from a signed .mobileconfig. The certificate chain is embedded so the
+// device can show who issued the profile.
+func SignProfile(profile []byte, cert *tls.Certificate) ([]byte, error) {
+	if cert == nil || len(cert.Certificate) == 0 {
+		return nil, errors.New("no signing certificate")
+	}
+
+	leaf := cert.Leaf
+	if leaf == nil {
+		var err error
+		leaf, err = x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse the signing certificate: %w", err)
+		}
+	}
+
+	signer, ok := cert.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("the signing key cannot sign")
+	}
+
+	var sigAlg cmsAlgorithmIdentifier
+	switch signer.Public().(type) {
+	case *rsa.PublicKey:
+		sigAlg = cmsAlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue}
+	case *ecdsa.PublicKey:
+		sigAlg = cmsAlgorithmIdentifier{Algorithm: oidECDSAWithSHA256}
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %T", signer.Public())
+	}
+
+	digest := sha256.Sum256(profile)
+
+	var attrs []cmsAttribute
+	for _, a := range []struct {
+		oid   asn1.ObjectIdentifier
+		value any
+	}{
+		{oidContentType, oidData},
+		{oidSigningTime, time.Now().UTC()},
+		{oidMessageDigest, digest[:]},
+	} {
+		attr, err := cmsAttributeOf(a.oid, a.value)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, attr)
+	}
+
+	// The signature covers the DER encoding of the attributes as a SET OF,
+	// while the SignerInfo carries them under an implicit [0] tag.
+	signedAttrs, err := asn1.MarshalWithParams(attrs, "set")
+	if err != nil {
+		return nil, err
+	}
+	attrsDigest := sha256.Sum256(signedAttrs)
+	signature, err := signer.Sign(rand.Reader, attrsDigest[:], crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign the profile: %w", err)
+	}
+
+	taggedAttrs := append([]byte{}, signedAttrs...)
+	taggedAttrs[0] = 0xa0
+
+	content, err := asn1.Marshal(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []byte
+	for _, c := range cert.Certificate {
+		certs = append(certs, c...)
+	}
+
+	digestAlg := cmsAlgorithmIdentifier{Algorithm: oidSHA256}
+	sd := cmsSignedData{
+		Version:          1,
+		DigestAlgorithms: []cmsAlgorithmIdentifier{digestAlg},
+		EncapContentInfo: cmsEncapsulatedContentInfo{
+			EContentType: oidData,
+			EContent:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: content},
+		},
+		Certificates: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs},
+		SignerInfos: []cmsSignerInfo{{
+			Version: 1,
+			SID: cmsIssuerAndSerialNumber{
+				Issuer:       asn1.RawValue{FullBytes: leaf.RawIssuer},
+				SerialNumber: leaf.SerialNumber,
+			},
+			DigestAlgorithm:    digestAlg,
+			SignedAttrs:        asn1.RawValue{FullBytes: taggedAttrs},
+			SignatureAlgorithm: sigAlg,
+			Signature:          signature,
+		}},
+	}
+
+	inner, err := asn1.Marshal(sd)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(cmsContentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: inner},
+	})
+}
@@ -20,18 +20,23 @@
 // along with this program.  If not, see <https://www.gnu.org/licenses/>.
 
 // Package emailautoconfig serves the public mail-client auto-configuration
-// HTTP endpoints (Mozilla Autoconfig + Microsoft Autodiscover) and the Caddy
-// on-demand TLS validation hook.
+// HTTP endpoints (Mozilla Autoconfig, Microsoft Autodiscover and Apple
+// configuration profiles), the MTA-STS policy files, the OpenPGP Web Key
+// Directory, and the Caddy on-demand TLS validation hook.
 package emailautoconfig
 
 import (
+	"crypto/tls"
 	"errors"
+	"log"
+	"slices"
 	"strings"
 
 	"github.com/miekg/dns"
 
-	"git.happydns.org/happyDomain/services/abstract"
 	"git.happydns.org/happyDomain/model"
+	"git.happydns.org/happyDomain/services"
+	"git.happydns.org/happyDomain/services/abstract"
 )
 
 // DomainFinder looks up Domains by FQDN across all users.
@@ -44,15 +49,33 @@ type ZoneGetter interface {
 	Get(zoneID happydns.Identifier) (*happydns.Zone, error)
 }
 
+// Resolver asks a recursive server about the records currently served, to
+// tell which of the accounts holding a domain name is the one its DNS
+// answers for.
+type Resolver interface {
+	ResolveQuestion(happydns.ResolverRequest) (*dns.Msg, error)
+}
+
 // Usecase implements happydns.EmailAutoconfigUsecase.
 type Usecase struct {
-	domains DomainFinder
-	zones   ZoneGetter
+	domains  DomainFinder
+	zones    ZoneGetter
+	resolver Resolver
+
+	// profileSigner signs the Apple configuration profiles; they are served
+	// unsigned when nil.
+	profileSigner *tls.Certificate
 }
 
 // NewUsecase constructs an Usecase wired to the given storage adapters.
-func NewUsecase(domains DomainFinder, zones ZoneGetter) *Usecase {
-	return &Usecase{domains: domains, zones: zones}
+func NewUsecase(domains DomainFinder, zones ZoneGetter, resolver Resolver) *Usecase {
+	return &Usecase{domains: domains, zones: zones, resolver: resolver}
+}
+
+// SetProfileSigner sets the certificate signing the Apple configuration
+// profiles. Passing nil serves them unsigned.
+func (uc *Usecase) SetProfileSigner(cert *tls.Certificate) {
+	uc.profileSigner = cert
 }
 
 // stripDiscoveryPrefix removes a leading "autoconfig." or "autodiscover."
@@ -69,10 +92,11 @@ func stripDiscoveryPrefix(fqdn string) string {
 }
 
 // findService walks every owner of the given parent domain, loads the latest
-// zone, and returns the first EmailAutoConfig service found at the apex.
+// zone, and returns the first EmailAutoConfig service found, along with the
+// zone holding it.
 //
 // Returns happydns.ErrNotFound if no domain matches or none has the service.
-func (uc *Usecase) findService(parentFQDN string) (*abstract.EmailAutoConfig, *happydns.Domain, error) {
+func (uc *Usecase) findService(parentFQDN string) (*abstract.EmailAutoConfig, *happydns.Zone, error) {
 	domains, err := uc.domains.FindDomainsByName(parentFQDN)
 	if err != nil {
 		return nil, nil, err
@@ -89,7 +113,7 @@ func (uc *Usecase) findService(parentFQDN string) (*abstract.EmailAutoConfig, *h
 		for _, services := range zone.Services {
 			for _, s := range services {
 				if ec, ok := s.Service.(*abstract.EmailAutoConfig); ok {
-					return ec, d, nil
+					return ec, zone, nil
 				}
 			}
 		}
@@ -98,10 +122,128 @@ func (uc *Usecase) findService(parentFQDN string) (*abstract.EmailAutoConfig, *h
 	return nil, nil, happydns.ErrNotFound
 }
 
-// IsManaged reports whether happyDomain hosts the email auto-configuration
-// for the given FQDN. Used by the Caddy on-demand TLS ask endpoint.
+// publishedZone returns the last zone published for the domain, or nil when
+// it has never been published.
+func (uc *Usecase) publishedZone(domain *happydns.Domain) *happydns.Zone {
+	for _, id := range domain.ZoneHistory {
+		zone, err := uc.zones.Get(id)
+		if err != nil {
+			return nil
+		}
+		if zone.Published != nil {
+			return zone
+		}
+	}
+
+	return nil
+}
+
+// liveRecords returns the records of type qtype currently served for name.
+//
+// Several accounts can hold the same domain name: the records a domain
+// publishes are only trusted when the DNS serves them, which proves the
+// account's provider is the one hosting the zone.
+func (uc *Usecase) liveRecords(name string, qtype uint16) []dns.RR {
+	r, err := uc.resolver.ResolveQuestion(happydns.ResolverRequest{
+		Resolver:   "local",
+		DomainName: name,
+		Type:       dns.TypeToString[qtype],
+	})
+	if err != nil {
+		var nxdomain happydns.NotFoundError
+		if !errors.As(err, &nxdomain) {
+			log.Printf("EmailAutoconfig: unable to resolve %s %s: %s", name, dns.TypeToString[qtype], err)
+		}
+		return nil
+	}
+
+	var ret []dns.RR
+	for _, rr := range r.Answer {
+		if rr.Header().Rrtype == qtype {
+			ret = append(ret, rr)
+		}
+	}
+	return ret
+}
+
+// findMTASTS looks for a hosted MTA-STS policy for the given policy domain,
+// which may be the apex or a subdomain of a managed domain.
+//
+// The policy is looked for in the last published zone of each account
+// holding the domain, and is only served when the _mta-sts record currently
+// in the DNS announces its identifier.
+//
+// Returns happydns.ErrNotFound if no domain hosts a policy for it.
+func (uc *Usecase) findMTASTS(policyFQDN string) (*svcs.MTA_STS, error) {
+	policyFQDN = strings.ToLower(dns.Fqdn(policyFQDN))
+	labels := dns.SplitDomainName(policyFQDN)
+
+	for i := 0; i < len(labels)-1; i++ {
+		parent := dns.Fqdn(strings.Join(labels[i:], "."))
+		domains, err := uc.domains.FindDomainsByName(parent)
+		if err != nil {
+			if errors.Is(err, happydns.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+
+		var live []string
+		for _, rr := range uc.liveRecords("_mta-sts."+policyFQDN, dns.TypeTXT) {
+			t := svcs.MTASTSFields{}
+			if t.Analyze(strings.Join(rr.(*dns.TXT).Txt, "")) == nil {
+				live = append(live, t.Id)
+			}
+		}
+
+		subdomain := happydns.Subdomain(strings.Join(labels[:i], "."))
+		for _, d := range domains {
+			zone := uc.publishedZone(d)
+			if zone == nil {
+				continue
+			}
+			for _, s := range zone.Services[subdomain] {
+				if sts, ok := s.Service.(*svcs.MTA_STS); ok && sts.Hosted() && slices.Contains(live, sts.PolicyID()) {
+					return sts, nil
+				}
+			}
+		}
+
+		// The closest managed domain answers, whether it hosts the policy
+		// or not.
+		break
+	}
+
+	return nil, happydns.ErrNotFound
+}
+
+// IsManaged reports whether happyDomain hosts the email auto-configuration,
+// the MTA-STS policy or the Web Key Directory for the given FQDN. Used by the
+// Caddy on-demand TLS ask endpoint.
 func (uc *Usecase) IsManaged(fqdn string) (bool, error) {
 	fqdn = dns.Fqdn(fqdn)
+	if strings.HasPrefix(fqdn, "openpgpkey.") {
+		_, err := uc.findOpenPGPKeys(stripWKDPrefix(fqdn))
+		if err != nil {
+			if errors.Is(err, happydns.ErrNotFound) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	}
+
+	if strings.HasPrefix(fqdn, "mta-sts.") {
+		_, err := uc.findMTASTS(strings.TrimPrefix(fqdn, "mta-sts."))
+		if err != nil {
+			if errors.Is(err, happydns.ErrNotFound) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	}
+
 	if !strings.HasPrefix(fqdn, "autoconfig.") && !strings.HasPrefix(fqdn, "autodiscover.") {
 		return false, nil
 	}
@@ -142,3 +284,71 @@ func (uc *Usecase) AutodiscoverConfig(domainFQDN, emailAddress string) ([]byte,
 	bareDomain := strings.TrimSuffix(parent, ".")
 	return RenderAutodiscoverXML(svc, bareDomain, emailAddress)
 }
+
+// AutodiscoverJSON renders the Autodiscover v2 JSON response for the given
+// FQDN and protocol.
+func (uc *Usecase) AutodiscoverJSON(domainFQDN, protocol string) ([]byte, error) {
+	parent := stripDiscoveryPrefix(domainFQDN)
+	svc, _, err := uc.findService(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	bareDomain := strings.TrimSuffix(parent, ".")
+	return RenderAutodiscoverJSON(svc, bareDomain, protocol)
+}
+
+// MobileConfig renders the Apple configuration profile for the given FQDN,
+// signed when a signing certificate is configured. The CalDAV and CardDAV
+// services found at the apex of the zone are included.
+func (uc *Usecase) MobileConfig(domainFQDN, emailAddress string) ([]byte, error) {
+	parent := stripDiscoveryPrefix(domainFQDN)
+	svc, zone, err := uc.findService(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	var others MobileConfigServices
+	for _, s := range zone.Services[""] {
+		switch body := s.Service.(type) {
+		case *abstract.CalDAV:
+			if others.CalDAV == nil {
+				others.CalDAV = body
+			}
+		case *abstract.CardDAV:
+			if others.CardDAV == nil {
+				others.CardDAV = body
+			}
+		}
+	}
+
+	bareDomain := strings.TrimSuffix(parent, ".")
+	profile, err := RenderMobileConfig(svc, others, bareDomain, emailAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	if uc.profileSigner == nil {
+		return profile, nil
+	}
+	return SignProfile(profile, uc.profileSigner)
+}
+
+// MTASTSPolicy renders the MTA-STS policy file for the given FQDN, with or
+// without its "mta-sts." prefix.
+func (uc *Usecase) MTASTSPolicy(domainFQDN string) ([]byte, error) {
+	policyDomain := strings.TrimPrefix(dns.Fqdn(domainFQDN), "mta-sts.")
+	sts, err := uc.findMTASTS(policyDomain)
+	if err != nil {
+		return nil, err
+	}
+
+	// A policy saved before its fields were checked is better not served
+	// at all than served broken.
+	if err := sts.Validate(); err != nil {
+		log.Printf("MTA-STS: not serving the invalid policy of %s: %s", policyDomain, err.Error())
+		return nil, happydns.ErrNotFound
+	}
+
+	return []byte(sts.Policy()), nil
+}
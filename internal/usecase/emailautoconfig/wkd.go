@@ -0,0 +1,157 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package emailautoconfig
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/internal/helpers"
+	"git.happydns.org/happyDomain/model"
+	"git.happydns.org/happyDomain/services/abstract"
+)
+
+// stripWKDPrefix removes the leading "openpgpkey." of the host of the
+// advanced Web Key Directory method, returning the mail domain.
+func stripWKDPrefix(fqdn string) string {
+	return strings.TrimPrefix(dns.Fqdn(fqdn), "openpgpkey.")
+}
+
+// findOpenPGPKeys returns the OpenPGP services publishing the keys of the
+// addresses of the given mail domain, which may be the apex or a subdomain
+// of a managed domain.
+//
+// The keys are looked for in the last published zone of each account holding
+// the domain, and are only served while their OPENPGPKEY record is in the
+// DNS: another account can't serve its own key for someone else's address.
+//
+// Returns happydns.ErrNotFound if no domain publishes any key for it.
+func (uc *Usecase) findOpenPGPKeys(mailFQDN string) ([]*abstract.OpenPGP, error) {
+	mailFQDN = strings.ToLower(dns.Fqdn(mailFQDN))
+	labels := dns.SplitDomainName(mailFQDN)
+
+	for i := 0; i < len(labels)-1; i++ {
+		parent := dns.Fqdn(strings.Join(labels[i:], "."))
+		domains, err := uc.domains.FindDomainsByName(parent)
+		if err != nil {
+			if errors.Is(err, happydns.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+
+		subdomain := happydns.Subdomain(strings.Join(labels[:i], "."))
+		for _, d := range domains {
+			zone := uc.publishedZone(d)
+			if zone == nil {
+				continue
+			}
+
+			var keys []*abstract.OpenPGP
+			for _, s := range zone.Services[subdomain] {
+				if key, ok := s.Service.(*abstract.OpenPGP); ok && key.Record != nil && uc.openPGPKeyLive(key, mailFQDN) {
+					keys = append(keys, key)
+				}
+			}
+			if len(keys) > 0 {
+				return keys, nil
+			}
+		}
+
+		// The closest managed domain answers, whether it publishes keys
+		// or not.
+		break
+	}
+
+	return nil, happydns.ErrNotFound
+}
+
+// openPGPKeyLive tells whether the record of key, published for an address
+// of the given mail domain, is currently served by the DNS.
+func (uc *Usecase) openPGPKeyLive(key *abstract.OpenPGP, mailFQDN string) bool {
+	name := key.Record.Hdr.Name
+	if !dns.IsFqdn(name) {
+		name += "." + mailFQDN
+	}
+
+	want := strings.Join(strings.Fields(key.Record.PublicKey), "")
+	for _, rr := range uc.liveRecords(name, dns.TypeOPENPGPKEY) {
+		if strings.Join(strings.Fields(rr.(*dns.OPENPGPKEY).PublicKey), "") == want {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wkdKeyMatches tells whether key is the one of the local-part whose WKD hash
+// is given. localPart, sent by clients along with the hash, is only needed
+// for the services whose username is unknown.
+func wkdKeyMatches(key *abstract.OpenPGP, hash, localPart string) bool {
+	if key.Username != "" {
+		return helpers.WKDHash(key.Username) == hash
+	}
+
+	if localPart == "" || helpers.WKDHash(localPart) != hash {
+		return false
+	}
+
+	return strings.HasPrefix(key.Record.Hdr.Name, helpers.EmailIdentifier(localPart)+".")
+}
+
+// WKDPolicy renders the policy file of the Web Key Directory of the given
+// mail domain, with or without its "openpgpkey." prefix.
+func (uc *Usecase) WKDPolicy(domainFQDN string) ([]byte, error) {
+	if _, err := uc.findOpenPGPKeys(stripWKDPrefix(domainFQDN)); err != nil {
+		return nil, err
+	}
+
+	// No option is set: the file only has to exist.
+	return []byte{}, nil
+}
+
+// WKDKey returns the binary OpenPGP key published for the local-part of the
+// given mail domain whose WKD hash is given.
+func (uc *Usecase) WKDKey(domainFQDN, hash, localPart string) ([]byte, error) {
+	keys, err := uc.findOpenPGPKeys(stripWKDPrefix(domainFQDN))
+	if err != nil {
+		return nil, err
+	}
+
+	hash = strings.ToLower(hash)
+	for _, key := range keys {
+		if !wkdKeyMatches(key, hash, localPart) {
+			continue
+		}
+
+		data, err := base64.StdEncoding.DecodeString(key.Record.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+
+	return nil, happydns.ErrNotFound
+}
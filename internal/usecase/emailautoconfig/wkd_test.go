@@ -0,0 +1,173 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package emailautoconfig
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/internal/helpers"
+	"git.happydns.org/happyDomain/model"
+	"git.happydns.org/happyDomain/services/abstract"
+)
+
+func openPGPKey(username, local, key string) *happydns.Service {
+	return &happydns.Service{Service: &abstract.OpenPGP{
+		Username: username,
+		Record: &dns.OPENPGPKEY{
+			Hdr:       dns.RR_Header{Name: helpers.EmailIdentifier(local) + "._openpgpkey", Rrtype: dns.TypeOPENPGPKEY},
+			PublicKey: key,
+		},
+	}}
+}
+
+func wkdUsecase() *Usecase {
+	published := time.Now()
+	zoneId := happydns.Identifier{2}
+	zone := &happydns.Zone{
+		Published: &published,
+		Services: map[happydns.Subdomain][]*happydns.Service{
+			"": {
+				openPGPKey("alice", "alice", "YWxpY2U="),
+				openPGPKey("", "bob", "Ym9i"),
+			},
+		},
+	}
+
+	return NewUsecase(
+		fakeDomains{"example.com.": {{DomainName: "example.com.", ZoneHistory: []happydns.Identifier{zoneId}}}},
+		fakeZones{zoneId.String(): zone},
+		fakeResolver{
+			helpers.EmailIdentifier("alice") + "._openpgpkey.example.com. OPENPGPKEY": {helpers.EmailIdentifier("alice") + "._openpgpkey.example.com. 300 IN OPENPGPKEY YWxpY2U="},
+			helpers.EmailIdentifier("bob") + "._openpgpkey.example.com. OPENPGPKEY":   {helpers.EmailIdentifier("bob") + "._openpgpkey.example.com. 300 IN OPENPGPKEY Ym9i"},
+		},
+	)
+}
+
+func TestWKDKey(t *testing.T) {
+	uc := wkdUsecase()
+
+	body, err := uc.WKDKey("openpgpkey.example.com.", helpers.WKDHash("Alice"), "")
+	if err != nil {
+		t.Fatalf("WKDKey(alice) error = %v", err)
+	}
+	if !bytes.Equal(body, []byte("alice")) {
+		t.Errorf("WKDKey(alice) = %q", body)
+	}
+
+	// Without a username in the service, the local-part is needed.
+	if _, err := uc.WKDKey("example.com.", helpers.WKDHash("bob"), ""); err != happydns.ErrNotFound {
+		t.Errorf("WKDKey(bob) without local-part error = %v; want ErrNotFound", err)
+	}
+	body, err = uc.WKDKey("example.com.", helpers.WKDHash("bob"), "bob")
+	if err != nil {
+		t.Fatalf("WKDKey(bob) error = %v", err)
+	}
+	if !bytes.Equal(body, []byte("bob")) {
+		t.Errorf("WKDKey(bob) = %q", body)
+	}
+
+	// A local-part not matching the hash is ignored.
+	if _, err := uc.WKDKey("example.com.", helpers.WKDHash("carol"), "bob"); err != happydns.ErrNotFound {
+		t.Errorf("WKDKey(carol) error = %v; want ErrNotFound", err)
+	}
+
+	if _, err := uc.WKDKey("example.net.", helpers.WKDHash("alice"), ""); err != happydns.ErrNotFound {
+		t.Errorf("WKDKey on an unmanaged domain error = %v; want ErrNotFound", err)
+	}
+}
+
+func TestWKDPolicy(t *testing.T) {
+	uc := wkdUsecase()
+
+	if _, err := uc.WKDPolicy("openpgpkey.example.com."); err != nil {
+		t.Errorf("WKDPolicy() error = %v", err)
+	}
+	if _, err := uc.WKDPolicy("sub.example.com."); err != happydns.ErrNotFound {
+		t.Errorf("WKDPolicy(sub) error = %v; want ErrNotFound", err)
+	}
+}
+
+func TestIsManagedWKD(t *testing.T) {
+	uc := wkdUsecase()
+
+	for fqdn, want := range map[string]bool{
+		"openpgpkey.example.com":     true,
+		"openpgpkey.sub.example.com": false,
+		"openpgpkey.example.net":     false,
+	} {
+		got, err := uc.IsManaged(fqdn)
+		if err != nil {
+			t.Fatalf("IsManaged(%s) error = %v", fqdn, err)
+		}
+		if got != want {
+			t.Errorf("IsManaged(%s) = %v; want %v", fqdn, got, want)
+		}
+	}
+}
+
+func TestWKDKeyOtherAccount(t *testing.T) {
+	published := time.Now()
+	live := helpers.EmailIdentifier("alice") + "._openpgpkey.example.com."
+
+	// Another account holds the same domain name, with its own key for
+	// alice, both published and in its working zone.
+	zones := fakeZones{
+		happydns.Identifier{1}.String(): {Services: map[happydns.Subdomain][]*happydns.Service{
+			"": {openPGPKey("alice", "alice", "bWFsbG9yeQ==")},
+		}},
+		happydns.Identifier{2}.String(): {Published: &published, Services: map[happydns.Subdomain][]*happydns.Service{
+			"": {openPGPKey("alice", "alice", "bWFsbG9yeQ==")},
+		}},
+		// The owner's working zone changes the key, not yet published.
+		happydns.Identifier{3}.String(): {Services: map[happydns.Subdomain][]*happydns.Service{
+			"": {openPGPKey("alice", "alice", "bmV3")},
+		}},
+		happydns.Identifier{4}.String(): {Published: &published, Services: map[happydns.Subdomain][]*happydns.Service{
+			"": {openPGPKey("alice", "alice", "YWxpY2U=")},
+		}},
+	}
+	domains := fakeDomains{"example.com.": {
+		{DomainName: "example.com.", ZoneHistory: []happydns.Identifier{{1}, {2}}},
+		{DomainName: "example.com.", ZoneHistory: []happydns.Identifier{{3}, {4}}},
+	}}
+
+	uc := NewUsecase(domains, zones, fakeResolver{
+		live + " OPENPGPKEY": {live + " 300 IN OPENPGPKEY YWxpY2U="},
+	})
+	body, err := uc.WKDKey("example.com.", helpers.WKDHash("alice"), "")
+	if err != nil {
+		t.Fatalf("WKDKey(alice) error = %v", err)
+	}
+	if !bytes.Equal(body, []byte("alice")) {
+		t.Errorf("WKDKey(alice) = %q; want the key served by the DNS", body)
+	}
+
+	// No key is served while the DNS doesn't publish it.
+	uc = NewUsecase(domains, zones, fakeResolver{})
+	if _, err := uc.WKDKey("example.com.", helpers.WKDHash("alice"), ""); err != happydns.ErrNotFound {
+		t.Errorf("WKDKey(alice) without live record error = %v; want ErrNotFound", err)
+	}
+}
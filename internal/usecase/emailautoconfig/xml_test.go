@@ -118,3 +118,28 @@ func TestStripDiscoveryPrefix(t *testing.T) {
 		}
 	}
 }
+
+func TestRenderAutodiscoverJSON(t *testing.T) {
+	svc := sampleService()
+
+	body, err := RenderAutodiscoverJSON(svc, "example.com", "autodiscoverv1")
+	if err != nil {
+		t.Fatalf("RenderAutodiscoverJSON: %v", err)
+	}
+	if want := `{"Protocol":"AutodiscoverV1","Url":"https://autodiscover.example.com/autodiscover/autodiscover.xml"}`; string(body) != want {
+		t.Errorf("got %s; want %s", body, want)
+	}
+
+	if _, err := RenderAutodiscoverJSON(svc, "example.com", "ActiveSync"); err == nil {
+		t.Errorf("ActiveSync answered without an Exchange server")
+	}
+
+	svc.ExchangeServer = "exchange.example.com"
+	body, err = RenderAutodiscoverJSON(svc, "example.com", "Ews")
+	if err != nil {
+		t.Fatalf("RenderAutodiscoverJSON: %v", err)
+	}
+	if !strings.Contains(string(body), `"Url":"https://exchange.example.com/EWS/Exchange.asmx"`) {
+		t.Errorf("unexpected EWS response: %s", body)
+	}
+}
@@ -0,0 +1,29 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package emailkeys publishes the OpenPGP keys (RFC 7929) and S/MIME
+// certificates (RFC 8162) of email addresses in the DNS.
+//
+// Uploaded keys are read for the addresses they certify; each address of the
+// subdomain gets an OpenPGP or S/MIME service, whose owner name is derived
+// from the local-part of the address, so that users never have to hash it
+// themselves.
+package emailkeys
@@ -0,0 +1,238 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package emailkeys
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+const (
+	pgpArmorStart  = "-----BEGIN PGP "
+	certArmorStart = "-----BEGIN CERTIFICATE-----"
+)
+
+// oidEmailAddress is the PKCS #9 emailAddress attribute, that older
+// certificates carry in their subject instead of a subjectAltName.
+var oidEmailAddress = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1}
+
+// addressKey is a key found for an address.
+type addressKey struct {
+	// LocalPart is the local-part of the address, as written in the key.
+	LocalPart string
+
+	// Domain is the domain of the address, lowercased and fully
+	// qualified.
+	Domain string
+
+	// Kind is happydns.EmailKeyOpenPGP or happydns.EmailKeySMIME.
+	Kind string
+
+	// OpenPGP is the binary transferable public key, restricted to the
+	// user ID of the address.
+	OpenPGP []byte
+
+	// Certificate is the S/MIME certificate.
+	Certificate *x509.Certificate
+}
+
+// Email returns the address the key is for.
+func (k *addressKey) Email() string {
+	return k.LocalPart + "@" + strings.TrimSuffix(k.Domain, ".")
+}
+
+// splitAddress splits an email address into its local-part and its fully
+// qualified, lowercased domain.
+func splitAddress(address string) (localPart, domain string, ok bool) {
+	at := strings.LastIndex(address, "@")
+	if at <= 0 || at == len(address)-1 {
+		return "", "", false
+	}
+
+	return address[:at], strings.ToLower(dns.Fqdn(address[at+1:])), true
+}
+
+// armoredBlocks splits data on the lines starting an armored block with the
+// given prefix.
+func armoredBlocks(data, start string) []string {
+	var blocks []string
+
+	for {
+		idx := strings.Index(data, start)
+		if idx < 0 {
+			return blocks
+		}
+		data = data[idx:]
+
+		next := strings.Index(data[len(start):], start)
+		if next < 0 {
+			return append(blocks, data)
+		}
+		blocks = append(blocks, data[:len(start)+next])
+		data = data[len(start)+next:]
+	}
+}
+
+// parseOpenPGPKeys reads the ASCII-armored public keys of data, and returns
+// one key per user ID holding an email address.
+func parseOpenPGPKeys(data string) ([]*addressKey, error) {
+	var keys []*addressKey
+
+	for _, text := range armoredBlocks(data, pgpArmorStart) {
+		block, err := armor.Decode(strings.NewReader(text))
+		if err != nil {
+			return nil, fmt.Errorf("invalid armored OpenPGP block: %w", err)
+		}
+		if block.Type != openpgp.PublicKeyType {
+			return nil, fmt.Errorf("unexpected %q: only public keys can be published", block.Type)
+		}
+
+		entities, err := openpgp.ReadKeyRing(block.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OpenPGP key: %w", err)
+		}
+
+		for _, entity := range entities {
+			for name, identity := range entity.Identities {
+				if identity.UserId == nil || identity.UserId.Email == "" {
+					continue
+				}
+
+				localPart, domain, ok := splitAddress(identity.UserId.Email)
+				if !ok {
+					continue
+				}
+
+				// RFC 7929 recommends to publish the key with the
+				// only user ID of the address.
+				minimal := *entity
+				minimal.PrivateKey = nil
+				minimal.Identities = map[string]*openpgp.Identity{name: identity}
+
+				var buf bytes.Buffer
+				if err := minimal.Serialize(&buf); err != nil {
+					return nil, fmt.Errorf("unable to serialize the key of %s: %w", identity.UserId.Email, err)
+				}
+
+				keys = append(keys, &addressKey{
+					LocalPart: localPart,
+					Domain:    domain,
+					Kind:      happydns.EmailKeyOpenPGP,
+					OpenPGP:   buf.Bytes(),
+				})
+			}
+		}
+	}
+
+	return keys, nil
+}
+
+// certificateAddresses returns the email addresses certified by cert, from its
+// subjectAltName and its subject.
+func certificateAddresses(cert *x509.Certificate) []string {
+	addresses := append([]string{}, cert.EmailAddresses...)
+
+	for _, attr := range cert.Subject.Names {
+		if !attr.Type.Equal(oidEmailAddress) {
+			continue
+		}
+		if address, ok := attr.Value.(string); ok {
+			addresses = append(addresses, address)
+		}
+	}
+
+	var ret []string
+next:
+	for _, address := range addresses {
+		for _, a := range ret {
+			if strings.EqualFold(a, address) {
+				continue next
+			}
+		}
+		ret = append(ret, address)
+	}
+
+	return ret
+}
+
+// parseSMIMECertificates reads the PEM-encoded certificates of data, and
+// returns one key per email address they certify.
+func parseSMIMECertificates(data string) ([]*addressKey, error) {
+	var keys []*addressKey
+
+	for _, text := range armoredBlocks(data, certArmorStart) {
+		block, _ := pem.Decode([]byte(text))
+		if block == nil {
+			return nil, fmt.Errorf("invalid PEM-encoded certificate")
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate: %w", err)
+		}
+
+		for _, address := range certificateAddresses(cert) {
+			localPart, domain, ok := splitAddress(address)
+			if !ok {
+				continue
+			}
+
+			keys = append(keys, &addressKey{
+				LocalPart:   localPart,
+				Domain:      domain,
+				Kind:        happydns.EmailKeySMIME,
+				Certificate: cert,
+			})
+		}
+	}
+
+	return keys, nil
+}
+
+// parseKeys reads the OpenPGP keys and the S/MIME certificates of data.
+func parseKeys(data string) ([]*addressKey, error) {
+	if strings.Contains(data, "PRIVATE KEY") {
+		return nil, fmt.Errorf("private keys must not be uploaded: only public keys and certificates are published")
+	}
+
+	keys, err := parseOpenPGPKeys(data)
+	if err != nil {
+		return nil, err
+	}
+
+	certs, err := parseSMIMECertificates(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(keys, certs...), nil
+}
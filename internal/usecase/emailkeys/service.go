@@ -0,0 +1,179 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package emailkeys
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/internal/helpers"
+	"git.happydns.org/happyDomain/model"
+	"git.happydns.org/happyDomain/services/abstract"
+)
+
+// Service implements happydns.EmailKeysUsecase.
+type Service struct {
+	zoneService happydns.ZoneServiceUsecase
+}
+
+// NewService builds the email keys Service.
+func NewService(zoneService happydns.ZoneServiceUsecase) *Service {
+	return &Service{
+		zoneService: zoneService,
+	}
+}
+
+// ownerPrefix returns the label under which the records of the given kind
+// are published, below the domain of the address.
+func ownerPrefix(kind string) string {
+	if kind == happydns.EmailKeySMIME {
+		return "_smimecert"
+	}
+	return "_openpgpkey"
+}
+
+// newServiceBody builds the service publishing key, with its owner name
+// relative to the domain of the address.
+func newServiceBody(key *addressKey) (happydns.ServiceBody, string, error) {
+	owner := helpers.EmailIdentifier(key.LocalPart) + "." + ownerPrefix(key.Kind)
+
+	if key.Kind == happydns.EmailKeySMIME {
+		// Full certificate (3 0 0), as mail agents need it to encrypt.
+		data, err := dns.CertificateToDANE(0, 0, key.Certificate)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return &abstract.SMimeCert{
+			Username: key.LocalPart,
+			Record: &dns.SMIMEA{
+				Hdr: dns.RR_Header{
+					Name:   owner,
+					Rrtype: dns.TypeSMIMEA,
+					Class:  dns.ClassINET,
+				},
+				Usage:        3,
+				Selector:     0,
+				MatchingType: 0,
+				Certificate:  data,
+			},
+		}, owner, nil
+	}
+
+	return &abstract.OpenPGP{
+		Username: key.LocalPart,
+		Record: &dns.OPENPGPKEY{
+			Hdr: dns.RR_Header{
+				Name:   owner,
+				Rrtype: dns.TypeOPENPGPKEY,
+				Class:  dns.ClassINET,
+			},
+			PublicKey: base64.StdEncoding.EncodeToString(key.OpenPGP),
+		},
+	}, owner, nil
+}
+
+// findExisting returns the service of the subdomain already publishing a key
+// of the given kind under owner.
+func findExisting(zone *happydns.Zone, subdomain happydns.Subdomain, kind, owner string) *happydns.Service {
+	for _, svc := range zone.Services[subdomain] {
+		switch body := svc.Service.(type) {
+		case *abstract.OpenPGP:
+			if kind == happydns.EmailKeyOpenPGP && body.Record != nil && strings.EqualFold(body.Record.Hdr.Name, owner) {
+				return svc
+			}
+		case *abstract.SMimeCert:
+			if kind == happydns.EmailKeySMIME && body.Record != nil && strings.EqualFold(body.Record.Hdr.Name, owner) {
+				return svc
+			}
+		}
+	}
+	return nil
+}
+
+// PublishKeys reads the OpenPGP public keys and S/MIME certificates of input,
+// and publishes each of them for the addresses of subdomain it certifies,
+// replacing the key previously published for the same address.
+func (s *Service) PublishKeys(user *happydns.User, domain *happydns.Domain, zone *happydns.Zone, subdomain happydns.Subdomain, input *happydns.EmailKeysInput) (*happydns.EmailKeysPublication, error) {
+	keys, err := parseKeys(input.Keys)
+	if err != nil {
+		return nil, happydns.ValidationError{Msg: err.Error()}
+	}
+	if len(keys) == 0 {
+		return nil, happydns.ValidationError{Msg: "no OpenPGP public key or S/MIME certificate holding an email address was found"}
+	}
+
+	dn := strings.ToLower(helpers.DomainFQDN(string(subdomain), domain.DomainName))
+
+	ret := &happydns.EmailKeysPublication{}
+	for _, key := range keys {
+		if key.Domain != dn {
+			ret.Skipped = append(ret.Skipped, key.Email())
+			continue
+		}
+
+		body, owner, err := newServiceBody(key)
+		if err != nil {
+			return nil, happydns.ValidationError{Msg: fmt.Sprintf("unable to compute the record of %s: %s", key.Email(), err.Error())}
+		}
+
+		svc := &happydns.Service{
+			ServiceMeta: happydns.ServiceMeta{
+				UserComment: "Generated by happyDomain",
+			},
+			Service: body,
+		}
+
+		existing := findExisting(zone, subdomain, key.Kind, owner)
+		if existing != nil {
+			svc.ServiceMeta = existing.ServiceMeta
+			zone, err = s.zoneService.UpdateZoneService(user, domain, zone, subdomain, existing.Id, svc)
+		} else {
+			if key.Kind == happydns.EmailKeySMIME {
+				svc.Type = "abstract.SMimeCert"
+			} else {
+				svc.Type = "abstract.OpenPGP"
+			}
+			zone, err = s.zoneService.AddServiceToZone(user, domain, zone, subdomain, happydns.Origin(domain.DomainName), svc)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to publish the key of %s: %w", key.Email(), err)
+		}
+
+		ret.Published = append(ret.Published, &happydns.EmailKey{
+			Email:    key.Email(),
+			Kind:     key.Kind,
+			Owner:    helpers.DomainJoin(owner, dn),
+			Replaced: existing != nil,
+		})
+	}
+
+	if len(ret.Published) == 0 {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("none of the addresses of the keys (%s) belongs to %s", strings.Join(ret.Skipped, ", "), strings.TrimSuffix(dn, "."))}
+	}
+
+	ret.Zone = zone
+	return ret, nil
+}
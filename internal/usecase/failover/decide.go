@@ -0,0 +1,101 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package failover
+
+import (
+	"git.happydns.org/happyDomain/model"
+)
+
+// targetHealth sums up the latest executions of the plan watching a target.
+type targetHealth struct {
+	// watched is false for a target without plan, deemed healthy.
+	watched bool
+
+	// critical is the number of consecutive critical executions, the
+	// latest one included.
+	critical int
+
+	// healthy is the number of consecutive successful executions, the
+	// latest one included.
+	healthy int
+}
+
+// streaks computes the health of a target from the executions of its plan,
+// newest first. Executions that neither succeeded nor found the target
+// critical (warnings, checker errors) end both streaks.
+func streaks(execs []*happydns.Execution) targetHealth {
+	h := targetHealth{watched: true}
+
+	for _, e := range execs {
+		switch {
+		case e.Status == happydns.ExecutionDone && e.Result.Status == happydns.StatusCrit:
+			if h.healthy > 0 {
+				return h
+			}
+			h.critical++
+		case e.Status == happydns.ExecutionDone && e.Result.Status <= happydns.StatusInfo:
+			if h.critical > 0 {
+				return h
+			}
+			h.healthy++
+		default:
+			return h
+		}
+	}
+
+	return h
+}
+
+// decide returns the index of the target to publish, the targets being
+// ordered by preference:
+//   - a preferred target is published again once it has been healthy for
+//     recoverAfter executions;
+//   - the published target is abandoned once it has been critical for
+//     failAfter executions, for the next target that isn't down.
+//
+// Targets without plan are always deemed healthy.
+func decide(active int, health []targetHealth, failAfter, recoverAfter int) int {
+	recovered := func(i int) bool {
+		return !health[i].watched || health[i].healthy >= recoverAfter
+	}
+	down := func(i int) bool {
+		return health[i].watched && health[i].critical >= failAfter
+	}
+
+	for i := 0; i < active; i++ {
+		if recovered(i) {
+			return i
+		}
+	}
+
+	if !down(active) {
+		return active
+	}
+
+	for i := active + 1; i < len(health); i++ {
+		if !down(i) {
+			return i
+		}
+	}
+
+	return active
+}
@@ -0,0 +1,94 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package failover
+
+import (
+	"testing"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+func execs(statuses ...happydns.Status) (list []*happydns.Execution) {
+	for _, st := range statuses {
+		list = append(list, &happydns.Execution{
+			Status: happydns.ExecutionDone,
+			Result: happydns.CheckState{Status: st},
+		})
+	}
+	return
+}
+
+func TestStreaks(t *testing.T) {
+	crit, ok, warn := happydns.StatusCrit, happydns.StatusOK, happydns.StatusWarn
+
+	for _, tc := range []struct {
+		name   string
+		execs  []*happydns.Execution
+		crit   int
+		health int
+	}{
+		{"none", nil, 0, 0},
+		{"critical streak", execs(crit, crit, ok), 2, 0},
+		{"healthy streak", execs(ok, happydns.StatusInfo, ok, crit), 0, 3},
+		{"warning ends streaks", execs(crit, warn, crit), 1, 0},
+		{"warning first", execs(warn, crit, crit), 0, 0},
+		{"failed execution", append(execs(ok), &happydns.Execution{Status: happydns.ExecutionFailed}), 0, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := streaks(tc.execs)
+			if !h.watched || h.critical != tc.crit || h.healthy != tc.health {
+				t.Errorf("streaks() = %+v; want critical=%d healthy=%d", h, tc.crit, tc.health)
+			}
+		})
+	}
+}
+
+func TestDecide(t *testing.T) {
+	up := targetHealth{watched: true, healthy: 10}
+	down := targetHealth{watched: true, critical: 3}
+	flapping := targetHealth{watched: true, healthy: 2}
+	unwatched := targetHealth{}
+
+	for _, tc := range []struct {
+		name   string
+		active int
+		health []targetHealth
+		want   int
+	}{
+		{"primary up", 0, []targetHealth{up, up}, 0},
+		{"primary not down long enough", 0, []targetHealth{{watched: true, critical: 2}, up}, 0},
+		{"primary down", 0, []targetHealth{down, up}, 1},
+		{"primary down, unwatched backup", 0, []targetHealth{down, unwatched}, 1},
+		{"primary and first backup down", 0, []targetHealth{down, down, up}, 2},
+		{"everything down", 0, []targetHealth{down, down}, 0},
+		{"primary recovering", 1, []targetHealth{flapping, up}, 1},
+		{"primary recovered", 1, []targetHealth{up, up}, 0},
+		{"backup down, primary recovering", 1, []targetHealth{flapping, down, up}, 2},
+		{"better backup recovered", 2, []targetHealth{down, up, up}, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := decide(tc.active, tc.health, 3, 5); got != tc.want {
+				t.Errorf("decide() = %d; want %d", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,356 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"git.happydns.org/happyDomain/internal/helpers"
+	domainlogUC "git.happydns.org/happyDomain/internal/usecase/domain_log"
+	"git.happydns.org/happyDomain/internal/usecase/orchestrator"
+	serviceUC "git.happydns.org/happyDomain/internal/usecase/service"
+	"git.happydns.org/happyDomain/model"
+	"git.happydns.org/happyDomain/services/abstract"
+)
+
+const (
+	// CheckerID identifies the failover notifications in the notification
+	// pipeline, in place of a checker.
+	CheckerID = "failover"
+
+	// publishTimeout bounds the time given to the provider to publish a
+	// switch.
+	publishTimeout = 2 * time.Minute
+)
+
+// Service switches the published target of the Failover services according
+// to the executions of the checker plans watching their targets.
+type Service struct {
+	checks      CheckerStorage
+	domains     DomainGetter
+	users       UserGetter
+	getZone     ZoneGetter
+	zoneService happydns.ZoneServiceUsecase
+	publisher   ZonePublisher
+	domainLog   domainlogUC.DomainLogAppender
+	notifier    EventNotifier
+	now         func() time.Time
+
+	// mu serializes the evaluations, so that two executions completing
+	// together don't edit the same zone concurrently.
+	mu sync.Mutex
+}
+
+// NewService builds the failover Service. notifier may be nil to disable
+// notifications.
+func NewService(
+	checks CheckerStorage,
+	domains DomainGetter,
+	users UserGetter,
+	getZone ZoneGetter,
+	zoneService happydns.ZoneServiceUsecase,
+	publisher ZonePublisher,
+	domainLog domainlogUC.DomainLogAppender,
+	notifier EventNotifier,
+) *Service {
+	return &Service{
+		checks:      checks,
+		domains:     domains,
+		users:       users,
+		getZone:     getZone,
+		zoneService: zoneService,
+		publisher:   publisher,
+		domainLog:   domainLog,
+		notifier:    notifier,
+		now:         time.Now,
+	}
+}
+
+// OnExecutionComplete is chained to the checker engine completion callback.
+// Executions of a checker plan are evaluated in the background, so that
+// publishing a switch never holds a checker worker.
+func (s *Service) OnExecutionComplete(exec *happydns.Execution, _ *happydns.CheckEvaluation) {
+	if exec == nil || exec.PlanID == nil || exec.Target.DomainId == "" {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		defer cancel()
+
+		if err := s.Evaluate(ctx, exec); err != nil {
+			log.Printf("Failover: unable to evaluate execution %s: %s", exec.Id.String(), err.Error())
+		}
+	}()
+}
+
+// Evaluate looks for the Failover services of the execution's domain that
+// are linked to the execution's plan, and switches their published target
+// when needed.
+func (s *Service) Evaluate(ctx context.Context, exec *happydns.Execution) error {
+	if exec.PlanID == nil {
+		return nil
+	}
+
+	domainId, err := happydns.NewIdentifierFromString(exec.Target.DomainId)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	domain, zone, err := s.currentZone(domainId)
+	if err != nil || zone == nil {
+		return err
+	}
+
+	type match struct {
+		subdomain happydns.Subdomain
+		serviceId happydns.Identifier
+	}
+	var matches []match
+	for subdomain, services := range zone.Services {
+		for _, svc := range services {
+			if fo, ok := svc.Service.(*abstract.Failover); ok && watches(fo, *exec.PlanID) {
+				matches = append(matches, match{subdomain, svc.Id})
+			}
+		}
+	}
+
+	var errs []error
+	for _, m := range matches {
+		if err := s.evaluateService(ctx, domainId, m.subdomain, m.serviceId); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", helpers.DomainFQDN(string(m.subdomain), domain.DomainName), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// watches tells whether one of the targets of fo is linked to the plan.
+func watches(fo *abstract.Failover, planId happydns.Identifier) bool {
+	for _, t := range fo.Targets() {
+		if t.PlanId == planId.String() {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) currentZone(domainId happydns.Identifier) (*happydns.Domain, *happydns.Zone, error) {
+	domain, err := s.domains.GetDomain(domainId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(domain.ZoneHistory) == 0 {
+		return domain, nil, nil
+	}
+
+	zone, err := s.getZone.Get(domain.ZoneHistory[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return domain, zone, nil
+}
+
+func findService(zone *happydns.Zone, subdomain happydns.Subdomain, serviceId happydns.Identifier) (*happydns.Service, *abstract.Failover) {
+	for _, svc := range zone.Services[subdomain] {
+		if svc.Id.Equals(serviceId) {
+			if fo, ok := svc.Service.(*abstract.Failover); ok {
+				return svc, fo
+			}
+		}
+	}
+	return nil, nil
+}
+
+// evaluateService decides which target of the given Failover service should
+// be published, and publishes it when it changes.
+func (s *Service) evaluateService(ctx context.Context, domainId happydns.Identifier, subdomain happydns.Subdomain, serviceId happydns.Identifier) error {
+	// Reload the zone: a previous switch may have changed it.
+	domain, zone, err := s.currentZone(domainId)
+	if err != nil || zone == nil {
+		return err
+	}
+
+	svc, fo := findService(zone, subdomain, serviceId)
+	if fo == nil {
+		return nil
+	}
+
+	targets := fo.Targets()
+	if len(targets) < 2 {
+		return nil
+	}
+
+	failAfter, recoverAfter := fo.Thresholds()
+
+	health := make([]targetHealth, len(targets))
+	for i, t := range targets {
+		health[i], err = s.health(domain, t.PlanId, max(failAfter, recoverAfter))
+		if err != nil {
+			return fmt.Errorf("unable to retrieve the health of target %s: %w", t.String(), err)
+		}
+	}
+
+	active := fo.Active
+	if active < 0 || active >= len(targets) {
+		active = 0
+	}
+
+	next := decide(active, health, failAfter, recoverAfter)
+	if next == active {
+		return nil
+	}
+
+	return s.switchTo(ctx, domain, zone, subdomain, svc, fo, active, next, health)
+}
+
+// switchTo publishes the next target of the Failover service, then logs and
+// notifies the switch. The zone is restored should the publication fail.
+func (s *Service) switchTo(ctx context.Context, domain *happydns.Domain, zone *happydns.Zone, subdomain happydns.Subdomain, svc *happydns.Service, fo *abstract.Failover, active, next int, health []targetHealth) error {
+	user, err := s.users.GetUser(domain.Owner)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	fqdn := helpers.DomainFQDN(string(subdomain), domain.DomainName)
+	previous := fo.Targets()[active]
+	_, recoverAfter := fo.Thresholds()
+
+	updated := *fo
+	updated.Active = next
+	updated.LastSwitch = &now
+
+	newSvc := *svc
+	newSvc.Service = &updated
+
+	// Only the records of the failover service are published: the other
+	// changes pending at the same subdomain are left for the user to review.
+	listRecords := serviceUC.NewListRecordsUsecase()
+	records, err := listRecords.List(svc, domain.DomainName, zone.DefaultTTL)
+	if err != nil {
+		return err
+	}
+	newRecords, err := listRecords.List(&newSvc, domain.DomainName, zone.DefaultTTL)
+	if err != nil {
+		return err
+	}
+	records = append(records, newRecords...)
+
+	newZone, err := s.zoneService.UpdateZoneService(user, domain, zone, subdomain, svc.Id, &newSvc)
+	if err != nil {
+		return err
+	}
+
+	// The notification state follows the situation rather than each
+	// switch: critical while a backup replaces a failed target, back to OK
+	// with the primary.
+	var msg string
+	var status happydns.Status
+	var code string
+	var level int8
+	if next == 0 {
+		msg = fmt.Sprintf("Failover of %s: primary target %s is back, after %d successful checks", fqdn, updated.ActiveTarget().String(), recoverAfter)
+		status, code, level = happydns.StatusOK, "failover_restored", happydns.LOG_INFO
+	} else if next < active {
+		msg = fmt.Sprintf("Failover of %s: backup %d (%s) is back, after %d successful checks", fqdn, next, updated.ActiveTarget().String(), recoverAfter)
+		status, code, level = happydns.StatusWarn, "failover_switched", happydns.LOG_WARN
+	} else {
+		msg = fmt.Sprintf("Failover of %s: %s critical for %d checks, switching to backup %d (%s)", fqdn, previous.String(), health[active].critical, next, updated.ActiveTarget().String())
+		status, code, level = happydns.StatusCrit, "failover_switched", happydns.LOG_WARN
+	}
+
+	if _, err := s.publisher.ApplyMatching(ctx, user, domain, newZone, msg, orchestrator.MatchRecords(records...)); err != nil {
+		s.appendLog(domain, user, happydns.LOG_ERR, fmt.Sprintf("%s: unable to publish: %s", msg, err.Error()))
+
+		// Keep the zone telling what is actually published, so the
+		// switch is tried again on the next execution.
+		if _, restoreErr := s.zoneService.UpdateZoneService(user, domain, newZone, subdomain, svc.Id, svc); restoreErr != nil {
+			log.Printf("Failover: unable to restore %s after a failed switch: %s", fqdn, restoreErr.Error())
+		}
+		return err
+	}
+
+	s.appendLog(domain, user, level, msg)
+
+	if s.notifier != nil {
+		s.notifier.NotifyEvent(CheckerID, happydns.CheckTarget{
+			UserId:    domain.Owner.String(),
+			DomainId:  domain.Id.String(),
+			ServiceId: svc.Id.String(),
+		}, []happydns.CheckState{{
+			Status:  status,
+			Code:    code,
+			Message: msg + ".",
+		}})
+	}
+
+	return nil
+}
+
+// health computes the streaks of the latest executions of the plan watching
+// a target. A target without plan is deemed healthy.
+func (s *Service) health(domain *happydns.Domain, planId string, limit int) (targetHealth, error) {
+	if planId == "" {
+		return targetHealth{}, nil
+	}
+
+	id, err := happydns.NewIdentifierFromString(planId)
+	if err != nil {
+		return targetHealth{}, fmt.Errorf("invalid checker plan identifier %q: %w", planId, err)
+	}
+
+	plan, err := s.checks.GetCheckPlan(id)
+	if err != nil {
+		return targetHealth{}, err
+	}
+
+	// Only the plans of the domain owner are taken into account.
+	if plan.Target.UserId != domain.Owner.String() {
+		return targetHealth{}, happydns.ErrCheckPlanNotFound
+	}
+
+	execs, err := s.checks.ListExecutionsByChecker(plan.CheckerID, plan.Target, limit, func(e *happydns.Execution) bool {
+		return e.PlanID != nil && e.PlanID.Equals(plan.Id) && (e.Status == happydns.ExecutionDone || e.Status == happydns.ExecutionFailed)
+	})
+	if err != nil {
+		return targetHealth{}, err
+	}
+
+	return streaks(execs), nil
+}
+
+func (s *Service) appendLog(domain *happydns.Domain, user *happydns.User, level int8, msg string) {
+	if err := s.domainLog.AppendDomainLog(domain, happydns.NewDomainLog(user, level, msg)); err != nil {
+		log.Printf("Failover: unable to append domain log for %s: %s", domain.DomainName, err.Error())
+	}
+}
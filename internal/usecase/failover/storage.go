@@ -0,0 +1,61 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package failover
+
+import (
+	"context"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// CheckerStorage gives access to the checker plans and their executions.
+type CheckerStorage interface {
+	GetCheckPlan(planID happydns.Identifier) (*happydns.CheckPlan, error)
+	ListExecutionsByChecker(checkerID string, target happydns.CheckTarget, limit int, filter func(*happydns.Execution) bool) ([]*happydns.Execution, error)
+}
+
+// UserGetter retrieves the owner of a Domain, on whose behalf the switch
+// edits and publishes the zone.
+type UserGetter interface {
+	GetUser(id happydns.Identifier) (*happydns.User, error)
+}
+
+// DomainGetter retrieves a Domain by its identifier.
+type DomainGetter interface {
+	GetDomain(id happydns.Identifier) (*happydns.Domain, error)
+}
+
+// ZoneGetter retrieves a Zone by its identifier.
+type ZoneGetter interface {
+	Get(zoneID happydns.Identifier) (*happydns.Zone, error)
+}
+
+// ZonePublisher publishes a subset of the pending corrections of a zone.
+type ZonePublisher interface {
+	ApplyMatching(ctx context.Context, user *happydns.User, domain *happydns.Domain, zone *happydns.Zone, commitMsg string, keep func(*happydns.Correction) bool) (*happydns.Zone, error)
+}
+
+// EventNotifier raises notifications for events happening outside of the
+// checker engine.
+type EventNotifier interface {
+	NotifyEvent(checkerID string, target happydns.CheckTarget, states []happydns.CheckState)
+}
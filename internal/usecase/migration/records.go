@@ -0,0 +1,156 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package migration
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// rrsetKey identifies an RRset of the zone.
+type rrsetKey struct {
+	name   string
+	rrtype uint16
+}
+
+// isApex tells whether the record is at the apex of the domain, and is of
+// one of the types each provider sets for itself there.
+func isApex(record happydns.Record, domain string) bool {
+	hdr := record.Header()
+	if hdr.Rrtype != dns.TypeNS && hdr.Rrtype != dns.TypeSOA {
+		return false
+	}
+	return strings.EqualFold(dns.Fqdn(hdr.Name), dns.Fqdn(domain))
+}
+
+// migrateRecords returns the records of the snapshot, with the apex NS and
+// SOA of the target provider in place of those of the former one.
+func migrateRecords(snapshot []happydns.Record, domain string, target []happydns.Record) []happydns.Record {
+	var ret []happydns.Record
+	for _, record := range snapshot {
+		if !isApex(record, domain) {
+			ret = append(ret, record)
+		}
+	}
+	for _, record := range target {
+		if isApex(record, domain) {
+			ret = append(ret, record)
+		}
+	}
+	return ret
+}
+
+// apexNameservers returns the name servers declared at the apex of the
+// domain, lowercased and sorted.
+func apexNameservers(records []happydns.Record, domain string) []string {
+	var ret []string
+	for _, record := range records {
+		if ns, ok := record.(*dns.NS); ok && isApex(ns, domain) {
+			ret = append(ret, strings.ToLower(dns.Fqdn(ns.Ns)))
+		}
+	}
+	slices.Sort(ret)
+	return slices.Compact(ret)
+}
+
+// capTTL lowers to ttl the TTL of the records exceeding it, and returns the
+// highest TTL they had.
+func capTTL(records []happydns.Record, ttl uint32) (highest uint32) {
+	for _, record := range records {
+		hdr := record.Header()
+		highest = max(highest, hdr.Ttl)
+		hdr.Ttl = min(hdr.Ttl, ttl)
+	}
+	return
+}
+
+// toRR returns the record as the DNS carries it, or nil for the records
+// existing only in the API of a provider.
+func toRR(record happydns.Record) dns.RR {
+	if _, isPseudo := happydns.PseudoTypeByRrtype(record.Header().Rrtype); isPseudo {
+		return nil
+	}
+
+	switch rr := record.(type) {
+	case happydns.ConvertibleRecord:
+		return rr.ToRR()
+	case dns.RR:
+		return rr
+	}
+	return nil
+}
+
+// rrsets lists the RRsets of the zone to compare between the providers: all
+// of them, but the apex NS and SOA, which differ from one provider to
+// another.
+func rrsets(records []happydns.Record, domain string) []rrsetKey {
+	var ret []rrsetKey
+	for _, record := range records {
+		if isApex(record, domain) || toRR(record) == nil {
+			continue
+		}
+		key := rrsetKey{
+			name:   strings.ToLower(dns.Fqdn(record.Header().Name)),
+			rrtype: record.Header().Rrtype,
+		}
+		if !slices.Contains(ret, key) {
+			ret = append(ret, key)
+		}
+	}
+	return ret
+}
+
+// rdata returns the data of the record, without its header, in a form which
+// compares equal whatever the way each provider serves it.
+func rdata(rr dns.RR) string {
+	switch rr := rr.(type) {
+	case *dns.TXT:
+		return strings.Join(rr.Txt, "")
+	case *dns.SPF:
+		return strings.Join(rr.Txt, "")
+	}
+
+	return strings.ToLower(strings.TrimPrefix(rr.String(), rr.Header().String()))
+}
+
+// answerSet returns the sorted data of the records of the message matching
+// the RRset, wherever they are in the message: below the apex, NS records
+// come in the authority section of a referral.
+func answerSet(msg *dns.Msg, key rrsetKey) []string {
+	var ret []string
+	if msg == nil {
+		return ret
+	}
+
+	for _, section := range [][]dns.RR{msg.Answer, msg.Ns} {
+		for _, rr := range section {
+			if rr.Header().Rrtype == key.rrtype && strings.EqualFold(rr.Header().Name, key.name) {
+				ret = append(ret, rdata(rr))
+			}
+		}
+	}
+	slices.Sort(ret)
+	return slices.Compact(ret)
+}
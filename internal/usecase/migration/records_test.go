@@ -0,0 +1,172 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package migration
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+func mustRR(t *testing.T, s string) happydns.Record {
+	t.Helper()
+
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rr
+}
+
+func TestMigrateRecords(t *testing.T) {
+	snapshot := []happydns.Record{
+		mustRR(t, "example.com. 3600 IN SOA ns1.old.net. hostmaster.example.com. 1 7200 3600 86400 300"),
+		mustRR(t, "example.com. 3600 IN NS ns1.old.net."),
+		mustRR(t, "example.com. 3600 IN NS ns2.old.net."),
+		mustRR(t, "www.example.com. 3600 IN A 192.0.2.1"),
+		mustRR(t, "sub.example.com. 3600 IN NS ns.elsewhere.org."),
+	}
+	target := []happydns.Record{
+		mustRR(t, "example.com. 3600 IN SOA a.new.net. hostmaster.new.net. 42 7200 3600 86400 300"),
+		mustRR(t, "example.com. 172800 IN NS A.new.net."),
+		mustRR(t, "example.com. 172800 IN NS b.new.net"),
+		mustRR(t, "old.example.com. 3600 IN A 192.0.2.99"),
+	}
+
+	got := migrateRecords(snapshot, "example.com", target)
+
+	var strs []string
+	for _, rr := range got {
+		strs = append(strs, rr.String())
+	}
+	want := []string{
+		snapshot[3].String(),
+		snapshot[4].String(),
+		target[0].String(),
+		target[1].String(),
+		target[2].String(),
+	}
+	if !slices.Equal(strs, want) {
+		t.Errorf("migrateRecords() = %v, want %v", strs, want)
+	}
+
+	if ns := apexNameservers(got, "example.com."); !slices.Equal(ns, []string{"a.new.net.", "b.new.net."}) {
+		t.Errorf("apexNameservers() = %v", ns)
+	}
+}
+
+func TestCapTTL(t *testing.T) {
+	records := []happydns.Record{
+		mustRR(t, "example.com. 86400 IN MX 10 mx.example.com."),
+		mustRR(t, "www.example.com. 60 IN A 192.0.2.1"),
+	}
+
+	if highest := capTTL(records, 300); highest != 86400 {
+		t.Errorf("capTTL() = %d, want 86400", highest)
+	}
+	if ttl := records[0].Header().Ttl; ttl != 300 {
+		t.Errorf("MX TTL = %d, want 300", ttl)
+	}
+	if ttl := records[1].Header().Ttl; ttl != 60 {
+		t.Errorf("A TTL = %d, want it left at 60", ttl)
+	}
+}
+
+func TestRRsets(t *testing.T) {
+	records := []happydns.Record{
+		mustRR(t, "example.com. 3600 IN NS ns1.old.net."),
+		mustRR(t, "www.example.com. 3600 IN A 192.0.2.1"),
+		mustRR(t, "WWW.example.com. 3600 IN A 192.0.2.2"),
+		mustRR(t, "www.example.com. 3600 IN AAAA 2001:db8::1"),
+		&happydns.TXT{Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeTXT, Class: dns.ClassINET}, Txt: "v=spf1 -all"},
+	}
+
+	got := rrsets(records, "example.com.")
+	want := []rrsetKey{
+		{"www.example.com.", dns.TypeA},
+		{"www.example.com.", dns.TypeAAAA},
+		{"example.com.", dns.TypeTXT},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("rrsets() = %v, want %v", got, want)
+	}
+}
+
+func TestAnswerSetIgnoresTTLAndSplitting(t *testing.T) {
+	key := rrsetKey{"example.com.", dns.TypeTXT}
+
+	a := new(dns.Msg)
+	a.Answer = []dns.RR{
+		mustRR(t, `example.com. 3600 IN TXT "hello world"`).(dns.RR),
+	}
+	b := new(dns.Msg)
+	b.Answer = []dns.RR{
+		mustRR(t, `EXAMPLE.com. 300 IN TXT "hello " "world"`).(dns.RR),
+		mustRR(t, "www.example.com. 300 IN A 192.0.2.1").(dns.RR),
+	}
+
+	if sa, sb := answerSet(a, key), answerSet(b, key); !slices.Equal(sa, sb) {
+		t.Errorf("answerSet() differ: %v and %v", sa, sb)
+	}
+}
+
+func TestAnswerSetReferral(t *testing.T) {
+	msg := new(dns.Msg)
+	msg.Ns = []dns.RR{
+		mustRR(t, "example.com. 172800 IN NS B.new.net.").(dns.RR),
+		mustRR(t, "example.com. 172800 IN NS a.new.net.").(dns.RR),
+	}
+
+	got := answerSet(msg, rrsetKey{"example.com.", dns.TypeNS})
+	if !slices.Equal(got, []string{"a.new.net.", "b.new.net."}) {
+		t.Errorf("answerSet() = %v", got)
+	}
+}
+
+func TestRewind(t *testing.T) {
+	tests := []struct {
+		current happydns.ProviderMigrationStep
+		to      happydns.ProviderMigrationStep
+		ok      bool
+	}{
+		{happydns.MigrationStepVerify, happydns.MigrationStepCopy, true},
+		{happydns.MigrationStepSwitch, happydns.MigrationStepLowerTTL, true},
+		{happydns.MigrationStepVerify, happydns.MigrationStepVerify, true},
+		{happydns.MigrationStepVerify, happydns.MigrationStepSwitch, false},
+		{happydns.MigrationStepDelegation, happydns.MigrationStepCopy, false},
+		{happydns.MigrationStepDelegation, happydns.MigrationStepDone, false},
+		{happydns.MigrationStepCopy, "bogus", false},
+	}
+
+	for _, tt := range tests {
+		m := &happydns.ProviderMigration{Step: tt.current}
+		err := rewind(m, tt.to)
+		if (err == nil) != tt.ok {
+			t.Errorf("rewind(%s, %s) error = %v, want ok=%v", tt.current, tt.to, err, tt.ok)
+		}
+		if err == nil && m.Step != tt.to {
+			t.Errorf("rewind(%s, %s) left the step at %s", tt.current, tt.to, m.Step)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package migration
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// defaultTTL is the TTL the records are lowered to when the user did not
+// choose one.
+const defaultTTL = 300
+
+// Service implements happydns.ProviderMigrationUsecase.
+type Service struct {
+	providers   ProviderService
+	applier     ZoneApplier
+	domains     DomainUpdater
+	logs        DomainLogStore
+	getZone     ZoneGetter
+	listRecords RecordLister
+	zoneService happydns.ZoneServiceUsecase
+	resolver    Resolver
+	now         func() time.Time
+}
+
+// NewService builds the provider migration Service.
+func NewService(
+	providers ProviderService,
+	applier ZoneApplier,
+	domains DomainUpdater,
+	logs DomainLogStore,
+	getZone ZoneGetter,
+	listRecords RecordLister,
+	zoneService happydns.ZoneServiceUsecase,
+	resolver Resolver,
+) *Service {
+	return &Service{
+		providers:   providers,
+		applier:     applier,
+		domains:     domains,
+		logs:        logs,
+		getZone:     getZone,
+		listRecords: listRecords,
+		zoneService: zoneService,
+		resolver:    resolver,
+		now:         time.Now,
+	}
+}
+
+// Get returns the state of the last migration of domain.
+func (s *Service) Get(user *happydns.User, domain *happydns.Domain) (*happydns.ProviderMigration, error) {
+	m, err := s.current(domain)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, happydns.NotFoundError{Msg: fmt.Sprintf("%s has never been migrated.", domain.DomainName)}
+	}
+	return m, nil
+}
+
+// current returns the state recorded by the last migration entry of the
+// domain log, or nil when there is none.
+func (s *Service) current(domain *happydns.Domain) (*happydns.ProviderMigration, error) {
+	logs, err := s.logs.ListDomainLogs(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	// The logs come newest first.
+	for _, entry := range logs {
+		if entry.Migration != nil {
+			return entry.Migration, nil
+		}
+	}
+	return nil, nil
+}
+
+// record appends to the domain log an entry carrying the state of the
+// migration.
+func (s *Service) record(user *happydns.User, domain *happydns.Domain, m *happydns.ProviderMigration, level int8, msg string) error {
+	state := *m
+
+	entry := happydns.NewDomainLog(user, level, msg)
+	entry.Date = s.now()
+	entry.Migration = &state
+
+	if err := s.logs.AppendDomainLog(domain, entry); err != nil {
+		return happydns.InternalError{
+			Err:         fmt.Errorf("unable to record the migration of %s: %w", domain.DomainName, err),
+			UserMessage: "Unable to record the progress of the migration. Please try again later.",
+		}
+	}
+	return nil
+}
+
+// Start begins the migration of domain to the provider of the form, and runs
+// its first step.
+func (s *Service) Start(ctx context.Context, user *happydns.User, domain *happydns.Domain, form *happydns.ProviderMigrationForm) (*happydns.ProviderMigration, error) {
+	if m, err := s.current(domain); err != nil {
+		return nil, err
+	} else if m != nil && m.InProgress() {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("a migration of %s is already in progress", domain.DomainName)}
+	}
+
+	if form.To.IsEmpty() {
+		return nil, happydns.ValidationError{Msg: "the provider to migrate to is required"}
+	}
+	if form.To.Equals(domain.ProviderId) {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("%s is already hosted by this provider", domain.DomainName)}
+	}
+
+	to, err := s.providers.GetUserProvider(ctx, user, form.To)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := s.publishedZone(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &happydns.ProviderMigration{
+		From:     domain.ProviderId,
+		To:       form.To,
+		Snapshot: snapshot.Id,
+		Step:     happydns.MigrationStepCopy,
+		TTL:      form.TTL,
+	}
+	if m.TTL == 0 {
+		m.TTL = defaultTTL
+	}
+
+	if err := s.record(user, domain, m, happydns.LOG_INFO, fmt.Sprintf("Migration to the provider %q started", to.Comment)); err != nil {
+		return nil, err
+	}
+
+	return s.Continue(ctx, user, domain, nil)
+}
+
+// Continue runs the next step of the migration in progress on domain, or
+// the one given in the form, when it comes earlier.
+func (s *Service) Continue(ctx context.Context, user *happydns.User, domain *happydns.Domain, form *happydns.ProviderMigrationContinueForm) (*happydns.ProviderMigration, error) {
+	m, err := s.current(domain)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil || !m.InProgress() {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("no migration of %s is in progress", domain.DomainName)}
+	}
+
+	if form != nil && form.Step != "" {
+		if err := rewind(m, form.Step); err != nil {
+			return nil, err
+		}
+	}
+
+	var run func(context.Context, *happydns.User, *happydns.Domain, *happydns.ProviderMigration) (int8, string, error)
+	switch m.Step {
+	case happydns.MigrationStepCopy:
+		run = s.copyZone
+	case happydns.MigrationStepVerify:
+		run = s.verify
+	case happydns.MigrationStepLowerTTL:
+		run = s.lowerTTL
+	case happydns.MigrationStepSwitch:
+		run = s.switchProvider
+	case happydns.MigrationStepDelegation:
+		run = s.trackDelegation
+	default:
+		return nil, happydns.InternalError{Err: fmt.Errorf("unknown migration step %q", m.Step)}
+	}
+
+	step := m.Step
+	level, msg, err := run(ctx, user, domain, m)
+	if err != nil {
+		// The state is recorded as it stood before the step, for the step to
+		// be run again.
+		m.Step = step
+		if logErr := s.record(user, domain, m, happydns.LOG_ERR, fmt.Sprintf("Migration step %q failed: %s", step, err.Error())); logErr != nil {
+			log.Printf("%s: %s", domain.DomainName, logErr.Error())
+		}
+		return nil, err
+	}
+
+	if err := s.record(user, domain, m, level, msg); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Abort gives the migration in progress on domain up. Before the switch, the
+// TTLs lowered at the former provider are restored.
+func (s *Service) Abort(ctx context.Context, user *happydns.User, domain *happydns.Domain) (*happydns.ProviderMigration, error) {
+	m, err := s.current(domain)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil || !m.InProgress() {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("no migration of %s is in progress", domain.DomainName)}
+	}
+
+	if m.WaitUntil != nil && stepIndex(m.Step) <= stepIndex(happydns.MigrationStepSwitch) {
+		if err := s.restoreTTL(ctx, user, domain, m); err != nil {
+			return nil, err
+		}
+	}
+
+	m.Step = happydns.MigrationStepAborted
+	if err := s.record(user, domain, m, happydns.LOG_WARN, "Migration aborted"); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// stepIndex returns the rank of the step in the migration.
+func stepIndex(step happydns.ProviderMigrationStep) int {
+	return slices.Index(happydns.ProviderMigrationSteps, step)
+}
+
+// rewind moves the migration back to an earlier step, which cannot precede
+// the switch once it happened: the domain is then served by the new
+// provider.
+func rewind(m *happydns.ProviderMigration, step happydns.ProviderMigrationStep) error {
+	to, current := stepIndex(step), stepIndex(m.Step)
+	switch {
+	case to < 0 || step == happydns.MigrationStepDone:
+		return happydns.ValidationError{Msg: fmt.Sprintf("%q is not a step that can be run", step)}
+	case to > current:
+		return happydns.ValidationError{Msg: fmt.Sprintf("the step %q cannot be run before %q", step, m.Step)}
+	case current > stepIndex(happydns.MigrationStepSwitch) && to <= stepIndex(happydns.MigrationStepSwitch):
+		return happydns.ValidationError{Msg: fmt.Sprintf("the domain already switched provider, the step %q cannot be run again", step)}
+	}
+
+	m.Step = step
+	return nil
+}
+
+// publishedZone returns the last published zone of domain.
+func (s *Service) publishedZone(domain *happydns.Domain) (*happydns.Zone, error) {
+	for _, zid := range domain.ZoneHistory {
+		zone, err := s.getZone.Get(zid)
+		if err != nil {
+			return nil, err
+		}
+		if zone.Published != nil {
+			return zone, nil
+		}
+	}
+
+	return nil, happydns.ValidationError{Msg: fmt.Sprintf("%s has never been published: there is nothing to migrate yet", domain.DomainName)}
+}
@@ -0,0 +1,409 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+	"git.happydns.org/happyDomain/services/abstract"
+)
+
+// copyZone copies the last published zone to the new provider, creating the
+// zone there when needed. The apex NS and SOA of the new provider are kept.
+func (s *Service) copyZone(ctx context.Context, user *happydns.User, domain *happydns.Domain, m *happydns.ProviderMigration) (int8, string, error) {
+	published, err := s.publishedZone(domain)
+	if err != nil {
+		return 0, "", err
+	}
+	m.Snapshot = published.Id
+
+	zone, snapshot, err := s.snapshotRecords(domain, m)
+	if err != nil {
+		return 0, "", err
+	}
+
+	m.OldNameservers = apexNameservers(snapshot, domain.DomainName)
+	if len(m.OldNameservers) == 0 {
+		return 0, "", happydns.ValidationError{Msg: fmt.Sprintf("the published zone of %s declares no name server to compare the new provider with", domain.DomainName)}
+	}
+
+	to, err := s.providers.GetUserProvider(ctx, user, m.To)
+	if err != nil {
+		return 0, "", err
+	}
+
+	if s.providers.TestDomainExistence(ctx, to, domain.DomainName) != nil {
+		if err := s.providers.CreateDomainOnProvider(ctx, to, domain.DomainName); err != nil {
+			return 0, "", happydns.ValidationError{Msg: fmt.Sprintf("unable to create %s on the new provider: %s", domain.DomainName, err.Error())}
+		}
+	}
+
+	records, err := s.targetRecords(ctx, to, domain, snapshot)
+	if err != nil {
+		return 0, "", err
+	}
+
+	m.Nameservers = apexNameservers(records, domain.DomainName)
+	if len(m.Nameservers) == 0 {
+		return 0, "", happydns.ValidationError{Msg: fmt.Sprintf("the new provider does not tell the name servers it serves %s from", domain.DomainName)}
+	}
+
+	// Copied again after the TTLs were lowered, the zone keeps them low.
+	if m.WaitUntil != nil {
+		capTTL(records, m.TTL)
+	}
+
+	applied, err := s.applier.ApplyRecords(ctx, domain, zone, to, records)
+	if err != nil {
+		return 0, "", err
+	}
+
+	m.Mismatches = nil
+	m.Step = happydns.MigrationStepVerify
+	return happydns.LOG_ACK, fmt.Sprintf("Zone copied to the provider %q, %d corrections applied", to.Comment, applied), nil
+}
+
+// verify asks the name servers of both providers about every RRset of the
+// zone, and compares their answers, whatever the TTLs.
+func (s *Service) verify(ctx context.Context, user *happydns.User, domain *happydns.Domain, m *happydns.ProviderMigration) (int8, string, error) {
+	_, snapshot, err := s.snapshotRecords(domain, m)
+	if err != nil {
+		return 0, "", err
+	}
+
+	keys := rrsets(snapshot, domain.DomainName)
+
+	m.Mismatches = nil
+	for _, key := range keys {
+		expected, err := s.ask(m.OldNameservers, key)
+		if err != nil {
+			return 0, "", err
+		}
+
+		got, err := s.ask(m.Nameservers, key)
+		if err != nil {
+			return 0, "", err
+		}
+
+		if !slices.Equal(expected, got) {
+			m.Mismatches = append(m.Mismatches, &happydns.ProviderMigrationMismatch{
+				Name:     key.name,
+				Type:     dns.TypeToString[key.rrtype],
+				Expected: expected,
+				Got:      got,
+			})
+		}
+	}
+
+	// The step stays to be run again, once the differences are fixed.
+	if len(m.Mismatches) > 0 {
+		return happydns.LOG_WARN, fmt.Sprintf("The new provider answers differently for %d of the %d RRsets of the zone", len(m.Mismatches), len(keys)), nil
+	}
+
+	m.Step = happydns.MigrationStepLowerTTL
+	return happydns.LOG_ACK, fmt.Sprintf("The new provider answers as the former one for the %d RRsets of the zone", len(keys)), nil
+}
+
+// lowerTTL caps the TTLs of the zone at both providers, and computes when
+// the records cached with their former TTLs have expired.
+func (s *Service) lowerTTL(ctx context.Context, user *happydns.User, domain *happydns.Domain, m *happydns.ProviderMigration) (int8, string, error) {
+	from, err := s.providers.GetUserProvider(ctx, user, m.From)
+	if err != nil {
+		return 0, "", err
+	}
+
+	to, err := s.providers.GetUserProvider(ctx, user, m.To)
+	if err != nil {
+		return 0, "", err
+	}
+
+	zone, snapshot, err := s.snapshotRecords(domain, m)
+	if err != nil {
+		return 0, "", err
+	}
+
+	highest := capTTL(snapshot, m.TTL)
+
+	appliedFrom, err := s.applier.ApplyRecords(ctx, domain, zone, from, snapshot)
+	if err != nil {
+		return 0, "", err
+	}
+
+	// Recorded as soon as the former provider serves the lowered TTLs, for
+	// an abort to restore them even when the new provider failed.
+	waitUntil := s.now().Add(time.Duration(highest) * time.Second)
+	m.WaitUntil = &waitUntil
+
+	records, err := s.targetRecords(ctx, to, domain, snapshot)
+	if err != nil {
+		return 0, "", err
+	}
+	capTTL(records, m.TTL)
+
+	appliedTo, err := s.applier.ApplyRecords(ctx, domain, zone, to, records)
+	if err != nil {
+		return 0, "", err
+	}
+
+	m.Step = happydns.MigrationStepSwitch
+	return happydns.LOG_ACK, fmt.Sprintf("TTLs lowered to %d seconds (%d and %d corrections applied): the switch can happen from %s", m.TTL, appliedFrom, appliedTo, waitUntil.Format(time.RFC3339)), nil
+}
+
+// switchProvider makes the new provider the one of the domain, and points
+// the apex NS of the zone being edited at its name servers.
+func (s *Service) switchProvider(ctx context.Context, user *happydns.User, domain *happydns.Domain, m *happydns.ProviderMigration) (int8, string, error) {
+	if m.WaitUntil == nil {
+		return 0, "", happydns.ValidationError{Msg: "the TTLs have not been lowered yet"}
+	}
+	if now := s.now(); now.Before(*m.WaitUntil) {
+		return 0, "", happydns.ValidationError{Msg: fmt.Sprintf("the records cached with their former TTLs expire at %s: wait until then to switch", m.WaitUntil.Format(time.RFC3339))}
+	}
+
+	// What was published since the copy is not at the new provider.
+	published, err := s.publishedZone(domain)
+	if err != nil {
+		return 0, "", err
+	}
+	if !published.Id.Equals(m.Snapshot) {
+		return 0, "", happydns.ValidationError{Msg: "the zone was published again since it was copied: run the migration again from the copy step"}
+	}
+
+	if err := s.domains.UpdateDomain(domain.Id, user, func(d *happydns.Domain) {
+		d.ProviderId = m.To
+	}); err != nil {
+		return 0, "", err
+	}
+	domain.ProviderId = m.To
+
+	if err := s.updateOrigin(user, domain, m.Nameservers); err != nil {
+		return 0, "", err
+	}
+
+	m.Step = happydns.MigrationStepDelegation
+	return happydns.LOG_ACK, fmt.Sprintf("%s is now hosted by the new provider: set its name servers at the registrar to %s", domain.DomainName, strings.Join(m.Nameservers, ", ")), nil
+}
+
+// trackDelegation asks the parent zone where it delegates the domain to.
+// Once it points at the new provider, the TTLs are restored there.
+func (s *Service) trackDelegation(ctx context.Context, user *happydns.User, domain *happydns.Domain, m *happydns.ProviderMigration) (int8, string, error) {
+	parent, err := s.parentNameservers(domain.DomainName)
+	if err != nil {
+		return 0, "", err
+	}
+
+	delegation, err := s.ask(parent, rrsetKey{name: strings.ToLower(dns.Fqdn(domain.DomainName)), rrtype: dns.TypeNS})
+	if err != nil {
+		return 0, "", err
+	}
+	m.ParentNameservers = delegation
+
+	if !slices.Equal(delegation, m.Nameservers) {
+		return happydns.LOG_INFO, fmt.Sprintf("The parent zone delegates %s to %s, not yet to the new provider", domain.DomainName, strings.Join(delegation, ", ")), nil
+	}
+
+	// A zone published since the switch came with the TTLs of the user.
+	if published, err := s.publishedZone(domain); err != nil {
+		return 0, "", err
+	} else if published.Id.Equals(m.Snapshot) {
+		to, err := s.providers.GetUserProvider(ctx, user, m.To)
+		if err != nil {
+			return 0, "", err
+		}
+
+		zone, snapshot, err := s.snapshotRecords(domain, m)
+		if err != nil {
+			return 0, "", err
+		}
+
+		records, err := s.targetRecords(ctx, to, domain, snapshot)
+		if err != nil {
+			return 0, "", err
+		}
+
+		if _, err := s.applier.ApplyRecords(ctx, domain, zone, to, records); err != nil {
+			return 0, "", err
+		}
+	}
+
+	m.Step = happydns.MigrationStepDone
+	return happydns.LOG_ACK, fmt.Sprintf("The parent zone delegates %s to the new provider: migration complete", domain.DomainName), nil
+}
+
+// restoreTTL publishes again the last published zone at the former
+// provider, with its own TTLs.
+func (s *Service) restoreTTL(ctx context.Context, user *happydns.User, domain *happydns.Domain, m *happydns.ProviderMigration) error {
+	from, err := s.providers.GetUserProvider(ctx, user, m.From)
+	if err != nil {
+		return err
+	}
+
+	published, err := s.publishedZone(domain)
+	if err != nil {
+		return err
+	}
+
+	records, err := s.listRecords.List(domain, published)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.applier.ApplyRecords(ctx, domain, published, from, records)
+	return err
+}
+
+// snapshotRecords returns the zone being migrated, and its records.
+func (s *Service) snapshotRecords(domain *happydns.Domain, m *happydns.ProviderMigration) (*happydns.Zone, []happydns.Record, error) {
+	zone, err := s.getZone.Get(m.Snapshot)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	records, err := s.listRecords.List(domain, zone)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return zone, records, nil
+}
+
+// targetRecords returns the records to publish at the given provider: those
+// of the snapshot, with the apex NS and SOA the provider currently serves.
+func (s *Service) targetRecords(ctx context.Context, provider *happydns.Provider, domain *happydns.Domain, snapshot []happydns.Record) ([]happydns.Record, error) {
+	current, err := s.providers.RetrieveZone(ctx, provider, domain.DomainName)
+	if err != nil {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("unable to retrieve %s from the provider %q: %s", domain.DomainName, provider.Comment, err.Error())}
+	}
+
+	return migrateRecords(snapshot, domain.DomainName, current), nil
+}
+
+// updateOrigin points the apex NS of the zone being edited at the given name
+// servers, for the next publication not to bring the former ones back.
+func (s *Service) updateOrigin(user *happydns.User, domain *happydns.Domain, nameservers []string) error {
+	if len(domain.ZoneHistory) == 0 {
+		return nil
+	}
+
+	zone, err := s.getZone.Get(domain.ZoneHistory[0])
+	if err != nil {
+		return err
+	}
+
+	for _, svc := range zone.Services[""] {
+		newSvc := *svc
+
+		switch origin := svc.Service.(type) {
+		case *abstract.Origin:
+			updated := *origin
+			updated.NameServers = replaceNameservers(origin.NameServers, nameservers)
+			newSvc.Service = &updated
+		case *abstract.NSOnlyOrigin:
+			updated := *origin
+			updated.NameServers = replaceNameservers(origin.NameServers, nameservers)
+			newSvc.Service = &updated
+		default:
+			continue
+		}
+
+		_, err = s.zoneService.UpdateZoneService(user, domain, zone, "", svc.Id, &newSvc)
+		return err
+	}
+
+	return nil
+}
+
+// replaceNameservers returns NS records pointing at the given name servers,
+// with the header of the former ones.
+func replaceNameservers(former []*dns.NS, nameservers []string) []*dns.NS {
+	hdr := dns.RR_Header{Rrtype: dns.TypeNS, Class: dns.ClassINET}
+	if len(former) > 0 {
+		hdr = former[0].Hdr
+	}
+
+	ret := make([]*dns.NS, len(nameservers))
+	for i, ns := range nameservers {
+		ret[i] = &dns.NS{Hdr: hdr, Ns: ns}
+	}
+	return ret
+}
+
+// ask queries the given name servers about an RRset, until one of them
+// answers.
+func (s *Service) ask(servers []string, key rrsetKey) ([]string, error) {
+	var lastErr error
+	for _, server := range servers {
+		msg, err := s.resolver.ResolveQuestion(happydns.ResolverRequest{
+			Resolver:   "custom",
+			Custom:     strings.TrimSuffix(server, "."),
+			DomainName: key.name,
+			Type:       dns.TypeToString[key.rrtype],
+		})
+		var nxdomain happydns.NotFoundError
+		if errors.As(err, &nxdomain) {
+			return nil, nil
+		} else if err != nil {
+			lastErr = err
+			continue
+		}
+
+		return answerSet(msg, key), nil
+	}
+
+	return nil, happydns.ValidationError{Msg: fmt.Sprintf("none of %s answered about %s %s: %s", strings.Join(servers, ", "), key.name, dns.TypeToString[key.rrtype], lastErr)}
+}
+
+// parentNameservers returns the name servers of the closest enclosing zone
+// of domain.
+func (s *Service) parentNameservers(domain string) ([]string, error) {
+	name := dns.Fqdn(domain)
+	for name != "." {
+		parent := "."
+		if off, end := dns.NextLabel(name, 0); !end {
+			parent = name[off:]
+		}
+
+		msg, err := s.resolver.ResolveQuestion(happydns.ResolverRequest{
+			Resolver:   "local",
+			DomainName: parent,
+			Type:       "NS",
+		})
+		var nxdomain happydns.NotFoundError
+		if err != nil && !errors.As(err, &nxdomain) {
+			return nil, fmt.Errorf("unable to find the name servers of %s: %w", parent, err)
+		} else if err == nil {
+			if servers := answerSet(msg, rrsetKey{name: strings.ToLower(parent), rrtype: dns.TypeNS}); len(servers) > 0 {
+				return servers, nil
+			}
+		}
+
+		name = parent
+	}
+
+	return nil, happydns.ValidationError{Msg: fmt.Sprintf("unable to find the parent zone of %s", domain)}
+}
@@ -0,0 +1,71 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package migration
+
+import (
+	"context"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// ProviderService reaches the providers on both sides of the migration.
+type ProviderService interface {
+	GetUserProvider(ctx context.Context, user *happydns.User, providerID happydns.Identifier) (*happydns.Provider, error)
+	TestDomainExistence(ctx context.Context, provider *happydns.Provider, domain string) error
+	CreateDomainOnProvider(ctx context.Context, provider *happydns.Provider, domain string) error
+	RetrieveZone(ctx context.Context, provider *happydns.Provider, domain string) ([]happydns.Record, error)
+}
+
+// ZoneApplier makes a provider serve the records of a zone of the domain. It
+// journals the corrections executed, and drops what it cached of the
+// provider zone, as a publication does.
+type ZoneApplier interface {
+	ApplyRecords(ctx context.Context, domain *happydns.Domain, zone *happydns.Zone, provider *happydns.Provider, records []happydns.Record) (int, error)
+}
+
+// DomainUpdater switches the provider of a Domain.
+type DomainUpdater interface {
+	UpdateDomain(domainID happydns.Identifier, user *happydns.User, updateFn func(*happydns.Domain)) error
+}
+
+// DomainLogStore keeps the state of the migrations.
+type DomainLogStore interface {
+	AppendDomainLog(domain *happydns.Domain, entry *happydns.DomainLog) error
+	ListDomainLogs(domain *happydns.Domain) ([]*happydns.DomainLog, error)
+}
+
+// ZoneGetter retrieves a Zone by its identifier.
+type ZoneGetter interface {
+	Get(zoneID happydns.Identifier) (*happydns.Zone, error)
+}
+
+// RecordLister expands a zone into its records, with absolute names.
+type RecordLister interface {
+	List(domain *happydns.Domain, zone *happydns.Zone) ([]happydns.Record, error)
+}
+
+// Resolver asks the name servers of both providers, and those of the parent
+// zone.
+type Resolver interface {
+	ResolveQuestion(happydns.ResolverRequest) (*dns.Msg, error)
+}
@@ -0,0 +1,70 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package notification
+
+import (
+	"strings"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// NotifyEvent feeds the notification pipeline with states observed outside of
+// the checker engine (e.g. incoming reports), as if checkerID had just run on
+// target. The worst state drives the notification decision, so the usual
+// per-user preferences, quiet hours and acknowledgements apply.
+func (d *Dispatcher) NotifyEvent(checkerID string, target happydns.CheckTarget, states []happydns.CheckState) {
+	if len(states) == 0 {
+		return
+	}
+
+	now := d.nowFn()
+
+	result := states[0]
+	var messages []string
+	for _, s := range states {
+		if s.Status > result.Status {
+			result = s
+		}
+		if s.Status > happydns.StatusOK {
+			messages = append(messages, s.Message)
+		}
+	}
+	if len(messages) > 1 {
+		result.Message = strings.Join(messages, "\n")
+	}
+
+	exec := &happydns.Execution{
+		CheckerID: checkerID,
+		Target:    target,
+		StartedAt: now,
+		EndedAt:   &now,
+		Status:    happydns.ExecutionDone,
+		Result:    result,
+	}
+
+	d.OnExecutionComplete(exec, &happydns.CheckEvaluation{
+		CheckerID:   checkerID,
+		Target:      target,
+		EvaluatedAt: now,
+		States:      states,
+	})
+}
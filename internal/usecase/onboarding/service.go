@@ -0,0 +1,249 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package onboarding
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// workers bounds the zones of an onboarding added at once. The calls to the
+// provider are throttled anyway, this only keeps an account with hundreds of
+// zones from holding as many goroutines.
+const workers = 4
+
+// zoneTimeout bounds the onboarding of a single zone, so that a slow provider
+// doesn't hold the whole onboarding.
+const zoneTimeout = 2 * time.Minute
+
+// Service onboards the zones hosted by the providers, keeping the progress of
+// the last onboarding of each provider in memory.
+type Service struct {
+	providers ProviderService
+	domains   DomainService
+	importer  ZoneImporter
+
+	mu          sync.Mutex
+	onboardings map[string]*happydns.Onboarding
+}
+
+// NewService builds a Service creating the domains through domains and
+// importing their zone through importer.
+func NewService(providers ProviderService, domains DomainService, importer ZoneImporter) *Service {
+	return &Service{
+		providers:   providers,
+		domains:     domains,
+		importer:    importer,
+		onboardings: map[string]*happydns.Onboarding{},
+	}
+}
+
+// Get returns the progress of the last onboarding from the provider.
+func (s *Service) Get(user *happydns.User, provider *happydns.Provider) (*happydns.Onboarding, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	onboarding, ok := s.onboardings[provider.Id.String()]
+	if !ok || !user.Id.Equals(provider.Owner) {
+		return nil, happydns.NotFoundError{Msg: "no zone was onboarded from this provider"}
+	}
+
+	return copyOnboarding(onboarding), nil
+}
+
+// Start begins adding the zones hosted by the provider, all of them or those
+// selected by the form, as domains of the user. The zones already managed
+// by the user are skipped. The onboarding runs in the background: its
+// progress is returned by Get.
+func (s *Service) Start(ctx context.Context, user *happydns.User, provider *happydns.Provider, form *happydns.OnboardingForm) (*happydns.Onboarding, error) {
+	hosted, err := s.providers.ListHostedDomains(ctx, provider)
+	if err != nil {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("unable to list the zones hosted by the provider: %s", err.Error())}
+	}
+
+	names := normalizeNames(hosted)
+
+	if form != nil && len(form.Domains) > 0 {
+		selected := normalizeNames(form.Domains)
+		for _, name := range selected {
+			if !slices.Contains(names, name) {
+				return nil, happydns.ValidationError{Msg: fmt.Sprintf("%s is not hosted by the provider", name)}
+			}
+		}
+		names = selected
+	}
+
+	return s.start(ctx, user, provider, names)
+}
+
+// start onboards the given zones, already known to be hosted by the
+// provider.
+func (s *Service) start(ctx context.Context, user *happydns.User, provider *happydns.Provider, names []string) (*happydns.Onboarding, error) {
+	domains, err := s.domains.ListUserDomains(user)
+	if err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to list the domains of %s: %w", user.Id.String(), err),
+			UserMessage: "Sorry, we are unable to list your domains now.",
+		}
+	}
+
+	managed := map[string]*happydns.Domain{}
+	for _, domain := range domains {
+		managed[strings.ToLower(domain.DomainName)] = domain
+	}
+
+	onboarding := &happydns.Onboarding{
+		ProviderId: provider.Id,
+		Started:    time.Now(),
+		Total:      len(names),
+	}
+	for _, name := range names {
+		zone := &happydns.OnboardingZone{
+			DomainName: name,
+			Status:     happydns.OnboardingPending,
+		}
+		if domain, ok := managed[name]; ok {
+			zone.Status = happydns.OnboardingSkipped
+			zone.DomainId = domain.Id
+			onboarding.Handled++
+		}
+		onboarding.Zones = append(onboarding.Zones, zone)
+	}
+
+	s.mu.Lock()
+	if current, ok := s.onboardings[provider.Id.String()]; ok && current.Finished == nil {
+		s.mu.Unlock()
+		return nil, happydns.ValidationError{Msg: "an onboarding from this provider is already in progress"}
+	}
+	s.onboardings[provider.Id.String()] = onboarding
+	ret := copyOnboarding(onboarding)
+	s.mu.Unlock()
+
+	// The onboarding outlives the request that started it.
+	go s.run(context.WithoutCancel(ctx), user, provider, onboarding)
+
+	return ret, nil
+}
+
+// run onboards the pending zones of the onboarding, workers at a time.
+func (s *Service) run(ctx context.Context, user *happydns.User, provider *happydns.Provider, onboarding *happydns.Onboarding) {
+	queue := make(chan *happydns.OnboardingZone)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for zone := range queue {
+				s.onboardZone(ctx, user, provider, onboarding, zone)
+			}
+		})
+	}
+
+	for _, zone := range onboarding.Zones {
+		if zone.Status == happydns.OnboardingPending {
+			queue <- zone
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	finished := time.Now()
+	onboarding.Finished = &finished
+}
+
+// onboardZone creates the domain of the zone and imports its records,
+// reporting the outcome in the onboarding.
+func (s *Service) onboardZone(ctx context.Context, user *happydns.User, provider *happydns.Provider, onboarding *happydns.Onboarding, zone *happydns.OnboardingZone) {
+	s.mu.Lock()
+	zone.Status = happydns.OnboardingRunning
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, zoneTimeout)
+	defer cancel()
+
+	domainId, err := s.onboard(ctx, user, provider, zone.DomainName)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zone.DomainId = domainId
+	if err != nil {
+		zone.Status = happydns.OnboardingFailed
+		zone.Error = err.Error()
+	} else {
+		zone.Status = happydns.OnboardingDone
+	}
+	onboarding.Handled++
+}
+
+func (s *Service) onboard(ctx context.Context, user *happydns.User, provider *happydns.Provider, name string) (happydns.Identifier, error) {
+	domain, err := s.domains.CreateDomain(ctx, user, &happydns.DomainCreationInput{
+		ProviderId: provider.Id,
+		DomainName: name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The domain is kept even if its records can't be imported: the import
+	// can be run again from it.
+	if _, err = s.importer.Import(ctx, user, domain); err != nil {
+		return domain.Id, fmt.Errorf("the domain was added, but its zone could not be imported: %w", err)
+	}
+
+	return domain.Id, nil
+}
+
+// normalizeNames returns the given names as FQDN, in lower case, without
+// duplicates.
+func normalizeNames(names []string) []string {
+	var ret []string
+	for _, name := range names {
+		name = strings.ToLower(dns.Fqdn(strings.TrimSpace(name)))
+		if name != "." && !slices.Contains(ret, name) {
+			ret = append(ret, name)
+		}
+	}
+	return ret
+}
+
+// copyOnboarding returns a copy of onboarding the workers won't alter. It is
+// to be called with the lock held.
+func copyOnboarding(onboarding *happydns.Onboarding) *happydns.Onboarding {
+	ret := *onboarding
+	ret.Zones = make([]*happydns.OnboardingZone, len(onboarding.Zones))
+	for i, zone := range onboarding.Zones {
+		z := *zone
+		ret.Zones[i] = &z
+	}
+	return &ret
+}
@@ -0,0 +1,254 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package onboarding
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+type fakeProviders struct {
+	hosted []string
+	err    error
+}
+
+func (f *fakeProviders) GetUserProvider(_ context.Context, _ *happydns.User, _ happydns.Identifier) (*happydns.Provider, error) {
+	return nil, errors.New("not used")
+}
+
+func (f *fakeProviders) ListHostedDomains(_ context.Context, _ *happydns.Provider) ([]string, error) {
+	return f.hosted, f.err
+}
+
+type fakeDomains struct {
+	mu      sync.Mutex
+	domains []*happydns.Domain
+}
+
+func (f *fakeDomains) CreateDomain(_ context.Context, user *happydns.User, input *happydns.DomainCreationInput) (*happydns.Domain, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if strings.HasPrefix(input.DomainName, "broken.") {
+		return nil, errors.New("unable to create")
+	}
+
+	domain, err := happydns.NewDomain(user, input.DomainName, input.ProviderId)
+	if err != nil {
+		return nil, err
+	}
+	domain.Id = happydns.Identifier(input.DomainName)
+	f.domains = append(f.domains, domain)
+	return domain, nil
+}
+
+func (f *fakeDomains) ListUserDomains(_ *happydns.User) ([]*happydns.Domain, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]*happydns.Domain{}, f.domains...), nil
+}
+
+func (f *fakeDomains) UpdateDomain(domainID happydns.Identifier, _ *happydns.User, updateFn func(*happydns.Domain)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, domain := range f.domains {
+		if domain.Id.Equals(domainID) {
+			updateFn(domain)
+			return nil
+		}
+	}
+	return happydns.ErrDomainNotFound
+}
+
+type fakeImporter struct{}
+
+func (fakeImporter) Import(_ context.Context, _ *happydns.User, domain *happydns.Domain) (*happydns.Zone, error) {
+	if strings.HasPrefix(domain.DomainName, "noimport.") {
+		return nil, errors.New("provider unavailable")
+	}
+	return &happydns.Zone{}, nil
+}
+
+func newTestService(hosted []string, domains ...*happydns.Domain) (*Service, *fakeDomains, *happydns.User, *happydns.Provider) {
+	user := &happydns.User{Id: happydns.Identifier("user")}
+	provider := &happydns.Provider{ProviderMeta: happydns.ProviderMeta{Id: happydns.Identifier("provider"), Owner: user.Id}}
+	store := &fakeDomains{domains: domains}
+
+	return NewService(&fakeProviders{hosted: hosted}, store, fakeImporter{}), store, user, provider
+}
+
+func waitOnboarding(t *testing.T, s *Service, user *happydns.User, provider *happydns.Provider) *happydns.Onboarding {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		onboarding, err := s.Get(user, provider)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if onboarding.Finished != nil {
+			return onboarding
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("the onboarding did not finish in time")
+	return nil
+}
+
+func TestStart_OnboardsAllZones(t *testing.T) {
+	s, store, user, provider := newTestService(
+		[]string{"example.com", "Example.org.", "broken.net.", "noimport.com.", "managed.com."},
+		&happydns.Domain{Id: happydns.Identifier("managed"), DomainName: "managed.com.", ProviderId: happydns.Identifier("other")},
+	)
+
+	onboarding, err := s.Start(context.Background(), user, provider, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if onboarding.Total != 5 {
+		t.Errorf("expected 5 zones, got %d", onboarding.Total)
+	}
+
+	onboarding = waitOnboarding(t, s, user, provider)
+	if onboarding.Handled != 5 {
+		t.Errorf("expected the 5 zones to be handled, got %d", onboarding.Handled)
+	}
+
+	expected := map[string]happydns.OnboardingStatus{
+		"example.com.":  happydns.OnboardingDone,
+		"example.org.":  happydns.OnboardingDone,
+		"broken.net.":   happydns.OnboardingFailed,
+		"noimport.com.": happydns.OnboardingFailed,
+		"managed.com.":  happydns.OnboardingSkipped,
+	}
+	for _, zone := range onboarding.Zones {
+		if zone.Status != expected[zone.DomainName] {
+			t.Errorf("%s: expected status %q, got %q (%s)", zone.DomainName, expected[zone.DomainName], zone.Status, zone.Error)
+		}
+		if zone.DomainName == "noimport.com." && zone.DomainId == nil {
+			t.Error("expected the domain whose import failed to be kept")
+		}
+	}
+
+	domains, _ := store.ListUserDomains(user)
+	if len(domains) != 4 {
+		t.Errorf("expected 3 domains to be added, got %d", len(domains)-1)
+	}
+}
+
+func TestStart_Selection(t *testing.T) {
+	s, store, user, provider := newTestService([]string{"example.com.", "example.org."})
+
+	if _, err := s.Start(context.Background(), user, provider, &happydns.OnboardingForm{Domains: []string{"example.net"}}); err == nil {
+		t.Fatal("expected a zone not hosted by the provider to be refused")
+	}
+
+	if _, err := s.Start(context.Background(), user, provider, &happydns.OnboardingForm{Domains: []string{"EXAMPLE.org"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	onboarding := waitOnboarding(t, s, user, provider)
+	if onboarding.Total != 1 || onboarding.Zones[0].DomainName != "example.org." {
+		t.Errorf("expected only the selected zone to be onboarded, got %+v", onboarding.Zones)
+	}
+
+	domains, _ := store.ListUserDomains(user)
+	if len(domains) != 1 {
+		t.Errorf("expected 1 domain to be added, got %d", len(domains))
+	}
+}
+
+func TestStart_ListingError(t *testing.T) {
+	s, _, user, provider := newTestService(nil)
+	s.providers = &fakeProviders{err: errors.New("the provider doesn't support domain listing")}
+
+	if _, err := s.Start(context.Background(), user, provider, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if _, err := s.Get(user, provider); err == nil {
+		t.Error("expected no onboarding to be recorded")
+	}
+}
+
+func TestWatcherSync(t *testing.T) {
+	s, store, user, provider := newTestService(
+		[]string{"kept.com.", "new.com.", "back.com."},
+		&happydns.Domain{Id: happydns.Identifier("kept"), DomainName: "kept.com.", ProviderId: happydns.Identifier("provider")},
+		&happydns.Domain{Id: happydns.Identifier("gone"), DomainName: "gone.com.", ProviderId: happydns.Identifier("provider")},
+		&happydns.Domain{Id: happydns.Identifier("back"), DomainName: "back.com.", ProviderId: happydns.Identifier("provider"), MissingFromProvider: &time.Time{}},
+		&happydns.Domain{Id: happydns.Identifier("elsewhere"), DomainName: "elsewhere.com.", ProviderId: happydns.Identifier("other")},
+	)
+	w := NewWatcher(nil, s, nil, time.Hour)
+
+	added, removed, err := w.Sync(context.Background(), user, provider)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if added != 1 || removed != 1 {
+		t.Errorf("expected 1 zone added and 1 removed, got %d and %d", added, removed)
+	}
+
+	waitOnboarding(t, s, user, provider)
+
+	domains, _ := store.ListUserDomains(user)
+	missing := map[string]bool{}
+	for _, domain := range domains {
+		missing[domain.DomainName] = domain.MissingFromProvider != nil
+	}
+
+	if _, ok := missing["new.com."]; !ok {
+		t.Error("expected the new zone to be added")
+	}
+	if !missing["gone.com."] {
+		t.Error("expected the removed zone to be flagged")
+	}
+	if missing["back.com."] || missing["kept.com."] || missing["elsewhere.com."] {
+		t.Errorf("expected only the removed zone to be flagged, got %v", missing)
+	}
+}
+
+func TestWatcherSync_EmptyListing(t *testing.T) {
+	s, store, user, provider := newTestService(
+		nil,
+		&happydns.Domain{Id: happydns.Identifier("kept"), DomainName: "kept.com.", ProviderId: happydns.Identifier("provider")},
+	)
+	w := NewWatcher(nil, s, nil, time.Hour)
+
+	if _, removed, err := w.Sync(context.Background(), user, provider); err != nil || removed != 0 {
+		t.Fatalf("expected nothing to be flagged, got %d (%v)", removed, err)
+	}
+
+	domains, _ := store.ListUserDomains(user)
+	if domains[0].MissingFromProvider != nil {
+		t.Error("expected an empty listing not to flag the domains")
+	}
+}
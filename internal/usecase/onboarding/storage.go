@@ -0,0 +1,65 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package onboarding
+
+import (
+	"context"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// ProviderService lists the zones hosted by a provider.
+type ProviderService interface {
+	GetUserProvider(ctx context.Context, user *happydns.User, providerID happydns.Identifier) (*happydns.Provider, error)
+	ListHostedDomains(ctx context.Context, provider *happydns.Provider) ([]string, error)
+}
+
+// DomainService creates the domains of the zones onboarded, and tells the
+// zones already managed.
+type DomainService interface {
+	CreateDomain(ctx context.Context, user *happydns.User, input *happydns.DomainCreationInput) (*happydns.Domain, error)
+	ListUserDomains(user *happydns.User) ([]*happydns.Domain, error)
+	UpdateDomain(domainID happydns.Identifier, user *happydns.User, updateFn func(*happydns.Domain)) error
+}
+
+// ZoneImporter imports the records of a domain from its provider.
+type ZoneImporter interface {
+	Import(ctx context.Context, user *happydns.User, domain *happydns.Domain) (*happydns.Zone, error)
+}
+
+// ProviderLister lists every Provider, for the Watcher to find those kept in
+// sync.
+type ProviderLister interface {
+	ListAllProviders() (happydns.Iterator[happydns.ProviderMessage], error)
+}
+
+// UserGetter retrieves the owner of a Provider, on whose behalf the Watcher
+// adds the domains.
+type UserGetter interface {
+	GetUser(id happydns.Identifier) (*happydns.User, error)
+}
+
+// WatcherStorage is the storage needed by the Watcher.
+type WatcherStorage interface {
+	ProviderLister
+	UserGetter
+}
@@ -0,0 +1,250 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package onboarding
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	domainlogUC "git.happydns.org/happyDomain/internal/usecase/domain_log"
+	"git.happydns.org/happyDomain/model"
+)
+
+// listTimeout bounds the listing of the zones of a single provider, so that
+// a slow provider doesn't hold the whole sweep.
+const listTimeout = 2 * time.Minute
+
+// Watcher periodically lists the zones of the providers kept in sync, adds
+// the zones created there as domains and flags the domains whose zone is no
+// longer hosted.
+type Watcher struct {
+	store     WatcherStorage
+	service   *Service
+	domainLog domainlogUC.DomainLogAppender
+	interval  time.Duration
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	done    chan struct{}
+	running bool
+}
+
+// NewWatcher builds a Watcher that runs every `interval`, onboarding the new
+// zones through service.
+func NewWatcher(store WatcherStorage, service *Service, domainLog domainlogUC.DomainLogAppender, interval time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &Watcher{
+		store:     store,
+		service:   service,
+		domainLog: domainLog,
+		interval:  interval,
+	}
+}
+
+// Start launches the watcher loop in a goroutine.
+func (w *Watcher) Start(ctx context.Context) {
+	w.mu.Lock()
+	if w.running {
+		w.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	w.cancel = cancel
+	w.done = make(chan struct{})
+	w.running = true
+	w.mu.Unlock()
+
+	go w.loop(ctx)
+}
+
+// Stop halts the watcher and waits for the current sweep to finish.
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	cancel := w.cancel
+	done := w.done
+	w.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	if done != nil {
+		<-done
+	}
+	w.mu.Lock()
+	w.running = false
+	w.mu.Unlock()
+}
+
+func (w *Watcher) loop(ctx context.Context) {
+	defer close(w.done)
+
+	w.RunOnce(ctx)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce performs a single sweep over the providers kept in sync. It
+// returns the number of zones found created and removed.
+func (w *Watcher) RunOnce(ctx context.Context) (added int, removed int) {
+	iter, err := w.store.ListAllProviders()
+	if err != nil {
+		log.Printf("Zone sync: failed to list providers: %v", err)
+		return
+	}
+
+	var providers []happydns.ProviderMeta
+	for iter.Next() {
+		if msg := iter.Item(); msg.SyncZones {
+			providers = append(providers, msg.ProviderMeta)
+		}
+	}
+	iter.Close()
+
+	for _, meta := range providers {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		user, err := w.store.GetUser(meta.Owner)
+		if err != nil {
+			log.Printf("Zone sync: unable to retrieve the owner of provider %s: %v", meta.Id.String(), err)
+			continue
+		}
+
+		provider, err := w.service.providers.GetUserProvider(ctx, user, meta.Id)
+		if err != nil {
+			log.Printf("Zone sync: unable to retrieve provider %s: %v", meta.Id.String(), err)
+			continue
+		}
+
+		a, r, err := w.Sync(ctx, user, provider)
+		if err != nil {
+			log.Printf("Zone sync: unable to sync the zones of provider %s: %v", meta.Id.String(), err)
+		}
+		added += a
+		removed += r
+	}
+
+	return
+}
+
+// Sync compares the zones hosted by the provider with the domains of the
+// user. The new zones are onboarded in the background; the domains whose
+// zone disappeared are flagged, and unflagged when it comes back. It returns
+// the number of zones found created and removed.
+func (w *Watcher) Sync(ctx context.Context, user *happydns.User, provider *happydns.Provider) (added int, removed int, err error) {
+	listCtx, cancel := context.WithTimeout(ctx, listTimeout)
+	hosted, err := w.service.providers.ListHostedDomains(listCtx, provider)
+	cancel()
+	if err != nil {
+		return 0, 0, err
+	}
+	names := normalizeNames(hosted)
+
+	domains, err := w.service.domains.ListUserDomains(user)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	managed := map[string]bool{}
+	var own []*happydns.Domain
+	for _, domain := range domains {
+		managed[strings.ToLower(domain.DomainName)] = true
+		if domain.ProviderId.Equals(provider.Id) {
+			own = append(own, domain)
+		}
+	}
+
+	// A provider listing no zone at all more likely fails than was emptied:
+	// don't flag every domain on its word.
+	if len(names) > 0 {
+		for _, domain := range own {
+			if w.flag(user, domain, slices.Contains(names, strings.ToLower(domain.DomainName))) {
+				removed++
+			}
+		}
+	}
+
+	var created []string
+	for _, name := range names {
+		if !managed[name] {
+			created = append(created, name)
+		}
+	}
+
+	if len(created) > 0 {
+		if _, err := w.service.start(ctx, user, provider, created); err != nil {
+			return 0, removed, fmt.Errorf("unable to onboard the new zones: %w", err)
+		}
+	}
+
+	return len(created), removed, nil
+}
+
+// flag records whether the zone of the domain is still hosted by its
+// provider. It returns true when the zone was found removed.
+func (w *Watcher) flag(user *happydns.User, domain *happydns.Domain, hosted bool) bool {
+	if hosted == (domain.MissingFromProvider == nil) {
+		return false
+	}
+
+	var missingSince *time.Time
+	msg, level := "The zone is hosted by the provider again.", int8(happydns.LOG_INFO)
+	if !hosted {
+		now := time.Now()
+		missingSince = &now
+		msg, level = "The zone is no longer hosted by the provider.", happydns.LOG_WARN
+	}
+
+	err := w.service.domains.UpdateDomain(domain.Id, user, func(d *happydns.Domain) {
+		d.MissingFromProvider = missingSince
+	})
+	if err != nil {
+		log.Printf("Zone sync: unable to flag %s: %v", domain.DomainName, err)
+		return false
+	}
+
+	if w.domainLog != nil {
+		if err := w.domainLog.AppendDomainLog(domain, happydns.NewDomainLog(user, level, msg)); err != nil {
+			log.Printf("Zone sync: unable to append domain log for %s: %v", domain.DomainName, err)
+		}
+	}
+
+	return !hosted
+}
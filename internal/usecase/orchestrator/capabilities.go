@@ -0,0 +1,178 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package orchestrator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/internal/helpers"
+	"git.happydns.org/happyDomain/model"
+)
+
+// ClampedTTLs gives the TTL the user wrote for the records ClampTTLs brought
+// within the bounds of the provider, by owner name and record type.
+type ClampedTTLs map[string]uint32
+
+func clampedKey(hdr *dns.RR_Header) string {
+	return strings.ToLower(hdr.Name) + " " + dns.Type(hdr.Rrtype).String()
+}
+
+// ClampTTLs returns the records with the TTLs out of the bounds of the
+// provider brought within them, so that the corrections are computed against
+// what the provider will publish. The records concerned are copies: the given
+// ones, those of the zone, are left untouched.
+func ClampTTLs(caps *happydns.ProviderCapabilities, records []happydns.Record) ([]happydns.Record, ClampedTTLs) {
+	if caps == nil || (caps.MinTTL == 0 && caps.MaxTTL == 0) {
+		return records, nil
+	}
+
+	var clamped ClampedTTLs
+	ret := make([]happydns.Record, len(records))
+	for i, rr := range records {
+		ret[i] = rr
+
+		// The SOA is in the hands of the provider.
+		hdr := rr.Header()
+		if hdr.Rrtype == dns.TypeSOA {
+			continue
+		}
+
+		ttl := hdr.Ttl
+		if caps.MinTTL > 0 && ttl < caps.MinTTL {
+			ttl = caps.MinTTL
+		} else if caps.MaxTTL > 0 && ttl > caps.MaxTTL {
+			ttl = caps.MaxTTL
+		}
+		if ttl == hdr.Ttl {
+			continue
+		}
+
+		if clamped == nil {
+			clamped = ClampedTTLs{}
+		}
+		clamped[clampedKey(hdr)] = hdr.Ttl
+
+		ret[i] = helpers.CopyRecord(rr)
+		ret[i].Header().Ttl = ttl
+	}
+
+	return ret, clamped
+}
+
+// CheckCapabilities flags the corrections publishing records the provider is
+// unable to publish as they are written. Records of a type the provider does
+// not handle, or with a TXT content longer than it accepts, make their
+// correction unpublishable; the TTLs ClampTTLs brought within its bounds are
+// told in a warning.
+func CheckCapabilities(caps *happydns.ProviderCapabilities, corrections []*happydns.Correction, clamped ClampedTTLs) {
+	if caps == nil {
+		return
+	}
+
+	for _, cr := range corrections {
+		if cr.Kind != happydns.CorrectionKindAddition && cr.Kind != happydns.CorrectionKindUpdate {
+			continue
+		}
+
+		var warnings []string
+		warn := func(format string, a ...any) {
+			if msg := fmt.Sprintf(format, a...); !slices.Contains(warnings, msg) {
+				warnings = append(warnings, msg)
+			}
+		}
+
+		for _, rr := range cr.NewRecords {
+			hdr := rr.Header()
+
+			// The SOA is in the hands of the provider.
+			if hdr.Rrtype == dns.TypeSOA {
+				continue
+			}
+
+			rrtype := dns.Type(hdr.Rrtype).String()
+			if !caps.SupportsType(rrtype) {
+				cr.Unpublishable = true
+				warn("%s records are not supported by the provider", rrtype)
+				continue
+			}
+
+			if length := txtLength(rr); caps.MaxTXTLength > 0 && length > caps.MaxTXTLength {
+				cr.Unpublishable = true
+				warn("TXT content of %d characters exceeds the %d the provider accepts", length, caps.MaxTXTLength)
+				continue
+			}
+
+			if ttl, ok := clamped[clampedKey(hdr)]; ok && ttl < hdr.Ttl {
+				warn("TTL %d raised to %d, the minimum of the provider", ttl, hdr.Ttl)
+			} else if ok && ttl > hdr.Ttl {
+				warn("TTL %d lowered to %d, the maximum of the provider", ttl, hdr.Ttl)
+			}
+		}
+
+		cr.Warning = strings.Join(warnings, "; ")
+	}
+}
+
+// publishable drops from the selection the corrections the provider is
+// unable to publish, and returns the warnings of the selected ones.
+func publishable(corrections []*happydns.Correction, wanted []happydns.Identifier) ([]happydns.Identifier, []string) {
+	byId := make(map[string]*happydns.Correction, len(corrections))
+	for _, cr := range corrections {
+		byId[string(cr.Id)] = cr
+	}
+
+	var kept []happydns.Identifier
+	var warnings []string
+	for _, id := range wanted {
+		cr, ok := byId[string(id)]
+		if ok && cr.Warning != "" {
+			if cr.Unpublishable {
+				warnings = append(warnings, fmt.Sprintf("%s: left out, %s", cr.Msg, cr.Warning))
+			} else {
+				warnings = append(warnings, fmt.Sprintf("%s: %s", cr.Msg, cr.Warning))
+			}
+		}
+		if ok && cr.Unpublishable {
+			continue
+		}
+
+		kept = append(kept, id)
+	}
+
+	return kept, warnings
+}
+
+// txtLength returns the length of the content of a TXT record, all its
+// strings together, or 0 for other records.
+func txtLength(rr happydns.Record) int {
+	switch record := rr.(type) {
+	case *dns.TXT:
+		return len(strings.Join(record.Txt, ""))
+	case *happydns.TXT:
+		return len(record.Txt)
+	}
+	return 0
+}
@@ -0,0 +1,137 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package orchestrator_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/internal/usecase/orchestrator"
+	"git.happydns.org/happyDomain/model"
+)
+
+func mustRR(t *testing.T, s string) happydns.Record {
+	t.Helper()
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		t.Fatalf("dns.NewRR(%q): %s", s, err)
+	}
+	return rr
+}
+
+func TestCheckCapabilities(t *testing.T) {
+	caps := &happydns.ProviderCapabilities{
+		RRTypes:      []string{"A", "TXT"},
+		MaxTXTLength: 10,
+		MinTTL:       300,
+		MaxTTL:       3600,
+	}
+
+	wanted := []happydns.Record{
+		mustRR(t, "www.example.com. 60 IN A 192.0.2.1"),
+		mustRR(t, "ftp.example.com. 86400 IN A 192.0.2.2"),
+	}
+	records, clamped := orchestrator.ClampTTLs(caps, wanted)
+
+	corrections := []*happydns.Correction{
+		{Id: happydns.Identifier("ok"), Kind: happydns.CorrectionKindAddition, NewRecords: []happydns.Record{mustRR(t, "example.com. 600 IN A 192.0.2.3")}},
+		{Id: happydns.Identifier("low"), Kind: happydns.CorrectionKindAddition, NewRecords: []happydns.Record{records[0]}},
+		{Id: happydns.Identifier("high"), Kind: happydns.CorrectionKindUpdate, NewRecords: []happydns.Record{records[1]}},
+		{Id: happydns.Identifier("caa"), Kind: happydns.CorrectionKindAddition, NewRecords: []happydns.Record{mustRR(t, `example.com. 600 IN CAA 0 issue "letsencrypt.org"`)}},
+		{Id: happydns.Identifier("txt"), Kind: happydns.CorrectionKindAddition, NewRecords: []happydns.Record{mustRR(t, `example.com. 600 IN TXT "0123456" "789abc"`)}},
+		{Id: happydns.Identifier("soa"), Kind: happydns.CorrectionKindUpdate, NewRecords: []happydns.Record{mustRR(t, "example.com. 3600 IN SOA ns.example.com. hostmaster.example.com. 1 7200 3600 1209600 300")}},
+		{Id: happydns.Identifier("del"), Kind: happydns.CorrectionKindDeletion, OldRecords: []happydns.Record{mustRR(t, `example.com. 600 IN CAA 0 issue "letsencrypt.org"`)}},
+	}
+
+	orchestrator.CheckCapabilities(caps, corrections, clamped)
+
+	tests := []struct {
+		id            string
+		warning       string
+		unpublishable bool
+	}{
+		{id: "ok"},
+		{id: "low", warning: "TTL 60 raised to 300"},
+		{id: "high", warning: "TTL 86400 lowered to 3600"},
+		{id: "caa", warning: "CAA records are not supported", unpublishable: true},
+		{id: "txt", warning: "13 characters exceeds the 10", unpublishable: true},
+		{id: "soa"},
+		{id: "del"},
+	}
+
+	for i, tt := range tests {
+		cr := corrections[i]
+		if tt.warning == "" && cr.Warning != "" {
+			t.Errorf("%s: unexpected warning %q", tt.id, cr.Warning)
+		} else if !strings.Contains(cr.Warning, tt.warning) {
+			t.Errorf("%s: warning = %q, want it to contain %q", tt.id, cr.Warning, tt.warning)
+		}
+		if cr.Unpublishable != tt.unpublishable {
+			t.Errorf("%s: Unpublishable = %v, want %v", tt.id, cr.Unpublishable, tt.unpublishable)
+		}
+	}
+}
+
+func TestClampTTLs(t *testing.T) {
+	caps := &happydns.ProviderCapabilities{MinTTL: 300, MaxTTL: 3600}
+
+	wanted := []happydns.Record{
+		mustRR(t, "www.example.com. 60 IN A 192.0.2.1"),
+		mustRR(t, "ftp.example.com. 86400 IN A 192.0.2.2"),
+		mustRR(t, "example.com. 600 IN A 192.0.2.3"),
+		mustRR(t, "example.com. 60 IN SOA ns.example.com. hostmaster.example.com. 1 7200 3600 1209600 300"),
+	}
+
+	records, clamped := orchestrator.ClampTTLs(caps, wanted)
+
+	for i, want := range []uint32{300, 3600, 600, 60} {
+		if got := records[i].Header().Ttl; got != want {
+			t.Errorf("%s: TTL = %d, want %d", records[i], got, want)
+		}
+	}
+	if records[2] != wanted[2] {
+		t.Error("record within bounds copied")
+	}
+	if wanted[0].Header().Ttl != 60 || wanted[1].Header().Ttl != 86400 {
+		t.Error("records of the zone altered")
+	}
+	if len(clamped) != 2 {
+		t.Errorf("clamped = %v, want the 2 records out of bounds", clamped)
+	}
+}
+
+func TestCheckCapabilities_Unknown(t *testing.T) {
+	cr := &happydns.Correction{Kind: happydns.CorrectionKindAddition, NewRecords: []happydns.Record{mustRR(t, "www.example.com. 1 IN A 192.0.2.1")}}
+
+	orchestrator.CheckCapabilities(nil, []*happydns.Correction{cr}, nil)
+	orchestrator.CheckCapabilities(&happydns.ProviderCapabilities{}, []*happydns.Correction{cr}, nil)
+
+	if records, clamped := orchestrator.ClampTTLs(&happydns.ProviderCapabilities{}, cr.NewRecords); records[0] != cr.NewRecords[0] || clamped != nil {
+		t.Errorf("records clamped without known limits: %v", records)
+	}
+
+	if cr.Warning != "" || cr.Unpublishable || cr.NewRecords[0].Header().Ttl != 1 {
+		t.Errorf("correction altered without known limits: %+v", cr)
+	}
+}
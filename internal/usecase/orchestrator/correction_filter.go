@@ -0,0 +1,68 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package orchestrator
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// MatchRecords returns a correction filter, for ApplyMatching, accepting the
+// corrections that only add or remove the given records, the TTL aside. The
+// other changes pending at the same owners are left for the user to review.
+func MatchRecords(records ...happydns.Record) func(*happydns.Correction) bool {
+	wanted := make(map[string]struct{}, len(records))
+	for _, rr := range records {
+		wanted[recordIdentity(rr)] = struct{}{}
+	}
+
+	return func(cr *happydns.Correction) bool {
+		if len(cr.OldRecords) == 0 && len(cr.NewRecords) == 0 {
+			return false
+		}
+
+		for _, records := range [][]happydns.Record{cr.OldRecords, cr.NewRecords} {
+			for _, rr := range records {
+				if _, ok := wanted[recordIdentity(rr)]; !ok {
+					return false
+				}
+			}
+		}
+		return true
+	}
+}
+
+// recordIdentity identifies a record by its owner, type and data. TXT records
+// are compared on their whole content, however it is split into strings.
+func recordIdentity(rr happydns.Record) string {
+	switch record := rr.(type) {
+	case *dns.TXT:
+		return dns.CanonicalName(record.Hdr.Name) + " TXT " + strings.Join(record.Txt, "")
+	case *happydns.TXT:
+		return dns.CanonicalName(record.Hdr.Name) + " TXT " + record.Txt
+	}
+
+	return happydns.RecordCommentKey(rr)
+}
@@ -0,0 +1,80 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package orchestrator_test
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/internal/usecase/orchestrator"
+	"git.happydns.org/happyDomain/model"
+)
+
+func TestMatchRecords(t *testing.T) {
+	selector := &happydns.TXT{
+		Hdr: dns.RR_Header{Name: "sel._domainkey.example.com.", Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 3600},
+		Txt: "v=DKIM1; k=ed25519; p=abc",
+	}
+	withdrawn := mustRR(t, `old._domainkey.example.com. 3600 IN TXT "v=DKIM1; k=ed25519; p=def"`)
+	keep := orchestrator.MatchRecords(selector, withdrawn)
+
+	tests := []struct {
+		name string
+		cr   *happydns.Correction
+		want bool
+	}{
+		{
+			name: "addition, split and with another TTL",
+			cr:   &happydns.Correction{NewRecords: []happydns.Record{mustRR(t, `sel._domainkey.example.com. 300 IN TXT "v=DKIM1; " "k=ed25519; p=abc"`)}},
+			want: true,
+		},
+		{
+			name: "removal",
+			cr:   &happydns.Correction{OldRecords: []happydns.Record{withdrawn}},
+			want: true,
+		},
+		{
+			name: "other record at the same owner",
+			cr:   &happydns.Correction{NewRecords: []happydns.Record{mustRR(t, `sel._domainkey.example.com. 3600 IN TXT "edited by hand"`)}},
+		},
+		{
+			name: "update of another record",
+			cr: &happydns.Correction{
+				OldRecords: []happydns.Record{withdrawn},
+				NewRecords: []happydns.Record{mustRR(t, `old._domainkey.example.com. 3600 IN TXT "v=DKIM1; p=xyz"`)},
+			},
+		},
+		{
+			name: "no record",
+			cr:   &happydns.Correction{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keep(tt.cr); got != tt.want {
+				t.Errorf("MatchRecords()(%s) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package orchestrator
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// derivedRecords returns, for each owner of derived, the records of rrs
+// computed from what derived gives for it. match and prefix are those given
+// to markDerived.
+func derivedRecords(rrs []happydns.Record, derived map[string]string, prefix string, match func(happydns.Record) bool) []*happydns.DerivedRecords {
+	var ret []*happydns.DerivedRecords
+	for owner, from := range derived {
+		ret = append(ret, &happydns.DerivedRecords{
+			Owner:   owner,
+			From:    prefix + from,
+			Records: recordsDataAt(rrs, owner, match),
+		})
+	}
+
+	return ret
+}
+
+// publishedDerived returns the derivations holding for the records published,
+// given those of the zone just published and those recorded on the previous
+// published zone: a derivation holds as long as the records published under
+// its owner are the ones it computed.
+func publishedDerived(published []happydns.Record, wip, prev []*happydns.DerivedRecords) []*happydns.DerivedRecords {
+	var ret []*happydns.DerivedRecords
+	for _, d := range slices.Concat(wip, prev) {
+		if findDerived(ret, d.Owner, d.From) != nil {
+			continue
+		}
+
+		if slices.Equal(recordsDataAt(published, d.Owner, derivedMatch(d.From)), d.Records) {
+			ret = append(ret, d)
+		}
+	}
+
+	return ret
+}
+
+// findDerived returns the derivation of derived under owner of the same kind
+// as from, if any.
+func findDerived(derived []*happydns.DerivedRecords, owner, from string) *happydns.DerivedRecords {
+	alias := strings.HasPrefix(from, aliasDerivation)
+	for _, d := range derived {
+		if d.Owner == owner && strings.HasPrefix(d.From, aliasDerivation) == alias {
+			return d
+		}
+	}
+
+	return nil
+}
+
+// derivedMatch returns the function matching the records computed from
+// from.
+func derivedMatch(from string) func(happydns.Record) bool {
+	if strings.HasPrefix(from, aliasDerivation) {
+		return isAddressRecord
+	}
+
+	return isSPFRecord
+}
+
+// recordsDataAt returns the sorted data of the records of rrs matched by
+// match under owner.
+func recordsDataAt(rrs []happydns.Record, owner string, match func(happydns.Record) bool) []string {
+	ret := []string{}
+	for _, rr := range rrs {
+		if dns.CanonicalName(rr.Header().Name) == owner && match(rr) {
+			ret = append(ret, recordData(rr))
+		}
+	}
+	slices.Sort(ret)
+
+	return ret
+}
+
+// recordData returns the data of rr, without its header. The text of a TXT
+// record is given whole, whatever the way it is split in strings.
+func recordData(rr happydns.Record) string {
+	switch record := rr.(type) {
+	case *happydns.TXT:
+		return record.Txt
+	case *dns.TXT:
+		return strings.Join(record.Txt, "")
+	}
+
+	return strings.TrimPrefix(rr.String(), rr.Header().String())
+}
+
+// correctionOwner returns the canonical name of the owner of the records
+// touched by cr.
+func correctionOwner(cr *happydns.Correction) (string, bool) {
+	records := cr.NewRecords
+	if len(records) == 0 {
+		records = cr.OldRecords
+	}
+	if len(records) == 0 {
+		return "", false
+	}
+
+	return dns.CanonicalName(records[0].Header().Name), true
+}
+
+// RefreshesPublished restricts keep to the corrections computed from what the
+// records of their owner derived from when published, which published
+// recorded. The corrections coming from a change the user made to the
+// record they derive from are left for them to review and publish.
+func RefreshesPublished(published *happydns.Zone, keep func(*happydns.Correction) bool) func(*happydns.Correction) bool {
+	return func(cr *happydns.Correction) bool {
+		if published == nil || !keep(cr) {
+			return false
+		}
+
+		owner, ok := correctionOwner(cr)
+		if !ok {
+			return false
+		}
+
+		d := findDerived(published.Derived, owner, cr.DerivedFrom)
+		return d != nil && d.From == cr.DerivedFrom
+	}
+}
+
+// restoreDerived puts back in rrs, under each owner of derived still
+// publishing the records computed, the record they were computed from. It
+// returns the records along with the derivations restored.
+func restoreDerived(rrs []happydns.Record, derived []*happydns.DerivedRecords) ([]happydns.Record, []*happydns.DerivedRecords) {
+	var restored []*happydns.DerivedRecords
+	for _, d := range derived {
+		match := derivedMatch(d.From)
+		if len(d.Records) == 0 || !slices.Equal(recordsDataAt(rrs, d.Owner, match), d.Records) {
+			continue
+		}
+
+		computed := func(rr happydns.Record) bool {
+			return dns.CanonicalName(rr.Header().Name) == d.Owner && match(rr)
+		}
+
+		source := sourceRecord(d, *rrs[slices.IndexFunc(rrs, computed)].Header())
+		if source == nil {
+			continue
+		}
+
+		rrs = append(slices.DeleteFunc(slices.Clone(rrs), computed), source)
+		restored = append(restored, d)
+	}
+
+	return rrs, restored
+}
+
+// sourceRecord returns the record the derivation d computes its records
+// from, under the header of one of them.
+func sourceRecord(d *happydns.DerivedRecords, hdr dns.RR_Header) happydns.Record {
+	if target, ok := strings.CutPrefix(d.From, aliasDerivation); ok {
+		hdr.Rrtype = happydns.TypeALIAS
+
+		rr := dns.TypeToRR[happydns.TypeALIAS]()
+		*rr.Header() = hdr
+		if prr, ok := rr.(*dns.PrivateRR); ok {
+			if rdata, ok := prr.Data.(happydns.TargetRdata); ok {
+				rdata.SetTarget(target)
+				return rr
+			}
+		}
+
+		return nil
+	}
+
+	hdr.Rrtype = dns.TypeTXT
+	return &happydns.TXT{Hdr: hdr, Txt: d.From}
+}
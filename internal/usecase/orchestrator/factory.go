@@ -28,6 +28,7 @@ package orchestrator
 
 import (
 	"context"
+	"time"
 
 	domainlogUC "git.happydns.org/happyDomain/internal/usecase/domain_log"
 	zoneUC "git.happydns.org/happyDomain/internal/usecase/zone"
@@ -54,6 +55,34 @@ type ZoneCorrector interface {
 	ListZoneCorrections(ctx context.Context, provider *happydns.Provider, domain *happydns.Domain, records []happydns.Record) ([]*happydns.Correction, int, error)
 }
 
+// ZoneCommenter stores a comment along the records published by the providers
+// able to, and reads them back.
+type ZoneCommenter interface {
+	ListZoneCorrectionsWithComments(ctx context.Context, provider *happydns.Provider, domain *happydns.Domain, records []happydns.Record, comments happydns.RecordComments) ([]*happydns.Correction, int, error)
+	RetrieveZoneComments(ctx context.Context, provider *happydns.Provider, name string) (happydns.RecordComments, error)
+}
+
+// AliasFlattener replaces the ALIAS records of a zone by the addresses their
+// target currently resolves to, for the providers unable to publish them. It
+// returns, along with the new records, the target of each flattened owner.
+type AliasFlattener interface {
+	FlattenAliases(ctx context.Context, rrs []happydns.Record) ([]happydns.Record, map[string]string, error)
+}
+
+// SPFFlattener replaces the SPF records of a zone by their flattened form,
+// where the includes are replaced by the addresses they currently stand for.
+// It returns, along with the new records, the SPF record written by the user
+// for each flattened owner.
+type SPFFlattener interface {
+	FlattenSPF(ctx context.Context, rrs []happydns.Record) ([]happydns.Record, map[string]string, error)
+}
+
+// ProviderJournal keeps the corrections executed against a provider, for the
+// providers having their journal enabled.
+type ProviderJournal interface {
+	Record(ctx context.Context, provider *happydns.Provider, calls []*happydns.ProviderCall) error
+}
+
 // Orchestrator aggregates the use-cases that together implement the DNS zone
 // lifecycle: importing zones from a provider, listing required corrections, and
 // applying those corrections back to the provider.
@@ -98,3 +127,35 @@ func (o *Orchestrator) SetSchedulerNotifier(notifier happydns.SchedulerDomainNot
 	o.RemoteZoneImporter.schedulerNotifier = notifier
 	o.ZoneCorrectionApplier.schedulerNotifier = notifier
 }
+
+// SetAliasFlattener sets the optional flattener publishing the ALIAS records
+// of the zones hosted by a provider lacking them.
+func (o *Orchestrator) SetAliasFlattener(flattener AliasFlattener) {
+	o.ZoneCorrectionApplier.aliasFlattener = flattener
+}
+
+// SetSPFFlattener sets the optional flattener publishing the SPF records of
+// the domains that opted in flattened.
+func (o *Orchestrator) SetSPFFlattener(flattener SPFFlattener) {
+	o.ZoneCorrectionApplier.spfFlattener = flattener
+}
+
+// SetProviderJournal sets the optional journal keeping the corrections
+// executed against the providers.
+func (o *Orchestrator) SetProviderJournal(journal ProviderJournal) {
+	o.ZoneCorrectionApplier.providerJournal = journal
+}
+
+// SetZoneCommenter sets the optional commenter publishing the comment of each
+// service along its records, and restoring them on import.
+func (o *Orchestrator) SetZoneCommenter(commenter ZoneCommenter) {
+	o.RemoteZoneImporter.zoneCommenter = commenter
+	o.ZoneCorrectionApplier.zoneCommenter = commenter
+}
+
+// SetZoneCacheTTL makes the diffs reuse the records fetched from the
+// providers for ttl. A ttl of 0 fetches them for each diff.
+func (o *Orchestrator) SetZoneCacheTTL(ttl time.Duration) {
+	lister := o.ZoneCorrectionApplier.ZoneCorrectionListerUsecase
+	lister.zoneCache = NewZoneCache(lister.zoneRetriever, ttl)
+}
@@ -0,0 +1,90 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package orchestrator
+
+import (
+	"slices"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/internal/helpers"
+	"git.happydns.org/happyDomain/model"
+	"git.happydns.org/happyDomain/services/abstract"
+)
+
+// restoreFailovers takes out of rrs the records of the failovers of the
+// published zone whose owner still publishes those of the active target, and
+// only those. It returns the remaining records along with the failovers, to
+// be imported as such rather than as the plain addresses or alias they
+// publish, which would lose their targets and state.
+func restoreFailovers(rrs []happydns.Record, published *happydns.Zone, origin string) ([]happydns.Record, map[happydns.Subdomain][]*happydns.Service) {
+	restored := map[happydns.Subdomain][]*happydns.Service{}
+	for subdomain, services := range published.Services {
+		owner := dns.CanonicalName(helpers.DomainJoin(string(subdomain), origin))
+
+		for _, s := range services {
+			fo, ok := s.Service.(*abstract.Failover)
+			if !ok || fo.ActiveTarget() == nil {
+				continue
+			}
+
+			want := failoverData(fo.ActiveTarget().Records(), owner, origin, false)
+			if len(want) == 0 || !slices.Equal(failoverData(rrs, owner, origin, true), want) {
+				continue
+			}
+
+			rrs = slices.DeleteFunc(slices.Clone(rrs), func(rr happydns.Record) bool {
+				return dns.CanonicalName(rr.Header().Name) == owner && isFailoverRecord(rr)
+			})
+			restored[subdomain] = append(restored[subdomain], s)
+		}
+	}
+
+	return rrs, restored
+}
+
+// failoverData returns the sorted types and data of the records of rrs a
+// failover publishes. With atOwner, only the records under owner are
+// considered; the others are taken as published there.
+func failoverData(rrs []happydns.Record, owner, origin string, atOwner bool) []string {
+	ret := []string{}
+	for _, rr := range rrs {
+		if !isFailoverRecord(rr) || (atOwner && dns.CanonicalName(rr.Header().Name) != owner) {
+			continue
+		}
+
+		data := recordData(rr)
+		if cname, ok := rr.(*dns.CNAME); ok {
+			data = dns.CanonicalName(helpers.DomainJoin(cname.Target, origin))
+		}
+		ret = append(ret, dns.TypeToString[rr.Header().Rrtype]+" "+data)
+	}
+	slices.Sort(ret)
+
+	return ret
+}
+
+// isFailoverRecord matches the records a failover target publishes.
+func isFailoverRecord(rr happydns.Record) bool {
+	rrtype := rr.Header().Rrtype
+	return rrtype == dns.TypeA || rrtype == dns.TypeAAAA || rrtype == dns.TypeCNAME
+}
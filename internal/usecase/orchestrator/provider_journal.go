@@ -0,0 +1,105 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// newProviderCall builds the journal entry of a correction executed at
+// started, which returned err.
+func newProviderCall(domain *happydns.Domain, zone *happydns.Zone, cr *happydns.Correction, started time.Time, err error) *happydns.ProviderCall {
+	call := &happydns.ProviderCall{
+		DomainId: domain.Id,
+		ZoneId:   zone.Id,
+		Msg:      cr.Msg,
+		Kind:     cr.Kind,
+		Date:     started,
+		Duration: time.Since(started),
+		Success:  err == nil,
+	}
+
+	if err != nil {
+		call.Error = err.Error()
+	}
+
+	if cr.Trace != nil {
+		call.Request = marshalTrace(domain, cr.Trace.Request)
+		call.Response = marshalTrace(domain, cr.Trace.Response)
+	}
+
+	return call
+}
+
+// marshalTrace encodes what an adapter traced. What cannot be encoded is left
+// out of the journal rather than failing the publication.
+func marshalTrace(domain *happydns.Domain, v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("%s: unable to journal a provider trace: %s", domain.DomainName, err)
+		return nil
+	}
+
+	return raw
+}
+
+// recordCalls hands the executed corrections to the provider journal, linked
+// to the snapshot they published, if any. The journal is best effort:
+// failures are only logged.
+func (uc *ZoneCorrectionApplierUsecase) recordCalls(ctx context.Context, user *happydns.User, domain *happydns.Domain, calls []*happydns.ProviderCall, snapshotId happydns.Identifier) {
+	if uc.providerJournal == nil || len(calls) == 0 {
+		return
+	}
+
+	provider, err := uc.providerService.GetUserProvider(ctx, user, domain.ProviderId)
+	if err != nil {
+		log.Printf("%s: unable to journal the provider calls: %s", domain.DomainName, err)
+		return
+	}
+
+	uc.recordProviderCalls(ctx, provider, domain, calls, snapshotId)
+}
+
+// recordProviderCalls is recordCalls for the corrections executed against
+// provider, which may not be the one of the domain.
+func (uc *ZoneCorrectionApplierUsecase) recordProviderCalls(ctx context.Context, provider *happydns.Provider, domain *happydns.Domain, calls []*happydns.ProviderCall, snapshotId happydns.Identifier) {
+	if uc.providerJournal == nil || len(calls) == 0 {
+		return
+	}
+
+	for _, call := range calls {
+		call.SnapshotId = snapshotId
+	}
+
+	if err := uc.providerJournal.Record(ctx, provider, calls); err != nil {
+		log.Printf("%s: unable to journal the provider calls: %s", domain.DomainName, err)
+	}
+}
@@ -34,11 +34,18 @@ import (
 // from the provider and delegates to ZoneImporterUsecase to persist them.  It
 // also appends a domain log entry on success.
 type RemoteZoneImporterUsecase struct {
-	appendDomainLog    domainlogUC.DomainLogAppender
-	providerService    ProviderGetter
-	zoneImporter       happydns.ZoneImporterUsecase
-	zoneRetriever      ZoneRetriever
-	schedulerNotifier  happydns.SchedulerDomainNotifier
+	appendDomainLog   domainlogUC.DomainLogAppender
+	providerService   ProviderGetter
+	zoneImporter      happydns.ZoneImporterUsecase
+	zoneRetriever     ZoneRetriever
+	schedulerNotifier happydns.SchedulerDomainNotifier
+	zoneCommenter     ZoneCommenter
+}
+
+// commentedZoneImporter is implemented by the zone importers able to restore
+// the comments stored along the records.
+type commentedZoneImporter interface {
+	ImportWithComments(*happydns.User, *happydns.Domain, []happydns.Record, happydns.RecordComments) (*happydns.Zone, error)
 }
 
 // NewRemoteZoneImporterUsecase creates a RemoteZoneImporterUsecase wired to
@@ -71,9 +78,24 @@ func (uc *RemoteZoneImporterUsecase) Import(ctx context.Context, user *happydns.
 		return nil, fmt.Errorf("unable to retrieve the zone from server: %w", err)
 	}
 
-	myZone, err := uc.zoneImporter.Import(user, domain, zone)
-	if err != nil {
-		return nil, err
+	var myZone *happydns.Zone
+	if importer, ok := uc.zoneImporter.(commentedZoneImporter); ok && uc.zoneCommenter != nil {
+		// The comments only complete the zone: it is imported without them
+		// when they can't be read.
+		comments, err := uc.zoneCommenter.RetrieveZoneComments(ctx, provider, domain.DomainName)
+		if err != nil {
+			log.Printf("%s: unable to retrieve the record comments: %s", domain.DomainName, err.Error())
+		}
+
+		myZone, err = importer.ImportWithComments(user, domain, zone, comments)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		myZone, err = uc.zoneImporter.Import(user, domain, zone)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if err := uc.appendDomainLog.AppendDomainLog(domain, happydns.NewDomainLog(user, happydns.LOG_INFO, fmt.Sprintf("Zone imported from provider API: %s", myZone.Id.String()))); err != nil {
@@ -0,0 +1,204 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"git.happydns.org/happyDomain/internal/helpers"
+	"git.happydns.org/happyDomain/internal/metrics"
+	"git.happydns.org/happyDomain/model"
+)
+
+// ZoneCache keeps, for a short while, the records last fetched from the
+// provider of each domain, so that successive diffs do not each wait for the
+// provider API. A nil ZoneCache keeps nothing.
+type ZoneCache struct {
+	retriever ZoneRetriever
+	ttl       time.Duration
+	clock     func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*zoneCacheEntry
+	fetches singleflight.Group
+	// generation changes on each invalidation, telling the fetches
+	// started before it not to fill the cache with what they get.
+	generation uint64
+}
+
+type zoneCacheEntry struct {
+	records   []happydns.Record
+	fetchedAt time.Time
+}
+
+// NewZoneCache creates a ZoneCache keeping the records retrieved through
+// retriever for ttl. It returns nil when ttl is not positive.
+func NewZoneCache(retriever ZoneRetriever, ttl time.Duration) *ZoneCache {
+	if ttl <= 0 {
+		return nil
+	}
+
+	return &ZoneCache{
+		retriever: retriever,
+		ttl:       ttl,
+		clock:     time.Now,
+		entries:   map[string]*zoneCacheEntry{},
+	}
+}
+
+// zoneCacheKey identifies the records of the zone name hosted by provider.
+func zoneCacheKey(providerId happydns.Identifier, name string) string {
+	return providerId.String() + "|" + strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// Retrieve returns the records of the zone name hosted by provider. Unless
+// fresh is set, records fetched less than the TTL ago are returned without
+// calling the provider, and concurrent lookups of the same zone wait for a
+// single fetch.
+func (c *ZoneCache) Retrieve(ctx context.Context, provider *happydns.Provider, name string, fresh bool) ([]happydns.Record, error) {
+	key := zoneCacheKey(provider.Id, name)
+
+	var entry *zoneCacheEntry
+	var err error
+	if fresh {
+		metrics.ProviderZoneCacheLookupsTotal.WithLabelValues("bypass").Inc()
+		entry, err = c.fetch(ctx, key, provider, name)
+	} else if entry = c.get(key); entry != nil {
+		metrics.ProviderZoneCacheLookupsTotal.WithLabelValues("hit").Inc()
+	} else {
+		metrics.ProviderZoneCacheLookupsTotal.WithLabelValues("miss").Inc()
+
+		var v any
+		v, err, _ = c.fetches.Do(key, func() (any, error) {
+			return c.fetch(ctx, key, provider, name)
+		})
+		if err == nil {
+			entry = v.(*zoneCacheEntry)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return copyRecords(entry.records), nil
+}
+
+// FetchedAt returns the time the cached records of the zone name hosted by
+// provider were fetched at, if they are still in the cache.
+func (c *ZoneCache) FetchedAt(providerId happydns.Identifier, name string) (time.Time, bool) {
+	if c == nil {
+		return time.Time{}, false
+	}
+
+	entry := c.get(zoneCacheKey(providerId, name))
+	if entry == nil {
+		return time.Time{}, false
+	}
+
+	return entry.fetchedAt, true
+}
+
+// Invalidate forgets the records of the zone name hosted by provider, so
+// that the next diff fetches them again.
+func (c *ZoneCache) Invalidate(providerId happydns.Identifier, name string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, zoneCacheKey(providerId, name))
+	c.generation++
+}
+
+func (c *ZoneCache) get(key string) *zoneCacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+
+	if c.clock().Sub(entry.fetchedAt) >= c.ttl {
+		delete(c.entries, key)
+		return nil
+	}
+
+	return entry
+}
+
+func (c *ZoneCache) fetch(ctx context.Context, key string, provider *happydns.Provider, name string) (*zoneCacheEntry, error) {
+	c.mu.Lock()
+	generation := c.generation
+	c.mu.Unlock()
+
+	fetchedAt := c.clock()
+
+	records, err := c.retriever.RetrieveZone(ctx, provider, name)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &zoneCacheEntry{
+		records:   copyRecords(records),
+		fetchedAt: fetchedAt,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Take the opportunity to drop the expired entries: nothing else
+	// would for the domains no one looks at anymore.
+	now := c.clock()
+	for k, e := range c.entries {
+		if now.Sub(e.fetchedAt) >= c.ttl {
+			delete(c.entries, k)
+		}
+	}
+
+	if generation == c.generation {
+		c.entries[key] = entry
+	}
+
+	return entry, nil
+}
+
+// copyRecords returns a deep copy of rrs, so that the callers altering the
+// records they are given do not alter the cache.
+func copyRecords(rrs []happydns.Record) []happydns.Record {
+	if rrs == nil {
+		return nil
+	}
+
+	ret := make([]happydns.Record, len(rrs))
+	for i, rr := range rrs {
+		ret[i] = helpers.CopyRecord(rr)
+	}
+	return ret
+}
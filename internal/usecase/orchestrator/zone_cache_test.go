@@ -0,0 +1,185 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/internal/usecase/orchestrator"
+	"git.happydns.org/happyDomain/model"
+)
+
+// countingZoneRetriever implements ZoneRetriever, counting the fetches.
+type countingZoneRetriever struct {
+	mu      sync.Mutex
+	calls   int
+	records []happydns.Record
+	err     error
+}
+
+func (m *countingZoneRetriever) RetrieveZone(_ context.Context, _ *happydns.Provider, _ string) ([]happydns.Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls++
+	return m.records, m.err
+}
+
+func newCacheTestRecords() []happydns.Record {
+	return []happydns.Record{
+		&dns.A{
+			Hdr: dns.RR_Header{Name: "www.example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 3600},
+			A:   []byte{192, 0, 2, 1},
+		},
+	}
+}
+
+func TestZoneCache_DisabledWithoutTTL(t *testing.T) {
+	if cache := orchestrator.NewZoneCache(&countingZoneRetriever{}, 0); cache != nil {
+		t.Fatal("expected no cache for a zero TTL")
+	}
+
+	var cache *orchestrator.ZoneCache
+	if _, ok := cache.FetchedAt(happydns.Identifier("p"), "example.com."); ok {
+		t.Error("expected a nil cache to hold nothing")
+	}
+	cache.Invalidate(happydns.Identifier("p"), "example.com.")
+}
+
+func TestZoneCache_ReusesRecords(t *testing.T) {
+	retriever := &countingZoneRetriever{records: newCacheTestRecords()}
+	cache := orchestrator.NewZoneCache(retriever, time.Minute)
+	provider := &happydns.Provider{ProviderMeta: happydns.ProviderMeta{Id: happydns.Identifier("p")}}
+
+	before := time.Now()
+	for range 3 {
+		records, err := cache.Retrieve(context.Background(), provider, "example.com.", false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(records) != 1 {
+			t.Fatalf("expected 1 record, got %d", len(records))
+		}
+	}
+
+	if retriever.calls != 1 {
+		t.Errorf("expected a single fetch, got %d", retriever.calls)
+	}
+
+	fetchedAt, ok := cache.FetchedAt(provider.Id, "EXAMPLE.com")
+	if !ok {
+		t.Fatal("expected the records to be cached")
+	}
+	if fetchedAt.Before(before) {
+		t.Errorf("unexpected fetch time %s", fetchedAt)
+	}
+}
+
+func TestZoneCache_ReturnsCopies(t *testing.T) {
+	retriever := &countingZoneRetriever{records: newCacheTestRecords()}
+	cache := orchestrator.NewZoneCache(retriever, time.Minute)
+	provider := &happydns.Provider{ProviderMeta: happydns.ProviderMeta{Id: happydns.Identifier("p")}}
+
+	records, err := cache.Retrieve(context.Background(), provider, "example.com.", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	records[0].Header().Ttl = 42
+
+	records, err = cache.Retrieve(context.Background(), provider, "example.com.", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if records[0].Header().Ttl != 3600 {
+		t.Errorf("expected the cached record to be left untouched, got TTL %d", records[0].Header().Ttl)
+	}
+}
+
+func TestZoneCache_FreshAndInvalidate(t *testing.T) {
+	retriever := &countingZoneRetriever{records: newCacheTestRecords()}
+	cache := orchestrator.NewZoneCache(retriever, time.Minute)
+	provider := &happydns.Provider{ProviderMeta: happydns.ProviderMeta{Id: happydns.Identifier("p")}}
+
+	if _, err := cache.Retrieve(context.Background(), provider, "example.com.", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := cache.Retrieve(context.Background(), provider, "example.com.", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if retriever.calls != 2 {
+		t.Errorf("expected a fresh read to fetch again, got %d fetches", retriever.calls)
+	}
+
+	cache.Invalidate(provider.Id, "example.com.")
+	if _, ok := cache.FetchedAt(provider.Id, "example.com."); ok {
+		t.Error("expected the records to be forgotten")
+	}
+
+	if _, err := cache.Retrieve(context.Background(), provider, "example.com.", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if retriever.calls != 3 {
+		t.Errorf("expected an invalidation to fetch again, got %d fetches", retriever.calls)
+	}
+}
+
+func TestZoneCache_Expires(t *testing.T) {
+	retriever := &countingZoneRetriever{records: newCacheTestRecords()}
+	cache := orchestrator.NewZoneCache(retriever, 10*time.Millisecond)
+	provider := &happydns.Provider{ProviderMeta: happydns.ProviderMeta{Id: happydns.Identifier("p")}}
+
+	if _, err := cache.Retrieve(context.Background(), provider, "example.com.", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := cache.FetchedAt(provider.Id, "example.com."); ok {
+		t.Error("expected the records to have expired")
+	}
+	if _, err := cache.Retrieve(context.Background(), provider, "example.com.", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if retriever.calls != 2 {
+		t.Errorf("expected expired records to be fetched again, got %d fetches", retriever.calls)
+	}
+}
+
+func TestZoneCache_DoesNotKeepErrors(t *testing.T) {
+	fetchErr := errors.New("provider unavailable")
+	retriever := &countingZoneRetriever{err: fetchErr}
+	cache := orchestrator.NewZoneCache(retriever, time.Minute)
+	provider := &happydns.Provider{ProviderMeta: happydns.ProviderMeta{Id: happydns.Identifier("p")}}
+
+	if _, err := cache.Retrieve(context.Background(), provider, "example.com.", false); !errors.Is(err, fetchErr) {
+		t.Fatalf("expected %v, got %v", fetchErr, err)
+	}
+	if _, ok := cache.FetchedAt(provider.Id, "example.com."); ok {
+		t.Error("expected a failed fetch not to be cached")
+	}
+}
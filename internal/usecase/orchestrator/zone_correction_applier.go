@@ -41,14 +41,15 @@ import (
 // in the domain history. The WIP zone at ZoneHistory[0] is never modified.
 type ZoneCorrectionApplierUsecase struct {
 	*ZoneCorrectionListerUsecase
-	appendDomainLog    domainlogUC.DomainLogAppender
-	domainUpdater      DomainUpdater
-	zoneCreator        *zoneUC.CreateZoneUsecase
-	zoneGetter         *zoneUC.GetZoneUsecase
-	zoneRetriever      ZoneRetriever
-	zoneUpdater        *zoneUC.UpdateZoneUsecase
-	schedulerNotifier  happydns.SchedulerDomainNotifier
-	clock              func() time.Time
+	appendDomainLog   domainlogUC.DomainLogAppender
+	domainUpdater     DomainUpdater
+	zoneCreator       *zoneUC.CreateZoneUsecase
+	zoneGetter        *zoneUC.GetZoneUsecase
+	zoneRetriever     ZoneRetriever
+	zoneUpdater       *zoneUC.UpdateZoneUsecase
+	schedulerNotifier happydns.SchedulerDomainNotifier
+	providerJournal   ProviderJournal
+	clock             func() time.Time
 }
 
 // NewZoneCorrectionApplierUsecase creates a ZoneCorrectionApplierUsecase with
@@ -77,35 +78,94 @@ func NewZoneCorrectionApplierUsecase(
 
 // computeExecutableCorrections computes the executable corrections for the
 // given selection. It performs the diff, builds the target record set, and asks
-// the provider what it would execute to reach that target state.
+// the provider what it would execute to reach that target state. The diff is
+// computed from cached provider records unless fresh is set. The corrections
+// the provider is unable to publish are left out of the selection, with a
+// warning. It also returns the records of the zone computed from another one.
 func (uc *ZoneCorrectionApplierUsecase) computeExecutableCorrections(
 	ctx context.Context,
 	user *happydns.User,
 	domain *happydns.Domain,
 	zone *happydns.Zone,
 	wantedCorrections []happydns.Identifier,
-) (execCorrections []*happydns.Correction, targetRecords []happydns.Record, providerRecords []happydns.Record, nbDiffs int, err error) {
+	fresh bool,
+) (execCorrections []*happydns.Correction, targetRecords []happydns.Record, providerRecords []happydns.Record, derived []*happydns.DerivedRecords, nbDiffs int, warnings []string, err error) {
 	// Step 1: Compute the diff and get provider/WIP records.
-	corrections, providerRecords, _, nbDiffs, err := uc.listWithRecords(ctx, user, domain, zone)
+	corrections, providerRecords, derived, nbDiffs, err := uc.listWithRecords(ctx, user, domain, zone, fresh)
 	if err != nil {
-		return nil, nil, nil, nbDiffs, err
+		return nil, nil, nil, nil, nbDiffs, nil, err
 	}
 
 	// Step 2: Build target records from selected corrections.
+	wantedCorrections, warnings = publishable(corrections, wantedCorrections)
 	targetRecords = adapter.BuildTargetRecords(providerRecords, corrections, wantedCorrections)
+	commented := selectedRecords(corrections, wantedCorrections)
 
 	// Step 3: Get executable corrections from the provider for the target state.
 	provider, err := uc.providerService.GetUserProvider(ctx, user, domain.ProviderId)
 	if err != nil {
-		return nil, nil, nil, nbDiffs, err
+		return nil, nil, nil, nil, nbDiffs, nil, err
 	}
 
-	execCorrections, nbDiffs, err = uc.zoneCorrector.ListZoneCorrections(ctx, provider, domain, targetRecords)
+	execCorrections, nbDiffs, err = uc.listZoneCorrections(ctx, provider, domain, zone, targetRecords, commented)
 	if err != nil {
-		return nil, nil, nil, nbDiffs, fmt.Errorf("unable to compute executable corrections: %w", err)
+		return nil, nil, nil, nil, nbDiffs, nil, fmt.Errorf("unable to compute executable corrections: %w", err)
 	}
 
-	return execCorrections, targetRecords, providerRecords, nbDiffs, nil
+	return execCorrections, targetRecords, providerRecords, derived, nbDiffs, warnings, nil
+}
+
+// listZoneCorrections asks the provider for the corrections reaching the
+// target records, along with, when a commenter is set, the comment of the
+// service each of the commented records comes from. The other records keep
+// the comment they have at the provider. A nil commented takes them all.
+func (uc *ZoneCorrectionApplierUsecase) listZoneCorrections(
+	ctx context.Context,
+	provider *happydns.Provider,
+	domain *happydns.Domain,
+	zone *happydns.Zone,
+	targetRecords []happydns.Record,
+	commented []happydns.Record,
+) ([]*happydns.Correction, int, error) {
+	if uc.zoneCommenter == nil {
+		return uc.zoneCorrector.ListZoneCorrections(ctx, provider, domain, targetRecords)
+	}
+
+	comments, err := uc.listRecords.Comments(domain, zone)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if commented != nil {
+		selected := happydns.RecordComments{}
+		for _, rr := range commented {
+			key := happydns.RecordCommentKey(rr)
+			if comment, ok := comments[key]; ok {
+				selected[key] = comment
+			}
+		}
+		comments = selected
+	}
+
+	return uc.zoneCommenter.ListZoneCorrectionsWithComments(ctx, provider, domain, targetRecords, comments)
+}
+
+// selectedRecords returns the records the selected corrections publish. It is
+// never nil: selecting nothing comments no record.
+func selectedRecords(corrections []*happydns.Correction, selectedIDs []happydns.Identifier) []happydns.Record {
+	selected := make(map[string]bool, len(selectedIDs))
+	for _, id := range selectedIDs {
+		selected[string(id)] = true
+	}
+
+	records := []happydns.Record{}
+	for _, cr := range corrections {
+		if selected[string(cr.Id)] {
+			records = append(records, cr.NewRecords...)
+		}
+	}
+
+	return records
 }
 
 // Prepare computes the executable corrections for the given selection without
@@ -118,14 +178,16 @@ func (uc *ZoneCorrectionApplierUsecase) Prepare(
 	zone *happydns.Zone,
 	form *happydns.PrepareZoneForm,
 ) (*happydns.PrepareZoneResponse, error) {
-	execCorrections, _, _, nbDiffs, err := uc.computeExecutableCorrections(ctx, user, domain, zone, form.WantedCorrections)
+	execCorrections, _, _, _, nbDiffs, warnings, err := uc.computeExecutableCorrections(ctx, user, domain, zone, form.WantedCorrections, false)
 	if err != nil {
 		return nil, err
 	}
 
 	return &happydns.PrepareZoneResponse{
-		Corrections: execCorrections,
-		NbDiffs:     nbDiffs,
+		Corrections:              execCorrections,
+		NbDiffs:                  nbDiffs,
+		ProviderRecordsFetchedAt: uc.ProviderRecordsFetchedAt(domain),
+		Warnings:                 warnings,
 	}, nil
 }
 
@@ -148,16 +210,38 @@ func (uc *ZoneCorrectionApplierUsecase) Apply(
 	zone *happydns.Zone,
 	form *happydns.ApplyZoneForm,
 ) (*happydns.Zone, error) {
-	executableCorrections, targetRecords, providerRecords, _, err := uc.computeExecutableCorrections(ctx, user, domain, zone, form.WantedCorrections)
+	// The corrections sent to the provider are never computed from stale
+	// records.
+	executableCorrections, targetRecords, providerRecords, derived, _, warnings, err := uc.computeExecutableCorrections(ctx, user, domain, zone, form.WantedCorrections, true)
 	if err != nil {
 		return nil, err
 	}
 
+	for _, warning := range warnings {
+		if logErr := uc.appendDomainLog.AppendDomainLog(domain, happydns.NewDomainLog(user, happydns.LOG_WARN, warning)); logErr != nil {
+			log.Printf("unable to append domain log for %s: %s", domain.DomainName, logErr.Error())
+		}
+	}
+
+	// Whatever got applied, what the provider publishes has changed.
+	defer uc.InvalidateProviderRecords(domain)
+
+	// Whatever happens next, the corrections executed go to the journal, with
+	// the snapshot they led to if it gets created.
+	var calls []*happydns.ProviderCall
+	var snapshotId happydns.Identifier
+	defer func() {
+		uc.recordCalls(ctx, user, domain, calls, snapshotId)
+	}()
+
 	// Step 4: Execute all corrections.
 	appliedCount := 0
 	for _, cr := range executableCorrections {
 		log.Printf("%s: apply correction: %s", domain.DomainName, cr.Msg)
-		if corrErr := cr.F(); corrErr != nil {
+		started := time.Now()
+		corrErr := cr.F()
+		calls = append(calls, newProviderCall(domain, zone, cr, started, corrErr))
+		if corrErr != nil {
 			log.Printf("%s: unable to apply correction: %s", domain.DomainName, corrErr.Error())
 			if logErr := uc.appendDomainLog.AppendDomainLog(domain, happydns.NewDomainLog(user, happydns.LOG_ERR, fmt.Sprintf("Failed record update (%s): %s", cr.Msg, corrErr.Error()))); logErr != nil {
 				log.Printf("unable to append domain log for %s: %s", domain.DomainName, logErr.Error())
@@ -215,6 +299,15 @@ func (uc *ZoneCorrectionApplierUsecase) Apply(
 		}
 	}
 
+	// The derived records left unchanged still come from what they came from
+	// when last published.
+	var prevDerived []*happydns.DerivedRecords
+	if prevPublished, prevErr := uc.lastPublishedZone(domain); prevErr != nil {
+		log.Printf("%s: unable to load the last published zone: %s (its derived records will be forgotten)", domain.DomainName, prevErr)
+	} else if prevPublished != nil {
+		prevDerived = prevPublished.Derived
+	}
+
 	now := uc.clock()
 
 	// Compute propagation times for changed services on the snapshot.
@@ -229,6 +322,7 @@ func (uc *ZoneCorrectionApplierUsecase) Apply(
 			CommitDate:   &now,
 			Published:    &now,
 			ParentZone:   zone.ParentZone,
+			Derived:      publishedDerived(publishedRecords, derived, prevDerived),
 		},
 		Services: services,
 	}
@@ -240,6 +334,7 @@ func (uc *ZoneCorrectionApplierUsecase) Apply(
 			UserMessage: "Sorry, we are unable to create the published zone snapshot.",
 		}
 	}
+	snapshotId = snapshot.Id
 
 	// Update the parent zone of the WIP zone
 	zone.ParentZone = &snapshot.Id
@@ -296,6 +391,97 @@ func (uc *ZoneCorrectionApplierUsecase) Apply(
 	return snapshot, nil
 }
 
+// ApplyMatching publishes only the corrections accepted by keep, leaving every
+// other pending change of the zone for the user to review. It is meant for the
+// changes happyDomain derives on its own (rotated keys, refreshed records...)
+// and returns a nil zone when no correction matched.
+func (uc *ZoneCorrectionApplierUsecase) ApplyMatching(
+	ctx context.Context,
+	user *happydns.User,
+	domain *happydns.Domain,
+	zone *happydns.Zone,
+	commitMsg string,
+	keep func(*happydns.Correction) bool,
+) (*happydns.Zone, error) {
+	corrections, _, err := uc.List(ctx, user, domain, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	var wanted []happydns.Identifier
+	for _, cr := range corrections {
+		if keep(cr) {
+			wanted = append(wanted, cr.Id)
+		}
+	}
+
+	if len(wanted) == 0 {
+		return nil, nil
+	}
+
+	return uc.Apply(ctx, user, domain, zone, &happydns.ApplyZoneForm{
+		WantedCorrections: wanted,
+		CommitMsg:         commitMsg,
+	})
+}
+
+// ApplyRecords makes provider serve records for domain, provider being the
+// one of the domain or not, as happens during a migration. The records are
+// those of zone, a zone already in the history of the domain: no snapshot is
+// created. The TTLs out of the bounds of provider are brought within them.
+// As with Apply, the corrections executed go to the journal of provider,
+// linked to zone, and the records cached for provider are dropped. It
+// returns the number of corrections applied.
+func (uc *ZoneCorrectionApplierUsecase) ApplyRecords(
+	ctx context.Context,
+	domain *happydns.Domain,
+	zone *happydns.Zone,
+	provider *happydns.Provider,
+	records []happydns.Record,
+) (int, error) {
+	records, _ = ClampTTLs(providerReg.GetProviderCapabilities(provider.Type), records)
+
+	corrections, _, err := uc.listZoneCorrections(ctx, provider, domain, zone, records, nil)
+	if err != nil {
+		return 0, fmt.Errorf("unable to compute the corrections for the provider %q: %w", provider.Comment, err)
+	}
+
+	defer uc.zoneCache.Invalidate(provider.Id, domain.DomainName)
+
+	var calls []*happydns.ProviderCall
+	defer func() {
+		uc.recordProviderCalls(ctx, provider, domain, calls, zone.Id)
+	}()
+
+	for i, cr := range corrections {
+		log.Printf("%s: apply correction: %s", domain.DomainName, cr.Msg)
+		started := time.Now()
+		corrErr := cr.F()
+		calls = append(calls, newProviderCall(domain, zone, cr, started, corrErr))
+		if corrErr != nil {
+			return i, happydns.ValidationError{Msg: fmt.Sprintf("unable to update the zone at the provider %q (%d of %d corrections applied): %s", provider.Comment, i, len(corrections), corrErr.Error())}
+		}
+	}
+
+	return len(corrections), nil
+}
+
+// lastPublishedZone returns the newest zone of the domain published to its
+// provider, if any.
+func (uc *ZoneCorrectionApplierUsecase) lastPublishedZone(domain *happydns.Domain) (*happydns.Zone, error) {
+	for _, id := range domain.ZoneHistory {
+		zone, err := uc.zoneGetter.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		if zone.Published != nil {
+			return zone, nil
+		}
+	}
+
+	return nil, nil
+}
+
 // extractOriginSOASerial extracts the SOA serial from the Origin service
 // at the zone apex, if present.
 func extractOriginSOASerial(zone *happydns.Zone) (uint32, bool) {
@@ -429,3 +429,42 @@ func getOriginSOASerial(t *testing.T, zone *happydns.Zone) uint32 {
 	t.Fatal("no Origin service with SOA found in zone")
 	return 0
 }
+
+func TestApplyRecords_StopsOnFailure(t *testing.T) {
+	var executed []string
+	correction := func(msg string, err error) *happydns.Correction {
+		return &happydns.Correction{Msg: msg, F: func() error {
+			executed = append(executed, msg)
+			return err
+		}}
+	}
+
+	uc := buildTestApplier(
+		&mockProviderGetter{},
+		&mockZoneCorrector{corrections: []*happydns.Correction{
+			correction("first", nil),
+			correction("second", fmt.Errorf("refused")),
+			correction("third", nil),
+		}},
+		&mockZoneRetriever{},
+		&mockDomainUpdater{},
+		newInMemoryZoneStorage(),
+	)
+
+	applied, err := uc.ApplyRecords(
+		context.Background(),
+		&happydns.Domain{DomainName: "example.com."},
+		&happydns.Zone{ZoneMeta: happydns.ZoneMeta{Id: happydns.Identifier([]byte("snapshot"))}},
+		&happydns.Provider{ProviderMeta: happydns.ProviderMeta{Id: happydns.Identifier([]byte("other-provider"))}},
+		nil,
+	)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if applied != 1 {
+		t.Errorf("applied = %d, want 1", applied)
+	}
+	if len(executed) != 2 {
+		t.Errorf("executed = %v, want the corrections up to the failing one", executed)
+	}
+}
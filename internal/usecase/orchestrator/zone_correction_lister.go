@@ -23,8 +23,15 @@ package orchestrator
 
 import (
 	"context"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
 
 	adapter "git.happydns.org/happyDomain/internal/adapters"
+	providerReg "git.happydns.org/happyDomain/internal/providerregistry"
 	zoneUC "git.happydns.org/happyDomain/internal/usecase/zone"
 	"git.happydns.org/happyDomain/model"
 )
@@ -38,6 +45,10 @@ type ZoneCorrectionListerUsecase struct {
 	listRecords     *zoneUC.ListRecordsUsecase
 	zoneCorrector   ZoneCorrector
 	zoneRetriever   ZoneRetriever
+	aliasFlattener  AliasFlattener
+	spfFlattener    SPFFlattener
+	zoneCommenter   ZoneCommenter
+	zoneCache       *ZoneCache
 }
 
 // NewZoneCorrectionListerUsecase creates a ZoneCorrectionListerUsecase with
@@ -56,20 +67,33 @@ func NewZoneCorrectionListerUsecase(
 	}
 }
 
+// retrieveProviderRecords returns the records the provider currently
+// publishes for the domain, from the cache unless fresh is set.
+func (uc *ZoneCorrectionListerUsecase) retrieveProviderRecords(ctx context.Context, provider *happydns.Provider, domain *happydns.Domain, fresh bool) ([]happydns.Record, error) {
+	if uc.zoneCache == nil {
+		return uc.zoneRetriever.RetrieveZone(ctx, provider, domain.DomainName)
+	}
+
+	return uc.zoneCache.Retrieve(ctx, provider, domain.DomainName, fresh)
+}
+
 // listWithRecords is the internal implementation that returns the corrections
-// along with the provider and WIP records used to compute them.
+// along with the provider records used to compute them, and the records of
+// the zone computed from another one. The provider records come from the
+// cache unless fresh is set.
 func (uc *ZoneCorrectionListerUsecase) listWithRecords(
 	ctx context.Context,
 	user *happydns.User,
 	domain *happydns.Domain,
 	zone *happydns.Zone,
-) ([]*happydns.Correction, []happydns.Record, []happydns.Record, int, error) {
+	fresh bool,
+) ([]*happydns.Correction, []happydns.Record, []*happydns.DerivedRecords, int, error) {
 	provider, err := uc.providerService.GetUserProvider(ctx, user, domain.ProviderId)
 	if err != nil {
 		return nil, nil, nil, 0, err
 	}
 
-	providerRecords, err := uc.zoneRetriever.RetrieveZone(ctx, provider, domain.DomainName)
+	providerRecords, err := uc.retrieveProviderRecords(ctx, provider, domain, fresh)
 	if err != nil {
 		return nil, nil, nil, 0, err
 	}
@@ -79,24 +103,207 @@ func (uc *ZoneCorrectionListerUsecase) listWithRecords(
 		return nil, nil, nil, 0, err
 	}
 
+	// The provider is unable to publish an ALIAS: publish instead the
+	// addresses its target resolves to.
+	var aliases map[string]string
+	if uc.aliasFlattener != nil && providerReg.ProviderHasCapability(provider, adapter.AliasFlatteningCapability) {
+		wipRecords, aliases, err = uc.aliasFlattener.FlattenAliases(ctx, wipRecords)
+		if err != nil {
+			return nil, nil, nil, 0, err
+		}
+	}
+
+	// The user chose to publish their SPF records flattened.
+	var spfs map[string]string
+	if uc.spfFlattener != nil && domain.SPFFlattening != happydns.SPFFlatteningOff {
+		wipRecords, spfs, err = uc.spfFlattener.FlattenSPF(ctx, wipRecords)
+		if err != nil {
+			return nil, nil, nil, 0, err
+		}
+	}
+
+	// The provider brings the TTLs out of its bounds within them: diff
+	// against what it will publish, or the correction comes back each time.
+	caps := providerReg.GetProviderCapabilities(provider.Type)
+	wipRecords, clamped := ClampTTLs(caps, wipRecords)
+
 	corrections, nbDiffs, err := adapter.DNSControlDiffByRecord(providerRecords, wipRecords, domain.DomainName)
 	if err != nil {
 		return nil, nil, nil, nbDiffs, err
 	}
 
-	return corrections, providerRecords, wipRecords, nbDiffs, nil
+	markDerived(corrections, aliases, aliasDerivation, isAddressRecord)
+	markDerived(corrections, spfs, "", isSPFRecord)
+	derived := slices.Concat(
+		derivedRecords(wipRecords, aliases, aliasDerivation, isAddressRecord),
+		derivedRecords(wipRecords, spfs, "", isSPFRecord),
+	)
+
+	CheckCapabilities(caps, corrections, clamped)
+
+	if caps != nil && caps.RecordComments && uc.zoneCommenter != nil {
+		comments := uc.commentCorrections(ctx, provider, domain, zone, wipRecords, corrections)
+		corrections = append(corrections, comments...)
+		nbDiffs += len(comments)
+	}
+
+	return corrections, providerRecords, derived, nbDiffs, nil
+}
+
+// commentCorrections returns a correction for each record published as is
+// whose comment at the provider differs from the one of its service. The
+// comments are read from the provider each time, not cached: failing to read
+// them only leaves them out of the diff.
+func (uc *ZoneCorrectionListerUsecase) commentCorrections(
+	ctx context.Context,
+	provider *happydns.Provider,
+	domain *happydns.Domain,
+	zone *happydns.Zone,
+	records []happydns.Record,
+	corrections []*happydns.Correction,
+) []*happydns.Correction {
+	wanted, err := uc.listRecords.Comments(domain, zone)
+	if err != nil {
+		log.Printf("%s: unable to list the record comments: %s", domain.DomainName, err.Error())
+		return nil
+	}
+
+	current, err := uc.zoneCommenter.RetrieveZoneComments(ctx, provider, domain.DomainName)
+	if err != nil {
+		log.Printf("%s: unable to retrieve the record comments: %s", domain.DomainName, err.Error())
+		return nil
+	}
+
+	// The records added or changed carry their comment along.
+	published := map[string]bool{}
+	for _, cr := range corrections {
+		for _, rr := range cr.NewRecords {
+			published[happydns.RecordCommentKey(rr)] = true
+		}
+	}
+
+	var ret []*happydns.Correction
+	for _, rr := range records {
+		if published[happydns.RecordCommentKey(rr)] {
+			continue
+		}
+
+		changed := wanted.Changed([]happydns.Record{rr}, current)
+		if changed == nil {
+			continue
+		}
+
+		cr := adapter.NewCommentsCorrection(changed, nil)
+		cr.OldRecords = []happydns.Record{rr}
+		cr.NewRecords = []happydns.Record{rr}
+		ret = append(ret, cr)
+	}
+
+	return ret
 }
 
 // List returns the corrections required to bring the provider's live DNS
 // records in line with the given zone. It fetches the current provider
 // records, expands the zone into individual records, and computes the diff
 // locally. The second return value is the total number of corrections.
+//
+// The provider records may come from the cache: ProviderRecordsFetchedAt
+// tells how old they are.
 func (uc *ZoneCorrectionListerUsecase) List(
 	ctx context.Context,
 	user *happydns.User,
 	domain *happydns.Domain,
 	zone *happydns.Zone,
 ) ([]*happydns.Correction, int, error) {
-	corrections, _, _, nbDiffs, err := uc.listWithRecords(ctx, user, domain, zone)
+	corrections, _, _, nbDiffs, err := uc.listWithRecords(ctx, user, domain, zone, false)
 	return corrections, nbDiffs, err
 }
+
+// ProviderRecordsFetchedAt returns the time the provider records of the
+// domain held in the cache were fetched at, if any.
+func (uc *ZoneCorrectionListerUsecase) ProviderRecordsFetchedAt(domain *happydns.Domain) *time.Time {
+	fetchedAt, ok := uc.zoneCache.FetchedAt(domain.ProviderId, domain.DomainName)
+	if !ok {
+		return nil
+	}
+
+	return &fetchedAt
+}
+
+// InvalidateProviderRecords forgets the provider records of the domain held
+// in the cache, so that the next diff fetches them again.
+func (uc *ZoneCorrectionListerUsecase) InvalidateProviderRecords(domain *happydns.Domain) {
+	uc.zoneCache.Invalidate(domain.ProviderId, domain.DomainName)
+}
+
+// markDerived tells apart the corrections touching the records happyDomain
+// publishes in place of another one, which the user does not manage by hand.
+// derived gives, for each owner, what they are computed from; match tells
+// which records of the owner are the computed ones.
+func markDerived(corrections []*happydns.Correction, derived map[string]string, prefix string, match func(happydns.Record) bool) {
+	if len(derived) == 0 {
+		return
+	}
+
+	for _, cr := range corrections {
+		owner, ok := correctionOwner(cr)
+		if !ok {
+			continue
+		}
+
+		from, ok := derived[owner]
+		if !ok {
+			continue
+		}
+
+		records := cr.NewRecords
+		if len(records) == 0 {
+			records = cr.OldRecords
+		}
+		for _, rr := range records {
+			if !match(rr) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			cr.DerivedFrom = prefix + from
+		}
+	}
+}
+
+// aliasDerivation prefixes the target of the ALIAS a correction derives from.
+const aliasDerivation = "ALIAS "
+
+// FromFlattenedAlias tells whether the correction touches the addresses
+// published in place of an ALIAS.
+func FromFlattenedAlias(cr *happydns.Correction) bool {
+	return strings.HasPrefix(cr.DerivedFrom, aliasDerivation)
+}
+
+// FromFlattenedSPF tells whether the correction touches the flattened form
+// of an SPF record.
+func FromFlattenedSPF(cr *happydns.Correction) bool {
+	return strings.HasPrefix(strings.ToLower(cr.DerivedFrom), "v=spf1")
+}
+
+// isAddressRecord matches the records a flattened ALIAS is published as.
+func isAddressRecord(rr happydns.Record) bool {
+	rrtype := rr.Header().Rrtype
+	return rrtype == dns.TypeA || rrtype == dns.TypeAAAA
+}
+
+// isSPFRecord matches the TXT records holding an SPF policy.
+func isSPFRecord(rr happydns.Record) bool {
+	var txt string
+	switch record := rr.(type) {
+	case *dns.TXT:
+		txt = strings.Join(record.Txt, "")
+	case *happydns.TXT:
+		txt = record.Txt
+	default:
+		return false
+	}
+
+	return strings.HasPrefix(strings.ToLower(txt), "v=spf1")
+}
@@ -26,10 +26,14 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/miekg/dns"
+
+	domainlogUC "git.happydns.org/happyDomain/internal/usecase/domain_log"
 	"git.happydns.org/happyDomain/internal/usecase/orchestrator"
 	serviceUC "git.happydns.org/happyDomain/internal/usecase/service"
 	zoneUC "git.happydns.org/happyDomain/internal/usecase/zone"
 	"git.happydns.org/happyDomain/model"
+	"git.happydns.org/happyDomain/services/abstract"
 )
 
 // mockProviderGetter implements ProviderGetter for testing.
@@ -186,3 +190,86 @@ func TestZoneCorrectionLister_List_NoCorrections(t *testing.T) {
 		t.Errorf("expected 0 corrections, got %d", len(got))
 	}
 }
+
+// mockZoneCommenter implements ZoneCommenter, holding the comments of a
+// provider.
+type mockZoneCommenter struct {
+	comments happydns.RecordComments
+}
+
+func (m *mockZoneCommenter) ListZoneCorrectionsWithComments(_ context.Context, _ *happydns.Provider, _ *happydns.Domain, _ []happydns.Record, _ happydns.RecordComments) ([]*happydns.Correction, int, error) {
+	return nil, 0, nil
+}
+
+func (m *mockZoneCommenter) RetrieveZoneComments(_ context.Context, _ *happydns.Provider, _ string) (happydns.RecordComments, error) {
+	return m.comments, nil
+}
+
+func TestZoneCorrectionLister_List_CommentChanged(t *testing.T) {
+	domain := &happydns.Domain{
+		ProviderId: happydns.Identifier([]byte("test-provider")),
+		DomainName: "example.com.",
+	}
+	zone := &happydns.Zone{
+		ZoneMeta: happydns.ZoneMeta{DefaultTTL: 3600},
+		Services: map[happydns.Subdomain][]*happydns.Service{
+			"": {{
+				ServiceMeta: happydns.ServiceMeta{Type: "abstract.Origin", UserComment: "apex"},
+				Service: &abstract.Origin{
+					SOA: &dns.SOA{
+						Hdr:    dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 3600},
+						Ns:     "ns1.example.com.",
+						Mbox:   "admin.example.com.",
+						Serial: 1,
+					},
+					NameServers: []*dns.NS{
+						{Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeNS, Class: dns.ClassINET, Ttl: 3600}, Ns: "ns1.example.com."},
+					},
+				},
+			}},
+		},
+	}
+
+	listRecords := newTestListRecordsUsecase()
+	records, err := listRecords.List(domain, zone)
+	if err != nil {
+		t.Fatalf("List() = %v", err)
+	}
+
+	// The provider holds the records as they are, the NS one with an older
+	// comment.
+	commenter := &mockZoneCommenter{comments: happydns.RecordComments{}}
+	var ns happydns.Record
+	for _, rr := range records {
+		commenter.comments[happydns.RecordCommentKey(rr)] = happydns.FormatRecordComment("apex")
+		if rr.Header().Rrtype == dns.TypeNS {
+			ns = rr
+			commenter.comments[happydns.RecordCommentKey(rr)] = happydns.FormatRecordComment("old")
+		}
+	}
+
+	applier := orchestrator.NewZoneCorrectionApplierUsecase(
+		domainlogUC.NoopDomainLogAppender{},
+		&mockDomainUpdater{domain: domain},
+		orchestrator.NewZoneCorrectionListerUsecase(
+			&mockProviderGetter{provider: &happydns.Provider{ProviderMeta: happydns.ProviderMeta{Type: "BuiltinServer"}}},
+			listRecords,
+			&mockZoneCorrector{},
+			&mockZoneRetriever{records: records},
+		),
+		nil, nil, nil, nil,
+	)
+	o := &orchestrator.Orchestrator{RemoteZoneImporter: &orchestrator.RemoteZoneImporterUsecase{}, ZoneCorrectionApplier: applier}
+	o.SetZoneCommenter(commenter)
+
+	got, nbDiff, err := applier.List(context.Background(), &happydns.User{}, domain, zone)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || nbDiff != 1 {
+		t.Fatalf("expected the correction of the NS comment, got %d corrections (nbDiff=%d)", len(got), nbDiff)
+	}
+	if got[0].Kind != happydns.CorrectionKindUpdate || len(got[0].NewRecords) != 1 || happydns.RecordCommentKey(got[0].NewRecords[0]) != happydns.RecordCommentKey(ns) {
+		t.Errorf("correction = %+v, want an update of the NS record", got[0])
+	}
+}
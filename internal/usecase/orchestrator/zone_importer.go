@@ -54,11 +54,45 @@ func NewZoneImporterUsecase(domainUpdater DomainUpdater, zoneCreator *zoneUC.Cre
 // domain's most recent zone, persists the new zone, and prepends its ID to the
 // domain's history.  Returns the created zone or an error.
 func (uc *ZoneImporterUsecase) Import(user *happydns.User, domain *happydns.Domain, rrs []happydns.Record) (*happydns.Zone, error) {
+	return uc.ImportWithComments(user, domain, rrs, nil)
+}
+
+// ImportWithComments is Import, giving to the services without a comment
+// after the metadata carry-over the one stored along their records.
+//
+// The records happyDomain published in place of another one, such as a
+// flattened SPF record, are imported as the record they were computed from,
+// as long as the provider still publishes them as computed. So are the
+// records of a failover imported as the failover publishing them.
+func (uc *ZoneImporterUsecase) ImportWithComments(user *happydns.User, domain *happydns.Domain, rrs []happydns.Record, comments happydns.RecordComments) (*happydns.Zone, error) {
+	var (
+		derived   []*happydns.DerivedRecords
+		failovers map[happydns.Subdomain][]*happydns.Service
+	)
+	published, err := uc.lastPublishedZone(domain)
+	if err != nil {
+		log.Printf("%s: unable to load the last published zone: %s (its derived records will be imported as published)", domain.DomainName, err)
+	} else if published != nil {
+		rrs, derived = restoreDerived(rrs, published.Derived)
+		rrs, failovers = restoreFailovers(rrs, published, domain.DomainName)
+	}
+
 	services, defaultTTL, err := svc.AnalyzeZone(domain.DomainName, rrs)
 	if err != nil {
 		return nil, happydns.ValidationError{Msg: fmt.Sprintf("unable to perform the analysis of your zone: %s", err.Error())}
 	}
 
+	for subdomain, restored := range failovers {
+		services[subdomain] = append(services[subdomain], restored...)
+	}
+
+	// The fields of the services not published in DNS, such as the policy
+	// of a hosted MTA-STS, are known from the zone last published, when
+	// the unpublished changes of the one below make them differ.
+	if published != nil && len(domain.ZoneHistory) > 0 && !published.Id.Equals(domain.ZoneHistory[0]) {
+		zoneUC.ReassociateMetadata(published.Services, services, domain.DomainName, defaultTTL)
+	}
+
 	if len(domain.ZoneHistory) > 0 {
 		prevZone, err := uc.zoneGetter.Get(domain.ZoneHistory[0])
 		if err != nil {
@@ -68,6 +102,8 @@ func (uc *ZoneImporterUsecase) Import(user *happydns.User, domain *happydns.Doma
 		}
 	}
 
+	zoneUC.RestoreComments(services, domain.DomainName, defaultTTL, comments)
+
 	now := time.Now()
 	commit := fmt.Sprintf("Initial zone fetch from %s", domain.DomainName)
 	if len(domain.ZoneHistory) > 0 {
@@ -82,6 +118,7 @@ func (uc *ZoneImporterUsecase) Import(user *happydns.User, domain *happydns.Doma
 			CommitMsg:    &commit,
 			CommitDate:   &now,
 			Published:    &now,
+			Derived:      derived,
 		},
 		Services: services,
 	}
@@ -110,3 +147,19 @@ func (uc *ZoneImporterUsecase) Import(user *happydns.User, domain *happydns.Doma
 
 	return myZone, nil
 }
+
+// lastPublishedZone returns the newest zone of the domain published to its
+// provider, if any.
+func (uc *ZoneImporterUsecase) lastPublishedZone(domain *happydns.Domain) (*happydns.Zone, error) {
+	for _, id := range domain.ZoneHistory {
+		zone, err := uc.zoneGetter.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		if zone.Published != nil {
+			return zone, nil
+		}
+	}
+
+	return nil, nil
+}
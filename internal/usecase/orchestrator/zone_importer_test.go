@@ -0,0 +1,194 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package orchestrator_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/internal/usecase/orchestrator"
+	zoneUC "git.happydns.org/happyDomain/internal/usecase/zone"
+	"git.happydns.org/happyDomain/model"
+	svcs "git.happydns.org/happyDomain/services"
+	"git.happydns.org/happyDomain/services/abstract"
+)
+
+// importAfterPublish imports rrs for a domain whose last published zone
+// recorded derived.
+func importAfterPublish(t *testing.T, derived []*happydns.DerivedRecords, rrs []happydns.Record) *happydns.Zone {
+	t.Helper()
+
+	return importAfterPublishing(t, derived, map[happydns.Subdomain][]*happydns.Service{}, rrs)
+}
+
+// importAfterPublishing imports rrs for a domain whose last published zone
+// recorded derived and held services.
+func importAfterPublishing(t *testing.T, derived []*happydns.DerivedRecords, services map[happydns.Subdomain][]*happydns.Service, rrs []happydns.Record) *happydns.Zone {
+	t.Helper()
+
+	now := time.Now()
+	storage := newInMemoryZoneStorage()
+	published := &happydns.Zone{
+		ZoneMeta: happydns.ZoneMeta{
+			Id:         happydns.Identifier("published"),
+			DefaultTTL: 3600,
+			Published:  &now,
+			Derived:    derived,
+		},
+		Services: services,
+	}
+	storage.zones[published.Id.String()] = published
+
+	domain := &happydns.Domain{
+		Id:          happydns.Identifier("domain"),
+		DomainName:  "example.com.",
+		ZoneHistory: []happydns.Identifier{published.Id},
+	}
+
+	uc := orchestrator.NewZoneImporterUsecase(&mockDomainUpdater{domain: domain}, zoneUC.NewCreateZoneUsecase(storage), zoneUC.NewGetZoneUsecase(storage))
+
+	zone, err := uc.Import(&happydns.User{}, domain, rrs)
+	if err != nil {
+		t.Fatalf("Import returned an error: %s", err)
+	}
+
+	return zone
+}
+
+func zoneSPF(zone *happydns.Zone, subdomain happydns.Subdomain) string {
+	for _, s := range zone.Services[subdomain] {
+		if spf, ok := s.Service.(*svcs.SPF); ok {
+			return spf.Record.Txt
+		}
+	}
+
+	return ""
+}
+
+func TestImport_RestoresFlattenedSPF(t *testing.T) {
+	derived := []*happydns.DerivedRecords{
+		{Owner: "example.com.", From: "v=spf1 include:sendgrid.net -all", Records: []string{"v=spf1 ip4:167.89.0.0/17 -all"}},
+	}
+	hdr := dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 3600}
+
+	zone := importAfterPublish(t, derived, []happydns.Record{
+		&dns.TXT{Hdr: hdr, Txt: []string{"v=spf1 ip4:167.89.0.0/17 -all"}},
+	})
+
+	if got := zoneSPF(zone, ""); got != "v=spf1 include:sendgrid.net -all" {
+		t.Errorf("the SPF record was imported as %q, want the policy it was flattened from", got)
+	}
+	if len(zone.Derived) != 1 || zone.Derived[0] != derived[0] {
+		t.Errorf("the imported zone records %v, want the flattening restored", zone.Derived)
+	}
+}
+
+func TestImport_KeepsSPFChangedAtProvider(t *testing.T) {
+	derived := []*happydns.DerivedRecords{
+		{Owner: "example.com.", From: "v=spf1 include:sendgrid.net -all", Records: []string{"v=spf1 ip4:167.89.0.0/17 -all"}},
+	}
+	hdr := dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 3600}
+
+	zone := importAfterPublish(t, derived, []happydns.Record{
+		&dns.TXT{Hdr: hdr, Txt: []string{"v=spf1 ip4:192.0.2.0/24 -all"}},
+	})
+
+	if got := zoneSPF(zone, ""); got != "v=spf1 ip4:192.0.2.0/24 -all" {
+		t.Errorf("the SPF record was imported as %q, want the one the provider publishes", got)
+	}
+	if len(zone.Derived) != 0 {
+		t.Errorf("the imported zone records %v, want no derivation", zone.Derived)
+	}
+}
+
+func TestImport_RestoresFlattenedAlias(t *testing.T) {
+	derived := []*happydns.DerivedRecords{
+		{Owner: "example.com.", From: "ALIAS cdn.example.net.", Records: []string{"192.0.2.10", "2001:db8::1"}},
+	}
+
+	zone := importAfterPublish(t, derived, []happydns.Record{
+		&dns.AAAA{Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: 300}, AAAA: net.ParseIP("2001:db8::1")},
+		&dns.A{Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300}, A: net.ParseIP("192.0.2.10")},
+	})
+
+	var target string
+	for _, s := range zone.Services[""] {
+		if alias, ok := s.Service.(*svcs.Alias); ok && alias.Record.Header().Rrtype == happydns.TypeALIAS {
+			target = alias.Record.(*dns.PrivateRR).Data.(happydns.TargetRdata).GetTarget()
+		}
+	}
+	if target != "cdn.example.net." {
+		t.Errorf("the apex was imported with the ALIAS target %q, want the ALIAS it was flattened from: %v", target, zone.Services[""])
+	}
+	if len(zone.Derived) != 1 || zone.Derived[0] != derived[0] {
+		t.Errorf("the imported zone records %v, want the flattening restored", zone.Derived)
+	}
+}
+
+func failoverZone() map[happydns.Subdomain][]*happydns.Service {
+	hdr := dns.RR_Header{Rrtype: dns.TypeA, Class: dns.ClassINET}
+
+	return map[happydns.Subdomain][]*happydns.Service{
+		"www": {{
+			ServiceMeta: happydns.ServiceMeta{Id: happydns.Identifier("failover"), Type: "abstract.Failover"},
+			Service: &abstract.Failover{
+				Primary: &abstract.FailoverTarget{A: &dns.A{Hdr: hdr, A: net.ParseIP("192.0.2.1")}, PlanId: "plan"},
+				Backups: []*abstract.FailoverTarget{
+					{CNAME: &dns.CNAME{Hdr: dns.RR_Header{Rrtype: dns.TypeCNAME, Class: dns.ClassINET}, Target: "backup.example.net."}},
+				},
+				Active: 1,
+			},
+		}},
+	}
+}
+
+func TestImport_RestoresFailover(t *testing.T) {
+	zone := importAfterPublishing(t, nil, failoverZone(), []happydns.Record{
+		&dns.CNAME{Hdr: dns.RR_Header{Name: "www.example.com.", Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 300}, Target: "backup.example.net."},
+	})
+
+	if len(zone.Services["www"]) != 1 {
+		t.Fatalf("www was imported as %v, want the failover alone", zone.Services["www"])
+	}
+	fo, ok := zone.Services["www"][0].Service.(*abstract.Failover)
+	if !ok {
+		t.Fatalf("www was imported as %T, want the failover", zone.Services["www"][0].Service)
+	}
+	if fo.Active != 1 || len(fo.Backups) != 1 || !zone.Services["www"][0].Id.Equals(happydns.Identifier("failover")) {
+		t.Errorf("the failover was imported as %+v, want it unchanged", fo)
+	}
+}
+
+func TestImport_KeepsFailoverChangedAtProvider(t *testing.T) {
+	zone := importAfterPublishing(t, nil, failoverZone(), []happydns.Record{
+		&dns.A{Hdr: dns.RR_Header{Name: "www.example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300}, A: net.ParseIP("192.0.2.1")},
+	})
+
+	for _, s := range zone.Services["www"] {
+		if _, ok := s.Service.(*abstract.Failover); ok {
+			t.Errorf("www was imported as the failover, whose active target the provider no longer publishes")
+		}
+	}
+}
@@ -22,6 +22,9 @@
 package provider
 
 import (
+	"errors"
+	"fmt"
+
 	happydns "git.happydns.org/happyDomain/model"
 )
 
@@ -54,3 +57,56 @@ func (s *Service) DeleteProviderByID(providerID happydns.Identifier) error {
 func (s *Service) ClearProviders() error {
 	return s.store.ClearProviders()
 }
+
+// ReencryptProviders writes every provider back to the storage, so that their
+// credentials get sealed under the current master key: those still in clear,
+// and those sealed under a key it replaced. It returns the number of providers
+// rewritten. Intended for administrative callers, after a master key has been
+// set or rotated.
+//
+// A provider that cannot be read back, because it cannot be decoded or opened
+// under any key of the keyring, is reported in the returned error: until it is
+// dealt with, the previous key must not be retired.
+func (s *Service) ReencryptProviders() (int, error) {
+	iter, err := s.store.ListAllProviders()
+	if err != nil {
+		return 0, err
+	}
+
+	// Drain first: rewriting entries while iterating over them is not
+	// something every storage engine supports.
+	var (
+		msgs []*happydns.ProviderMessage
+		errs error
+	)
+	for iter.NextWithError() {
+		if msg := iter.Item(); msg != nil {
+			msgs = append(msgs, msg)
+		} else {
+			errs = errors.Join(errs, fmt.Errorf("provider at %q: %w", iter.Key(), iter.Err()))
+		}
+	}
+	err = iter.Err()
+	iter.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	var done int
+	for _, msg := range msgs {
+		p, err := ParseProvider(msg)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("provider %s: %w", msg.Id.String(), err))
+			continue
+		}
+
+		if err := s.store.UpdateProvider(p); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("provider %s: %w", msg.Id.String(), err))
+			continue
+		}
+
+		done++
+	}
+
+	return done, errs
+}
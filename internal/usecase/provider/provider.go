@@ -28,6 +28,7 @@ import (
 
 	"git.happydns.org/happyDomain/internal/forms"
 	"git.happydns.org/happyDomain/internal/netguard"
+	"git.happydns.org/happyDomain/internal/providerlimit"
 	providerReg "git.happydns.org/happyDomain/internal/providerregistry"
 	"git.happydns.org/happyDomain/model"
 )
@@ -40,6 +41,10 @@ type Service struct {
 	// guard decides which endpoints a provider may be pointed at. A nil guard
 	// still refuses non-public destinations: see netguard.Guard.
 	guard *netguard.Guard
+
+	// limiter throttles the calls made to the providers; nil lets them all
+	// through.
+	limiter *providerlimit.Limiter
 }
 
 // NewService creates a new provider Service. If validator is nil,
@@ -55,6 +60,13 @@ func NewService(store ProviderStorage, validator ProviderValidator, guard *netgu
 	}
 }
 
+// SetLimiter sets the limiter throttling the calls made to the providers. It
+// has to be shared by every Service of the instance for the quota of an
+// account to be shared too.
+func (s *Service) SetLimiter(limiter *providerlimit.Limiter) {
+	s.limiter = limiter
+}
+
 // ParseProvider converts a ProviderMessage to a Provider.
 func ParseProvider(msg *happydns.ProviderMessage) (p *happydns.Provider, err error) {
 	p = &happydns.Provider{}
@@ -85,7 +97,29 @@ func (s *Service) instantiate(ctx context.Context, p *happydns.Provider) (happyd
 	if err != nil {
 		return nil, fmt.Errorf("unable to instantiate provider: %w", err)
 	}
-	return instance, nil
+	return s.limiter.Wrap(p, instance), nil
+}
+
+// actuatorAs looks for an optional interface on the given actuator. The
+// wrappers around it, such as the throttling one, are asked first, then the
+// actuators they wrap: a wrapper only exposes the interfaces it handles.
+func actuatorAs[T any](actuator happydns.ProviderActuator) (T, bool) {
+	for actuator != nil {
+		if found, ok := actuator.(T); ok {
+			return found, true
+		}
+
+		wrapper, ok := actuator.(interface {
+			Unwrap() happydns.ProviderActuator
+		})
+		if !ok {
+			break
+		}
+		actuator = wrapper.Unwrap()
+	}
+
+	var zero T
+	return zero, false
 }
 
 // CreateProvider creates a new provider for the given user.
@@ -222,6 +256,8 @@ func (s *Service) UpdateProviderFromMessage(ctx context.Context, providerID happ
 
 		provider.Type = newprovider.Type
 		provider.Comment = newprovider.Comment
+		provider.Journal = newprovider.Journal
+		provider.SyncZones = newprovider.SyncZones
 		provider.Provider = newprovider.Provider
 	})
 }
@@ -257,6 +293,11 @@ func NewRestrictedService(cfg *happydns.Options, store ProviderStorage, guard *n
 	}
 }
 
+// SetLimiter sets the limiter throttling the calls made to the providers.
+func (s *RestrictedService) SetLimiter(limiter *providerlimit.Limiter) {
+	s.inner.(*Service).SetLimiter(limiter)
+}
+
 // CreateProvider refuses the operation when DisableProviders is set, otherwise delegates to Service.
 func (s *RestrictedService) CreateProvider(ctx context.Context, user *happydns.User, msg *happydns.ProviderMessage) (*happydns.Provider, error) {
 	if s.config.DisableProviders {
@@ -0,0 +1,46 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// GetRegistrar returns the registrar side of the given provider. It fails
+// with a happydns.ValidationError when the provider is not a registrar.
+func (s *Service) GetRegistrar(ctx context.Context, provider *happydns.Provider) (happydns.RegistrarActuator, error) {
+	p, err := s.instantiate(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	// The registrar side is not throttled: it is seldom called, and only on
+	// a user's direct request.
+	registrar, ok := actuatorAs[happydns.RegistrarActuator](p)
+	if !ok {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("the provider %q cannot manage the registration of domains", provider.Comment)}
+	}
+
+	return registrar, nil
+}
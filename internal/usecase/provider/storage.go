@@ -53,3 +53,24 @@ type ProviderStorage interface {
 	// ClearProviders deletes all Providers present in the database.
 	ClearProviders() error
 }
+
+// BuiltinZoneStorage is the persistence interface of the built-in provider,
+// which keeps its zones in happyDomain's own database.
+type BuiltinZoneStorage interface {
+	// ListAllBuiltinZones retrieves the zones of every built-in provider.
+	ListAllBuiltinZones() (happydns.Iterator[happydns.BuiltinZone], error)
+
+	// ListBuiltinZones retrieves the zones kept by the given built-in provider.
+	ListBuiltinZones(providerId happydns.Identifier) ([]*happydns.BuiltinZone, error)
+
+	// GetBuiltinZone retrieves the zone with the given name kept by the given
+	// built-in provider.
+	GetBuiltinZone(providerId happydns.Identifier, name string) (*happydns.BuiltinZone, error)
+
+	// PutBuiltinZone creates or replaces the given zone.
+	PutBuiltinZone(zone *happydns.BuiltinZone) error
+
+	// DeleteBuiltinZone removes the zone with the given name kept by the given
+	// built-in provider.
+	DeleteBuiltinZone(providerId happydns.Identifier, name string) error
+}
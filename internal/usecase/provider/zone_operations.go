@@ -46,3 +46,35 @@ func (s *Service) ListZoneCorrections(ctx context.Context, provider *happydns.Pr
 
 	return instance.GetZoneCorrections(domain.DomainName, records)
 }
+
+// RetrieveZoneComments retrieves the comments stored along the records of the
+// zone, for the providers able to store some. The others have none.
+func (s *Service) RetrieveZoneComments(ctx context.Context, provider *happydns.Provider, name string) (happydns.RecordComments, error) {
+	instance, err := s.instantiate(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	commenter, ok := actuatorAs[happydns.RecordCommenter](instance)
+	if !ok {
+		return nil, nil
+	}
+
+	return commenter.GetZoneRecordComments(name)
+}
+
+// ListZoneCorrectionsWithComments is ListZoneCorrections storing the given
+// comments along the records, for the providers able to store some.
+func (s *Service) ListZoneCorrectionsWithComments(ctx context.Context, provider *happydns.Provider, domain *happydns.Domain, records []happydns.Record, comments happydns.RecordComments) ([]*happydns.Correction, int, error) {
+	instance, err := s.instantiate(ctx, provider)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	commenter, ok := actuatorAs[happydns.RecordCommenter](instance)
+	if !ok {
+		return instance.GetZoneCorrections(domain.DomainName, records)
+	}
+
+	return commenter.GetZoneCorrectionsWithComments(domain.DomainName, records, comments)
+}
@@ -79,5 +79,6 @@ func (psu *providerSpecsUsecase) GetProviderSpecs(psid string) (*happydns.Provid
 	return &happydns.ProviderSpecs{
 		Fields:       forms.GenStructFields(pcreator.Creator()),
 		Capabilities: pcreator.Infos.Capabilities,
+		Features:     providerReg.GetProviderCapabilities(psid),
 	}, nil
 }
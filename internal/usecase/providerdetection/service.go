@@ -0,0 +1,142 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package providerdetection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	providerReg "git.happydns.org/happyDomain/internal/providerregistry"
+	"git.happydns.org/happyDomain/model"
+)
+
+// Service guesses the provider hosting a domain.
+type Service struct {
+	resolver  Resolver
+	providers ProviderLister
+}
+
+// NewService builds a Service resolving the domains through resolver, and
+// suggesting the providers of the user listed by providers.
+func NewService(resolver Resolver, providers ProviderLister) *Service {
+	return &Service{
+		resolver:  resolver,
+		providers: providers,
+	}
+}
+
+// Detect resolves the NS and SOA of the domain, and suggests the provider
+// types known to serve zones from these name servers, along with the
+// providers of these types the user already has.
+func (s *Service) Detect(ctx context.Context, user *happydns.User, domain string) (*happydns.ProviderDetection, error) {
+	name := strings.ToLower(dns.Fqdn(strings.TrimSpace(domain)))
+	if _, ok := dns.IsDomainName(name); !ok || name == "." {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("%q is not a valid domain name", domain)}
+	}
+
+	detection := &happydns.ProviderDetection{
+		DomainName:  name,
+		Nameservers: []string{},
+		Suggestions: []*happydns.ProviderSuggestion{},
+	}
+
+	answer, err := s.lookup(name, dns.TypeNS)
+	if err != nil {
+		return nil, err
+	}
+	for _, rr := range answer {
+		if ns, ok := rr.(*dns.NS); ok {
+			detection.Nameservers = append(detection.Nameservers, strings.ToLower(ns.Ns))
+		}
+	}
+
+	if len(detection.Nameservers) == 0 {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("%s is not delegated to any name server", name)}
+	}
+
+	// The SOA is only a hint: its absence doesn't prevent the detection.
+	nameservers := detection.Nameservers
+	if answer, err := s.lookup(name, dns.TypeSOA); err == nil {
+		for _, rr := range answer {
+			if soa, ok := rr.(*dns.SOA); ok {
+				detection.SOAMname = strings.ToLower(soa.Ns)
+				nameservers = append(slices.Clone(nameservers), detection.SOAMname)
+				break
+			}
+		}
+	}
+
+	types := providerReg.MatchNameservers(nameservers)
+	if len(types) == 0 {
+		return detection, nil
+	}
+
+	providers, err := s.providers.ListUserProviders(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	registered := providerReg.GetProviders()
+	for _, ptype := range types {
+		suggestion := &happydns.ProviderSuggestion{
+			Type:     ptype,
+			Name:     registered[ptype].Infos.Name,
+			HelpLink: registered[ptype].Infos.HelpLink,
+		}
+		for _, provider := range providers {
+			if provider.Type == ptype {
+				suggestion.Providers = append(suggestion.Providers, provider)
+			}
+		}
+		detection.Suggestions = append(detection.Suggestions, suggestion)
+	}
+
+	return detection, nil
+}
+
+// lookup returns the records of the given type owned by name.
+func (s *Service) lookup(name string, rrtype uint16) ([]dns.RR, error) {
+	msg, err := s.resolver.ResolveQuestion(happydns.ResolverRequest{
+		Resolver:   "local",
+		DomainName: name,
+		Type:       dns.TypeToString[rrtype],
+	})
+	var nxdomain happydns.NotFoundError
+	if errors.As(err, &nxdomain) {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("%s does not exist", name)}
+	} else if err != nil {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("unable to resolve the %s of %s: %s", dns.TypeToString[rrtype], name, err.Error())}
+	}
+
+	var ret []dns.RR
+	for _, rr := range msg.Answer {
+		if rr.Header().Rrtype == rrtype && strings.EqualFold(rr.Header().Name, name) {
+			ret = append(ret, rr)
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,132 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package providerdetection
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	providerReg "git.happydns.org/happyDomain/internal/providerregistry"
+	"git.happydns.org/happyDomain/model"
+)
+
+type fakeResolver struct {
+	records map[uint16][]string
+	err     error
+}
+
+func (f *fakeResolver) ResolveQuestion(req happydns.ResolverRequest) (*dns.Msg, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	msg := new(dns.Msg)
+	for _, record := range f.records[dns.StringToType[req.Type]] {
+		rr, err := dns.NewRR(record)
+		if err != nil {
+			return nil, err
+		}
+		msg.Answer = append(msg.Answer, rr)
+	}
+	return msg, nil
+}
+
+type fakeProviders []*happydns.ProviderMeta
+
+func (f fakeProviders) ListUserProviders(_ context.Context, _ *happydns.User) ([]*happydns.ProviderMeta, error) {
+	return f, nil
+}
+
+func init() {
+	providerReg.RegisterNamedProvider("CloudflareAPI", func() happydns.ProviderBody { return nil }, happydns.ProviderInfos{
+		Name:     "Cloudflare",
+		HelpLink: "https://docs.dnscontrol.org/service-providers/providers/cloudflareapi",
+	})
+}
+
+func TestDetect(t *testing.T) {
+	resolver := &fakeResolver{records: map[uint16][]string{
+		dns.TypeNS: {
+			"example.com. 3600 IN NS ada.ns.cloudflare.com.",
+			"example.com. 3600 IN NS bob.ns.cloudflare.com.",
+		},
+		dns.TypeSOA: {
+			"example.com. 3600 IN SOA ada.ns.cloudflare.com. dns.cloudflare.com. 1 10000 2400 604800 1800",
+		},
+	}}
+	mine := &happydns.ProviderMeta{Type: "CloudflareAPI", Id: happydns.Identifier("mine")}
+	s := NewService(resolver, fakeProviders{mine, {Type: "OVHAPI", Id: happydns.Identifier("other")}})
+
+	detection, err := s.Detect(context.Background(), &happydns.User{}, "Example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if detection.DomainName != "example.com." || len(detection.Nameservers) != 2 || detection.SOAMname != "ada.ns.cloudflare.com." {
+		t.Errorf("unexpected detection %+v", detection)
+	}
+
+	if len(detection.Suggestions) != 1 {
+		t.Fatalf("expected 1 suggestion, got %d", len(detection.Suggestions))
+	}
+	suggestion := detection.Suggestions[0]
+	if suggestion.Type != "CloudflareAPI" || suggestion.Name != "Cloudflare" || suggestion.HelpLink == "" {
+		t.Errorf("unexpected suggestion %+v", suggestion)
+	}
+	if len(suggestion.Providers) != 1 || suggestion.Providers[0] != mine {
+		t.Errorf("expected the existing Cloudflare provider to be suggested, got %v", suggestion.Providers)
+	}
+}
+
+func TestDetect_Unknown(t *testing.T) {
+	resolver := &fakeResolver{records: map[uint16][]string{
+		dns.TypeNS: {"example.com. 3600 IN NS ns1.example.net."},
+	}}
+	s := NewService(resolver, fakeProviders{})
+
+	detection, err := s.Detect(context.Background(), &happydns.User{}, "example.com.")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(detection.Suggestions) != 0 {
+		t.Errorf("expected no suggestion, got %v", detection.Suggestions)
+	}
+}
+
+func TestDetect_Errors(t *testing.T) {
+	s := NewService(&fakeResolver{}, fakeProviders{})
+	if _, err := s.Detect(context.Background(), &happydns.User{}, "example.com"); err == nil {
+		t.Error("expected a domain without NS to be refused")
+	}
+
+	s = NewService(&fakeResolver{err: happydns.NotFoundError{Msg: "NXDOMAIN"}}, fakeProviders{})
+	if _, err := s.Detect(context.Background(), &happydns.User{}, "example.com"); err == nil {
+		t.Error("expected a nonexistent domain to be refused")
+	}
+
+	if _, err := s.Detect(context.Background(), &happydns.User{}, strings.Repeat("a", 64)+".com"); err == nil {
+		t.Error("expected an invalid domain name to be refused")
+	}
+}
@@ -0,0 +1,31 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package providerjournal implements the provider journal: for the providers
+// having it enabled, every correction executed while publishing a zone is kept
+// along with its timing, its outcome and, when the provider adapter supplies
+// them, the request sent and the response received.
+//
+// Entries are stored per domain and linked to the published snapshot, so a
+// misbehaving publication can be traced back to what was actually sent. The
+// provider's secrets, those tagged `secret` in its settings, are redacted
+// before anything is stored.
+package providerjournal
@@ -0,0 +1,71 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package providerjournal
+
+import (
+	"encoding/json"
+	"strings"
+
+	"git.happydns.org/happyDomain/internal/forms"
+	"git.happydns.org/happyDomain/model"
+)
+
+// redactor hides the secrets of a provider in what gets journaled.
+type redactor struct {
+	replacer *strings.Replacer
+}
+
+// newRedactor collects the values of the fields of body tagged `secret`, the
+// same ones the provider API withholds.
+func newRedactor(body happydns.ProviderBody) *redactor {
+	var pairs []string
+
+	forms.TransformSecrets(body, func(secret string) (string, error) {
+		pairs = append(pairs, secret, happydns.RedactedSecret)
+
+		// In a JSON document, the secret appears escaped.
+		if escaped, err := json.Marshal(secret); err == nil {
+			if s := strings.Trim(string(escaped), `"`); s != secret {
+				pairs = append(pairs, s, happydns.RedactedSecret)
+			}
+		}
+
+		// Leave the secret as it is: only its value was wanted.
+		return secret, nil
+	})
+
+	return &redactor{replacer: strings.NewReplacer(pairs...)}
+}
+
+func (r *redactor) String(s string) string {
+	return r.replacer.Replace(s)
+}
+
+// JSON redacts the secrets in raw. The replacement is itself a valid JSON
+// string content, so the document stays valid.
+func (r *redactor) JSON(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return raw
+	}
+
+	return json.RawMessage(r.replacer.Replace(string(raw)))
+}
@@ -0,0 +1,132 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package providerjournal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// MaxCallsPerDomain bounds the journal of a domain: beyond it, the oldest
+// entries are dropped.
+const MaxCallsPerDomain = 500
+
+// Service implements happydns.ProviderJournalUsecase.
+type Service struct {
+	store ProviderCallStorage
+}
+
+// NewService creates the provider journal service.
+func NewService(store ProviderCallStorage) *Service {
+	return &Service{store: store}
+}
+
+// ListDomainCalls returns the journal entries of the given domain, the most
+// recent first. When snapshotId is not empty, only the entries of the
+// publication that created this snapshot are returned.
+func (s *Service) ListDomainCalls(_ context.Context, domain *happydns.Domain, snapshotId happydns.Identifier) ([]*happydns.ProviderCall, error) {
+	calls, err := s.store.ListProviderCalls(domain.Id)
+	if err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to list the provider calls of %s: %w", domain.DomainName, err),
+			UserMessage: "Sorry, we are unable to retrieve the provider journal of this domain.",
+		}
+	}
+
+	if !snapshotId.IsEmpty() {
+		filtered := calls[:0]
+		for _, call := range calls {
+			if call.SnapshotId.Equals(snapshotId) {
+				filtered = append(filtered, call)
+			}
+		}
+		calls = filtered
+	}
+
+	sortCalls(calls)
+
+	return calls, nil
+}
+
+// Record keeps the given entries, once redacted, when the provider has its
+// journal enabled. Entries are expected to carry their DomainId and ZoneId;
+// ProviderId is set here.
+func (s *Service) Record(_ context.Context, provider *happydns.Provider, calls []*happydns.ProviderCall) error {
+	if !provider.Journal || len(calls) == 0 {
+		return nil
+	}
+
+	r := newRedactor(provider.Provider)
+
+	domains := map[string]happydns.Identifier{}
+	for _, call := range calls {
+		call.ProviderId = provider.Id
+		call.Msg = r.String(call.Msg)
+		call.Error = r.String(call.Error)
+		call.Request = r.JSON(call.Request)
+		call.Response = r.JSON(call.Response)
+
+		if err := s.store.CreateProviderCall(call); err != nil {
+			return fmt.Errorf("unable to journal the call %q: %w", call.Msg, err)
+		}
+
+		domains[call.DomainId.String()] = call.DomainId
+	}
+
+	for _, domainId := range domains {
+		s.trim(domainId)
+	}
+
+	return nil
+}
+
+// trim drops the oldest entries of the domain beyond MaxCallsPerDomain.
+// Failures are only logged: the journal is best effort.
+func (s *Service) trim(domainId happydns.Identifier) {
+	calls, err := s.store.ListProviderCalls(domainId)
+	if err != nil {
+		log.Printf("unable to list the provider calls of %s to trim them: %s", domainId.String(), err)
+		return
+	}
+
+	if len(calls) <= MaxCallsPerDomain {
+		return
+	}
+
+	sortCalls(calls)
+	for _, call := range calls[MaxCallsPerDomain:] {
+		if err := s.store.DeleteProviderCall(call); err != nil {
+			log.Printf("unable to drop the provider call %s: %s", call.Id.String(), err)
+		}
+	}
+}
+
+// sortCalls sorts calls the most recent first.
+func sortCalls(calls []*happydns.ProviderCall) {
+	sort.SliceStable(calls, func(i, j int) bool {
+		return calls[i].Date.After(calls[j].Date)
+	})
+}
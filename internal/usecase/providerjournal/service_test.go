@@ -0,0 +1,185 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package providerjournal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+type memoryCalls struct {
+	calls []*happydns.ProviderCall
+}
+
+func (m *memoryCalls) ListAllProviderCalls() (happydns.Iterator[happydns.ProviderCall], error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m *memoryCalls) ListProviderCalls(domainId happydns.Identifier) (calls []*happydns.ProviderCall, err error) {
+	for _, call := range m.calls {
+		if call.DomainId.Equals(domainId) {
+			calls = append(calls, call)
+		}
+	}
+	return
+}
+
+func (m *memoryCalls) CreateProviderCall(call *happydns.ProviderCall) error {
+	call.Id = happydns.Identifier(fmt.Appendf(nil, "call-%d", len(m.calls)))
+	m.calls = append(m.calls, call)
+	return nil
+}
+
+func (m *memoryCalls) DeleteProviderCall(call *happydns.ProviderCall) error {
+	for i, c := range m.calls {
+		if c.Id.Equals(call.Id) {
+			m.calls = append(m.calls[:i], m.calls[i+1:]...)
+			return nil
+		}
+	}
+	return happydns.ErrNotFound
+}
+
+type journaledProvider struct {
+	Endpoint string `json:"endpoint"`
+	Token    string `json:"token" happydomain:"label=Token,secret"`
+}
+
+func (p *journaledProvider) InstantiateProvider() (happydns.ProviderActuator, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func newProvider(journal bool) *happydns.Provider {
+	return &happydns.Provider{
+		ProviderMeta: happydns.ProviderMeta{Id: happydns.Identifier("provider"), Journal: journal},
+		Provider:     &journaledProvider{Endpoint: "https://api.example.net", Token: `s3cr"et`},
+	}
+}
+
+func TestRecordOnlyWhenEnabled(t *testing.T) {
+	store := &memoryCalls{}
+	s := NewService(store)
+
+	call := &happydns.ProviderCall{DomainId: happydns.Identifier("domain"), Msg: "+ CREATE", Success: true}
+	if err := s.Record(context.Background(), newProvider(false), []*happydns.ProviderCall{call}); err != nil {
+		t.Fatalf("Record() = %v", err)
+	}
+	if len(store.calls) != 0 {
+		t.Fatalf("%d calls journaled for a provider without journal", len(store.calls))
+	}
+
+	if err := s.Record(context.Background(), newProvider(true), []*happydns.ProviderCall{call}); err != nil {
+		t.Fatalf("Record() = %v", err)
+	}
+	if len(store.calls) != 1 || !store.calls[0].ProviderId.Equals(happydns.Identifier("provider")) {
+		t.Fatalf("journaled calls = %v; want one, linked to the provider", store.calls)
+	}
+}
+
+func TestRecordRedactsSecrets(t *testing.T) {
+	store := &memoryCalls{}
+	s := NewService(store)
+
+	request, _ := json.Marshal(map[string]string{"auth": `s3cr"et`, "zone": "example.com."})
+	call := &happydns.ProviderCall{
+		DomainId: happydns.Identifier("domain"),
+		Msg:      "+ CREATE",
+		Error:    `401: bad token s3cr"et`,
+		Request:  request,
+	}
+
+	if err := s.Record(context.Background(), newProvider(true), []*happydns.ProviderCall{call}); err != nil {
+		t.Fatalf("Record() = %v", err)
+	}
+
+	got := store.calls[0]
+	if strings.Contains(got.Error, "s3cr") || strings.Contains(string(got.Request), "s3cr") {
+		t.Fatalf("the secret was journaled: %q, %s", got.Error, got.Request)
+	}
+	if !strings.Contains(string(got.Request), "example.com.") {
+		t.Errorf("the request lost what is not secret: %s", got.Request)
+	}
+
+	var decoded map[string]string
+	if err := json.Unmarshal(got.Request, &decoded); err != nil {
+		t.Fatalf("the redacted request is no longer JSON: %v", err)
+	}
+	if decoded["auth"] != happydns.RedactedSecret {
+		t.Errorf("auth = %q; want %q", decoded["auth"], happydns.RedactedSecret)
+	}
+}
+
+func TestListDomainCalls(t *testing.T) {
+	store := &memoryCalls{}
+	s := NewService(store)
+	domain := &happydns.Domain{Id: happydns.Identifier("domain")}
+
+	now := time.Now()
+	calls := []*happydns.ProviderCall{
+		{DomainId: domain.Id, SnapshotId: happydns.Identifier("one"), Date: now.Add(-time.Hour), Msg: "first"},
+		{DomainId: domain.Id, SnapshotId: happydns.Identifier("two"), Date: now, Msg: "second"},
+		{DomainId: happydns.Identifier("other"), Date: now, Msg: "other"},
+	}
+	if err := s.Record(context.Background(), newProvider(true), calls); err != nil {
+		t.Fatalf("Record() = %v", err)
+	}
+
+	got, err := s.ListDomainCalls(context.Background(), domain, nil)
+	if err != nil || len(got) != 2 || got[0].Msg != "second" || got[1].Msg != "first" {
+		t.Fatalf("ListDomainCalls() = %v, %v; want second then first", got, err)
+	}
+
+	got, err = s.ListDomainCalls(context.Background(), domain, happydns.Identifier("one"))
+	if err != nil || len(got) != 1 || got[0].Msg != "first" {
+		t.Fatalf("ListDomainCalls(one) = %v, %v; want first", got, err)
+	}
+}
+
+func TestRecordTrimsTheJournal(t *testing.T) {
+	store := &memoryCalls{}
+	s := NewService(store)
+	domainId := happydns.Identifier("domain")
+
+	start := time.Now()
+	var calls []*happydns.ProviderCall
+	for i := 0; i < MaxCallsPerDomain+10; i++ {
+		calls = append(calls, &happydns.ProviderCall{DomainId: domainId, Date: start.Add(time.Duration(i) * time.Second)})
+	}
+
+	if err := s.Record(context.Background(), newProvider(true), calls); err != nil {
+		t.Fatalf("Record() = %v", err)
+	}
+
+	if len(store.calls) != MaxCallsPerDomain {
+		t.Fatalf("%d calls kept; want %d", len(store.calls), MaxCallsPerDomain)
+	}
+	for _, call := range store.calls {
+		if call.Date.Before(start.Add(10 * time.Second)) {
+			t.Fatalf("an old call was kept: %v", call.Date)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package providerjournal
+
+import (
+	"git.happydns.org/happyDomain/model"
+)
+
+// ProviderCallStorage is the persistence interface of the provider journal.
+type ProviderCallStorage interface {
+	// ListAllProviderCalls retrieves the journal entries of every domain.
+	ListAllProviderCalls() (happydns.Iterator[happydns.ProviderCall], error)
+
+	// ListProviderCalls retrieves the journal entries of the given domain.
+	ListProviderCalls(domainId happydns.Identifier) ([]*happydns.ProviderCall, error)
+
+	// CreateProviderCall stores a new entry, assigning its identifier.
+	CreateProviderCall(call *happydns.ProviderCall) error
+
+	// DeleteProviderCall removes the given entry.
+	DeleteProviderCall(call *happydns.ProviderCall) error
+}
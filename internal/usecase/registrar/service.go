@@ -0,0 +1,332 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package registrar
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	domainlogUC "git.happydns.org/happyDomain/internal/usecase/domain_log"
+	"git.happydns.org/happyDomain/model"
+)
+
+// Service implements happydns.RegistrarUsecase.
+type Service struct {
+	providers  ProviderGetter
+	registrars RegistrarGetter
+	domainInfo DomainInfoGetter
+	resolver   Resolver
+	domainLog  domainlogUC.DomainLogAppender
+}
+
+// NewService builds the registrar Service.
+func NewService(
+	providers ProviderGetter,
+	registrars RegistrarGetter,
+	domainInfo DomainInfoGetter,
+	resolver Resolver,
+	domainLog domainlogUC.DomainLogAppender,
+) *Service {
+	return &Service{
+		providers:  providers,
+		registrars: registrars,
+		domainInfo: domainInfo,
+		resolver:   resolver,
+		domainLog:  domainLog,
+	}
+}
+
+// registrar returns the provider acting as registrar of domain, along with
+// its registrar side.
+func (s *Service) registrar(ctx context.Context, user *happydns.User, domain *happydns.Domain) (*happydns.Provider, happydns.RegistrarActuator, error) {
+	providerID := domain.RegistrarId
+	if providerID.IsEmpty() {
+		providerID = domain.ProviderId
+	}
+
+	provider, err := s.providers.GetUserProvider(ctx, user, providerID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	registrar, err := s.registrars.GetRegistrar(ctx, provider)
+	if err != nil {
+		return provider, nil, err
+	}
+
+	return provider, registrar, nil
+}
+
+// GetRegistration returns how domain is registered. The registration of a
+// domain whose provider is not a registrar is looked up publicly.
+func (s *Service) GetRegistration(ctx context.Context, user *happydns.User, domain *happydns.Domain) (*happydns.Registration, error) {
+	_, registrar, err := s.registrar(ctx, user, domain)
+	var notRegistrar happydns.ValidationError
+	if err != nil && !errors.As(err, &notRegistrar) {
+		return nil, err
+	}
+
+	return s.registration(ctx, domain, registrar)
+}
+
+// registration asks the registrar about domain when it can tell, or looks
+// the domain up otherwise.
+func (s *Service) registration(ctx context.Context, domain *happydns.Domain, registrar happydns.RegistrarActuator) (reg *happydns.Registration, err error) {
+	if reader, ok := registrar.(happydns.RegistrarReader); ok {
+		reg, err = reader.GetRegistration(domain.DomainName)
+		if err != nil {
+			return nil, happydns.ValidationError{Msg: fmt.Sprintf("unable to retrieve the registration of %s from the registrar: %s", domain.DomainName, err.Error())}
+		}
+		reg.Source = "registrar"
+	} else {
+		reg, err = s.lookup(ctx, domain)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if reg.Nameservers == nil {
+		reg.Nameservers = []string{}
+	}
+	if reg.DS == nil {
+		reg.DS = []happydns.DelegationSigner{}
+	}
+	reg.Operations = operations(registrar)
+
+	return reg, nil
+}
+
+// lookup reads the registration of domain from RDAP, and its DS records
+// from the DNS.
+func (s *Service) lookup(ctx context.Context, domain *happydns.Domain) (*happydns.Registration, error) {
+	info, err := s.domainInfo.GetDomainInfo(ctx, happydns.Origin(domain.DomainName))
+	if err != nil {
+		return nil, err
+	}
+
+	reg := &happydns.Registration{
+		Source:       "rdap",
+		TransferLock: transferLocked(info.Status),
+	}
+	for _, ns := range info.Nameservers {
+		reg.Nameservers = append(reg.Nameservers, strings.ToLower(dns.Fqdn(ns)))
+	}
+
+	reg.DS, err = s.delegationSigners(domain.DomainName)
+	if err != nil {
+		return nil, err
+	}
+
+	return reg, nil
+}
+
+// delegationSigners returns the DS records the parent zone holds for domain.
+func (s *Service) delegationSigners(domain string) ([]happydns.DelegationSigner, error) {
+	msg, err := s.resolver.ResolveQuestion(happydns.ResolverRequest{
+		Resolver:   "local",
+		DomainName: domain,
+		Type:       "DS",
+	})
+	var nxdomain happydns.NotFoundError
+	if errors.As(err, &nxdomain) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to retrieve the DS records of %s: %w", domain, err)
+	}
+
+	var ret []happydns.DelegationSigner
+	for _, rr := range msg.Answer {
+		if ds, ok := rr.(*dns.DS); ok && strings.EqualFold(ds.Hdr.Name, dns.Fqdn(domain)) {
+			ret = append(ret, happydns.DelegationSigner{
+				KeyTag:     ds.KeyTag,
+				Algorithm:  ds.Algorithm,
+				DigestType: ds.DigestType,
+				Digest:     strings.ToUpper(ds.Digest),
+			})
+		}
+	}
+	return ret, nil
+}
+
+// transferLocked tells from the EPP statuses of a domain whether its
+// transfer is prohibited. RDAP spells them out ("client transfer
+// prohibited"), WHOIS does not ("clientTransferProhibited").
+func transferLocked(status []string) *bool {
+	locked := false
+	for _, st := range status {
+		if strings.HasSuffix(strings.ToLower(strings.ReplaceAll(st, " ", "")), "transferprohibited") {
+			locked = true
+		}
+	}
+	return &locked
+}
+
+// operations lists the changes the registrar can make.
+func operations(registrar happydns.RegistrarActuator) []string {
+	ret := []string{}
+	if registrar == nil {
+		return ret
+	}
+
+	ret = append(ret, happydns.RegistrarOpNameservers)
+	if _, ok := registrar.(happydns.RegistrarDSActuator); ok {
+		ret = append(ret, happydns.RegistrarOpDS)
+	}
+	if _, ok := registrar.(happydns.RegistrarLockActuator); ok {
+		ret = append(ret, happydns.RegistrarOpTransferLock)
+	}
+	if _, ok := registrar.(happydns.RegistrarAutoRenewActuator); ok {
+		ret = append(ret, happydns.RegistrarOpAutoRenew)
+	}
+	return ret
+}
+
+// UpdateRegistration applies the form through the registrar of domain. The
+// whole form is checked against what the registrar can do before anything
+// is changed.
+func (s *Service) UpdateRegistration(ctx context.Context, user *happydns.User, domain *happydns.Domain, form *happydns.RegistrarForm) (*happydns.Registration, error) {
+	provider, registrar, err := s.registrar(ctx, user, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	nameservers, err := normalizeNameservers(form.Nameservers)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []change
+	if nameservers != nil {
+		changes = append(changes, change{
+			what: fmt.Sprintf("set the name servers to %s", strings.Join(nameservers, ", ")),
+			do:   func() error { return registrar.SetNameservers(domain.DomainName, nameservers) },
+		})
+	}
+
+	if form.DS != nil {
+		dsRegistrar, ok := registrar.(happydns.RegistrarDSActuator)
+		if !ok {
+			return nil, happydns.ValidationError{Msg: fmt.Sprintf("the registrar %q cannot manage the DS records", provider.Comment)}
+		}
+		if err := checkDelegationSigners(form.DS); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change{
+			what: fmt.Sprintf("set %d DS records", len(form.DS)),
+			do:   func() error { return dsRegistrar.SetDelegationSigners(domain.DomainName, form.DS) },
+		})
+	}
+
+	if form.TransferLock != nil {
+		lockRegistrar, ok := registrar.(happydns.RegistrarLockActuator)
+		if !ok {
+			return nil, happydns.ValidationError{Msg: fmt.Sprintf("the registrar %q cannot lock the transfer of domains", provider.Comment)}
+		}
+		locked := *form.TransferLock
+		what := "unlock the transfer"
+		if locked {
+			what = "lock the transfer"
+		}
+		changes = append(changes, change{
+			what: what,
+			do:   func() error { return lockRegistrar.SetTransferLock(domain.DomainName, locked) },
+		})
+	}
+
+	if form.AutoRenew != nil {
+		renewRegistrar, ok := registrar.(happydns.RegistrarAutoRenewActuator)
+		if !ok {
+			return nil, happydns.ValidationError{Msg: fmt.Sprintf("the registrar %q cannot renew domains automatically", provider.Comment)}
+		}
+		enabled := *form.AutoRenew
+		what := "turn the automatic renewal off"
+		if enabled {
+			what = "turn the automatic renewal on"
+		}
+		changes = append(changes, change{
+			what: what,
+			do:   func() error { return renewRegistrar.SetAutoRenew(domain.DomainName, enabled) },
+		})
+	}
+
+	for _, c := range changes {
+		if err := c.do(); err != nil {
+			s.appendLog(domain, user, happydns.LOG_ERR, fmt.Sprintf("Registrar %q: unable to %s: %s", provider.Comment, c.what, err.Error()))
+			return nil, happydns.ValidationError{Msg: fmt.Sprintf("unable to %s: %s", c.what, err.Error())}
+		}
+		s.appendLog(domain, user, happydns.LOG_ACK, fmt.Sprintf("Registrar %q: %s", provider.Comment, c.what))
+	}
+
+	return s.registration(ctx, domain, registrar)
+}
+
+// change is a change of the registration, described for the domain log.
+type change struct {
+	what string
+	do   func() error
+}
+
+// normalizeNameservers checks the name servers of the form, and returns
+// them as absolute, lowercase names. It returns nil when none were given.
+func normalizeNameservers(nameservers []string) ([]string, error) {
+	if nameservers == nil {
+		return nil, nil
+	}
+
+	ret := make([]string, 0, len(nameservers))
+	for _, ns := range nameservers {
+		ns = strings.ToLower(dns.Fqdn(strings.TrimSpace(ns)))
+		if _, ok := dns.IsDomainName(ns); !ok || ns == "." {
+			return nil, happydns.ValidationError{Msg: fmt.Sprintf("%q is not a valid name server", ns)}
+		}
+		ret = append(ret, ns)
+	}
+
+	if len(ret) == 0 {
+		return nil, happydns.ValidationError{Msg: "at least one name server is required"}
+	}
+	return ret, nil
+}
+
+// checkDelegationSigners checks the DS records of the form.
+func checkDelegationSigners(signers []happydns.DelegationSigner) error {
+	for _, ds := range signers {
+		if _, err := hex.DecodeString(ds.Digest); err != nil || ds.Digest == "" {
+			return happydns.ValidationError{Msg: fmt.Sprintf("the digest of the DS record %d is not a valid hexadecimal string", ds.KeyTag)}
+		}
+		if ds.Algorithm == 0 || ds.DigestType == 0 {
+			return happydns.ValidationError{Msg: fmt.Sprintf("the DS record %d lacks its algorithm or its digest type", ds.KeyTag)}
+		}
+	}
+	return nil
+}
+
+func (s *Service) appendLog(domain *happydns.Domain, user *happydns.User, level int8, msg string) {
+	if err := s.domainLog.AppendDomainLog(domain, happydns.NewDomainLog(user, level, msg)); err != nil {
+		log.Printf("Registrar: unable to append domain log for %s: %s", domain.DomainName, err.Error())
+	}
+}
@@ -0,0 +1,237 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package registrar
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+type fakeProviders struct{}
+
+func (fakeProviders) GetUserProvider(ctx context.Context, user *happydns.User, providerID happydns.Identifier) (*happydns.Provider, error) {
+	return &happydns.Provider{ProviderMeta: happydns.ProviderMeta{Id: providerID, Comment: "test"}}, nil
+}
+
+type fakeRegistrars struct {
+	registrar happydns.RegistrarActuator
+}
+
+func (f fakeRegistrars) GetRegistrar(ctx context.Context, provider *happydns.Provider) (happydns.RegistrarActuator, error) {
+	if f.registrar == nil {
+		return nil, happydns.ValidationError{Msg: "not a registrar"}
+	}
+	return f.registrar, nil
+}
+
+type fakeDomainInfo struct{}
+
+func (fakeDomainInfo) GetDomainInfo(ctx context.Context, domain happydns.Origin) (*happydns.DomainInfo, error) {
+	return &happydns.DomainInfo{
+		Name:        string(domain),
+		Nameservers: []string{"NS1.example.net", "ns2.example.net."},
+		Status:      []string{"client transfer prohibited", "active"},
+	}, nil
+}
+
+type fakeResolver struct{}
+
+func (fakeResolver) ResolveQuestion(req happydns.ResolverRequest) (*dns.Msg, error) {
+	rr, err := dns.NewRR(req.DomainName + " 3600 IN DS 12345 13 2 abcdef0123")
+	if err != nil {
+		return nil, err
+	}
+	return &dns.Msg{Answer: []dns.RR{rr}}, nil
+}
+
+type fakeLog struct {
+	entries []*happydns.DomainLog
+}
+
+func (f *fakeLog) AppendDomainLog(domain *happydns.Domain, entry *happydns.DomainLog) error {
+	f.entries = append(f.entries, entry)
+	return nil
+}
+
+// nsRegistrar only changes the name servers.
+type nsRegistrar struct {
+	nameservers []string
+	err         error
+}
+
+func (r *nsRegistrar) SetNameservers(domain string, nameservers []string) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.nameservers = nameservers
+	return nil
+}
+
+// fullRegistrar offers every operation.
+type fullRegistrar struct {
+	nsRegistrar
+	locked *bool
+}
+
+func (r *fullRegistrar) SetDelegationSigners(domain string, ds []happydns.DelegationSigner) error {
+	return nil
+}
+
+func (r *fullRegistrar) SetTransferLock(domain string, locked bool) error {
+	r.locked = &locked
+	return nil
+}
+
+func (r *fullRegistrar) SetAutoRenew(domain string, enabled bool) error {
+	return nil
+}
+
+func newTestService(registrar happydns.RegistrarActuator, logs *fakeLog) *Service {
+	return NewService(fakeProviders{}, fakeRegistrars{registrar}, fakeDomainInfo{}, fakeResolver{}, logs)
+}
+
+var testDomain = &happydns.Domain{
+	DomainName: "example.com.",
+	ProviderId: happydns.Identifier("provider"),
+}
+
+func TestGetRegistrationLooksUp(t *testing.T) {
+	s := newTestService(nil, &fakeLog{})
+
+	reg, err := s.GetRegistration(context.Background(), &happydns.User{}, testDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reg.Source != "rdap" {
+		t.Errorf("Source = %q, want rdap", reg.Source)
+	}
+	if !slices.Equal(reg.Nameservers, []string{"ns1.example.net.", "ns2.example.net."}) {
+		t.Errorf("Nameservers = %v", reg.Nameservers)
+	}
+	if reg.TransferLock == nil || !*reg.TransferLock {
+		t.Errorf("TransferLock = %v, want locked", reg.TransferLock)
+	}
+	if len(reg.DS) != 1 || reg.DS[0].KeyTag != 12345 || reg.DS[0].Digest != "ABCDEF0123" {
+		t.Errorf("DS = %+v", reg.DS)
+	}
+	if len(reg.Operations) != 0 {
+		t.Errorf("Operations = %v, want none", reg.Operations)
+	}
+}
+
+func TestOperations(t *testing.T) {
+	if got := operations(&nsRegistrar{}); !slices.Equal(got, []string{happydns.RegistrarOpNameservers}) {
+		t.Errorf("operations(nsRegistrar) = %v", got)
+	}
+
+	want := []string{happydns.RegistrarOpNameservers, happydns.RegistrarOpDS, happydns.RegistrarOpTransferLock, happydns.RegistrarOpAutoRenew}
+	if got := operations(&fullRegistrar{}); !slices.Equal(got, want) {
+		t.Errorf("operations(fullRegistrar) = %v", got)
+	}
+}
+
+func TestUpdateRegistration(t *testing.T) {
+	registrar := &fullRegistrar{}
+	logs := &fakeLog{}
+	s := newTestService(registrar, logs)
+
+	locked := false
+	_, err := s.UpdateRegistration(context.Background(), &happydns.User{}, testDomain, &happydns.RegistrarForm{
+		Nameservers:  []string{" NS1.new.net", "ns2.new.net."},
+		TransferLock: &locked,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(registrar.nameservers, []string{"ns1.new.net.", "ns2.new.net."}) {
+		t.Errorf("name servers set to %v", registrar.nameservers)
+	}
+	if registrar.locked == nil || *registrar.locked {
+		t.Errorf("transfer lock set to %v, want unlocked", registrar.locked)
+	}
+	if len(logs.entries) != 2 {
+		t.Errorf("got %d log entries, want 2", len(logs.entries))
+	}
+}
+
+func TestUpdateRegistrationRefusesUnsupported(t *testing.T) {
+	registrar := &nsRegistrar{}
+	s := newTestService(registrar, &fakeLog{})
+
+	locked := true
+	_, err := s.UpdateRegistration(context.Background(), &happydns.User{}, testDomain, &happydns.RegistrarForm{
+		Nameservers:  []string{"ns1.new.net"},
+		TransferLock: &locked,
+	})
+
+	var verr happydns.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("UpdateRegistration() error = %v, want a ValidationError", err)
+	}
+	if registrar.nameservers != nil {
+		t.Errorf("name servers changed to %v although the form was refused", registrar.nameservers)
+	}
+}
+
+func TestUpdateRegistrationLogsFailure(t *testing.T) {
+	registrar := &nsRegistrar{err: errors.New("denied")}
+	logs := &fakeLog{}
+	s := newTestService(registrar, logs)
+
+	_, err := s.UpdateRegistration(context.Background(), &happydns.User{}, testDomain, &happydns.RegistrarForm{
+		Nameservers: []string{"ns1.new.net"},
+	})
+	if err == nil {
+		t.Fatal("UpdateRegistration() succeeded, want an error")
+	}
+	if len(logs.entries) != 1 || logs.entries[0].Level != happydns.LOG_ERR {
+		t.Errorf("log entries = %+v, want one error", logs.entries)
+	}
+}
+
+func TestNormalizeNameservers(t *testing.T) {
+	if got, err := normalizeNameservers(nil); got != nil || err != nil {
+		t.Errorf("normalizeNameservers(nil) = %v, %v", got, err)
+	}
+	if _, err := normalizeNameservers([]string{}); err == nil {
+		t.Error("normalizeNameservers([]) succeeded, want an error")
+	}
+	if _, err := normalizeNameservers([]string{"ns1..example"}); err == nil {
+		t.Error("normalizeNameservers() accepted an invalid name")
+	}
+}
+
+func TestCheckDelegationSigners(t *testing.T) {
+	if err := checkDelegationSigners([]happydns.DelegationSigner{{KeyTag: 1, Algorithm: 13, DigestType: 2, Digest: "ABCD"}}); err != nil {
+		t.Errorf("checkDelegationSigners() = %v", err)
+	}
+	if err := checkDelegationSigners([]happydns.DelegationSigner{{KeyTag: 1, Algorithm: 13, DigestType: 2, Digest: "XYZ"}}); err == nil {
+		t.Error("checkDelegationSigners() accepted a non-hexadecimal digest")
+	}
+}
@@ -0,0 +1,49 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package registrar
+
+import (
+	"context"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// ProviderGetter retrieves the provider acting as registrar of a Domain.
+type ProviderGetter interface {
+	GetUserProvider(ctx context.Context, user *happydns.User, providerID happydns.Identifier) (*happydns.Provider, error)
+}
+
+// RegistrarGetter instantiates the registrar side of a provider.
+type RegistrarGetter interface {
+	GetRegistrar(ctx context.Context, provider *happydns.Provider) (happydns.RegistrarActuator, error)
+}
+
+// DomainInfoGetter looks the registration of a domain up with RDAP or WHOIS.
+type DomainInfoGetter interface {
+	GetDomainInfo(ctx context.Context, domain happydns.Origin) (*happydns.DomainInfo, error)
+}
+
+// Resolver asks for the DS records of a domain.
+type Resolver interface {
+	ResolveQuestion(happydns.ResolverRequest) (*dns.Msg, error)
+}
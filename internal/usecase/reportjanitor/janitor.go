@@ -0,0 +1,174 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package reportjanitor drops the aggregate reports past their retention
+// period, whatever their kind.
+package reportjanitor
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// DomainLister lists the Domains of all users.
+type DomainLister interface {
+	ListAllDomains() (happydns.Iterator[happydns.Domain], error)
+}
+
+// Janitor periodically drops the reports older than the retention period.
+type Janitor[R any] struct {
+	name     string
+	domains  DomainLister
+	list     func(domainId happydns.Identifier) ([]R, error)
+	expired  func(R) bool
+	remove   func(R) error
+	interval time.Duration
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	done    chan struct{}
+	running bool
+}
+
+// NewJanitor builds a Janitor that runs every `interval`, deleting with
+// remove the reports of each domain, as given by list, that expired tells
+// are past retention. name designates the reports in the logs.
+func NewJanitor[R any](name string, domains DomainLister, list func(domainId happydns.Identifier) ([]R, error), expired func(R) bool, remove func(R) error, interval time.Duration) *Janitor[R] {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	return &Janitor[R]{
+		name:     name,
+		domains:  domains,
+		list:     list,
+		expired:  expired,
+		remove:   remove,
+		interval: interval,
+	}
+}
+
+// Start launches the janitor loop in a goroutine.
+func (j *Janitor[R]) Start(ctx context.Context) {
+	j.mu.Lock()
+	if j.running {
+		j.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	j.cancel = cancel
+	j.done = make(chan struct{})
+	j.running = true
+	j.mu.Unlock()
+
+	go j.loop(ctx)
+}
+
+// Stop halts the janitor and waits for the current sweep to finish.
+func (j *Janitor[R]) Stop() {
+	j.mu.Lock()
+	cancel := j.cancel
+	done := j.done
+	j.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	if done != nil {
+		<-done
+	}
+	j.mu.Lock()
+	j.running = false
+	j.mu.Unlock()
+}
+
+func (j *Janitor[R]) loop(ctx context.Context) {
+	defer close(j.done)
+
+	j.RunOnce(ctx)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce performs a single sweep over the domains. It returns the number of
+// reports deleted.
+func (j *Janitor[R]) RunOnce(ctx context.Context) int {
+	iter, err := j.domains.ListAllDomains()
+	if err != nil {
+		log.Printf("%s janitor: failed to list domains: %v", j.name, err)
+		return 0
+	}
+	defer iter.Close()
+
+	deleted := 0
+	for iter.Next() {
+		select {
+		case <-ctx.Done():
+			return deleted
+		default:
+		}
+
+		deleted += j.prune(iter.Item())
+	}
+	if err := iter.Err(); err != nil {
+		log.Printf("%s janitor: failed to iterate domains: %v", j.name, err)
+	}
+
+	if deleted > 0 {
+		log.Printf("%s janitor: %d report(s) past retention deleted", j.name, deleted)
+	}
+
+	return deleted
+}
+
+// prune deletes the reports of domain past retention. It returns the number
+// of reports deleted.
+func (j *Janitor[R]) prune(domain *happydns.Domain) int {
+	reports, err := j.list(domain.Id)
+	if err != nil {
+		log.Printf("%s janitor: unable to list reports of %s: %s", j.name, domain.DomainName, err.Error())
+		return 0
+	}
+
+	deleted := 0
+	for _, r := range reports {
+		if j.expired(r) {
+			if err := j.remove(r); err != nil {
+				log.Printf("%s janitor: unable to delete a report of %s: %s", j.name, domain.DomainName, err.Error())
+				continue
+			}
+			deleted++
+		}
+	}
+
+	return deleted
+}
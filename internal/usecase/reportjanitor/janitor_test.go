@@ -0,0 +1,88 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package reportjanitor
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+type sliceIterator struct {
+	items []*happydns.Domain
+	idx   int
+	cur   *happydns.Domain
+}
+
+func (it *sliceIterator) Next() bool {
+	if it.idx >= len(it.items) {
+		return false
+	}
+	it.cur = it.items[it.idx]
+	it.idx++
+	return true
+}
+func (it *sliceIterator) NextWithError() bool    { return it.Next() }
+func (it *sliceIterator) Item() *happydns.Domain { return it.cur }
+func (it *sliceIterator) DropItem() error        { return nil }
+func (it *sliceIterator) Key() string            { return "" }
+func (it *sliceIterator) Raw() any               { return nil }
+func (it *sliceIterator) Err() error             { return nil }
+func (it *sliceIterator) Close()                 {}
+
+type domainList []*happydns.Domain
+
+func (d domainList) ListAllDomains() (happydns.Iterator[happydns.Domain], error) {
+	return &sliceIterator{items: d}, nil
+}
+
+func TestRunOnceDeletesExpiredReports(t *testing.T) {
+	now := time.Now()
+	reports := map[string][]*happydns.TLSReport{
+		"a": {{ReportID: "old-a", End: now.Add(-200 * 24 * time.Hour)}, {ReportID: "new-a", End: now}},
+		"b": {{ReportID: "old-b", End: now.Add(-181 * 24 * time.Hour)}},
+	}
+
+	var deleted []string
+	j := NewJanitor("TLS reports", domainList{{Id: happydns.Identifier("a")}, {Id: happydns.Identifier("b")}},
+		func(domainId happydns.Identifier) ([]*happydns.TLSReport, error) {
+			return reports[string(domainId)], nil
+		},
+		func(r *happydns.TLSReport) bool {
+			return r.End.Before(now.Add(-180 * 24 * time.Hour))
+		},
+		func(r *happydns.TLSReport) error {
+			deleted = append(deleted, r.ReportID)
+			return nil
+		},
+		time.Hour)
+
+	if n := j.RunOnce(context.Background()); n != 2 {
+		t.Errorf("RunOnce() deleted %d reports, want 2", n)
+	}
+	if !slices.Equal(deleted, []string{"old-a", "old-b"}) {
+		t.Errorf("RunOnce() deleted %v, want the reports past retention only", deleted)
+	}
+}
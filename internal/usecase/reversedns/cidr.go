@@ -0,0 +1,141 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package reversedns
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// reversePlan describes the reverse zones of a network.
+type reversePlan struct {
+	// zones are the names of the reverse zones to create.
+	zones []string
+
+	// parent is the zone delegating a classless zone, empty otherwise.
+	parent string
+
+	// cnames are the records the parent needs for a classless zone.
+	cnames []*dns.CNAME
+}
+
+// planReverseZones computes the reverse zones of prefix. The zones of IPv4
+// networks start on octet boundaries and those of IPv6 networks on nibble
+// boundaries: a prefix in between is split into the zones of the next
+// boundary. IPv4 networks smaller than a /24 get an RFC 2317 classless zone,
+// named <first>/<prefix length>, and the CNAME records its parent needs.
+func planReverseZones(prefix netip.Prefix) (*reversePlan, error) {
+	prefix = prefix.Masked()
+	bits := prefix.Bits()
+
+	if prefix.Addr().Is4() {
+		if bits < 8 {
+			return nil, fmt.Errorf("%s is too large: reverse zones can be created for IPv4 networks up to a /8", prefix.String())
+		}
+
+		octets := prefix.Addr().As4()
+
+		if bits > 24 {
+			return planClassless(octets, bits), nil
+		}
+
+		// Round up to the next octet boundary.
+		zoneBits := (bits + 7) / 8 * 8
+		n := zoneBits / 8
+
+		plan := &reversePlan{}
+		for i := 0; i < 1<<(zoneBits-bits); i++ {
+			labels := make([]string, 0, n)
+			for j := n - 1; j >= 0; j-- {
+				o := int(octets[j])
+				if j == n-1 {
+					o += i
+				}
+				labels = append(labels, strconv.Itoa(o))
+			}
+			plan.zones = append(plan.zones, strings.Join(labels, ".")+".in-addr.arpa.")
+		}
+
+		return plan, nil
+	}
+
+	if bits < 16 {
+		return nil, fmt.Errorf("%s is too large: reverse zones can be created for IPv6 networks up to a /16", prefix.String())
+	}
+
+	bytes := prefix.Addr().As16()
+	nibbles := make([]int, 32)
+	for i, b := range bytes {
+		nibbles[2*i] = int(b >> 4)
+		nibbles[2*i+1] = int(b & 0xf)
+	}
+
+	// Round up to the next nibble boundary.
+	zoneBits := (bits + 3) / 4 * 4
+	n := zoneBits / 4
+
+	plan := &reversePlan{}
+	for i := 0; i < 1<<(zoneBits-bits); i++ {
+		labels := make([]string, 0, n)
+		for j := n - 1; j >= 0; j-- {
+			nb := nibbles[j]
+			if j == n-1 {
+				nb += i
+			}
+			labels = append(labels, strconv.FormatInt(int64(nb), 16))
+		}
+		plan.zones = append(plan.zones, strings.Join(labels, ".")+".ip6.arpa.")
+	}
+
+	return plan, nil
+}
+
+// planClassless computes the RFC 2317 classless zone of an IPv4 network
+// smaller than a /24, and the CNAME records of its parent zone pointing each
+// address into it.
+func planClassless(octets [4]byte, bits int) *reversePlan {
+	parent := fmt.Sprintf("%d.%d.%d.in-addr.arpa.", octets[2], octets[1], octets[0])
+	first := int(octets[3])
+	zone := fmt.Sprintf("%d/%d.%s", first, bits, parent)
+
+	plan := &reversePlan{
+		zones:  []string{zone},
+		parent: parent,
+	}
+
+	for h := first; h < first+1<<(32-bits); h++ {
+		plan.cnames = append(plan.cnames, &dns.CNAME{
+			Hdr: dns.RR_Header{
+				Name:   strconv.Itoa(h) + "." + parent,
+				Rrtype: dns.TypeCNAME,
+				Class:  dns.ClassINET,
+			},
+			Target: strconv.Itoa(h) + "." + zone,
+		})
+	}
+
+	return plan
+}
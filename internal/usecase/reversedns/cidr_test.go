@@ -0,0 +1,102 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package reversedns
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func TestPlanReverseZones(t *testing.T) {
+	tests := []struct {
+		cidr   string
+		zones  []string
+		parent string
+		cnames int
+	}{
+		{"192.0.2.0/24", []string{"2.0.192.in-addr.arpa."}, "", 0},
+		{"10.0.0.0/8", []string{"10.in-addr.arpa."}, "", 0},
+		{"192.0.2.17/24", []string{"2.0.192.in-addr.arpa."}, "", 0},
+		{"198.51.100.0/23", []string{"100.51.198.in-addr.arpa.", "101.51.198.in-addr.arpa."}, "", 0},
+		{"172.16.0.0/14", []string{"16.172.in-addr.arpa.", "17.172.in-addr.arpa.", "18.172.in-addr.arpa.", "19.172.in-addr.arpa."}, "", 0},
+		{"192.0.2.0/25", []string{"0/25.2.0.192.in-addr.arpa."}, "2.0.192.in-addr.arpa.", 128},
+		{"192.0.2.64/26", []string{"64/26.2.0.192.in-addr.arpa."}, "2.0.192.in-addr.arpa.", 64},
+		{"2001:db8::/32", []string{"8.b.d.0.1.0.0.2.ip6.arpa."}, "", 0},
+		{"2001:db8::/33", []string{"0.8.b.d.0.1.0.0.2.ip6.arpa.", "1.8.b.d.0.1.0.0.2.ip6.arpa.", "2.8.b.d.0.1.0.0.2.ip6.arpa.", "3.8.b.d.0.1.0.0.2.ip6.arpa.", "4.8.b.d.0.1.0.0.2.ip6.arpa.", "5.8.b.d.0.1.0.0.2.ip6.arpa.", "6.8.b.d.0.1.0.0.2.ip6.arpa.", "7.8.b.d.0.1.0.0.2.ip6.arpa."}, "", 0},
+		{"2001:db8:fffc::/46", []string{"c.f.f.f.8.b.d.0.1.0.0.2.ip6.arpa.", "d.f.f.f.8.b.d.0.1.0.0.2.ip6.arpa.", "e.f.f.f.8.b.d.0.1.0.0.2.ip6.arpa.", "f.f.f.f.8.b.d.0.1.0.0.2.ip6.arpa."}, "", 0},
+	}
+
+	for _, tt := range tests {
+		plan, err := planReverseZones(netip.MustParsePrefix(tt.cidr))
+		if err != nil {
+			t.Errorf("planReverseZones(%s): unexpected error: %s", tt.cidr, err)
+			continue
+		}
+
+		if !slices.Equal(plan.zones, tt.zones) {
+			t.Errorf("planReverseZones(%s).zones = %v, want %v", tt.cidr, plan.zones, tt.zones)
+		}
+		if plan.parent != tt.parent {
+			t.Errorf("planReverseZones(%s).parent = %q, want %q", tt.cidr, plan.parent, tt.parent)
+		}
+		if len(plan.cnames) != tt.cnames {
+			t.Errorf("planReverseZones(%s) has %d CNAME, want %d", tt.cidr, len(plan.cnames), tt.cnames)
+		}
+	}
+}
+
+func TestPlanClasslessCNAMEs(t *testing.T) {
+	plan, err := planReverseZones(netip.MustParsePrefix("192.0.2.128/30"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"128.2.0.192.in-addr.arpa.\t0\tIN\tCNAME\t128.128/30.2.0.192.in-addr.arpa.",
+		"129.2.0.192.in-addr.arpa.\t0\tIN\tCNAME\t129.128/30.2.0.192.in-addr.arpa.",
+		"130.2.0.192.in-addr.arpa.\t0\tIN\tCNAME\t130.128/30.2.0.192.in-addr.arpa.",
+		"131.2.0.192.in-addr.arpa.\t0\tIN\tCNAME\t131.128/30.2.0.192.in-addr.arpa.",
+	}
+
+	if len(plan.cnames) != len(want) {
+		t.Fatalf("got %d CNAME, want %d", len(plan.cnames), len(want))
+	}
+	for i, cname := range plan.cnames {
+		if cname.String() != want[i] {
+			t.Errorf("CNAME %d = %q, want %q", i, cname.String(), want[i])
+		}
+	}
+
+	// The classless zone is found back from its addresses.
+	if _, first, last, ok := splitClassless(plan.zones[0]); !ok || first != 128 || last != 131 {
+		t.Errorf("splitClassless(%s) = %d, %d, %v", plan.zones[0], first, last, ok)
+	}
+}
+
+func TestPlanReverseZonesTooLarge(t *testing.T) {
+	for _, cidr := range []string{"10.0.0.0/7", "2001::/12"} {
+		if _, err := planReverseZones(netip.MustParsePrefix(cidr)); err == nil {
+			t.Errorf("planReverseZones(%s): expected an error", cidr)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package reversedns
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/netip"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+	svcs "git.happydns.org/happyDomain/services"
+)
+
+// CreateZones creates the reverse zones of the network given in input. When
+// the network is smaller than a /24 and the user manages the parent zone, the
+// RFC 2317 CNAME records pointing its addresses into the classless zone are
+// added to the current zone of the parent, without being published. Should
+// the creation of a zone fail, those created before are deleted.
+func (s *Service) CreateZones(ctx context.Context, user *happydns.User, input *happydns.ReverseZonesCreationInput) (*happydns.ReverseZonesCreation, error) {
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(input.CIDR))
+	if err != nil {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("invalid network %q: expected a CIDR, like 192.0.2.0/24 or 2001:db8::/48", input.CIDR)}
+	}
+
+	plan, err := planReverseZones(prefix)
+	if err != nil {
+		return nil, happydns.ValidationError{Msg: err.Error()}
+	}
+
+	domains, err := s.listDomains(user)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refuse the whole network rather than creating only part of it.
+	for _, zone := range plan.zones {
+		if d := findDomain(domains, zone); d != nil {
+			return nil, happydns.ValidationError{Msg: fmt.Sprintf("%s is already managed", d.DomainName)}
+		}
+	}
+
+	ret := &happydns.ReverseZonesCreation{
+		Parent: plan.parent,
+	}
+
+	for _, zone := range plan.zones {
+		d, err := s.creator.CreateDomain(ctx, user, &happydns.DomainCreationInput{
+			ProviderId: input.ProviderId,
+			DomainName: zone,
+		})
+		if err != nil {
+			return nil, s.rollback(ret.Domains, err)
+		}
+		ret.Domains = append(ret.Domains, d)
+	}
+
+	for _, cname := range plan.cnames {
+		ret.CNAMEs = append(ret.CNAMEs, cname.String())
+	}
+
+	if plan.parent == "" {
+		return ret, nil
+	}
+
+	parent := findDomain(domains, plan.parent)
+	if parent == nil {
+		return ret, nil
+	}
+
+	zone, err := s.currentZone(parent)
+	if err != nil {
+		// The CNAME records are still listed, to be added by hand once the
+		// parent zone is imported.
+		return ret, nil
+	}
+
+	for _, cname := range plan.cnames {
+		subdomain := happydns.Subdomain(strings.TrimSuffix(cname.Hdr.Name, "."+plan.parent))
+
+		if len(zone.Services[subdomain]) > 0 {
+			ret.Skipped = append(ret.Skipped, cname.Hdr.Name)
+			continue
+		}
+
+		zone, err = s.zoneService.AddServiceToZone(user, parent, zone, subdomain, happydns.Origin(parent.DomainName), &happydns.Service{
+			ServiceMeta: happydns.ServiceMeta{
+				Type:        "svcs.Alias",
+				UserComment: "Generated by happyDomain",
+			},
+			Service: &svcs.Alias{
+				Record: &dns.CNAME{
+					Hdr: dns.RR_Header{
+						Name:   "",
+						Rrtype: dns.TypeCNAME,
+						Class:  dns.ClassINET,
+					},
+					Target: cname.Target,
+				},
+			},
+		})
+		if err != nil {
+			return ret, fmt.Errorf("unable to add the classless delegation of %s to %s: %w", cname.Hdr.Name, parent.DomainName, err)
+		}
+	}
+	ret.ParentId = parent.Id
+
+	return ret, nil
+}
+
+// rollback deletes the domains created before the creation of the next one
+// failed with err, so that the network is not left partly managed. The
+// returned error names the domains that could not be deleted.
+func (s *Service) rollback(created []*happydns.Domain, err error) error {
+	var left []string
+	for i := len(created) - 1; i >= 0; i-- {
+		if derr := s.creator.DeleteDomain(created[i].Id); derr != nil {
+			log.Printf("reverse DNS: unable to delete %s after a failed creation: %s", created[i].DomainName, derr.Error())
+			left = append(left, created[i].DomainName)
+		}
+	}
+
+	if len(left) > 0 {
+		return happydns.InternalError{
+			Err:         fmt.Errorf("unable to create the reverse zones: %w", err),
+			UserMessage: fmt.Sprintf("Sorry, we are unable to create the reverse zones of this network, and %s, already created, could not be removed: please delete them.", strings.Join(left, ", ")),
+		}
+	}
+
+	return err
+}
+
+// findDomain returns the domain of the given name, if any.
+func findDomain(domains []*happydns.Domain, name string) *happydns.Domain {
+	for _, d := range domains {
+		if dns.CanonicalName(d.DomainName) == dns.CanonicalName(name) {
+			return d
+		}
+	}
+	return nil
+}
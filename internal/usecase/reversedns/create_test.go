@@ -0,0 +1,72 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package reversedns
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+type noDomains struct{}
+
+func (noDomains) ListDomains(*happydns.User) ([]*happydns.Domain, error) {
+	return nil, nil
+}
+
+// failingCreator fails to create the failAt-th domain, and records the
+// domains it deletes.
+type failingCreator struct {
+	failAt  int
+	created int
+	deleted []string
+}
+
+func (c *failingCreator) CreateDomain(_ context.Context, user *happydns.User, input *happydns.DomainCreationInput) (*happydns.Domain, error) {
+	c.created++
+	if c.created == c.failAt {
+		return nil, errors.New("provider unreachable")
+	}
+	return &happydns.Domain{Id: happydns.Identifier(input.DomainName), DomainName: input.DomainName}, nil
+}
+
+func (c *failingCreator) DeleteDomain(domainID happydns.Identifier) error {
+	c.deleted = append(c.deleted, string(domainID))
+	return nil
+}
+
+func TestCreateZonesRollsBack(t *testing.T) {
+	creator := &failingCreator{failAt: 3}
+	s := NewService(noDomains{}, creator, nil, nil, nil, nil)
+
+	ret, err := s.CreateZones(context.Background(), &happydns.User{}, &happydns.ReverseZonesCreationInput{CIDR: "172.16.0.0/14"})
+	if err == nil {
+		t.Fatalf("CreateZones() succeeded although the creation of a zone failed: %v", ret)
+	}
+
+	if want := []string{"17.172.in-addr.arpa.", "16.172.in-addr.arpa."}; !slices.Equal(creator.deleted, want) {
+		t.Errorf("CreateZones() deleted %v, want the zones created before the failure: %v", creator.deleted, want)
+	}
+}
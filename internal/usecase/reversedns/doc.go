@@ -0,0 +1,31 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package reversedns keeps the reverse zones of a user in line with the
+// servers declared in their forward zones.
+//
+// When the user owns both a forward zone and the in-addr.arpa or ip6.arpa
+// zone covering the addresses of its servers (RFC 2317 classless delegations
+// included), the Service proposes the PTR records pointing back at the
+// servers, applies them to the reverse zones and returns the diff of all the
+// zones involved. It also reports the forward-confirmed reverse DNS
+// mismatches found across all the zones of the user.
+package reversedns
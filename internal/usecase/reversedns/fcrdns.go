@@ -0,0 +1,227 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package reversedns
+
+import (
+	"log"
+	"net"
+	"slices"
+	"sort"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// zoneRecords holds the records of the current zone of a Domain.
+type zoneRecords struct {
+	domain  *happydns.Domain
+	records []happydns.Record
+}
+
+// Check reports the forward-confirmed reverse DNS mismatches across the
+// current zones of the user. Only the zones the user owns on both sides are
+// compared: an address whose reverse zone is managed elsewhere is not
+// reported.
+func (s *Service) Check(user *happydns.User) ([]*happydns.FCrDNSIssue, error) {
+	domains, err := s.listDomains(user)
+	if err != nil {
+		return nil, err
+	}
+
+	var zones []zoneRecords
+	for _, d := range domains {
+		zone, err := s.currentZone(d)
+		if err != nil {
+			continue
+		}
+
+		rrs, err := s.listRecords.List(d, zone)
+		if err != nil {
+			log.Printf("ReverseDNS: unable to list the records of %s: %s", d.DomainName, err.Error())
+			continue
+		}
+
+		zones = append(zones, zoneRecords{domain: d, records: rrs})
+	}
+
+	return checkFCrDNS(domains, zones), nil
+}
+
+// checkFCrDNS compares the addresses of the forward zones with the PTR
+// records of the reverse zones.
+func checkFCrDNS(domains []*happydns.Domain, zones []zoneRecords) (issues []*happydns.FCrDNSIssue) {
+	// Addresses of each forward name, and where they are declared.
+	addrs := map[string][]string{}
+	type forward struct {
+		name   string
+		ip     net.IP
+		domain *happydns.Domain
+	}
+	var forwards []forward
+
+	// PTR targets of each reverse name, and where they are declared.
+	ptrs := map[string][]string{}
+	type reverse struct {
+		arpa   string
+		target string
+		domain *happydns.Domain
+	}
+	var reverses []reverse
+
+	for _, z := range zones {
+		isReverse := IsReverseZone(z.domain.DomainName)
+
+		for _, rr := range z.records {
+			switch rr := rr.(type) {
+			case *dns.A:
+				name := dns.CanonicalName(rr.Hdr.Name)
+				addrs[name] = append(addrs[name], rr.A.String())
+				forwards = append(forwards, forward{name, rr.A, z.domain})
+			case *dns.AAAA:
+				name := dns.CanonicalName(rr.Hdr.Name)
+				addrs[name] = append(addrs[name], rr.AAAA.String())
+				forwards = append(forwards, forward{name, rr.AAAA, z.domain})
+			case *dns.PTR:
+				if !isReverse {
+					continue
+				}
+				arpa := canonicalArpa(rr.Hdr.Name, z.domain.DomainName)
+				target := dns.CanonicalName(rr.Ptr)
+				ptrs[arpa] = append(ptrs[arpa], target)
+				reverses = append(reverses, reverse{arpa, target, z.domain})
+			}
+		}
+	}
+
+	for _, f := range forwards {
+		arpa, err := dns.ReverseAddr(f.ip.String())
+		if err != nil {
+			continue
+		}
+		if rdomain, _ := findReverseZone(arpa, domains); rdomain == nil {
+			continue
+		}
+
+		targets, ok := ptrs[arpa]
+		if !ok {
+			issues = append(issues, &happydns.FCrDNSIssue{
+				Kind:     happydns.FCrDNSMissingPTR,
+				Address:  f.ip.String(),
+				Name:     f.name,
+				DomainId: f.domain.Id,
+			})
+		} else if !slices.Contains(targets, f.name) {
+			issues = append(issues, &happydns.FCrDNSIssue{
+				Kind:     happydns.FCrDNSPTRMismatch,
+				Address:  f.ip.String(),
+				Name:     f.name,
+				DomainId: f.domain.Id,
+				Found:    targets,
+			})
+		}
+	}
+
+	for _, r := range reverses {
+		if !ownsForward(r.target, domains) {
+			continue
+		}
+
+		ip := arpaToIP(r.arpa)
+		if ip == nil {
+			continue
+		}
+
+		found, ok := addrs[r.target]
+		if !ok {
+			issues = append(issues, &happydns.FCrDNSIssue{
+				Kind:     happydns.FCrDNSMissingForward,
+				Address:  ip.String(),
+				Name:     r.target,
+				DomainId: r.domain.Id,
+			})
+		} else if !containsIP(found, ip) {
+			issues = append(issues, &happydns.FCrDNSIssue{
+				Kind:     happydns.FCrDNSForwardMismatch,
+				Address:  ip.String(),
+				Name:     r.target,
+				DomainId: r.domain.Id,
+				Found:    found,
+			})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Address != issues[j].Address {
+			return issues[i].Address < issues[j].Address
+		}
+		return issues[i].Name < issues[j].Name
+	})
+
+	return
+}
+
+// ownsForward tells whether name lies in a forward zone of the user.
+func ownsForward(name string, domains []*happydns.Domain) bool {
+	for _, d := range domains {
+		if !IsReverseZone(d.DomainName) && dns.IsSubDomain(dns.CanonicalName(d.DomainName), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// arpaToIP converts back a complete in-addr.arpa or ip6.arpa name into the
+// address it stands for.
+func arpaToIP(arpa string) net.IP {
+	labels := dns.SplitDomainName(arpa)
+	n := len(labels)
+
+	if n == 6 && labels[4] == "in-addr" && labels[5] == "arpa" {
+		return net.ParseIP(labels[3] + "." + labels[2] + "." + labels[1] + "." + labels[0]).To4()
+	}
+
+	if n == 34 && labels[32] == "ip6" && labels[33] == "arpa" {
+		buf := make([]byte, 0, 39)
+		for i := 31; i >= 0; i-- {
+			if len(labels[i]) != 1 {
+				return nil
+			}
+			buf = append(buf, labels[i][0])
+			if i%4 == 0 && i > 0 {
+				buf = append(buf, ':')
+			}
+		}
+		return net.ParseIP(string(buf))
+	}
+
+	return nil
+}
+
+func containsIP(addrs []string, ip net.IP) bool {
+	for _, a := range addrs {
+		if other := net.ParseIP(a); other != nil && other.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
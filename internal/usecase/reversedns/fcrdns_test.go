@@ -0,0 +1,80 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package reversedns
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+func TestCheckFCrDNS(t *testing.T) {
+	fwd := &happydns.Domain{Id: happydns.Identifier("fwd"), DomainName: "example.com."}
+	rev := &happydns.Domain{Id: happydns.Identifier("rev"), DomainName: "2.0.192.in-addr.arpa."}
+	domains := []*happydns.Domain{fwd, rev}
+
+	a := func(name, ip string) happydns.Record {
+		return &dns.A{Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET}, A: net.ParseIP(ip).To4()}
+	}
+	ptr := func(name, target string) happydns.Record {
+		return &dns.PTR{Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypePTR, Class: dns.ClassINET}, Ptr: target}
+	}
+
+	zones := []zoneRecords{
+		{domain: fwd, records: []happydns.Record{
+			a("ok.example.com.", "192.0.2.1"),
+			a("noptr.example.com.", "192.0.2.2"),
+			a("wrong.example.com.", "192.0.2.3"),
+			a("elsewhere.example.com.", "198.51.100.1"),
+			a("moved.example.com.", "192.0.2.40"),
+		}},
+		{domain: rev, records: []happydns.Record{
+			ptr("1.2.0.192.in-addr.arpa.", "ok.example.com."),
+			ptr("3.2.0.192.in-addr.arpa.", "other.example.net."),
+			ptr("4.2.0.192.in-addr.arpa.", "gone.example.com."),
+			ptr("5.2.0.192.in-addr.arpa.", "moved.example.com."),
+		}},
+	}
+
+	issues := checkFCrDNS(domains, zones)
+
+	want := map[string]happydns.FCrDNSIssueKind{
+		"192.0.2.2/noptr.example.com.":  happydns.FCrDNSMissingPTR,
+		"192.0.2.3/wrong.example.com.":  happydns.FCrDNSPTRMismatch,
+		"192.0.2.4/gone.example.com.":   happydns.FCrDNSMissingForward,
+		"192.0.2.5/moved.example.com.":  happydns.FCrDNSForwardMismatch,
+		"192.0.2.40/moved.example.com.": happydns.FCrDNSMissingPTR,
+	}
+
+	if len(issues) != len(want) {
+		t.Fatalf("got %d issues, want %d: %+v", len(issues), len(want), issues)
+	}
+	for _, issue := range issues {
+		key := issue.Address + "/" + issue.Name
+		if kind, ok := want[key]; !ok || kind != issue.Kind {
+			t.Errorf("unexpected issue %s: %s", key, issue.Kind)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package reversedns
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// IsReverseZone tells whether origin lies under in-addr.arpa or ip6.arpa.
+func IsReverseZone(origin string) bool {
+	origin = dns.CanonicalName(origin)
+	return dns.IsSubDomain("in-addr.arpa.", origin) || dns.IsSubDomain("ip6.arpa.", origin)
+}
+
+// ClasslessRange parses the first label of an RFC 2317 classless reverse
+// zone, either "<first>/<prefix length>" or "<first>-<last>", and returns the
+// range of last octets it covers.
+func ClasslessRange(label string) (first, last int, ok bool) {
+	if a, b, found := strings.Cut(label, "/"); found {
+		var plen int
+		first, plen, ok = parseOctetPair(a, b)
+		if !ok || plen < 24 || plen > 32 {
+			return 0, 0, false
+		}
+
+		size := 1 << (32 - plen)
+		if first%size != 0 {
+			return 0, 0, false
+		}
+		return first, first + size - 1, true
+	}
+
+	if a, b, found := strings.Cut(label, "-"); found {
+		first, last, ok = parseOctetPair(a, b)
+		if !ok || first > last {
+			return 0, 0, false
+		}
+		return first, last, true
+	}
+
+	return 0, 0, false
+}
+
+func parseOctetPair(a, b string) (int, int, bool) {
+	x, err := strconv.Atoi(a)
+	if err != nil || x < 0 || x > 255 {
+		return 0, 0, false
+	}
+
+	y, err := strconv.Atoi(b)
+	if err != nil || y < 0 || y > 255 {
+		return 0, 0, false
+	}
+
+	return x, y, true
+}
+
+// splitClassless returns the parent zone of an RFC 2317 classless zone, along
+// with the range of last octets it covers. ok is false when origin is not
+// such a zone.
+func splitClassless(origin string) (parent string, first, last int, ok bool) {
+	origin = dns.CanonicalName(origin)
+	if !dns.IsSubDomain("in-addr.arpa.", origin) {
+		return "", 0, 0, false
+	}
+
+	label, parent, found := strings.Cut(origin, ".")
+	if !found {
+		return "", 0, 0, false
+	}
+
+	first, last, ok = ClasslessRange(label)
+	return parent, first, last, ok
+}
+
+// findReverseZone looks among domains for the reverse zone holding the PTR
+// record of arpa, and returns it along with the subdomain of the record. When
+// several zones match, the most specific one wins: a classless zone is
+// preferred to the /24 zone delegating it.
+func findReverseZone(arpa string, domains []*happydns.Domain) (best *happydns.Domain, subdomain string) {
+	arpa = dns.CanonicalName(arpa)
+	octet, arpaParent, _ := strings.Cut(arpa, ".")
+	bestLabels := -1
+
+	for _, d := range domains {
+		origin := dns.CanonicalName(d.DomainName)
+		if !IsReverseZone(origin) {
+			continue
+		}
+
+		labels := dns.CountLabel(origin)
+		if labels <= bestLabels {
+			continue
+		}
+
+		if parent, first, last, ok := splitClassless(origin); ok {
+			n, err := strconv.Atoi(octet)
+			if err != nil || parent != arpaParent || n < first || n > last {
+				continue
+			}
+			best, subdomain, bestLabels = d, octet, labels
+		} else if dns.IsSubDomain(origin, arpa) {
+			best, subdomain, bestLabels = d, strings.TrimSuffix(strings.TrimSuffix(arpa, origin), "."), labels
+		}
+	}
+
+	return
+}
+
+// canonicalArpa returns the reverse name a record owner of the given reverse
+// zone stands for: the owners of a classless zone are moved back under its
+// parent zone.
+func canonicalArpa(owner string, origin string) string {
+	owner = dns.CanonicalName(owner)
+
+	parent, _, _, ok := splitClassless(origin)
+	if !ok {
+		return owner
+	}
+
+	rel := strings.TrimSuffix(strings.TrimSuffix(owner, dns.CanonicalName(origin)), ".")
+	if rel == "" || strings.Contains(rel, ".") {
+		return owner
+	}
+
+	return rel + "." + parent
+}
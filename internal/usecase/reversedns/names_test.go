@@ -0,0 +1,106 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package reversedns
+
+import (
+	"testing"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+func TestClasslessRange(t *testing.T) {
+	tests := []struct {
+		label       string
+		first, last int
+		ok          bool
+	}{
+		{"0/25", 0, 127, true},
+		{"128/25", 128, 255, true},
+		{"64/26", 64, 127, true},
+		{"0-63", 0, 63, true},
+		{"5/32", 5, 5, true},
+		{"10/25", 0, 0, false},
+		{"0/16", 0, 0, false},
+		{"63-0", 0, 0, false},
+		{"2", 0, 0, false},
+		{"a/25", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		first, last, ok := ClasslessRange(tt.label)
+		if ok != tt.ok || first != tt.first || last != tt.last {
+			t.Errorf("ClasslessRange(%q) = %d, %d, %v; want %d, %d, %v", tt.label, first, last, ok, tt.first, tt.last, tt.ok)
+		}
+	}
+}
+
+func TestFindReverseZone(t *testing.T) {
+	domains := []*happydns.Domain{
+		{Id: happydns.Identifier("fwd"), DomainName: "example.com."},
+		{Id: happydns.Identifier("v4"), DomainName: "2.0.192.in-addr.arpa."},
+		{Id: happydns.Identifier("cl"), DomainName: "128/25.2.0.192.in-addr.arpa."},
+		{Id: happydns.Identifier("v6"), DomainName: "8.b.d.0.1.0.0.2.ip6.arpa."},
+	}
+
+	tests := []struct {
+		arpa      string
+		domain    string
+		subdomain string
+	}{
+		{"5.2.0.192.in-addr.arpa.", "v4", "5"},
+		{"200.2.0.192.in-addr.arpa.", "cl", "200"},
+		{"5.3.0.192.in-addr.arpa.", "", ""},
+		{"1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.", "v6", "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0"},
+	}
+
+	for _, tt := range tests {
+		d, sub := findReverseZone(tt.arpa, domains)
+		got := ""
+		if d != nil {
+			got = string(d.Id)
+		}
+		if got != tt.domain || sub != tt.subdomain {
+			t.Errorf("findReverseZone(%q) = %q, %q; want %q, %q", tt.arpa, got, sub, tt.domain, tt.subdomain)
+		}
+	}
+}
+
+func TestCanonicalArpa(t *testing.T) {
+	if got := canonicalArpa("200.128/25.2.0.192.in-addr.arpa.", "128/25.2.0.192.in-addr.arpa."); got != "200.2.0.192.in-addr.arpa." {
+		t.Errorf("canonicalArpa in a classless zone = %q", got)
+	}
+	if got := canonicalArpa("5.2.0.192.in-addr.arpa.", "2.0.192.in-addr.arpa."); got != "5.2.0.192.in-addr.arpa." {
+		t.Errorf("canonicalArpa in a /24 zone = %q", got)
+	}
+}
+
+func TestArpaToIP(t *testing.T) {
+	if ip := arpaToIP("5.2.0.192.in-addr.arpa."); ip == nil || ip.String() != "192.0.2.5" {
+		t.Errorf("arpaToIP(IPv4) = %v", ip)
+	}
+	if ip := arpaToIP("1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa."); ip == nil || ip.String() != "2001:db8::1" {
+		t.Errorf("arpaToIP(IPv6) = %v", ip)
+	}
+	if ip := arpaToIP("2.0.192.in-addr.arpa."); ip != nil {
+		t.Errorf("arpaToIP(partial) = %v, want nil", ip)
+	}
+}
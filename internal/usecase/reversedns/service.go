@@ -0,0 +1,325 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package reversedns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/internal/helpers"
+	"git.happydns.org/happyDomain/model"
+	svcs "git.happydns.org/happyDomain/services"
+	"git.happydns.org/happyDomain/services/abstract"
+)
+
+// Service implements happydns.ReverseDNSUsecase.
+type Service struct {
+	domains     DomainLister
+	creator     DomainCreator
+	getZone     ZoneGetter
+	listRecords RecordLister
+	zoneService happydns.ZoneServiceUsecase
+	corrections CorrectionLister
+}
+
+// NewService builds the reverse DNS Service.
+func NewService(
+	domains DomainLister,
+	creator DomainCreator,
+	getZone ZoneGetter,
+	listRecords RecordLister,
+	zoneService happydns.ZoneServiceUsecase,
+	corrections CorrectionLister,
+) *Service {
+	return &Service{
+		domains:     domains,
+		creator:     creator,
+		getZone:     getZone,
+		listRecords: listRecords,
+		zoneService: zoneService,
+		corrections: corrections,
+	}
+}
+
+// serverAddress is an address of a server declared in a forward zone.
+type serverAddress struct {
+	ip   net.IP
+	name string
+}
+
+// Plan lists the PTR records to create or update for the servers of domain.
+func (s *Service) Plan(user *happydns.User, domain *happydns.Domain) ([]*happydns.ReverseDNSProposal, error) {
+	domains, err := s.listDomains(user)
+	if err != nil {
+		return nil, err
+	}
+
+	proposals, _, err := s.plan(domain, domains)
+	return proposals, err
+}
+
+// plan computes the proposals for the servers of domain, and returns the
+// reverse zones covering them, keyed by Domain identifier, with their current
+// zone.
+func (s *Service) plan(domain *happydns.Domain, domains []*happydns.Domain) ([]*happydns.ReverseDNSProposal, map[string]*reverseZone, error) {
+	zone, err := s.currentZone(domain)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var proposals []*happydns.ReverseDNSProposal
+	reverses := map[string]*reverseZone{}
+	seen := map[string]bool{}
+
+	for _, srv := range serverAddresses(domain, zone) {
+		arpa, err := dns.ReverseAddr(srv.ip.String())
+		if err != nil || seen[arpa] {
+			continue
+		}
+		seen[arpa] = true
+
+		rdomain, subdomain := findReverseZone(arpa, domains)
+		if rdomain == nil {
+			continue
+		}
+
+		rz, ok := reverses[rdomain.Id.String()]
+		if !ok {
+			rzone, err := s.currentZone(rdomain)
+			if err != nil {
+				// A reverse zone not imported yet cannot be edited.
+				continue
+			}
+			rz = &reverseZone{domain: rdomain, zone: rzone}
+			reverses[rdomain.Id.String()] = rz
+		}
+
+		proposal := &happydns.ReverseDNSProposal{
+			Address:         srv.ip.String(),
+			Target:          srv.name,
+			ReverseDomainId: rdomain.Id,
+			ReverseDomain:   rdomain.DomainName,
+			Subdomain:       happydns.Subdomain(subdomain),
+		}
+
+		if svc, ptr := findPTRService(rz.zone, proposal.Subdomain); svc != nil {
+			current := helpers.DomainFQDN(ptr.Ptr, rdomain.DomainName)
+			if dns.CanonicalName(current) == dns.CanonicalName(srv.name) {
+				continue
+			}
+			proposal.ServiceId = svc.Id
+			proposal.Current = current
+		}
+
+		proposals = append(proposals, proposal)
+	}
+
+	return proposals, reverses, nil
+}
+
+// Diff returns the corrections of domain and of the reverse zones covering
+// its servers.
+func (s *Service) Diff(ctx context.Context, user *happydns.User, domain *happydns.Domain) ([]*happydns.DomainCorrections, error) {
+	domains, err := s.listDomains(user)
+	if err != nil {
+		return nil, err
+	}
+
+	_, reverses, err := s.plan(domain, domains)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.diff(ctx, user, domain, reverses)
+}
+
+// Sync applies the selected proposals to the current zones of the reverse
+// domains, then returns the combined diff. Nothing is published: the user
+// reviews and applies the corrections of each zone.
+func (s *Service) Sync(ctx context.Context, user *happydns.User, domain *happydns.Domain, form *happydns.ReverseDNSSyncForm) ([]*happydns.DomainCorrections, error) {
+	domains, err := s.listDomains(user)
+	if err != nil {
+		return nil, err
+	}
+
+	proposals, reverses, err := s.plan(domain, domains)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range proposals {
+		if form != nil && len(form.Addresses) > 0 && !slices.Contains(form.Addresses, p.Address) {
+			continue
+		}
+
+		rz := reverses[p.ReverseDomainId.String()]
+
+		svc := &happydns.Service{
+			ServiceMeta: happydns.ServiceMeta{
+				Type:        "svcs.PTR",
+				UserComment: "Generated by happyDomain",
+			},
+			Service: &svcs.PTR{
+				Record: &dns.PTR{
+					Hdr: dns.RR_Header{
+						Name:   "",
+						Rrtype: dns.TypePTR,
+						Class:  dns.ClassINET,
+					},
+					Ptr: dns.Fqdn(p.Target),
+				},
+			},
+		}
+
+		var newZone *happydns.Zone
+		if p.ServiceId != nil {
+			svc.Id = p.ServiceId
+			newZone, err = s.zoneService.UpdateZoneService(user, rz.domain, rz.zone, p.Subdomain, p.ServiceId, svc)
+		} else {
+			newZone, err = s.zoneService.AddServiceToZone(user, rz.domain, rz.zone, p.Subdomain, happydns.Origin(rz.domain.DomainName), svc)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to set the PTR record of %s in %s: %w", p.Address, rz.domain.DomainName, err)
+		}
+		rz.zone = newZone
+	}
+
+	return s.diff(ctx, user, domain, reverses)
+}
+
+func (s *Service) diff(ctx context.Context, user *happydns.User, domain *happydns.Domain, reverses map[string]*reverseZone) ([]*happydns.DomainCorrections, error) {
+	zone, err := s.currentZone(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	dc, err := s.domainCorrections(ctx, user, domain, zone)
+	if err != nil {
+		return nil, err
+	}
+	ret := []*happydns.DomainCorrections{dc}
+
+	keys := make([]string, 0, len(reverses))
+	for k := range reverses {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return reverses[keys[i]].domain.DomainName < reverses[keys[j]].domain.DomainName
+	})
+
+	for _, k := range keys {
+		rz := reverses[k]
+		if rz.domain.Id.Equals(domain.Id) {
+			continue
+		}
+
+		dc, err := s.domainCorrections(ctx, user, rz.domain, rz.zone)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, dc)
+	}
+
+	return ret, nil
+}
+
+func (s *Service) domainCorrections(ctx context.Context, user *happydns.User, domain *happydns.Domain, zone *happydns.Zone) (*happydns.DomainCorrections, error) {
+	corrections, nbDiffs, err := s.corrections.List(ctx, user, domain, zone)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute the corrections of %s: %w", domain.DomainName, err)
+	}
+
+	return &happydns.DomainCorrections{
+		DomainId:    domain.Id,
+		DomainName:  domain.DomainName,
+		Corrections: corrections,
+		NbDiffs:     nbDiffs,
+	}, nil
+}
+
+// reverseZone is a reverse Domain along with its zone being edited.
+type reverseZone struct {
+	domain *happydns.Domain
+	zone   *happydns.Zone
+}
+
+// serverAddresses lists the addresses of the servers declared in zone, in a
+// stable order.
+func serverAddresses(domain *happydns.Domain, zone *happydns.Zone) (ret []serverAddress) {
+	subdomains := make([]string, 0, len(zone.Services))
+	for sub := range zone.Services {
+		subdomains = append(subdomains, string(sub))
+	}
+	sort.Strings(subdomains)
+
+	for _, sub := range subdomains {
+		for _, svc := range zone.Services[happydns.Subdomain(sub)] {
+			srv, ok := svc.Service.(*abstract.Server)
+			if !ok {
+				continue
+			}
+
+			if srv.A != nil && len(srv.A.A) != 0 {
+				ret = append(ret, serverAddress{ip: srv.A.A, name: helpers.DomainJoin(srv.A.Hdr.Name, sub, domain.DomainName)})
+			}
+			if srv.AAAA != nil && len(srv.AAAA.AAAA) != 0 {
+				ret = append(ret, serverAddress{ip: srv.AAAA.AAAA, name: helpers.DomainJoin(srv.AAAA.Hdr.Name, sub, domain.DomainName)})
+			}
+		}
+	}
+
+	return
+}
+
+// findPTRService looks for the PTR service of the given subdomain.
+func findPTRService(zone *happydns.Zone, subdomain happydns.Subdomain) (*happydns.Service, *dns.PTR) {
+	for _, svc := range zone.Services[subdomain] {
+		if ptr, ok := svc.Service.(*svcs.PTR); ok && ptr.Record != nil {
+			return svc, ptr.Record
+		}
+	}
+	return nil, nil
+}
+
+func (s *Service) listDomains(user *happydns.User) ([]*happydns.Domain, error) {
+	domains, err := s.domains.ListDomains(user)
+	if err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to ListDomains(%s): %w", user.Id.String(), err),
+			UserMessage: "Sorry, we are currently unable to retrieve your domains. Please retry later.",
+		}
+	}
+	return domains, nil
+}
+
+func (s *Service) currentZone(domain *happydns.Domain) (*happydns.Zone, error) {
+	if len(domain.ZoneHistory) == 0 {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("%s has no zone yet: import it first", domain.DomainName)}
+	}
+
+	return s.getZone.Get(domain.ZoneHistory[0])
+}
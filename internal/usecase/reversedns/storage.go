@@ -0,0 +1,56 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package reversedns
+
+import (
+	"context"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// DomainLister retrieves the domains of a user, among which the reverse
+// zones are looked for.
+type DomainLister interface {
+	ListDomains(user *happydns.User) ([]*happydns.Domain, error)
+}
+
+// ZoneGetter retrieves a Zone by its identifier.
+type ZoneGetter interface {
+	Get(zoneID happydns.Identifier) (*happydns.Zone, error)
+}
+
+// RecordLister expands a zone into its records, with absolute names.
+type RecordLister interface {
+	List(domain *happydns.Domain, zone *happydns.Zone) ([]happydns.Record, error)
+}
+
+// CorrectionLister computes the corrections pending on a zone.
+type CorrectionLister interface {
+	List(ctx context.Context, user *happydns.User, domain *happydns.Domain, zone *happydns.Zone) ([]*happydns.Correction, int, error)
+}
+
+// DomainCreator creates a Domain on behalf of a user, checking it exists on
+// the provider, and deletes it back.
+type DomainCreator interface {
+	CreateDomain(ctx context.Context, user *happydns.User, input *happydns.DomainCreationInput) (*happydns.Domain, error)
+	DeleteDomain(domainID happydns.Identifier) error
+}
@@ -43,6 +43,10 @@ func NewValidateServiceUsecase() *ValidateServiceUsecase {
 // SHA-1 hash of all record strings concatenated — suitable for change
 // detection on the client side.
 func (uc *ValidateServiceUsecase) Validate(svc happydns.ServiceBody, subdomain happydns.Subdomain, origin happydns.Origin) ([]byte, error) {
+	if err := ValidateFields(svc); err != nil {
+		return nil, err
+	}
+
 	rrs, err := svc.GetRecords(string(subdomain), 0, string(origin))
 	if err != nil {
 		return nil, fmt.Errorf("unable to retrieve records: %w", err)
@@ -59,3 +63,13 @@ func (uc *ValidateServiceUsecase) Validate(svc happydns.ServiceBody, subdomain h
 		return hash.Sum(nil), nil
 	}
 }
+
+// ValidateFields checks the fields of svc against the rules of its type, when
+// it has some.
+func ValidateFields(svc happydns.ServiceBody) error {
+	if validator, ok := svc.(happydns.ServiceValidator); ok {
+		return validator.Validate()
+	}
+
+	return nil
+}
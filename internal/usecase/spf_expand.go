@@ -0,0 +1,468 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+const (
+	// spfExpandMaxQueries bounds the queries an expansion does. Unlike an
+	// evaluation, it follows every include and resolves every MX host, but a
+	// record needing more than that is not one to publish flattened.
+	spfExpandMaxQueries = 100
+	spfExpandMaxMX      = 10
+	spfExpandDeadline   = 30 * time.Second
+
+	// spfExpandMaxAnswer is the size the answer holding the flattened
+	// record has to fit in: that of a DNS answer over UDP without EDNS0
+	// (RFC 7208 section 3.4).
+	spfExpandMaxAnswer = 512
+)
+
+// errSPFUnexpandable is returned for a record holding a term that doesn't
+// stand for a fixed set of addresses.
+var errSPFUnexpandable = errors.New("term cannot be expanded into addresses")
+
+// spfExpandSource answers the queries needed to expand an SPF record.
+type spfExpandSource interface {
+	TXT(name string) ([]string, error)
+	Addrs(name string) ([]net.IP, error)
+	MX(name string) ([]string, error)
+}
+
+// dnsSPFSource queries the given resolver.
+type dnsSPFSource struct {
+	client   dns.Client
+	resolver string
+}
+
+func (s dnsSPFSource) TXT(name string) ([]string, error) {
+	records, _, err := queryTXT(s.client, s.resolver, name)
+	return records, err
+}
+
+func (s dnsSPFSource) Addrs(name string) ([]net.IP, error) {
+	var out []net.IP
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		answers, err := querySPF(s.client, s.resolver, name, qtype)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rr := range answers {
+			switch a := rr.(type) {
+			case *dns.A:
+				out = append(out, a.A)
+			case *dns.AAAA:
+				out = append(out, a.AAAA)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (s dnsSPFSource) MX(name string) ([]string, error) {
+	answers, err := querySPF(s.client, s.resolver, name, dns.TypeMX)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, rr := range answers {
+		if mx, ok := rr.(*dns.MX); ok {
+			out = append(out, mx.Mx)
+		}
+	}
+	return out, nil
+}
+
+// spfExpander rewrites an SPF record into ip4/ip6 terms.
+type spfExpander struct {
+	src      spfExpandSource
+	queries  int
+	deadline time.Time
+	visited  map[string]struct{}
+	kept     []string
+}
+
+func (e *spfExpander) query() error {
+	e.queries++
+	if e.queries > spfExpandMaxQueries {
+		return fmt.Errorf("the record needs more than %d queries to be expanded", spfExpandMaxQueries)
+	}
+	if time.Now().After(e.deadline) {
+		return errors.New("the expansion took too long")
+	}
+	return nil
+}
+
+// fetch returns the SPF record published at domain.
+func (e *spfExpander) fetch(domain string) (string, error) {
+	if err := e.query(); err != nil {
+		return "", err
+	}
+
+	records, err := e.src.TXT(domain)
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve the SPF record of %s: %w", domain, err)
+	}
+
+	record := pickSPFRecord(records)
+	if record == "" {
+		return "", fmt.Errorf("%s has no SPF record", domain)
+	}
+	return record, nil
+}
+
+// addresses returns the ip4/ip6 terms standing for the addresses of name,
+// prefixed by qualifier.
+func (e *spfExpander) addresses(name, qualifier, cidr4, cidr6 string) ([]string, error) {
+	if err := e.query(); err != nil {
+		return nil, err
+	}
+
+	addrs, err := e.src.Addrs(name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve %s: %w", name, err)
+	}
+
+	var terms []string
+	for _, ip := range addrs {
+		if ip4 := ip.To4(); ip4 != nil {
+			terms = append(terms, qualifier+"ip4:"+ip4.String()+cidr4)
+		} else {
+			terms = append(terms, qualifier+"ip6:"+ip.String()+cidr6)
+		}
+	}
+	return terms, nil
+}
+
+// expand returns the terms of record, published at domain, with the includes
+// and the a/mx mechanisms replaced by the addresses they stand for.
+//
+// When nested is set, the record is the target of an include, qualified
+// with outer: only its terms giving a pass result make the include match,
+// and they then give the include's result. A term that cannot be expanded,
+// or a pass term coming after one that doesn't give a pass, makes the whole
+// include being kept as written: the first term matching decides, so
+// dropping the earlier one would let through the addresses it rejects.
+func (e *spfExpander) expand(domain, record, outer string, nested bool, depth int) ([]string, error) {
+	if depth > spfMaxDepth {
+		return nil, fmt.Errorf("too many nested includes under %s", domain)
+	}
+
+	fields := strings.Fields(record)
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "v=spf1") {
+		return nil, fmt.Errorf("the SPF record of %s is invalid", domain)
+	}
+
+	var terms []string
+	var redirect string
+	hasAll := false
+	shadowed := false
+
+	for _, raw := range fields[1:] {
+		term := parseSPFTerm(raw)
+
+		qualifier := "+"
+		if strings.ContainsAny(raw[:1], "+-~?") {
+			qualifier = raw[:1]
+		}
+
+		// The qualifier the expanded terms take.
+		if nested {
+			if qualifier != "+" && !term.isAll {
+				// Matching it doesn't give a pass: the include doesn't
+				// match on its account.
+				shadowed = true
+				continue
+			}
+			if shadowed && (term.kind.consumesLookup() || term.mechanism == "ip4" || term.mechanism == "ip6") {
+				return nil, errSPFUnexpandable
+			}
+			qualifier = outer
+		}
+		prefix := qualifier
+		if prefix == "+" {
+			prefix = ""
+		}
+
+		if strings.Contains(raw, "%") {
+			// A macro depends on the message being checked.
+			if nested {
+				return nil, errSPFUnexpandable
+			}
+			terms = append(terms, raw)
+			e.kept = append(e.kept, raw)
+			continue
+		}
+
+		switch {
+		case term.isAll:
+			hasAll = true
+			if nested {
+				if strings.HasPrefix(raw, "+") || raw == "all" {
+					return nil, errSPFUnexpandable
+				}
+			} else {
+				terms = append(terms, raw)
+			}
+
+		case term.kind == spfTermRedirect:
+			redirect = term.value
+
+		case term.kind == spfTermInclude:
+			target, err := e.include(term.value, qualifier, depth)
+			if errors.Is(err, errSPFUnexpandable) {
+				if nested {
+					return nil, err
+				}
+				terms = append(terms, raw)
+				e.kept = append(e.kept, raw)
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			terms = append(terms, target...)
+
+		case term.kind == spfTermA || term.kind == spfTermMX:
+			name, cidr4, cidr6 := splitSPFDomainSpec(raw, term.mechanism, domain)
+
+			hosts := []string{name}
+			if term.kind == spfTermMX {
+				if err := e.query(); err != nil {
+					return nil, err
+				}
+				var err error
+				hosts, err = e.src.MX(name)
+				if err != nil {
+					return nil, fmt.Errorf("unable to retrieve the MX of %s: %w", name, err)
+				}
+				if len(hosts) > spfExpandMaxMX {
+					// Evaluating it is a permanent error (RFC 7208
+					// section 4.6.4): nothing to expand.
+					return nil, fmt.Errorf("%s has more than %d MX hosts", name, spfExpandMaxMX)
+				}
+			}
+
+			for _, host := range hosts {
+				addrs, err := e.addresses(host, prefix, cidr4, cidr6)
+				if err != nil {
+					return nil, err
+				}
+				terms = append(terms, addrs...)
+			}
+
+		case term.kind.consumesLookup():
+			// exists and ptr depend on the message being checked.
+			if nested {
+				return nil, errSPFUnexpandable
+			}
+			terms = append(terms, raw)
+			e.kept = append(e.kept, raw)
+
+		case term.mechanism == "ip4" || term.mechanism == "ip6":
+			terms = append(terms, prefix+strings.TrimLeft(raw, "+-~?"))
+
+		default:
+			// Other modifiers (exp=...) only mean something on the record
+			// they are written on.
+			if !nested {
+				terms = append(terms, raw)
+			}
+		}
+
+		if hasAll {
+			// Nothing after an all term is ever evaluated.
+			break
+		}
+	}
+
+	// The redirect only applies when no all term matched beforehand.
+	if redirect != "" && !hasAll {
+		if nested && shadowed {
+			return nil, errSPFUnexpandable
+		}
+		var target []string
+		var err error
+		if nested {
+			target, err = e.include(redirect, outer, depth)
+		} else {
+			// A redirect stands for the whole record it points at, its
+			// qualifiers and all term included.
+			target, err = e.expandRedirect(redirect, depth)
+		}
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, target...)
+	}
+
+	return terms, nil
+}
+
+// include expands the record published at target, as an include qualified
+// with qualifier.
+func (e *spfExpander) include(target, qualifier string, depth int) ([]string, error) {
+	if target == "" {
+		return nil, errors.New("an include or redirect has no domain")
+	}
+
+	key := strings.ToLower(dns.Fqdn(target))
+	if _, seen := e.visited[key]; seen {
+		return nil, fmt.Errorf("%s includes itself", target)
+	}
+	e.visited[key] = struct{}{}
+	defer delete(e.visited, key)
+
+	record, err := e.fetch(target)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.expand(target, record, qualifier, true, depth+1)
+}
+
+// expandRedirect expands the record a top level redirect points at.
+func (e *spfExpander) expandRedirect(target string, depth int) ([]string, error) {
+	key := strings.ToLower(dns.Fqdn(target))
+	if _, seen := e.visited[key]; seen {
+		return nil, fmt.Errorf("%s redirects to itself", target)
+	}
+	e.visited[key] = struct{}{}
+	defer delete(e.visited, key)
+
+	record, err := e.fetch(target)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.expand(target, record, "", false, depth+1)
+}
+
+// splitSPFDomainSpec splits the a or mx term raw into the domain it refers
+// to, falling back to domain, and its optional "/cidr" and "//cidr6"
+// suffixes.
+func splitSPFDomainSpec(raw, mechanism, domain string) (name, cidr4, cidr6 string) {
+	rest := strings.TrimLeft(raw, "+-~?")[len(mechanism):]
+
+	if idx := strings.Index(rest, "//"); idx != -1 {
+		cidr6 = "/" + rest[idx+2:]
+		rest = rest[:idx]
+	}
+	if idx := strings.Index(rest, "/"); idx != -1 {
+		cidr4 = rest[idx:]
+		rest = rest[:idx]
+	}
+
+	name = strings.TrimPrefix(rest, ":")
+	if name == "" {
+		name = domain
+	}
+	return
+}
+
+// expandSPF returns the flattened form of the record published at domain.
+func expandSPF(src spfExpandSource, domain, record string) (*happydns.SPFExpandResponse, error) {
+	e := &spfExpander{
+		src:      src,
+		deadline: time.Now().Add(spfExpandDeadline),
+		visited:  map[string]struct{}{strings.ToLower(dns.Fqdn(domain)): {}},
+	}
+
+	if record == "" {
+		var err error
+		record, err = e.fetch(domain)
+		if err != nil {
+			return nil, happydns.ValidationError{Msg: err.Error()}
+		}
+	}
+
+	terms, err := e.expand(domain, record, "", false, 0)
+	if err != nil {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("unable to flatten the SPF record of %s: %s", domain, err.Error())}
+	}
+
+	// Vendors often list the same ranges under several includes.
+	var deduped []string
+	lookups := 0
+	for _, t := range terms {
+		if slices.Contains(deduped, t) {
+			continue
+		}
+		deduped = append(deduped, t)
+
+		if parseSPFTerm(t).kind.consumesLookup() {
+			lookups++
+		}
+	}
+
+	flattened := strings.Join(append([]string{"v=spf1"}, deduped...), " ")
+	if size := spfAnswerSize(domain, flattened); size > spfExpandMaxAnswer {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("unable to flatten the SPF record of %s: the answer holding the flattened record would be %d bytes long, more than the %d bytes a DNS answer over UDP holds", domain, size, spfExpandMaxAnswer)}
+	}
+
+	return &happydns.SPFExpandResponse{
+		Record:      flattened,
+		Kept:        e.kept,
+		LookupCount: lookups,
+	}, nil
+}
+
+// spfAnswerSize returns the size of the answer to a TXT query for domain
+// holding record alone.
+func spfAnswerSize(domain, record string) int {
+	hdr := dns.RR_Header{Name: dns.Fqdn(domain), Rrtype: dns.TypeTXT, Class: dns.ClassINET}
+
+	m := new(dns.Msg)
+	m.SetQuestion(hdr.Name, dns.TypeTXT)
+	m.Answer = []dns.RR{(&happydns.TXT{Hdr: hdr, Txt: record}).ToRR()}
+
+	return m.Len()
+}
+
+func (ru *resolverUsecase) ExpandSPF(req happydns.SPFExpandRequest) (*happydns.SPFExpandResponse, error) {
+	if req.Domain == "" {
+		return nil, happydns.ValidationError{Msg: "domain is required"}
+	}
+
+	resolver, err := ru.pickResolver(context.Background(), req.Resolver, req.Custom)
+	if err != nil {
+		return nil, err
+	}
+
+	return expandSPF(dnsSPFSource{
+		client:   dns.Client{Timeout: spfPerLookupTimeout},
+		resolver: resolver,
+	}, req.Domain, req.Record)
+}
@@ -0,0 +1,221 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package usecase
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeSPFSource answers from static tables; a name missing from them doesn't
+// exist.
+type fakeSPFSource struct {
+	txt   map[string]string
+	addrs map[string][]string
+	mx    map[string][]string
+}
+
+func (f fakeSPFSource) TXT(name string) ([]string, error) {
+	if rec, ok := f.txt[strings.TrimSuffix(name, ".")]; ok {
+		return []string{"some other text", rec}, nil
+	}
+	return nil, nil
+}
+
+func (f fakeSPFSource) Addrs(name string) (out []net.IP, err error) {
+	for _, a := range f.addrs[strings.TrimSuffix(name, ".")] {
+		out = append(out, net.ParseIP(a))
+	}
+	return
+}
+
+func (f fakeSPFSource) MX(name string) ([]string, error) {
+	return f.mx[strings.TrimSuffix(name, ".")], nil
+}
+
+func TestExpandSPF(t *testing.T) {
+	src := fakeSPFSource{
+		txt: map[string]string{
+			"sendgrid.net":           "v=spf1 ip4:167.89.0.0/17 ip4:208.117.48.0/20 ~all",
+			"spf.protection.com":     "v=spf1 ip4:40.92.0.0/15 ip6:2a01:111:f400::/48 include:spf2.protection.com -all",
+			"spf2.protection.com":    "v=spf1 ip4:40.92.0.0/15 a:relay.protection.com -ip4:192.0.2.66 -all",
+			"servers.mcsv.net":       "v=spf1 mx ?all",
+			"redirected.example.net": "v=spf1 ip4:203.0.113.0/24 -all",
+		},
+		addrs: map[string][]string{
+			"example.com":          {"192.0.2.1", "2001:db8::1"},
+			"relay.protection.com": {"198.51.100.7"},
+			"mx1.mcsv.net":         {"198.2.128.1"},
+		},
+		mx: map[string][]string{
+			"servers.mcsv.net": {"mx1.mcsv.net."},
+		},
+	}
+
+	for _, tc := range []struct {
+		record  string
+		want    string
+		kept    []string
+		lookups int
+	}{
+		{
+			record: "v=spf1 a/24 include:sendgrid.net include:spf.protection.com ~include:servers.mcsv.net -all",
+			want:   "v=spf1 ip4:192.0.2.1/24 ip6:2001:db8::1 ip4:167.89.0.0/17 ip4:208.117.48.0/20 ip4:40.92.0.0/15 ip6:2a01:111:f400::/48 ip4:198.51.100.7 ~ip4:198.2.128.1 -all",
+		},
+		{
+			record:  "v=spf1 ip4:192.0.2.10 exists:%{i}._spf.example.com include:sendgrid.net redirect=redirected.example.net",
+			want:    "v=spf1 ip4:192.0.2.10 exists:%{i}._spf.example.com ip4:167.89.0.0/17 ip4:208.117.48.0/20 ip4:203.0.113.0/24 -all",
+			kept:    []string{"exists:%{i}._spf.example.com"},
+			lookups: 1,
+		},
+	} {
+		got, err := expandSPF(src, "example.com.", tc.record)
+		if err != nil {
+			t.Fatalf("expandSPF(%q) returned an error: %s", tc.record, err)
+		}
+		if got.Record != tc.want {
+			t.Errorf("expandSPF(%q) = %q, want %q", tc.record, got.Record, tc.want)
+		}
+		if !slices.Equal(got.Kept, tc.kept) {
+			t.Errorf("expandSPF(%q) kept %v, want %v", tc.record, got.Kept, tc.kept)
+		}
+		if got.LookupCount != tc.lookups {
+			t.Errorf("expandSPF(%q) costs %d lookups, want %d", tc.record, got.LookupCount, tc.lookups)
+		}
+	}
+}
+
+// TestExpandSPFKeepsUnexpandableInclude covers an include whose record
+// depends on the message being checked: it is kept as written.
+func TestExpandSPFKeepsUnexpandableInclude(t *testing.T) {
+	src := fakeSPFSource{
+		txt: map[string]string{
+			"vendor.example.net": "v=spf1 ip4:192.0.2.0/24 ptr -all",
+		},
+	}
+
+	got, err := expandSPF(src, "example.com.", "v=spf1 include:vendor.example.net -all")
+	if err != nil {
+		t.Fatalf("expandSPF returned an error: %s", err)
+	}
+	if want := "v=spf1 include:vendor.example.net -all"; got.Record != want {
+		t.Errorf("expandSPF = %q, want %q", got.Record, want)
+	}
+	if got.LookupCount != 1 {
+		t.Errorf("expandSPF costs %d lookups, want 1", got.LookupCount)
+	}
+}
+
+// TestExpandSPFKeepsShadowingInclude covers an include rejecting an address
+// before passing the range holding it: dropping the rejection would let the
+// address through, so the include is kept as written.
+func TestExpandSPFKeepsShadowingInclude(t *testing.T) {
+	src := fakeSPFSource{
+		txt: map[string]string{
+			"x.example.net":        "v=spf1 -ip4:192.0.2.5 ip4:192.0.2.0/24 -all",
+			"nested.example.net":   "v=spf1 include:x.example.net ip4:198.51.100.0/24 -all",
+			"redirect.example.net": "v=spf1 ~ip4:192.0.2.5 redirect=y.example.net",
+			"y.example.net":        "v=spf1 ip4:192.0.2.0/24 -all",
+		},
+	}
+
+	for _, tc := range []struct {
+		record string
+		want   string
+	}{
+		{
+			record: "v=spf1 include:x.example.net -all",
+			want:   "v=spf1 include:x.example.net -all",
+		},
+		{
+			record: "v=spf1 include:nested.example.net -all",
+			want:   "v=spf1 include:nested.example.net -all",
+		},
+		{
+			record: "v=spf1 include:redirect.example.net -all",
+			want:   "v=spf1 include:redirect.example.net -all",
+		},
+	} {
+		got, err := expandSPF(src, "example.com.", tc.record)
+		if err != nil {
+			t.Fatalf("expandSPF(%q) returned an error: %s", tc.record, err)
+		}
+		if got.Record != tc.want {
+			t.Errorf("expandSPF(%q) = %q, want %q", tc.record, got.Record, tc.want)
+		}
+	}
+}
+
+func TestExpandSPFErrors(t *testing.T) {
+	src := fakeSPFSource{
+		txt: map[string]string{
+			"loop.example.net": "v=spf1 include:loop.example.net -all",
+		},
+		addrs: map[string][]string{},
+		mx:    map[string][]string{},
+	}
+
+	// More MX hosts than an evaluation accepts.
+	for i := range 11 {
+		host := fmt.Sprintf("mx%d.example.net", i)
+		src.mx["many.example.net"] = append(src.mx["many.example.net"], host)
+		src.addrs[host] = []string{fmt.Sprintf("192.0.2.%d", i)}
+	}
+
+	// Addresses too many for the answer to fit in a UDP message.
+	for i := range 40 {
+		src.addrs["big.example.net"] = append(src.addrs["big.example.net"], fmt.Sprintf("2001:db8::%x", i+1))
+	}
+
+	for _, record := range []string{
+		"v=spf1 include:missing.example.net -all",
+		"v=spf1 include:loop.example.net -all",
+		"v=spf1 include: -all",
+		"v=spf1 mx:many.example.net -all",
+		"v=spf1 a:big.example.net -all",
+	} {
+		if _, err := expandSPF(src, "example.com.", record); err == nil {
+			t.Errorf("expandSPF(%q) succeeded, want an error", record)
+		}
+	}
+}
+
+func TestSplitSPFDomainSpec(t *testing.T) {
+	for _, tc := range []struct {
+		raw, mechanism     string
+		name, cidr4, cidr6 string
+	}{
+		{"a", "a", "example.com.", "", ""},
+		{"-a/24", "a", "example.com.", "/24", ""},
+		{"a:host.example.net", "a", "host.example.net", "", ""},
+		{"mx:mail.example.net/28//64", "mx", "mail.example.net", "/28", "/64"},
+		{"mx//48", "mx", "example.com.", "", "/48"},
+	} {
+		name, cidr4, cidr6 := splitSPFDomainSpec(tc.raw, tc.mechanism, "example.com.")
+		if name != tc.name || cidr4 != tc.cidr4 || cidr6 != tc.cidr6 {
+			t.Errorf("splitSPFDomainSpec(%q) = %q, %q, %q; want %q, %q, %q", tc.raw, name, cidr4, cidr6, tc.name, tc.cidr4, tc.cidr6)
+		}
+	}
+}
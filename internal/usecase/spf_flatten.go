@@ -143,33 +143,44 @@ func (fc *flattenContext) deadlineExceeded() bool {
 	return time.Now().After(fc.deadline)
 }
 
-// queryTXT issues a TXT query and returns the raw payload joined into a
-// single string per record. The second return value is true when the lookup
-// "voids" — i.e. NXDOMAIN, NoData, or no SPF record found.
-func queryTXT(client dns.Client, resolver, name string) ([]string, bool, error) {
+// querySPF issues a query of type qtype for name to resolver, on behalf of
+// an SPF evaluation. A name that doesn't exist gives no answer and no error.
+func querySPF(client dns.Client, resolver, name string, qtype uint16) ([]dns.RR, error) {
 	m := new(dns.Msg)
-	m.SetQuestion(dns.Fqdn(name), dns.TypeTXT)
+	m.SetQuestion(dns.Fqdn(name), qtype)
 	m.RecursionDesired = true
 	m.SetEdns0(4096, true)
 
 	r, _, err := client.Exchange(m, resolver)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 	if r == nil {
-		return nil, true, nil
+		return nil, nil
 	}
 	switch r.Rcode {
 	case dns.RcodeNameError:
-		return nil, true, nil
+		return nil, nil
 	case dns.RcodeSuccess:
 		// fallthrough
 	default:
-		return nil, false, fmt.Errorf("resolver returned %s", dns.RcodeToString[r.Rcode])
+		return nil, fmt.Errorf("resolver returned %s", dns.RcodeToString[r.Rcode])
+	}
+
+	return r.Answer, nil
+}
+
+// queryTXT issues a TXT query and returns the raw payload joined into a
+// single string per record. The second return value is true when the lookup
+// "voids" — i.e. NXDOMAIN, NoData, or no SPF record found.
+func queryTXT(client dns.Client, resolver, name string) ([]string, bool, error) {
+	answers, err := querySPF(client, resolver, name, dns.TypeTXT)
+	if err != nil {
+		return nil, false, err
 	}
 
 	var out []string
-	for _, ans := range r.Answer {
+	for _, ans := range answers {
 		if txt, ok := ans.(*dns.TXT); ok {
 			out = append(out, strings.Join(txt.Txt, ""))
 		}
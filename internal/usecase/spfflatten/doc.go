@@ -0,0 +1,32 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package spfflatten publishes the SPF records of the domains that opted in
+// flattened. The zone keeps the SPF record the user wrote, with its
+// includes; the Flattener replaces it, when the corrections of a zone are
+// computed, by the ip4/ip6 terms the includes currently stand for, so that
+// it stays under the 10-lookup limit.
+//
+// The Refresher re-flattens them on a schedule. When a vendor changes the
+// addresses behind its include, it republishes the record on its own, or
+// only raises a notification about the pending change, depending on the
+// mode the domain opted in.
+package spfflatten
@@ -0,0 +1,94 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package spfflatten
+
+import (
+	"context"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// Flattener replaces SPF records by their flattened form.
+type Flattener struct {
+	expander Expander
+}
+
+// NewFlattener builds a Flattener relying on the given expander.
+func NewFlattener(expander Expander) *Flattener {
+	return &Flattener{expander: expander}
+}
+
+// FlattenSPF returns the given records, where each SPF record is replaced by
+// its flattened form. The returned map gives the SPF record written by the
+// user for each flattened owner, keyed by its canonical name.
+//
+// An include that cannot be resolved is an error: publishing the record
+// without the addresses it stands for would make the vendor's mails fail.
+func (f *Flattener) FlattenSPF(ctx context.Context, rrs []happydns.Record) ([]happydns.Record, map[string]string, error) {
+	ret := make([]happydns.Record, 0, len(rrs))
+	flattened := map[string]string{}
+
+	for _, rr := range rrs {
+		txt, ok := spfText(rr)
+		if !ok {
+			ret = append(ret, rr)
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		expanded, err := f.expander.ExpandSPF(happydns.SPFExpandRequest{
+			Resolver: "local",
+			Domain:   rr.Header().Name,
+			Record:   txt,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		hdr := *rr.Header()
+		ret = append(ret, &happydns.TXT{Hdr: hdr, Txt: expanded.Record})
+		flattened[dns.CanonicalName(hdr.Name)] = txt
+	}
+
+	return ret, flattened, nil
+}
+
+// spfText returns the SPF policy the given record holds.
+func spfText(rr happydns.Record) (string, bool) {
+	var txt string
+	switch record := rr.(type) {
+	case *happydns.TXT:
+		txt = record.Txt
+	case *dns.TXT:
+		txt = strings.Join(record.Txt, "")
+	default:
+		return "", false
+	}
+
+	return txt, strings.HasPrefix(strings.ToLower(txt), "v=spf1")
+}
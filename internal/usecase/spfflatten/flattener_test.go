@@ -0,0 +1,95 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package spfflatten
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// fakeExpander answers from a static table, keyed by the record to expand.
+type fakeExpander struct {
+	expanded map[string]string
+	asked    []happydns.SPFExpandRequest
+}
+
+func (f *fakeExpander) ExpandSPF(req happydns.SPFExpandRequest) (*happydns.SPFExpandResponse, error) {
+	f.asked = append(f.asked, req)
+
+	record, ok := f.expanded[req.Record]
+	if !ok {
+		return nil, happydns.ValidationError{Msg: "unable to expand"}
+	}
+	return &happydns.SPFExpandResponse{Record: record}, nil
+}
+
+func TestFlattenSPF(t *testing.T) {
+	expander := &fakeExpander{expanded: map[string]string{
+		"v=spf1 include:sendgrid.net -all": "v=spf1 ip4:167.89.0.0/17 -all",
+	}}
+
+	hdr := dns.RR_Header{Name: "Example.com.", Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 300}
+	rrs := []happydns.Record{
+		&happydns.TXT{Hdr: hdr, Txt: "google-site-verification=abc"},
+		&happydns.TXT{Hdr: hdr, Txt: "v=spf1 include:sendgrid.net -all"},
+		&dns.A{Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300}},
+	}
+
+	got, flattened, err := NewFlattener(expander).FlattenSPF(context.Background(), rrs)
+	if err != nil {
+		t.Fatalf("FlattenSPF returned an error: %s", err)
+	}
+
+	if len(got) != 3 || got[0] != rrs[0] || got[2] != rrs[2] {
+		t.Errorf("the records other than SPF were changed: %v", got)
+	}
+	if txt, ok := got[1].(*happydns.TXT); !ok || txt.Txt != "v=spf1 ip4:167.89.0.0/17 -all" || txt.Hdr != hdr {
+		t.Errorf("the SPF record was not flattened: %v", got[1])
+	}
+	if rrs[1].(*happydns.TXT).Txt != "v=spf1 include:sendgrid.net -all" {
+		t.Errorf("the record of the zone was modified in place")
+	}
+
+	if len(flattened) != 1 || flattened["example.com."] != "v=spf1 include:sendgrid.net -all" {
+		t.Errorf("flattened owners are %v", flattened)
+	}
+	if len(expander.asked) != 1 || expander.asked[0].Domain != "Example.com." {
+		t.Errorf("the expander was asked %v", expander.asked)
+	}
+}
+
+func TestFlattenSPFError(t *testing.T) {
+	rrs := []happydns.Record{
+		&dns.TXT{Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeTXT, Class: dns.ClassINET}, Txt: []string{"v=spf1 include:", "missing.example.net -all"}},
+	}
+
+	_, _, err := NewFlattener(&fakeExpander{}).FlattenSPF(context.Background(), rrs)
+	var verr happydns.ValidationError
+	if !errors.As(err, &verr) {
+		t.Errorf("FlattenSPF returned %v, want the ValidationError of the expander", err)
+	}
+}
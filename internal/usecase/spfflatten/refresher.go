@@ -0,0 +1,268 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package spfflatten
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	domainlogUC "git.happydns.org/happyDomain/internal/usecase/domain_log"
+	"git.happydns.org/happyDomain/internal/usecase/orchestrator"
+	"git.happydns.org/happyDomain/model"
+)
+
+// NotifierID identifies the notifications raised about the flattened SPF
+// records, in place of a checker identifier.
+const NotifierID = "spf_flattening"
+
+// publishTimeout bounds the publication of a single zone, so that a slow
+// provider doesn't hold the whole sweep.
+const publishTimeout = 2 * time.Minute
+
+// RefresherStorage is the storage needed by the Refresher.
+type RefresherStorage interface {
+	DomainLister
+	UserGetter
+}
+
+// Refresher periodically re-flattens the SPF records of the domains that
+// opted in, and republishes them or raises a notification when the includes
+// they refer to changed.
+type Refresher struct {
+	store     RefresherStorage
+	getZone   ZoneGetter
+	publisher ZonePublisher
+	domainLog domainlogUC.DomainLogAppender
+	notifier  EventNotifier
+	interval  time.Duration
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	done    chan struct{}
+	running bool
+}
+
+// NewRefresher builds a Refresher that runs every `interval`.
+func NewRefresher(store RefresherStorage, getZone ZoneGetter, publisher ZonePublisher, domainLog domainlogUC.DomainLogAppender, notifier EventNotifier, interval time.Duration) *Refresher {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &Refresher{
+		store:     store,
+		getZone:   getZone,
+		publisher: publisher,
+		domainLog: domainLog,
+		notifier:  notifier,
+		interval:  interval,
+	}
+}
+
+// Start launches the refresher loop in a goroutine.
+func (r *Refresher) Start(ctx context.Context) {
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r.cancel = cancel
+	r.done = make(chan struct{})
+	r.running = true
+	r.mu.Unlock()
+
+	go r.loop(ctx)
+}
+
+// Stop halts the refresher and waits for the current sweep to finish.
+func (r *Refresher) Stop() {
+	r.mu.Lock()
+	cancel := r.cancel
+	done := r.done
+	r.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	if done != nil {
+		<-done
+	}
+	r.mu.Lock()
+	r.running = false
+	r.mu.Unlock()
+}
+
+func (r *Refresher) loop(ctx context.Context) {
+	defer close(r.done)
+
+	r.RunOnce(ctx)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce performs a single sweep over the domains that opted in. It returns
+// the number of zones republished.
+func (r *Refresher) RunOnce(ctx context.Context) int {
+	iter, err := r.store.ListAllDomains()
+	if err != nil {
+		log.Printf("SPF refresher: failed to list domains: %v", err)
+		return 0
+	}
+
+	// Publishing updates the domains: don't hold the iterator meanwhile.
+	var domains []*happydns.Domain
+	for iter.Next() {
+		if domain := iter.Item(); domain.SPFFlattening != happydns.SPFFlatteningOff && len(domain.ZoneHistory) > 0 {
+			domains = append(domains, domain)
+		}
+	}
+	iter.Close()
+
+	republished := 0
+
+	for _, domain := range domains {
+		select {
+		case <-ctx.Done():
+			return republished
+		default:
+		}
+
+		zone, err := r.getZone.Get(domain.ZoneHistory[0])
+		if err != nil {
+			log.Printf("SPF refresher: unable to retrieve the zone of %s: %v", domain.DomainName, err)
+			continue
+		}
+
+		published, err := r.publishedZone(domain)
+		if err != nil {
+			log.Printf("SPF refresher: unable to retrieve the published zone of %s: %v", domain.DomainName, err)
+			continue
+		} else if published == nil {
+			continue
+		}
+
+		user, err := r.store.GetUser(domain.Owner)
+		if err != nil {
+			log.Printf("SPF refresher: unable to retrieve owner of %s: %v", domain.DomainName, err)
+			continue
+		}
+
+		switch domain.SPFFlattening {
+		case happydns.SPFFlatteningAuto:
+			if r.republish(ctx, user, domain, zone, published) {
+				republished++
+			}
+		case happydns.SPFFlatteningReview:
+			r.review(ctx, user, domain, zone, published)
+		}
+	}
+
+	return republished
+}
+
+// publishedZone returns the newest zone of the domain published to its
+// provider, if any.
+func (r *Refresher) publishedZone(domain *happydns.Domain) (*happydns.Zone, error) {
+	for _, id := range domain.ZoneHistory {
+		zone, err := r.getZone.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		if zone.Published != nil {
+			return zone, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// republish publishes the pending corrections of the flattened SPF records
+// of the given zone, leaving the other ones for the user to review. Only the
+// SPF records flattened from the policy they had when last published are
+// refreshed: a change the user made to the policy since waits for them to
+// publish it.
+func (r *Refresher) republish(ctx context.Context, user *happydns.User, domain *happydns.Domain, zone, last *happydns.Zone) bool {
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+
+	published, err := r.publisher.ApplyMatching(ctx, user, domain, zone, "Refresh the flattened SPF records", orchestrator.RefreshesPublished(last, orchestrator.FromFlattenedSPF))
+	if err != nil {
+		log.Printf("SPF refresher: unable to republish the flattened SPF records of %s: %v", domain.DomainName, err)
+		return false
+	} else if published == nil {
+		return false
+	}
+
+	log.Printf("SPF refresher: %s republished with the new addresses of its SPF includes", domain.DomainName)
+	if r.domainLog != nil {
+		if err := r.domainLog.AppendDomainLog(domain, happydns.NewDomainLog(user, happydns.LOG_INFO, "The addresses behind the includes of an SPF record changed, the flattened record was republished.")); err != nil {
+			log.Printf("unable to append domain log for %s: %s", domain.DomainName, err.Error())
+		}
+	}
+
+	return true
+}
+
+// review raises a notification when the flattened SPF records of the given
+// zone have a pending change, and clears it once the zone is in sync again.
+// The notification pipeline only reports the transitions, so it can be
+// raised on every sweep.
+func (r *Refresher) review(ctx context.Context, user *happydns.User, domain *happydns.Domain, zone, last *happydns.Zone) {
+	if r.notifier == nil {
+		return
+	}
+
+	corrections, _, err := r.publisher.List(ctx, user, domain, zone)
+	if err != nil {
+		log.Printf("SPF refresher: unable to list the pending changes of %s: %v", domain.DomainName, err)
+		return
+	}
+
+	state := happydns.CheckState{
+		Status:  happydns.StatusOK,
+		Code:    "spf_flattening_in_sync",
+		Message: fmt.Sprintf("The flattened SPF records of %s are up to date.", domain.DomainName),
+	}
+	if slices.ContainsFunc(corrections, orchestrator.RefreshesPublished(last, orchestrator.FromFlattenedSPF)) {
+		state = happydns.CheckState{
+			Status:  happydns.StatusWarn,
+			Code:    "spf_includes_changed",
+			Message: fmt.Sprintf("The addresses behind the SPF includes of %s changed: review and publish the pending change of its flattened SPF records.", domain.DomainName),
+		}
+	}
+
+	r.notifier.NotifyEvent(NotifierID, happydns.CheckTarget{
+		UserId:   domain.Owner.String(),
+		DomainId: domain.Id.String(),
+	}, []happydns.CheckState{state})
+}
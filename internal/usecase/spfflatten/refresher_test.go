@@ -0,0 +1,165 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package spfflatten
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+type sliceIterator[T any] struct {
+	items []*T
+	idx   int
+	cur   *T
+}
+
+func (it *sliceIterator[T]) Next() bool {
+	if it.idx >= len(it.items) {
+		return false
+	}
+	it.cur = it.items[it.idx]
+	it.idx++
+	return true
+}
+func (it *sliceIterator[T]) NextWithError() bool { return it.Next() }
+func (it *sliceIterator[T]) Item() *T            { return it.cur }
+func (it *sliceIterator[T]) DropItem() error     { return nil }
+func (it *sliceIterator[T]) Key() string         { return "" }
+func (it *sliceIterator[T]) Raw() any            { return nil }
+func (it *sliceIterator[T]) Err() error          { return nil }
+func (it *sliceIterator[T]) Close()              {}
+
+type fakeStore struct {
+	domains []*happydns.Domain
+}
+
+func (s *fakeStore) ListAllDomains() (happydns.Iterator[happydns.Domain], error) {
+	return &sliceIterator[happydns.Domain]{items: s.domains}, nil
+}
+
+func (s *fakeStore) GetUser(id happydns.Identifier) (*happydns.User, error) {
+	return &happydns.User{Id: id}, nil
+}
+
+type fakeZones map[string]*happydns.Zone
+
+func (z fakeZones) Get(id happydns.Identifier) (*happydns.Zone, error) {
+	return z[id.String()], nil
+}
+
+// fakePublisher proposes the same corrections for every zone, and records
+// those it was asked to publish.
+type fakePublisher struct {
+	corrections []*happydns.Correction
+	published   []*happydns.Correction
+}
+
+func (p *fakePublisher) List(context.Context, *happydns.User, *happydns.Domain, *happydns.Zone) ([]*happydns.Correction, int, error) {
+	return p.corrections, len(p.corrections), nil
+}
+
+func (p *fakePublisher) ApplyMatching(_ context.Context, _ *happydns.User, _ *happydns.Domain, zone *happydns.Zone, _ string, keep func(*happydns.Correction) bool) (*happydns.Zone, error) {
+	var published []*happydns.Correction
+	for _, cr := range p.corrections {
+		if keep(cr) {
+			published = append(published, cr)
+		}
+	}
+	if len(published) == 0 {
+		return nil, nil
+	}
+
+	p.published = append(p.published, published...)
+	return zone, nil
+}
+
+func spfCorrection(owner, from, flattened string) *happydns.Correction {
+	return &happydns.Correction{
+		NewRecords: []happydns.Record{
+			&happydns.TXT{Hdr: dns.RR_Header{Name: owner, Rrtype: dns.TypeTXT, Class: dns.ClassINET}, Txt: flattened},
+		},
+		DerivedFrom: from,
+	}
+}
+
+func TestRefresherKeepsUnpublishedEdits(t *testing.T) {
+	now := time.Now()
+	published := &happydns.Zone{
+		ZoneMeta: happydns.ZoneMeta{
+			Id:        happydns.Identifier("published"),
+			Published: &now,
+			Derived: []*happydns.DerivedRecords{
+				{Owner: "example.com.", From: "v=spf1 include:sendgrid.net -all", Records: []string{"v=spf1 ip4:167.89.0.0/17 -all"}},
+				{Owner: "mail.example.com.", From: "v=spf1 include:sendgrid.net -all", Records: []string{"v=spf1 ip4:167.89.0.0/17 -all"}},
+			},
+		},
+	}
+	wip := &happydns.Zone{ZoneMeta: happydns.ZoneMeta{Id: happydns.Identifier("wip")}}
+
+	domain := &happydns.Domain{
+		Id:            happydns.Identifier("domain"),
+		Owner:         happydns.Identifier("user"),
+		DomainName:    "example.com.",
+		ZoneHistory:   []happydns.Identifier{wip.Id, published.Id},
+		SPFFlattening: happydns.SPFFlatteningAuto,
+	}
+
+	// The addresses of sendgrid.net changed. Meanwhile, the user edited the
+	// SPF policy of mail.example.com without publishing it.
+	refreshed := spfCorrection("example.com.", "v=spf1 include:sendgrid.net -all", "v=spf1 ip4:167.89.0.0/16 -all")
+	edited := spfCorrection("mail.example.com.", "v=spf1 include:sendgrid.net include:mailgun.org -all", "v=spf1 ip4:167.89.0.0/16 ip4:69.72.32.0/20 -all")
+	publisher := &fakePublisher{corrections: []*happydns.Correction{refreshed, edited}}
+
+	r := NewRefresher(&fakeStore{domains: []*happydns.Domain{domain}}, fakeZones{"wip": wip, "published": published}, publisher, nil, nil, time.Hour)
+
+	if n := r.RunOnce(context.Background()); n != 1 {
+		t.Errorf("RunOnce republished %d zones, want 1", n)
+	}
+	if len(publisher.published) != 1 || publisher.published[0] != refreshed {
+		t.Errorf("RunOnce published %v, want only the refreshed record of the published policy", publisher.published)
+	}
+}
+
+func TestRefresherWithoutPublishedZone(t *testing.T) {
+	wip := &happydns.Zone{ZoneMeta: happydns.ZoneMeta{Id: happydns.Identifier("wip")}}
+	domain := &happydns.Domain{
+		Id:            happydns.Identifier("domain"),
+		DomainName:    "example.com.",
+		ZoneHistory:   []happydns.Identifier{wip.Id},
+		SPFFlattening: happydns.SPFFlatteningAuto,
+	}
+
+	publisher := &fakePublisher{corrections: []*happydns.Correction{
+		spfCorrection("example.com.", "v=spf1 include:sendgrid.net -all", "v=spf1 ip4:167.89.0.0/17 -all"),
+	}}
+
+	r := NewRefresher(&fakeStore{domains: []*happydns.Domain{domain}}, fakeZones{"wip": wip}, publisher, nil, nil, time.Hour)
+
+	if n := r.RunOnce(context.Background()); n != 0 || len(publisher.published) != 0 {
+		t.Errorf("RunOnce published %v for a zone never published", publisher.published)
+	}
+}
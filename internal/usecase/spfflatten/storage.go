@@ -0,0 +1,62 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package spfflatten
+
+import (
+	"context"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// Expander computes the flattened form of an SPF record.
+type Expander interface {
+	ExpandSPF(happydns.SPFExpandRequest) (*happydns.SPFExpandResponse, error)
+}
+
+// DomainLister lists every Domain, for the Refresher to walk them.
+type DomainLister interface {
+	ListAllDomains() (happydns.Iterator[happydns.Domain], error)
+}
+
+// UserGetter retrieves the owner of a Domain, on whose behalf the Refresher
+// publishes the zone.
+type UserGetter interface {
+	GetUser(id happydns.Identifier) (*happydns.User, error)
+}
+
+// ZoneGetter retrieves a Zone by its identifier.
+type ZoneGetter interface {
+	Get(zoneID happydns.Identifier) (*happydns.Zone, error)
+}
+
+// ZonePublisher lists the pending corrections of a zone, and publishes a
+// subset of them.
+type ZonePublisher interface {
+	List(ctx context.Context, user *happydns.User, domain *happydns.Domain, zone *happydns.Zone) ([]*happydns.Correction, int, error)
+	ApplyMatching(ctx context.Context, user *happydns.User, domain *happydns.Domain, zone *happydns.Zone, commitMsg string, keep func(*happydns.Correction) bool) (*happydns.Zone, error)
+}
+
+// EventNotifier raises notifications for events happening outside of the
+// checker engine.
+type EventNotifier interface {
+	NotifyEvent(checkerID string, target happydns.CheckTarget, states []happydns.CheckState)
+}
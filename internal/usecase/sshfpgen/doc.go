@@ -0,0 +1,29 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package sshfpgen computes the SSHFP records of the host keys of a server,
+// so that SSH clients enabling VerifyHostKeyDNS can check them.
+//
+// Host keys are read from the output of ssh-keyscan or from OpenSSH public
+// key files, or retrieved directly from the server through the outbound
+// guard. Their SHA-256 fingerprints are then compared with the SSHFP records
+// of the published zone, to tell which are missing and which are stale.
+package sshfpgen
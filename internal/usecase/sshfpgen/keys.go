@@ -0,0 +1,181 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sshfpgen
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+	"golang.org/x/crypto/ssh"
+)
+
+// Algorithm numbers of the SSHFP records, from the IANA registry.
+const (
+	AlgorithmRSA     uint8 = 1
+	AlgorithmDSA     uint8 = 2
+	AlgorithmECDSA   uint8 = 3
+	AlgorithmEd25519 uint8 = 4
+)
+
+// Fingerprint types of the SSHFP records.
+const (
+	FingerprintSHA1   uint8 = 1
+	FingerprintSHA256 uint8 = 2
+)
+
+// sshfpAlgorithm returns the SSHFP algorithm number of key, or 0 when SSHFP
+// has none for its type.
+func sshfpAlgorithm(key ssh.PublicKey) uint8 {
+	switch key.Type() {
+	case ssh.KeyAlgoRSA:
+		return AlgorithmRSA
+	case ssh.KeyAlgoDSA:
+		return AlgorithmDSA
+	case ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521:
+		return AlgorithmECDSA
+	case ssh.KeyAlgoED25519:
+		return AlgorithmEd25519
+	default:
+		return 0
+	}
+}
+
+// fingerprint hashes the wire encoding of key with the digest of the given
+// SSHFP fingerprint type.
+func fingerprint(key ssh.PublicKey, fptype uint8) (string, bool) {
+	switch fptype {
+	case FingerprintSHA1:
+		sum := sha1.Sum(key.Marshal())
+		return hex.EncodeToString(sum[:]), true
+	case FingerprintSHA256:
+		sum := sha256.Sum256(key.Marshal())
+		return hex.EncodeToString(sum[:]), true
+	default:
+		return "", false
+	}
+}
+
+// ParseHostKeys reads the keys of the output of ssh-keyscan, or of OpenSSH
+// public key files, one key per line. Blank lines and comments are skipped;
+// duplicated keys are returned once.
+func ParseHostKeys(data string) ([]ssh.PublicKey, error) {
+	var keys []ssh.PublicKey
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(nil, 64*1024)
+
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// ssh-keyscan prefixes the key with the host name, which is
+		// read here as options of an authorized key.
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineno, err)
+		}
+
+		if sshfpAlgorithm(key) == 0 {
+			return nil, fmt.Errorf("line %d: %s keys cannot be published in SSHFP records", lineno, key.Type())
+		}
+
+		keys = appendKey(keys, key)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// appendKey appends key to keys unless it is already there.
+func appendKey(keys []ssh.PublicKey, key ssh.PublicKey) []ssh.PublicKey {
+	for _, k := range keys {
+		if string(k.Marshal()) == string(key.Marshal()) {
+			return keys
+		}
+	}
+	return append(keys, key)
+}
+
+// newSSHFP computes the SHA-256 SSHFP record of key, owned by name.
+func newSSHFP(name string, key ssh.PublicKey) *dns.SSHFP {
+	fp, _ := fingerprint(key, FingerprintSHA256)
+
+	return &dns.SSHFP{
+		Hdr: dns.RR_Header{
+			Name:   name,
+			Rrtype: dns.TypeSSHFP,
+			Class:  dns.ClassINET,
+		},
+		Algorithm:   sshfpAlgorithm(key),
+		Type:        FingerprintSHA256,
+		FingerPrint: fp,
+	}
+}
+
+// matchesKey tells whether rr is the fingerprint, of any supported type, of
+// one of keys.
+func matchesKey(rr *dns.SSHFP, keys []ssh.PublicKey) bool {
+	for _, key := range keys {
+		if sshfpAlgorithm(key) != rr.Algorithm {
+			continue
+		}
+
+		if fp, ok := fingerprint(key, rr.Type); ok && strings.EqualFold(fp, rr.FingerPrint) {
+			return true
+		}
+	}
+	return false
+}
+
+// diffRecords splits records between those missing from published, and the
+// published ones matching none of keys.
+func diffRecords(records, published []*dns.SSHFP, keys []ssh.PublicKey) (toAdd, toDelete []*dns.SSHFP) {
+next:
+	for _, rr := range records {
+		for _, prr := range published {
+			if rr.Algorithm == prr.Algorithm && rr.Type == prr.Type && strings.EqualFold(rr.FingerPrint, prr.FingerPrint) {
+				continue next
+			}
+		}
+		toAdd = append(toAdd, rr)
+	}
+
+	for _, prr := range published {
+		if !matchesKey(prr, keys) {
+			toDelete = append(toDelete, prr)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,128 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sshfpgen
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestParseHostKeys(t *testing.T) {
+	k1 := newTestKey(t)
+	k2 := newTestKey(t)
+
+	line1 := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(k1)))
+	line2 := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(k2)))
+
+	input := strings.Join([]string{
+		"# srv.example.com:22 SSH-2.0-OpenSSH_9.6",
+		"srv.example.com " + line1,
+		"",
+		line2 + " root@srv",
+		"[srv.example.com]:2222 " + line1,
+	}, "\n")
+
+	keys, err := ParseHostKeys(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 distinct keys, got %d", len(keys))
+	}
+
+	if _, err := ParseHostKeys("srv.example.com ssh-ed25519 notbase64!"); err == nil {
+		t.Error("expected an error on an invalid key")
+	}
+
+	keys, err = ParseHostKeys("")
+	if err != nil || len(keys) != 0 {
+		t.Errorf("expected no key and no error on empty input, got %v, %v", keys, err)
+	}
+}
+
+func TestNewSSHFP(t *testing.T) {
+	key := newTestKey(t)
+	rr := newSSHFP("srv.example.com.", key)
+
+	if rr.Algorithm != AlgorithmEd25519 || rr.Type != FingerprintSHA256 {
+		t.Errorf("unexpected parameters: %s", rr)
+	}
+	if len(rr.FingerPrint) != 64 {
+		t.Errorf("expected a SHA-256 hex fingerprint, got %q", rr.FingerPrint)
+	}
+	if !matchesKey(rr, []ssh.PublicKey{key}) {
+		t.Error("record doesn't match its own key")
+	}
+
+	sha1fp, _ := fingerprint(key, FingerprintSHA1)
+	if !matchesKey(&dns.SSHFP{Algorithm: AlgorithmEd25519, Type: FingerprintSHA1, FingerPrint: strings.ToUpper(sha1fp)}, []ssh.PublicKey{key}) {
+		t.Error("SHA-1 record doesn't match its key")
+	}
+	if matchesKey(&dns.SSHFP{Algorithm: AlgorithmRSA, Type: FingerprintSHA256, FingerPrint: rr.FingerPrint}, []ssh.PublicKey{key}) {
+		t.Error("record of another algorithm shouldn't match")
+	}
+}
+
+func TestDiffRecords(t *testing.T) {
+	kept := newTestKey(t)
+	added := newTestKey(t)
+	removed := newTestKey(t)
+
+	keys := []ssh.PublicKey{kept, added}
+	records := []*dns.SSHFP{newSSHFP("srv.example.com.", kept), newSSHFP("srv.example.com.", added)}
+
+	keptSHA1, _ := fingerprint(kept, FingerprintSHA1)
+	published := []*dns.SSHFP{
+		newSSHFP("srv.example.com.", kept),
+		{Algorithm: AlgorithmEd25519, Type: FingerprintSHA1, FingerPrint: keptSHA1},
+		newSSHFP("srv.example.com.", removed),
+	}
+
+	toAdd, toDelete := diffRecords(records, published, keys)
+
+	if len(toAdd) != 1 || toAdd[0].FingerPrint != records[1].FingerPrint {
+		t.Errorf("expected the new key's record to be added, got %v", toAdd)
+	}
+	if len(toDelete) != 1 || toDelete[0].FingerPrint != published[2].FingerPrint {
+		t.Errorf("expected the removed key's record to be deleted, got %v", toDelete)
+	}
+}
@@ -0,0 +1,126 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sshfpgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"git.happydns.org/happyDomain/internal/netguard"
+)
+
+// scanTimeout bounds the whole scan of a server, all algorithms included.
+const scanTimeout = 30 * time.Second
+
+// scannedAlgorithms are the host key algorithms asked in turn, a server
+// offering a single key per handshake.
+var scannedAlgorithms = []string{
+	ssh.KeyAlgoED25519,
+	ssh.KeyAlgoECDSA256,
+	ssh.KeyAlgoECDSA384,
+	ssh.KeyAlgoECDSA521,
+	ssh.KeyAlgoRSASHA512,
+}
+
+// errKeyReceived interrupts the handshake once the host key is known.
+var errKeyReceived = errors.New("host key received")
+
+// Scanner retrieves host keys through the outbound guard, so that the name
+// of a zone cannot be pointed at an internal address.
+type Scanner struct {
+	guard *netguard.Guard
+}
+
+// NewScanner builds a Scanner dialing through guard.
+func NewScanner(guard *netguard.Guard) *Scanner {
+	return &Scanner{guard: guard}
+}
+
+// ScanHostKeys does what ssh-keyscan does: it starts one handshake per host
+// key algorithm with the server at host:port, and keeps the key it presents,
+// without ever authenticating.
+func (s *Scanner) ScanHostKeys(ctx context.Context, host string, port uint16) ([]ssh.PublicKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, scanTimeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(host, strconv.FormatUint(uint64(port), 10))
+
+	var keys []ssh.PublicKey
+	var lastErr error
+	for _, algo := range scannedAlgorithms {
+		key, err := s.scanAlgorithm(ctx, addr, algo)
+		if err != nil {
+			lastErr = err
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		keys = appendKey(keys, key)
+	}
+
+	if len(keys) == 0 {
+		if lastErr == nil {
+			lastErr = fmt.Errorf("no host key offered")
+		}
+		return nil, lastErr
+	}
+
+	return keys, nil
+}
+
+// scanAlgorithm retrieves the host key of the given algorithm of the server at
+// addr.
+func (s *Scanner) scanAlgorithm(ctx context.Context, addr, algo string) (ssh.PublicKey, error) {
+	conn, err := s.guard.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	var key ssh.PublicKey
+	_, _, _, err = ssh.NewClientConn(conn, addr, &ssh.ClientConfig{
+		User:              "happydomain",
+		HostKeyAlgorithms: []string{algo},
+		HostKeyCallback: func(hostname string, remote net.Addr, k ssh.PublicKey) error {
+			key = k
+			return errKeyReceived
+		},
+	})
+	if key != nil {
+		return key, nil
+	}
+	if err == nil {
+		err = fmt.Errorf("no %s host key offered", algo)
+	}
+	return nil, err
+}
@@ -0,0 +1,133 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sshfpgen
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/internal/helpers"
+	"git.happydns.org/happyDomain/model"
+)
+
+// defaultSSHPort is the port scanned when the input gives none.
+const defaultSSHPort = 22
+
+// Service implements happydns.SSHFPGeneratorUsecase.
+type Service struct {
+	scanner     HostKeyScanner
+	getZone     ZoneGetter
+	listRecords RecordLister
+}
+
+// NewService builds the SSHFP generation Service.
+func NewService(scanner HostKeyScanner, getZone ZoneGetter, listRecords RecordLister) *Service {
+	return &Service{
+		scanner:     scanner,
+		getZone:     getZone,
+		listRecords: listRecords,
+	}
+}
+
+// Generate computes the SHA-256 SSHFP records of the host keys given in
+// input, and of those offered by the SSH server of subdomain when a scan is
+// asked, then compares them with the SSHFP records of the subdomain in the
+// last published zone of domain.
+func (s *Service) Generate(ctx context.Context, domain *happydns.Domain, subdomain happydns.Subdomain, input *happydns.SSHFPGenerationInput) (*happydns.SSHFPGeneration, error) {
+	keys, err := ParseHostKeys(input.Keys)
+	if err != nil {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("unable to read the host keys: %s", err.Error())}
+	}
+
+	owner := helpers.DomainFQDN(string(subdomain), domain.DomainName)
+
+	if input.Scan {
+		port := input.Port
+		if port == 0 {
+			port = defaultSSHPort
+		}
+
+		host := strings.TrimSuffix(owner, ".")
+		scanned, err := s.scanner.ScanHostKeys(ctx, host, port)
+		if err != nil {
+			return nil, happydns.ValidationError{Msg: fmt.Sprintf("unable to retrieve the host keys of %s: %s", host, err.Error())}
+		}
+
+		for _, key := range scanned {
+			if sshfpAlgorithm(key) != 0 {
+				keys = appendKey(keys, key)
+			}
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, happydns.ValidationError{Msg: "no host key given: paste the output of ssh-keyscan or public key files, or ask for a scan"}
+	}
+
+	ret := &happydns.SSHFPGeneration{
+		Owner: owner,
+	}
+	for _, key := range keys {
+		ret.Records = append(ret.Records, newSSHFP(owner, key))
+	}
+
+	published, found, err := s.publishedRecords(domain, owner)
+	if err != nil {
+		return nil, err
+	}
+	ret.Published = found
+	ret.ToAdd, ret.ToDelete = diffRecords(ret.Records, published, keys)
+
+	return ret, nil
+}
+
+// publishedRecords returns the SSHFP records of owner in the last published
+// zone of domain, and whether such a zone exists.
+func (s *Service) publishedRecords(domain *happydns.Domain, owner string) ([]*dns.SSHFP, bool, error) {
+	for _, zid := range domain.ZoneHistory {
+		zone, err := s.getZone.Get(zid)
+		if err != nil {
+			return nil, false, err
+		}
+		if zone.Published == nil {
+			continue
+		}
+
+		records, err := s.listRecords.List(domain, zone)
+		if err != nil {
+			return nil, false, err
+		}
+
+		var ret []*dns.SSHFP
+		for _, record := range records {
+			if rr, ok := record.(*dns.SSHFP); ok && strings.EqualFold(rr.Hdr.Name, owner) {
+				ret = append(ret, rr)
+			}
+		}
+		return ret, true, nil
+	}
+
+	return nil, false, nil
+}
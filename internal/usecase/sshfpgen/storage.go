@@ -0,0 +1,45 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sshfpgen
+
+import (
+	"context"
+
+	"golang.org/x/crypto/ssh"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// HostKeyScanner retrieves the host keys offered by an SSH server.
+type HostKeyScanner interface {
+	ScanHostKeys(ctx context.Context, host string, port uint16) ([]ssh.PublicKey, error)
+}
+
+// ZoneGetter retrieves a Zone by its identifier.
+type ZoneGetter interface {
+	Get(zoneID happydns.Identifier) (*happydns.Zone, error)
+}
+
+// RecordLister expands a zone into its records, with absolute names.
+type RecordLister interface {
+	List(domain *happydns.Domain, zone *happydns.Zone) ([]happydns.Record, error)
+}
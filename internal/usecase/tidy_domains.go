@@ -73,3 +73,22 @@ func (tu *tidyUpUsecase) TidyDomainLogs(dropInvalid bool) error {
 		return nil
 	})
 }
+
+func (tu *tidyUpUsecase) TidyProviderCalls(dropInvalid bool) error {
+	iter, err := tu.store.ListAllProviderCalls()
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	return iterateTidy(iter, dropInvalid, func(call *happydns.ProviderCall) error {
+		if _, err := tu.store.GetDomain(call.DomainId); errors.Is(err, happydns.ErrDomainNotFound) {
+			// Drop the journal of deleted domains
+			log.Printf("Deleting orphan provider call (domain %s not found): %s\n", call.DomainId.String(), call.Msg)
+			if err = iter.DropItem(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
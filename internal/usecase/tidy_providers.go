@@ -47,3 +47,23 @@ func (tu *tidyUpUsecase) TidyProviders(dropInvalid bool) error {
 		return nil
 	})
 }
+
+func (tu *tidyUpUsecase) TidyBuiltinZones(dropInvalid bool) error {
+	iter, err := tu.store.ListAllBuiltinZones()
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	return iterateTidy(iter, dropInvalid, func(zone *happydns.BuiltinZone) error {
+		_, err := tu.store.GetProvider(zone.ProviderId)
+		if errors.Is(err, happydns.ErrProviderNotFound) {
+			// Drop zones of deleted built-in providers
+			log.Printf("Deleting orphan built-in zone (provider %s not found): %s\n", zone.ProviderId.String(), zone.Name)
+			if err = iter.DropItem(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
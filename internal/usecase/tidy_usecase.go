@@ -73,9 +73,11 @@ func (tu *tidyUpUsecase) TidyAll(dropInvalid bool) error {
 		tu.TidyAuthUsers,
 		tu.TidyUsers,
 		tu.TidyProviders,
+		tu.TidyBuiltinZones,
 		tu.TidyDomains,
 		tu.TidyZones,
 		tu.TidyDomainLogs,
+		tu.TidyProviderCalls,
 		tu.TidyCheckPlans,
 		tu.TidyCheckerConfigurations,
 		tu.TidyExecutions,
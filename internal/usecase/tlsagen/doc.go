@@ -0,0 +1,32 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package tlsagen derives TLSA records from the certificate chain actually
+// served by an endpoint.
+//
+// The Service fetches the chain of the endpoint of a TLSAs service, STARTTLS
+// included, and proposes its DANE-EE (3 1 1) and DANE-TA (2 1 1) records,
+// optionally keeping the current and the next key published together during
+// a rollover. It also follows the executions of the DANE checker: when the
+// published records no longer match the deployed certificate, the service is
+// regenerated in the current zone, leaving a pending correction for the user
+// to publish.
+package tlsagen
@@ -0,0 +1,199 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tlsagen
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.happydns.org/happyDomain/internal/netguard"
+)
+
+const fetchTimeout = 10 * time.Second
+
+// STARTTLS protocols understood by the fetcher.
+const (
+	STARTTLSNone = "none"
+	STARTTLSSMTP = "smtp"
+	STARTTLSIMAP = "imap"
+	STARTTLSPOP3 = "pop3"
+)
+
+// AutoSTARTTLS guesses the STARTTLS protocol of the well-known ports where
+// TLS is negotiated in-band.
+func AutoSTARTTLS(port uint16) string {
+	switch port {
+	case 25, 587:
+		return STARTTLSSMTP
+	case 143:
+		return STARTTLSIMAP
+	case 110:
+		return STARTTLSPOP3
+	default:
+		return STARTTLSNone
+	}
+}
+
+// Fetcher fetches certificate chains through the outbound guard, so that the
+// endpoint of a zone cannot be pointed at an internal address.
+type Fetcher struct {
+	guard *netguard.Guard
+}
+
+// NewFetcher builds a Fetcher dialing through guard.
+func NewFetcher(guard *netguard.Guard) *Fetcher {
+	return &Fetcher{guard: guard}
+}
+
+// FetchChain connects to host:port, negotiates TLS, upgrading the connection
+// first when starttls is not "none", and returns the chain sent by the server.
+// The chain is not verified: DANE is precisely about trusting what the zone
+// publishes rather than a CA.
+func (f *Fetcher) FetchChain(ctx context.Context, host string, port uint16, starttls string) ([]*x509.Certificate, error) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	conn, err := f.guard.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.FormatUint(uint64(port), 10)))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if err := startTLS(conn, starttls); err != nil {
+		return nil, fmt.Errorf("STARTTLS (%s) failed: %w", starttls, err)
+	}
+
+	tconn := tls.Client(conn, &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
+	})
+	if err := tconn.HandshakeContext(ctx); err != nil {
+		return nil, fmt.Errorf("TLS handshake failed: %w", err)
+	}
+
+	chain := tconn.ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("the server sent no certificate")
+	}
+
+	return chain, nil
+}
+
+// startTLS runs the in-band negotiation of protocol on conn, until the point
+// where the TLS handshake starts.
+func startTLS(conn net.Conn, protocol string) error {
+	tp := textproto.NewConn(conn)
+	if err := negotiate(tp, protocol); err != nil {
+		return err
+	}
+
+	// The handshake runs on conn, past the buffer: what the server sent
+	// after its answer would be lost, or is what was injected in the clear
+	// for the session to take as encrypted (CVE-2011-0411).
+	if n := tp.Reader.R.Buffered(); n > 0 {
+		return fmt.Errorf("the server sent %d bytes past its answer to STARTTLS", n)
+	}
+
+	return nil
+}
+
+// negotiate asks the server behind tp to start TLS, as protocol does it.
+func negotiate(tp *textproto.Conn, protocol string) error {
+	switch protocol {
+	case "", STARTTLSNone:
+		return nil
+
+	case STARTTLSSMTP:
+		if _, _, err := tp.ReadResponse(220); err != nil {
+			return err
+		}
+		if _, err := tp.Cmd("EHLO happydomain.invalid"); err != nil {
+			return err
+		}
+		if _, msg, err := tp.ReadResponse(250); err != nil {
+			return err
+		} else if !strings.Contains(strings.ToUpper(msg), "STARTTLS") {
+			return fmt.Errorf("the server does not offer STARTTLS")
+		}
+		if _, err := tp.Cmd("STARTTLS"); err != nil {
+			return err
+		}
+		_, _, err := tp.ReadResponse(220)
+		return err
+
+	case STARTTLSIMAP:
+		if err := expectPrefix(tp.Reader.R, "* OK"); err != nil {
+			return err
+		}
+		if _, err := tp.Cmd("a1 STARTTLS"); err != nil {
+			return err
+		}
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return err
+			}
+			if strings.HasPrefix(line, "a1 ") {
+				if !strings.HasPrefix(strings.ToUpper(line), "A1 OK") {
+					return fmt.Errorf("unexpected answer: %s", line)
+				}
+				return nil
+			}
+		}
+
+	case STARTTLSPOP3:
+		if err := expectPrefix(tp.Reader.R, "+OK"); err != nil {
+			return err
+		}
+		if _, err := tp.Cmd("STLS"); err != nil {
+			return err
+		}
+		return expectPrefix(tp.Reader.R, "+OK")
+
+	default:
+		return fmt.Errorf("unsupported protocol %q", protocol)
+	}
+}
+
+// expectPrefix reads a line and checks it starts with prefix.
+func expectPrefix(r *bufio.Reader, prefix string) error {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(strings.ToUpper(line), strings.ToUpper(prefix)) {
+		return fmt.Errorf("unexpected answer: %s", strings.TrimSpace(line))
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tlsagen
+
+import (
+	"bufio"
+	"net"
+	"testing"
+)
+
+// pop3Server greets the client on conn, then sends answer once the client
+// asks for TLS.
+func pop3Server(conn net.Conn, answer string) {
+	defer conn.Close()
+
+	conn.Write([]byte("+OK ready\r\n"))
+	if _, err := bufio.NewReader(conn).ReadString('\n'); err != nil {
+		return
+	}
+	conn.Write([]byte(answer))
+}
+
+func TestStartTLS(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	go pop3Server(server, "+OK begin TLS\r\n")
+
+	if err := startTLS(client, STARTTLSPOP3); err != nil {
+		t.Errorf("startTLS() error = %v", err)
+	}
+}
+
+func TestStartTLSInjected(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	go pop3Server(server, "+OK begin TLS\r\n-ERR injected\r\n")
+
+	if err := startTLS(client, STARTTLSPOP3); err == nil {
+		t.Errorf("startTLS() succeeded with data past the answer")
+	}
+}
@@ -0,0 +1,192 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tlsagen
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// TLSA certificate usages (RFC 7218).
+const (
+	usagePKIXTA uint8 = 0
+	usagePKIXEE uint8 = 1
+	usageDANETA uint8 = 2
+	usageDANEEE uint8 = 3
+
+	selectorSPKI uint8 = 1
+	matchSHA256  uint8 = 1
+)
+
+// newTLSA computes the record of the given parameters for cert, owned by
+// name.
+func newTLSA(name string, usage, selector, matching uint8, cert *x509.Certificate) (*dns.TLSA, error) {
+	data, err := dns.CertificateToDANE(selector, matching, cert)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dns.TLSA{
+		Hdr: dns.RR_Header{
+			Name:   name,
+			Rrtype: dns.TypeTLSA,
+			Class:  dns.ClassINET,
+		},
+		Usage:        usage,
+		Selector:     selector,
+		MatchingType: matching,
+		Certificate:  data,
+	}, nil
+}
+
+// certFor returns the certificate of chain a record of the given usage is
+// about: the leaf for the end-entity usages, its issuer for the trust anchor
+// ones.
+func certFor(chain []*x509.Certificate, usage uint8) *x509.Certificate {
+	if usage == usageDANEEE || usage == usagePKIXEE {
+		return chain[0]
+	}
+	if len(chain) > 1 {
+		return chain[1]
+	}
+	return nil
+}
+
+// proposeRecords computes the 3 1 1 record of the leaf of chain and, when
+// the server sends its issuer, the 2 1 1 one.
+func proposeRecords(name string, chain []*x509.Certificate) ([]*dns.TLSA, error) {
+	var ret []*dns.TLSA
+
+	for _, usage := range []uint8{usageDANEEE, usageDANETA} {
+		cert := certFor(chain, usage)
+		if cert == nil {
+			continue
+		}
+
+		rr, err := newTLSA(name, usage, selectorSPKI, matchSHA256, cert)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, rr)
+	}
+
+	return ret, nil
+}
+
+// regenerateRecords recomputes, against chain, one record for each set of
+// parameters used by records, so that the user's choice of usage, selector
+// and matching type is kept.
+func regenerateRecords(name string, records []*dns.TLSA, chain []*x509.Certificate) ([]*dns.TLSA, error) {
+	var ret []*dns.TLSA
+
+	for _, rr := range records {
+		cert := certFor(chain, rr.Usage)
+		if cert == nil {
+			continue
+		}
+
+		nrr, err := newTLSA(name, rr.Usage, rr.Selector, rr.MatchingType, cert)
+		if err != nil {
+			return nil, err
+		}
+		ret = mergeRecords(ret, nrr)
+	}
+
+	return ret, nil
+}
+
+// matchesChain tells whether one of records matches the certificate chain.
+func matchesChain(records []*dns.TLSA, chain []*x509.Certificate) bool {
+	for _, rr := range records {
+		cert := certFor(chain, rr.Usage)
+		if cert == nil {
+			continue
+		}
+
+		data, err := dns.CertificateToDANE(rr.Selector, rr.MatchingType, cert)
+		if err == nil && strings.EqualFold(data, rr.Certificate) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeRecords appends to records those of add they don't already hold.
+func mergeRecords(records []*dns.TLSA, add ...*dns.TLSA) []*dns.TLSA {
+next:
+	for _, rr := range add {
+		for _, existing := range records {
+			if sameTLSA(existing, rr) {
+				continue next
+			}
+		}
+		records = append(records, rr)
+	}
+	return records
+}
+
+func sameTLSA(a, b *dns.TLSA) bool {
+	return a.Usage == b.Usage && a.Selector == b.Selector && a.MatchingType == b.MatchingType && strings.EqualFold(a.Certificate, b.Certificate)
+}
+
+// sameRecords tells whether a and b hold the same records, in any order.
+func sameRecords(a, b []*dns.TLSA) bool {
+	return len(mergeRecords(a, b...)) == len(a) && len(mergeRecords(b, a...)) == len(b)
+}
+
+// parseNextKey reads the public key of a PEM-encoded public key, certificate
+// or certificate request, as a certificate holding only that key.
+func parseNextKey(data string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(data)))
+	if block == nil {
+		return nil, fmt.Errorf("the next key is not PEM-encoded")
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		if _, err := x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		return &x509.Certificate{RawSubjectPublicKeyInfo: block.Bytes}, nil
+
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate: %w", err)
+		}
+		return &x509.Certificate{RawSubjectPublicKeyInfo: cert.RawSubjectPublicKeyInfo}, nil
+
+	case "CERTIFICATE REQUEST", "NEW CERTIFICATE REQUEST":
+		csr, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate request: %w", err)
+		}
+		return &x509.Certificate{RawSubjectPublicKeyInfo: csr.RawSubjectPublicKeyInfo}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q: expected a public key, a certificate or a certificate request", block.Type)
+	}
+}
@@ -0,0 +1,219 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tlsagen
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func newTestCert(t *testing.T, cn string, issuer *x509.Certificate, issuerKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  issuer == nil,
+		BasicConstraintsValid: true,
+	}
+
+	parent, signer := tmpl, key
+	if issuer != nil {
+		parent, signer = issuer, issuerKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func newTestChain(t *testing.T) []*x509.Certificate {
+	t.Helper()
+
+	ca, caKey := newTestCert(t, "Test CA", nil, nil)
+	leaf, _ := newTestCert(t, "www.example.com", ca, caKey)
+	return []*x509.Certificate{leaf, ca}
+}
+
+func TestProposeRecords(t *testing.T) {
+	chain := newTestChain(t)
+
+	records, err := proposeRecords("_443._tcp", chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	if records[0].Usage != usageDANEEE || records[0].Selector != selectorSPKI || records[0].MatchingType != matchSHA256 {
+		t.Errorf("unexpected first record parameters: %s", records[0])
+	}
+	if records[1].Usage != usageDANETA {
+		t.Errorf("expected a DANE-TA second record, got %s", records[1])
+	}
+
+	leafData, _ := dns.CertificateToDANE(selectorSPKI, matchSHA256, chain[0])
+	if records[0].Certificate != leafData {
+		t.Errorf("DANE-EE record doesn't match the leaf")
+	}
+	caData, _ := dns.CertificateToDANE(selectorSPKI, matchSHA256, chain[1])
+	if records[1].Certificate != caData {
+		t.Errorf("DANE-TA record doesn't match the issuer")
+	}
+
+	// Without the issuer, only the end-entity record can be computed.
+	records, err = proposeRecords("_443._tcp", chain[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Usage != usageDANEEE {
+		t.Errorf("expected a single DANE-EE record, got %v", records)
+	}
+}
+
+func TestRegenerateRecordsKeepsParameters(t *testing.T) {
+	oldChain := newTestChain(t)
+	newChain := newTestChain(t)
+
+	old, err := newTLSA("_25._tcp", usageDANEEE, 0, 2, oldChain[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if matchesChain([]*dns.TLSA{old}, newChain) {
+		t.Fatal("old record shouldn't match the new chain")
+	}
+
+	records, err := regenerateRecords("_25._tcp", []*dns.TLSA{old}, newChain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	if records[0].Usage != usageDANEEE || records[0].Selector != 0 || records[0].MatchingType != 2 {
+		t.Errorf("parameters not kept: %s", records[0])
+	}
+	if !matchesChain(records, newChain) {
+		t.Error("regenerated record doesn't match the new chain")
+	}
+}
+
+func TestMergeRecords(t *testing.T) {
+	chain := newTestChain(t)
+
+	records, err := proposeRecords("_443._tcp", chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merged := mergeRecords(records[:1], records...)
+	if len(merged) != 2 {
+		t.Errorf("expected 2 records after merge, got %d", len(merged))
+	}
+	if !sameRecords(merged, []*dns.TLSA{records[1], records[0]}) {
+		t.Error("expected the same records in any order")
+	}
+	if sameRecords(merged, records[:1]) {
+		t.Error("record sets of different sizes shouldn't be the same")
+	}
+}
+
+func TestParseNextKey(t *testing.T) {
+	cert, key := newTestCert(t, "next.example.com", nil, nil)
+	expected, _ := dns.CertificateToDANE(selectorSPKI, matchSHA256, cert)
+
+	spki, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: "next.example.com"}}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, block := range map[string]*pem.Block{
+		"public key":  {Type: "PUBLIC KEY", Bytes: spki},
+		"certificate": {Type: "CERTIFICATE", Bytes: cert.Raw},
+		"csr":         {Type: "CERTIFICATE REQUEST", Bytes: csrDER},
+	} {
+		next, err := parseNextKey(string(pem.EncodeToMemory(block)))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", name, err)
+			continue
+		}
+
+		data, err := dns.CertificateToDANE(selectorSPKI, matchSHA256, next)
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+		} else if data != expected {
+			t.Errorf("%s: got %s, expected %s", name, data, expected)
+		}
+	}
+
+	if _, err := parseNextKey("not a key"); err == nil {
+		t.Error("expected an error on non-PEM input")
+	}
+	if _, err := parseNextKey(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte{0}}))); err == nil {
+		t.Error("expected an error on unsupported PEM block")
+	}
+}
+
+func TestAutoSTARTTLS(t *testing.T) {
+	for port, expected := range map[uint16]string{
+		25:  STARTTLSSMTP,
+		587: STARTTLSSMTP,
+		143: STARTTLSIMAP,
+		110: STARTTLSPOP3,
+		443: STARTTLSNone,
+		993: STARTTLSNone,
+	} {
+		if got := AutoSTARTTLS(port); got != expected {
+			t.Errorf("AutoSTARTTLS(%d) = %q, expected %q", port, got, expected)
+		}
+	}
+}
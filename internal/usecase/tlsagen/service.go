@@ -0,0 +1,311 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tlsagen
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"git.happydns.org/happyDomain/internal/helpers"
+	domainlogUC "git.happydns.org/happyDomain/internal/usecase/domain_log"
+	"git.happydns.org/happyDomain/model"
+	svcs "git.happydns.org/happyDomain/services"
+)
+
+const (
+	// DANECheckerID is the checker whose executions are followed.
+	DANECheckerID = "dane"
+
+	// NotifierID identifies the TLSA corrections in the notification
+	// pipeline, in place of a checker.
+	NotifierID = "tlsa_generation"
+
+	// correctionTimeout bounds the time given to a correction, certificate
+	// fetch included.
+	correctionTimeout = time.Minute
+)
+
+// Service implements happydns.TLSAGeneratorUsecase.
+type Service struct {
+	fetcher     ChainFetcher
+	domains     DomainGetter
+	users       UserGetter
+	getZone     ZoneGetter
+	zoneService happydns.ZoneServiceUsecase
+	domainLog   domainlogUC.DomainLogAppender
+	notifier    EventNotifier
+
+	// mu serializes the corrections, so that two executions completing
+	// together don't edit the same zone concurrently.
+	mu sync.Mutex
+}
+
+// NewService builds the TLSA generation Service. notifier may be nil to
+// disable notifications.
+func NewService(
+	fetcher ChainFetcher,
+	domains DomainGetter,
+	users UserGetter,
+	getZone ZoneGetter,
+	zoneService happydns.ZoneServiceUsecase,
+	domainLog domainlogUC.DomainLogAppender,
+	notifier EventNotifier,
+) *Service {
+	return &Service{
+		fetcher:     fetcher,
+		domains:     domains,
+		users:       users,
+		getZone:     getZone,
+		zoneService: zoneService,
+		domainLog:   domainLog,
+		notifier:    notifier,
+	}
+}
+
+// Generate fetches the certificate chain of the endpoint of the TLSAs service
+// and proposes its records. Nothing is changed in the zone: the proposal is
+// for the user to review in the service editor.
+func (s *Service) Generate(ctx context.Context, domain *happydns.Domain, zone *happydns.Zone, subdomain happydns.Subdomain, serviceId happydns.Identifier, input *happydns.TLSAGenerationInput) (*happydns.TLSAGeneration, error) {
+	_, svc := zone.FindSubdomainService(subdomain, serviceId)
+	if svc == nil {
+		return nil, happydns.NotFoundError{Msg: "service not found"}
+	}
+
+	tlsas, ok := svc.Service.(*svcs.TLSAs)
+	if !ok {
+		return nil, happydns.ValidationError{Msg: "TLSA records can only be generated for a TLSA service"}
+	}
+
+	port, proto := endpointOf(tlsas)
+	if input.Port != 0 {
+		port = input.Port
+	}
+	if port == 0 {
+		port = 443
+	}
+	if proto != "tcp" {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("TLSA records can only be generated for TCP endpoints, not %s", proto)}
+	}
+
+	starttls := strings.ToLower(input.STARTTLS)
+	if starttls == "" {
+		starttls = AutoSTARTTLS(port)
+	}
+
+	nextKey := strings.TrimSpace(input.NextKey)
+	if input.Rollover && nextKey == "" && len(tlsas.Records) == 0 {
+		return nil, happydns.ValidationError{Msg: "a rollover needs either records already in the service or the next key"}
+	}
+
+	host := strings.TrimSuffix(helpers.DomainFQDN(string(subdomain), domain.DomainName), ".")
+	chain, err := s.fetcher.FetchChain(ctx, host, port, starttls)
+	if err != nil {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("unable to fetch the certificate of %s: %s", host, err.Error())}
+	}
+
+	owner := fmt.Sprintf("_%d._tcp", port)
+
+	records, err := proposeRecords(owner, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Rollover {
+		for _, rr := range tlsas.Records {
+			if rr.Hdr.Name == owner {
+				records = mergeRecords(records, rr)
+			}
+		}
+	}
+
+	if nextKey != "" {
+		next, err := parseNextKey(nextKey)
+		if err != nil {
+			return nil, happydns.ValidationError{Msg: err.Error()}
+		}
+
+		rr, err := newTLSA(owner, usageDANEEE, selectorSPKI, matchSHA256, next)
+		if err != nil {
+			return nil, happydns.ValidationError{Msg: err.Error()}
+		}
+		records = mergeRecords(records, rr)
+	}
+
+	return &happydns.TLSAGeneration{
+		Endpoint: net.JoinHostPort(host, strconv.FormatUint(uint64(port), 10)),
+		Records:  records,
+	}, nil
+}
+
+// endpointOf reads the port and protocol the records of the service are
+// about, from their owner name.
+func endpointOf(tlsas *svcs.TLSAs) (port uint16, proto string) {
+	proto = "tcp"
+
+	for _, rr := range tlsas.Records {
+		m := svcs.TLSA_DOMAIN.FindStringSubmatch(helpers.DomainJoin(rr.Hdr.Name, "x"))
+		if len(m) != 4 {
+			continue
+		}
+
+		p, err := strconv.ParseUint(m[1], 10, 16)
+		if err != nil {
+			continue
+		}
+		return uint16(p), m[2]
+	}
+
+	return 0, proto
+}
+
+// OnExecutionComplete is chained to the checker engine completion callback.
+// A DANE execution on a TLSAs service reporting a problem gets the service
+// checked against the deployed certificate, in the background.
+func (s *Service) OnExecutionComplete(exec *happydns.Execution, eval *happydns.CheckEvaluation) {
+	if exec == nil || eval == nil || exec.CheckerID != DANECheckerID || exec.Target.DomainId == "" || exec.Target.ServiceId == "" {
+		return
+	}
+
+	failing := false
+	for _, st := range eval.States {
+		if st.Status >= happydns.StatusWarn {
+			failing = true
+			break
+		}
+	}
+	if !failing {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), correctionTimeout)
+		defer cancel()
+
+		if err := s.Correct(ctx, exec.Target); err != nil {
+			log.Printf("TLSA: unable to correct service %s of domain %s: %s", exec.Target.ServiceId, exec.Target.DomainId, err.Error())
+		}
+	}()
+}
+
+// Correct compares the records of the TLSAs service of target with the
+// certificate chain its endpoint currently serves. When none of them matches,
+// the records are regenerated, with the same parameters, in the current zone
+// of the domain: the change is left pending, for the user to publish.
+func (s *Service) Correct(ctx context.Context, target happydns.CheckTarget) error {
+	domainId, err := happydns.NewIdentifierFromString(target.DomainId)
+	if err != nil {
+		return err
+	}
+	serviceId, err := happydns.NewIdentifierFromString(target.ServiceId)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	domain, err := s.domains.GetDomain(domainId)
+	if err != nil {
+		return err
+	}
+	if len(domain.ZoneHistory) == 0 {
+		return nil
+	}
+
+	zone, err := s.getZone.Get(domain.ZoneHistory[0])
+	if err != nil {
+		return err
+	}
+
+	subdomain, svc := zone.FindService(serviceId)
+	if svc == nil {
+		return nil
+	}
+	tlsas, ok := svc.Service.(*svcs.TLSAs)
+	if !ok || len(tlsas.Records) == 0 {
+		return nil
+	}
+
+	port, proto := endpointOf(tlsas)
+	if port == 0 || proto != "tcp" {
+		return nil
+	}
+
+	host := strings.TrimSuffix(helpers.DomainFQDN(string(subdomain), domain.DomainName), ".")
+	chain, err := s.fetcher.FetchChain(ctx, host, port, AutoSTARTTLS(port))
+	if err != nil {
+		// The endpoint being down is the checker's business, not a
+		// mismatch.
+		return nil
+	}
+
+	if matchesChain(tlsas.Records, chain) {
+		return nil
+	}
+
+	records, err := regenerateRecords(tlsas.Records[0].Hdr.Name, tlsas.Records, chain)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 || sameRecords(records, tlsas.Records) {
+		return nil
+	}
+
+	user, err := s.users.GetUser(domain.Owner)
+	if err != nil {
+		return err
+	}
+
+	newSvc := *svc
+	newSvc.Service = &svcs.TLSAs{Records: records}
+
+	if _, err := s.zoneService.UpdateZoneService(user, domain, zone, subdomain, svc.Id, &newSvc); err != nil {
+		return err
+	}
+
+	fqdn := helpers.DomainFQDN(string(subdomain), domain.DomainName)
+	msg := fmt.Sprintf("TLSA records of %s:%d do not match the deployed certificate anymore: they have been regenerated, review and publish the pending changes", fqdn, port)
+
+	if err := s.domainLog.AppendDomainLog(domain, happydns.NewDomainLog(user, happydns.LOG_WARN, msg)); err != nil {
+		log.Printf("TLSA: unable to append domain log for %s: %s", domain.DomainName, err.Error())
+	}
+
+	if s.notifier != nil {
+		s.notifier.NotifyEvent(NotifierID, happydns.CheckTarget{
+			UserId:    domain.Owner.String(),
+			DomainId:  domain.Id.String(),
+			ServiceId: svc.Id.String(),
+		}, []happydns.CheckState{{
+			Status:  happydns.StatusWarn,
+			Code:    "tlsa_correction_pending",
+			Message: msg + ".",
+		}})
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package tlsreport ingests the RFC 8460 SMTP TLS reports that sending mail
+// servers deliver to the rua= address of a domain's TLS-RPT record, keeps
+// them for a retention period, and summarizes the failed sessions per
+// result type (sts-policy-fetch-error, certificate-expired, tlsa-invalid...).
+//
+// Reports are posted by the senders to the per-domain report URI, uploaded
+// through the API, or fetched from the mailbox the reports are delivered
+// to. A report showing a burst of failures is notified through the
+// notification dispatcher.
+package tlsreport
@@ -0,0 +1,173 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tlsreport
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// maxReportSize bounds the size of a decompressed report, as a safeguard
+// against compression bombs.
+const maxReportSize = 32 << 20
+
+// report mirrors the JSON schema of RFC 8460 section 4.
+type report struct {
+	OrganizationName string `json:"organization-name"`
+	DateRange        struct {
+		Start time.Time `json:"start-datetime"`
+		End   time.Time `json:"end-datetime"`
+	} `json:"date-range"`
+	ContactInfo string `json:"contact-info"`
+	ReportID    string `json:"report-id"`
+	Policies    []struct {
+		Policy struct {
+			PolicyType   string   `json:"policy-type"`
+			PolicyString []string `json:"policy-string"`
+			PolicyDomain string   `json:"policy-domain"`
+			MXHost       []string `json:"mx-host"`
+		} `json:"policy"`
+		Summary struct {
+			TotalSuccessfulSessionCount int `json:"total-successful-session-count"`
+			TotalFailureSessionCount    int `json:"total-failure-session-count"`
+		} `json:"summary"`
+		FailureDetails []struct {
+			ResultType            string `json:"result-type"`
+			SendingMTAIP          string `json:"sending-mta-ip"`
+			ReceivingMXHostname   string `json:"receiving-mx-hostname"`
+			ReceivingIP           string `json:"receiving-ip"`
+			FailedSessionCount    int    `json:"failed-session-count"`
+			AdditionalInformation string `json:"additional-information"`
+			FailureReasonCode     string `json:"failure-reason-code"`
+		} `json:"failure-details"`
+	} `json:"policies"`
+}
+
+// decompress returns the JSON document held in raw, which may be a gzip
+// stream (application/tlsrpt+gzip) or plain JSON (application/tlsrpt+json).
+func decompress(raw []byte) ([]byte, error) {
+	if !bytes.HasPrefix(raw, []byte{0x1f, 0x8b}) {
+		return raw, nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(io.LimitReader(zr, maxReportSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxReportSize {
+		return nil, errors.New("report too large")
+	}
+	return data, nil
+}
+
+func normalizeDomain(domain string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+}
+
+// Parse decodes a raw SMTP TLS report, compressed or not.
+func Parse(raw []byte) (*happydns.TLSReport, error) {
+	data, err := decompress(raw)
+	if err != nil {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("unable to decompress the report: %s", err.Error())}
+	}
+
+	var r report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("not an SMTP TLS report: %s", err.Error())}
+	}
+
+	if r.ReportID == "" {
+		return nil, happydns.ValidationError{Msg: "not an SMTP TLS report: no report identifier"}
+	}
+	if len(r.Policies) == 0 {
+		return nil, happydns.ValidationError{Msg: "not an SMTP TLS report: no policy"}
+	}
+
+	ret := &happydns.TLSReport{
+		OrgName:     strings.TrimSpace(r.OrganizationName),
+		ContactInfo: strings.TrimSpace(r.ContactInfo),
+		ReportID:    strings.TrimSpace(r.ReportID),
+		Begin:       r.DateRange.Start.UTC(),
+		End:         r.DateRange.End.UTC(),
+	}
+
+	for _, p := range r.Policies {
+		domain := normalizeDomain(p.Policy.PolicyDomain)
+		if domain == "" {
+			return nil, happydns.ValidationError{Msg: "not an SMTP TLS report: a policy has no domain"}
+		}
+
+		policy := happydns.TLSReportPolicy{
+			PolicyType:         strings.ToLower(strings.TrimSpace(p.Policy.PolicyType)),
+			PolicyDomain:       domain,
+			SuccessfulSessions: max(p.Summary.TotalSuccessfulSessionCount, 0),
+			FailedSessions:     max(p.Summary.TotalFailureSessionCount, 0),
+		}
+		for _, mx := range p.Policy.MXHost {
+			policy.MXHosts = append(policy.MXHosts, normalizeDomain(mx))
+		}
+		for _, f := range p.FailureDetails {
+			if f.FailedSessionCount <= 0 {
+				continue
+			}
+			policy.Failures = append(policy.Failures, happydns.TLSReportFailure{
+				ResultType:          strings.ToLower(strings.TrimSpace(f.ResultType)),
+				SendingMTAIP:        f.SendingMTAIP,
+				ReceivingMXHostname: normalizeDomain(f.ReceivingMXHostname),
+				ReceivingIP:         f.ReceivingIP,
+				FailedSessions:      f.FailedSessionCount,
+				AdditionalInfo:      f.AdditionalInformation,
+				FailureReasonCode:   f.FailureReasonCode,
+			})
+		}
+
+		ret.Policies = append(ret.Policies, policy)
+	}
+
+	return ret, nil
+}
+
+// PolicyDomains returns the distinct domains the policies of r are about.
+func PolicyDomains(r *happydns.TLSReport) []string {
+	var domains []string
+	for _, p := range r.Policies {
+		if !slices.Contains(domains, p.PolicyDomain) {
+			domains = append(domains, p.PolicyDomain)
+		}
+	}
+	return domains
+}
@@ -0,0 +1,193 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tlsreport
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"time"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// sampleReport is the example of RFC 8460 appendix B, with an extra policy.
+const sampleReport = `{
+  "organization-name": "Company-X",
+  "date-range": {
+    "start-datetime": "2016-04-01T00:00:00Z",
+    "end-datetime": "2016-04-01T23:59:59Z"
+  },
+  "contact-info": "sts-reporting@company-x.example",
+  "report-id": "5065427c-23d3-47ca-b6e0-946ea0e8c4be",
+  "policies": [{
+    "policy": {
+      "policy-type": "sts",
+      "policy-string": ["version: STSv1", "mode: testing", "mx: *.mail.company-y.example", "max_age: 86400"],
+      "policy-domain": "company-y.example",
+      "mx-host": ["*.mail.company-y.example"]
+    },
+    "summary": {
+      "total-successful-session-count": 5326,
+      "total-failure-session-count": 303
+    },
+    "failure-details": [{
+      "result-type": "certificate-expired",
+      "sending-mta-ip": "2001:db8:abcd:0012::1",
+      "receiving-mx-hostname": "mx1.mail.company-y.example",
+      "failed-session-count": 100
+    }, {
+      "result-type": "starttls-not-supported",
+      "sending-mta-ip": "2001:db8:abcd:0013::1",
+      "receiving-mx-hostname": "mx2.mail.company-y.example",
+      "receiving-ip": "203.0.113.56",
+      "failed-session-count": 200,
+      "additional-information": "https://reports.company-x.example/report_info?id=5065427c-23d3#StarttlsNotSupported"
+    }, {
+      "result-type": "validation-failure",
+      "sending-mta-ip": "198.51.100.62",
+      "receiving-ip": "203.0.113.58",
+      "receiving-mx-hostname": "mx-backup.mail.company-y.example",
+      "failed-session-count": 3,
+      "failure-reason-code": "X509_V_ERR_PROXY_PATH_LENGTH_EXCEEDED"
+    }]
+  }, {
+    "policy": {
+      "policy-type": "no-policy-found",
+      "policy-domain": "other.example"
+    },
+    "summary": {
+      "total-successful-session-count": 10,
+      "total-failure-session-count": 0
+    }
+  }]
+}`
+
+func TestParse(t *testing.T) {
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write([]byte(sampleReport))
+	gw.Close()
+
+	for name, raw := range map[string][]byte{
+		"json": []byte(sampleReport),
+		"gzip": gz.Bytes(),
+	} {
+		report, err := Parse(raw)
+		if err != nil {
+			t.Fatalf("%s: Parse() error = %v", name, err)
+		}
+
+		if report.OrgName != "Company-X" || report.ReportID != "5065427c-23d3-47ca-b6e0-946ea0e8c4be" || !report.Begin.Equal(time.Date(2016, 4, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("%s: unexpected metadata: %+v", name, report)
+		}
+		if len(report.Policies) != 2 {
+			t.Fatalf("%s: got %d policies, want 2", name, len(report.Policies))
+		}
+
+		p := report.Policies[0]
+		if p.PolicyType != "sts" || p.PolicyDomain != "company-y.example" || p.SuccessfulSessions != 5326 || p.FailedSessions != 303 || len(p.Failures) != 3 {
+			t.Errorf("%s: unexpected first policy: %+v", name, p)
+		}
+		if f := p.Failures[2]; f.ResultType != "validation-failure" || f.FailedSessions != 3 || f.FailureReasonCode == "" {
+			t.Errorf("%s: unexpected failure: %+v", name, f)
+		}
+
+		if domains := PolicyDomains(report); len(domains) != 2 || domains[1] != "other.example" {
+			t.Errorf("%s: PolicyDomains() = %v", name, domains)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for name, raw := range map[string]string{
+		"empty":       "",
+		"not json":    "<feedback/>",
+		"no id":       `{"policies": [{"policy": {"policy-domain": "example.com"}}]}`,
+		"no policy":   `{"report-id": "1"}`,
+		"no domain":   `{"report-id": "1", "policies": [{"policy": {"policy-type": "sts"}}]}`,
+		"bad gzip":    "\x1f\x8bgarbage",
+		"bad summary": `{"report-id": "1", "policies": [{"policy": {"policy-domain": "example.com"}, "summary": {"total-failure-session-count": "many"}}]}`,
+	} {
+		if _, err := Parse([]byte(raw)); err == nil {
+			t.Errorf("%s: Parse() succeeded, want an error", name)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	report, err := Parse([]byte(sampleReport))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	other := *report
+	other.OrgName = "Company-Z"
+
+	summary := summarize([]*happydns.TLSReport{report, &other})
+
+	if summary.Reports != 2 || summary.SuccessfulSessions != 2*5336 || summary.FailedSessions != 2*303 {
+		t.Errorf("unexpected totals: %+v", summary)
+	}
+	if len(summary.Failures) != 3 {
+		t.Fatalf("got %d failure types, want 3", len(summary.Failures))
+	}
+	if f := summary.Failures[0]; f.ResultType != "starttls-not-supported" || f.Sessions != 400 || len(f.MXHosts) != 1 || len(f.Reporters) != 2 {
+		t.Errorf("unexpected first failure type: %+v", f)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	report, err := Parse([]byte(sampleReport))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	settings := &happydns.TLSReportSettings{
+		NotifySpikes:   true,
+		SpikeThreshold: 5,
+	}
+
+	// 303 failures out of 5639 sessions, 5.4%.
+	states := evaluate(report, nil, settings)
+	if len(states) != 1 || states[0].Code != "tlsrpt_failure_spike" {
+		t.Errorf("unexpected states without history: %+v", states)
+	}
+
+	// Same failure rate as the day before: not a spike.
+	previous := *report
+	previous.End = report.End.Add(-24 * time.Hour)
+	states = evaluate(report, []*happydns.TLSReport{&previous}, settings)
+	if len(states) != 1 || states[0].Status != happydns.StatusOK {
+		t.Errorf("unexpected states with a steady rate: %+v", states)
+	}
+
+	settings.SpikeThreshold = 10
+	states = evaluate(report, nil, settings)
+	if len(states) != 1 || states[0].Status != happydns.StatusOK {
+		t.Errorf("unexpected states below the threshold: %+v", states)
+	}
+
+	settings.NotifySpikes = false
+	if states := evaluate(report, nil, settings); states != nil {
+		t.Errorf("expected no state when notifications are disabled, got %+v", states)
+	}
+}
@@ -0,0 +1,504 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tlsreport
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"git.happydns.org/happyDomain/internal/mailbox"
+	"git.happydns.org/happyDomain/model"
+)
+
+const (
+	// CheckerID identifies the report notifications in the notification
+	// pipeline, in place of a checker.
+	CheckerID = "tls-report"
+
+	// defaultSpikeThreshold is the failure rate, in percent, from which a
+	// report is notified unless the domain settings say otherwise.
+	defaultSpikeThreshold = 5
+
+	// baselineWindow is how far back the previous reports are considered
+	// to tell a spike from a steady failure rate.
+	baselineWindow = 30 * 24 * time.Hour
+)
+
+// Service implements happydns.TLSReportUsecase.
+type Service struct {
+	store     TLSReportStorage
+	domains   DomainFinder
+	notifier  EventNotifier
+	baseURL   string
+	address   string
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewService builds the report Service. baseURL is the public URL of
+// happyDomain, used to build the report URIs, and address the mailbox
+// receiving the reports, of which each domain gets a subaddress. Reports
+// older than retentionDays are dropped; notifier may be nil to disable
+// notifications.
+func NewService(store TLSReportStorage, domains DomainFinder, notifier EventNotifier, baseURL, address string, retentionDays int) *Service {
+	if retentionDays <= 0 {
+		retentionDays = 180
+	}
+	return &Service{
+		store:     store,
+		domains:   domains,
+		notifier:  notifier,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		address:   address,
+		retention: time.Duration(retentionDays) * 24 * time.Hour,
+		now:       time.Now,
+	}
+}
+
+// IngestForDomain parses a report uploaded for domain. Only the policies
+// about domain or one of its subdomains are kept.
+func (s *Service) IngestForDomain(ctx context.Context, domain *happydns.Domain, raw []byte) (*happydns.TLSReport, error) {
+	report, err := Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ingestFor(domain, report)
+}
+
+// IngestWithToken parses a report posted by a sender to the report URI of
+// the domain.
+func (s *Service) IngestWithToken(ctx context.Context, domainId happydns.Identifier, token string, raw []byte) (*happydns.TLSReport, error) {
+	domain := s.authenticatedDomain(domainId, token)
+	if domain == nil {
+		// Don't tell apart unknown domains and bad tokens.
+		return nil, happydns.ErrDomainNotFound
+	}
+
+	return s.IngestForDomain(ctx, domain, raw)
+}
+
+// Ingest parses a report and stores it for the domains it was sent to the
+// report address of. Anyone can add a domain of any name, so the names of
+// the policy domains alone don't tell whose the report is: only the owner
+// of the domain can publish its report address in the TLS-RPT record.
+func (s *Service) Ingest(ctx context.Context, recipients []string, raw []byte) ([]*happydns.TLSReport, error) {
+	report, err := Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var stored []*happydns.TLSReport
+	for _, tag := range mailbox.SubaddressTags(s.address, recipients) {
+		id, token, ok := strings.Cut(tag, ".")
+		if !ok {
+			continue
+		}
+
+		domainId, err := happydns.NewIdentifierFromString(id)
+		if err != nil {
+			continue
+		}
+
+		domain := s.authenticatedDomain(domainId, token)
+		if domain == nil {
+			continue
+		}
+
+		saved, err := s.ingestFor(domain, report)
+		var verr happydns.ValidationError
+		if errors.As(err, &verr) {
+			continue
+		} else if err != nil {
+			return stored, err
+		}
+		stored = append(stored, saved)
+	}
+
+	if len(stored) == 0 {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("no domain matching %s has this report address", strings.Join(PolicyDomains(report), ", "))}
+	}
+
+	return stored, nil
+}
+
+// IngestMessage is the mailbox.Handler of the reports delivered by email:
+// every attachment holding a report is ingested.
+func (s *Service) IngestMessage(ctx context.Context, msg *mailbox.Message, attachments []mailbox.Attachment) error {
+	var errs []error
+	for _, att := range attachments {
+		if !IsReportAttachment(att) {
+			continue
+		}
+		if _, err := s.Ingest(ctx, msg.Recipients(), att.Data); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", att.Filename, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// IsReportAttachment tells whether an email attachment looks like an SMTP
+// TLS report, from its media type or file name.
+func IsReportAttachment(att mailbox.Attachment) bool {
+	switch att.ContentType {
+	case "application/tlsrpt+gzip", "application/tlsrpt+json":
+		return true
+	}
+
+	name := strings.ToLower(att.Filename)
+	return strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".json.gz")
+}
+
+// authenticatedDomain returns the domain of domainId when token is its
+// ingestion token.
+func (s *Service) authenticatedDomain(domainId happydns.Identifier, token string) *happydns.Domain {
+	domain, err := s.domains.GetDomain(domainId)
+	if err != nil {
+		return nil
+	}
+
+	settings, err := s.store.GetTLSReportSettings(domain.Id)
+	if err != nil || settings.IngestToken == "" || subtle.ConstantTimeCompare([]byte(settings.IngestToken), []byte(token)) != 1 {
+		return nil
+	}
+
+	return domain
+}
+
+// ingestFor stores the part of report about domain.
+func (s *Service) ingestFor(domain *happydns.Domain, report *happydns.TLSReport) (*happydns.TLSReport, error) {
+	r := *report
+	r.Policies = nil
+	for _, p := range report.Policies {
+		if isSubdomain(p.PolicyDomain, domain.DomainName) {
+			r.Policies = append(r.Policies, p)
+		}
+	}
+
+	if len(r.Policies) == 0 {
+		return nil, happydns.ValidationError{Msg: fmt.Sprintf("this report is about %s, not %s", strings.Join(PolicyDomains(report), ", "), strings.TrimSuffix(domain.DomainName, "."))}
+	}
+
+	return s.storeReport(domain, &r)
+}
+
+// storeReport saves report for domain, unless the same report has already
+// been received, then raises the notification it calls for.
+func (s *Service) storeReport(domain *happydns.Domain, report *happydns.TLSReport) (*happydns.TLSReport, error) {
+	previous, err := s.store.ListTLSReports(domain.Id)
+	if err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to ListTLSReports(%s): %w", domain.Id.String(), err),
+			UserMessage: "Sorry, we are currently unable to store the report. Please retry later.",
+		}
+	}
+
+	for _, p := range previous {
+		if p.OrgName == report.OrgName && p.ReportID == report.ReportID {
+			return p, nil
+		}
+	}
+
+	report.DomainId = domain.Id
+	report.ReceivedAt = s.now()
+
+	if err := s.store.CreateTLSReport(report); err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to CreateTLSReport(%s): %w", domain.Id.String(), err),
+			UserMessage: "Sorry, we are currently unable to store the report. Please retry later.",
+		}
+	}
+
+	if s.notifier != nil {
+		settings, err := s.GetSettings(domain)
+		if err != nil {
+			log.Printf("TLS reports: unable to retrieve settings of %s: %s", domain.DomainName, err.Error())
+		} else if states := evaluate(report, previous, settings); states != nil {
+			s.notifier.NotifyEvent(CheckerID, happydns.CheckTarget{
+				UserId:   domain.Owner.String(),
+				DomainId: domain.Id.String(),
+			}, states)
+		}
+	}
+
+	return report, nil
+}
+
+func sessions(r *happydns.TLSReport) (successful, failed int) {
+	for _, p := range r.Policies {
+		successful += p.SuccessfulSessions
+		failed += p.FailedSessions
+	}
+	return
+}
+
+// evaluate builds the notification states of a newly received report. A
+// report is a spike when its failure rate reaches the threshold and at
+// least doubles the rate of the reports received over the previous
+// baselineWindow. It returns nil when there is nothing to tell.
+func evaluate(report *happydns.TLSReport, previous []*happydns.TLSReport, settings *happydns.TLSReportSettings) []happydns.CheckState {
+	if !settings.NotifySpikes {
+		return nil
+	}
+
+	successful, failed := sessions(report)
+	if successful+failed == 0 {
+		return nil
+	}
+	rate := 100 * float64(failed) / float64(successful+failed)
+
+	var baseSuccessful, baseFailed int
+	for _, p := range previous {
+		if p.End.Before(report.End) && report.End.Sub(p.End) <= baselineWindow {
+			s, f := sessions(p)
+			baseSuccessful += s
+			baseFailed += f
+		}
+	}
+	baseRate := 0.0
+	if baseSuccessful+baseFailed > 0 {
+		baseRate = 100 * float64(baseFailed) / float64(baseSuccessful+baseFailed)
+	}
+
+	if rate < settings.SpikeThreshold || rate < 2*baseRate {
+		return []happydns.CheckState{{
+			Status:  happydns.StatusOK,
+			Code:    "tlsrpt_ok",
+			Message: fmt.Sprintf("%s reported %d failed TLS session(s) out of %d (%.1f%%).", report.OrgName, failed, successful+failed, rate),
+		}}
+	}
+
+	var types []string
+	for _, f := range summarize([]*happydns.TLSReport{report}).Failures {
+		types = append(types, fmt.Sprintf("%s (%d)", f.ResultType, f.Sessions))
+	}
+
+	msg := fmt.Sprintf("%s reported %d failed TLS session(s) out of %d (%.1f%%, against %.1f%% previously)", report.OrgName, failed, successful+failed, rate, baseRate)
+	if len(types) > 0 {
+		msg += ": " + strings.Join(types, ", ")
+	}
+
+	return []happydns.CheckState{{
+		Status:  happydns.StatusWarn,
+		Code:    "tlsrpt_failure_spike",
+		Message: msg + ".",
+	}}
+}
+
+// ListReports returns the reports covering a period ending after since,
+// newest first.
+func (s *Service) ListReports(domain *happydns.Domain, since time.Time) ([]*happydns.TLSReport, error) {
+	reports, err := s.store.ListTLSReports(domain.Id)
+	if err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to ListTLSReports(%s): %w", domain.Id.String(), err),
+			UserMessage: "Sorry, we are currently unable to retrieve the reports. Please retry later.",
+		}
+	}
+
+	ret := reports[:0]
+	for _, r := range reports {
+		if !r.End.Before(since) {
+			ret = append(ret, r)
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].End.After(ret[j].End)
+	})
+
+	return ret, nil
+}
+
+// Summary aggregates per result type the failures reported over a period
+// ending after since.
+func (s *Service) Summary(domain *happydns.Domain, since time.Time) (*happydns.TLSReportSummary, error) {
+	reports, err := s.ListReports(domain, since)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := summarize(reports)
+	summary.DomainId = domain.Id
+	summary.From = since
+	summary.To = s.now()
+
+	return summary, nil
+}
+
+func summarize(reports []*happydns.TLSReport) *happydns.TLSReportSummary {
+	summary := &happydns.TLSReportSummary{
+		Reports:  len(reports),
+		Failures: []*happydns.TLSFailureStats{},
+	}
+
+	byType := map[string]*happydns.TLSFailureStats{}
+	for _, report := range reports {
+		for _, p := range report.Policies {
+			summary.SuccessfulSessions += p.SuccessfulSessions
+			summary.FailedSessions += p.FailedSessions
+
+			for _, f := range p.Failures {
+				stats, ok := byType[f.ResultType]
+				if !ok {
+					stats = &happydns.TLSFailureStats{
+						ResultType: f.ResultType,
+						MXHosts:    []string{},
+					}
+					byType[f.ResultType] = stats
+					summary.Failures = append(summary.Failures, stats)
+				}
+
+				stats.Sessions += f.FailedSessions
+				if f.ReceivingMXHostname != "" && !slices.Contains(stats.MXHosts, f.ReceivingMXHostname) {
+					stats.MXHosts = append(stats.MXHosts, f.ReceivingMXHostname)
+				}
+				if !slices.Contains(stats.Reporters, report.OrgName) {
+					stats.Reporters = append(stats.Reporters, report.OrgName)
+				}
+			}
+		}
+	}
+
+	sort.Slice(summary.Failures, func(i, j int) bool {
+		return summary.Failures[i].Sessions > summary.Failures[j].Sessions
+	})
+
+	return summary
+}
+
+// GetSettings returns the report settings of domain. The first call
+// generates the ingestion token, so that the report URI is stable.
+func (s *Service) GetSettings(domain *happydns.Domain) (*happydns.TLSReportSettings, error) {
+	settings, err := s.store.GetTLSReportSettings(domain.Id)
+	if errors.Is(err, happydns.ErrTLSReportSettingsNotFound) {
+		settings = &happydns.TLSReportSettings{
+			DomainId:       domain.Id,
+			NotifySpikes:   true,
+			SpikeThreshold: defaultSpikeThreshold,
+		}
+		return s.resetToken(settings)
+	} else if err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to GetTLSReportSettings(%s): %w", domain.Id.String(), err),
+			UserMessage: "Sorry, we are currently unable to retrieve the report settings. Please retry later.",
+		}
+	}
+
+	settings.ReportURI = s.reportURI(settings)
+	settings.ReportAddress = s.reportAddress(settings)
+	return settings, nil
+}
+
+// SetSettings validates and stores the report settings of domain. The
+// ingestion token is kept.
+func (s *Service) SetSettings(domain *happydns.Domain, settings *happydns.TLSReportSettings) (*happydns.TLSReportSettings, error) {
+	if settings.SpikeThreshold <= 0 || settings.SpikeThreshold > 100 {
+		return nil, happydns.ValidationError{Msg: "the spike threshold should be a percentage of the sessions, between 0 and 100"}
+	}
+
+	current, err := s.GetSettings(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	settings.DomainId = domain.Id
+	settings.IngestToken = current.IngestToken
+
+	return s.putSettings(settings)
+}
+
+// ResetIngestToken generates a new ingestion token for domain: reports
+// posted to the previous report URI are refused from now on.
+func (s *Service) ResetIngestToken(domain *happydns.Domain) (*happydns.TLSReportSettings, error) {
+	settings, err := s.GetSettings(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.resetToken(settings)
+}
+
+func (s *Service) resetToken(settings *happydns.TLSReportSettings) (*happydns.TLSReportSettings, error) {
+	token, err := happydns.NewRandomIdentifier()
+	if err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to generate an ingestion token: %w", err),
+			UserMessage: "Sorry, we are currently unable to generate the report address. Please retry later.",
+		}
+	}
+
+	settings.IngestToken = token.String()
+	return s.putSettings(settings)
+}
+
+func (s *Service) putSettings(settings *happydns.TLSReportSettings) (*happydns.TLSReportSettings, error) {
+	settings.ReportURI = ""
+	settings.ReportAddress = ""
+	if err := s.store.PutTLSReportSettings(settings); err != nil {
+		return nil, happydns.InternalError{
+			Err:         fmt.Errorf("unable to PutTLSReportSettings(%s): %w", settings.DomainId.String(), err),
+			UserMessage: "Sorry, we are currently unable to save the report settings. Please retry later.",
+		}
+	}
+
+	settings.ReportURI = s.reportURI(settings)
+	settings.ReportAddress = s.reportAddress(settings)
+	return settings, nil
+}
+
+// reportURI is the address the senders post the reports of the domain to.
+func (s *Service) reportURI(settings *happydns.TLSReportSettings) string {
+	if settings.IngestToken == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/api/reports/tlsrpt/%s/%s", s.baseURL, settings.DomainId.String(), settings.IngestToken)
+}
+
+// reportAddress is the address the senders can mail the reports of the
+// domain to, a subaddress of the mailbox receiving the reports.
+func (s *Service) reportAddress(settings *happydns.TLSReportSettings) string {
+	if settings.IngestToken == "" {
+		return ""
+	}
+	return mailbox.Subaddress(s.address, settings.DomainId.String()+"."+settings.IngestToken)
+}
+
+// Expired tells whether the period of report ended before the retention
+// horizon.
+func (s *Service) Expired(report *happydns.TLSReport) bool {
+	return report.End.Before(s.now().Add(-s.retention))
+}
+
+// isSubdomain tells whether name is domain or one of its subdomains.
+func isSubdomain(name, domain string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	return name == domain || strings.HasSuffix(name, "."+domain)
+}
@@ -0,0 +1,111 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tlsreport
+
+import (
+	"context"
+	"testing"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+type memoryStore struct {
+	reports  []*happydns.TLSReport
+	settings map[string]*happydns.TLSReportSettings
+}
+
+func (s *memoryStore) ListTLSReports(domainId happydns.Identifier) (ret []*happydns.TLSReport, err error) {
+	for _, r := range s.reports {
+		if r.DomainId.Equals(domainId) {
+			ret = append(ret, r)
+		}
+	}
+	return
+}
+
+func (s *memoryStore) CreateTLSReport(report *happydns.TLSReport) error {
+	s.reports = append(s.reports, report)
+	return nil
+}
+
+func (s *memoryStore) DeleteTLSReport(report *happydns.TLSReport) error {
+	return nil
+}
+
+func (s *memoryStore) GetTLSReportSettings(domainId happydns.Identifier) (*happydns.TLSReportSettings, error) {
+	settings, ok := s.settings[domainId.String()]
+	if !ok {
+		return nil, happydns.ErrTLSReportSettingsNotFound
+	}
+	saved := *settings
+	return &saved, nil
+}
+
+func (s *memoryStore) PutTLSReportSettings(settings *happydns.TLSReportSettings) error {
+	saved := *settings
+	s.settings[settings.DomainId.String()] = &saved
+	return nil
+}
+
+type memoryDomains []*happydns.Domain
+
+func (d memoryDomains) GetDomain(domainId happydns.Identifier) (*happydns.Domain, error) {
+	for _, domain := range d {
+		if domain.Id.Equals(domainId) {
+			return domain, nil
+		}
+	}
+	return nil, happydns.ErrDomainNotFound
+}
+
+func TestIngestDeliversToReportAddressOnly(t *testing.T) {
+	// Two accounts manage company-y.example; only the owner publishes the
+	// report address of its domain.
+	owner := &happydns.Domain{Id: happydns.Identifier("owner-domain"), Owner: happydns.Identifier("owner"), DomainName: "company-y.example."}
+	other := &happydns.Domain{Id: happydns.Identifier("other-domain"), Owner: happydns.Identifier("other"), DomainName: "company-y.example."}
+
+	store := &memoryStore{settings: map[string]*happydns.TLSReportSettings{}}
+	s := NewService(store, memoryDomains{owner, other}, nil, "https://happydomain.example", "reports@happydomain.example", 0)
+
+	ownerSettings, err := s.GetSettings(owner)
+	if err != nil {
+		t.Fatalf("GetSettings() error = %v", err)
+	}
+	if _, err := s.GetSettings(other); err != nil {
+		t.Fatalf("GetSettings() error = %v", err)
+	}
+
+	if _, err := s.Ingest(context.Background(), []string{"reports@happydomain.example"}, []byte(sampleReport)); err == nil {
+		t.Errorf("Ingest() delivered a report sent to no domain's report address")
+	}
+
+	stored, err := s.Ingest(context.Background(), []string{ownerSettings.ReportAddress}, []byte(sampleReport))
+	if err != nil {
+		t.Fatalf("Ingest() error = %v", err)
+	}
+	if len(stored) != 1 || !stored[0].DomainId.Equals(owner.Id) {
+		t.Errorf("Ingest() stored %v, want the report for the domain of its address only", stored)
+	}
+	if reports, _ := store.ListTLSReports(other.Id); len(reports) != 0 {
+		t.Errorf("the other account received %d report(s) sent to the owner", len(reports))
+	}
+}
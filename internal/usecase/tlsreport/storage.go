@@ -0,0 +1,54 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tlsreport
+
+import (
+	"git.happydns.org/happyDomain/model"
+)
+
+type TLSReportStorage interface {
+	// ListTLSReports retrieves the reports received for the given Domain.
+	ListTLSReports(domainId happydns.Identifier) ([]*happydns.TLSReport, error)
+
+	// CreateTLSReport stores a new report, assigning its identifier.
+	CreateTLSReport(report *happydns.TLSReport) error
+
+	// DeleteTLSReport removes the given report.
+	DeleteTLSReport(report *happydns.TLSReport) error
+
+	// GetTLSReportSettings retrieves the report settings of the given Domain.
+	GetTLSReportSettings(domainId happydns.Identifier) (*happydns.TLSReportSettings, error)
+
+	// PutTLSReportSettings creates or replaces the report settings of its Domain.
+	PutTLSReportSettings(settings *happydns.TLSReportSettings) error
+}
+
+// DomainFinder retrieves Domains of any user.
+type DomainFinder interface {
+	GetDomain(domainId happydns.Identifier) (*happydns.Domain, error)
+}
+
+// EventNotifier raises notifications for events happening outside of the
+// checker engine.
+type EventNotifier interface {
+	NotifyEvent(checkerID string, target happydns.CheckTarget, states []happydns.CheckState)
+}
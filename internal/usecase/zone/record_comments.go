@@ -0,0 +1,80 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package zone
+
+import (
+	"git.happydns.org/happyDomain/internal/usecase/service"
+	"git.happydns.org/happyDomain/model"
+)
+
+// Comments returns the comment to store along each record of the zone: the
+// marker of the records happyDomain manages, followed by the comment of the
+// service the record comes from.
+func (uc *ListRecordsUsecase) Comments(domain *happydns.Domain, zone *happydns.Zone) (happydns.RecordComments, error) {
+	comments := happydns.RecordComments{}
+
+	for _, services := range zone.Services {
+		for _, svc := range services {
+			records, err := uc.serviceListRecordsUC.List(svc, domain.DomainName, zone.DefaultTTL)
+			if err != nil {
+				return nil, err
+			}
+
+			comment := happydns.FormatRecordComment(svc.UserComment)
+			for _, rr := range records {
+				comments[happydns.RecordCommentKey(rr)] = comment
+			}
+		}
+	}
+
+	return comments, nil
+}
+
+// RestoreComments gives back to the services lacking one the comment stored
+// along their records at the provider. Comments written at the provider by
+// someone else are taken as well.
+func RestoreComments(services map[happydns.Subdomain][]*happydns.Service, origin string, defaultTTL uint32, comments happydns.RecordComments) {
+	if len(comments) == 0 {
+		return
+	}
+
+	listRecords := service.NewListRecordsUsecase()
+	for _, svcs := range services {
+		for _, svc := range svcs {
+			if svc.UserComment != "" {
+				continue
+			}
+
+			records, err := listRecords.List(svc, origin, defaultTTL)
+			if err != nil {
+				continue
+			}
+
+			for _, rr := range records {
+				if comment, _ := happydns.ParseRecordComment(comments[happydns.RecordCommentKey(rr)]); comment != "" {
+					svc.UserComment = comment
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package zone
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/internal/usecase/service"
+	"git.happydns.org/happyDomain/model"
+)
+
+func newCommentedService(comment string, ip string) *happydns.Service {
+	return &happydns.Service{
+		ServiceMeta: happydns.ServiceMeta{
+			Type:        "svcs.testSimpleService",
+			Domain:      "www",
+			UserComment: comment,
+		},
+		Service: &testSimpleService{Record: &dns.A{
+			Hdr: dns.RR_Header{Rrtype: dns.TypeA, Class: dns.ClassINET},
+			A:   net.ParseIP(ip),
+		}},
+	}
+}
+
+func TestComments_RoundTrip(t *testing.T) {
+	domain := &happydns.Domain{DomainName: "example.com."}
+	published := &happydns.Zone{
+		ZoneMeta: happydns.ZoneMeta{DefaultTTL: 3600},
+		Services: map[happydns.Subdomain][]*happydns.Service{
+			"www": {newCommentedService("web front", "192.0.2.1"), newCommentedService("", "192.0.2.2")},
+		},
+	}
+
+	comments, err := NewListRecordsUsecase(service.NewListRecordsUsecase()).Comments(domain, published)
+	if err != nil {
+		t.Fatalf("Comments() = %v", err)
+	}
+	if len(comments) != 2 {
+		t.Fatalf("Comments() = %v; want a comment for each record", comments)
+	}
+
+	imported := map[happydns.Subdomain][]*happydns.Service{
+		"www": {newCommentedService("", "192.0.2.1"), newCommentedService("", "192.0.2.2"), newCommentedService("kept", "192.0.2.1")},
+	}
+	RestoreComments(imported, domain.DomainName, 3600, comments)
+
+	for i, want := range []string{"web front", "", "kept"} {
+		if got := imported["www"][i].UserComment; got != want {
+			t.Errorf("service %d: UserComment = %q; want %q", i, got, want)
+		}
+	}
+}
@@ -44,14 +44,21 @@ func NewUpdateServiceUsecase(store serviceUC.ZoneUpdaterStorage) *UpdateServiceU
 
 // Update replaces the service identified by serviceid under subdomain in zone
 // with newservice, updates the zone's LastModified timestamp, and persists the
-// change.  A validation error is returned when the service cannot be found;
-// an internal error is returned when the storage update fails.
+// change.  A validation error is returned when the service cannot be found or
+// its fields are invalid; an internal error is returned when the storage
+// update fails.
 func (uc *UpdateServiceUsecase) Update(
 	zone *happydns.Zone,
 	subdomain happydns.Subdomain,
 	serviceid happydns.Identifier,
 	newservice *happydns.Service,
 ) error {
+	if newservice.Service != nil {
+		if err := serviceUC.ValidateFields(newservice.Service); err != nil {
+			return err
+		}
+	}
+
 	err := zone.EraseService(subdomain, serviceid, newservice)
 	if err != nil {
 		return happydns.ValidationError{Msg: fmt.Sprintf("unable to delete service: %s", err.Error())}
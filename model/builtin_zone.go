@@ -0,0 +1,48 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package happydns
+
+// BuiltinZone is a zone kept by the built-in provider, which stores the
+// published records in happyDomain's own database instead of sending them to
+// a third party.
+type BuiltinZone struct {
+	// ProviderId is the identifier of the built-in provider holding the zone:
+	// two providers of the same user never share their zones.
+	ProviderId Identifier `json:"id_provider"`
+
+	// Name is the lower-cased, fully qualified name of the zone.
+	Name string `json:"name"`
+
+	// Records holds the published records, in presentation format.
+	Records []string `json:"records"`
+
+	// Comments holds the comment stored along the records, indexed by
+	// RecordCommentKey.
+	Comments RecordComments `json:"comments,omitempty"`
+}
+
+// InstanceAwareProviderBody is implemented by the provider bodies whose
+// actuator depends on the provider they belong to, not only on its settings.
+// Provider.InstantiateProvider prefers it over ProviderBody.InstantiateProvider.
+type InstanceAwareProviderBody interface {
+	InstantiateProviderFor(meta *ProviderMeta) (ProviderActuator, error)
+}
@@ -60,6 +60,27 @@ type Options struct {
 	// DisableProviders should disallow all actions on provider (add/edit/delete) through public API.
 	DisableProviders bool
 
+	// ProviderRateLimit is the number of calls per second made to a provider
+	// account, shared by every provider using the same credentials (0
+	// disables the limit).
+	ProviderRateLimit float64
+
+	// ProviderRateBurst is the number of calls allowed at once above
+	// ProviderRateLimit.
+	ProviderRateBurst int
+
+	// ProviderRetries is how many times a read throttled by the provider, or
+	// timing out, is attempted again.
+	ProviderRetries int
+
+	// ProviderMaxWait bounds how long a call may wait for the rate limit of
+	// its provider before failing.
+	ProviderMaxWait time.Duration
+
+	// ProviderZoneCacheTTL is how long the records fetched from a provider
+	// are reused to compute the diffs of a domain. 0 disables the cache.
+	ProviderZoneCacheTTL time.Duration
+
 	// DisableRegistration forbids all new registration using the public form/API.
 	DisableRegistration bool
 
@@ -78,9 +99,25 @@ type Options struct {
 	// it falls back to ExternalURL.Host.
 	MailAutoconfigHost string
 
+	// MailProfileSigningCert and MailProfileSigningKey are the paths to the
+	// PEM certificate (with its chain) and private key signing the Apple
+	// configuration profiles. Profiles are served unsigned when empty.
+	MailProfileSigningCert string
+	MailProfileSigningKey  string
+
 	// JWTSecretKey stores the private key to sign and verify JWT tokens.
 	JWTSecretKey []byte
 
+	// SecretKey is the master key sealing the secrets happyDomain keeps at
+	// rest on behalf of its users, such as the DKIM private keys it
+	// generates. When empty, the features needing it are unavailable.
+	SecretKey []byte
+
+	// PreviousSecretKeys are the master keys SecretKey replaced. They are
+	// only used to open what they sealed, until it is sealed again under
+	// SecretKey.
+	PreviousSecretKeys [][]byte
+
 	// JWTSigningMethod is the signing method to check token signature.
 	JWTSigningMethod string
 
@@ -145,6 +182,40 @@ type Options struct {
 	MailSMTPPassword     string
 	MailSMTPTLSSNoVerify bool
 
+	// ReportsIngestToken authenticates the mail servers and scripts posting
+	// aggregate reports to the public ingestion endpoint, as a bearer token.
+	// When empty, the endpoint is disabled; reports can still be uploaded by
+	// the domain owner or fetched from ReportsIMAPAddress.
+	ReportsIngestToken string
+
+	// ReportsAddress is the email address of the mailbox receiving the rua=
+	// reports. Each domain gets its own subaddress of it to publish, and the
+	// reports received by mail or through the ingestion endpoint are only
+	// delivered to the domain whose subaddress they were sent to.
+	ReportsAddress string
+
+	// ReportsRetentionDays is how many days the received DMARC and TLS
+	// aggregate reports are kept.
+	ReportsRetentionDays int
+
+	// ReportsIMAPAddress is the host:port of the IMAP server holding the
+	// mailbox that receives the rua= reports. When empty, no mailbox is
+	// polled.
+	ReportsIMAPAddress string
+
+	// ReportsIMAPTLS connects to ReportsIMAPAddress over TLS. It can only be
+	// disabled for a server listening on the loopback interface.
+	ReportsIMAPTLS bool
+
+	ReportsIMAPUsername string
+	ReportsIMAPPassword string
+
+	// ReportsIMAPMailbox is the folder polled for reports.
+	ReportsIMAPMailbox string
+
+	// ReportsIMAPInterval is how often the mailbox is polled.
+	ReportsIMAPInterval time.Duration
+
 	OIDCClients []OIDCSettings
 
 	// CheckerMaxConcurrency is the maximum number of checker jobs that can
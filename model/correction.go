@@ -40,4 +40,26 @@ type Correction struct {
 	Kind       CorrectionKind `json:"kind" binding:"required"`
 	OldRecords []Record       `json:"-"`
 	NewRecords []Record       `json:"-"`
+
+	// DerivedFrom tells, for records happyDomain computes on its own rather
+	// than publishing them as the user wrote them, which record they derive
+	// from (eg. "ALIAS cdn.example.net." for a flattened ALIAS).
+	DerivedFrom string `json:"derived_from,omitempty"`
+
+	// Warning tells what the provider is unable to publish as the user
+	// wrote it. When Unpublishable is set, the correction is left out of
+	// publication; otherwise it is published adjusted to the provider.
+	Warning       string `json:"warning,omitempty"`
+	Unpublishable bool   `json:"unpublishable,omitempty"`
+
+	// Trace, when the provider adapter supplies it, is filled by F with what
+	// it sent to the provider and what it received back.
+	Trace *CorrectionTrace `json:"-"`
+}
+
+// CorrectionTrace holds the request a correction made to the provider and the
+// response it got, in any form that marshals to JSON.
+type CorrectionTrace struct {
+	Request  any
+	Response any
 }
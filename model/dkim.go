@@ -0,0 +1,176 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package happydns
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// DKIMAlgorithmEd25519 generates Ed25519 keys (RFC 8463).
+	DKIMAlgorithmEd25519 = "ed25519"
+
+	// DKIMAlgorithmRSA generates RSA keys (RFC 6376).
+	DKIMAlgorithmRSA = "rsa"
+)
+
+// DKIMKeyState tells where a generated key stands in its rotation.
+type DKIMKeyState string
+
+const (
+	// DKIMKeyPending is a key whose selector is not published yet: the MTA
+	// gets it, and the former key is retired, once it is.
+	DKIMKeyPending DKIMKeyState = "pending"
+
+	// DKIMKeyActive is the key the MTA should sign with.
+	DKIMKeyActive DKIMKeyState = "active"
+
+	// DKIMKeyRetiring is a former key: the MTA no longer signs with it, but
+	// its selector stays published until the grace period is over, so that
+	// messages still in transit can be verified.
+	DKIMKeyRetiring DKIMKeyState = "retiring"
+)
+
+// DKIMKey is a DKIM key pair generated by happyDomain for a Domain.
+type DKIMKey struct {
+	// Id is the key's identifier in the database.
+	Id Identifier `json:"id" swaggertype:"string" readonly:"true"`
+
+	// DomainId is the identifier of the Domain the key signs for.
+	DomainId Identifier `json:"id_domain" swaggertype:"string" readonly:"true"`
+
+	// Subdomain is the signing domain (d= tag), relative to the Domain.
+	Subdomain Subdomain `json:"subdomain"`
+
+	// Selector is the s= tag under which the public key is published.
+	Selector string `json:"selector" readonly:"true"`
+
+	// Algorithm is either DKIMAlgorithmEd25519 or DKIMAlgorithmRSA.
+	Algorithm string `json:"algorithm" readonly:"true"`
+
+	// Bits is the RSA modulus size; zero for Ed25519.
+	Bits int `json:"bits,omitempty" readonly:"true"`
+
+	// PublicKey is the DER-encoded public key, as published in the p= tag.
+	PublicKey []byte `json:"public_key" readonly:"true"`
+
+	// SealedPrivateKey is the PKCS#8 private key, sealed with the instance
+	// secret key. It is never sent to clients: see Redacted.
+	SealedPrivateKey string `json:"sealed_private_key,omitempty" swaggerignore:"true"`
+
+	// State tells whether the key awaits publication, is the one in use or
+	// is being retired.
+	State DKIMKeyState `json:"state" readonly:"true"`
+
+	// CreatedAt is when the key has been generated.
+	CreatedAt time.Time `json:"created_at" format:"date-time" readonly:"true"`
+
+	// RetiredAt is when the key has been superseded by a newer one.
+	RetiredAt *time.Time `json:"retired_at,omitempty" format:"date-time" readonly:"true"`
+}
+
+// Redacted returns a copy of the key without its sealed private part, fit to
+// be sent to a client.
+func (k *DKIMKey) Redacted() *DKIMKey {
+	c := *k
+	c.SealedPrivateKey = ""
+	return &c
+}
+
+// DKIMPolicy describes how happyDomain generates and rotates the DKIM keys of
+// a Domain.
+type DKIMPolicy struct {
+	// DomainId is the identifier of the Domain the policy applies to.
+	DomainId Identifier `json:"id_domain" swaggertype:"string" readonly:"true"`
+
+	// Subdomain is the signing domain, relative to the Domain.
+	Subdomain Subdomain `json:"subdomain"`
+
+	// Algorithm is the algorithm of the keys to generate.
+	Algorithm string `json:"algorithm"`
+
+	// Bits is the RSA modulus size; ignored for Ed25519.
+	Bits int `json:"bits,omitempty"`
+
+	// SelectorPrefix starts every generated selector, which is then
+	// followed by the generation date.
+	SelectorPrefix string `json:"selector_prefix"`
+
+	// RotationDays is the number of days after which a new key replaces the
+	// active one. 0 disables automatic rotation.
+	RotationDays int `json:"rotation_days"`
+
+	// GraceDays is the number of days a retired key stays published.
+	GraceDays int `json:"grace_days"`
+
+	// AutoPublish lets the rotation publish the selector changes to the
+	// provider on its own. When false, they are left in the current zone for
+	// the user to review.
+	AutoPublish bool `json:"auto_publish"`
+
+	// WebhookURL, when set, receives the new private key whenever one is
+	// generated, so that the MTA can pick it up.
+	WebhookURL string `json:"webhook_url,omitempty"`
+
+	// WebhookSecret signs the webhook requests (HMAC-SHA256 of the body).
+	WebhookSecret string `json:"webhook_secret,omitempty"`
+
+	// HasWebhookSecret is set only by Redacted: never stored nor accepted.
+	HasWebhookSecret bool `json:"has_webhook_secret,omitempty" readonly:"true"`
+
+	// LastRotation is when the active key has been generated.
+	LastRotation *time.Time `json:"last_rotation,omitempty" format:"date-time" readonly:"true"`
+}
+
+// Redacted returns a copy of the policy without its webhook secret.
+func (p *DKIMPolicy) Redacted() *DKIMPolicy {
+	c := *p
+	c.HasWebhookSecret = c.WebhookSecret != ""
+	c.WebhookSecret = ""
+	return &c
+}
+
+// DKIMPrivateKey is the exported form of a generated private key.
+type DKIMPrivateKey struct {
+	Domain    string `json:"domain"`
+	Selector  string `json:"selector"`
+	Algorithm string `json:"algorithm"`
+
+	// PEM is the PKCS#8 private key, PEM-encoded.
+	PEM string `json:"private_key"`
+}
+
+type DKIMUsecase interface {
+	// GetPolicy returns the rotation policy of the domain.
+	GetPolicy(*Domain) (*DKIMPolicy, error)
+	// SetPolicy creates or replaces the rotation policy of the domain.
+	SetPolicy(*Domain, *DKIMPolicy) (*DKIMPolicy, error)
+	// ListKeys lists the keys generated for the domain, newest first.
+	ListKeys(*Domain) ([]*DKIMKey, error)
+	// GenerateKey creates a new pending key following the domain policy and
+	// adds its selector to the current zone. The key becomes active, retiring
+	// the previous active key, once its selector is published.
+	GenerateKey(context.Context, *User, *Domain) (*DKIMKey, error)
+	// ExportPrivateKey unseals the private part of a key.
+	ExportPrivateKey(*Domain, Identifier) (*DKIMPrivateKey, error)
+}
@@ -0,0 +1,154 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package happydns
+
+import (
+	"context"
+	"time"
+)
+
+// DMARCReport is an RFC 7489 aggregate report received for a Domain.
+type DMARCReport struct {
+	// Id is the report's identifier in the database.
+	Id Identifier `json:"id" swaggertype:"string" readonly:"true"`
+
+	// DomainId is the identifier of the Domain the report is about.
+	DomainId Identifier `json:"id_domain" swaggertype:"string" readonly:"true"`
+
+	// OrgName is the name of the organization that sent the report.
+	OrgName string `json:"org_name"`
+
+	// Email is the contact address of the reporting organization.
+	Email string `json:"email,omitempty"`
+
+	// ReportID is the identifier given to the report by its sender.
+	ReportID string `json:"report_id"`
+
+	// Begin and End delimit the period covered by the report.
+	Begin time.Time `json:"begin" format:"date-time"`
+	End   time.Time `json:"end" format:"date-time"`
+
+	// ReceivedAt is when happyDomain ingested the report.
+	ReceivedAt time.Time `json:"received_at" format:"date-time" readonly:"true"`
+
+	// PolicyDomain is the domain whose published policy has been applied.
+	PolicyDomain string `json:"policy_domain"`
+
+	// Policy is the p= tag of the policy, as seen by the reporter.
+	Policy string `json:"policy,omitempty"`
+
+	// Records holds one row per source IP and evaluation outcome.
+	Records []DMARCReportRecord `json:"records"`
+}
+
+// DMARCReportRecord is a row of an aggregate report.
+type DMARCReportRecord struct {
+	SourceIP    string `json:"source_ip"`
+	Count       int    `json:"count"`
+	Disposition string `json:"disposition,omitempty"`
+	HeaderFrom  string `json:"header_from,omitempty"`
+
+	// DKIMAligned and SPFAligned report the policy evaluation: whether an
+	// authenticated identifier aligned with the header From domain.
+	DKIMAligned bool `json:"dkim_aligned"`
+	SPFAligned  bool `json:"spf_aligned"`
+
+	// DKIMDomains and SPFDomain are the authenticated identifiers, before
+	// alignment.
+	DKIMDomains []string `json:"dkim_domains,omitempty"`
+	SPFDomain   string   `json:"spf_domain,omitempty"`
+}
+
+// DMARCSourceStats aggregates the messages sent from one source IP.
+type DMARCSourceStats struct {
+	SourceIP    string `json:"source_ip"`
+	Messages    int    `json:"messages"`
+	DKIMAligned int    `json:"dkim_aligned"`
+	SPFAligned  int    `json:"spf_aligned"`
+
+	// Failed counts the messages that passed neither alignment.
+	Failed int `json:"failed"`
+
+	// Known is true when the source is listed in the domain settings.
+	Known bool `json:"known"`
+
+	// Reporters lists the organizations that saw this source.
+	Reporters []string `json:"reporters"`
+}
+
+// DMARCSummary aggregates the reports received for a Domain over a period.
+type DMARCSummary struct {
+	DomainId    Identifier          `json:"id_domain" swaggertype:"string"`
+	From        time.Time           `json:"from" format:"date-time"`
+	To          time.Time           `json:"to" format:"date-time"`
+	Reports     int                 `json:"reports"`
+	Messages    int                 `json:"messages"`
+	DKIMAligned int                 `json:"dkim_aligned"`
+	SPFAligned  int                 `json:"spf_aligned"`
+	Failed      int                 `json:"failed"`
+	Sources     []*DMARCSourceStats `json:"sources"`
+}
+
+// DMARCReportSettings holds the per-Domain preferences for the reports.
+type DMARCReportSettings struct {
+	// DomainId is the identifier of the Domain the settings apply to.
+	DomainId Identifier `json:"id_domain" swaggertype:"string" readonly:"true"`
+
+	// IngestToken tells the reports sent to ReportAddress apart from those
+	// sent to the address of another domain.
+	IngestToken string `json:"ingest_token,omitempty" readonly:"true"`
+
+	// ReportAddress is the rua= address to publish in the DMARC record of
+	// the domain to have the reports delivered to happyDomain.
+	ReportAddress string `json:"report_address,omitempty" readonly:"true"`
+
+	// KnownSources lists the IP addresses or CIDR blocks of the legitimate
+	// senders: no notification is raised when they show up.
+	KnownSources []string `json:"known_sources"`
+
+	// NotifyUnknownSenders raises a notification when a report shows a
+	// source not seen before and not listed in KnownSources.
+	NotifyUnknownSenders bool `json:"notify_unknown_senders"`
+
+	// NotifyFailures raises a notification when a report shows messages
+	// passing neither DKIM nor SPF alignment.
+	NotifyFailures bool `json:"notify_failures"`
+}
+
+type DMARCReportUsecase interface {
+	// IngestForDomain parses a raw report (XML, gzip or zip) uploaded for
+	// the given domain.
+	IngestForDomain(context.Context, *Domain, []byte) (*DMARCReport, error)
+	// Ingest parses a raw report sent to the given recipients, and stores
+	// it for the domains whose report address is among them.
+	Ingest(ctx context.Context, recipients []string, raw []byte) ([]*DMARCReport, error)
+	// ListReports lists the reports received for the domain since the
+	// given date, newest first.
+	ListReports(*Domain, time.Time) ([]*DMARCReport, error)
+	// Summary aggregates the reports received for the domain since the
+	// given date.
+	Summary(*Domain, time.Time) (*DMARCSummary, error)
+	// GetSettings returns the report settings of the domain.
+	GetSettings(*Domain) (*DMARCReportSettings, error)
+	// SetSettings replaces the report settings of the domain.
+	SetSettings(*Domain, *DMARCReportSettings) (*DMARCReportSettings, error)
+}
@@ -25,6 +25,7 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/miekg/dns"
 )
@@ -51,6 +52,10 @@ type Domain struct {
 	// Domain.
 	ProviderId Identifier `json:"id_provider" swaggertype:"string" binding:"required"`
 
+	// RegistrarId is the identifier of the Provider acting as registrar of
+	// the domain, when it is not the one hosting its zone.
+	RegistrarId Identifier `json:"id_registrar,omitempty" swaggertype:"string"`
+
 	// DomainName is the FQDN of the managed Domain.
 	DomainName string `json:"domain" binding:"required"`
 
@@ -60,12 +65,49 @@ type Domain struct {
 	// ZoneHistory are the identifiers to the Zone attached to the current
 	// Domain.
 	ZoneHistory []Identifier `json:"zone_history" swaggertype:"array,string" binding:"required" readonly:"true"`
+
+	// SPFFlattening tells whether happyDomain publishes the SPF records of
+	// the Domain flattened, and how it follows the changes of their includes.
+	SPFFlattening SPFFlatteningMode `json:"spf_flattening,omitempty" enums:",auto,review"`
+
+	// MissingFromProvider is when the zone was found no longer hosted by the
+	// Provider, for the providers whose zones are kept in sync.
+	MissingFromProvider *time.Time `json:"missing_from_provider,omitempty" format:"date-time" readonly:"true"`
+}
+
+// SPFFlatteningMode is the way happyDomain publishes the SPF records of a
+// Domain.
+type SPFFlatteningMode string
+
+const (
+	// SPFFlatteningOff publishes the SPF records as written.
+	SPFFlatteningOff SPFFlatteningMode = ""
+
+	// SPFFlatteningAuto publishes the SPF records flattened, and republishes
+	// them on its own when the includes they refer to change.
+	SPFFlatteningAuto SPFFlatteningMode = "auto"
+
+	// SPFFlatteningReview publishes the SPF records flattened, but leaves the
+	// changes of their includes as pending changes for the user to review.
+	SPFFlatteningReview SPFFlatteningMode = "review"
+)
+
+// Valid tells whether the mode is one happyDomain knows.
+func (m SPFFlatteningMode) Valid() bool {
+	return m == SPFFlatteningOff || m == SPFFlatteningAuto || m == SPFFlatteningReview
 }
 
 // DomainUpdateInput is used for swagger documentation as Domain update.
 type DomainUpdateInput struct {
 	// Group is a hint string aims to group domains.
 	Group string `json:"group,omitempty"`
+
+	// SPFFlattening changes the way the SPF records are published, when set.
+	SPFFlattening *SPFFlatteningMode `json:"spf_flattening,omitempty" enums:",auto,review"`
+
+	// RegistrarId changes the Provider acting as registrar of the domain,
+	// when set. An empty identifier falls back to the one hosting the zone.
+	RegistrarId *Identifier `json:"id_registrar,omitempty" swaggertype:"string"`
 }
 
 func NewDomain(user *User, name string, providerID Identifier) (*Domain, error) {
@@ -22,8 +22,8 @@
 package happydns
 
 // EmailAutoconfigUsecase serves the public mail-client auto-configuration
-// endpoints (Mozilla Autoconfig + Microsoft Autodiscover) and the Caddy
-// on-demand TLS validation hook.
+// endpoints (Mozilla Autoconfig, Microsoft Autodiscover and Apple
+// configuration profiles) and the Caddy on-demand TLS validation hook.
 //
 // All methods take fully-qualified domain names. The usecase looks up the
 // owning Domain in storage, finds the latest Zone, and reads the
@@ -32,7 +32,10 @@ type EmailAutoconfigUsecase interface {
 	// IsManaged returns true if the given FQDN is hosted by happyDomain
 	// for the email auto-configuration purpose. It strips an
 	// "autoconfig." or "autodiscover." prefix and checks that the parent
-	// domain has a configured EmailAutoConfig service.
+	// domain has a configured EmailAutoConfig service, an "mta-sts."
+	// prefix and checks that the parent domain has a hosted MTA-STS policy,
+	// or an "openpgpkey." prefix and checks that the parent domain
+	// publishes OpenPGP keys.
 	IsManaged(fqdn string) (bool, error)
 
 	// MozillaConfig renders the Thunderbird-style XML for the given
@@ -42,4 +45,27 @@ type EmailAutoconfigUsecase interface {
 	// AutodiscoverConfig renders the Outlook-style XML for the given
 	// domain. emailAddress may be empty.
 	AutodiscoverConfig(domainFQDN, emailAddress string) ([]byte, error)
+
+	// AutodiscoverJSON renders the Autodiscover v2 JSON response pointing
+	// modern Outlook to the service speaking the given protocol. It
+	// returns a ValidationError for a protocol the domain doesn't offer.
+	AutodiscoverJSON(domainFQDN, protocol string) ([]byte, error)
+
+	// MobileConfig renders the Apple configuration profile (.mobileconfig)
+	// setting up the mail, calendar and contacts accounts of the given
+	// domain. emailAddress may be empty.
+	MobileConfig(domainFQDN, emailAddress string) ([]byte, error)
+
+	// MTASTSPolicy renders the MTA-STS policy file (RFC 8461) for the
+	// given domain, from its MTA-STS service.
+	MTASTSPolicy(domainFQDN string) ([]byte, error)
+
+	// WKDPolicy renders the policy file of the OpenPGP Web Key Directory
+	// of the given mail domain, which has to publish at least one key.
+	WKDPolicy(domainFQDN string) ([]byte, error)
+
+	// WKDKey returns the binary OpenPGP key of the address whose
+	// local-part has the given WKD hash, from the OpenPGP services of the
+	// given mail domain. localPart may be empty.
+	WKDKey(domainFQDN, hash, localPart string) ([]byte, error)
 }
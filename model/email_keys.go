@@ -0,0 +1,73 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package happydns
+
+// Kinds of the keys published for email addresses.
+const (
+	EmailKeyOpenPGP = "openpgp"
+	EmailKeySMIME   = "smime"
+)
+
+// EmailKeysInput holds the keys to publish for the addresses of a
+// subdomain.
+type EmailKeysInput struct {
+	// Keys are ASCII-armored OpenPGP public keys and/or PEM-encoded S/MIME
+	// certificates, concatenated.
+	Keys string `json:"keys" binding:"required"`
+}
+
+// EmailKey describes a key published for an address.
+type EmailKey struct {
+	// Email is the address the key is published for.
+	Email string `json:"email"`
+
+	// Kind is either "openpgp" (OPENPGPKEY record) or "smime" (SMIMEA
+	// record).
+	Kind string `json:"kind"`
+
+	// Owner is the owner name of the record, derived from the local-part
+	// of the address.
+	Owner string `json:"owner"`
+
+	// Replaced tells whether a key already published for the address has
+	// been replaced.
+	Replaced bool `json:"replaced,omitempty"`
+}
+
+// EmailKeysPublication is the outcome of a key upload.
+type EmailKeysPublication struct {
+	// Zone is the zone holding the new services.
+	Zone *Zone `json:"zone"`
+
+	// Published lists the keys added to the zone, one per address.
+	Published []*EmailKey `json:"published"`
+
+	// Skipped lists the addresses found in the keys that don't belong to
+	// the subdomain.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+type EmailKeysUsecase interface {
+	// PublishKeys adds an OpenPGP or S/MIME service to the subdomain for
+	// each of its addresses found in the uploaded keys.
+	PublishKeys(*User, *Domain, *Zone, Subdomain, *EmailKeysInput) (*EmailKeysPublication, error)
+}
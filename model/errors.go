@@ -28,9 +28,13 @@ import (
 
 var (
 	ErrAuthUserNotFound               = errors.New("auth user not found")
+	ErrBuiltinZoneNotFound            = errors.New("zone not found on the built-in provider")
 	ErrCheckPlanNotFound              = errors.New("check plan not found")
 	ErrCheckEvaluationNotFound        = errors.New("check evaluation not found")
 	ErrCheckerNotFound                = errors.New("checker not found")
+	ErrDKIMKeyNotFound                = errors.New("DKIM key not found")
+	ErrDKIMPolicyNotFound             = errors.New("DKIM policy not found")
+	ErrDMARCReportSettingsNotFound    = errors.New("DMARC report settings not found")
 	ErrDomainDoesNotExist             = errors.New("domain name doesn't exist")
 	ErrDomainNotFound                 = errors.New("domain not found")
 	ErrDomainLogNotFound              = errors.New("domain log not found")
@@ -41,6 +45,7 @@ var (
 	ErrProviderNotFound               = errors.New("provider not found")
 	ErrSessionNotFound                = errors.New("session not found")
 	ErrSnapshotNotFound               = errors.New("snapshot not found")
+	ErrTLSReportSettingsNotFound      = errors.New("TLS report settings not found")
 	ErrUserNotFound                   = errors.New("user not found")
 	ErrUserAlreadyExist               = errors.New("user already exists")
 	ErrZoneNotFound                   = errors.New("zone not found")
@@ -54,6 +54,10 @@ type DomainLog struct {
 
 	// Level reports the criticity level of the action logged.
 	Level int8 `json:"level" binding:"required" readonly:"true"`
+
+	// Migration is the state of the provider migration of the domain, as
+	// it stood after the step logged by this entry.
+	Migration *ProviderMigration `json:"migration,omitempty" readonly:"true"`
 }
 
 type DomainLogWithDomainId struct {
@@ -21,7 +21,10 @@
 
 package happydns
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type RemoteZoneImporterUsecase interface {
 	Import(context.Context, *User, *Domain) (*Zone, error)
@@ -29,8 +32,10 @@ type RemoteZoneImporterUsecase interface {
 
 type ZoneCorrectionApplierUsecase interface {
 	Apply(context.Context, *User, *Domain, *Zone, *ApplyZoneForm) (*Zone, error)
+	InvalidateProviderRecords(*Domain)
 	List(context.Context, *User, *Domain, *Zone) ([]*Correction, int, error)
 	Prepare(context.Context, *User, *Domain, *Zone, *PrepareZoneForm) (*PrepareZoneResponse, error)
+	ProviderRecordsFetchedAt(*Domain) *time.Time
 }
 
 type ZoneImporterUsecase interface {
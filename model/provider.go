@@ -52,6 +52,10 @@ type ProviderInfos struct {
 // RegisterProviderFunc abstract the registration of a Provider
 type RegisterProviderFunc func(ProviderCreatorFunc, ProviderInfos)
 
+// RegisterNamedProviderFunc abstract the registration of a Provider whose
+// body type does not give its name, as the generic bridges do.
+type RegisterNamedProviderFunc func(string, ProviderCreatorFunc, ProviderInfos)
+
 // ProviderCreatorFunc abstract the instanciation of a Provider
 type ProviderCreatorFunc func() ProviderBody
 
@@ -72,6 +76,14 @@ type ProviderMinimal struct {
 
 	// Comment is a string that helps user to distinguish the Provider.
 	Comment string `json:"_comment,omitempty"`
+
+	// Journal tells whether the corrections executed against the Provider are
+	// kept in its journal.
+	Journal bool `json:"_journal,omitempty"`
+
+	// SyncZones tells whether the zones created on the Provider are added as
+	// domains on their own, and the removed ones flagged.
+	SyncZones bool `json:"_sync_zones,omitempty"`
 }
 
 // ProviderMeta holds the metadata associated to a Provider.
@@ -87,6 +99,14 @@ type ProviderMeta struct {
 
 	// Comment is a string that helps user to distinguish the Provider.
 	Comment string `json:"_comment,omitempty"`
+
+	// Journal tells whether the corrections executed against the Provider are
+	// kept in its journal.
+	Journal bool `json:"_journal,omitempty"`
+
+	// SyncZones tells whether the zones created on the Provider are added as
+	// domains on their own, and the removed ones flagged.
+	SyncZones bool `json:"_sync_zones,omitempty"`
 }
 
 // ProviderMessage combined ProviderMeta + Provider in a parsable way
@@ -115,6 +135,10 @@ type Provider struct {
 }
 
 func (p *Provider) InstantiateProvider() (ProviderActuator, error) {
+	if body, ok := p.Provider.(InstanceAwareProviderBody); ok {
+		return body.InstantiateProviderFor(&p.ProviderMeta)
+	}
+
 	return p.Provider.InstantiateProvider()
 }
 
@@ -145,11 +169,13 @@ type ProviderUsecase interface {
 
 // AdminProviderUsecase exposes administrative provider operations that are
 // not scoped to a specific User. Admin callers can list every provider,
-// force-delete one by ID, and wipe the table.
+// force-delete one by ID, wipe the table, and rewrite every provider so that
+// its credentials are sealed under the current master key.
 type AdminProviderUsecase interface {
 	ListAllProviderMetas() ([]*ProviderMeta, error)
 	DeleteProviderByID(Identifier) error
 	ClearProviders() error
+	ReencryptProviders() (int, error)
 }
 
 type ProviderActuator interface {
@@ -0,0 +1,53 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package happydns
+
+import (
+	"slices"
+)
+
+// ProviderCapabilities describes what a provider type is able to publish,
+// so that records it would reject or silently drop are spotted before the
+// zone is sent to it. Zero limits mean no limit is known.
+type ProviderCapabilities struct {
+	// RRTypes lists the record types the provider handles. An empty list
+	// means the provider does not tell.
+	RRTypes []string `json:"rr_types,omitempty"`
+
+	// MaxTXTLength is the longest TXT content, all strings together, the
+	// provider accepts.
+	MaxTXTLength int `json:"max_txt_length,omitempty"`
+
+	// MinTTL and MaxTTL bound the TTL the provider accepts.
+	MinTTL uint32 `json:"min_ttl,omitempty"`
+	MaxTTL uint32 `json:"max_ttl,omitempty"`
+
+	// RecordComments tells whether the provider stores a comment along each
+	// record.
+	RecordComments bool `json:"record_comments,omitempty"`
+}
+
+// SupportsType tells whether the provider handles the given record type.
+// Types are all considered handled when the provider does not tell.
+func (pc *ProviderCapabilities) SupportsType(rrtype string) bool {
+	return len(pc.RRTypes) == 0 || slices.Contains(pc.RRTypes, rrtype)
+}
@@ -0,0 +1,62 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package happydns
+
+import "context"
+
+// ProviderDetection tells which provider hosts a domain, as guessed from the
+// name servers it is delegated to.
+type ProviderDetection struct {
+	// DomainName is the FQDN of the domain.
+	DomainName string `json:"domain"`
+
+	// Nameservers are the name servers the domain is delegated to.
+	Nameservers []string `json:"nameservers"`
+
+	// SOAMname is the primary name server given by the SOA of the domain.
+	SOAMname string `json:"soa_mname,omitempty"`
+
+	// Suggestions are the provider types serving zones from these name
+	// servers, the most likely first.
+	Suggestions []*ProviderSuggestion `json:"suggestions"`
+}
+
+// ProviderSuggestion is a provider type likely hosting a domain.
+type ProviderSuggestion struct {
+	// Type is the provider type.
+	Type string `json:"_srctype"`
+
+	// Name is the name displayed for the provider type.
+	Name string `json:"name"`
+
+	// HelpLink is the link to the documentation of the provider
+	// configuration, to help creating one.
+	HelpLink string `json:"helplink,omitempty"`
+
+	// Providers are the providers of this type the user already has.
+	Providers []*ProviderMeta `json:"providers,omitempty"`
+}
+
+type ProviderDetectionUsecase interface {
+	// Detect guesses the provider hosting the given domain.
+	Detect(context.Context, *User, string) (*ProviderDetection, error)
+}
@@ -0,0 +1,83 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package happydns
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// ProviderCall is an entry of a provider journal: a correction executed
+// against the provider while publishing a zone.
+type ProviderCall struct {
+	// Id is the entry identifier.
+	Id Identifier `json:"id" swaggertype:"string" readonly:"true"`
+
+	// ProviderId is the provider the correction was executed against.
+	ProviderId Identifier `json:"id_provider" swaggertype:"string"`
+
+	// DomainId is the domain being published.
+	DomainId Identifier `json:"id_domain" swaggertype:"string"`
+
+	// ZoneId is the zone the publication was made from.
+	ZoneId Identifier `json:"id_zone" swaggertype:"string"`
+
+	// SnapshotId is the published snapshot created by the publication. It is
+	// empty when the publication failed, as no snapshot is then created.
+	SnapshotId Identifier `json:"id_snapshot,omitempty" swaggertype:"string"`
+
+	// Msg is the correction message.
+	Msg string `json:"msg"`
+
+	// Kind is the kind of the correction.
+	Kind CorrectionKind `json:"kind"`
+
+	// Date is when the correction started.
+	Date time.Time `json:"date"`
+
+	// Duration is how long the correction took.
+	Duration time.Duration `json:"duration" swaggertype:"integer"`
+
+	// Success tells whether the correction succeeded.
+	Success bool `json:"success"`
+
+	// Error is the error returned by the provider, when it failed.
+	Error string `json:"error,omitempty"`
+
+	// Request is what was sent to the provider, when its adapter supplies it,
+	// with the provider's secrets redacted.
+	Request json.RawMessage `json:"request,omitempty" swaggertype:"object"`
+
+	// Response is what the provider returned, when its adapter supplies it,
+	// with the provider's secrets redacted.
+	Response json.RawMessage `json:"response,omitempty" swaggertype:"object"`
+}
+
+type ProviderJournalUsecase interface {
+	// ListDomainCalls returns the journal entries of the given domain, the
+	// most recent first, restricted to a snapshot when one is given.
+	ListDomainCalls(ctx context.Context, domain *Domain, snapshotId Identifier) ([]*ProviderCall, error)
+	// Record keeps the given entries, when the provider has its journal
+	// enabled.
+	Record(ctx context.Context, provider *Provider, calls []*ProviderCall) error
+}
@@ -0,0 +1,148 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package happydns
+
+import (
+	"context"
+	"time"
+)
+
+// ProviderMigrationStep is a step of the migration of a domain from one
+// provider to another.
+type ProviderMigrationStep string
+
+const (
+	// MigrationStepCopy copies the published zone to the new provider.
+	MigrationStepCopy ProviderMigrationStep = "copy"
+
+	// MigrationStepVerify checks that the new provider answers as the
+	// current one does, for every RRset of the zone.
+	MigrationStepVerify ProviderMigrationStep = "verify"
+
+	// MigrationStepLowerTTL lowers the TTLs at both providers, so that the
+	// resolvers pick the change of delegation up quickly.
+	MigrationStepLowerTTL ProviderMigrationStep = "lower-ttl"
+
+	// MigrationStepSwitch makes the new provider the one of the domain.
+	MigrationStepSwitch ProviderMigrationStep = "switch"
+
+	// MigrationStepDelegation waits for the parent zone to delegate the
+	// domain to the name servers of the new provider.
+	MigrationStepDelegation ProviderMigrationStep = "delegation"
+
+	// MigrationStepDone marks a completed migration.
+	MigrationStepDone ProviderMigrationStep = "done"
+
+	// MigrationStepAborted marks a migration given up by the user.
+	MigrationStepAborted ProviderMigrationStep = "aborted"
+)
+
+// ProviderMigrationSteps lists the steps of a migration, in the order they
+// are run.
+var ProviderMigrationSteps = []ProviderMigrationStep{
+	MigrationStepCopy,
+	MigrationStepVerify,
+	MigrationStepLowerTTL,
+	MigrationStepSwitch,
+	MigrationStepDelegation,
+	MigrationStepDone,
+}
+
+// ProviderMigration is the state of the migration of a domain from one
+// provider to another. It is kept in the domain log, along with the entry of
+// each step.
+type ProviderMigration struct {
+	// From is the identifier of the provider the domain is moved away from.
+	From Identifier `json:"from" swaggertype:"string"`
+
+	// To is the identifier of the provider the domain is moved to.
+	To Identifier `json:"to" swaggertype:"string"`
+
+	// Snapshot is the identifier of the published zone being copied.
+	Snapshot Identifier `json:"snapshot" swaggertype:"string"`
+
+	// Step is the next step to run.
+	Step ProviderMigrationStep `json:"step" enums:"copy,verify,lower-ttl,switch,delegation,done,aborted"`
+
+	// TTL is the TTL the records are lowered to, in seconds.
+	TTL uint32 `json:"ttl"`
+
+	// OldNameservers are the name servers of the provider left.
+	OldNameservers []string `json:"old_nameservers,omitempty"`
+
+	// Nameservers are the name servers of the new provider.
+	Nameservers []string `json:"nameservers,omitempty"`
+
+	// Mismatches lists the RRsets on which both providers disagreed at the
+	// last verification.
+	Mismatches []*ProviderMigrationMismatch `json:"mismatches,omitempty"`
+
+	// WaitUntil is the date after which the records cached with their
+	// former TTLs have expired, and the switch can happen.
+	WaitUntil *time.Time `json:"wait_until,omitempty" format:"date-time"`
+
+	// ParentNameservers are the name servers the parent zone delegated the
+	// domain to, when last asked.
+	ParentNameservers []string `json:"parent_nameservers,omitempty"`
+}
+
+// InProgress tells whether the migration still has steps to run.
+func (m *ProviderMigration) InProgress() bool {
+	return m.Step != MigrationStepDone && m.Step != MigrationStepAborted
+}
+
+// ProviderMigrationMismatch is an RRset answered differently by the two
+// providers. The records are given in presentation format, without their
+// header.
+type ProviderMigrationMismatch struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Expected []string `json:"expected"`
+	Got      []string `json:"got"`
+}
+
+// ProviderMigrationForm starts the migration of a domain.
+type ProviderMigrationForm struct {
+	// To is the identifier of the provider to move the domain to.
+	To Identifier `json:"to" swaggertype:"string" binding:"required"`
+
+	// TTL is the TTL to lower the records to, in seconds. Defaults to 300.
+	TTL uint32 `json:"ttl,omitempty"`
+}
+
+// ProviderMigrationContinueForm resumes a migration.
+type ProviderMigrationContinueForm struct {
+	// Step, when set, runs the migration again from this earlier step.
+	// The steps preceding the switch cannot be run again once it is done.
+	Step ProviderMigrationStep `json:"step,omitempty"`
+}
+
+type ProviderMigrationUsecase interface {
+	// Get returns the state of the last migration of the Domain.
+	Get(*User, *Domain) (*ProviderMigration, error)
+	// Start begins the migration of the Domain and runs its first step.
+	Start(context.Context, *User, *Domain, *ProviderMigrationForm) (*ProviderMigration, error)
+	// Continue runs the next step of the migration in progress.
+	Continue(context.Context, *User, *Domain, *ProviderMigrationContinueForm) (*ProviderMigration, error)
+	// Abort gives the migration in progress up, restoring the TTLs at the
+	// former provider when the switch did not happen yet.
+	Abort(context.Context, *User, *Domain) (*ProviderMigration, error)
+}
@@ -29,6 +29,9 @@ type ProviderSpecs struct {
 
 	// Capabilities exposes what the provider can do.
 	Capabilities []string `json:"capabilities,omitempty"`
+
+	// Features details the record types and limits of the provider.
+	Features *ProviderCapabilities `json:"features,omitempty"`
 }
 
 type ProviderSpecsUsecase interface {
@@ -0,0 +1,112 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package happydns
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// ManagedRecordMarker starts the comment happyDomain stores along the records
+// it publishes, telling the people editing the zone at the provider where the
+// record comes from.
+const ManagedRecordMarker = "managed by happyDomain"
+
+// RecordComments holds the comment of each record of a zone, indexed by
+// RecordCommentKey.
+type RecordComments map[string]string
+
+// RecordCommentKey identifies a record by its owner, type and data. The TTL is
+// left out: the provider may have adjusted it.
+func RecordCommentKey(rr Record) string {
+	if record, ok := rr.(ConvertibleRecord); ok {
+		rr = record.ToRR()
+	}
+
+	hdr := rr.Header()
+	rdata := strings.TrimSpace(strings.TrimPrefix(rr.String(), hdr.String()))
+
+	return dns.CanonicalName(hdr.Name) + " " + dns.Type(hdr.Rrtype).String() + " " + rdata
+}
+
+// Changed returns the comments of the given records that differ from those in
+// current, the comments stored at the provider, or nil when none does. The
+// records without a comment here are left out: they keep theirs. So are the
+// records missing from current: the provider does not hold them, or can't
+// comment them.
+func (comments RecordComments) Changed(records []Record, current RecordComments) RecordComments {
+	var changed RecordComments
+	for _, rr := range records {
+		key := RecordCommentKey(rr)
+		comment, ok := comments[key]
+		stored, held := current[key]
+		if !ok || !held || comment == stored {
+			continue
+		}
+
+		if changed == nil {
+			changed = RecordComments{}
+		}
+		changed[key] = comment
+	}
+
+	return changed
+}
+
+// FormatRecordComment returns the comment to store along a record of a
+// service bearing the given user comment.
+func FormatRecordComment(userComment string) string {
+	if userComment = strings.TrimSpace(userComment); userComment == "" {
+		return ManagedRecordMarker
+	}
+
+	return ManagedRecordMarker + ": " + userComment
+}
+
+// ParseRecordComment reads back a comment stored along a record. It returns
+// the user comment it holds and tells whether happyDomain wrote it; a comment
+// written by someone else is returned as is.
+func ParseRecordComment(comment string) (userComment string, managed bool) {
+	rest, ok := strings.CutPrefix(comment, ManagedRecordMarker)
+	if !ok || (rest != "" && !strings.HasPrefix(rest, ":")) {
+		return strings.TrimSpace(comment), false
+	}
+
+	return strings.TrimSpace(strings.TrimPrefix(rest, ":")), true
+}
+
+// RecordCommenter is implemented by the ProviderActuator of the providers able
+// to store a comment along each record.
+type RecordCommenter interface {
+	// GetZoneRecordComments returns the comments stored along the records of
+	// the zone: an entry for each record the provider can comment, empty
+	// when it bears none.
+	GetZoneRecordComments(domain string) (RecordComments, error)
+
+	// GetZoneCorrectionsWithComments is GetZoneCorrections storing, when
+	// the corrections are applied, the given comments along the records of
+	// the zone. The records without a comment among them keep theirs. A
+	// comment differing from the stored one gets its own correction when
+	// its record is otherwise left as is.
+	GetZoneCorrectionsWithComments(domain string, wantedRecords []Record, comments RecordComments) ([]*Correction, int, error)
+}
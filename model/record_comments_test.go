@@ -0,0 +1,94 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package happydns_test
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+func TestRecordCommentKey(t *testing.T) {
+	txt := &happydns.TXT{
+		Hdr: dns.RR_Header{Name: "WWW.example.com.", Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 3600},
+		Txt: "hello",
+	}
+	rr, err := dns.NewRR("www.example.com. 60 IN TXT \"hello\"")
+	if err != nil {
+		t.Fatalf("dns.NewRR() = %v", err)
+	}
+
+	if a, b := happydns.RecordCommentKey(txt), happydns.RecordCommentKey(rr); a != b {
+		t.Errorf("RecordCommentKey() = %q and %q; want the same key, whatever the TTL, case and type", a, b)
+	}
+}
+
+func TestRecordCommentRoundTrip(t *testing.T) {
+	tests := []struct {
+		stored  string
+		comment string
+		managed bool
+	}{
+		{stored: happydns.FormatRecordComment(""), managed: true},
+		{stored: happydns.FormatRecordComment(" web front "), comment: "web front", managed: true},
+		{stored: "managed by happyDomainish", comment: "managed by happyDomainish"},
+		{stored: "added by hand", comment: "added by hand"},
+		{stored: ""},
+	}
+
+	for _, tt := range tests {
+		comment, managed := happydns.ParseRecordComment(tt.stored)
+		if comment != tt.comment || managed != tt.managed {
+			t.Errorf("ParseRecordComment(%q) = %q, %v; want %q, %v", tt.stored, comment, managed, tt.comment, tt.managed)
+		}
+	}
+}
+
+func TestRecordCommentsChanged(t *testing.T) {
+	www, _ := dns.NewRR("www.example.com. 300 IN A 192.0.2.1")
+	ftp, _ := dns.NewRR("ftp.example.com. 300 IN A 192.0.2.2")
+	mail, _ := dns.NewRR("mail.example.com. 300 IN A 192.0.2.3")
+	apex, _ := dns.NewRR("example.com. 300 IN NS ns.example.net.")
+	records := []happydns.Record{www, ftp, mail, apex}
+
+	wanted := happydns.RecordComments{
+		happydns.RecordCommentKey(www):  happydns.FormatRecordComment("web"),
+		happydns.RecordCommentKey(ftp):  happydns.FormatRecordComment("files"),
+		happydns.RecordCommentKey(apex): happydns.FormatRecordComment(""),
+	}
+	current := happydns.RecordComments{
+		happydns.RecordCommentKey(www):  happydns.FormatRecordComment("web"),
+		happydns.RecordCommentKey(ftp):  happydns.FormatRecordComment("old"),
+		happydns.RecordCommentKey(mail): "added by hand",
+	}
+
+	changed := wanted.Changed(records, current)
+	if len(changed) != 1 || changed[happydns.RecordCommentKey(ftp)] != happydns.FormatRecordComment("files") {
+		t.Errorf("Changed() = %v; want only the comment of ftp, the NS record not being held", changed)
+	}
+
+	if changed := wanted.Changed(records, wanted); changed != nil {
+		t.Errorf("Changed() = %v; want nil when the comments are all stored", changed)
+	}
+}
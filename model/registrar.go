@@ -0,0 +1,123 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package happydns
+
+import (
+	"context"
+)
+
+// RegistrarActuator is implemented by the ProviderActuator of the providers
+// which are also the registrar of the domains they host, and can change their
+// delegation.
+type RegistrarActuator interface {
+	// SetNameservers delegates the domain to the given name servers.
+	SetNameservers(domain string, nameservers []string) error
+}
+
+// RegistrarReader is implemented by the registrars able to tell how a domain
+// is registered. The others are looked up with RDAP and the DNS.
+type RegistrarReader interface {
+	GetRegistration(domain string) (*Registration, error)
+}
+
+// RegistrarDSActuator is implemented by the registrars managing the DS
+// records of the domains they register.
+type RegistrarDSActuator interface {
+	SetDelegationSigners(domain string, ds []DelegationSigner) error
+}
+
+// RegistrarLockActuator is implemented by the registrars able to lock the
+// transfer of a domain to another registrar.
+type RegistrarLockActuator interface {
+	SetTransferLock(domain string, locked bool) error
+}
+
+// RegistrarAutoRenewActuator is implemented by the registrars able to renew
+// a domain automatically before it expires.
+type RegistrarAutoRenewActuator interface {
+	SetAutoRenew(domain string, enabled bool) error
+}
+
+// Operations a registrar may offer, as listed in Registration.
+const (
+	RegistrarOpNameservers  = "nameservers"
+	RegistrarOpDS           = "ds"
+	RegistrarOpTransferLock = "transfer-lock"
+	RegistrarOpAutoRenew    = "auto-renew"
+)
+
+// DelegationSigner is a DS record held by the parent zone of a domain.
+type DelegationSigner struct {
+	KeyTag     uint16 `json:"keytag"`
+	Algorithm  uint8  `json:"algorithm"`
+	DigestType uint8  `json:"digest_type"`
+	Digest     string `json:"digest"`
+}
+
+// Registration holds the delegation and the registration settings of a
+// domain.
+type Registration struct {
+	// Source tells where the data come from: "registrar" when the provider
+	// gave it, "rdap" when it was looked up publicly.
+	Source string `json:"source" enums:"registrar,rdap"`
+
+	// Nameservers are the name servers the domain is delegated to.
+	Nameservers []string `json:"nameservers"`
+
+	// DS are the DS records of the domain in its parent zone.
+	DS []DelegationSigner `json:"ds"`
+
+	// TransferLock tells whether the transfer of the domain is prohibited,
+	// when known.
+	TransferLock *bool `json:"transfer_lock,omitempty"`
+
+	// AutoRenew tells whether the domain is renewed automatically, when
+	// known.
+	AutoRenew *bool `json:"auto_renew,omitempty"`
+
+	// Operations lists the changes the registrar can make, among
+	// "nameservers", "ds", "transfer-lock" and "auto-renew".
+	Operations []string `json:"operations"`
+}
+
+// RegistrarForm changes the registration of a domain. The fields left out
+// are left untouched.
+type RegistrarForm struct {
+	// Nameservers, when given, are the name servers to delegate to.
+	Nameservers []string `json:"nameservers,omitempty"`
+
+	// DS, when given, replaces the DS records: an empty list removes them.
+	DS []DelegationSigner `json:"ds"`
+
+	// TransferLock locks or unlocks the transfer of the domain.
+	TransferLock *bool `json:"transfer_lock,omitempty"`
+
+	// AutoRenew turns the automatic renewal on or off.
+	AutoRenew *bool `json:"auto_renew,omitempty"`
+}
+
+type RegistrarUsecase interface {
+	// GetRegistration returns how the Domain is registered.
+	GetRegistration(context.Context, *User, *Domain) (*Registration, error)
+	// UpdateRegistration applies the form through the registrar of the
+	// Domain, then returns its registration.
+	UpdateRegistration(context.Context, *User, *Domain, *RegistrarForm) (*Registration, error)
+}
@@ -87,6 +87,7 @@ func NewResolverResponseFromMsg(msg *dns.Msg) *ResolverResponse {
 type ResolverUsecase interface {
 	ResolveQuestion(ResolverRequest) (*dns.Msg, error)
 	FlattenSPF(SPFFlattenRequest) (*SPFFlattenResponse, error)
+	ExpandSPF(SPFExpandRequest) (*SPFExpandResponse, error)
 	FetchMTASTSPolicy(MTASTSPolicyRequest) (*MTASTSPolicyResponse, error)
 	CheckDMARCReportAuth(DMARCReportAuthRequest) (*DMARCReportAuthResponse, error)
 }
@@ -249,3 +250,36 @@ type SPFFlattenResponse struct {
 	// Tree is the recursive evaluation tree, rooted at Domain.
 	Tree *SPFNode `json:"tree,omitempty"`
 }
+
+// SPFExpandRequest asks the backend to rewrite an SPF record into its
+// flattened form, where the includes and the a/mx mechanisms are replaced by
+// the ip4/ip6 terms they currently stand for.
+type SPFExpandRequest struct {
+	// Resolver is the name of the resolver to use (or local or custom).
+	Resolver string `json:"resolver,omitempty"`
+
+	// Custom is the address to the recursive server to use.
+	Custom string `json:"custom,omitempty"`
+
+	// Domain is the FQDN the record is published at. The a and mx mechanisms
+	// without domain refer to it.
+	Domain string `json:"domain"`
+
+	// Record is the SPF record to expand. When empty, the resolver fetches
+	// Domain's TXT and looks for "v=spf1".
+	Record string `json:"record,omitempty"`
+}
+
+// SPFExpandResponse is the flattened form of an SPF record.
+type SPFExpandResponse struct {
+	// Record is the flattened SPF record.
+	Record string `json:"record"`
+
+	// Kept lists the terms left as written, because they cannot be expanded
+	// into addresses (exists, ptr, macros...).
+	Kept []string `json:"kept,omitempty"`
+
+	// LookupCount is the number of DNS lookups the flattened record still
+	// costs to evaluate.
+	LookupCount int `json:"lookupCount"`
+}
@@ -0,0 +1,164 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package happydns
+
+import (
+	"context"
+)
+
+// ReverseDNSProposal is a PTR record that should be created or updated in a
+// reverse zone owned by the user, to point back at a server of a forward
+// zone.
+type ReverseDNSProposal struct {
+	// Address is the IPv4 or IPv6 address of the server.
+	Address string `json:"address"`
+
+	// Target is the fully qualified name of the server, which the PTR
+	// record should point to.
+	Target string `json:"target"`
+
+	// ReverseDomainId is the identifier of the reverse Domain holding the
+	// PTR record.
+	ReverseDomainId Identifier `json:"id_reverse_domain" swaggertype:"string"`
+
+	// ReverseDomain is the name of the reverse Domain.
+	ReverseDomain string `json:"reverse_domain"`
+
+	// Subdomain is where the PTR service lives, relative to ReverseDomain.
+	Subdomain Subdomain `json:"subdomain"`
+
+	// ServiceId is the identifier of the PTR service to update; empty when
+	// the service has to be created.
+	ServiceId Identifier `json:"id_service,omitempty" swaggertype:"string"`
+
+	// Current is the name the existing PTR record points to, if any.
+	Current string `json:"current,omitempty"`
+}
+
+// ReverseDNSSyncForm selects the proposals to apply.
+type ReverseDNSSyncForm struct {
+	// Addresses restricts the synchronization to these addresses. All the
+	// proposals are applied when empty.
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+// DomainCorrections gathers the corrections pending on one Domain, as part of
+// a diff spanning several zones.
+type DomainCorrections struct {
+	DomainId    Identifier    `json:"id_domain" swaggertype:"string"`
+	DomainName  string        `json:"domain"`
+	Corrections []*Correction `json:"corrections"`
+	NbDiffs     int           `json:"nbDiffs"`
+}
+
+// FCrDNSIssueKind tells how forward and reverse resolutions disagree.
+type FCrDNSIssueKind string
+
+const (
+	// FCrDNSMissingPTR is an address of a forward zone without PTR record,
+	// although the user owns its reverse zone.
+	FCrDNSMissingPTR FCrDNSIssueKind = "missing_ptr"
+
+	// FCrDNSPTRMismatch is an address whose PTR records do not point back
+	// at the name using it.
+	FCrDNSPTRMismatch FCrDNSIssueKind = "ptr_mismatch"
+
+	// FCrDNSMissingForward is a PTR record pointing at a name of a forward
+	// zone owned by the user, which has no address.
+	FCrDNSMissingForward FCrDNSIssueKind = "missing_forward"
+
+	// FCrDNSForwardMismatch is a PTR record pointing at a name whose
+	// addresses do not include the one the PTR record is about.
+	FCrDNSForwardMismatch FCrDNSIssueKind = "forward_mismatch"
+)
+
+// FCrDNSIssue is a forward-confirmed reverse DNS mismatch found across the
+// zones of a user.
+type FCrDNSIssue struct {
+	Kind FCrDNSIssueKind `json:"kind"`
+
+	// Address is the IP address at stake.
+	Address string `json:"address"`
+
+	// Name is the forward name at stake.
+	Name string `json:"name"`
+
+	// DomainId is the identifier of the Domain holding the faulty record:
+	// the forward one for missing_ptr and ptr_mismatch, the reverse one
+	// otherwise.
+	DomainId Identifier `json:"id_domain" swaggertype:"string"`
+
+	// Found lists what is actually published on the other side: the PTR
+	// targets, or the addresses of Name.
+	Found []string `json:"found,omitempty"`
+}
+
+// ReverseZonesCreationInput asks for the creation of the reverse zones of a
+// network.
+type ReverseZonesCreationInput struct {
+	// ProviderId is the identifier of the Provider hosting the new zones.
+	ProviderId Identifier `json:"id_provider" swaggertype:"string"`
+
+	// CIDR is the IPv4 or IPv6 network, e.g. 192.0.2.0/25 or 2001:db8::/48.
+	CIDR string `json:"cidr"`
+}
+
+// ReverseZonesCreation reports the reverse zones created for a network.
+type ReverseZonesCreation struct {
+	// Domains are the created reverse zones. Prefixes not ending on an
+	// octet (IPv4) or nibble (IPv6) boundary span several zones.
+	Domains []*Domain `json:"domains"`
+
+	// Parent is the zone delegating an RFC 2317 classless zone, for
+	// networks smaller than a /24.
+	Parent string `json:"parent,omitempty"`
+
+	// ParentId is the identifier of Parent when the user manages it: the
+	// CNAME records have then been added to its current zone, to be
+	// reviewed and published.
+	ParentId Identifier `json:"id_parent,omitempty" swaggertype:"string"`
+
+	// CNAMEs are the records Parent needs, in zone file format.
+	CNAMEs []string `json:"cnames,omitempty"`
+
+	// Skipped are the owners of Parent already holding records, where no
+	// CNAME has been added.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+type ReverseDNSUsecase interface {
+	// Plan lists the PTR records to create or update in the reverse zones
+	// of the user, for the servers of the given forward Domain.
+	Plan(*User, *Domain) ([]*ReverseDNSProposal, error)
+	// Diff returns the pending corrections of the forward Domain along with
+	// those of the reverse zones covering its servers.
+	Diff(context.Context, *User, *Domain) ([]*DomainCorrections, error)
+	// Sync applies the proposals to the reverse zones, then returns the
+	// combined diff.
+	Sync(context.Context, *User, *Domain, *ReverseDNSSyncForm) ([]*DomainCorrections, error)
+	// Check reports the FCrDNS mismatches across all the zones of the user.
+	Check(*User) ([]*FCrDNSIssue, error)
+	// CreateZones creates the reverse zones of a network, along with the
+	// RFC 2317 CNAME records of a classless delegation when the user
+	// manages its parent zone.
+	CreateZones(context.Context, *User, *ReverseZonesCreationInput) (*ReverseZonesCreation, error)
+}
@@ -74,6 +74,13 @@ type MetadataEnricher interface {
 	EnrichFromPrevious(old ServiceBody)
 }
 
+// ServiceValidator is implemented by ServiceBody types whose fields follow
+// rules their type alone doesn't enforce. Validate is called before the
+// service is saved.
+type ServiceValidator interface {
+	Validate() error
+}
+
 // SPFContributor is implemented by services that contribute SPF directives.
 // When multiple services implement this interface for the same domain, their
 // directives are merged into a single SPF TXT record (RFC 7208 requires
@@ -0,0 +1,69 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package happydns
+
+import (
+	"context"
+
+	"github.com/miekg/dns"
+)
+
+// SSHFPGenerationInput gives the host keys whose fingerprints are turned into
+// SSHFP records.
+type SSHFPGenerationInput struct {
+	// Keys is the output of ssh-keyscan, or the content of OpenSSH public
+	// key files, one key per line.
+	Keys string `json:"keys,omitempty"`
+
+	// Scan asks for the host keys to be retrieved from the SSH server of
+	// the subdomain, in addition to Keys.
+	Scan bool `json:"scan,omitempty"`
+
+	// Port is the port of the SSH server to scan. It defaults to 22.
+	Port uint16 `json:"port,omitempty"`
+}
+
+// SSHFPGeneration is the set of SSHFP records computed for a subdomain,
+// compared with the ones currently published.
+type SSHFPGeneration struct {
+	// Owner is the name the records are for.
+	Owner string `json:"owner"`
+
+	// Records are the SHA-256 fingerprints of every host key.
+	Records []*dns.SSHFP `json:"records"`
+
+	// ToAdd are the records missing from the published zone.
+	ToAdd []*dns.SSHFP `json:"to_add,omitempty"`
+
+	// ToDelete are the published records matching none of the host keys.
+	ToDelete []*dns.SSHFP `json:"to_delete,omitempty"`
+
+	// Published tells whether the domain has a published zone the records
+	// have been compared with.
+	Published bool `json:"published"`
+}
+
+type SSHFPGeneratorUsecase interface {
+	// Generate computes the SSHFP records of the given or scanned host keys
+	// of a subdomain and compares them with the published ones.
+	Generate(context.Context, *Domain, Subdomain, *SSHFPGenerationInput) (*SSHFPGeneration, error)
+}
@@ -29,6 +29,7 @@ type TidyUpUseCase interface {
 	// delete those records; otherwise they are only logged.
 	TidyAll(dropInvalid bool) error
 	TidyAuthUsers(dropInvalid bool) error
+	TidyBuiltinZones(dropInvalid bool) error
 	TidyCheckEvaluations(dropInvalid bool) error
 	TidyCheckPlans(dropInvalid bool) error
 	TidyCheckerConfigurations(dropInvalid bool) error
@@ -38,6 +39,7 @@ type TidyUpUseCase interface {
 	TidyDomains(dropInvalid bool) error
 	TidyDomainLogs(dropInvalid bool) error
 	TidyProviders(dropInvalid bool) error
+	TidyProviderCalls(dropInvalid bool) error
 	TidySessions(dropInvalid bool) error
 	TidyUsers(dropInvalid bool) error
 	TidyZones(dropInvalid bool) error
@@ -0,0 +1,152 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package happydns
+
+import (
+	"context"
+	"time"
+)
+
+// TLSReport is an RFC 8460 SMTP TLS aggregate report received for a Domain.
+type TLSReport struct {
+	// Id is the report's identifier in the database.
+	Id Identifier `json:"id" swaggertype:"string" readonly:"true"`
+
+	// DomainId is the identifier of the Domain the report is about.
+	DomainId Identifier `json:"id_domain" swaggertype:"string" readonly:"true"`
+
+	// OrgName is the name of the organization that sent the report.
+	OrgName string `json:"org_name"`
+
+	// ContactInfo is how to reach the reporting organization.
+	ContactInfo string `json:"contact_info,omitempty"`
+
+	// ReportID is the identifier given to the report by its sender.
+	ReportID string `json:"report_id"`
+
+	// Begin and End delimit the period covered by the report.
+	Begin time.Time `json:"begin" format:"date-time"`
+	End   time.Time `json:"end" format:"date-time"`
+
+	// ReceivedAt is when happyDomain ingested the report.
+	ReceivedAt time.Time `json:"received_at" format:"date-time" readonly:"true"`
+
+	// Policies holds the outcome of the sessions, per policy applied.
+	Policies []TLSReportPolicy `json:"policies"`
+}
+
+// TLSReportPolicy is the outcome of the sessions made under one policy.
+type TLSReportPolicy struct {
+	// PolicyType is "sts", "tlsa" or "no-policy-found".
+	PolicyType   string   `json:"policy_type"`
+	PolicyDomain string   `json:"policy_domain"`
+	MXHosts      []string `json:"mx_hosts,omitempty"`
+
+	SuccessfulSessions int `json:"successful_sessions"`
+	FailedSessions     int `json:"failed_sessions"`
+
+	Failures []TLSReportFailure `json:"failures,omitempty"`
+}
+
+// TLSReportFailure details the sessions that failed for the same reason.
+type TLSReportFailure struct {
+	// ResultType is the failure reason registered by RFC 8460, such as
+	// sts-policy-fetch-error, certificate-expired or tlsa-invalid.
+	ResultType          string `json:"result_type"`
+	SendingMTAIP        string `json:"sending_mta_ip,omitempty"`
+	ReceivingMXHostname string `json:"receiving_mx_hostname,omitempty"`
+	ReceivingIP         string `json:"receiving_ip,omitempty"`
+	FailedSessions      int    `json:"failed_sessions"`
+	AdditionalInfo      string `json:"additional_information,omitempty"`
+	FailureReasonCode   string `json:"failure_reason_code,omitempty"`
+}
+
+// TLSFailureStats aggregates the failed sessions sharing a result type.
+type TLSFailureStats struct {
+	ResultType string   `json:"result_type"`
+	Sessions   int      `json:"sessions"`
+	MXHosts    []string `json:"mx_hosts"`
+	Reporters  []string `json:"reporters"`
+}
+
+// TLSReportSummary aggregates the reports received for a Domain over a
+// period.
+type TLSReportSummary struct {
+	DomainId           Identifier         `json:"id_domain" swaggertype:"string"`
+	From               time.Time          `json:"from" format:"date-time"`
+	To                 time.Time          `json:"to" format:"date-time"`
+	Reports            int                `json:"reports"`
+	SuccessfulSessions int                `json:"successful_sessions"`
+	FailedSessions     int                `json:"failed_sessions"`
+	Failures           []*TLSFailureStats `json:"failures"`
+}
+
+// TLSReportSettings holds the per-Domain preferences for the reports.
+type TLSReportSettings struct {
+	// DomainId is the identifier of the Domain the settings apply to.
+	DomainId Identifier `json:"id_domain" swaggertype:"string" readonly:"true"`
+
+	// IngestToken authenticates the reports posted to ReportURI, and tells
+	// the reports sent to ReportAddress apart from those of another domain.
+	IngestToken string `json:"ingest_token,omitempty" readonly:"true"`
+
+	// ReportURI is the https rua= address to publish in the TLS-RPT record
+	// of the domain to have reports delivered to happyDomain.
+	ReportURI string `json:"report_uri,omitempty" readonly:"true"`
+
+	// ReportAddress is the mailto: rua= address that can be published in
+	// place of ReportURI.
+	ReportAddress string `json:"report_address,omitempty" readonly:"true"`
+
+	// NotifySpikes raises a notification when a report shows a failure
+	// rate above SpikeThreshold and well above the previous reports.
+	NotifySpikes bool `json:"notify_spikes"`
+
+	// SpikeThreshold is the failure rate, in percent of the sessions, from
+	// which a report is worth a notification.
+	SpikeThreshold float64 `json:"spike_threshold"`
+}
+
+type TLSReportUsecase interface {
+	// IngestForDomain parses a raw report (JSON or gzip) uploaded for the
+	// given domain.
+	IngestForDomain(context.Context, *Domain, []byte) (*TLSReport, error)
+	// IngestWithToken parses a raw report posted to the report URI of the
+	// domain, authenticated by its ingestion token.
+	IngestWithToken(ctx context.Context, domainId Identifier, token string, raw []byte) (*TLSReport, error)
+	// Ingest parses a raw report sent to the given recipients, and stores
+	// it for the domains whose report address is among them.
+	Ingest(ctx context.Context, recipients []string, raw []byte) ([]*TLSReport, error)
+	// ListReports lists the reports received for the domain since the
+	// given date, newest first.
+	ListReports(*Domain, time.Time) ([]*TLSReport, error)
+	// Summary aggregates the failures reported for the domain since the
+	// given date.
+	Summary(*Domain, time.Time) (*TLSReportSummary, error)
+	// GetSettings returns the report settings of the domain.
+	GetSettings(*Domain) (*TLSReportSettings, error)
+	// SetSettings replaces the report settings of the domain.
+	SetSettings(*Domain, *TLSReportSettings) (*TLSReportSettings, error)
+	// ResetIngestToken replaces the ingestion token, and so the report URI,
+	// of the domain.
+	ResetIngestToken(*Domain) (*TLSReportSettings, error)
+}
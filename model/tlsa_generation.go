@@ -0,0 +1,64 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package happydns
+
+import (
+	"context"
+
+	"github.com/miekg/dns"
+)
+
+// TLSAGenerationInput selects the endpoint whose certificate chain is hashed
+// into TLSA records.
+type TLSAGenerationInput struct {
+	// Port is the port of the endpoint. It defaults to the port of the
+	// records of the service.
+	Port uint16 `json:"port,omitempty"`
+
+	// STARTTLS is the protocol used to upgrade the connection: smtp, imap,
+	// pop3 or none. It is guessed from the port when empty.
+	STARTTLS string `json:"starttls,omitempty"`
+
+	// Rollover keeps the records already in the service next to the ones
+	// of the live chain, so that both are published during a key change.
+	Rollover bool `json:"rollover,omitempty"`
+
+	// NextKey is the PEM-encoded public key, certificate or certificate
+	// request of the key to be deployed next. Its DANE-EE hash is proposed
+	// along with the live ones.
+	NextKey string `json:"next_key,omitempty"`
+}
+
+// TLSAGeneration is the set of TLSA records proposed for a service.
+type TLSAGeneration struct {
+	// Endpoint is the host:port whose chain has been fetched.
+	Endpoint string `json:"endpoint"`
+
+	// Records are the proposed records, in the shape of the TLSAs service.
+	Records []*dns.TLSA `json:"tlsa"`
+}
+
+type TLSAGeneratorUsecase interface {
+	// Generate fetches the certificate chain of the endpoint of a TLSAs
+	// service and proposes its 3 1 1 and 2 1 1 records.
+	Generate(context.Context, *Domain, *Zone, Subdomain, Identifier, *TLSAGenerationInput) (*TLSAGeneration, error)
+}
@@ -52,6 +52,26 @@ type ZoneMeta struct {
 
 	// Published indicates whether the Zone has already been published or not.
 	Published *time.Time `json:"published,omitempty" format:"date-time"`
+
+	// Derived lists, on a published Zone, the records happyDomain published
+	// in place of the ones of the Zone they are computed from.
+	Derived []*DerivedRecords `json:"derived,omitempty"`
+}
+
+// DerivedRecords are the records published under an owner in place of the
+// record of the Zone they are computed from, such as the addresses of a
+// flattened ALIAS.
+type DerivedRecords struct {
+	// Owner is the canonical name the records are published under.
+	Owner string `json:"owner"`
+
+	// From tells what the records derive from, as Correction.DerivedFrom
+	// does.
+	From string `json:"from"`
+
+	// Records holds the data of the records published, without their
+	// header.
+	Records []string `json:"records"`
 }
 
 // ZoneMessage is the intermediate struct for parsing zones.
@@ -186,4 +206,10 @@ type PrepareZoneForm struct {
 type PrepareZoneResponse struct {
 	Corrections []*Correction `json:"corrections" binding:"required"`
 	NbDiffs     int           `json:"nbDiffs" binding:"required"`
+	// ProviderRecordsFetchedAt is when the provider records the diff was
+	// computed from were fetched, when they came from the cache.
+	ProviderRecordsFetchedAt *time.Time `json:"providerRecordsFetchedAt,omitempty"`
+	// Warnings lists the selected changes the provider is unable to publish
+	// as they are written.
+	Warnings []string `json:"warnings,omitempty"`
 }
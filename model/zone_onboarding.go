@@ -0,0 +1,100 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package happydns
+
+import (
+	"context"
+	"time"
+)
+
+// OnboardingStatus is where the onboarding of a zone stands.
+type OnboardingStatus string
+
+const (
+	// OnboardingPending is a zone waiting for its turn.
+	OnboardingPending OnboardingStatus = "pending"
+
+	// OnboardingRunning is a zone being added.
+	OnboardingRunning OnboardingStatus = "running"
+
+	// OnboardingDone is a zone added as a domain, with its records imported.
+	OnboardingDone OnboardingStatus = "done"
+
+	// OnboardingSkipped is a zone already managed by the user.
+	OnboardingSkipped OnboardingStatus = "skipped"
+
+	// OnboardingFailed is a zone that could not be added.
+	OnboardingFailed OnboardingStatus = "failed"
+)
+
+// OnboardingForm selects the zones of a provider to add as domains.
+type OnboardingForm struct {
+	// Domains are the zones to add. All the zones hosted by the provider
+	// are added when empty.
+	Domains []string `json:"domains,omitempty"`
+}
+
+// Onboarding is the progress of adding the zones hosted by a provider as
+// domains of its owner.
+type Onboarding struct {
+	// ProviderId is the identifier of the provider the zones come from.
+	ProviderId Identifier `json:"id_provider" swaggertype:"string"`
+
+	// Started is when the onboarding began.
+	Started time.Time `json:"started" format:"date-time"`
+
+	// Finished is when the last zone was handled, once they all were.
+	Finished *time.Time `json:"finished,omitempty" format:"date-time"`
+
+	// Total is the number of zones to handle.
+	Total int `json:"total"`
+
+	// Handled is the number of zones handled so far, whatever the outcome.
+	Handled int `json:"handled"`
+
+	// Zones are the zones to handle, with their outcome.
+	Zones []*OnboardingZone `json:"zones"`
+}
+
+// OnboardingZone is the onboarding of a single zone.
+type OnboardingZone struct {
+	// DomainName is the FQDN of the zone.
+	DomainName string `json:"domain"`
+
+	// Status is where the onboarding of the zone stands.
+	Status OnboardingStatus `json:"status" enums:"pending,running,done,skipped,failed"`
+
+	// DomainId is the identifier of the domain created for the zone, or of
+	// the one already managing it.
+	DomainId Identifier `json:"id_domain,omitempty" swaggertype:"string"`
+
+	// Error tells why the zone could not be added.
+	Error string `json:"error,omitempty"`
+}
+
+type OnboardingUsecase interface {
+	// Get returns the progress of the last onboarding from the Provider.
+	Get(*User, *Provider) (*Onboarding, error)
+	// Start begins adding the zones hosted by the Provider as domains, in
+	// the background.
+	Start(context.Context, *User, *Provider, *OnboardingForm) (*Onboarding, error)
+}
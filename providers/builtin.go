@@ -0,0 +1,392 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package providers // import "git.happydns.org/happyDomain/providers"
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/internal/adapters"
+	providerReg "git.happydns.org/happyDomain/internal/providerregistry"
+	"git.happydns.org/happyDomain/model"
+)
+
+// BuiltinZoneStore is the part of the storage the built-in provider keeps its
+// zones in.
+type BuiltinZoneStore interface {
+	ListBuiltinZones(providerId happydns.Identifier) ([]*happydns.BuiltinZone, error)
+	GetBuiltinZone(providerId happydns.Identifier, name string) (*happydns.BuiltinZone, error)
+	PutBuiltinZone(zone *happydns.BuiltinZone) error
+}
+
+// builtinZones is where every built-in provider of the instance keeps its
+// zones. It is set once at startup, by SetBuiltinZoneStorage.
+var builtinZones BuiltinZoneStore
+
+// SetBuiltinZoneStorage sets the storage the built-in provider keeps its zones
+// in. Until it is called, the provider cannot be instantiated.
+func SetBuiltinZoneStorage(store BuiltinZoneStore) {
+	builtinZones = store
+}
+
+// builtinRecordTypes lists the record types the built-in provider declares:
+// it stores any record as is, these are the ones worth offering.
+var builtinRecordTypes = []uint16{
+	dns.TypeA, dns.TypeAAAA, dns.TypeCAA, dns.TypeCNAME, dns.TypeDNAME,
+	dns.TypeDS, dns.TypeHTTPS, dns.TypeMX, dns.TypeNAPTR, dns.TypeNS,
+	dns.TypeOPENPGPKEY, dns.TypePTR, dns.TypeSMIMEA, dns.TypeSRV,
+	dns.TypeSSHFP, dns.TypeSVCB, dns.TypeTLSA, dns.TypeTXT,
+}
+
+// BuiltinServer is the provider keeping the zones in happyDomain's own
+// storage. Nothing is published anywhere: it serves record keeping, demos,
+// and the tests needing a provider without any external API.
+type BuiltinServer struct{}
+
+func (s *BuiltinServer) InstantiateProvider() (happydns.ProviderActuator, error) {
+	return nil, errors.New("the built-in provider can only be instantiated for a given provider")
+}
+
+func (s *BuiltinServer) InstantiateProviderFor(meta *happydns.ProviderMeta) (happydns.ProviderActuator, error) {
+	if builtinZones == nil {
+		return nil, errors.New("the built-in provider is not available on this instance")
+	}
+
+	return &builtinActuator{
+		store:      builtinZones,
+		providerId: meta.Id,
+	}, nil
+}
+
+// builtinActuator implements the whole ProviderActuator contract on top of
+// a BuiltinZoneStore. Zones are kept per provider: the zones of a provider
+// are only reachable through it.
+type builtinActuator struct {
+	store      BuiltinZoneStore
+	providerId happydns.Identifier
+}
+
+func (a *builtinActuator) CanCreateDomain() bool {
+	return true
+}
+
+func (a *builtinActuator) CanListZones() bool {
+	return true
+}
+
+// ListZones returns the zones kept by the provider. A provider not saved yet
+// holds none, which is what lets it pass the validation run on creation.
+func (a *builtinActuator) ListZones() ([]string, error) {
+	if a.providerId.IsEmpty() {
+		return []string{}, nil
+	}
+
+	zones, err := a.store.ListBuiltinZones(a.providerId)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the zones: %w", err)
+	}
+
+	names := make([]string, len(zones))
+	for i, zone := range zones {
+		names[i] = zone.Name
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// CreateDomain creates an empty zone, unless it already exists.
+func (a *builtinActuator) CreateDomain(fqdn string) error {
+	if a.providerId.IsEmpty() {
+		return errors.New("the provider has to be saved before it can hold zones")
+	}
+
+	name := builtinZoneName(fqdn)
+	if _, ok := dns.IsDomainName(name); !ok {
+		return fmt.Errorf("%q is not a valid zone name", fqdn)
+	}
+
+	_, err := a.store.GetBuiltinZone(a.providerId, name)
+	if err == nil {
+		return nil
+	} else if !errors.Is(err, happydns.ErrBuiltinZoneNotFound) {
+		return err
+	}
+
+	return a.store.PutBuiltinZone(&happydns.BuiltinZone{
+		ProviderId: a.providerId,
+		Name:       name,
+		Records:    []string{},
+	})
+}
+
+func (a *builtinActuator) GetZoneRecords(domain string) ([]happydns.Record, error) {
+	zone, err := a.getZone(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseBuiltinRecords(zone)
+}
+
+// GetZoneRecordComments returns the comments stored along the records.
+func (a *builtinActuator) GetZoneRecordComments(domain string) (happydns.RecordComments, error) {
+	zone, err := a.getZone(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	return builtinRecordComments(zone)
+}
+
+// GetZoneCorrections diffs the wanted records against the stored ones with the
+// DNSControl diff engine, like the other providers, and makes each correction
+// apply its own change to the stored zone.
+func (a *builtinActuator) GetZoneCorrections(domain string, wantedRecords []happydns.Record) ([]*happydns.Correction, int, error) {
+	return a.GetZoneCorrectionsWithComments(domain, wantedRecords, nil)
+}
+
+// GetZoneCorrectionsWithComments is GetZoneCorrections where each correction,
+// once applied, also stores the given comments along the records of the zone.
+// The records without a comment among them keep theirs.
+func (a *builtinActuator) GetZoneCorrectionsWithComments(domain string, wantedRecords []happydns.Record, comments happydns.RecordComments) ([]*happydns.Correction, int, error) {
+	// happyDomain flattens ALIAS itself for this provider (see
+	// GetBuiltinCapabilities): no other pseudo-type has a form to store.
+	for _, rr := range wantedRecords {
+		if adapter.IsPseudoTypeRecord(rr) {
+			return nil, 0, fmt.Errorf("%s records are not supported by this provider", dns.TypeToString[rr.Header().Rrtype])
+		}
+	}
+
+	zone, err := a.getZone(domain)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	current, err := parseBuiltinRecords(zone)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	corrections, nbCorrections, err := adapter.DNSControlDiffByRecord(current, wantedRecords, zone.Name)
+	if err != nil {
+		return nil, nbCorrections, fmt.Errorf("unable to compute zone diff: %w", err)
+	}
+
+	for _, correction := range corrections {
+		correction.Trace = &happydns.CorrectionTrace{}
+		correction.F = a.makeCorrectionFunc(zone.Name, correction, comments)
+	}
+
+	// The comments changed along records left as they are have a
+	// correction of their own.
+	stored, err := builtinRecordComments(zone)
+	if err != nil {
+		return nil, nbCorrections, err
+	}
+	if changed := comments.Changed(wantedRecords, stored); changed != nil {
+		corrections = append(corrections, adapter.NewCommentsCorrection(changed, func() error {
+			zone, err := a.getZone(domain)
+			if err != nil {
+				return err
+			}
+
+			zone.Comments = builtinComments(zone, changed)
+			return a.store.PutBuiltinZone(zone)
+		}))
+		nbCorrections++
+	}
+
+	return corrections, nbCorrections, nil
+}
+
+// builtinWrite is what the provider journal keeps of a correction applied by
+// the built-in provider.
+type builtinWrite struct {
+	Zone    string   `json:"zone"`
+	Removed []string `json:"removed,omitempty"`
+	Added   []string `json:"added,omitempty"`
+}
+
+// makeCorrectionFunc returns the function applying a single correction. The
+// zone is read again when it runs: the corrections are applied one after the
+// other, or only some of them, each on top of the previous ones.
+func (a *builtinActuator) makeCorrectionFunc(name string, correction *happydns.Correction, comments happydns.RecordComments) func() error {
+	oldRecords, newRecords := correction.OldRecords, correction.NewRecords
+
+	return func() error {
+		zone, err := a.getZone(name)
+		if err != nil {
+			return err
+		}
+
+		records, err := parseBuiltinRecords(zone)
+		if err != nil {
+			return err
+		}
+
+		write := builtinWrite{Zone: zone.Name}
+
+		zone.Records = zone.Records[:0]
+		for _, rr := range records {
+			if containsBuiltinRecord(oldRecords, rr) {
+				write.Removed = append(write.Removed, rr.String())
+			} else {
+				zone.Records = append(zone.Records, rr.String())
+			}
+		}
+		for _, rr := range newRecords {
+			s := builtinRR(rr).String()
+			write.Added = append(write.Added, s)
+			zone.Records = append(zone.Records, s)
+		}
+
+		if correction.Trace != nil {
+			correction.Trace.Request = write
+		}
+
+		zone.Comments = builtinComments(zone, comments)
+
+		return a.store.PutBuiltinZone(zone)
+	}
+}
+
+func (a *builtinActuator) getZone(domain string) (*happydns.BuiltinZone, error) {
+	if a.providerId.IsEmpty() {
+		return nil, happydns.ErrBuiltinZoneNotFound
+	}
+
+	zone, err := a.store.GetBuiltinZone(a.providerId, builtinZoneName(domain))
+	if errors.Is(err, happydns.ErrBuiltinZoneNotFound) {
+		return nil, fmt.Errorf("%q: %w", domain, err)
+	}
+	return zone, err
+}
+
+// builtinZoneName normalizes the name a zone is stored under.
+func builtinZoneName(domain string) string {
+	return strings.ToLower(dns.Fqdn(domain))
+}
+
+// builtinRR turns the records happyDomain holds in its own types back into
+// plain dns.RR, whose presentation format is what gets stored.
+func builtinRR(rr happydns.Record) happydns.Record {
+	if record, ok := rr.(happydns.ConvertibleRecord); ok {
+		return record.ToRR()
+	}
+	return rr
+}
+
+// builtinRecordComments returns the comment of each record of the zone, empty
+// for those bearing none.
+func builtinRecordComments(zone *happydns.BuiltinZone) (happydns.RecordComments, error) {
+	records, err := parseBuiltinRecords(zone)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := happydns.RecordComments{}
+	for _, rr := range records {
+		key := happydns.RecordCommentKey(rr)
+		comments[key] = zone.Comments[key]
+	}
+
+	return comments, nil
+}
+
+// builtinComments returns the comments to keep along the records of the
+// zone: the given ones, or else the ones already stored.
+func builtinComments(zone *happydns.BuiltinZone, comments happydns.RecordComments) happydns.RecordComments {
+	records, err := parseBuiltinRecords(zone)
+	if err != nil {
+		return zone.Comments
+	}
+
+	kept := happydns.RecordComments{}
+	for _, rr := range records {
+		key := happydns.RecordCommentKey(rr)
+		if comment, ok := comments[key]; ok {
+			kept[key] = comment
+		} else if comment, ok := zone.Comments[key]; ok {
+			kept[key] = comment
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+
+	return kept
+}
+
+func parseBuiltinRecords(zone *happydns.BuiltinZone) ([]happydns.Record, error) {
+	records := make([]happydns.Record, 0, len(zone.Records))
+	for _, s := range zone.Records {
+		rr, err := dns.NewRR(s)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the stored record %q: %w", s, err)
+		} else if rr == nil {
+			continue
+		}
+
+		records = append(records, rr)
+	}
+
+	return records, nil
+}
+
+// containsBuiltinRecord tells whether rr is among records, comparing names,
+// types and data but not the TTL: a modification carries the old TTL.
+func containsBuiltinRecord(records []happydns.Record, rr happydns.Record) bool {
+	for _, r := range records {
+		if old, ok := builtinRR(r).(dns.RR); ok && dns.IsDuplicate(old, rr.(dns.RR)) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetBuiltinCapabilities returns the capabilities of the built-in provider.
+func GetBuiltinCapabilities() (caps []string) {
+	caps = append(caps, "CreateDomain", "ListDomains")
+
+	for _, v := range builtinRecordTypes {
+		caps = append(caps, fmt.Sprintf("rr-%d-%s", v, dns.TypeToString[v]))
+	}
+
+	caps = append(caps, fmt.Sprintf("rr-%d-ALIAS", happydns.TypeALIAS), adapter.AliasFlatteningCapability)
+
+	return
+}
+
+func init() {
+	providerReg.RegisterProvider(func() happydns.ProviderBody {
+		return &BuiltinServer{}
+	}, happydns.ProviderInfos{
+		Name:         "happyDomain",
+		Description:  "Keeps your zones in happyDomain itself, without publishing them anywhere.",
+		Capabilities: GetBuiltinCapabilities(),
+	})
+}
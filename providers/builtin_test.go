@@ -0,0 +1,294 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package providers // import "git.happydns.org/happyDomain/providers"
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/model"
+)
+
+// memoryBuiltinZones is a BuiltinZoneStore keeping the zones in a map.
+type memoryBuiltinZones map[string]*happydns.BuiltinZone
+
+func (m memoryBuiltinZones) ListBuiltinZones(providerId happydns.Identifier) (zones []*happydns.BuiltinZone, err error) {
+	for _, zone := range m {
+		if zone.ProviderId.Equals(providerId) {
+			zones = append(zones, zone)
+		}
+	}
+	return
+}
+
+func (m memoryBuiltinZones) GetBuiltinZone(providerId happydns.Identifier, name string) (*happydns.BuiltinZone, error) {
+	zone, ok := m[providerId.String()+"|"+name]
+	if !ok {
+		return nil, happydns.ErrBuiltinZoneNotFound
+	}
+
+	cpy := *zone
+	cpy.Records = append([]string{}, zone.Records...)
+	return &cpy, nil
+}
+
+func (m memoryBuiltinZones) PutBuiltinZone(zone *happydns.BuiltinZone) error {
+	m[zone.ProviderId.String()+"|"+zone.Name] = zone
+	return nil
+}
+
+func newBuiltinActuator(t *testing.T, store BuiltinZoneStore, id string) happydns.ProviderActuator {
+	t.Helper()
+
+	previous := builtinZones
+	t.Cleanup(func() { builtinZones = previous })
+	SetBuiltinZoneStorage(store)
+
+	p := &happydns.Provider{
+		ProviderMeta: happydns.ProviderMeta{Id: happydns.Identifier(id)},
+		Provider:     &BuiltinServer{},
+	}
+
+	actuator, err := p.InstantiateProvider()
+	if err != nil {
+		t.Fatalf("InstantiateProvider() = %v", err)
+	}
+	return actuator
+}
+
+func mustRR(t *testing.T, s string) happydns.Record {
+	t.Helper()
+
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		t.Fatalf("dns.NewRR(%q) = %v", s, err)
+	}
+	return rr
+}
+
+func TestBuiltinZonesArePerProvider(t *testing.T) {
+	store := memoryBuiltinZones{}
+	a := newBuiltinActuator(t, store, "one")
+	b := newBuiltinActuator(t, store, "two")
+
+	if err := a.CreateDomain("Example.COM"); err != nil {
+		t.Fatalf("CreateDomain() = %v", err)
+	}
+	// Creating an existing zone is a no-op.
+	if err := a.CreateDomain("example.com."); err != nil {
+		t.Fatalf("CreateDomain() on an existing zone = %v", err)
+	}
+
+	zones, err := a.ListZones()
+	if err != nil || len(zones) != 1 || zones[0] != "example.com." {
+		t.Fatalf("ListZones() = %v, %v; want [example.com.]", zones, err)
+	}
+
+	zones, err = b.ListZones()
+	if err != nil || len(zones) != 0 {
+		t.Fatalf("ListZones() on another provider = %v, %v; want none", zones, err)
+	}
+
+	if _, err := b.GetZoneRecords("example.com"); !errors.Is(err, happydns.ErrBuiltinZoneNotFound) {
+		t.Fatalf("GetZoneRecords() on another provider = %v; want ErrBuiltinZoneNotFound", err)
+	}
+}
+
+func TestBuiltinUnsavedProvider(t *testing.T) {
+	a := newBuiltinActuator(t, memoryBuiltinZones{}, "")
+
+	// This is what the validation of a new provider runs.
+	if zones, err := a.ListZones(); err != nil || len(zones) != 0 {
+		t.Fatalf("ListZones() = %v, %v; want none", zones, err)
+	}
+
+	if err := a.CreateDomain("example.com"); err == nil {
+		t.Fatal("CreateDomain() on an unsaved provider succeeded")
+	}
+}
+
+func TestBuiltinNotAvailable(t *testing.T) {
+	previous := builtinZones
+	t.Cleanup(func() { builtinZones = previous })
+	builtinZones = nil
+
+	p := &happydns.Provider{Provider: &BuiltinServer{}}
+	if _, err := p.InstantiateProvider(); err == nil {
+		t.Fatal("InstantiateProvider() succeeded without a storage")
+	}
+}
+
+func TestBuiltinRefusesPseudoTypes(t *testing.T) {
+	a := newBuiltinActuator(t, memoryBuiltinZones{}, "one")
+	if err := a.CreateDomain("example.com"); err != nil {
+		t.Fatalf("CreateDomain() = %v", err)
+	}
+
+	alias := &dns.PrivateRR{Hdr: dns.RR_Header{Name: "example.com.", Rrtype: happydns.TypeALIAS, Class: dns.ClassINET, Ttl: 300}}
+	if _, _, err := a.GetZoneCorrections("example.com", []happydns.Record{alias}); err == nil {
+		t.Fatal("GetZoneCorrections() accepted an ALIAS record")
+	}
+}
+
+func TestBuiltinContainsRecordIgnoresTTL(t *testing.T) {
+	records := []happydns.Record{mustRR(t, "www.example.com. 300 IN A 192.0.2.1")}
+
+	if !containsBuiltinRecord(records, mustRR(t, "WWW.example.com. 3600 IN A 192.0.2.1")) {
+		t.Error("the same record with another TTL is not found")
+	}
+	if containsBuiltinRecord(records, mustRR(t, "www.example.com. 300 IN A 192.0.2.2")) {
+		t.Error("a record with other data is found")
+	}
+}
+
+func TestBuiltinParseRecords(t *testing.T) {
+	zone := &happydns.BuiltinZone{
+		Name: "example.com.",
+		Records: []string{
+			"example.com.\t3600\tIN\tMX\t10 mail.example.com.",
+			"www.example.com.\t300\tIN\tAAAA\t2001:db8::1",
+		},
+	}
+
+	records, err := parseBuiltinRecords(zone)
+	if err != nil {
+		t.Fatalf("parseBuiltinRecords() = %v", err)
+	}
+	if len(records) != 2 || records[0].Header().Rrtype != dns.TypeMX || records[1].Header().Rrtype != dns.TypeAAAA {
+		t.Fatalf("parseBuiltinRecords() = %v", records)
+	}
+
+	zone.Records = append(zone.Records, "not a record")
+	if _, err := parseBuiltinRecords(zone); err == nil {
+		t.Fatal("parseBuiltinRecords() accepted an invalid record")
+	}
+}
+
+func TestBuiltinCorrectionsRoundTrip(t *testing.T) {
+	a := newBuiltinActuator(t, memoryBuiltinZones{}, "one")
+	if err := a.CreateDomain("example.com"); err != nil {
+		t.Fatalf("CreateDomain() = %v", err)
+	}
+
+	apply := func(wanted []happydns.Record) int {
+		t.Helper()
+
+		corrections, _, err := a.GetZoneCorrections("example.com", wanted)
+		if err != nil {
+			t.Fatalf("GetZoneCorrections() = %v", err)
+		}
+		for _, correction := range corrections {
+			if err := correction.F(); err != nil {
+				t.Fatalf("applying %q = %v", correction.Msg, err)
+			}
+		}
+		return len(corrections)
+	}
+
+	wanted := []happydns.Record{
+		mustRR(t, "www.example.com. 300 IN A 192.0.2.1"),
+		mustRR(t, "example.com. 3600 IN MX 10 mail.example.com."),
+	}
+	if n := apply(wanted); n == 0 {
+		t.Fatal("no correction to create the records")
+	}
+
+	records, err := a.GetZoneRecords("example.com")
+	if err != nil || len(records) != 2 {
+		t.Fatalf("GetZoneRecords() = %v, %v; want 2 records", records, err)
+	}
+
+	// Applied, the zone holds what was wanted: nothing left to do.
+	if n := apply(wanted); n != 0 {
+		t.Fatalf("%d corrections left after applying them", n)
+	}
+
+	wanted[0] = mustRR(t, "www.example.com. 300 IN A 192.0.2.2")
+	apply(wanted[:1])
+
+	records, err = a.GetZoneRecords("example.com")
+	if err != nil || len(records) != 1 || !dns.IsDuplicate(records[0].(dns.RR), wanted[0].(dns.RR)) {
+		t.Fatalf("GetZoneRecords() = %v, %v; want %v", records, err, wanted[0])
+	}
+}
+
+func TestBuiltinStoresComments(t *testing.T) {
+	a := newBuiltinActuator(t, memoryBuiltinZones{}, "one")
+	if err := a.CreateDomain("example.com"); err != nil {
+		t.Fatalf("CreateDomain() = %v", err)
+	}
+
+	commenter, ok := a.(happydns.RecordCommenter)
+	if !ok {
+		t.Fatal("the built-in provider does not store comments")
+	}
+
+	www := mustRR(t, "www.example.com. 300 IN A 192.0.2.1")
+	mx := mustRR(t, "example.com. 3600 IN MX 10 mail.example.com.")
+	apply := func(wanted []happydns.Record, comments happydns.RecordComments) {
+		t.Helper()
+
+		corrections, _, err := commenter.GetZoneCorrectionsWithComments("example.com", wanted, comments)
+		if err != nil {
+			t.Fatalf("GetZoneCorrectionsWithComments() = %v", err)
+		}
+		for _, correction := range corrections {
+			if err := correction.F(); err != nil {
+				t.Fatalf("applying %q = %v", correction.Msg, err)
+			}
+		}
+	}
+
+	apply([]happydns.Record{www, mx}, happydns.RecordComments{
+		happydns.RecordCommentKey(www): happydns.FormatRecordComment("front"),
+		happydns.RecordCommentKey(mx):  happydns.FormatRecordComment(""),
+		"gone.example. A 192.0.2.9":    "not in the zone",
+	})
+
+	comments, err := commenter.GetZoneRecordComments("example.com")
+	if err != nil || len(comments) != 2 || comments[happydns.RecordCommentKey(www)] != "managed by happyDomain: front" {
+		t.Fatalf("GetZoneRecordComments() = %v, %v; want the comments of the 2 records", comments, err)
+	}
+
+	// Without comments, the ones of the records kept stay.
+	apply([]happydns.Record{www}, nil)
+
+	comments, err = commenter.GetZoneRecordComments("example.com")
+	if err != nil || len(comments) != 1 || comments[happydns.RecordCommentKey(www)] == "" {
+		t.Fatalf("GetZoneRecordComments() = %v, %v; want the comment of www only", comments, err)
+	}
+
+	// A comment changed alone gets a correction.
+	edited := happydns.RecordComments{happydns.RecordCommentKey(www): happydns.FormatRecordComment("web front")}
+	corrections, _, err := commenter.GetZoneCorrectionsWithComments("example.com", []happydns.Record{www}, edited)
+	if err != nil || len(corrections) != 1 {
+		t.Fatalf("GetZoneCorrectionsWithComments() = %v, %v; want the correction of the comment", corrections, err)
+	}
+	apply([]happydns.Record{www}, edited)
+
+	comments, err = commenter.GetZoneRecordComments("example.com")
+	if err != nil || comments[happydns.RecordCommentKey(www)] != "managed by happyDomain: web front" {
+		t.Fatalf("GetZoneRecordComments() = %v, %v; want the edited comment", comments, err)
+	}
+}
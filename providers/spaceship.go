@@ -29,28 +29,13 @@ import (
 	"git.happydns.org/happyDomain/model"
 )
 
-type SpaceshipAPI struct {
-	APIKey    string `json:"api_key,omitempty" happydomain:"label=API Key,secret,required,description=Your Spaceship API key"`
-	APISecret string `json:"api_secret,omitempty" happydomain:"label=API Secret,secret,required,description=Your Spaceship API secret"`
-}
-
-func (s *SpaceshipAPI) LibdnsProvider() any {
-	return &spaceship.Provider{
-		APIKey:    s.APIKey,
-		APISecret: s.APISecret,
-	}
-}
-
-func (s *SpaceshipAPI) InstantiateProvider() (happydns.ProviderActuator, error) {
-	return adapter.NewLibdnsProviderAdapter(s)
-}
-
 func init() {
-	adapter.RegisterLibdnsProviderAdapter(func() happydns.ProviderBody {
-		return &SpaceshipAPI{}
-	}, happydns.ProviderInfos{
+	// Registered under the name of the body it replaced, so that the
+	// providers already stored keep working: their settings share the JSON
+	// names of spaceship.Provider.
+	adapter.RegisterLibdnsBridge[spaceship.Provider]("SpaceshipAPI", happydns.ProviderInfos{
 		Name:        "Spaceship",
 		Description: "Domain registrar and DNS provider",
 		Website:     "https://www.spaceship.com",
-	}, providerReg.RegisterProvider)
+	}, providerReg.RegisterNamedProvider)
 }
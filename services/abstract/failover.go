@@ -0,0 +1,225 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package abstract
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	svc "git.happydns.org/happyDomain/internal/serviceanalyzer"
+	"git.happydns.org/happyDomain/model"
+)
+
+const (
+	// FailoverDefaultFailAfter is the number of consecutive critical checks
+	// after which a target is abandoned, when the service doesn't say.
+	FailoverDefaultFailAfter = 3
+
+	// FailoverDefaultRecoverAfter is the number of consecutive successful
+	// checks after which a preferred target is published again, when the
+	// service doesn't say.
+	FailoverDefaultRecoverAfter = 5
+)
+
+// FailoverTarget is one of the destinations a Failover can publish: either
+// addresses (A and/or AAAA) or an alias (CNAME).
+type FailoverTarget struct {
+	A     *dns.A     `json:"A,omitempty"`
+	AAAA  *dns.AAAA  `json:"AAAA,omitempty"`
+	CNAME *dns.CNAME `json:"CNAME,omitempty"`
+
+	// PlanId links the target to the checker plan watching it.
+	PlanId string `json:"planId,omitempty" happydomain:"label=Checker plan,description=Identifier of the checker plan watching this target. Required for the primary target; a backup without plan is deemed healthy."`
+}
+
+// Records returns the records publishing the target.
+func (t *FailoverTarget) Records() (rrs []happydns.Record) {
+	if t.CNAME != nil && t.CNAME.Target != "" {
+		return []happydns.Record{t.CNAME}
+	}
+	if t.A != nil && len(t.A.A) != 0 {
+		rrs = append(rrs, t.A)
+	}
+	if t.AAAA != nil && len(t.AAAA.AAAA) != 0 {
+		rrs = append(rrs, t.AAAA)
+	}
+	return
+}
+
+func (t *FailoverTarget) String() string {
+	if t.CNAME != nil && t.CNAME.Target != "" {
+		return t.CNAME.Target
+	}
+
+	var addrs []string
+	if t.A != nil && len(t.A.A) != 0 {
+		addrs = append(addrs, t.A.A.String())
+	}
+	if t.AAAA != nil && len(t.AAAA.AAAA) != 0 {
+		addrs = append(addrs, t.AAAA.AAAA.String())
+	}
+	return strings.Join(addrs, "; ")
+}
+
+func (t *FailoverTarget) validate() error {
+	hasAddr := (t.A != nil && len(t.A.A) != 0) || (t.AAAA != nil && len(t.AAAA.AAAA) != 0)
+	hasAlias := t.CNAME != nil && t.CNAME.Target != ""
+
+	if hasAddr && hasAlias {
+		return errors.New("a failover target cannot have both addresses and an alias")
+	}
+	if !hasAddr && !hasAlias {
+		return errors.New("a failover target needs an address or an alias")
+	}
+	return nil
+}
+
+// Failover publishes a primary target and switches to a backup when the
+// checker plan watching the primary reports it critical for FailAfter
+// consecutive executions. The primary is published again once it has been
+// healthy for RecoverAfter consecutive executions.
+//
+// Active and LastSwitch are maintained by happyDomain: they tell which
+// target is currently published.
+//
+// Nothing in the records tells a failover apart, so it has no analyzer: the
+// import recognizes it from the last published zone, as long as the provider
+// publishes the records of its active target.
+type Failover struct {
+	Primary *FailoverTarget   `json:"primary"`
+	Backups []*FailoverTarget `json:"backups"`
+
+	FailAfter    uint `json:"failAfter,omitempty" happydomain:"label=Fail after,default=3,description=Number of consecutive critical checks after which a target is abandoned."`
+	RecoverAfter uint `json:"recoverAfter,omitempty" happydomain:"label=Recover after,default=5,description=Number of consecutive successful checks after which a preferred target is published again."`
+
+	// Active is the index, in Targets(), of the published target: 0 for
+	// the primary, n for the n-th backup.
+	Active     int        `json:"active"`
+	LastSwitch *time.Time `json:"lastSwitch,omitempty"`
+}
+
+// Targets returns the primary target followed by the backups, by order of
+// preference.
+func (s *Failover) Targets() []*FailoverTarget {
+	targets := make([]*FailoverTarget, 0, len(s.Backups)+1)
+	if s.Primary != nil {
+		targets = append(targets, s.Primary)
+	}
+	for _, b := range s.Backups {
+		if b != nil {
+			targets = append(targets, b)
+		}
+	}
+	return targets
+}
+
+// ActiveTarget returns the published target, falling back to the primary
+// when Active is out of range.
+func (s *Failover) ActiveTarget() *FailoverTarget {
+	targets := s.Targets()
+	if len(targets) == 0 {
+		return nil
+	}
+	if s.Active < 0 || s.Active >= len(targets) {
+		return targets[0]
+	}
+	return targets[s.Active]
+}
+
+// Thresholds returns FailAfter and RecoverAfter, defaulted when unset.
+func (s *Failover) Thresholds() (failAfter, recoverAfter int) {
+	failAfter, recoverAfter = FailoverDefaultFailAfter, FailoverDefaultRecoverAfter
+	if s.FailAfter > 0 {
+		failAfter = int(s.FailAfter)
+	}
+	if s.RecoverAfter > 0 {
+		recoverAfter = int(s.RecoverAfter)
+	}
+	return
+}
+
+func (s *Failover) GetNbResources() int {
+	if t := s.ActiveTarget(); t != nil {
+		return len(t.Records())
+	}
+	return 0
+}
+
+func (s *Failover) GenComment() string {
+	t := s.ActiveTarget()
+	if t == nil {
+		return ""
+	}
+
+	if s.Active > 0 && s.Active <= len(s.Backups) {
+		return fmt.Sprintf("%s (backup %d, primary down)", t.String(), s.Active)
+	}
+	return fmt.Sprintf("%s (+ %d backup)", t.String(), len(s.Backups))
+}
+
+func (s *Failover) GetRecords(domain string, ttl uint32, origin string) ([]happydns.Record, error) {
+	if s.Primary == nil {
+		return nil, errors.New("a failover needs a primary target")
+	}
+	if s.Primary.PlanId == "" {
+		return nil, errors.New("the primary target needs a checker plan watching it")
+	}
+	if len(s.Backups) == 0 {
+		return nil, errors.New("a failover needs at least one backup target")
+	}
+	for _, t := range s.Targets() {
+		if err := t.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.ActiveTarget().Records(), nil
+}
+
+func init() {
+	svc.RegisterService(
+		func() happydns.ServiceBody {
+			return &Failover{}
+		},
+		nil,
+		happydns.ServiceInfos{
+			Name:   "Failover",
+			Family: happydns.SERVICE_FAMILY_ABSTRACT,
+			Categories: []string{
+				"server",
+			},
+			RecordTypes: []uint16{
+				dns.TypeA,
+				dns.TypeAAAA,
+				dns.TypeCNAME,
+			},
+			Restrictions: happydns.ServiceRestrictions{
+				Single: true,
+			},
+		},
+		100,
+	)
+}
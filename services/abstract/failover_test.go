@@ -0,0 +1,82 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2020-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package abstract_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"git.happydns.org/happyDomain/services/abstract"
+)
+
+func failoverTarget(ip, plan string) *abstract.FailoverTarget {
+	return &abstract.FailoverTarget{
+		A:      &dns.A{Hdr: dns.RR_Header{Name: "", Rrtype: dns.TypeA, Class: dns.ClassINET}, A: net.ParseIP(ip)},
+		PlanId: plan,
+	}
+}
+
+func TestFailover_GetRecords(t *testing.T) {
+	s := &abstract.Failover{
+		Primary: failoverTarget("192.0.2.1", "plan"),
+		Backups: []*abstract.FailoverTarget{failoverTarget("192.0.2.2", "")},
+	}
+
+	rrs, err := s.GetRecords("www", 300, "example.com.")
+	if err != nil {
+		t.Fatalf("GetRecords: %v", err)
+	}
+	if len(rrs) != 1 || rrs[0].(*dns.A).A.String() != "192.0.2.1" {
+		t.Errorf("GetRecords() = %v; want the primary", rrs)
+	}
+
+	s.Active = 1
+	rrs, err = s.GetRecords("www", 300, "example.com.")
+	if err != nil {
+		t.Fatalf("GetRecords: %v", err)
+	}
+	if len(rrs) != 1 || rrs[0].(*dns.A).A.String() != "192.0.2.2" {
+		t.Errorf("GetRecords() = %v; want the backup", rrs)
+	}
+
+	s.Active = 5
+	if got := s.ActiveTarget(); got != s.Primary {
+		t.Errorf("ActiveTarget() out of range = %v; want the primary", got)
+	}
+}
+
+func TestFailover_GetRecordsInvalid(t *testing.T) {
+	mixed := failoverTarget("192.0.2.3", "")
+	mixed.CNAME = &dns.CNAME{Hdr: dns.RR_Header{Rrtype: dns.TypeCNAME, Class: dns.ClassINET}, Target: "backup.example.net."}
+
+	for name, s := range map[string]*abstract.Failover{
+		"no backup":       {Primary: failoverTarget("192.0.2.1", "plan")},
+		"unwatched":       {Primary: failoverTarget("192.0.2.1", ""), Backups: []*abstract.FailoverTarget{failoverTarget("192.0.2.2", "")}},
+		"address + alias": {Primary: failoverTarget("192.0.2.1", "plan"), Backups: []*abstract.FailoverTarget{mixed}},
+	} {
+		if _, err := s.GetRecords("www", 300, "example.com."); err == nil {
+			t.Errorf("%s: GetRecords succeeded", name)
+		}
+	}
+}
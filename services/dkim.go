@@ -37,7 +37,7 @@ import (
 type DKIM struct {
 	Version        uint     `json:"version" happydomain:"label=Version,placeholder=1,required,description=The version of DKIM to use.,default=1,hidden"`
 	AcceptableHash []string `json:"h" happydomain:"label=Hash Algorithms,choices=*;sha1;sha256"`
-	KeyType        string   `json:"k" happydomain:"label=Key Type,choices=rsa"`
+	KeyType        string   `json:"k" happydomain:"label=Key Type,choices=rsa;ed25519"`
 	Notes          string   `json:"n" happydomain:"label=Notes,description=Notes intended for a foreign postmaster"`
 	PublicKey      []byte   `json:"p" happydomain:"label=Public Key,placeholder=a0b1c2d3e4f5==,required"`
 	ServiceType    []string `json:"s" happydomain:"label=Service Types,choices=*;email"`
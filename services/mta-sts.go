@@ -22,6 +22,8 @@
 package svcs
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strconv"
 	"strings"
@@ -33,8 +35,108 @@ import (
 	"git.happydns.org/happyDomain/model"
 )
 
+// MTASTSDefaultMaxAge is the max_age of a hosted policy when none is given:
+// one week, as recommended by RFC 8461 section 3.2.
+const MTASTSDefaultMaxAge = 604800
+
+// MTASTSMaxMaxAge is the longest max_age a policy can have: about one year
+// (RFC 8461 section 3.2).
+const MTASTSMaxMaxAge = 31557600
+
 type MTA_STS struct {
 	Record *happydns.TXT `json:"txt"`
+
+	// The fields below describe the policy file happyDomain serves at
+	// https://mta-sts.<domain>/.well-known/mta-sts.txt. They are not
+	// published in DNS; leave Mode empty when the policy is hosted
+	// elsewhere.
+	Mode   string   `json:"mode,omitempty" happydomain:"label=Policy Mode,choices=;enforce;testing;none,description=Mode of the policy served by happyDomain; leave empty if the policy file is hosted elsewhere."`
+	MX     []string `json:"mx,omitempty" happydomain:"label=MX Patterns,placeholder=mail.example.com,description=Hostnames (or *.example.com wildcards) of the MX allowed to receive mail for the domain."`
+	MaxAge uint32   `json:"max_age,omitempty" happydomain:"label=Max Age,placeholder=604800,description=How long senders may cache the policy, in seconds."`
+}
+
+// Hosted tells whether happyDomain serves the policy file of the domain.
+func (s *MTA_STS) Hosted() bool {
+	return s.Mode != ""
+}
+
+// Validate checks that the fields of a hosted policy make a valid policy
+// file (RFC 8461 section 3.2).
+func (s *MTA_STS) Validate() error {
+	switch s.Mode {
+	case "":
+		return nil
+	case "enforce", "testing", "none":
+	default:
+		return happydns.ValidationError{Msg: fmt.Sprintf("invalid MTA-STS policy mode %q: expected enforce, testing or none", s.Mode)}
+	}
+
+	nbMX := 0
+	for _, mx := range s.MX {
+		if strings.ContainsAny(mx, "\r\n") {
+			return happydns.ValidationError{Msg: fmt.Sprintf("invalid MTA-STS MX pattern %q: line breaks are not allowed", mx)}
+		}
+		if strings.TrimSpace(mx) != "" {
+			nbMX++
+		}
+	}
+	if nbMX == 0 && s.Mode != "none" {
+		return happydns.ValidationError{Msg: fmt.Sprintf("an MTA-STS policy in %s mode needs at least one MX pattern", s.Mode)}
+	}
+
+	if s.MaxAge > MTASTSMaxMaxAge {
+		return happydns.ValidationError{Msg: fmt.Sprintf("invalid MTA-STS max age %d: it can't exceed %d seconds", s.MaxAge, MTASTSMaxMaxAge)}
+	}
+
+	return nil
+}
+
+// Policy renders the policy file described by the service (RFC 8461
+// section 3.2).
+func (s *MTA_STS) Policy() string {
+	maxAge := s.MaxAge
+	if maxAge == 0 {
+		maxAge = MTASTSDefaultMaxAge
+	}
+
+	var b strings.Builder
+	b.WriteString("version: STSv1\r\n")
+	fmt.Fprintf(&b, "mode: %s\r\n", s.Mode)
+	for _, mx := range s.MX {
+		if mx = strings.TrimSuffix(strings.TrimSpace(mx), "."); mx != "" {
+			fmt.Fprintf(&b, "mx: %s\r\n", mx)
+		}
+	}
+	fmt.Fprintf(&b, "max_age: %d\r\n", maxAge)
+
+	return b.String()
+}
+
+// PolicyID derives the policy identifier from the content of the policy,
+// so that it changes, and senders refresh their cached copy, whenever the
+// policy does.
+func (s *MTA_STS) PolicyID() string {
+	sum := sha256.Sum256([]byte(s.Policy()))
+	return hex.EncodeToString(sum[:10])
+}
+
+// EnrichFromPrevious keeps the policy happyDomain hosts for the domain when
+// the record still announces it: the policy file is not in the zone, so the
+// analysis of the record alone can't tell it.
+func (s *MTA_STS) EnrichFromPrevious(old happydns.ServiceBody) {
+	prev, ok := old.(*MTA_STS)
+	if !ok || !prev.Hosted() || s.Hosted() || s.Record == nil {
+		return
+	}
+
+	t := MTASTSFields{}
+	if t.Analyze(s.Record.Txt) != nil || t.Id != prev.PolicyID() {
+		return
+	}
+
+	s.Mode = prev.Mode
+	s.MX = prev.MX
+	s.MaxAge = prev.MaxAge
 }
 
 func (s *MTA_STS) GetNbResources() int {
@@ -42,6 +144,10 @@ func (s *MTA_STS) GetNbResources() int {
 }
 
 func (s *MTA_STS) GenComment() string {
+	if s.Hosted() {
+		return s.Mode + " " + s.PolicyID()
+	}
+
 	t := MTASTSFields{}
 	t.Analyze(s.Record.Txt)
 
@@ -49,7 +155,26 @@ func (s *MTA_STS) GenComment() string {
 }
 
 func (s *MTA_STS) GetRecords(domain string, ttl uint32, origin string) ([]happydns.Record, error) {
-	return []happydns.Record{s.Record}, nil
+	if !s.Hosted() {
+		return []happydns.Record{s.Record}, nil
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	// The identifier of a hosted policy is not the user's to maintain.
+	t := MTASTSFields{}
+	t.Analyze(s.Record.Txt)
+	if t.Version == 0 {
+		t.Version = 1
+	}
+	t.Id = s.PolicyID()
+
+	rr := *s.Record
+	rr.Txt = t.String()
+
+	return []happydns.Record{&rr}, nil
 }
 
 type MTASTSFields struct {
@@ -84,3 +84,115 @@ func TestMTA_STS(t *testing.T) {
 		t.Errorf("Expected TXT = %q, got %q", txtValue, txtrr.Txt)
 	}
 }
+
+func TestMTA_STSHosted(t *testing.T) {
+	rr, err := dns.NewRR("_mta-sts.example.com. 3600 IN TXT \"v=STSv1; id=manual;\"")
+	if err != nil {
+		t.Fatalf("dns.NewRR failed: %v", err)
+	}
+
+	mta := &svcs.MTA_STS{
+		Record: happydns.NewTXT(rr.(*dns.TXT)),
+		Mode:   "enforce",
+		MX:     []string{"mx1.example.com.", "*.backup.example.com"},
+	}
+
+	if !mta.Hosted() {
+		t.Fatalf("Hosted() = false; want true")
+	}
+
+	expected := "version: STSv1\r\nmode: enforce\r\nmx: mx1.example.com\r\nmx: *.backup.example.com\r\nmax_age: 604800\r\n"
+	if policy := mta.Policy(); policy != expected {
+		t.Errorf("Policy() = %q; want %q", policy, expected)
+	}
+
+	records, err := mta.GetRecords("example.com", 3600, "example.com")
+	if err != nil {
+		t.Fatalf("GetRecords() failed: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 record, got %d", len(records))
+	}
+
+	id := mta.PolicyID()
+	if txt := records[0].(*happydns.TXT).Txt; txt != "v=STSv1; id="+id {
+		t.Errorf("Expected TXT with the policy id, got %q", txt)
+	}
+	if mta.Record.Txt != "v=STSv1; id=manual;" {
+		t.Errorf("GetRecords() altered the stored record: %q", mta.Record.Txt)
+	}
+
+	mta.Mode = "testing"
+	if mta.PolicyID() == id {
+		t.Errorf("PolicyID() did not change along with the policy")
+	}
+}
+
+func TestMTA_STSEnrichFromPrevious(t *testing.T) {
+	rr, err := dns.NewRR("_mta-sts.example.com. 3600 IN TXT \"v=STSv1; id=manual;\"")
+	if err != nil {
+		t.Fatalf("dns.NewRR failed: %v", err)
+	}
+
+	hosted := &svcs.MTA_STS{
+		Record: happydns.NewTXT(rr.(*dns.TXT)),
+		Mode:   "enforce",
+		MX:     []string{"mx1.example.com."},
+		MaxAge: 86400,
+	}
+
+	published, err := hosted.GetRecords("", 3600, "example.com.")
+	if err != nil {
+		t.Fatalf("GetRecords() failed: %v", err)
+	}
+
+	s, _, err := svc.AnalyzeZone("example.com.", published)
+	if err != nil {
+		t.Fatalf("AnalyzeZone failed: %v", err)
+	}
+
+	mta := s[""][0].Service.(*svcs.MTA_STS)
+	mta.EnrichFromPrevious(hosted)
+	if !mta.Hosted() || mta.Mode != "enforce" || len(mta.MX) != 1 || mta.MaxAge != 86400 {
+		t.Errorf("the re-analyzed record lost the hosted policy: %+v", mta)
+	}
+
+	// A record announcing another policy is not the hosted one anymore.
+	s, _, err = svc.AnalyzeZone("example.com.", []happydns.Record{rr})
+	if err != nil {
+		t.Fatalf("AnalyzeZone failed: %v", err)
+	}
+
+	other := s[""][0].Service.(*svcs.MTA_STS)
+	other.EnrichFromPrevious(hosted)
+	if other.Hosted() {
+		t.Errorf("a record with another id was taken for the hosted policy")
+	}
+}
+
+func TestMTA_STSValidate(t *testing.T) {
+	valid := []svcs.MTA_STS{
+		{},
+		{Mode: "enforce", MX: []string{"mx.example.com"}},
+		{Mode: "testing", MX: []string{"*.example.com"}, MaxAge: svcs.MTASTSMaxMaxAge},
+		{Mode: "none"},
+	}
+	for _, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v; want no error", s, err)
+		}
+	}
+
+	invalid := []svcs.MTA_STS{
+		{Mode: "strict", MX: []string{"mx.example.com"}},
+		{Mode: "enforce"},
+		{Mode: "testing", MX: []string{" "}},
+		{Mode: "enforce", MX: []string{"mx.example.com\r\nmode: none"}},
+		{Mode: "enforce", MX: []string{"mx.example.com"}, MaxAge: svcs.MTASTSMaxMaxAge + 1},
+	}
+	for _, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Errorf("Validate(%+v) accepted an invalid policy", s)
+		}
+	}
+}
@@ -40,27 +40,32 @@ import (
 
 // entityMap maps each embedded interface type name to the Prometheus entity label.
 var entityMap = map[string]string{
-	"AuthUserStorage":          "authuser",
-	"CheckPlanStorage":         "check_plan",
-	"CheckerOptionsStorage":    "check_config",
-	"CheckEvaluationStorage":   "check_evaluation",
-	"ExecutionStorage":         "execution",
-	"DiscoveryEntryStorage":       "discovery_entry",
-	"DiscoveryObservationStorage": "discovery_observation",
-	"ObservationCacheStorage":  "observation_cache",
-	"ObservationSnapshotStorage": "observation_snapshot",
-	"SchedulerStateStorage":    "scheduler_state",
-	"DomainStorage":            "domain",
-	"DomainLogStorage":         "domain_log",
-	"InsightStorage":           "insight",
+	"AuthUserStorage":               "authuser",
+	"CheckPlanStorage":              "check_plan",
+	"CheckerOptionsStorage":         "check_config",
+	"CheckEvaluationStorage":        "check_evaluation",
+	"ExecutionStorage":              "execution",
+	"DiscoveryEntryStorage":         "discovery_entry",
+	"DiscoveryObservationStorage":   "discovery_observation",
+	"ObservationCacheStorage":       "observation_cache",
+	"ObservationSnapshotStorage":    "observation_snapshot",
+	"SchedulerStateStorage":         "scheduler_state",
+	"DomainStorage":                 "domain",
+	"DKIMStorage":                   "dkim",
+	"DMARCReportStorage":            "dmarc_report",
+	"DomainLogStorage":              "domain_log",
+	"InsightStorage":                "insight",
 	"NotificationChannelStorage":    "notification_channel",
 	"NotificationPreferenceStorage": "notification_preference",
 	"NotificationStateStorage":      "notification_state",
 	"NotificationRecordStorage":     "notification_record",
-	"ProviderStorage":          "provider",
-	"SessionStorage":           "session",
-	"UserStorage":              "user",
-	"ZoneStorage":              "zone",
+	"BuiltinZoneStorage":            "builtin_zone",
+	"ProviderStorage":               "provider",
+	"ProviderCallStorage":           "provider_call",
+	"SessionStorage":                "session",
+	"TLSReportStorage":              "tls_report",
+	"UserStorage":                   "user",
+	"ZoneStorage":                   "zone",
 }
 
 // operationOverrides maps method names that don't follow the prefix convention.
@@ -0,0 +1,55 @@
+// This file is part of the happyDomain (R) project.
+// Copyright (c) 2022-2026 happyDomain
+// Authors: Pierre-Olivier Mercier, et al.
+//
+// This program is offered under a commercial and under the AGPL license.
+// For commercial licensing, contact us at <contact@happydomain.org>.
+//
+// For AGPL licensing:
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+
+import { customFetch } from "$lib/hey-api";
+import { base } from "$app/paths";
+import type { Domain } from "$lib/model/domain";
+import type { Provider } from "$lib/model/provider";
+
+export interface ReverseZonesCreation {
+    domains: Array<Domain>;
+    parent?: string;
+    id_parent?: string;
+    cnames?: Array<string>;
+    skipped?: Array<string>;
+}
+
+/**
+ * Create the reverse zones of an IPv4 or IPv6 network on the given provider.
+ *
+ * For IPv4 networks smaller than a /24, the backend also adds the RFC 2317
+ * CNAME records to the parent zone when it is managed too.
+ */
+export async function addReverseZones(cidr: string, provider: Provider): Promise<ReverseZonesCreation> {
+    const res = await customFetch(`${base}/api/reverse-dns/zones`, {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ cidr, id_provider: provider._id }),
+    });
+
+    if (!res.ok) {
+        const body = await res.json().catch(() => ({}));
+        throw new Error(body.errmsg || `HTTP ${res.status}`);
+    }
+
+    return await res.json();
+}
@@ -8,11 +8,17 @@ import { loadTranslations } from "$lib/translations";
 import NewDomain from "./NewDomain.svelte";
 
 const addDomain = vi.fn(async (domain: string, _provider: Provider | undefined) => ({ domain }) as Domain);
+const addReverseZones = vi.fn(async (cidr: string, _provider: Provider) => ({
+    domains: [{ domain: "0/25.2.0.192.in-addr.arpa." } as Domain],
+}));
 const refreshDomains = vi.fn(async () => []);
 
 vi.mock("$lib/api/domains", () => ({
     addDomain: (...args: [string, Provider | undefined]) => addDomain(...args),
 }));
+vi.mock("$lib/api/reverse_dns", () => ({
+    addReverseZones: (...args: [string, Provider]) => addReverseZones(...args),
+}));
 vi.mock("$lib/stores/domains", () => ({
     refreshDomains: () => refreshDomains(),
 }));
@@ -82,6 +88,21 @@ describe("New domain input", () => {
         await vi.waitFor(() => expect(onNewDomainAdded).toHaveBeenCalledWith({ domain: "example.com" }));
     });
 
+    it("creates the reverse zones of a typed network", async () => {
+        const user = userEvent.setup();
+        const onNewDomainAdded = vi.fn();
+        mount({ provider, onNewDomainAdded });
+
+        await user.type(screen.getByPlaceholderText("my.new.domain."), "192.0.2.0/25");
+        await user.click(screen.getByRole("button", { name: /Add new domain/ }));
+
+        expect(addReverseZones).toHaveBeenCalledWith("192.0.2.0/25", provider);
+        expect(addDomain).not.toHaveBeenCalled();
+        await vi.waitFor(() =>
+            expect(onNewDomainAdded).toHaveBeenCalledWith({ domain: "0/25.2.0.192.in-addr.arpa." }),
+        );
+    });
+
     it("clears the field once the domain has been added", async () => {
         const user = userEvent.setup();
         mount({ provider });
@@ -20,7 +20,7 @@
 // along with this program.  If not, see <https://www.gnu.org/licenses/>.
 
 import { describe, it, expect } from "vitest";
-import { domainCompare, fqdn, fqdnCompare, isReverseZone, nsttl, reverseDomain, unreverseDomain, validateDomain } from "./dns";
+import { domainCompare, fqdn, fqdnCompare, isCIDR, isReverseZone, nsttl, reverseDomain, unreverseDomain, validateDomain } from "./dns";
 
 describe('fqdn', () => {
   const origin = 'example.com.';
@@ -127,6 +127,22 @@ describe('nsttl', () => {
     });
 });
 
+describe('isCIDR', () => {
+  it('should accept IPv4 and IPv6 networks', () => {
+    expect(isCIDR('192.0.2.0/24')).toBe(true);
+    expect(isCIDR('192.0.2.128/25')).toBe(true);
+    expect(isCIDR('2001:db8::/48')).toBe(true);
+  });
+
+  it('should reject domain names and bare addresses', () => {
+    expect(isCIDR('example.com.')).toBe(false);
+    expect(isCIDR('0/25.2.0.192.in-addr.arpa.')).toBe(false);
+    expect(isCIDR('192.0.2.1')).toBe(false);
+    expect(isCIDR('192.0.2.0/33')).toBe(false);
+    expect(isCIDR('2001:db8::/129')).toBe(false);
+  });
+});
+
 describe('isReverseZone', () => {
   it('should return true for an IPv4 reverse zone', () => {
     const fqdn = '1.168.192.in-addr.arpa.';
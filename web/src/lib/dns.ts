@@ -300,6 +300,29 @@ export function isReverseZone(fqdn: string) {
     return fqdn.endsWith("in-addr.arpa.") || fqdn.endsWith("ip6.arpa.");
 }
 
+/**
+ * Tells whether the input is an IPv4 or IPv6 network in CIDR notation, like
+ * `192.0.2.0/24` or `2001:db8::/48`, from which reverse zones can be created.
+ *
+ * @param input - String to test.
+ */
+export function isCIDR(input: string) {
+    const [addr, len, ...rest] = input.trim().split("/");
+    if (rest.length || !len || !/^[0-9]{1,3}$/.test(len)) return false;
+
+    const bits = parseInt(len, 10);
+    if (addr.indexOf(":") >= 0) {
+        return bits <= 128 && /^[0-9a-fA-F:]+$/.test(addr) && normalizeIPv6(addr) !== null;
+    }
+
+    const octets = addr.split(".");
+    return (
+        bits <= 32 &&
+        octets.length === 4 &&
+        octets.every((o) => /^[0-9]{1,3}$/.test(o) && parseInt(o, 10) <= 255)
+    );
+}
+
 /**
  * Expands a possibly-abbreviated IPv6 address into its full 8-group form.
  *
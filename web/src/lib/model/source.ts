@@ -24,4 +24,6 @@ export interface SourceMeta {
     _id: string;
     _ownerid: string;
     _comment: string;
+    _journal?: boolean;
+    _sync_zones?: boolean;
 }